@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+// `accessAllowed` returns whether the caller described by inHeader may access inode.
+// Inodes outside of any backend (i.e. the FUSE root directory) are always accessible.
+func (inode *inodeStruct) accessAllowed(inHeader *fission.InHeader) (allowed bool) {
+	if inode.backend == nil {
+		allowed = true
+		return
+	}
+
+	allowed = inode.backend.accessAllowed(inHeader, inode.objectPath, inode.inodeType != FileObject)
+
+	return
+}
+
+// `accessAllowed` returns whether the caller described by inHeader may access path
+// (which, if isDir, ends with a trailing "/") according to backend.accessRules.
+// Of those rules matching the caller, the one with the longest prefix of path
+// decides (with "deny" winning a tie). Absent any such rule, access is allowed.
+// So that an allowed path may be reached, a directory that is an ancestor of
+// the prefix of a matching "allow" rule is always allowed.
+//
+// Note that, as FUSE only supplies the caller's primary gid, membership in
+// supplementary groups is not considered.
+func (backend *backendStruct) accessAllowed(inHeader *fission.InHeader, path string, isDir bool) (allowed bool) {
+	var (
+		accessRule       backendAccessRuleStruct
+		matched          bool
+		matchedPrefixLen int
+	)
+
+	allowed = true
+
+	for _, accessRule = range backend.accessRules {
+		if !accessRule.matches(inHeader) {
+			continue
+		}
+
+		if isDir && accessRule.allow && (len(accessRule.prefix) > len(path)) && strings.HasPrefix(accessRule.prefix, path) {
+			allowed = true
+			return
+		}
+
+		if !strings.HasPrefix(path, accessRule.prefix) {
+			continue
+		}
+
+		if !matched || (len(accessRule.prefix) > matchedPrefixLen) {
+			matched = true
+			matchedPrefixLen = len(accessRule.prefix)
+			allowed = accessRule.allow
+		} else if len(accessRule.prefix) == matchedPrefixLen {
+			allowed = allowed && accessRule.allow
+		}
+	}
+
+	return
+}
+
+// `matches` returns whether accessRule applies to the caller described by inHeader.
+func (accessRule *backendAccessRuleStruct) matches(inHeader *fission.InHeader) bool {
+	if (len(accessRule.uids) == 0) && (len(accessRule.gids) == 0) {
+		return true
+	}
+
+	return slices.Contains(accessRule.uids, uint64(inHeader.UID)) || slices.Contains(accessRule.gids, uint64(inHeader.GID))
+}
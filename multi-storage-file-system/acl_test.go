@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestAccessAllowed(t *testing.T) {
+	var (
+		backend = &backendStruct{
+			accessRules: []backendAccessRuleStruct{
+				{prefix: "", allow: false},
+				{prefix: "shared/", allow: true},
+				{prefix: "teams/a/", uids: []uint64{1000}, allow: true},
+				{prefix: "teams/b/", gids: []uint64{200}, allow: true},
+				{prefix: "teams/b/secret/", gids: []uint64{200}, allow: false},
+				{prefix: "teams/b/secret/", uids: []uint64{2001}, allow: true},
+			},
+		}
+	)
+
+	for _, testCase := range []struct {
+		uid     uint32
+		gid     uint32
+		path    string
+		isDir   bool
+		allowed bool
+	}{
+		{1000, 100, "", true, true},
+		{1000, 100, "top", false, false},
+		{1000, 100, "shared/x", false, true},
+		{1000, 100, "teams/", true, true},
+		{1000, 100, "teams/a/x", false, true},
+		{1000, 100, "teams/b/", true, false},
+		{1000, 100, "teams/b/x", false, false},
+		{2000, 200, "teams/", true, true},
+		{2000, 200, "teams/a/", true, false},
+		{2000, 200, "teams/b/x", false, true},
+		{2000, 200, "teams/b/secret/x", false, false},
+		{2001, 200, "teams/b/secret/x", false, false},
+	} {
+		inHeader := &fission.InHeader{UID: testCase.uid, GID: testCase.gid}
+		if backend.accessAllowed(inHeader, testCase.path, testCase.isDir) != testCase.allowed {
+			t.Fatalf("accessAllowed(uid %v, gid %v, \"%s\", %v) returned %v", testCase.uid, testCase.gid, testCase.path, testCase.isDir, !testCase.allowed)
+		}
+	}
+
+	backend.accessRules = nil
+
+	if !backend.accessAllowed(&fission.InHeader{UID: 1000, GID: 100}, "top", false) {
+		t.Fatalf("accessAllowed() with no access_rules returned false")
+	}
+}
@@ -0,0 +1,508 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// `adminHandlerStruct` serves the admin API on admin_socket. Beyond the endpoints below,
+// each of those served on endpoint (see (*globalsStruct).ServeHTTP()) is also available.
+type adminHandlerStruct struct{}
+
+// `adminStatsStruct` is the response to GET /stats.
+type adminStatsStruct struct {
+	Backends           uint64 `json:"backends"`
+	Inodes             uint64 `json:"inodes"`
+	OpenFileHandles    uint64 `json:"open_file_handles"`
+	CacheLinesMax      uint64 `json:"cache_lines_max"`
+	InboundCacheLines  uint64 `json:"inbound_cache_lines"`
+	CleanCacheLines    uint64 `json:"clean_cache_lines"`
+	OutboundCacheLines uint64 `json:"outbound_cache_lines"`
+	DirtyCacheLines    uint64 `json:"dirty_cache_lines"`
+	QueuedFetches      uint64 `json:"queued_fetches"` // Cache lines awaiting a worker of their backend's fetch_workers
+	Migrations         uint64 `json:"migrations"`
+	Copies             uint64 `json:"copies"`
+}
+
+// `adminCacheStruct` is an element of the response to GET /cache summarizing the cache lines held for a backend.
+type adminCacheStruct struct {
+	Backend    string `json:"backend"`
+	Files      uint64 `json:"files"`
+	CacheLines uint64 `json:"cache_lines"`
+	Bytes      uint64 `json:"bytes"`
+}
+
+// `adminInodeStruct` is an element of the response to GET /inodes describing an inode with open file handles.
+type adminInodeStruct struct {
+	Inode           uint64 `json:"inode"`
+	Type            string `json:"type"`
+	Backend         string `json:"backend,omitempty"`
+	Path            string `json:"path"`
+	OpenFileHandles uint64 `json:"open_file_handles"`
+	CacheLines      uint64 `json:"cache_lines"`
+}
+
+// `adminHealthStruct` is an element of the response to GET /health describing a backend.
+type adminHealthStruct struct {
+	Backend             string     `json:"backend"`
+	BackendType         string     `json:"backend_type"`
+	ReadOnly            bool       `json:"readonly"`
+	Monitored           bool       `json:"monitored"` // If false, health_check_interval == 0 (so the backend is never considered down)
+	Down                bool       `json:"down"`
+	DownSince           *time.Time `json:"down_since,omitempty"`
+	ConsecutiveFailures uint64     `json:"consecutive_failures"`
+}
+
+// `adminLatencyStruct` is an element of the response to GET /latency reporting the latency
+// distribution of a backend's requests by operation.
+type adminLatencyStruct struct {
+	Backend    string                           `json:"backend"`
+	Operations []*backendLatencyQuantilesStruct `json:"operations"`
+}
+
+// `startAdminSocket` is called to begin serving the admin API on admin_socket (if specified).
+// Any socket left behind at that path (e.g. by a prior instance that did not shut down cleanly)
+// is replaced.
+func startAdminSocket() {
+	var (
+		err      error
+		fileInfo fs.FileInfo
+		listener net.Listener
+	)
+
+	if globals.config.adminSocket == "" {
+		return
+	}
+
+	fileInfo, err = os.Lstat(globals.config.adminSocket)
+	if err == nil {
+		if fileInfo.Mode().Type() != fs.ModeSocket {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] admin_socket (\"%s\") exists but is not a socket", globals.config.adminSocket)
+		}
+		err = os.Remove(globals.config.adminSocket)
+		if err != nil {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] unable to remove stale admin_socket (\"%s\"): %v", globals.config.adminSocket, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] unable to stat admin_socket (\"%s\"): %v", globals.config.adminSocket, err)
+	}
+
+	listener, err = net.Listen("unix", globals.config.adminSocket)
+	if err != nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] net.Listen(\"unix\", \"%s\") failed: %v", globals.config.adminSocket, err)
+	}
+
+	err = os.Chmod(globals.config.adminSocket, 0o600)
+	if err != nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] unable to restrict access to admin_socket (\"%s\"): %v", globals.config.adminSocket, err)
+	}
+
+	globals.adminListener = listener
+
+	go func(listener net.Listener) {
+		var (
+			adminServer       *http.Server
+			adminServerLogger = log.New(globals.logger.Writer(), "[ADMIN-SERVER] ", globals.logger.Flags()) // set prefix to differentiate adminServer logging
+			err               error
+		)
+
+		adminServer = &http.Server{
+			Handler:     &adminHandlerStruct{},
+			ReadTimeout: HTTP_SERVER_READ_TIMEOUT,
+			IdleTimeout: HTTP_SERVER_IDLE_TIMEOUT,
+			ErrorLog:    adminServerLogger,
+		}
+
+		err = adminServer.Serve(listener)
+		if (err != nil) && !errors.Is(err, net.ErrClosed) {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] adminServer.Serve() failed: %v", err)
+		}
+	}(listener)
+
+	globals.logger.Printf("[INFO] admin_socket: %s", globals.config.adminSocket)
+}
+
+// `stopAdminSocket` is called at shutdown to stop serving the admin API (removing admin_socket).
+func stopAdminSocket() {
+	if globals.adminListener != nil {
+		_ = globals.adminListener.Close()
+		globals.adminListener = nil
+	}
+}
+
+// `adminSocketClient` returns an http.Client issuing each request (to a URL of the form
+// http://msfs/<endpoint>) to the admin API of the daemon serving admin_socket.
+func adminSocketClient() (httpClient *http.Client) {
+	httpClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", globals.config.adminSocket)
+			},
+		},
+	}
+
+	return
+}
+
+// `ServeHTTP` implements the admin API. Endpoints that change state require a POST.
+func (*adminHandlerStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		err            error
+		inodes         bool
+		numDrained     uint64
+		numEvicted     uint64
+		reloadDoneChan chan error
+		top            int
+	)
+
+	switch r.URL.Path {
+	case "/stats":
+		writeAdminJSON(w, adminStats())
+
+	case "/cache":
+		writeAdminJSON(w, adminCache())
+
+	case "/cache/stats", "/cache/ls", "/cache/drop", "/cache/pin", "/cache/unpin", "/cache/warm":
+		serveAdminCache(w, r)
+
+	case "/inodes":
+		writeAdminJSON(w, adminInodes())
+
+	case "/health":
+		writeAdminJSON(w, adminHealth())
+
+	case "/latency":
+		writeAdminJSON(w, adminLatency())
+
+	case "/io":
+		top = ioAccountingDefaultTop
+		if r.URL.Query().Get("top") != "" {
+			top, err = strconv.Atoi(r.URL.Query().Get("top"))
+			if (err != nil) || (top < 0) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad top: \"%s\"\n", r.URL.Query().Get("top"))
+				return
+			}
+		}
+
+		writeAdminJSON(w, globals.ioAccounting.top(top))
+
+	case "/top":
+		top = topFilesDefault
+		if r.URL.Query().Get("files") != "" {
+			top, err = strconv.Atoi(r.URL.Query().Get("files"))
+			if (err != nil) || (top < 0) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad files: \"%s\"\n", r.URL.Query().Get("files"))
+				return
+			}
+		}
+
+		writeAdminJSON(w, adminTop(top))
+
+	case "/control":
+		serveAdminControl(w, r)
+
+	case "/reset_io":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			fmt.Fprintf(w, "POST required\n")
+			return
+		}
+
+		globals.ioAccounting.reset()
+
+		globals.logger.Printf("[INFO] [admin] reset_io forgot all accumulated I/O")
+
+		writeAdminJSON(w, map[string]bool{"reset": true})
+
+	case "/drop_caches":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			fmt.Fprintf(w, "POST required\n")
+			return
+		}
+
+		if r.URL.Query().Get("inodes") != "" {
+			inodes, err = strconv.ParseBool(r.URL.Query().Get("inodes"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad inodes: %v\n", err)
+				return
+			}
+		}
+
+		globals.Lock()
+		numEvicted = cacheDropClean()
+		if inodes {
+			numDrained = inodeEvictorForceDrain()
+		}
+		globals.Unlock()
+
+		globals.logger.Printf("[INFO] [admin] drop_caches evicted %v cache line(s) and drained %v inode(s)", numEvicted, numDrained)
+
+		writeAdminJSON(w, map[string]uint64{"cache_lines_evicted": numEvicted, "inodes_drained": numDrained})
+
+	case "/reload":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			fmt.Fprintf(w, "POST required\n")
+			return
+		}
+
+		if globals.reloadChan == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "not yet mounted\n")
+			return
+		}
+
+		reloadDoneChan = make(chan error, 1)
+		globals.reloadChan <- reloadDoneChan
+		err = <-reloadDoneChan
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "parsing config-file (\"%s\") failed: %v\n", globals.configFilePath, err)
+			return
+		}
+
+		writeAdminJSON(w, map[string]string{"config_file": globals.configFilePath})
+
+	default:
+		globals.ServeHTTP(w, r)
+	}
+}
+
+// `writeAdminJSON` responds with v encoded as JSON.
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	var (
+		body []byte
+		err  error
+	)
+
+	body, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// `adminStats` returns the counts of inodes, file handles, and cache lines.
+func adminStats() (stats *adminStatsStruct) {
+	var (
+		backend *backendStruct
+		inode   *inodeStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	stats = &adminStatsStruct{
+		Backends:           uint64(len(globals.config.backends)),
+		Inodes:             uint64(len(globals.inodeMap)),
+		CacheLinesMax:      globals.config.cacheLines,
+		InboundCacheLines:  globals.inboundCacheLineCount,
+		CleanCacheLines:    uint64(globals.cleanCacheLineLRU.Len()),
+		OutboundCacheLines: globals.outboundCacheLineCount,
+		DirtyCacheLines:    uint64(globals.dirtyCacheLineLRU.Len()),
+		Migrations:         uint64(len(globals.migrations)),
+		Copies:             uint64(len(globals.copies)),
+	}
+
+	for _, inode = range globals.inodeMap {
+		stats.OpenFileHandles += uint64(len(inode.fhMap))
+	}
+
+	for _, backend = range globals.config.backends {
+		stats.QueuedFetches += backend.fetchPool.queued()
+	}
+
+	return
+}
+
+// `adminCache` returns, for each backend, how many files have cache lines and their total size.
+func adminCache() (caches []*adminCacheStruct) {
+	var (
+		backend     *backendStruct
+		cache       *adminCacheStruct
+		cacheByName = make(map[string]*adminCacheStruct)
+		cacheLine   *cacheLineStruct
+		inode       *inodeStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	for _, backend = range globals.config.backends {
+		cache = &adminCacheStruct{Backend: backend.dirName}
+		cacheByName[backend.dirName] = cache
+		caches = append(caches, cache)
+	}
+
+	for _, inode = range globals.inodeMap {
+		if (inode.inodeType != FileObject) || (len(inode.cache) == 0) || (inode.backend == nil) {
+			continue
+		}
+		cache = cacheByName[inode.backend.dirName]
+		if cache == nil {
+			continue
+		}
+		cache.Files++
+		for _, cacheLine = range inode.cache {
+			cache.CacheLines++
+			cache.Bytes += uint64(len(cacheLine.content))
+		}
+	}
+
+	slices.SortFunc(caches, func(a, b *adminCacheStruct) int {
+		return strings.Compare(a.Backend, b.Backend)
+	})
+
+	return
+}
+
+// `adminInodes` returns each inode with open file handles (ordered by inode number).
+func adminInodes() (inodes []*adminInodeStruct) {
+	var (
+		adminInode *adminInodeStruct
+		inode      *inodeStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	inodes = make([]*adminInodeStruct, 0)
+
+	for _, inode = range globals.inodeMap {
+		if len(inode.fhMap) == 0 {
+			continue
+		}
+
+		adminInode = &adminInodeStruct{
+			Inode:           inode.inodeNumber,
+			Path:            inode.objectPath,
+			OpenFileHandles: uint64(len(inode.fhMap)),
+			CacheLines:      uint64(len(inode.cache)),
+		}
+		if inode.backend != nil {
+			adminInode.Backend = inode.backend.dirName
+		}
+
+		adminInode.Type = inodeTypeName(inode.inodeType)
+
+		inodes = append(inodes, adminInode)
+	}
+
+	slices.SortFunc(inodes, func(a, b *adminInodeStruct) int {
+		return cmp.Compare(a.Inode, b.Inode)
+	})
+
+	return
+}
+
+// `inodeTypeName` returns how inodeType is reported by the admin API (and state dumps).
+func inodeTypeName(inodeType uint32) string {
+	switch inodeType {
+	case FileObject:
+		return "file"
+	case FUSERootDir:
+		return "root"
+	case BackendRootDir:
+		return "backend"
+	default:
+		return "dir"
+	}
+}
+
+// `adminHealth` returns the health of each backend (ordered by name).
+func adminHealth() (healths []*adminHealthStruct) {
+	var (
+		backend *backendStruct
+		health  *adminHealthStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	healths = make([]*adminHealthStruct, 0, len(globals.config.backends))
+
+	for _, backend = range globals.config.backends {
+		health = &adminHealthStruct{
+			Backend:     backend.dirName,
+			BackendType: backend.backendType,
+			ReadOnly:    backend.readOnly,
+		}
+
+		if backend.healthState != nil {
+			health.Monitored = true
+			backend.healthState.Lock()
+			health.Down = backend.healthState.down
+			health.ConsecutiveFailures = backend.healthState.consecutiveFailures
+			if health.Down {
+				downSince := backend.healthState.downSince
+				health.DownSince = &downSince
+			}
+			backend.healthState.Unlock()
+		}
+
+		healths = append(healths, health)
+	}
+
+	slices.SortFunc(healths, func(a, b *adminHealthStruct) int {
+		return strings.Compare(a.Backend, b.Backend)
+	})
+
+	return
+}
+
+// `adminLatency` returns the p50, p95, and p99 latencies of recent requests of each
+// backend by operation (ordered by backend name).
+func adminLatency() (latencies []*adminLatencyStruct) {
+	var (
+		backend *backendStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	latencies = make([]*adminLatencyStruct, 0, len(globals.config.backends))
+
+	for _, backend = range globals.config.backends {
+		if backend.backendMetrics == nil {
+			continue
+		}
+
+		latencies = append(latencies, &adminLatencyStruct{
+			Backend:    backend.dirName,
+			Operations: backend.backendMetrics.latencyQuantiles(),
+		})
+	}
+
+	slices.SortFunc(latencies, func(a, b *adminLatencyStruct) int {
+		return strings.Compare(a.Backend, b.Backend)
+	})
+
+	return
+}
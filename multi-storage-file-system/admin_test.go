@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestAdminSocket(t *testing.T) {
+	var (
+		adminSocketPath = filepath.Join(t.TempDir(), "admin.sock")
+		caches          []*adminCacheStruct
+		dropped         map[string]uint64
+		errno           syscall.Errno
+		fileAIno        uint64
+		healths         []*adminHealthStruct
+		inodes          []*adminInodeStruct
+		ioTop           *ioTopStruct
+		latencies       []*adminLatencyStruct
+		lookupOut       *fission.LookupOut
+		openOut         *fission.OpenOut
+		stats           *adminStatsStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileAIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	for _, pid := range []uint32{4242, 4343, 4343} {
+		_, errno = globals.DoRead(&fission.InHeader{NodeID: fileAIno, UID: 1000, PID: pid}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 1})
+		if errno != 0 {
+			t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+		}
+	}
+
+	globals.config.adminSocket = adminSocketPath
+	startAdminSocket()
+	defer stopAdminSocket()
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", adminSocketPath)
+			},
+		},
+	}
+
+	adminRequest := func(method string, uri string, expectedStatusCode int, response interface{}) {
+		httpRequest, err := http.NewRequest(method, "http://msfs"+uri, nil)
+		if err != nil {
+			t.Fatalf("http.NewRequest(%s,%s) failed: %v", method, uri, err)
+		}
+		httpResponse, err := httpClient.Do(httpRequest)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, uri, err)
+		}
+		body, err := io.ReadAll(httpResponse.Body)
+		_ = httpResponse.Body.Close()
+		if err != nil {
+			t.Fatalf("%s %s body unreadable: %v", method, uri, err)
+		}
+		if httpResponse.StatusCode != expectedStatusCode {
+			t.Fatalf("%s %s returned %v (expected %v): %s", method, uri, httpResponse.StatusCode, expectedStatusCode, body)
+		}
+		if response != nil {
+			err = json.Unmarshal(body, response)
+			if err != nil {
+				t.Fatalf("%s %s returned unparseable %s: %v", method, uri, body, err)
+			}
+		}
+	}
+
+	adminRequest(http.MethodGet, "/stats", http.StatusOK, &stats)
+	if (stats.Backends != 1) || (stats.OpenFileHandles != 1) || (stats.CleanCacheLines != 1) {
+		t.Fatalf("GET /stats returned %+v", stats)
+	}
+
+	adminRequest(http.MethodGet, "/cache", http.StatusOK, &caches)
+	if (len(caches) != 1) || (caches[0].Backend != "ram") || (caches[0].Files != 1) || (caches[0].CacheLines != 1) || (caches[0].Bytes == 0) {
+		t.Fatalf("GET /cache returned %+v", caches)
+	}
+
+	adminRequest(http.MethodGet, "/inodes", http.StatusOK, &inodes)
+	if (len(inodes) != 1) || (inodes[0].Inode != fileAIno) || (inodes[0].Type != "file") || (inodes[0].Path != "fileA") || (inodes[0].OpenFileHandles != 1) {
+		t.Fatalf("GET /inodes returned %+v", inodes)
+	}
+
+	adminRequest(http.MethodGet, "/health", http.StatusOK, &healths)
+	if (len(healths) != 1) || (healths[0].Backend != "ram") || healths[0].Monitored || healths[0].Down {
+		t.Fatalf("GET /health returned %+v", healths)
+	}
+
+	// Backend request outcomes are recorded asynchronously
+
+	time.Sleep(100 * time.Millisecond)
+
+	adminRequest(http.MethodGet, "/latency", http.StatusOK, &latencies)
+	if (len(latencies) != 1) || (latencies[0].Backend != "ram") || (len(latencies[0].Operations) == 0) {
+		t.Fatalf("GET /latency returned %+v", latencies)
+	}
+	for _, latency := range latencies[0].Operations {
+		if (latency.Operation == "read") && ((latency.Count != 1) || (latency.P50 <= 0) || (latency.P99 < latency.P50)) {
+			t.Fatalf("GET /latency returned read latencies %+v", latency)
+		}
+	}
+
+	adminRequest(http.MethodGet, "/io?top=1", http.StatusOK, &ioTop)
+	if (len(ioTop.Inodes) != 1) || (ioTop.Inodes[0].Inode != fileAIno) || (ioTop.Inodes[0].Backend != "ram") || (ioTop.Inodes[0].Path != "fileA") || (ioTop.Inodes[0].Reads != 3) || (ioTop.Inodes[0].BytesRead != 3) || (ioTop.Inodes[0].CacheMisses != 1) {
+		t.Fatalf("GET /io?top=1 returned inodes %+v", ioTop.Inodes)
+	}
+	if (len(ioTop.PIDs) != 1) || (ioTop.PIDs[0].PID != 4343) || (ioTop.PIDs[0].UID != 1000) || (ioTop.PIDs[0].BytesRead != 2) || (ioTop.PIDs[0].CacheMisses != 0) {
+		t.Fatalf("GET /io?top=1 returned pids %+v", ioTop.PIDs)
+	}
+	if (len(ioTop.UIDs) != 1) || (ioTop.UIDs[0].UID != 1000) || (ioTop.UIDs[0].BytesRead != 3) {
+		t.Fatalf("GET /io?top=1 returned uids %+v", ioTop.UIDs)
+	}
+
+	adminRequest(http.MethodGet, "/io?top=-1", http.StatusBadRequest, nil)
+
+	adminRequest(http.MethodPost, "/reset_io", http.StatusOK, nil)
+
+	ioTop = nil
+	adminRequest(http.MethodGet, "/io", http.StatusOK, &ioTop)
+	if (len(ioTop.Inodes) != 0) || (len(ioTop.PIDs) != 0) || (len(ioTop.UIDs) != 0) {
+		t.Fatalf("GET /io following POST /reset_io returned %+v", ioTop)
+	}
+
+	adminRequest(http.MethodGet, "/drop_caches", http.StatusMethodNotAllowed, nil)
+
+	adminRequest(http.MethodPost, "/drop_caches", http.StatusOK, &dropped)
+	if dropped["cache_lines_evicted"] != 1 {
+		t.Fatalf("POST /drop_caches returned %+v", dropped)
+	}
+
+	adminRequest(http.MethodGet, "/stats", http.StatusOK, &stats)
+	if stats.CleanCacheLines != 0 {
+		t.Fatalf("GET /stats following POST /drop_caches returned %+v", stats)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: fileAIno}, &fission.ReleaseIn{FH: openOut.FH})
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Endpoints not specific to the admin API are those served on endpoint
+
+	httpRecorder := httptest.NewRecorder()
+	(&adminHandlerStruct{}).ServeHTTP(httpRecorder, httptest.NewRequest(http.MethodGet, "/backends", nil))
+	if (httpRecorder.Code != http.StatusOK) || (strings.TrimSpace(httpRecorder.Body.String()) != "ram") {
+		t.Fatalf("GET /backends returned %v: %s", httpRecorder.Code, httpRecorder.Body.String())
+	}
+}
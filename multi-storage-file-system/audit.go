@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+const (
+	auditLogRotatedSuffixLayout = "20060102T150405.000000000Z"
+)
+
+// `auditLogOps` enumerates the FUSE operations that may be listed in audit_log_ops.
+var auditLogOps = []string{"create", "getattr", "lookup", "mkdir", "open", "opendir", "read", "readdir", "readdirplus", "release", "releasedir", "rmdir", "statx", "unlink"}
+
+// `defaultAuditLogOps` are the FUSE operations audited should audit_log_ops not be specified.
+var defaultAuditLogOps = []string{"create", "mkdir", "open", "opendir", "read", "rmdir", "unlink"}
+
+// `auditStruct` appends a JSON Lines record of each audited FUSE operation to
+// globals.config.auditLogFile, rotating it once it reaches auditLogMaxSize and
+// (if globals.config.auditBackend != "") uploading each rotated segment as an
+// object beneath globals.config.auditPrefix of that backend.
+type auditStruct struct {
+	sync.Mutex                          // Serializes record() and rotate()
+	file            *os.File            // Opened O_APPEND on globals.config.auditLogFile
+	size            uint64              // Current size of file
+	uploadWaitGroup sync.WaitGroup      // Tracks in-flight uploads of rotated segments
+	encodeBuf       []byte              // Reused to marshal each record
+	ops             map[string]struct{} // Key: each of globals.config.auditLogOps
+}
+
+// `auditRecordStruct` is the JSON-encoded form of each line of the audit log.
+type auditRecordStruct struct {
+	Time     string  `json:"time"`                // RFC3339Nano
+	UID      uint32  `json:"uid"`                 //
+	GID      uint32  `json:"gid"`                 //
+	PID      uint32  `json:"pid"`                 //
+	Op       string  `json:"op"`                  // e.g. "open", "read", "unlink"
+	Backend  string  `json:"backend"`             // backend.dirName (or "" for the FUSE root directory)
+	Path     string  `json:"path"`                // Object path within backend
+	Bytes    uint64  `json:"bytes"`               // Bytes transferred (if applicable)
+	Duration float64 `json:"duration_seconds"`    // From receipt of the operation until its completion
+	CacheHit *bool   `json:"cache_hit,omitempty"` // If op == "read", whether it was satisfied entirely from cache lines already present
+	Result   string  `json:"result"`              // "ok" or the errno's description
+	Errno    uint32  `json:"errno,omitempty"`     // Omitted on success
+}
+
+// `newAudit` opens (creating if necessary) globals.config.auditLogFile if
+// specified. If not, a nil *auditStruct is returned and record() is a no-op.
+func newAudit() (audit *auditStruct, err error) {
+	var (
+		fileInfo os.FileInfo
+	)
+
+	if globals.config.auditLogFile == "" {
+		return
+	}
+
+	audit = &auditStruct{
+		ops: make(map[string]struct{}, len(globals.config.auditLogOps)),
+	}
+
+	for _, op := range globals.config.auditLogOps {
+		audit.ops[op] = struct{}{}
+	}
+
+	audit.file, err = os.OpenFile(globals.config.auditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		audit = nil
+		return
+	}
+
+	fileInfo, err = audit.file.Stat()
+	if err != nil {
+		_ = audit.file.Close()
+		audit = nil
+		return
+	}
+
+	audit.size = uint64(fileInfo.Size())
+
+	return
+}
+
+// `record` appends a record of op having been performed on behalf of the caller
+// described by inHeader should op be among globals.config.auditLogOps (and, unless
+// it failed, be sampled per globals.config.auditLogSampleRate). The path is inode's
+// objectPath with basename appended (for operations naming a child of inode) while
+// the duration is that since startTime. A nil cacheHit (i.e. not applicable to op)
+// is omitted. A nil audit (i.e. auditing disabled) is tolerated. Must be called
+// without globals.Lock() held.
+func (audit *auditStruct) record(inHeader *fission.InHeader, op string, inode *inodeStruct, basename string, bytes uint64, startTime time.Time, cacheHit *bool, errno syscall.Errno) {
+	var (
+		auditRecord *auditRecordStruct
+		err         error
+		n           int
+		ok          bool
+	)
+
+	if audit == nil {
+		return
+	}
+
+	_, ok = audit.ops[op]
+	if !ok {
+		return
+	}
+
+	if (errno == 0) && (globals.config.auditLogSampleRate < 1) && (rand.Float64() >= globals.config.auditLogSampleRate) {
+		return
+	}
+
+	auditRecord = &auditRecordStruct{
+		UID:      inHeader.UID,
+		GID:      inHeader.GID,
+		PID:      inHeader.PID,
+		Op:       op,
+		Path:     basename,
+		Bytes:    bytes,
+		Duration: time.Since(startTime).Seconds(),
+		CacheHit: cacheHit,
+		Result:   "ok",
+	}
+
+	if inode != nil {
+		if inode.backend != nil {
+			auditRecord.Backend = inode.backend.dirName
+		}
+		auditRecord.Path = inode.objectPath + basename
+	}
+
+	if errno != 0 {
+		auditRecord.Result = errno.Error()
+		auditRecord.Errno = uint32(errno)
+	}
+
+	audit.Lock()
+	defer audit.Unlock()
+
+	auditRecord.Time = time.Now().UTC().Format(time.RFC3339Nano)
+
+	audit.encodeBuf, err = json.Marshal(auditRecord)
+	if err != nil {
+		globals.logger.Printf("[WARN] [audit] unable to marshal record: %v", err)
+		return
+	}
+	audit.encodeBuf = append(audit.encodeBuf, '\n')
+
+	n, err = audit.file.Write(audit.encodeBuf)
+	audit.size += uint64(n)
+	if err != nil {
+		globals.logger.Printf("[WARN] [audit] unable to append to %s: %v", globals.config.auditLogFile, err)
+		return
+	}
+
+	if audit.size >= globals.config.auditLogMaxSize {
+		audit.rotateAlreadyLocked()
+	}
+}
+
+// `close` rotates out any records not yet shipped to globals.config.auditBackend,
+// awaits all in-flight uploads of rotated segments, and closes the audit log.
+// A nil audit (i.e. auditing disabled) is tolerated.
+func (audit *auditStruct) close() {
+	if audit == nil {
+		return
+	}
+
+	audit.Lock()
+
+	if (globals.config.auditBackend != "") && (audit.size > 0) {
+		audit.rotateAlreadyLocked()
+	}
+
+	if audit.file != nil {
+		_ = audit.file.Close()
+		audit.file = nil
+	}
+
+	audit.Unlock()
+
+	audit.uploadWaitGroup.Wait()
+}
+
+// `rotateAlreadyLocked` is called while audit.Lock() is held to rename the current
+// audit log aside (suffixed by the UTC time of rotation), open a fresh one, prune all
+// but the most recent globals.config.auditLogMaxFiles rotated segments, and launch
+// the upload of the newly rotated segment (if globals.config.auditBackend != "").
+func (audit *auditStruct) rotateAlreadyLocked() {
+	var (
+		buf         []byte
+		err         error
+		rotatedFile string
+		rotatedGlob []string
+	)
+
+	err = audit.file.Sync()
+	if err != nil {
+		globals.logger.Printf("[WARN] [audit] unable to sync %s: %v", globals.config.auditLogFile, err)
+	}
+	_ = audit.file.Close()
+
+	rotatedFile = globals.config.auditLogFile + "." + time.Now().UTC().Format(auditLogRotatedSuffixLayout)
+
+	err = os.Rename(globals.config.auditLogFile, rotatedFile)
+	if err != nil {
+		globals.logger.Printf("[WARN] [audit] unable to rotate %s: %v", globals.config.auditLogFile, err)
+		rotatedFile = ""
+	}
+
+	audit.file, err = os.OpenFile(globals.config.auditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		globals.logger.Fatalf("[FATAL] [audit] unable to reopen %s: %v", globals.config.auditLogFile, err)
+	}
+
+	audit.size = 0
+
+	if rotatedFile == "" {
+		return
+	}
+
+	if globals.config.auditBackend != "" {
+		// Read rotatedFile now lest it be pruned below before upload() gets to it
+
+		buf, err = os.ReadFile(rotatedFile)
+		if err != nil {
+			globals.logger.Printf("[WARN] [audit] unable to read %s: %v", rotatedFile, err)
+		} else {
+			audit.uploadWaitGroup.Go(func() { audit.upload(rotatedFile, buf) })
+		}
+	}
+
+	rotatedGlob, err = filepath.Glob(globals.config.auditLogFile + ".*")
+	if err != nil {
+		return
+	}
+
+	// The fixed width UTC suffix ensures lexicographic order is chronological
+
+	slices.Sort(rotatedGlob)
+
+	for len(rotatedGlob) > int(globals.config.auditLogMaxFiles) {
+		err = os.Remove(rotatedGlob[0])
+		if err != nil {
+			globals.logger.Printf("[WARN] [audit] unable to prune %s: %v", rotatedGlob[0], err)
+		}
+		rotatedGlob = rotatedGlob[1:]
+	}
+}
+
+// `upload` writes buf, the content of rotatedFile, to an object named by its basename
+// beneath globals.config.auditPrefix of globals.config.auditBackend.
+func (audit *auditStruct) upload(rotatedFile string, buf []byte) {
+	var (
+		backend  *backendStruct
+		err      error
+		filePath = globals.config.auditPrefix + filepath.Base(rotatedFile)
+		ok       bool
+	)
+
+	globals.Lock()
+	backend, ok = globals.config.backends[globals.config.auditBackend]
+	globals.Unlock()
+
+	if !ok {
+		globals.logger.Printf("[WARN] [audit] unable to upload %s: backend \"%s\" not mounted", rotatedFile, globals.config.auditBackend)
+		return
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{
+		filePath: filePath,
+		buf:      buf,
+	})
+	if err != nil {
+		globals.logger.Printf("[WARN] [audit] unable to upload %s to %s/%s: %v", rotatedFile, backend.dirName, filePath, err)
+		return
+	}
+
+	if backend.traceLevel > 0 {
+		globals.logger.Printf("[INFO] [audit] uploaded %s to %s/%s", rotatedFile, backend.dirName, filePath)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestAudit(t *testing.T) {
+	var (
+		auditLogContent     []byte
+		auditLogFile        = filepath.Join(t.TempDir(), "audit.log")
+		auditRecord         auditRecordStruct
+		auditRecordLines    [][]byte
+		backend             *backendStruct
+		err                 error
+		errno               syscall.Errno
+		listDirectoryOutput *listDirectoryOutputStruct
+		ok                  bool
+		uploadedContent     []byte
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"audit_log_file": "`+auditLogFile+`",
+		"audit_backend": "audited",
+		"audit_prefix": "audit/",
+		"backends": [
+			{
+				"dir_name": "audited",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	backend, ok = globals.config.backends["audited"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"audited\"] returned !ok")
+	}
+
+	_, errno = globals.DoMkDir(&fission.InHeader{NodeID: backend.inode.inodeNumber, UID: 1000, GID: 100, PID: 42}, &fission.MkDirIn{Name: []byte("d")})
+	if errno != 0 {
+		t.Fatalf("DoMkDir(\"d\") failed (errno: %v)", errno)
+	}
+
+	errno = globals.DoUnlink(&fission.InHeader{NodeID: backend.inode.inodeNumber, UID: 1001, GID: 100, PID: 43}, &fission.UnlinkIn{Name: []byte("missing")})
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoUnlink(\"missing\") returned errno %v (expected ENOENT)", errno)
+	}
+
+	auditLogContent, err = os.ReadFile(auditLogFile)
+	if err != nil {
+		t.Fatalf("os.ReadFile(auditLogFile) failed: %v", err)
+	}
+
+	auditRecordLines = bytes.Split(bytes.TrimSuffix(auditLogContent, []byte("\n")), []byte("\n"))
+	if len(auditRecordLines) != 2 {
+		t.Fatalf("audit log contained %v records (expected 2): %s", len(auditRecordLines), auditLogContent)
+	}
+
+	for i, expectedAuditRecord := range []auditRecordStruct{
+		{UID: 1000, GID: 100, PID: 42, Op: "mkdir", Backend: "audited", Path: "d", Result: "ok"},
+		{UID: 1001, GID: 100, PID: 43, Op: "unlink", Backend: "audited", Path: "missing", Result: syscall.ENOENT.Error(), Errno: uint32(syscall.ENOENT)},
+	} {
+		auditRecord = auditRecordStruct{}
+		err = json.Unmarshal(auditRecordLines[i], &auditRecord)
+		if err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %v", auditRecordLines[i], err)
+		}
+		if auditRecord.Time == "" {
+			t.Fatalf("audit record %s missing time", auditRecordLines[i])
+		}
+		if auditRecord.Duration <= 0 {
+			t.Fatalf("audit record %s missing duration_seconds", auditRecordLines[i])
+		}
+		auditRecord.Time = ""
+		auditRecord.Duration = 0
+		if auditRecord != expectedAuditRecord {
+			t.Fatalf("audit record %+v (expected %+v)", auditRecord, expectedAuditRecord)
+		}
+	}
+
+	// Closing the audit log should ship the remaining records to audit_backend
+
+	globals.audit.close()
+
+	listDirectoryOutput, err = backend.context.listDirectory(&listDirectoryInputStruct{dirPath: "audit/"})
+	if err != nil {
+		t.Fatalf("listDirectory(\"audit/\") failed: %v", err)
+	}
+	if len(listDirectoryOutput.file) != 1 {
+		t.Fatalf("listDirectory(\"audit/\") returned %v files (expected 1)", len(listDirectoryOutput.file))
+	}
+
+	uploadedContent, _, err = readWholeFile(backend.context, "audit/"+listDirectoryOutput.file[0].basename)
+	if (err != nil) || !bytes.Equal(uploadedContent, auditLogContent) {
+		t.Fatalf("readWholeFile() of uploaded audit log returned %q, %v (expected %q)", uploadedContent, err, auditLogContent)
+	}
+}
+
+func TestAuditOpsAndSampling(t *testing.T) {
+	var (
+		auditLogContent  []byte
+		auditLogFile     = filepath.Join(t.TempDir(), "audit.log")
+		auditRecord      auditRecordStruct
+		auditRecordLines [][]byte
+		err              error
+		errno            syscall.Errno
+		fileAIno         uint64
+		lookupOut        *fission.LookupOut
+		openOut          *fission.OpenOut
+		ramDirIno        uint64
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	globals.config.auditLogFile = auditLogFile
+	globals.config.auditLogOps = []string{"lookup", "read"}
+	globals.config.auditLogSampleRate = 1
+
+	globals.audit, err = newAudit()
+	if err != nil {
+		t.Fatalf("newAudit() failed: %v", err)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileAIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// The first read must fetch the cache line while the second finds it already present
+
+	for range 2 {
+		_, errno = globals.DoRead(&fission.InHeader{NodeID: fileAIno, UID: 1000}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 1})
+		if errno != 0 {
+			t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+		}
+	}
+
+	// With (practically) no successful operations sampled, only the failed lookup is audited
+
+	globals.config.auditLogSampleRate = 1e-9
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileZ")})
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileZ\") returned errno: %v (expected ENOENT)", errno)
+	}
+
+	auditLogContent, err = os.ReadFile(auditLogFile)
+	if err != nil {
+		t.Fatalf("os.ReadFile(auditLogFile) failed: %v", err)
+	}
+
+	auditRecordLines = bytes.Split(bytes.TrimSuffix(auditLogContent, []byte("\n")), []byte("\n"))
+	if len(auditRecordLines) != 5 {
+		t.Fatalf("audit log contained %v records (expected 5): %s", len(auditRecordLines), auditLogContent)
+	}
+
+	cacheHit := func(b bool) *bool { return &b }
+
+	for i, expectedAuditRecord := range []auditRecordStruct{
+		{Op: "lookup", Path: "ram", Result: "ok"},
+		{Op: "lookup", Backend: "ram", Path: "fileA", Result: "ok"},
+		{UID: 1000, Op: "read", Backend: "ram", Path: "fileA", Bytes: 1, CacheHit: cacheHit(false), Result: "ok"},
+		{UID: 1000, Op: "read", Backend: "ram", Path: "fileA", Bytes: 1, CacheHit: cacheHit(true), Result: "ok"},
+		{Op: "lookup", Backend: "ram", Path: "fileZ", Result: syscall.ENOENT.Error(), Errno: uint32(syscall.ENOENT)},
+	} {
+		auditRecord = auditRecordStruct{}
+		err = json.Unmarshal(auditRecordLines[i], &auditRecord)
+		if err != nil {
+			t.Fatalf("json.Unmarshal(%s) failed: %v", auditRecordLines[i], err)
+		}
+		auditRecord.Time = ""
+		auditRecord.Duration = 0
+		if ((auditRecord.CacheHit == nil) != (expectedAuditRecord.CacheHit == nil)) || ((auditRecord.CacheHit != nil) && (*auditRecord.CacheHit != *expectedAuditRecord.CacheHit)) {
+			t.Fatalf("audit record %s (expected cache_hit %v)", auditRecordLines[i], expectedAuditRecord.CacheHit)
+		}
+		auditRecord.CacheHit = nil
+		expectedAuditRecord.CacheHit = nil
+		if auditRecord != expectedAuditRecord {
+			t.Fatalf("audit record %+v (expected %+v)", auditRecord, expectedAuditRecord)
+		}
+	}
+}
@@ -2,10 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"syscall"
 	"time"
 
+	"github.com/NVIDIA/aistore/cmn"
 	"github.com/NVIDIA/multi-storage-client/multi-storage-file-system/telemetry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	httpDialTimeout = 30 * time.Second // Matches that of the AWS SDK's default http.Transport
 )
 
 // `setupContext` is called to establish the client that will be used
@@ -18,17 +30,69 @@ func (backend *backendStruct) setupContext() (err error) {
 	switch backend.backendType {
 	case "AIStore":
 		err = backend.setupAIStoreContext()
+	case "AzureFiles":
+		err = backend.setupAzureFilesContext()
+	case "Dropbox":
+		err = backend.setupDropboxContext()
 	case "RAM":
 		err = backend.setupRAMContext()
 	case "S3":
 		err = backend.setupS3Context()
+	case "Sharded":
+		err = backend.setupShardedContext()
+	case "Snapshot":
+		err = backend.setupSnapshotContext()
 	default:
-		err = fmt.Errorf("for backend.dir_name \"%s\", unexpected backend_type \"%s\" (must be \"AIStore\", \"RAM\", or \"S3\")", backend.dirName, backend.backendType)
+		err = fmt.Errorf("for backend.dir_name \"%s\", unexpected backend_type \"%s\" (must be \"AIStore\", \"AzureFiles\", \"Dropbox\", \"RAM\", \"S3\", \"Sharded\", or \"Snapshot\")", backend.dirName, backend.backendType)
 	}
 
 	return
 }
 
+// `applyHTTPTransportOptions` is called to apply the backend's connection
+// pooling, keep-alive, protocol, and timeout settings to the `http.Transport`
+// used by its client.
+func (backend *backendStruct) applyHTTPTransportOptions(transport *http.Transport) {
+	var (
+		dialer = &net.Dialer{
+			Timeout:   httpDialTimeout,
+			KeepAlive: backend.httpKeepAlive,
+		}
+	)
+
+	if backend.httpKeepAlive == 0 {
+		dialer.KeepAlive = -1 // Disables TCP keep-alive probes (rather than applying net's default)
+	}
+
+	transport.DialContext = dialer.DialContext
+	transport.MaxIdleConns = 0 // Idle connections limited (per endpoint host) solely by MaxIdleConnsPerHost
+	transport.MaxIdleConnsPerHost = int(backend.httpMaxIdleConnsPerHost)
+	transport.MaxConnsPerHost = int(backend.httpMaxConnsPerHost)
+	transport.IdleConnTimeout = backend.httpIdleConnTimeout
+	transport.ResponseHeaderTimeout = backend.httpResponseHeaderTimeout
+
+	// As HTTP/2 multiplexes requests over (typically) a single connection per endpoint
+	// host, a silently dropped connection is detected by pinging it once idle for as
+	// long as http_keep_alive
+
+	transport.HTTP2 = &http.HTTP2Config{
+		SendPingTimeout: backend.httpKeepAlive,
+	}
+
+	switch backend.httpProtocol {
+	case HTTPProtocolHTTP1:
+		transport.ForceAttemptHTTP2 = false
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	case HTTPProtocolHTTP2:
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	default: // HTTPProtocolAuto
+		transport.ForceAttemptHTTP2 = true // Even if TLSClientConfig is customized (e.g. by skip_tls_certificate_verify)
+	}
+}
+
 // `backendContextIf` defines the methods available for each backend
 // context. In order to set a backend (a struct of some sort), a
 // backend type-specific implementation for each of these methods
@@ -41,12 +105,24 @@ type backendContextIf interface {
 	// If a `subdirectory` or nothing is found at that path, an error will be returned.
 	deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error)
 
+	// `deleteFiles` is called to remove the `files` at the specified paths (e.g. when recursively
+	// deleting a `directory`). Paths at which nothing is found are silently skipped.
+	deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error)
+
 	// `listDirectory` is called to fetch a `page` of the `directory` at the specified path.
 	// An empty continuationToken or empty list of directory elements (`subdirectories` and `files`)
 	// indicates the `directory` has been completely enumerated. The `isTruncated` field will also
 	// align with this convention.
 	listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error)
 
+	// `listMultipartUploads` is called to fetch the multipart uploads in progress (i.e. neither
+	// completed nor aborted) of `files` whose paths begin with the specified prefix. A backend
+	// unable to enumerate them returns an error wrapping syscall.ENOTSUP.
+	listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error)
+
+	// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts uploaded.
+	abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error)
+
 	// `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
 	// empty list of elements (`objects`) indicates the list of `objects` has been completely
 	// enumerated. The `isTruncated` field will also align with this convention.
@@ -56,6 +132,10 @@ type backendContextIf interface {
 	// As error will result if either the specified path is not a `file` or non-existent.
 	readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error)
 
+	// `prefetchFiles` is called to hint that the `files` at the specified paths are likely to be
+	// read soon. A backend unable to make use of such hints simply ignores them.
+	prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error)
+
 	// `statDirectory` is called to verify that the specified path refers to a `directory`.
 	// An error will result if either the specified path is not a `directory` or non-existent.
 	statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error)
@@ -64,7 +144,21 @@ type backendContextIf interface {
 	// As error will result if either the specified path is not a `file` or non-existent.
 	statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error)
 
-	// [TODO] writeFile equivalents: simple PUT as well as the exciting challenges of MPU
+	// `writeFile` is called to create (or replace) the `file` at the specified path with the
+	// supplied content in its entirety (i.e. with a single PUT).
+	writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error)
+
+	// `createMultipartUpload` is called to begin creating (or replacing) the `file` at the specified
+	// path via a sequence of uploadPart() calls concluded by completeMultipartUpload(). A backend
+	// unable to do so returns an error wrapping syscall.ENOTSUP (in which case writeFile() must be used).
+	createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error)
+
+	// `uploadPart` is called to upload one part of the specified multipart upload.
+	uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error)
+
+	// `completeMultipartUpload` is called to assemble the parts uploaded (in partNumber order)
+	// into the `file` at the specified path concluding the specified multipart upload.
+	completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error)
 }
 
 // `deleteFileInputStruct` lays out the fields provided as input
@@ -78,12 +172,25 @@ type deleteFileInputStruct struct {
 // by deleteFile(). Currently, there are none.
 type deleteFileOutputStruct struct{}
 
+// `deleteFilesInputStruct` lays out the fields provided as input
+// to deleteFiles().
+type deleteFilesInputStruct struct {
+	filePaths []string // Each relative to backend.prefix
+}
+
+// `deleteFilesOutputStruct` lays out the fields produced as output
+// by deleteFiles(). Currently, there are none.
+type deleteFilesOutputStruct struct{}
+
 // `listDirectoryInputStruct` lays out the fields provided as input
 // to listDirectory().
 type listDirectoryInputStruct struct {
-	continuationToken string // If != "", from prior listDirectoryOutput.nextContinuationToken
-	maxItems          uint64 // If == 0, limited instead by the object server
-	dirPath           string // Relative to backend.prefix; if != "", should end with a trailing "/"
+	continuationToken string                     // If != "", from prior listDirectoryOutput.nextContinuationToken
+	maxItems          uint64                     // If == 0, limited instead by the object server
+	dirPath           string                     // Relative to backend.prefix; if != "", should end with a trailing "/"
+	bulk              bool                       // If true, scheduled as QoSClassBulk (e.g. for prefetch or other background work)
+	startAfter        string                     // If != "" (and continuationToken == ""), list only entries whose path relative to dirPath sorts after it (S3 only)
+	reuse             *listDirectoryOutputStruct // If != nil, a prior (no longer referenced) listDirectoryOutput whose slices may be reused
 }
 
 // `listDirectoryOutputFileStruct` lays out the fields produced as output
@@ -104,6 +211,108 @@ type listDirectoryOutputStruct struct {
 	isTruncated           bool
 }
 
+// `newListDirectoryOutput` returns the listDirectoryOutput to be populated by a backend's
+// listDirectory() with slices able to hold (without growing) the specified number of entries.
+// Listing a huge tree page by page would otherwise allocate each page's slices anew, so
+// listDirectoryInput.reuse (and its slices, if sufficiently large) is returned if provided.
+func newListDirectoryOutput(listDirectoryInput *listDirectoryInputStruct, subdirectoryCap int, fileCap int) (listDirectoryOutput *listDirectoryOutputStruct) {
+	listDirectoryOutput = listDirectoryInput.reuse
+	if listDirectoryOutput == nil {
+		listDirectoryOutput = &listDirectoryOutputStruct{}
+	}
+
+	if (listDirectoryOutput.subdirectory == nil) || (cap(listDirectoryOutput.subdirectory) < subdirectoryCap) {
+		listDirectoryOutput.subdirectory = make([]string, 0, subdirectoryCap)
+	} else {
+		clear(listDirectoryOutput.subdirectory) // So as to not retain the prior page's strings
+		listDirectoryOutput.subdirectory = listDirectoryOutput.subdirectory[:0]
+	}
+
+	if (listDirectoryOutput.file == nil) || (cap(listDirectoryOutput.file) < fileCap) {
+		listDirectoryOutput.file = make([]listDirectoryOutputFileStruct, 0, fileCap)
+	} else {
+		clear(listDirectoryOutput.file) // So as to not retain the prior page's strings
+		listDirectoryOutput.file = listDirectoryOutput.file[:0]
+	}
+
+	listDirectoryOutput.nextContinuationToken = ""
+	listDirectoryOutput.isTruncated = false
+
+	return
+}
+
+// `listMultipartUploadsInputStruct` lays out the fields provided as input
+// to listMultipartUploads().
+type listMultipartUploadsInputStruct struct {
+	prefix string // Relative to backend.prefix
+}
+
+// `listMultipartUploadsOutputUploadStruct` lays out the fields produced as output
+// by listMultipartUploads() for each multipart upload.
+type listMultipartUploadsOutputUploadStruct struct {
+	filePath  string // Relative to backend.prefix
+	uploadID  string
+	initiated time.Time
+}
+
+// `listMultipartUploadsOutputStruct` lays out the fields produced as output
+// by listMultipartUploads().
+type listMultipartUploadsOutputStruct struct {
+	upload []listMultipartUploadsOutputUploadStruct
+}
+
+// `abortMultipartUploadInputStruct` lays out the fields provided as input
+// to abortMultipartUpload().
+type abortMultipartUploadInputStruct struct {
+	filePath string // Relative to backend.prefix
+	uploadID string // From listMultipartUploadsOutput.upload[].uploadID
+}
+
+// `abortMultipartUploadOutputStruct` lays out the fields produced as output
+// by abortMultipartUpload(). Currently, there are none.
+type abortMultipartUploadOutputStruct struct{}
+
+// `createMultipartUploadInputStruct` lays out the fields provided as input
+// to createMultipartUpload().
+type createMultipartUploadInputStruct struct {
+	filePath string // Relative to backend.prefix
+}
+
+// `createMultipartUploadOutputStruct` lays out the fields produced as output
+// by createMultipartUpload().
+type createMultipartUploadOutputStruct struct {
+	uploadID string
+}
+
+// `uploadPartInputStruct` lays out the fields provided as input
+// to uploadPart().
+type uploadPartInputStruct struct {
+	filePath   string // Relative to backend.prefix
+	uploadID   string // From createMultipartUploadOutput.uploadID
+	partNumber uint64 // Starting at 1
+	buf        []byte
+}
+
+// `uploadPartOutputStruct` lays out the fields produced as output
+// by uploadPart().
+type uploadPartOutputStruct struct {
+	eTag string // If == "", not reported by the backend
+}
+
+// `completeMultipartUploadInputStruct` lays out the fields provided as input
+// to completeMultipartUpload().
+type completeMultipartUploadInputStruct struct {
+	filePath string   // Relative to backend.prefix
+	uploadID string   // From createMultipartUploadOutput.uploadID
+	partETag []string // partETag[i] from uploadPartOutput.eTag of partNumber i+1
+}
+
+// `completeMultipartUploadOutputStruct` lays out the fields produced as output
+// by completeMultipartUpload().
+type completeMultipartUploadOutputStruct struct {
+	eTag string // If == "", not reported by the backend
+}
+
 // `listObjectsInputStruct` lays out the fields provided as input
 // to listObjects(). Objects to be enumerated are all relative to
 // backend.prefix which, if != "", should end with a trailing "/".
@@ -133,17 +342,43 @@ type listObjectsOutputStruct struct {
 // to readFile().
 type readFileInputStruct struct {
 	filePath        string // Relative to backend.prefix
-	offsetCacheLine uint64 // Read byte range [offsetCacheLine * backend.config.cacheLineSize:min((offsetCacheLine+1) * backend.config.cacheLineSize, <object size>))
+	offsetCacheLine uint64 // Read byte range [offsetCacheLine * cacheLineSize:min((offsetCacheLine+cacheLines) * cacheLineSize, <object size>))
+	cacheLineSize   uint64 // Typically globals.config.cacheLineSize (but see backendPathOverrideStruct)
+	cacheLines      uint64 // If > 1, the number of consecutive cache lines read at once (e.g. coalesced cache misses); otherwise, 1
 	ifMatch         string // If == "", then always matches existing object; if != "", must match existing object's eTag
+	ifNoneMatch     string // If != "" and matches existing object's eTag, nothing is read (see readFileOutputStruct.notModified)
+	bulk            bool   // If true, scheduled as QoSClassBulk (e.g. for prefetch or other background work)
+	replicaRouted   bool   // If true, already routed among the backend's replicas (so not to be routed again)
+
+	traceCtx context.Context // If != nil, context of the (traced) cache line fetch issuing the read
+}
+
+// `byteRange` returns the offset and size of the byte range to be read by readFile().
+func (readFileInput *readFileInputStruct) byteRange() (rangeBegin uint64, rangeSize uint64) {
+	rangeBegin = readFileInput.offsetCacheLine * readFileInput.cacheLineSize
+	rangeSize = max(readFileInput.cacheLines, 1) * readFileInput.cacheLineSize
+
+	return
 }
 
 // `readFileOutputStruct` lays out the fields produced as output
 // by readFile().
 type readFileOutputStruct struct {
-	eTag string
-	buf  []byte
+	eTag        string
+	buf         []byte
+	notModified bool // If true, the existing object's eTag matched readFileInput.ifNoneMatch (and buf is empty)
+}
+
+// `prefetchFilesInputStruct` lays out the fields provided as input
+// to prefetchFiles().
+type prefetchFilesInputStruct struct {
+	filePaths []string // Each relative to backend.prefix
 }
 
+// `prefetchFilesOutputStruct` lays out the fields produced as output
+// by prefetchFiles(). Currently, there are none.
+type prefetchFilesOutputStruct struct{}
+
 // `statDirectoryInputStruct` lays out the fields provided as input
 // to statDirectory().
 type statDirectoryInputStruct struct {
@@ -161,6 +396,7 @@ type statDirectoryOutputStruct struct{}
 type statFileInputStruct struct {
 	filePath string // Relative to backend.prefix
 	ifMatch  string // If == "", then always matches existing object; if != "", must match existing object's eTag
+	bulk     bool   // If true, scheduled as QoSClassBulk (e.g. for prefetch or other background work)
 }
 
 // `statFileOutputStruct` lays out the fields produced as output
@@ -172,6 +408,19 @@ type statFileOutputStruct struct {
 	size  uint64
 }
 
+// `writeFileInputStruct` lays out the fields provided as input
+// to writeFile().
+type writeFileInputStruct struct {
+	filePath string // Relative to backend.prefix
+	buf      []byte // The entire content of the "file"
+}
+
+// `writeFileOutputStruct` lays out the fields produced as output
+// by writeFile().
+type writeFileOutputStruct struct {
+	eTag string // If == "", the backend does not report an eTag
+}
+
 // `recordRequest` records the request counter at the START of an operation.
 // Matches Python's behavior: request.sum is recorded BEFORE the operation executes (line 209).
 // This should be called immediately at the start of each backend operation (not in defer).
@@ -235,41 +484,87 @@ func recordBackendMetrics(backendName, operation string, startTime time.Time, er
 	metrics.RecordBackendOperation(context.Background(), operation, version, backendName, duration, success, bytesTransferred)
 }
 
-// `deleteFileWrapper` is a wrapper function around the supplied backendContext's `deleteFile` function enabling centralized metrics and tracing capture.
+// `recordBackendRequest` records the outcome of a backend request in backend.requests and
+// to both the global and backend's (Prometheus) backend_requests_total,
+// backend_request_latency_seconds, and backend_request_latency_quantile_seconds. As the
+// Prometheus collectors are themselves safe for concurrent use, this is done inline without
+// taking globals.Lock() (which the caller may already hold).
+func (backend *backendStruct) recordBackendRequest(operation string, startTime time.Time, err error) {
+	var (
+		latency = time.Since(startTime).Seconds()
+		status  = backendRequestStatus(err)
+	)
+
+	backend.requests.Add(1)
+	globals.retryBudget.deposit()
+
+	globals.backendMetrics.Requests.WithLabelValues(operation, status).Inc()
+	globals.backendMetrics.RequestLatencies.WithLabelValues(operation).Observe(latency)
+	globals.backendMetrics.RequestLatencyQuantiles.WithLabelValues(operation).Observe(latency)
+	if backend.backendMetrics != nil {
+		backend.backendMetrics.Requests.WithLabelValues(operation, status).Inc()
+		backend.backendMetrics.RequestLatencies.WithLabelValues(operation).Observe(latency)
+		backend.backendMetrics.RequestLatencyQuantiles.WithLabelValues(operation).Observe(latency)
+	}
+}
+
+// `deleteFileWrapper` is a wrapper function around the supplied backendContext's `deleteFile` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as replication to the backend's mirror (if any) and redirection of files migrated to its tier_cold_backend (if any).
 func deleteFileWrapper(backendContext backendContextIf, deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
 	var (
-		backendCommon = backendContext.backendCommon()
-		latency       float64
-		startTime     time.Time
+		backendCommon  = backendContext.backendCommon()
+		coldContext    backendContextIf
+		latency        float64
+		retriesAtStart uint64
+		startTime      time.Time
 	)
 
+	coldContext = backendCommon.tieringColdContext(deleteFileInput.filePath)
+	if coldContext != nil {
+		deleteFileOutput, err = deleteFileWrapper(coldContext, deleteFileInput)
+		if err == nil {
+			backendCommon.tieringForget([]string{deleteFileInput.filePath}, false)
+		}
+		return
+	}
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
 	recordRequest(backendCommon.dirName, "delete")
 
 	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
 
+	globals.qosScheduler.acquire(backendCommon.qosClass(deleteFileInput.filePath, false))
 	deleteFileOutput, err = backendContext.deleteFile(deleteFileInput)
+	globals.qosScheduler.release()
 
 	latency = time.Since(startTime).Seconds()
 
-	go func(backend *backendStruct, latency float64) {
-		globals.Lock()
-		if err == nil {
-			globals.backendMetrics.DeleteFileSuccesses.Inc()
-			globals.backendMetrics.DeleteFileSuccessLatencies.Observe(latency)
+	if err == nil {
+		backendCommon.mirrorDeleteFiles([]string{deleteFileInput.filePath})
+	}
 
-			backend.backendMetrics.DeleteFileSuccesses.Inc()
-			backend.backendMetrics.DeleteFileSuccessLatencies.Observe(latency)
-		} else {
-			globals.backendMetrics.DeleteFileFailures.Inc()
-			globals.backendMetrics.DeleteFileFailureLatencies.Observe(latency)
+	if err == nil {
+		globals.backendMetrics.DeleteFileSuccesses.Inc()
+		globals.backendMetrics.DeleteFileSuccessLatencies.Observe(latency)
 
-			backend.backendMetrics.DeleteFileFailures.Inc()
-			backend.backendMetrics.DeleteFileFailureLatencies.Observe(latency)
-		}
-		globals.Unlock()
-	}(backendCommon, latency)
+		backendCommon.backendMetrics.DeleteFileSuccesses.Inc()
+		backendCommon.backendMetrics.DeleteFileSuccessLatencies.Observe(latency)
+	} else {
+		globals.backendMetrics.DeleteFileFailures.Inc()
+		globals.backendMetrics.DeleteFileFailureLatencies.Observe(latency)
 
+		backendCommon.backendMetrics.DeleteFileFailures.Inc()
+		backendCommon.backendMetrics.DeleteFileFailureLatencies.Observe(latency)
+	}
+
+	backendCommon.recordBackendRequest("delete", startTime, err)
 	recordBackendMetrics(backendCommon.dirName, "delete", startTime, err, 0)
+	backendCommon.logSlowBackendRequest("deleteFile", deleteFileInput.filePath, startTime, retriesAtStart, 0, err)
 
 	switch backendCommon.traceLevel {
 	case 0:
@@ -289,41 +584,129 @@ func deleteFileWrapper(backendContext backendContextIf, deleteFileInput *deleteF
 	return
 }
 
-// `listDirectoryWrapper` is a wrapper function around the supplied backendContext's `listDirectory` function enabling centralized metrics and tracing capture.
+// `deleteFilesWrapper` is a wrapper function around the supplied backendContext's `deleteFiles` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as replication to the backend's mirror (if any) and redirection of files migrated to its tier_cold_backend (if any).
+func deleteFilesWrapper(backendContext backendContextIf, deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		backendCommon  = backendContext.backendCommon()
+		coldContext    backendContextIf
+		coldFilePaths  []string
+		hotFilePaths   []string
+		retriesAtStart uint64
+		startTime      time.Time
+	)
+
+	coldContext, coldFilePaths, hotFilePaths = backendCommon.tieringPartition(deleteFilesInput.filePaths)
+	if len(coldFilePaths) > 0 {
+		deleteFilesOutput, err = deleteFilesWrapper(coldContext, &deleteFilesInputStruct{
+			filePaths: coldFilePaths,
+		})
+		if err != nil {
+			return
+		}
+
+		backendCommon.tieringForget(coldFilePaths, false)
+
+		if len(hotFilePaths) == 0 {
+			return
+		}
+
+		deleteFilesInput = &deleteFilesInputStruct{
+			filePaths: hotFilePaths,
+		}
+	}
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
+	recordRequest(backendCommon.dirName, "delete_multi")
+
+	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
+
+	globals.qosScheduler.acquire(backendCommon.qosClass("", false))
+	deleteFilesOutput, err = backendContext.deleteFiles(deleteFilesInput)
+	globals.qosScheduler.release()
+
+	if err == nil {
+		backendCommon.mirrorDeleteFiles(deleteFilesInput.filePaths)
+	}
+
+	backendCommon.recordBackendRequest("delete_multi", startTime, err)
+	recordBackendMetrics(backendCommon.dirName, "delete_multi", startTime, err, 0)
+	backendCommon.logSlowBackendRequest("deleteFiles", "", startTime, retriesAtStart, 0, err)
+
+	switch backendCommon.traceLevel {
+	case 0:
+		// Trace nothing
+	case 1:
+		if err != nil {
+			globals.logger.Printf("[WARN] %s.deleteFiles(<%v filePaths>) returning err: %v", backendCommon.dirName, len(deleteFilesInput.filePaths), err)
+		}
+	default:
+		if err == nil {
+			globals.logger.Printf("[INFO] %s.deleteFiles(<%v filePaths>) succeeded", backendCommon.dirName, len(deleteFilesInput.filePaths))
+		} else {
+			globals.logger.Printf("[WARN] %s.deleteFiles(<%v filePaths>) returning err: %v", backendCommon.dirName, len(deleteFilesInput.filePaths), err)
+		}
+	}
+
+	return
+}
+
+// `listDirectoryWrapper` is a wrapper function around the supplied backendContext's `listDirectory` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as honoring of directory marker objects and inclusion of files migrated to its tier_cold_backend (if any).
 func listDirectoryWrapper(backendContext backendContextIf, listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
 	var (
-		backendCommon = backendContext.backendCommon()
-		latency       float64
-		startTime     time.Time
+		backendCommon  = backendContext.backendCommon()
+		latency        float64
+		retriesAtStart uint64
+		startTime      time.Time
 	)
 
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
 	recordRequest(backendCommon.dirName, "list")
 
 	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
 
+	globals.qosScheduler.acquire(backendCommon.qosClass(listDirectoryInput.dirPath, listDirectoryInput.bulk))
 	listDirectoryOutput, err = backendContext.listDirectory(listDirectoryInput)
+	globals.qosScheduler.release()
+
+	if err == nil {
+		backendCommon.directoryMarkerMergeListDirectory(listDirectoryOutput)
+	}
+
+	if (err == nil) && !listDirectoryOutput.isTruncated {
+		backendCommon.tieringMergeListDirectory(listDirectoryInput.dirPath, listDirectoryOutput)
+	}
 
 	latency = time.Since(startTime).Seconds()
 
-	go func(backend *backendStruct, latency float64) {
-		globals.Lock()
-		if err == nil {
-			globals.backendMetrics.ListDirectorySuccesses.Inc()
-			globals.backendMetrics.ListDirectorySuccessLatencies.Observe(latency)
+	if err == nil {
+		globals.backendMetrics.ListDirectorySuccesses.Inc()
+		globals.backendMetrics.ListDirectorySuccessLatencies.Observe(latency)
 
-			backend.backendMetrics.ListDirectorySuccesses.Inc()
-			backend.backendMetrics.ListDirectorySuccessLatencies.Observe(latency)
-		} else {
-			globals.backendMetrics.ListDirectoryFailures.Inc()
-			globals.backendMetrics.ListDirectoryFailureLatencies.Observe(latency)
+		backendCommon.backendMetrics.ListDirectorySuccesses.Inc()
+		backendCommon.backendMetrics.ListDirectorySuccessLatencies.Observe(latency)
+	} else {
+		globals.backendMetrics.ListDirectoryFailures.Inc()
+		globals.backendMetrics.ListDirectoryFailureLatencies.Observe(latency)
 
-			backend.backendMetrics.ListDirectoryFailures.Inc()
-			backend.backendMetrics.ListDirectoryFailureLatencies.Observe(latency)
-		}
-		globals.Unlock()
-	}(backendCommon, latency)
+		backendCommon.backendMetrics.ListDirectoryFailures.Inc()
+		backendCommon.backendMetrics.ListDirectoryFailureLatencies.Observe(latency)
+	}
 
+	backendCommon.recordBackendRequest("list", startTime, err)
 	recordBackendMetrics(backendCommon.dirName, "list", startTime, err, 0)
+	backendCommon.logSlowBackendRequest("listDirectory", listDirectoryInput.dirPath, startTime, retriesAtStart, 0, err)
 
 	switch backendCommon.traceLevel {
 	case 0:
@@ -349,45 +732,96 @@ func listDirectoryWrapper(backendContext backendContextIf, listDirectoryInput *l
 	return
 }
 
-// `readFileWrapper` is a wrapper function around the supplied backendContext's `readFile` function enabling centralized metrics and tracing capture.
+// `readFileWrapper` is a wrapper function around the supplied backendContext's `readFile` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as redirection of files migrated to its tier_cold_backend (if any).
 func readFileWrapper(backendContext backendContextIf, readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
 	var (
-		backendCommon = backendContext.backendCommon()
-		bytesRead     = int64(0)
-		latency       float64
-		startTime     time.Time
+		backendCommon  = backendContext.backendCommon()
+		bytesRead      = int64(0)
+		coldContext    backendContextIf
+		hedgeContext   backendContextIf
+		latency        float64
+		replicaContext backendContextIf
+		retriesAtStart uint64
+		span           trace.Span
+		startTime      time.Time
 	)
 
+	backendCommon.tieringRecordAccess(readFileInput.filePath, readFileInput.offsetCacheLine)
+
+	coldContext = backendCommon.tieringColdContext(readFileInput.filePath)
+	if coldContext != nil {
+		readFileOutput, err = readFileWrapper(coldContext, readFileInput)
+		return
+	}
+
+	if !readFileInput.replicaRouted {
+		replicaContext = backendCommon.nearestReplicaContext()
+		hedgeContext = backendCommon.hedgeReplicaContext()
+		if hedgeContext != nil {
+			if replicaContext == nil {
+				replicaContext = backendContext
+			}
+			readFileOutput, err = hedgeReadFile(replicaContext, hedgeContext, backendCommon.replicaHedgeDelay, readFileInput)
+			return
+		}
+		if replicaContext != nil {
+			readFileOutput, err = readFileWrapper(replicaContext, readFileInput)
+			return
+		}
+	}
+
+	_, span = msfsTracer.Start(traceContextOrBackground(readFileInput.traceCtx), "backend.read", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("msfs.backend", backendCommon.dirName),
+		attribute.String("msfs.path", readFileInput.filePath),
+		attribute.Int64("msfs.cache_line", int64(readFileInput.offsetCacheLine)),
+		attribute.Int64("msfs.cache_line_size", int64(readFileInput.cacheLineSize)),
+		attribute.Int64("msfs.cache_lines", int64(max(readFileInput.cacheLines, 1))),
+		attribute.Bool("msfs.bulk", readFileInput.bulk),
+	))
+	defer func() {
+		span.SetAttributes(attribute.String("msfs.status", backendRequestStatus(err)), attribute.Int64("msfs.bytes", bytesRead))
+		endSpanWithErr(span, err)
+	}()
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
 	recordRequest(backendCommon.dirName, "read")
 
 	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
 
+	globals.qosScheduler.acquire(backendCommon.qosClass(readFileInput.filePath, readFileInput.bulk))
+	span.AddEvent("qos.acquired")
 	readFileOutput, err = backendContext.readFile(readFileInput)
+	globals.qosScheduler.release()
 
 	latency = time.Since(startTime).Seconds()
 
-	go func(backend *backendStruct, latency float64) {
-		globals.Lock()
-		if err == nil {
-			globals.backendMetrics.ReadFileSuccesses.Inc()
-			globals.backendMetrics.ReadFileSuccessLatencies.Observe(latency)
+	if err == nil {
+		globals.backendMetrics.ReadFileSuccesses.Inc()
+		globals.backendMetrics.ReadFileSuccessLatencies.Observe(latency)
 
-			backend.backendMetrics.ReadFileSuccesses.Inc()
-			backend.backendMetrics.ReadFileSuccessLatencies.Observe(latency)
-		} else {
-			globals.backendMetrics.ReadFileFailures.Inc()
-			globals.backendMetrics.ReadFileFailureLatencies.Observe(latency)
+		backendCommon.backendMetrics.ReadFileSuccesses.Inc()
+		backendCommon.backendMetrics.ReadFileSuccessLatencies.Observe(latency)
+	} else {
+		globals.backendMetrics.ReadFileFailures.Inc()
+		globals.backendMetrics.ReadFileFailureLatencies.Observe(latency)
 
-			backend.backendMetrics.ReadFileFailures.Inc()
-			backend.backendMetrics.ReadFileFailureLatencies.Observe(latency)
-		}
-		globals.Unlock()
-	}(backendCommon, latency)
+		backendCommon.backendMetrics.ReadFileFailures.Inc()
+		backendCommon.backendMetrics.ReadFileFailureLatencies.Observe(latency)
+	}
 
 	if (err == nil) && (readFileOutput != nil) {
 		bytesRead = int64(len(readFileOutput.buf))
+		backendCommon.bytesRead.Add(uint64(bytesRead))
 	}
+	backendCommon.recordBackendRequest("read", startTime, err)
 	recordBackendMetrics(backendCommon.dirName, "read", startTime, err, bytesRead)
+	backendCommon.logSlowBackendRequest("readFile", readFileInput.filePath, startTime, retriesAtStart, bytesRead, err)
 
 	switch backendCommon.traceLevel {
 	case 0:
@@ -413,41 +847,98 @@ func readFileWrapper(backendContext backendContextIf, readFileInput *readFileInp
 	return
 }
 
-// `statDirectoryWrapper` is a wrapper function around the supplied backendContext's `statDirectory` function enabling centralized metrics and tracing capture.
+// `prefetchFilesWrapper` is a wrapper function around the supplied backendContext's `prefetchFiles` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture.
+func prefetchFilesWrapper(backendContext backendContextIf, prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	var (
+		backendCommon  = backendContext.backendCommon()
+		retriesAtStart uint64
+		startTime      time.Time
+	)
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
+	recordRequest(backendCommon.dirName, "prefetch")
+
+	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
+
+	globals.qosScheduler.acquire(QoSClassBulk)
+	prefetchFilesOutput, err = backendContext.prefetchFiles(prefetchFilesInput)
+	globals.qosScheduler.release()
+
+	backendCommon.recordBackendRequest("prefetch", startTime, err)
+	recordBackendMetrics(backendCommon.dirName, "prefetch", startTime, err, 0)
+	backendCommon.logSlowBackendRequest("prefetchFiles", "", startTime, retriesAtStart, 0, err)
+
+	switch backendCommon.traceLevel {
+	case 0:
+		// Trace nothing
+	case 1:
+		if err != nil {
+			globals.logger.Printf("[WARN] %s.prefetchFiles(<%v filePaths>) returning err: %v", backendCommon.dirName, len(prefetchFilesInput.filePaths), err)
+		}
+	default:
+		if err == nil {
+			globals.logger.Printf("[INFO] %s.prefetchFiles(<%v filePaths>) succeeded", backendCommon.dirName, len(prefetchFilesInput.filePaths))
+		} else {
+			globals.logger.Printf("[WARN] %s.prefetchFiles(<%v filePaths>) returning err: %v", backendCommon.dirName, len(prefetchFilesInput.filePaths), err)
+		}
+	}
+
+	return
+}
+
+// `statDirectoryWrapper` is a wrapper function around the supplied backendContext's `statDirectory` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as honoring of directory marker objects and inclusion of files migrated to its tier_cold_backend (if any).
 func statDirectoryWrapper(backendContext backendContextIf, statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
 	var (
-		backendCommon = backendContext.backendCommon()
-		latency       float64
-		startTime     time.Time
+		backendCommon  = backendContext.backendCommon()
+		latency        float64
+		retriesAtStart uint64
+		startTime      time.Time
 	)
 
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
 	recordRequest(backendCommon.dirName, "info")
 
 	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
 
+	globals.qosScheduler.acquire(backendCommon.qosClass(statDirectoryInput.dirPath, false))
 	statDirectoryOutput, err = backendContext.statDirectory(statDirectoryInput)
+	globals.qosScheduler.release()
+
+	if (err != nil) && (backendCommon.tieringHasDirectory(statDirectoryInput.dirPath) || backendCommon.directoryMarkerHasDirectory(statDirectoryInput.dirPath)) {
+		statDirectoryOutput = &statDirectoryOutputStruct{}
+		err = nil
+	}
 
 	latency = time.Since(startTime).Seconds()
 
-	go func(backend *backendStruct, latency float64) {
-		globals.Lock()
-		if err == nil {
-			globals.backendMetrics.StatDirectorySuccesses.Inc()
-			globals.backendMetrics.StatDirectorySuccessLatencies.Observe(latency)
+	if err == nil {
+		globals.backendMetrics.StatDirectorySuccesses.Inc()
+		globals.backendMetrics.StatDirectorySuccessLatencies.Observe(latency)
 
-			backend.backendMetrics.StatDirectorySuccesses.Inc()
-			backend.backendMetrics.StatDirectorySuccessLatencies.Observe(latency)
-		} else {
-			globals.backendMetrics.StatDirectoryFailures.Inc()
-			globals.backendMetrics.StatDirectoryFailureLatencies.Observe(latency)
+		backendCommon.backendMetrics.StatDirectorySuccesses.Inc()
+		backendCommon.backendMetrics.StatDirectorySuccessLatencies.Observe(latency)
+	} else {
+		globals.backendMetrics.StatDirectoryFailures.Inc()
+		globals.backendMetrics.StatDirectoryFailureLatencies.Observe(latency)
 
-			backend.backendMetrics.StatDirectoryFailures.Inc()
-			backend.backendMetrics.StatDirectoryFailureLatencies.Observe(latency)
-		}
-		globals.Unlock()
-	}(backendCommon, latency)
+		backendCommon.backendMetrics.StatDirectoryFailures.Inc()
+		backendCommon.backendMetrics.StatDirectoryFailureLatencies.Observe(latency)
+	}
 
+	backendCommon.recordBackendRequest("info", startTime, err)
 	recordBackendMetrics(backendCommon.dirName, "info", startTime, err, 0)
+	backendCommon.logSlowBackendRequest("statDirectory", statDirectoryInput.dirPath, startTime, retriesAtStart, 0, err)
 
 	switch backendCommon.traceLevel {
 	case 0:
@@ -473,45 +964,60 @@ func statDirectoryWrapper(backendContext backendContextIf, statDirectoryInput *s
 	return
 }
 
-// `statFileWrapper` is a wrapper function around the supplied backendContext's `statFile` function enabling centralized metrics and tracing capture.
+// `statFileWrapper` is a wrapper function around the supplied backendContext's `statFile` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as redirection of files migrated to its tier_cold_backend (if any).
 func statFileWrapper(backendContext backendContextIf, statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
 	var (
-		backendCommon = backendContext.backendCommon()
-		bytesReported = int64(0)
-		latency       float64
-		startTime     time.Time
+		backendCommon  = backendContext.backendCommon()
+		bytesReported  = int64(0)
+		coldContext    backendContextIf
+		latency        float64
+		retriesAtStart uint64
+		startTime      time.Time
 	)
 
+	coldContext = backendCommon.tieringColdContext(statFileInput.filePath)
+	if coldContext != nil {
+		statFileOutput, err = statFileWrapper(coldContext, statFileInput)
+		return
+	}
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
 	recordRequest(backendCommon.dirName, "info")
 
 	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
 
+	globals.qosScheduler.acquire(backendCommon.qosClass(statFileInput.filePath, statFileInput.bulk))
 	statFileOutput, err = backendContext.statFile(statFileInput)
+	globals.qosScheduler.release()
 
 	latency = time.Since(startTime).Seconds()
 
-	go func(backend *backendStruct, latency float64) {
-		globals.Lock()
-		if err == nil {
-			globals.backendMetrics.StatFileSuccesses.Inc()
-			globals.backendMetrics.StatFileSuccessLatencies.Observe(latency)
+	if err == nil {
+		globals.backendMetrics.StatFileSuccesses.Inc()
+		globals.backendMetrics.StatFileSuccessLatencies.Observe(latency)
 
-			backend.backendMetrics.StatFileSuccesses.Inc()
-			backend.backendMetrics.StatFileSuccessLatencies.Observe(latency)
-		} else {
-			globals.backendMetrics.StatFileFailures.Inc()
-			globals.backendMetrics.StatFileFailureLatencies.Observe(latency)
+		backendCommon.backendMetrics.StatFileSuccesses.Inc()
+		backendCommon.backendMetrics.StatFileSuccessLatencies.Observe(latency)
+	} else {
+		globals.backendMetrics.StatFileFailures.Inc()
+		globals.backendMetrics.StatFileFailureLatencies.Observe(latency)
 
-			backend.backendMetrics.StatFileFailures.Inc()
-			backend.backendMetrics.StatFileFailureLatencies.Observe(latency)
-		}
-		globals.Unlock()
-	}(backendCommon, latency)
+		backendCommon.backendMetrics.StatFileFailures.Inc()
+		backendCommon.backendMetrics.StatFileFailureLatencies.Observe(latency)
+	}
 
 	if (err == nil) && (statFileOutput != nil) {
 		bytesReported = int64(statFileOutput.size)
 	}
+	backendCommon.recordBackendRequest("info", startTime, err)
 	recordBackendMetrics(backendCommon.dirName, "info", startTime, err, bytesReported)
+	backendCommon.logSlowBackendRequest("statFile", statFileInput.filePath, startTime, retriesAtStart, 0, err)
 
 	switch backendCommon.traceLevel {
 	case 0:
@@ -538,3 +1044,179 @@ func statFileWrapper(backendContext backendContextIf, statFileInput *statFileInp
 }
 
 // [TODO] writeFileWrapper equivalents
+
+// `writeFileWrapper` is a wrapper function around the supplied backendContext's `writeFile` function enabling centralized health checking, QoS scheduling, metrics, and tracing capture
+// as well as quota enforcement, replication to the backend's mirror (if any), and superseding any copy migrated to its tier_cold_backend (if any).
+func writeFileWrapper(backendContext backendContextIf, writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		backendCommon  = backendContext.backendCommon()
+		retriesAtStart uint64
+		startTime      time.Time
+	)
+
+	err = backendCommon.healthCheck()
+	if err != nil {
+		return
+	}
+
+	recordRequest(backendCommon.dirName, "write")
+
+	startTime = time.Now()
+	retriesAtStart = backendCommon.retries.Load()
+
+	err = backendCommon.quotaReserve(writeFileInput.filePath, uint64(len(writeFileInput.buf)))
+	if err == nil {
+		globals.qosScheduler.acquire(backendCommon.qosClass(writeFileInput.filePath, false))
+		writeFileOutput, err = backendContext.writeFile(writeFileInput)
+		globals.qosScheduler.release()
+		if err != nil {
+			backendCommon.quotaRelease(writeFileInput.filePath, uint64(len(writeFileInput.buf)))
+		}
+	}
+
+	if err == nil {
+		backendCommon.bytesWritten.Add(uint64(len(writeFileInput.buf)))
+		backendCommon.mirrorWriteFile(writeFileInput, writeFileOutput.eTag)
+		backendCommon.tieringForget([]string{writeFileInput.filePath}, true)
+	}
+
+	backendCommon.recordBackendRequest("write", startTime, err)
+	recordBackendMetrics(backendCommon.dirName, "write", startTime, err, int64(len(writeFileInput.buf)))
+	backendCommon.logSlowBackendRequest("writeFile", writeFileInput.filePath, startTime, retriesAtStart, int64(len(writeFileInput.buf)), err)
+
+	switch backendCommon.traceLevel {
+	case 0:
+		// Trace nothing
+	case 1:
+		if err != nil {
+			globals.logger.Printf("[WARN] %s.writeFile({\"filePath\":\"%s\",len(\"buf\"):%v}) returning err: %v", backendCommon.dirName, writeFileInput.filePath, len(writeFileInput.buf), err)
+		}
+	default:
+		if err == nil {
+			globals.logger.Printf("[INFO] %s.writeFile({\"filePath\":\"%s\",len(\"buf\"):%v}) returning writeFileOutput: {\"eTag\":\"%s\"}", backendCommon.dirName, writeFileInput.filePath, len(writeFileInput.buf), writeFileOutput.eTag)
+		} else {
+			globals.logger.Printf("[WARN] %s.writeFile({\"filePath\":\"%s\",len(\"buf\"):%v}) returning err: %v", backendCommon.dirName, writeFileInput.filePath, len(writeFileInput.buf), err)
+		}
+	}
+
+	return
+}
+
+// `readWholeFile` is called to fetch the entire content of the `file` at the specified path
+// of the supplied backendContext one cache line at a time. The content is verified to be
+// unchanged (via its eTag, if reported) across all cache lines read. As this is only used
+// for background work (e.g. mirroring, tiering, and migration), requests are scheduled as
+// QoSClassBulk.
+func readWholeFile(backendContext backendContextIf, filePath string) (buf []byte, eTag string, err error) {
+	var (
+		offsetCacheLine uint64
+		readFileOutput  *readFileOutputStruct
+		statFileOutput  *statFileOutputStruct
+	)
+
+	statFileOutput, err = statFileWrapper(backendContext, &statFileInputStruct{
+		filePath: filePath,
+		ifMatch:  "",
+		bulk:     true,
+	})
+	if err != nil {
+		return
+	}
+
+	eTag = statFileOutput.eTag
+	buf = make([]byte, 0, statFileOutput.size)
+
+	for offsetCacheLine = 0; uint64(len(buf)) < statFileOutput.size; offsetCacheLine++ {
+		readFileOutput, err = readFileWrapper(backendContext, &readFileInputStruct{
+			filePath:        filePath,
+			offsetCacheLine: offsetCacheLine,
+			cacheLineSize:   globals.config.cacheLineSize,
+			ifMatch:         eTag,
+			bulk:            true,
+		})
+		if err != nil {
+			return
+		}
+		if len(readFileOutput.buf) == 0 {
+			err = fmt.Errorf("\"%s\" truncated at %v bytes (expected %v)", filePath, len(buf), statFileOutput.size)
+			return
+		}
+
+		buf = append(buf, readFileOutput.buf...)
+
+		putCacheLineBuf(readFileOutput.buf)
+	}
+
+	if uint64(len(buf)) != statFileOutput.size {
+		err = fmt.Errorf("\"%s\" has %v bytes (expected %v)", filePath, len(buf), statFileOutput.size)
+	}
+
+	return
+}
+
+// `copyFile` is called to copy the `file` at the specified path of srcBackendContext
+// to the same path of dstBackendContext (each relative to the respective backend.prefix).
+func copyFile(srcBackendContext backendContextIf, dstBackendContext backendContextIf, filePath string) (err error) {
+	var (
+		buf []byte
+	)
+
+	buf, _, err = readWholeFile(srcBackendContext, filePath)
+	if err != nil {
+		return
+	}
+
+	_, err = writeFileWrapper(dstBackendContext, &writeFileInputStruct{
+		filePath: filePath,
+		buf:      buf,
+	})
+
+	return
+}
+
+// `backendErrno` maps the err returned by a backend request to the errno reported to the
+// application: that wrapped by err (e.g. ENOENT for a missing file of a RAM backend, ESTALE
+// for an eTag mismatch, or EHOSTDOWN for a backend marked down) if any, else that of the HTTP
+// status code of a failure response from S3 or AIStore (404 => ENOENT, 401 & 403 => EACCES,
+// 412 => ESTALE, 429 & 503 => EAGAIN), else ETIMEDOUT should it have timed out, and
+// otherwise (e.g. for a checksum mismatch) EIO.
+func backendErrno(err error) (errno syscall.Errno) {
+	var (
+		errHTTP    *cmn.ErrHTTP
+		httpErr    *awshttp.ResponseError
+		statusCode int
+	)
+
+	switch {
+	case err == nil:
+		errno = 0
+		return
+	case errors.As(err, &errno):
+		return
+	case errors.As(err, &httpErr):
+		statusCode = httpErr.HTTPStatusCode()
+	case errors.Is(err, context.DeadlineExceeded):
+		errno = syscall.ETIMEDOUT
+		return
+	default:
+		errHTTP = cmn.AsErrHTTP(err)
+		if errHTTP != nil {
+			statusCode = errHTTP.Status
+		}
+	}
+
+	switch statusCode {
+	case http.StatusNotFound:
+		errno = syscall.ENOENT
+	case http.StatusUnauthorized, http.StatusForbidden:
+		errno = syscall.EACCES
+	case http.StatusPreconditionFailed:
+		errno = syscall.ESTALE
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		errno = syscall.EAGAIN
+	default:
+		errno = syscall.EIO
+	}
+
+	return
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
-	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/NVIDIA/aistore/api"
@@ -14,6 +16,9 @@ import (
 	"github.com/NVIDIA/aistore/api/authn"
 	"github.com/NVIDIA/aistore/cmn"
 	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/NVIDIA/aistore/core/meta"
+	"github.com/NVIDIA/aistore/nl"
+	"github.com/NVIDIA/aistore/xact"
 )
 
 // `aistoreContextStruct` holds the AIStore-specific backend details.
@@ -21,11 +26,38 @@ import (
 // separates baseParams (connection) from bck (bucket metadata). We store
 // both since bucket info is reused across all operations.
 type aistoreContextStruct struct {
-	backend    *backendStruct
-	baseParams api.BaseParams // Connection parameters
-	bck        cmn.Bck        // Bucket metadata/ structure
+	sync.Mutex                                                   // Protects baseParams.Token, blobDownloadChecked, propsCache, smap, smapFetchTime, & backendConfigAIStoreStruct.authn*
+	backend             *backendStruct                           //
+	baseParams          api.BaseParams                           // Connection parameters
+	bck                 cmn.Bck                                  // Bucket metadata/ structure
+	blobDownloadChecked map[string]struct{}                      // Set of object paths already considered for blob download
+	propsCache          map[string]*aistorePropsCacheEntryStruct // If props_cache_ttl != 0, recently fetched object props indexed by object path
+	smap                *meta.Smap                               // If direct_target_reads == true, cluster map used to locate an object's owning target (or nil if not yet fetched or invalidated)
+	smapFetchTime       time.Time                                // When smap was fetched
 }
 
+// `aistorePropsCacheEntryStruct` holds the props of an object as of fetchTime.
+type aistorePropsCacheEntryStruct struct {
+	props     *cmn.ObjectProps
+	fetchTime time.Time
+}
+
+// `aistoreBlobDownloadCheckedMax` caps the size of aistoreContextStruct.blobDownloadChecked.
+// Once reached, the set is simply emptied (at worst causing an extra HEAD per object).
+const aistoreBlobDownloadCheckedMax = 65536
+
+// `aistoreMultiObjBatchMax` caps the number of object names in each multi-object
+// (delete or prefetch) request issued by deleteFiles() and prefetchFiles().
+const aistoreMultiObjBatchMax = 1000
+
+// `aistorePropsCacheMax` caps the size of aistoreContextStruct.propsCache.
+// Once reached, the cache is simply emptied.
+const aistorePropsCacheMax = 65536
+
+// `aistoreListProps` is the minimal set of object properties requested when listing.
+// Note that the object's checksum (not the unsupported "etag" property) serves as its eTag.
+var aistoreListProps = strings.Join([]string{apc.GetPropsName, apc.GetPropsSize, apc.GetPropsChecksum}, apc.LsPropsSepa)
+
 // `backendCommon` is called to return a pointer to the context's common `backendStruct`.
 func (backend *aistoreContextStruct) backendCommon() (backendCommon *backendStruct) {
 	backendCommon = backend.backend
@@ -44,6 +76,7 @@ func (backend *backendStruct) setupAIStoreContext() (err error) {
 
 	// Create HTTP client with custom timeout and TLS config (matches S3 backend pattern)
 	transport := &http.Transport{}
+	backend.applyHTTPTransportOptions(transport)
 	httpClient = &http.Client{
 		Timeout:   backendAIStore.timeout,
 		Transport: transport,
@@ -58,20 +91,16 @@ func (backend *backendStruct) setupAIStoreContext() (err error) {
 		transport.TLSClientConfig.MinVersion = tls.VersionTLS12 // Match S3 backend: allow TLS 1.2+
 	}
 
-	// Fetch  AuthN Token from either backendAIStore.authnToken or backendAIStore.authnTokenFile
+	// Fetch  AuthN Token from either backendAIStore.authnToken (or the secret it references),
+	// a login with backendAIStore.authn{Username|Password}, or backendAIStore.authnTokenFile
 	if backendAIStore.authnToken == "" {
-		if backendAIStore.authnTokenFile == "" {
-			authnToken = ""
-		} else {
-			authnToken, err = authn.LoadToken(backendAIStore.authnTokenFile)
-			if err != nil {
-				// Unreadable/loadable... just default to empty authnToken
-				authnToken = ""
-				err = nil
-			}
-		}
+		authnToken = backendAIStore.loadAuthnToken(httpClient)
 	} else {
-		authnToken = backendAIStore.authnToken
+		authnToken, err = backendAIStore.resolveAuthnToken()
+		if err != nil {
+			err = fmt.Errorf("[AIStore] %v", err)
+			return
+		}
 	}
 
 	// Create base parameters for AIStore API
@@ -88,16 +117,18 @@ func (backend *backendStruct) setupAIStoreContext() (err error) {
 	}
 
 	// Create bucket reference
-	bck := cmn.Bck{
-		Name:     backend.bucketContainerName, // Bucket name from configuration
-		Provider: backendAIStore.provider,     // Provider type (ais, aws, gcp, azure, ht)
+	bck, err := backendAIStore.bucket(backend.bucketContainerName)
+	if err != nil {
+		return
 	}
 
 	// Store context
 	backend.context = &aistoreContextStruct{
-		backend:    backend,
-		baseParams: baseParams,
-		bck:        bck,
+		backend:             backend,
+		baseParams:          baseParams,
+		bck:                 bck,
+		blobDownloadChecked: make(map[string]struct{}),
+		propsCache:          make(map[string]*aistorePropsCacheEntryStruct),
 	}
 
 	// Record backendPath
@@ -110,16 +141,272 @@ func (backend *backendStruct) setupAIStoreContext() (err error) {
 	return
 }
 
+// `bucket` constructs the bucket reference for bucketContainerName. This is either
+// simply a bucket name (qualified by the provider and namespace_{uuid|name} settings)
+// or an AIStore bucket URI (e.g. "aws://bucket" or "ais://@uuid#namespace/bucket")
+// that specifies the provider and namespace itself.
+func (backendAIStore *backendConfigAIStoreStruct) bucket(bucketContainerName string) (bck cmn.Bck, err error) {
+	var (
+		objName string
+	)
+
+	if !strings.Contains(bucketContainerName, apc.BckProviderSeparator) {
+		bck = cmn.Bck{
+			Name:     bucketContainerName,
+			Provider: backendAIStore.provider,
+			Ns: cmn.Ns{
+				UUID: backendAIStore.namespaceUUID,
+				Name: backendAIStore.namespaceName,
+			},
+		}
+		err = bck.Validate()
+		return
+	}
+
+	bck, objName, err = cmn.ParseBckObjectURI(bucketContainerName, cmn.ParseURIOpts{DefaultProvider: backendAIStore.provider})
+	if err != nil {
+		return
+	}
+	if bck.Name == "" {
+		err = fmt.Errorf("missing bucket name in \"%s\"", bucketContainerName)
+		return
+	}
+	if objName != "" {
+		err = fmt.Errorf("unexpected object name in \"%s\" (use prefix instead)", bucketContainerName)
+	}
+
+	return
+}
+
+// `loadAuthnToken` fetches the AuthN Token either by logging in with the
+// configured credentials or by (re)reading the configured token file. An
+// unobtainable token results in "" being returned.
+func (backendAIStore *backendConfigAIStoreStruct) loadAuthnToken(httpClient *http.Client) (authnToken string) {
+	var (
+		authnPassword []string
+		err           error
+		tokenMsg      *authn.TokenMsg
+	)
+
+	if backendAIStore.authnUsername != "" {
+		authnPassword, _, err = resolveSecrets(backendAIStore.authnPassword)
+		if err == nil {
+			tokenMsg, err = authn.LoginUser(api.BaseParams{
+				Client: httpClient,
+				URL:    backendAIStore.authnEndpoint,
+				UA:     "multi-storage-file-system",
+			}, backendAIStore.authnUsername, authnPassword[0], nil)
+		}
+		if err == nil {
+			authnToken = tokenMsg.Token
+			return
+		}
+		globals.logger.Printf("[WARN] [AIStore] AuthN login of \"%s\" at \"%s\" failed: %v", backendAIStore.authnUsername, backendAIStore.authnEndpoint, err)
+	}
+
+	if backendAIStore.authnTokenFile != "" {
+		authnToken, err = authn.LoadToken(backendAIStore.authnTokenFile)
+		if err != nil {
+			// Unreadable/loadable... just default to empty authnToken
+			authnToken = ""
+		}
+	}
+
+	return
+}
+
+// `resolveAuthnToken` returns the configured authn_token or, should it reference
+// a secret, the secret's current value.
+func (backendAIStore *backendConfigAIStoreStruct) resolveAuthnToken() (authnToken string, err error) {
+	var (
+		resolved []string
+	)
+
+	resolved, _, err = resolveSecrets(backendAIStore.authnToken)
+	if err != nil {
+		return
+	}
+
+	authnToken = resolved[0]
+
+	return
+}
+
+// `withAuthnRefresh` invokes op (via withRetry) with the current connection parameters. Should
+// op fail due to an expired (or otherwise rejected) AuthN Token, a fresh token
+// is fetched and, if it differs from the rejected one, op is retried once.
+// Concurrent failures using the same rejected token only trigger one refresh.
+func (aisContext *aistoreContextStruct) withAuthnRefresh(op func(baseParams api.BaseParams) (err error)) (err error) {
+	var (
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		baseParams     api.BaseParams
+		errHTTP        *cmn.ErrHTTP
+		errResolve     error
+		rejectedToken  string
+	)
+
+	aisContext.Lock()
+	baseParams = aisContext.baseParams
+	aisContext.Unlock()
+
+	err = aisContext.withRetry(op, baseParams)
+	if err == nil {
+		return
+	}
+
+	errHTTP = cmn.AsErrHTTP(err)
+	if (errHTTP == nil) || (errHTTP.Status != http.StatusUnauthorized) {
+		return
+	}
+
+	rejectedToken = baseParams.Token
+
+	aisContext.Lock()
+	if (backendAIStore.authnToken != "") && (backendAIStore.authnUsername == "") {
+		if !isSecretRef(backendAIStore.authnToken) {
+			// A statically configured token cannot be refreshed
+			aisContext.Unlock()
+			return
+		}
+	}
+	if aisContext.baseParams.Token == rejectedToken {
+		if (backendAIStore.authnToken != "") && (backendAIStore.authnUsername == "") {
+			// The referenced secret may since have been rotated, so fetch it anew
+			invalidateSecret(backendAIStore.authnToken)
+			aisContext.baseParams.Token, errResolve = backendAIStore.resolveAuthnToken()
+			if errResolve != nil {
+				globals.logger.Printf("[WARN] [AIStore] %v", errResolve)
+			}
+		} else {
+			aisContext.baseParams.Token = backendAIStore.loadAuthnToken(aisContext.baseParams.Client)
+		}
+		if aisContext.baseParams.Token != rejectedToken {
+			globals.logger.Printf("[INFO] [AIStore] refreshed AuthN Token for backend \"%s\"", aisContext.backend.dirName)
+		}
+	}
+	baseParams = aisContext.baseParams
+	aisContext.Unlock()
+
+	if baseParams.Token == rejectedToken {
+		return
+	}
+
+	err = aisContext.withRetry(op, baseParams)
+
+	return
+}
+
+// `rotateCredentials` replaces the authn_{token|token_file|endpoint|username|password}
+// settings with those of backendAIStoreNew and immediately fetches the AuthN Token
+// that subsequent requests will present.
+func (aisContext *aistoreContextStruct) rotateCredentials(backendAIStoreNew *backendConfigAIStoreStruct) {
+	var (
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		err            error
+	)
+
+	aisContext.Lock()
+	defer aisContext.Unlock()
+
+	backendAIStore.authnToken = backendAIStoreNew.authnToken
+	backendAIStore.authnTokenFile = backendAIStoreNew.authnTokenFile
+	backendAIStore.authnEndpoint = backendAIStoreNew.authnEndpoint
+	backendAIStore.authnUsername = backendAIStoreNew.authnUsername
+	backendAIStore.authnPassword = backendAIStoreNew.authnPassword
+
+	if backendAIStore.authnToken == "" {
+		aisContext.baseParams.Token = backendAIStore.loadAuthnToken(aisContext.baseParams.Client)
+	} else {
+		aisContext.baseParams.Token, err = backendAIStore.resolveAuthnToken()
+		if err != nil {
+			globals.logger.Printf("[WARN] [AIStore] %v", err)
+		}
+	}
+}
+
+// `reloadAuthnTokenFile` re-reads the AuthN Token from authn_token_file (should neither
+// authn_token nor a login supply it) after the file has changed (see credwatch.go).
+func (aisContext *aistoreContextStruct) reloadAuthnTokenFile() {
+	var (
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+	)
+
+	aisContext.Lock()
+	defer aisContext.Unlock()
+
+	if backendAIStore.authnToken == "" {
+		aisContext.baseParams.Token = backendAIStore.loadAuthnToken(aisContext.baseParams.Client)
+	}
+}
+
 // Note on Retry Logic:
-// Unlike S3 backend which implements aws.Retryer interface (IsErrorRetryable, MaxAttempts,
-// RetryDelay, GetRetryToken, GetInitialToken, GetAttemptToken), AIStore backend does NOT
-// need these methods. The AIStore SDK handles retry logic internally via cmn.RetryArgs with
-// hardcoded sensible defaults (5 retries, 100ms base delay, exponential backoff up to 4s).
-// This is an architectural difference between AWS SDK (user-controlled retries) and AIStore
-// SDK (SDK-controlled retries). Both approaches work correctly, just different design patterns.
+// Unlike the S3 backend which implements the aws.Retryer interface, the AIStore SDK
+// retries internally via cmn.RetryArgs with hardcoded settings (5 retries of only
+// connection refused/reset errors with a linear backoff capped at 4s) that cannot be
+// overridden. The AIStore backend therefore wraps each SDK call (see `withRetry`) in
+// a retry loop driven by the retry_{max_attempts|base_delay|next_delay_multiplier|max_delay}
+// settings that mirror those of the S3 backend. Throttling (429), server (5xx), and
+// transport failures are retried.
 // See: https://github.com/NVIDIA/aistore/tree/main/aistore/cmn/retry.go and
 // https://github.com/NVIDIA/aistore/tree/main/aistore/api/client.go:215-222
 
+// `withRetry` invokes op with the supplied connection parameters, retrying retryable
+// failures per the retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} settings
+// (unless shed by the retry budget, if any).
+func (aisContext *aistoreContextStruct) withRetry(op func(baseParams api.BaseParams) (err error), baseParams api.BaseParams) (err error) {
+	var (
+		attempt        int
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		retryDelay     = backendAIStore.retryBaseDelay
+	)
+
+	for attempt = 1; ; attempt++ {
+		err = op(baseParams)
+		if (err == nil) || (attempt >= backendAIStore.retryAttempts) || !aistoreErrorRetryable(err) {
+			return
+		}
+
+		if !globals.retryBudget.withdraw() {
+			err = fmt.Errorf("retry budget exhausted: %w", err)
+			return
+		}
+
+		aisContext.backend.retries.Add(1)
+		if aistoreErrorThrottle(err) {
+			aisContext.backend.throttles.Add(1)
+		}
+
+		time.Sleep(min(retryDelay, backendAIStore.retryMaxDelay))
+
+		retryDelay = time.Duration(float64(retryDelay) * backendAIStore.retryNextDelayMultiplier)
+	}
+}
+
+// `aistoreErrorRetryable` reports whether err indicates a throttling (429), server (5xx),
+// or transport (no HTTP response received) failure that should be retried.
+func aistoreErrorRetryable(err error) (retryable bool) {
+	var (
+		errHTTP *cmn.ErrHTTP
+	)
+
+	errHTTP = cmn.AsErrHTTP(err)
+	retryable = (errHTTP == nil) || (errHTTP.Status == 0) || (errHTTP.Status == http.StatusTooManyRequests) || (errHTTP.Status >= http.StatusInternalServerError)
+
+	return
+}
+
+// `aistoreErrorThrottle` reports whether err indicates a throttling (429 or 503) failure.
+func aistoreErrorThrottle(err error) (throttle bool) {
+	var (
+		errHTTP *cmn.ErrHTTP
+	)
+
+	errHTTP = cmn.AsErrHTTP(err)
+	throttle = (errHTTP != nil) && ((errHTTP.Status == http.StatusTooManyRequests) || (errHTTP.Status == http.StatusServiceUnavailable))
+
+	return
+}
+
 // `deleteFile` is called to remove a "file" at the specified path.
 // If a `subdirectory` or nothing is found at that path, an error will be returned.
 func (aisContext *aistoreContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
@@ -128,23 +415,130 @@ func (aisContext *aistoreContextStruct) deleteFile(deleteFileInput *deleteFileIn
 		fullFilePath = backend.prefix + deleteFileInput.filePath
 	)
 
-	// If ifMatch is specified, verify ETag first
+	// If ifMatch is specified, verify ETag first (never trusting cached props here)
 	if deleteFileInput.ifMatch != "" {
 		var props *cmn.ObjectProps
-		props, err = api.HeadObject(aisContext.baseParams, aisContext.bck, fullFilePath, api.HeadArgs{
-			Silent: true,
-		})
+		props, err = aisContext.headObject(fullFilePath, false)
 		if err != nil {
 			return
 		}
 		if props.Cksum != nil && props.Cksum.Value() != deleteFileInput.ifMatch {
-			err = errors.New("eTag mismatch")
+			err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 			return
 		}
 	}
 
 	// Delete the object
-	err = api.DeleteObject(aisContext.baseParams, aisContext.bck, fullFilePath)
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		err = api.DeleteObject(baseParams, aisContext.bck, fullFilePath)
+		return
+	})
+
+	aisContext.Lock()
+	delete(aisContext.propsCache, fullFilePath)
+	aisContext.Unlock()
+
+	return
+}
+
+// `deleteFiles` is called to remove the "files" at the specified paths using multi-object
+// delete requests of up to aistoreMultiObjBatchMax names each, awaiting completion of the
+// xaction each request launches. Paths at which nothing is found are silently skipped.
+func (aisContext *aistoreContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		backend    = aisContext.backend
+		batchEnd   int
+		batchStart int
+		filePath   string
+		objName    string
+		objNames   []string
+		xactStatus *nl.Status
+	)
+
+	for batchStart = 0; batchStart < len(deleteFilesInput.filePaths); batchStart = batchEnd {
+		batchEnd = min(batchStart+aistoreMultiObjBatchMax, len(deleteFilesInput.filePaths))
+
+		objNames = make([]string, 0, batchEnd-batchStart)
+		for _, filePath = range deleteFilesInput.filePaths[batchStart:batchEnd] {
+			objNames = append(objNames, backend.prefix+filePath)
+		}
+
+		err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+			var xid string
+			xid, err = api.DeleteMultiObj(baseParams, aisContext.bck, &apc.EvdMsg{
+				ListRange:       apc.ListRange{ObjNames: objNames},
+				ContinueOnError: true,
+			})
+			if err != nil {
+				return
+			}
+			xactStatus, err = api.WaitForXactionIC(baseParams, &xact.ArgsMsg{
+				ID:   xid,
+				Kind: apc.ActDeleteObjects,
+			})
+			return
+		})
+		if (err == nil) && xactStatus.IsAborted() {
+			err = fmt.Errorf("xaction %s aborted: %s", xactStatus.UUID, xactStatus.ErrMsg)
+		}
+
+		aisContext.Lock()
+		for _, objName = range objNames {
+			delete(aisContext.propsCache, objName)
+		}
+		aisContext.Unlock()
+
+		if err != nil {
+			err = fmt.Errorf("[AIStore] deleteFiles failed: %v", err)
+			return
+		}
+	}
+
+	return
+}
+
+// `headObject` fetches the props of the object at fullFilePath. If props_cache_ttl != 0,
+// successfully fetched props are cached and, if useCache is true, props fetched within
+// the last props_cache_ttl are returned without contacting the cluster.
+func (aisContext *aistoreContextStruct) headObject(fullFilePath string, useCache bool) (props *cmn.ObjectProps, err error) {
+	var (
+		backendAIStore  = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		ok              bool
+		propsCacheEntry *aistorePropsCacheEntryStruct
+	)
+
+	if useCache && (backendAIStore.propsCacheTTL != 0) {
+		aisContext.Lock()
+		propsCacheEntry, ok = aisContext.propsCache[fullFilePath]
+		aisContext.Unlock()
+		if ok && (time.Since(propsCacheEntry.fetchTime) < backendAIStore.propsCacheTTL) {
+			props = propsCacheEntry.props
+			return
+		}
+	}
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		props, err = api.HeadObject(baseParams, aisContext.bck, fullFilePath, api.HeadArgs{
+			Silent: true,
+		})
+		return
+	})
+
+	if backendAIStore.propsCacheTTL != 0 {
+		aisContext.Lock()
+		if err == nil {
+			if len(aisContext.propsCache) >= aistorePropsCacheMax {
+				aisContext.propsCache = make(map[string]*aistorePropsCacheEntryStruct)
+			}
+			aisContext.propsCache[fullFilePath] = &aistorePropsCacheEntryStruct{
+				props:     props,
+				fetchTime: time.Now(),
+			}
+		} else {
+			delete(aisContext.propsCache, fullFilePath)
+		}
+		aisContext.Unlock()
+	}
 
 	return
 }
@@ -158,7 +552,7 @@ func (aisContext *aistoreContextStruct) listDirectory(listDirectoryInput *listDi
 		backend     = aisContext.backend
 		fullDirPath = backend.prefix + listDirectoryInput.dirPath
 		lsmsg       = &apc.LsoMsg{
-			Props:  strings.Join([]string{apc.GetPropsName, apc.GetPropsETag, apc.GetPropsSize}, ","),
+			Props:  aistoreListProps,
 			Prefix: fullDirPath,
 			Flags:  apc.LsNoRecursion,
 		}
@@ -176,26 +570,37 @@ func (aisContext *aistoreContextStruct) listDirectory(listDirectoryInput *listDi
 	}
 
 	// List objects (one page)
-	var lsoResult *cmn.LsoRes                                                                          // List Objects Result
-	lsoResult, err = api.ListObjectsPage(aisContext.baseParams, aisContext.bck, lsmsg, api.ListArgs{}) // List Objects Page
+	var lsoResult *cmn.LsoRes // List Objects Result
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		lsoResult, err = api.ListObjectsPage(baseParams, aisContext.bck, lsmsg, api.ListArgs{}) // List Objects Page
+		return
+	})
 	if err != nil {
 		err = fmt.Errorf("[AIStore] listDirectory failed: %v", err)
 		return
 	}
 
-	// Parse results
-	listDirectoryOutput = &listDirectoryOutputStruct{
-		subdirectory:          make([]string, 0),
-		file:                  make([]listDirectoryOutputFileStruct, 0),
-		nextContinuationToken: lsoResult.ContinuationToken,
-		isTruncated:           lsoResult.ContinuationToken != "",
+	// Parse results (sizing each slice to exactly fit its entries)
+	subdirectoryCount := 0
+	for _, entry := range lsoResult.Entries {
+		if (entry.Flags & apc.EntryIsDir) != 0 {
+			subdirectoryCount++
+		}
 	}
+	listDirectoryOutput = newListDirectoryOutput(listDirectoryInput, subdirectoryCount, len(lsoResult.Entries)-subdirectoryCount)
+	listDirectoryOutput.nextContinuationToken = lsoResult.ContinuationToken
+	listDirectoryOutput.isTruncated = lsoResult.ContinuationToken != ""
 
 	// Process entries
 	for _, entry := range lsoResult.Entries {
 		// Remove the fullDirPath prefix
 		relativeName := strings.TrimPrefix(entry.Name, fullDirPath)
 
+		// Skip the virtual directory entry for fullDirPath itself (if returned)
+		if (relativeName == "") || (relativeName == "/") {
+			continue
+		}
+
 		if (entry.Flags & apc.EntryIsDir) == 0 {
 			// Append relativeName as a file
 
@@ -215,6 +620,30 @@ func (aisContext *aistoreContextStruct) listDirectory(listDirectoryInput *listDi
 	return
 }
 
+// `listMultipartUploads` is called to fetch the multipart uploads in progress of `files` whose
+// paths begin with the specified prefix. AIStore's API provides no means to enumerate them.
+func (aisContext *aistoreContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	err = fmt.Errorf("[AIStore] listMultipartUploads not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts uploaded.
+func (aisContext *aistoreContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	var (
+		backend = aisContext.backend
+	)
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		err = api.AbortMultipartUpload(baseParams, aisContext.bck, backend.prefix+abortMultipartUploadInput.filePath, abortMultipartUploadInput.uploadID)
+		return
+	})
+	if err == nil {
+		abortMultipartUploadOutput = &abortMultipartUploadOutputStruct{}
+	}
+
+	return
+}
+
 // `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
 // empty list of elements (`objects`) indicates the list of `objects` has been completely
 // enumerated. The `isTruncated` field will also align with this convention.
@@ -222,8 +651,9 @@ func (aisContext *aistoreContextStruct) listObjects(listObjectsInput *listObject
 	var (
 		backend = aisContext.backend
 		lsmsg   = &apc.LsoMsg{
-			Props:  strings.Join([]string{apc.GetPropsName, apc.GetPropsETag, apc.GetPropsSize}, ","),
+			Props:  aistoreListProps,
 			Prefix: backend.prefix,
+			Flags:  apc.LsNoDirs,
 		}
 		timeNow = time.Now()
 	)
@@ -239,16 +669,19 @@ func (aisContext *aistoreContextStruct) listObjects(listObjectsInput *listObject
 	}
 
 	// List objects (one page)
-	var lsoResult *cmn.LsoRes                                                                          // List Objects Result
-	lsoResult, err = api.ListObjectsPage(aisContext.baseParams, aisContext.bck, lsmsg, api.ListArgs{}) // List Objects Page
+	var lsoResult *cmn.LsoRes // List Objects Result
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		lsoResult, err = api.ListObjectsPage(baseParams, aisContext.bck, lsmsg, api.ListArgs{}) // List Objects Page
+		return
+	})
 	if err != nil {
-		err = fmt.Errorf("[AIStore] listDirectory failed: %v", err)
+		err = fmt.Errorf("[AIStore] listObjects failed: %v", err)
 		return
 	}
 
 	// Parse results
 	listObjectsOutput = &listObjectsOutputStruct{
-		object:                make([]listObjectsOutputObjectStruct, 0),
+		object:                make([]listObjectsOutputObjectStruct, 0, len(lsoResult.Entries)),
 		nextContinuationToken: lsoResult.ContinuationToken,
 		isTruncated:           lsoResult.ContinuationToken != "",
 	}
@@ -275,53 +708,316 @@ func (aisContext *aistoreContextStruct) listObjects(listObjectsInput *listObject
 // An error is returned if either the specified path is not a `file` or non-existent.
 func (aisContext *aistoreContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
 	var (
-		backend      = aisContext.backend
-		fullFilePath = backend.prefix + readFileInput.filePath
-		rangeBegin   = readFileInput.offsetCacheLine * globals.config.cacheLineSize
-		rangeEnd     = rangeBegin + globals.config.cacheLineSize - 1
+		backend        = aisContext.backend
+		backendAIStore = backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		fullFilePath   = backend.prefix + readFileInput.filePath
+		rangeBegin     uint64
+		rangeEnd       uint64
+		rangeSize      uint64
 	)
 
+	rangeBegin, rangeSize = readFileInput.byteRange()
+	rangeEnd = rangeBegin + rangeSize - 1
+
+	// Transformed contents have no ETag of their own (that of the object not describing them)
+	// so, with an ETL, neither ifNoneMatch nor ifMatch can be validated
+
+	// Skip the read entirely if the object's ETag still matches (never trusting cached props here)
+	if (readFileInput.ifNoneMatch != "") && (backendAIStore.etlName == "") {
+		var props *cmn.ObjectProps
+		props, err = aisContext.headObject(fullFilePath, true)
+		if err != nil {
+			return
+		}
+		if props.Cksum != nil && props.Cksum.Value() == readFileInput.ifNoneMatch {
+			readFileOutput = &readFileOutputStruct{
+				eTag:        readFileInput.ifNoneMatch,
+				buf:         make([]byte, 0),
+				notModified: true,
+			}
+			return
+		}
+	}
+
+	// Stage huge objects in-cluster before reading them (if enabled)
+	if backendAIStore.blobDownloadThreshold != 0 {
+		aisContext.blobDownload(fullFilePath)
+	}
+
 	// Verify ETag if specified
-	if readFileInput.ifMatch != "" {
+	if (readFileInput.ifMatch != "") && (backendAIStore.etlName == "") {
 		var props *cmn.ObjectProps
-		props, err = api.HeadObject(aisContext.baseParams, aisContext.bck, fullFilePath, api.HeadArgs{
-			Silent: true,
-		})
+		props, err = aisContext.headObject(fullFilePath, true)
 		if err != nil {
 			return
 		}
 		if props.Cksum != nil && props.Cksum.Value() != readFileInput.ifMatch {
-			err = errors.New("eTag mismatch")
+			err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 			return
 		}
 	}
 
-	// Create buffer and GetArgs
-	buf := &bytes.Buffer{}
-	getArgs := &api.GetArgs{
-		Writer: buf,
-		Header: http.Header{},
+	// Get the object streaming directly into a range sized buffer (rewound for each attempt)
+	bufWriter := &cacheLineBufWriterStruct{
+		buf: getCacheLineBuf(rangeSize),
+	}
+	etlWriter := &aistoreETLWriterStruct{
+		bufWriter:  bufWriter,
+		rangeBegin: rangeBegin,
 	}
+	var oah api.ObjAttrs
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		bufWriter.len = 0
+		etlWriter.offset = 0
+		getArgs := &api.GetArgs{
+			Writer: bufWriter,
+			Header: http.Header{},
+		}
 
-	// Set range header
-	getArgs.Header.Set(cos.HdrRange, fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd))
+		if backendAIStore.etlName == "" {
+			// Set range header
+			getArgs.Header.Set(cos.HdrRange, fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd))
+		} else {
+			// Apply ETL to the whole object server-side (as a range of its output need not
+			// be the transform of the same range of the object) retaining just the range
+			getArgs.Writer = etlWriter
+			getArgs.Query = url.Values{apc.QparamETLName: []string{backendAIStore.etlName}}
+			if backendAIStore.etlArgs != "" {
+				getArgs.Query.Set(apc.QparamETLTransformArgs, backendAIStore.etlArgs)
+			}
+		}
 
-	// Get the object
-	var oah api.ObjAttrs
-	oah, err = api.GetObject(aisContext.baseParams, aisContext.bck, fullFilePath, getArgs)
+		// Read directly from the owning target if enabled and locatable
+		if backendAIStore.directTargetReads {
+			targetBaseParams, ok := aisContext.targetBaseParams(baseParams, fullFilePath)
+			if ok {
+				oah, err = api.GetObject(targetBaseParams, aisContext.bck, fullFilePath, getArgs)
+				if !aistoreTargetUnreachable(err) {
+					return
+				}
+
+				// Fall back to reading via the proxy (with a refreshed cluster map next time)
+
+				aisContext.Lock()
+				aisContext.smap = nil
+				aisContext.Unlock()
+
+				bufWriter.len = 0
+				etlWriter.offset = 0
+			}
+		}
+
+		oah, err = api.GetObject(baseParams, aisContext.bck, fullFilePath, getArgs)
+		return
+	})
 	if err != nil {
+		putCacheLineBuf(bufWriter.buf)
 		return
 	}
 
 	// Build output
 	readFileOutput = &readFileOutputStruct{
-		eTag: oah.Attrs().Cksum.Value(),
-		buf:  buf.Bytes(),
+		buf: bufWriter.buf[:bufWriter.len],
+	}
+	if backendAIStore.etlName == "" {
+		readFileOutput.eTag = oah.Attrs().Cksum.Value()
 	}
 
 	return
 }
 
+// `aistoreETLWriterStruct` receives the whole transformed contents of an object read via an
+// ETL, retaining in bufWriter only those bytes at or beyond rangeBegin that fit.
+type aistoreETLWriterStruct struct {
+	bufWriter  *cacheLineBufWriterStruct //
+	rangeBegin uint64                    //
+	offset     uint64                    // Of the next byte written
+}
+
+// `Write` is called to append that of p within the range to etlWriter.bufWriter.
+func (etlWriter *aistoreETLWriterStruct) Write(p []byte) (n int, err error) {
+	var (
+		skip uint64
+	)
+
+	n = len(p)
+
+	if etlWriter.offset < etlWriter.rangeBegin {
+		skip = min(uint64(len(p)), etlWriter.rangeBegin-etlWriter.offset)
+		p = p[skip:]
+		etlWriter.offset += skip
+	}
+
+	etlWriter.offset += uint64(len(p))
+	etlWriter.bufWriter.len += copy(etlWriter.bufWriter.buf[etlWriter.bufWriter.len:], p)
+
+	return
+}
+
+// `prefetchFiles` is called to hint that the "files" at the specified paths are likely to be
+// read soon. If prefetch_listed_files == true and the bucket is remote, prefetch requests of up
+// to aistoreMultiObjBatchMax names each are issued (but not awaited) so that the cluster fetches
+// the objects from the remote backend ahead of their being read.
+func (aisContext *aistoreContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	var (
+		backend        = aisContext.backend
+		backendAIStore = backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		batchEnd       int
+		batchStart     int
+		filePath       string
+		objNames       []string
+	)
+
+	if !backendAIStore.prefetchListedFiles || !aisContext.bck.IsRemote() {
+		return
+	}
+
+	for batchStart = 0; batchStart < len(prefetchFilesInput.filePaths); batchStart = batchEnd {
+		batchEnd = min(batchStart+aistoreMultiObjBatchMax, len(prefetchFilesInput.filePaths))
+
+		objNames = make([]string, 0, batchEnd-batchStart)
+		for _, filePath = range prefetchFilesInput.filePaths[batchStart:batchEnd] {
+			objNames = append(objNames, backend.prefix+filePath)
+		}
+
+		err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+			_, err = api.Prefetch(baseParams, aisContext.bck, &apc.PrefetchMsg{
+				ListRange:       apc.ListRange{ObjNames: objNames},
+				BlobThreshold:   int64(backendAIStore.blobDownloadThreshold),
+				ContinueOnError: true,
+			})
+			return
+		})
+		if err != nil {
+			err = fmt.Errorf("[AIStore] prefetchFiles failed: %v", err)
+			return
+		}
+	}
+
+	return
+}
+
+// `blobDownload` is called prior to reading any portion of an object. The first
+// time a given object is read, should it be at least blob_download_threshold in
+// size yet not present in the cluster, AIStore's blob downloader is asked to stage
+// it from the remote backend so that subsequent ranged reads avoid repeated cold
+// reads. Failures are merely logged as the ranged reads will still succeed.
+func (aisContext *aistoreContextStruct) blobDownload(fullFilePath string) {
+	var (
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		err            error
+		ok             bool
+		props          *cmn.ObjectProps
+		xid            string
+	)
+
+	if !aisContext.bck.IsRemote() {
+		return
+	}
+
+	aisContext.Lock()
+	_, ok = aisContext.blobDownloadChecked[fullFilePath]
+	if ok {
+		aisContext.Unlock()
+		return
+	}
+	if len(aisContext.blobDownloadChecked) >= aistoreBlobDownloadCheckedMax {
+		aisContext.blobDownloadChecked = make(map[string]struct{})
+	}
+	aisContext.blobDownloadChecked[fullFilePath] = struct{}{}
+	aisContext.Unlock()
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		props, err = api.HeadObject(baseParams, aisContext.bck, fullFilePath, api.HeadArgs{
+			FltPresence: apc.FltExists,
+			Silent:      true,
+		})
+		return
+	})
+	if (err != nil) || props.Present || (uint64(props.Size) < backendAIStore.blobDownloadThreshold) {
+		return
+	}
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		xid, err = api.BlobDownload(baseParams, aisContext.bck, fullFilePath, &apc.BlobMsg{
+			ChunkSize:  int64(backendAIStore.blobDownloadChunkSize),
+			FullSize:   props.Size,
+			NumWorkers: int(backendAIStore.blobDownloadWorkers),
+		})
+		return
+	})
+	if err == nil {
+		globals.logger.Printf("[INFO] [AIStore] blob download of \"%s\" (%v bytes) started [xid: %s]", fullFilePath, props.Size, xid)
+	} else {
+		globals.logger.Printf("[WARN] [AIStore] blob download of \"%s\" (%v bytes) failed: %v", fullFilePath, props.Size, err)
+	}
+}
+
+// `targetBaseParams` is called to derive, from the supplied (proxy) connection
+// parameters, those addressing the target owning the object at fullFilePath. The
+// cluster map used to locate it is (re)fetched if absent or older than cluster_map_ttl.
+// If the target cannot be determined, ok will be false.
+func (aisContext *aistoreContextStruct) targetBaseParams(baseParams api.BaseParams, fullFilePath string) (targetBaseParams api.BaseParams, ok bool) {
+	var (
+		backendAIStore = aisContext.backend.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+		err            error
+		si             *meta.Snode
+		smap           *meta.Smap
+	)
+
+	aisContext.Lock()
+	smap = aisContext.smap
+	if (smap != nil) && (time.Since(aisContext.smapFetchTime) > backendAIStore.clusterMapTTL) {
+		smap = nil
+	}
+	aisContext.Unlock()
+
+	if smap == nil {
+		smap, err = api.GetClusterMap(baseParams)
+		if err != nil {
+			globals.logger.Printf("[WARN] [AIStore] api.GetClusterMap() failed: %v", err)
+			return
+		}
+
+		aisContext.Lock()
+		aisContext.smap = smap
+		aisContext.smapFetchTime = time.Now()
+		aisContext.Unlock()
+	}
+
+	si, err = smap.HrwName2T(aisContext.bck.MakeUname(fullFilePath))
+	if (err != nil) || (si.URL(cmn.NetPublic) == "") {
+		return
+	}
+
+	targetBaseParams = baseParams
+	targetBaseParams.URL = si.URL(cmn.NetPublic)
+	ok = true
+
+	return
+}
+
+// `aistoreTargetUnreachable` reports whether err (from a request sent directly to a
+// target) indicates that the target is unreachable or no longer owns the object
+// (e.g. due to a cluster map change) such that the request should be sent via the proxy.
+// Note that the api package reports a failure to connect (or any other transport error) as
+// a cmn.ErrHTTP with Status 400 whose TypeCode names the Go type of the error (e.g. "OpError")
+// rather than being empty or one of AIStore's (each beginning with "Err").
+func aistoreTargetUnreachable(err error) (unreachable bool) {
+	var (
+		errHTTP *cmn.ErrHTTP
+	)
+
+	if err == nil {
+		return
+	}
+
+	errHTTP = cmn.AsErrHTTP(err)
+	unreachable = (errHTTP == nil) || (errHTTP.Status >= http.StatusInternalServerError) || ((errHTTP.TypeCode != "") && !strings.HasPrefix(errHTTP.TypeCode, "Err"))
+
+	return
+}
+
 // `statDirectory` is called to verify that the specified path refers to a `directory`.
 // An error is returned if either the specified path is not a `directory` or non-existent.
 func (aisContext *aistoreContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
@@ -339,10 +1035,13 @@ func (aisContext *aistoreContextStruct) statDirectory(statDirectoryInput *statDi
 	// List with limit of 1 to check if directory is accessible
 	// Note: In object storage, directories are just prefixes and can be empty.
 	// We rely on the API error to determine if the bucket/prefix is inaccessible.
-	lsoResult, err = api.ListObjectsPage(aisContext.baseParams, aisContext.bck, lsmsg, api.ListArgs{})
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		lsoResult, err = api.ListObjectsPage(baseParams, aisContext.bck, lsmsg, api.ListArgs{})
+		return
+	})
 	if err == nil {
 		if (lsoResult == nil) || (lsoResult.Entries == nil) || (len(lsoResult.Entries) == 0) {
-			err = errors.New("missing directory")
+			err = fmt.Errorf("missing directory: %w", syscall.ENOENT)
 			return
 		}
 
@@ -360,11 +1059,9 @@ func (aisContext *aistoreContextStruct) statFile(statFileInput *statFileInputStr
 		fullFilePath = backend.prefix + statFileInput.filePath
 	)
 
-	// Head the object
+	// Head the object (refreshing the props cache used by readFile's ifMatch verification)
 	var props *cmn.ObjectProps
-	props, err = api.HeadObject(aisContext.baseParams, aisContext.bck, fullFilePath, api.HeadArgs{
-		Silent: true,
-	})
+	props, err = aisContext.headObject(fullFilePath, false)
 	if err != nil {
 		return
 	}
@@ -372,7 +1069,7 @@ func (aisContext *aistoreContextStruct) statFile(statFileInput *statFileInputStr
 	// Verify ETag if specified
 	if statFileInput.ifMatch != "" {
 		if props.Cksum != nil && props.Cksum.Value() != statFileInput.ifMatch {
-			err = errors.New("eTag mismatch")
+			err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 			return
 		}
 	}
@@ -385,3 +1082,128 @@ func (aisContext *aistoreContextStruct) statFile(statFileInput *statFileInputStr
 
 	return
 }
+
+// `writeFile` is called to create (or replace) the "file" at the specified path
+// with a single PUT request.
+func (aisContext *aistoreContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		backend      = aisContext.backend
+		fullFilePath = backend.prefix + writeFileInput.filePath
+		oah          api.ObjAttrs
+	)
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		oah, err = api.PutObject(&api.PutArgs{
+			Reader:     cos.NewByteReader(writeFileInput.buf),
+			BaseParams: baseParams,
+			Bck:        aisContext.bck,
+			ObjName:    fullFilePath,
+			Size:       uint64(len(writeFileInput.buf)),
+		})
+		return
+	})
+
+	aisContext.Lock()
+	delete(aisContext.propsCache, fullFilePath)
+	aisContext.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("[AIStore] writeFile failed: %v", err)
+		return
+	}
+
+	writeFileOutput = &writeFileOutputStruct{
+		eTag: oah.Attrs().Cksum.Value(),
+	}
+
+	return
+}
+
+// `createMultipartUpload` is called to begin creating (or replacing) the `file` at the specified
+// path via a sequence of uploadPart() calls concluded by completeMultipartUpload().
+func (aisContext *aistoreContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	var (
+		backend  = aisContext.backend
+		uploadID string
+	)
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		uploadID, err = api.CreateMultipartUpload(baseParams, aisContext.bck, backend.prefix+createMultipartUploadInput.filePath)
+		return
+	})
+	if err != nil {
+		err = fmt.Errorf("[AIStore] createMultipartUpload failed: %v", err)
+		return
+	}
+
+	createMultipartUploadOutput = &createMultipartUploadOutputStruct{
+		uploadID: uploadID,
+	}
+
+	return
+}
+
+// `uploadPart` is called to upload one part of the specified multipart upload.
+// AIStore does not report an eTag for each part.
+func (aisContext *aistoreContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	var (
+		backend = aisContext.backend
+	)
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		err = api.UploadPart(&api.PutPartArgs{
+			UploadID: uploadPartInput.uploadID,
+			PutArgs: api.PutArgs{
+				Reader:     cos.NewByteReader(uploadPartInput.buf),
+				BaseParams: baseParams,
+				Bck:        aisContext.bck,
+				ObjName:    backend.prefix + uploadPartInput.filePath,
+				Size:       uint64(len(uploadPartInput.buf)),
+			},
+			PartNumber: int(uploadPartInput.partNumber),
+		})
+		return
+	})
+	if err != nil {
+		err = fmt.Errorf("[AIStore] uploadPart failed: %v", err)
+		return
+	}
+
+	uploadPartOutput = &uploadPartOutputStruct{}
+
+	return
+}
+
+// `completeMultipartUpload` is called to assemble the parts uploaded (in partNumber order)
+// into the `file` at the specified path concluding the specified multipart upload.
+// AIStore does not report the resulting eTag.
+func (aisContext *aistoreContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	var (
+		backend      = aisContext.backend
+		fullFilePath = backend.prefix + completeMultipartUploadInput.filePath
+		partIndex    int
+		partNumbers  = make([]int, len(completeMultipartUploadInput.partETag))
+	)
+
+	for partIndex = range partNumbers {
+		partNumbers[partIndex] = partIndex + 1
+	}
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		err = api.CompleteMultipartUpload(baseParams, aisContext.bck, fullFilePath, completeMultipartUploadInput.uploadID, partNumbers)
+		return
+	})
+
+	aisContext.Lock()
+	delete(aisContext.propsCache, fullFilePath)
+	aisContext.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("[AIStore] completeMultipartUpload failed: %v", err)
+		return
+	}
+
+	completeMultipartUploadOutput = &completeMultipartUploadOutputStruct{}
+
+	return
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/api/apc"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/NVIDIA/aistore/core/meta"
+)
+
+func TestAIStoreAuthnRefresh(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		backendAIStore *backendConfigAIStoreStruct
+		err            error
+		opTokens       []string
+		testTokenFile  *os.File
+	)
+
+	if globals.logger == nil {
+		globals.logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmsgprefix)
+	}
+
+	testTokenFile, err = os.CreateTemp("", "MSFSTestAuthnTokenFile*")
+	if err != nil {
+		t.Fatalf("os.CreateTemp(\"\", \"MSFSTestAuthnTokenFile*\") failed: %v", err)
+	}
+	defer func() {
+		_ = os.Remove(testTokenFile.Name())
+	}()
+	_, err = testTokenFile.WriteString(`{"token":"fresh"}`)
+	if err != nil {
+		t.Fatalf("testTokenFile.WriteString() failed: %v", err)
+	}
+	err = testTokenFile.Close()
+	if err != nil {
+		t.Fatalf("testTokenFile.Close() failed: %v", err)
+	}
+
+	backendAIStore = &backendConfigAIStoreStruct{
+		authnTokenFile: testTokenFile.Name(),
+	}
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName:              "ais",
+			backendTypeSpecifics: backendAIStore,
+		},
+		baseParams: api.BaseParams{
+			Token: "expired",
+		},
+	}
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		opTokens = append(opTokens, baseParams.Token)
+		if baseParams.Token != "fresh" {
+			err = &cmn.ErrHTTP{Status: http.StatusUnauthorized}
+		}
+		return
+	})
+	if err != nil {
+		t.Fatalf("withAuthnRefresh() unexpectedly failed: %v", err)
+	}
+	if (len(opTokens) != 2) || (opTokens[0] != "expired") || (opTokens[1] != "fresh") {
+		t.Fatalf("withAuthnRefresh() invoked op with tokens %v (expected [expired fresh])", opTokens)
+	}
+
+	opTokens = nil
+
+	err = aisContext.withAuthnRefresh(func(baseParams api.BaseParams) (err error) {
+		opTokens = append(opTokens, baseParams.Token)
+		err = &cmn.ErrHTTP{Status: http.StatusUnauthorized}
+		return
+	})
+	if err == nil {
+		t.Fatalf("withAuthnRefresh() unexpectedly succeeded")
+	}
+	if len(opTokens) != 1 {
+		t.Fatalf("withAuthnRefresh() invoked op %v times (expected 1 as the token file is unchanged)", len(opTokens))
+	}
+}
+
+func TestAIStoreRetry(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		backendAIStore *backendConfigAIStoreStruct
+		err            error
+		opCalls        int
+	)
+
+	backendAIStore = &backendConfigAIStoreStruct{
+		retryBaseDelay:           time.Millisecond,
+		retryNextDelayMultiplier: 2.0,
+		retryMaxDelay:            2 * time.Millisecond,
+		retryAttempts:            3,
+	}
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName:              "ais",
+			backendTypeSpecifics: backendAIStore,
+		},
+	}
+
+	err = aisContext.withRetry(func(baseParams api.BaseParams) (err error) {
+		opCalls++
+		if opCalls < 3 {
+			err = &cmn.ErrHTTP{Status: http.StatusServiceUnavailable}
+		}
+		return
+	}, api.BaseParams{})
+	if err != nil {
+		t.Fatalf("withRetry() unexpectedly failed: %v", err)
+	}
+	if opCalls != 3 {
+		t.Fatalf("withRetry() invoked op %v times (expected 3)", opCalls)
+	}
+
+	opCalls = 0
+
+	err = aisContext.withRetry(func(baseParams api.BaseParams) (err error) {
+		opCalls++
+		err = &cmn.ErrHTTP{Status: http.StatusNotFound}
+		return
+	}, api.BaseParams{})
+	if err == nil {
+		t.Fatalf("withRetry() unexpectedly succeeded")
+	}
+	if opCalls != 1 {
+		t.Fatalf("withRetry() invoked op %v times for a 404 (expected 1)", opCalls)
+	}
+}
+
+func TestAIStoreBucket(t *testing.T) {
+	var (
+		backendAIStore = &backendConfigAIStoreStruct{
+			provider:      "s3",
+			namespaceName: "ns",
+		}
+		bck cmn.Bck
+		err error
+	)
+
+	bck, err = backendAIStore.bucket("dev")
+	if err != nil {
+		t.Fatalf("bucket(\"dev\") failed: %v", err)
+	}
+	if (bck.Name != "dev") || (bck.Provider != "s3") || (bck.Ns.Name != "ns") {
+		t.Fatalf("bucket(\"dev\") returned %+v", bck)
+	}
+
+	bck, err = backendAIStore.bucket("ais://@uuid#other/dev")
+	if err != nil {
+		t.Fatalf("bucket(\"ais://@uuid#other/dev\") failed: %v", err)
+	}
+	if (bck.Name != "dev") || (bck.Provider != "ais") || (bck.Ns.UUID != "uuid") || (bck.Ns.Name != "other") {
+		t.Fatalf("bucket(\"ais://@uuid#other/dev\") returned %+v", bck)
+	}
+
+	_, err = backendAIStore.bucket("aws://dev/object")
+	if err == nil {
+		t.Fatalf("bucket(\"aws://dev/object\") unexpectedly succeeded")
+	}
+}
+
+func TestAIStorePropsCache(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		backendAIStore *backendConfigAIStoreStruct
+		err            error
+		headCalls      atomic.Int32
+		props          *cmn.ObjectProps
+		testServer     *httptest.Server
+	)
+
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			headCalls.Add(1)
+		}
+		w.Header().Set(apc.HdrObjCksumType, cos.ChecksumOneXxh)
+		w.Header().Set(apc.HdrObjCksumVal, "abc")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	backendAIStore = &backendConfigAIStoreStruct{
+		propsCacheTTL: time.Minute,
+		retryAttempts: 1,
+	}
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName:              "ais",
+			backendTypeSpecifics: backendAIStore,
+		},
+		baseParams: api.BaseParams{
+			Client: testServer.Client(),
+			URL:    testServer.URL,
+		},
+		bck:        cmn.Bck{Name: "dev", Provider: apc.AIS},
+		propsCache: make(map[string]*aistorePropsCacheEntryStruct),
+	}
+
+	for _, useCache := range []bool{true, true, false, true} {
+		props, err = aisContext.headObject("obj", useCache)
+		if err != nil {
+			t.Fatalf("headObject(\"obj\", %v) failed: %v", useCache, err)
+		}
+		if (props.Cksum == nil) || (props.Cksum.Value() != "abc") {
+			t.Fatalf("headObject(\"obj\", %v) returned unexpected props %+v", useCache, props)
+		}
+	}
+	if headCalls.Load() != 2 {
+		t.Fatalf("headObject() issued %v HEADs (expected 2)", headCalls.Load())
+	}
+
+	backendAIStore.propsCacheTTL = 0
+
+	_, err = aisContext.headObject("obj", true)
+	if err != nil {
+		t.Fatalf("headObject(\"obj\", true) with props_cache_ttl == 0 failed: %v", err)
+	}
+	if headCalls.Load() != 3 {
+		t.Fatalf("headObject() with props_cache_ttl == 0 issued %v HEADs (expected 3)", headCalls.Load())
+	}
+}
+
+func TestAIStoreETL(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		err            error
+		etlArgs        string
+		etlName        string
+		heads          atomic.Int32
+		rangeHeader    string
+		readFileOutput *readFileOutputStruct
+		testServer     *httptest.Server
+		transformed    = []byte("TRANSFORMED-0123456789")
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// The proxy answers a GET with the transform of the whole object (ignoring any Range)
+
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+			w.Header().Set(apc.HdrObjCksumType, cos.ChecksumOneXxh)
+			w.Header().Set(apc.HdrObjCksumVal, "untransformed")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		etlName = r.URL.Query().Get(apc.QparamETLName)
+		etlArgs = r.URL.Query().Get(apc.QparamETLTransformArgs)
+		rangeHeader = r.Header.Get(cos.HdrRange)
+		w.Header().Set(apc.HdrObjCksumType, cos.ChecksumOneXxh)
+		w.Header().Set(apc.HdrObjCksumVal, "untransformed")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(transformed)
+	}))
+	defer testServer.Close()
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName: "ais",
+			backendTypeSpecifics: &backendConfigAIStoreStruct{
+				etlName:       "md5",
+				etlArgs:       "arg",
+				retryAttempts: 1,
+			},
+		},
+		baseParams: api.BaseParams{
+			Client: testServer.Client(),
+			URL:    testServer.URL,
+		},
+		bck:        cmn.Bck{Name: "dev", Provider: apc.AIS},
+		propsCache: make(map[string]*aistorePropsCacheEntryStruct),
+	}
+
+	// The second cache line of the transformed contents is read whole (with no eTag to validate against)
+
+	readFileOutput, err = aisContext.readFile(&readFileInputStruct{
+		filePath:        "obj",
+		offsetCacheLine: 1,
+		cacheLineSize:   8,
+		ifMatch:         "stale",
+		ifNoneMatch:     "untransformed",
+	})
+	if err != nil {
+		t.Fatalf("readFile() failed: %v", err)
+	}
+	if (etlName != "md5") || (etlArgs != "arg") || (rangeHeader != "") {
+		t.Fatalf("readFile() issued GET with etl_name \"%s\", etl_args \"%s\", and Range \"%s\" (expected \"md5\", \"arg\", and \"\")", etlName, etlArgs, rangeHeader)
+	}
+	if heads.Load() != 0 {
+		t.Fatalf("readFile() issued %v HEADs (expected 0)", heads.Load())
+	}
+	if (string(readFileOutput.buf) != string(transformed[8:16])) || (readFileOutput.eTag != "") || readFileOutput.notModified {
+		t.Fatalf("readFile() returned %+v (expected buf \"%s\" and no eTag)", readFileOutput, transformed[8:16])
+	}
+
+	// A cache line extending beyond the end of the transformed contents is truncated
+
+	readFileOutput, err = aisContext.readFile(&readFileInputStruct{
+		filePath:        "obj",
+		offsetCacheLine: 2,
+		cacheLineSize:   8,
+	})
+	if (err != nil) || (string(readFileOutput.buf) != string(transformed[16:])) {
+		t.Fatalf("readFile() of final cache line returned %+v (err: %v)", readFileOutput, err)
+	}
+}
+
+func TestAIStoreBlobDownload(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		backendAIStore *backendConfigAIStoreStruct
+		content        = []byte("0123456789")
+		err            error
+		objects        = map[string]struct {
+			size    int
+			present bool
+		}{
+			"small":   {10, false},
+			"huge":    {1000, false},
+			"cached":  {1000, true},
+			"failing": {1000, false},
+		}
+		readFileOutput *readFileOutputStruct
+		requests       []string
+		requestsLock   sync.Mutex
+		testServer     *httptest.Server
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// The proxy reports each object's size & presence, accepts blob downloads (other than
+	// of "failing"), and answers each GET with content
+
+	testServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			actMsg  apc.ActMsg
+			objName = path.Base(r.URL.Path)
+		)
+
+		switch r.Method {
+		case http.MethodHead:
+			requestsLock.Lock()
+			requests = append(requests, "HEAD "+objName)
+			requestsLock.Unlock()
+			w.Header().Set(cos.HdrContentLength, strconv.Itoa(objects[objName].size))
+			w.Header().Set(apc.PropToHeader("present"), strconv.FormatBool(objects[objName].present))
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&actMsg)
+			requestsLock.Lock()
+			requests = append(requests, "BLOB "+actMsg.Name)
+			requestsLock.Unlock()
+			if actMsg.Name == "failing" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte("xid"))
+		default:
+			w.Header().Set(apc.HdrObjCksumType, cos.ChecksumOneXxh)
+			w.Header().Set(apc.HdrObjCksumVal, "abc")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content)
+		}
+	}))
+	defer testServer.Close()
+
+	backendAIStore = &backendConfigAIStoreStruct{
+		blobDownloadThreshold: 100,
+		blobDownloadChunkSize: 10,
+		blobDownloadWorkers:   2,
+		retryAttempts:         1,
+	}
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName:              "ais",
+			backendTypeSpecifics: backendAIStore,
+		},
+		baseParams: api.BaseParams{
+			Client: testServer.Client(),
+			URL:    testServer.URL,
+		},
+		bck:                 cmn.Bck{Name: "dev", Provider: apc.AWS},
+		blobDownloadChecked: make(map[string]struct{}),
+		propsCache:          make(map[string]*aistorePropsCacheEntryStruct),
+	}
+
+	// Only objects absent from the cluster & at least blob_download_threshold in size are blob
+	// downloaded (each considered but once), while a failed blob download leaves the read unaffected
+
+	for _, objName := range []string{"small", "huge", "cached", "huge", "failing"} {
+		readFileOutput, err = aisContext.readFile(&readFileInputStruct{
+			filePath:      objName,
+			cacheLineSize: 16,
+		})
+		if (err != nil) || (string(readFileOutput.buf) != string(content)) {
+			t.Fatalf("readFile(\"%s\") returned %+v (err: %v)", objName, readFileOutput, err)
+		}
+	}
+
+	if strings.Join(requests, ",") != "HEAD small,HEAD huge,BLOB huge,HEAD cached,HEAD failing,BLOB failing" {
+		t.Fatalf("readFile() issued requests %v", requests)
+	}
+
+	// Objects of a bucket that is not remote are never blob downloaded
+
+	requests = nil
+	aisContext.bck = cmn.Bck{Name: "dev", Provider: apc.AIS}
+
+	aisContext.blobDownload("other")
+
+	if len(requests) != 0 {
+		t.Fatalf("blobDownload() of an ais:// bucket's object issued requests %v", requests)
+	}
+}
+
+func TestAIStoreDirectTargetReads(t *testing.T) {
+	var (
+		aisContext     *aistoreContextStruct
+		err            error
+		proxyGets      atomic.Int32
+		proxyServer    *httptest.Server
+		readFileOutput *readFileOutputStruct
+		smapFetches    atomic.Int32
+		targetGets     atomic.Int32
+		targetServer   *httptest.Server
+		targetStatus   atomic.Int32
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// The target answers each GET (unless targetStatus says otherwise) as does the proxy,
+	// whose cluster map places every object on that target
+
+	targetStatus.Store(http.StatusOK)
+
+	targetServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targetGets.Add(1)
+		if targetStatus.Load() != http.StatusOK {
+			w.WriteHeader(int(targetStatus.Load()))
+			return
+		}
+		_, _ = w.Write([]byte("target"))
+	}))
+	defer targetServer.Close()
+
+	proxyServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == apc.URLPathDae.S {
+			smapFetches.Add(1)
+			_ = json.NewEncoder(w).Encode(&meta.Smap{
+				Tmap: meta.NodeMap{
+					"t1": &meta.Snode{
+						DaeID:   "t1",
+						DaeType: apc.Target,
+						PubNet:  meta.NetInfo{URL: targetServer.URL},
+					},
+				},
+			})
+			return
+		}
+		proxyGets.Add(1)
+		_, _ = w.Write([]byte("proxy"))
+	}))
+	defer proxyServer.Close()
+
+	aisContext = &aistoreContextStruct{
+		backend: &backendStruct{
+			dirName: "ais",
+			backendTypeSpecifics: &backendConfigAIStoreStruct{
+				directTargetReads: true,
+				clusterMapTTL:     time.Minute,
+				retryAttempts:     1,
+			},
+		},
+		baseParams: api.BaseParams{
+			Client: proxyServer.Client(),
+			URL:    proxyServer.URL,
+		},
+		bck:        cmn.Bck{Name: "dev", Provider: apc.AIS},
+		propsCache: make(map[string]*aistorePropsCacheEntryStruct),
+	}
+
+	readFile := func() (readFileOutput *readFileOutputStruct, err error) {
+		readFileOutput, err = aisContext.readFile(&readFileInputStruct{
+			filePath:      "obj",
+			cacheLineSize: 16,
+		})
+		return
+	}
+
+	// Reads go directly to the target located via the (cached) cluster map
+
+	for range 2 {
+		readFileOutput, err = readFile()
+		if (err != nil) || (string(readFileOutput.buf) != "target") {
+			t.Fatalf("readFile() returned %+v (err: %v) (expected \"target\")", readFileOutput, err)
+		}
+	}
+	if (smapFetches.Load() != 1) || (targetGets.Load() != 2) || (proxyGets.Load() != 0) {
+		t.Fatalf("readFile() issued %v cluster map fetches, %v target GETs, and %v proxy GETs (expected 1, 2, and 0)", smapFetches.Load(), targetGets.Load(), proxyGets.Load())
+	}
+
+	// A target failing with a 5xx is bypassed via the proxy (and the cluster map refetched next time)
+
+	targetStatus.Store(http.StatusServiceUnavailable)
+
+	readFileOutput, err = readFile()
+	if (err != nil) || (string(readFileOutput.buf) != "proxy") {
+		t.Fatalf("readFile() with target failing returned %+v (err: %v) (expected \"proxy\")", readFileOutput, err)
+	}
+	if (targetGets.Load() != 3) || (proxyGets.Load() != 1) || (aisContext.smap != nil) {
+		t.Fatalf("readFile() with target failing issued %v target GETs and %v proxy GETs (expected 3 and 1) leaving smap %v", targetGets.Load(), proxyGets.Load(), aisContext.smap)
+	}
+
+	// A target responding with a 4xx is believed (rather than bypassed)
+
+	targetStatus.Store(http.StatusNotFound)
+
+	_, err = readFile()
+	if err == nil {
+		t.Fatalf("readFile() with target responding 404 unexpectedly succeeded")
+	}
+	if (smapFetches.Load() != 2) || (targetGets.Load() != 4) || (proxyGets.Load() != 1) {
+		t.Fatalf("readFile() with target responding 404 issued %v cluster map fetches, %v target GETs, and %v proxy GETs (expected 2, 4, and 1)", smapFetches.Load(), targetGets.Load(), proxyGets.Load())
+	}
+
+	// An unreachable target is bypassed via the proxy
+
+	targetServer.Close()
+
+	readFileOutput, err = readFile()
+	if (err != nil) || (string(readFileOutput.buf) != "proxy") {
+		t.Fatalf("readFile() with target unreachable returned %+v (err: %v) (expected \"proxy\")", readFileOutput, err)
+	}
+	if proxyGets.Load() != 2 {
+		t.Fatalf("readFile() with target unreachable issued %v proxy GETs (expected 2)", proxyGets.Load())
+	}
+
+	for _, testCase := range []struct {
+		err         error
+		unreachable bool
+	}{
+		{nil, false},
+		{&cmn.ErrHTTP{Status: http.StatusNotFound}, false},
+		{&cmn.ErrHTTP{Status: http.StatusServiceUnavailable}, true},
+		{&cmn.ErrHTTP{Status: http.StatusBadRequest, TypeCode: "ErrInvalidObjName"}, false},
+		{&cmn.ErrHTTP{Status: http.StatusBadRequest, TypeCode: "OpError"}, true},
+		{errors.New("connection refused"), true},
+	} {
+		if aistoreTargetUnreachable(testCase.err) != testCase.unreachable {
+			t.Fatalf("aistoreTargetUnreachable(%v) returned %v", testCase.err, !testCase.unreachable)
+		}
+	}
+}
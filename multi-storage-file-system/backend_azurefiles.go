@@ -0,0 +1,909 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// `azureFilesContextStruct` holds the Azure Files-specific backend details. As with the
+// Dropbox backend, no SDK is used: each operation is one (or a few) requests to the File
+// service REST API of the share named by bucket_container_name.
+type azureFilesContextStruct struct {
+	sync.Mutex                // Protects backendConfigAzureFilesStruct.{account_key|sas_token}
+	backend    *backendStruct //
+	httpClient *http.Client   //
+}
+
+// `azureFilesVersion` is the x-ms-version of each request. It is the earliest version
+// accepting include=Timestamps,ETag on listings that also defaults each SMB property
+// (attributes, creation/last write times, & permission) of created files and directories.
+const azureFilesVersion = "2021-06-08"
+
+// `azureFilesPutRangeMax` is the largest range that a single Put Range request may write.
+const azureFilesPutRangeMax = 4 * 1024 * 1024
+
+// `azureFilesListMaxResults` caps the maxresults of each List Directories and Files request.
+const azureFilesListMaxResults = 5000
+
+// `azureFilesErrorBodyMax` caps how much of a failed response's body is read for its message.
+const azureFilesErrorBodyMax = 4096
+
+// `azureFilesListResultStruct` is the (subset used of the) XML result of List Directories
+// and Files. Directories are listed by name alone while files also report the properties
+// requested by include=Timestamps,ETag.
+type azureFilesListResultStruct struct {
+	Files []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			ContentLength uint64 `xml:"Content-Length"`
+			LastWriteTime string `xml:"LastWriteTime"`
+			LastModified  string `xml:"Last-Modified"`
+			ETag          string `xml:"Etag"`
+		} `xml:"Properties"`
+	} `xml:"Entries>File"`
+	Directories []struct {
+		Name string `xml:"Name"`
+	} `xml:"Entries>Directory"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// `azureFilesErrorStruct` is the (XML) body of a failed response (other than to a HEAD).
+type azureFilesErrorStruct struct {
+	Code string `xml:"Code"`
+}
+
+// `azureFilesListObjectsTokenStruct` is the (JSON-encoded) continuation token of listObjects().
+// As the File service cannot list recursively, the directories remaining to be listed (the
+// first of which being that currently listed and, if Marker != "", partially so) are tracked.
+type azureFilesListObjectsTokenStruct struct {
+	Dirs   []string `json:"dirs"`   // Each relative to backend.prefix and, if != "", ending in "/"
+	Marker string   `json:"marker"` // If != "", the NextMarker of Dirs[0]'s most recent listing
+}
+
+// `backendCommon` is called to return a pointer to the context's common `backendStruct`.
+func (azureFilesContext *azureFilesContextStruct) backendCommon() (backendCommon *backendStruct) {
+	backendCommon = azureFilesContext.backend
+	return
+}
+
+// `setupAzureFilesContext` establishes the Azure Files client context. Once set up, each
+// method defined in the `backendConfigIf` interface may be invoked.
+// Note that there is no `destroyContext` counterpart.
+func (backend *backendStruct) setupAzureFilesContext() (err error) {
+	var (
+		backendAzureFiles = backend.backendTypeSpecifics.(*backendConfigAzureFilesStruct)
+		transport         = &http.Transport{}
+	)
+
+	_, _, err = resolveSecrets(backendAzureFiles.accountKey, backendAzureFiles.sasToken)
+	if err != nil {
+		err = fmt.Errorf("[AzureFiles] %v", err)
+		return
+	}
+
+	backend.applyHTTPTransportOptions(transport)
+
+	backend.context = &azureFilesContextStruct{
+		backend: backend,
+		httpClient: &http.Client{
+			Timeout:   backendAzureFiles.timeout,
+			Transport: transport,
+		},
+	}
+
+	backend.backendPath = backendAzureFiles.endpoint + "/" + backend.bucketContainerName + "/" + backend.prefix
+
+	return
+}
+
+// `rotateCredentials` replaces the account_key and sas_token settings with those of
+// backendAzureFilesNew such that subsequent requests are authorized by them.
+func (azureFilesContext *azureFilesContextStruct) rotateCredentials(backendAzureFilesNew *backendConfigAzureFilesStruct) {
+	var (
+		backendAzureFiles = azureFilesContext.backend.backendTypeSpecifics.(*backendConfigAzureFilesStruct)
+	)
+
+	azureFilesContext.Lock()
+	backendAzureFiles.accountKey = backendAzureFilesNew.accountKey
+	backendAzureFiles.sasToken = backendAzureFilesNew.sasToken
+	azureFilesContext.Unlock()
+}
+
+// `azureFilesURL` returns the URL of path (relative to backend.prefix) within the share with
+// each of its components escaped. As the File service's paths never end in "/", any
+// trailing "/" (e.g. of a directory's path) is removed. The share's root directory is
+// addressed by the URL of the share itself.
+func (azureFilesContext *azureFilesContextStruct) azureFilesURL(path string) (rawURL string) {
+	var (
+		backend           = azureFilesContext.backend
+		backendAzureFiles = backend.backendTypeSpecifics.(*backendConfigAzureFilesStruct)
+		component         string
+		sb                strings.Builder
+	)
+
+	_, _ = sb.WriteString(backendAzureFiles.endpoint)
+	_ = sb.WriteByte('/')
+	_, _ = sb.WriteString(url.PathEscape(backend.bucketContainerName))
+
+	path = strings.TrimSuffix(backend.prefix+path, "/")
+	if path != "" {
+		for _, component = range strings.Split(path, "/") {
+			_ = sb.WriteByte('/')
+			_, _ = sb.WriteString(url.PathEscape(component))
+		}
+	}
+
+	rawURL = sb.String()
+
+	return
+}
+
+// `azureFilesSharedKey` returns the Authorization header value of req signed by accountKey
+// (base64-encoded) of accountName per the Shared Key scheme of the Azure Storage services.
+func azureFilesSharedKey(req *http.Request, accountName string, accountKey string) (authorization string, err error) {
+	var (
+		canonicalizedHeaders []string
+		contentLength        string
+		headerName           string
+		headerValue          string
+		key                  []byte
+		mac                  hash.Hash
+		query                = req.URL.Query()
+		queryNames           []string
+		queryName            string
+		queryValues          []string
+		sb                   strings.Builder
+	)
+
+	key, err = base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		err = fmt.Errorf("account_key is not base64-encoded: %v", err)
+		return
+	}
+
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	for _, headerValue = range []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date (superseded by x-ms-date)
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	} {
+		_, _ = sb.WriteString(headerValue)
+		_ = sb.WriteByte('\n')
+	}
+
+	for headerName = range req.Header {
+		headerName = strings.ToLower(headerName)
+		if strings.HasPrefix(headerName, "x-ms-") {
+			canonicalizedHeaders = append(canonicalizedHeaders, headerName)
+		}
+	}
+	sort.Strings(canonicalizedHeaders)
+	for _, headerName = range canonicalizedHeaders {
+		_, _ = fmt.Fprintf(&sb, "%s:%s\n", headerName, strings.TrimSpace(req.Header.Get(headerName)))
+	}
+
+	_, _ = fmt.Fprintf(&sb, "/%s%s", accountName, req.URL.EscapedPath())
+
+	for queryName = range query {
+		queryNames = append(queryNames, queryName)
+	}
+	sort.Strings(queryNames)
+	for _, queryName = range queryNames {
+		queryValues = query[queryName]
+		sort.Strings(queryValues)
+		_, _ = fmt.Fprintf(&sb, "\n%s:%s", strings.ToLower(queryName), strings.Join(queryValues, ","))
+	}
+
+	mac = hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(sb.String()))
+
+	authorization = "SharedKey " + accountName + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return
+}
+
+// `azureFilesErrno` returns the syscall.Errno best describing a response of statusCode
+// whose x-ms-error-code is errorCode.
+func azureFilesErrno(statusCode int, errorCode string) (errno syscall.Errno) {
+	switch errorCode {
+	case "ResourceNotFound", "ParentNotFound", "ShareNotFound":
+		errno = syscall.ENOENT
+	case "ResourceAlreadyExists", "ResourceTypeMismatch":
+		errno = syscall.EEXIST
+	case "DirectoryNotEmpty":
+		errno = syscall.ENOTEMPTY
+	case "ShareSizeLimitReached":
+		errno = syscall.ENOSPC
+	case "SharingViolation", "ShareBeingDeleted", "ShareDisabled":
+		errno = syscall.EBUSY
+	case "InvalidRange":
+		errno = syscall.EINVAL
+	case "ConditionNotMet":
+		errno = syscall.ESTALE
+	default:
+		switch statusCode {
+		case http.StatusBadRequest:
+			errno = syscall.EINVAL
+		case http.StatusUnauthorized, http.StatusForbidden:
+			errno = syscall.EACCES
+		case http.StatusNotFound:
+			errno = syscall.ENOENT
+		case http.StatusConflict:
+			errno = syscall.EEXIST
+		case http.StatusPreconditionFailed:
+			errno = syscall.ESTALE
+		case http.StatusRequestedRangeNotSatisfiable:
+			errno = syscall.EINVAL
+		case http.StatusServiceUnavailable:
+			errno = syscall.EAGAIN
+		default:
+			errno = syscall.EIO
+		}
+	}
+
+	return
+}
+
+// `do` issues the request built by newRequest (called anew for each attempt) authorized by
+// account_key (via Shared Key) or, if account_key == "", sas_token (appended to its query).
+// Throttling (503), server (5xx), and transport failures are retried per the
+// retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} settings (unless shed by the
+// retry budget, if any). Should authorization fail (403) while account_key or sas_token
+// references a secret, the secret is fetched anew and the request reissued once. Only a
+// successful (2xx) response is returned (its body to be closed by the caller). The operation
+// op names the request in any error returned.
+func (azureFilesContext *azureFilesContextStruct) do(op string, newRequest func() (req *http.Request, err error)) (resp *http.Response, err error) {
+	var (
+		accountKey        string
+		attempt           int
+		authorization     string
+		azureFilesError   azureFilesErrorStruct
+		backend           = azureFilesContext.backend
+		backendAzureFiles = backend.backendTypeSpecifics.(*backendConfigAzureFilesStruct)
+		body              []byte
+		credentialRef     string
+		errorCode         string
+		refreshed         bool
+		req               *http.Request
+		resolved          []string
+		retryDelay        = backendAzureFiles.retryBaseDelay
+		sasToken          string
+		statusCode        int
+	)
+
+	for attempt = 1; ; attempt++ {
+		azureFilesContext.Lock()
+		accountKey = backendAzureFiles.accountKey
+		sasToken = backendAzureFiles.sasToken
+		azureFilesContext.Unlock()
+
+		resolved, _, err = resolveSecrets(accountKey, sasToken)
+		if err != nil {
+			err = fmt.Errorf("[AzureFiles] %s failed: %v", op, err)
+			return
+		}
+
+		req, err = newRequest()
+		if err != nil {
+			err = fmt.Errorf("[AzureFiles] %s failed: %v", op, err)
+			return
+		}
+
+		req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+		req.Header.Set("x-ms-version", azureFilesVersion)
+
+		if resolved[0] != "" {
+			credentialRef = accountKey
+			authorization, err = azureFilesSharedKey(req, backendAzureFiles.accountName, resolved[0])
+			if err != nil {
+				err = fmt.Errorf("[AzureFiles] %s failed: %v", op, err)
+				return
+			}
+			req.Header.Set("Authorization", authorization)
+		} else {
+			credentialRef = sasToken
+			if req.URL.RawQuery == "" {
+				req.URL.RawQuery = strings.TrimPrefix(resolved[1], "?")
+			} else {
+				req.URL.RawQuery += "&" + strings.TrimPrefix(resolved[1], "?")
+			}
+		}
+
+		resp, err = azureFilesContext.httpClient.Do(req)
+		if err != nil {
+			statusCode = 0
+			err = fmt.Errorf("[AzureFiles] %s failed: %w", op, err)
+		} else if resp.StatusCode < http.StatusMultipleChoices {
+			return
+		} else {
+			statusCode = resp.StatusCode
+			errorCode = resp.Header.Get("x-ms-error-code")
+			body, _ = io.ReadAll(io.LimitReader(resp.Body, azureFilesErrorBodyMax))
+			_ = resp.Body.Close()
+			resp = nil
+			azureFilesError = azureFilesErrorStruct{}
+			if (xml.Unmarshal(body, &azureFilesError) == nil) && (errorCode == "") {
+				errorCode = azureFilesError.Code
+			}
+			if errorCode == "" {
+				errorCode = http.StatusText(statusCode)
+			}
+			err = fmt.Errorf("[AzureFiles] %s failed (HTTP %d): %s: %w", op, statusCode, errorCode, azureFilesErrno(statusCode, errorCode))
+		}
+
+		if (statusCode == http.StatusForbidden) && !refreshed && isSecretRef(credentialRef) {
+			// The referenced secret may since have been rotated, so fetch it anew
+
+			refreshed = true
+			invalidateSecret(credentialRef)
+			attempt--
+			continue
+		}
+
+		if ((statusCode != 0) && (statusCode < http.StatusInternalServerError)) || (attempt >= backendAzureFiles.retryAttempts) {
+			return
+		}
+
+		if !globals.retryBudget.withdraw() {
+			err = fmt.Errorf("retry budget exhausted: %w", err)
+			return
+		}
+
+		backend.retries.Add(1)
+		if statusCode == http.StatusServiceUnavailable {
+			backend.throttles.Add(1)
+		}
+
+		time.Sleep(min(retryDelay, backendAzureFiles.retryMaxDelay))
+
+		retryDelay = time.Duration(float64(retryDelay) * backendAzureFiles.retryNextDelayMultiplier)
+	}
+}
+
+// `request` issues a request (per do()) of method to the URL of path (relative to
+// backend.prefix) to which query (if != "") is appended. Should body be != nil, it is
+// sent as the request's content. Each of headers (pairs of header names and values) is set.
+func (azureFilesContext *azureFilesContextStruct) request(op string, method string, path string, query string, body []byte, headers ...string) (resp *http.Response, err error) {
+	var (
+		rawURL = azureFilesContext.azureFilesURL(path)
+	)
+
+	if query != "" {
+		rawURL += "?" + query
+	}
+
+	resp, err = azureFilesContext.do(op, func() (req *http.Request, err error) {
+		var (
+			headerIndex int
+		)
+
+		if body == nil {
+			req, err = http.NewRequest(method, rawURL, nil)
+		} else {
+			req, err = http.NewRequest(method, rawURL, bytes.NewReader(body))
+		}
+		if err != nil {
+			return
+		}
+		for headerIndex = 0; (headerIndex + 1) < len(headers); headerIndex += 2 {
+			req.Header.Set(headers[headerIndex], headers[headerIndex+1])
+		}
+		return
+	})
+
+	return
+}
+
+// `azureFilesETag` returns eTag (as reported by the File service) less its quotes.
+func azureFilesETag(eTag string) string {
+	return strings.Trim(eTag, "\"")
+}
+
+// `azureFilesMTime` returns the modification time of a file reported as its (SMB) last
+// write time lastWriteTime (RFC 3339) or, should that be absent, as lastModified (RFC 1123).
+func azureFilesMTime(lastWriteTime string, lastModified string) (mTime time.Time) {
+	var (
+		err error
+	)
+
+	if lastWriteTime != "" {
+		mTime, err = time.Parse(time.RFC3339Nano, lastWriteTime)
+		if err == nil {
+			return
+		}
+	}
+
+	mTime, _ = http.ParseTime(lastModified)
+
+	return
+}
+
+// `getFileProperties` fetches the properties of the file at path (relative to backend.prefix)
+// via Get File Properties. Should a directory be found there instead, an error wrapping
+// syscall.ENOENT is returned (as would an object store should a "file" be looked for
+// where there is a "directory").
+func (azureFilesContext *azureFilesContextStruct) getFileProperties(path string) (statFileOutput *statFileOutputStruct, err error) {
+	var (
+		resp *http.Response
+	)
+
+	resp, err = azureFilesContext.request("GetFileProperties", http.MethodHead, path, "", nil)
+	if err != nil {
+		return
+	}
+
+	_ = resp.Body.Close()
+
+	if (resp.Header.Get("x-ms-type") != "") && !strings.EqualFold(resp.Header.Get("x-ms-type"), "File") {
+		err = fmt.Errorf("[AzureFiles] %s is not a file: %w", path, syscall.ENOENT)
+		return
+	}
+
+	statFileOutput = &statFileOutputStruct{
+		eTag:  azureFilesETag(resp.Header.Get("ETag")),
+		mTime: azureFilesMTime(resp.Header.Get("x-ms-file-last-write-time"), resp.Header.Get("Last-Modified")),
+		size:  uint64(max(resp.ContentLength, 0)),
+	}
+
+	return
+}
+
+// `listDirectoryPage` fetches the page of the directory at dirPath (relative to backend.prefix)
+// beginning at marker (if != "") of at most maxResults (if != 0) entries.
+func (azureFilesContext *azureFilesContextStruct) listDirectoryPage(dirPath string, marker string, maxResults uint64) (listResult *azureFilesListResultStruct, err error) {
+	var (
+		query = url.Values{
+			"restype": []string{"directory"},
+			"comp":    []string{"list"},
+			"include": []string{"Timestamps,ETag"},
+		}
+		resp *http.Response
+	)
+
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+	if maxResults != 0 {
+		query.Set("maxresults", strconv.FormatUint(min(maxResults, azureFilesListMaxResults), 10))
+	}
+
+	resp, err = azureFilesContext.request("ListDirectoriesAndFiles", http.MethodGet, dirPath, query.Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	listResult = &azureFilesListResultStruct{}
+
+	err = xml.NewDecoder(resp.Body).Decode(listResult)
+	if err != nil {
+		listResult = nil
+		err = fmt.Errorf("[AzureFiles] ListDirectoriesAndFiles returned an undecodable result: %v", err)
+	}
+
+	return
+}
+
+// `createParentDirectories` creates each (missing) directory along path (relative to
+// backend.prefix) but not path itself. As the File service's directories are real, a file
+// may only be created once each of its ancestors exists. Note that the directory at
+// backend.prefix (like the share itself) must already exist.
+func (azureFilesContext *azureFilesContextStruct) createParentDirectories(path string) (err error) {
+	var (
+		resp      *http.Response
+		searchPos int
+		slashPos  int
+	)
+
+	path = strings.TrimSuffix(path, "/")
+
+	for {
+		slashPos = strings.IndexByte(path[searchPos:], '/')
+		if slashPos < 0 {
+			return
+		}
+
+		searchPos += slashPos + 1
+
+		resp, err = azureFilesContext.request("CreateDirectory", http.MethodPut, path[:searchPos], "restype=directory", nil)
+		if err == nil {
+			_ = resp.Body.Close()
+		} else if backendErrno(err) != syscall.EEXIST {
+			return
+		}
+	}
+}
+
+// `deleteFile` is called to remove a "file" at the specified path. As Delete File is not
+// conditional, should ifMatch be != "", the file's eTag is verified first (leaving a window
+// in which it may be replaced). If the path ends in "/", the (real) directory at that path
+// is instead removed should it be empty (as with a directory marker of an object store).
+func (azureFilesContext *azureFilesContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
+	var (
+		resp           *http.Response
+		statFileOutput *statFileOutputStruct
+	)
+
+	if strings.HasSuffix(deleteFileInput.filePath, "/") {
+		resp, err = azureFilesContext.request("DeleteDirectory", http.MethodDelete, deleteFileInput.filePath, "restype=directory", nil)
+	} else {
+		if deleteFileInput.ifMatch != "" {
+			statFileOutput, err = azureFilesContext.getFileProperties(deleteFileInput.filePath)
+			if err != nil {
+				return
+			}
+			if statFileOutput.eTag != azureFilesETag(deleteFileInput.ifMatch) {
+				err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+				return
+			}
+		}
+
+		resp, err = azureFilesContext.request("DeleteFile", http.MethodDelete, deleteFileInput.filePath, "", nil)
+	}
+	if err != nil {
+		return
+	}
+
+	_ = resp.Body.Close()
+
+	deleteFileOutput = &deleteFileOutputStruct{}
+
+	return
+}
+
+// `deleteFiles` is called to remove the "files" at the specified paths. As the File service
+// offers no batch delete, each is removed in turn via deleteFile(). Paths at which nothing
+// is found are silently skipped.
+func (azureFilesContext *azureFilesContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		filePath string
+	)
+
+	for _, filePath = range deleteFilesInput.filePaths {
+		_, err = azureFilesContext.deleteFile(&deleteFileInputStruct{
+			filePath: filePath,
+		})
+		if (err != nil) && (backendErrno(err) != syscall.ENOENT) {
+			return
+		}
+	}
+
+	err = nil
+	deleteFilesOutput = &deleteFilesOutputStruct{}
+
+	return
+}
+
+// `listDirectory` is called to fetch a `page` of the `directory` at the specified path.
+// An empty continuationToken or empty list of directory elements (`subdirectories` and `files`)
+// indicates the `directory` has been completely enumerated. The `isTruncated` field will also
+// align with this convention. The NextMarker of List Directories and Files serves as the
+// continuation token.
+func (azureFilesContext *azureFilesContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		fileIndex         int
+		listResult        *azureFilesListResultStruct
+		subdirectoryIndex int
+	)
+
+	listResult, err = azureFilesContext.listDirectoryPage(listDirectoryInput.dirPath, listDirectoryInput.continuationToken, listDirectoryInput.maxItems)
+	if err != nil {
+		return
+	}
+
+	listDirectoryOutput = newListDirectoryOutput(listDirectoryInput, len(listResult.Directories), len(listResult.Files))
+
+	for subdirectoryIndex = range listResult.Directories {
+		listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, listResult.Directories[subdirectoryIndex].Name)
+	}
+
+	for fileIndex = range listResult.Files {
+		listDirectoryOutput.file = append(listDirectoryOutput.file, listDirectoryOutputFileStruct{
+			basename: listResult.Files[fileIndex].Name,
+			eTag:     azureFilesETag(listResult.Files[fileIndex].Properties.ETag),
+			mTime:    azureFilesMTime(listResult.Files[fileIndex].Properties.LastWriteTime, listResult.Files[fileIndex].Properties.LastModified),
+			size:     listResult.Files[fileIndex].Properties.ContentLength,
+		})
+	}
+
+	if listResult.NextMarker != "" {
+		listDirectoryOutput.nextContinuationToken = listResult.NextMarker
+		listDirectoryOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `listMultipartUploads` is called to fetch the multipart uploads in progress of `files` whose
+// paths begin with the specified prefix. The File service has no multipart uploads.
+func (azureFilesContext *azureFilesContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	err = fmt.Errorf("[AzureFiles] listMultipartUploads not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts
+// uploaded. As createMultipartUpload() is not supported, there are none to abort.
+func (azureFilesContext *azureFilesContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[AzureFiles] abortMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
+// empty list of elements (`objects`) indicates the list of `objects` has been completely
+// enumerated. The `isTruncated` field will also align with this convention. As the File
+// service cannot list recursively, the directory tree at backend.prefix is walked (listing
+// as many directories as needed for a non-empty page) with the continuation token recording
+// the directories yet to be (fully) listed.
+func (azureFilesContext *azureFilesContextStruct) listObjects(listObjectsInput *listObjectsInputStruct) (listObjectsOutput *listObjectsOutputStruct, err error) {
+	var (
+		dirPath           string
+		fileIndex         int
+		listResult        *azureFilesListResultStruct
+		subdirectoryIndex int
+		token             azureFilesListObjectsTokenStruct
+		tokenJSON         []byte
+	)
+
+	if listObjectsInput.continuationToken == "" {
+		token.Dirs = []string{""}
+	} else {
+		err = json.Unmarshal([]byte(listObjectsInput.continuationToken), &token)
+		if err != nil {
+			err = fmt.Errorf("[AzureFiles] bad listObjects continuationToken: %w", syscall.EINVAL)
+			return
+		}
+	}
+
+	listObjectsOutput = &listObjectsOutputStruct{}
+
+	for (len(token.Dirs) > 0) && (len(listObjectsOutput.object) == 0) {
+		dirPath = token.Dirs[0]
+
+		listResult, err = azureFilesContext.listDirectoryPage(dirPath, token.Marker, listObjectsInput.maxItems)
+		if err != nil {
+			listObjectsOutput = nil
+			return
+		}
+
+		for fileIndex = range listResult.Files {
+			listObjectsOutput.object = append(listObjectsOutput.object, listObjectsOutputObjectStruct{
+				path:  dirPath + listResult.Files[fileIndex].Name,
+				eTag:  azureFilesETag(listResult.Files[fileIndex].Properties.ETag),
+				mTime: azureFilesMTime(listResult.Files[fileIndex].Properties.LastWriteTime, listResult.Files[fileIndex].Properties.LastModified),
+				size:  listResult.Files[fileIndex].Properties.ContentLength,
+			})
+		}
+
+		for subdirectoryIndex = range listResult.Directories {
+			token.Dirs = append(token.Dirs, dirPath+listResult.Directories[subdirectoryIndex].Name+"/")
+		}
+
+		token.Marker = listResult.NextMarker
+		if token.Marker == "" {
+			token.Dirs = token.Dirs[1:]
+		}
+	}
+
+	if len(token.Dirs) > 0 {
+		tokenJSON, err = json.Marshal(&token)
+		if err != nil {
+			listObjectsOutput = nil
+			return
+		}
+		listObjectsOutput.nextContinuationToken = string(tokenJSON)
+		listObjectsOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `readFile` is called to read a range of a `file` at the specified path via a ranged
+// Get File. An error is returned if either the specified path is not a `file` or
+// non-existent. As Get File is not conditional, ifNoneMatch is checked by fetching the
+// file's properties first while ifMatch is checked against the ETag of what was read.
+func (azureFilesContext *azureFilesContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		eTag           string
+		rangeBegin     uint64
+		rangeSize      uint64
+		resp           *http.Response
+		statFileOutput *statFileOutputStruct
+	)
+
+	rangeBegin, rangeSize = readFileInput.byteRange()
+
+	if readFileInput.ifNoneMatch != "" {
+		statFileOutput, err = azureFilesContext.getFileProperties(readFileInput.filePath)
+		if err != nil {
+			return
+		}
+		if statFileOutput.eTag == azureFilesETag(readFileInput.ifNoneMatch) {
+			readFileOutput = &readFileOutputStruct{
+				eTag:        readFileInput.ifNoneMatch,
+				buf:         make([]byte, 0),
+				notModified: true,
+			}
+			return
+		}
+	}
+
+	resp, err = azureFilesContext.request("GetFile", http.MethodGet, readFileInput.filePath, "", nil, "x-ms-range", fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeBegin+rangeSize-1))
+	if err != nil {
+		return
+	}
+
+	eTag = azureFilesETag(resp.Header.Get("ETag"))
+
+	if (readFileInput.ifMatch != "") && (eTag != azureFilesETag(readFileInput.ifMatch)) {
+		_ = resp.Body.Close()
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	readFileOutput = &readFileOutputStruct{
+		eTag: eTag,
+	}
+
+	readFileOutput.buf, err = readS3Body(resp.Body, &resp.ContentLength, rangeSize)
+	if err != nil {
+		readFileOutput = nil
+		err = fmt.Errorf("[AzureFiles] GetFile failed: %v", err)
+	}
+
+	return
+}
+
+// `prefetchFiles` is called to hint that the "files" at the specified paths
+// are likely to be read soon. The File service offers no such facility, so this is a no-op.
+func (azureFilesContext *azureFilesContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	return
+}
+
+// `statDirectory` is called to verify that the specified path refers to a `directory`.
+// An error is returned if either the specified path is not a `directory` or non-existent.
+// As the File service's directories are real, Get Directory Properties suffices.
+func (azureFilesContext *azureFilesContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	var (
+		resp *http.Response
+	)
+
+	resp, err = azureFilesContext.request("GetDirectoryProperties", http.MethodHead, statDirectoryInput.dirPath, "restype=directory", nil)
+	if err != nil {
+		return
+	}
+
+	_ = resp.Body.Close()
+
+	statDirectoryOutput = &statDirectoryOutputStruct{}
+
+	return
+}
+
+// `statFile` is called to fetch the `file` metadata at the specified path.
+// An error is returned if either the specified path is not a `file` or non-existent.
+func (azureFilesContext *azureFilesContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	statFileOutput, err = azureFilesContext.getFileProperties(statFileInput.filePath)
+	if err != nil {
+		return
+	}
+
+	if (statFileInput.ifMatch != "") && (statFileOutput.eTag != azureFilesETag(statFileInput.ifMatch)) {
+		statFileOutput = nil
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+	}
+
+	return
+}
+
+// `writeFile` is called to create (or replace) the "file" at the specified path. The file is
+// created (at its full size, creating any missing parent directories) by Create File and its
+// content then written by a Put Range of (at most azureFilesPutRangeMax bytes) each. Note
+// that, until all have completed, readers may observe (zero-filled) unwritten ranges. A
+// zero-byte "file" whose path ends in "/" (i.e. a directory marker) instead creates a (real)
+// directory at that path.
+func (azureFilesContext *azureFilesContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		rangeBegin int
+		rangeEnd   int
+		resp       *http.Response
+	)
+
+	if strings.HasSuffix(writeFileInput.filePath, "/") && (len(writeFileInput.buf) == 0) {
+		err = azureFilesContext.createParentDirectories(writeFileInput.filePath)
+		if err != nil {
+			return
+		}
+		resp, err = azureFilesContext.request("CreateDirectory", http.MethodPut, writeFileInput.filePath, "restype=directory", nil)
+		if err == nil {
+			_ = resp.Body.Close()
+		} else if backendErrno(err) == syscall.EEXIST {
+			_, err = azureFilesContext.statDirectory(&statDirectoryInputStruct{dirPath: writeFileInput.filePath})
+		}
+		if err == nil {
+			writeFileOutput = &writeFileOutputStruct{}
+		}
+		return
+	}
+
+	resp, err = azureFilesContext.request("CreateFile", http.MethodPut, writeFileInput.filePath, "", nil, "x-ms-type", "file", "x-ms-content-length", strconv.Itoa(len(writeFileInput.buf)))
+	if (err != nil) && (backendErrno(err) == syscall.ENOENT) {
+		err = azureFilesContext.createParentDirectories(writeFileInput.filePath)
+		if err != nil {
+			return
+		}
+		resp, err = azureFilesContext.request("CreateFile", http.MethodPut, writeFileInput.filePath, "", nil, "x-ms-type", "file", "x-ms-content-length", strconv.Itoa(len(writeFileInput.buf)))
+	}
+	if err != nil {
+		return
+	}
+
+	_ = resp.Body.Close()
+
+	for rangeBegin = 0; rangeBegin < len(writeFileInput.buf); rangeBegin = rangeEnd {
+		rangeEnd = min(rangeBegin+azureFilesPutRangeMax, len(writeFileInput.buf))
+
+		resp, err = azureFilesContext.request("PutRange", http.MethodPut, writeFileInput.filePath, "comp=range", writeFileInput.buf[rangeBegin:rangeEnd], "x-ms-range", fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd-1), "x-ms-write", "update")
+		if err != nil {
+			return
+		}
+
+		_ = resp.Body.Close()
+	}
+
+	writeFileOutput = &writeFileOutputStruct{
+		eTag: azureFilesETag(resp.Header.Get("ETag")),
+	}
+
+	return
+}
+
+// `createMultipartUpload` is called to begin creating (or replacing) the `file` at the specified
+// path via a sequence of uploadPart() calls concluded by completeMultipartUpload(). As a file
+// must be created at its full size (unknown until all parts have been uploaded) before any of
+// its ranges may be written, this is not supported (and writeFile() must be used).
+func (azureFilesContext *azureFilesContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[AzureFiles] createMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `uploadPart` is called to upload one part of the specified multipart upload (not supported).
+func (azureFilesContext *azureFilesContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	err = fmt.Errorf("[AzureFiles] uploadPart not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `completeMultipartUpload` is called to conclude the specified multipart upload (not supported).
+func (azureFilesContext *azureFilesContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[AzureFiles] completeMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
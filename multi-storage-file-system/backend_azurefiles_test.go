@@ -0,0 +1,423 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// `testAzureFilesServerStruct` is a minimal fake of the File service REST API operations
+// used by the AzureFiles backend for the share "share" of account "account". Requests
+// must be signed by accountKey (via Shared Key) or bear sasToken.
+type testAzureFilesServerStruct struct {
+	sync.Mutex
+	accountKey string
+	sasToken   string
+	eTags      int
+	dirs       map[string]struct{} // Keyed by unescaped URL path (e.g. "/share/dir1")
+	files      map[string][]byte   // Keyed by unescaped URL path (e.g. "/share/dir1/fileA")
+	fileETags  map[string]string   // Keyed by unescaped URL path
+}
+
+func (server *testAzureFilesServerStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		authorization string
+		body          []byte
+		content       []byte
+		entries       strings.Builder
+		err           error
+		ok            bool
+		parentPath    string
+		path          = r.URL.Path
+		query         = r.URL.Query()
+		rangeBegin    int
+		rangeEnd      int
+		size          int
+		subPath       string
+		subPaths      []string
+		writeError    = func(statusCode int, errorCode string) {
+			w.Header().Set("x-ms-error-code", errorCode)
+			w.WriteHeader(statusCode)
+			if r.Method != http.MethodHead {
+				_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?><Error><Code>%s</Code><Message>...</Message></Error>", errorCode)
+			}
+		}
+	)
+
+	server.Lock()
+	defer server.Unlock()
+
+	if server.accountKey != "" {
+		authorization, err = azureFilesSharedKey(r, "account", server.accountKey)
+		if (err != nil) || (r.Header.Get("Authorization") != authorization) {
+			writeError(http.StatusForbidden, "AuthenticationFailed")
+			return
+		}
+	} else if query.Get("sig") != server.sasToken {
+		writeError(http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+
+	if r.Header.Get("x-ms-version") != azureFilesVersion {
+		writeError(http.StatusBadRequest, "InvalidHeaderValue")
+		return
+	}
+
+	parentPath = path[:strings.LastIndexByte(path, '/')]
+
+	switch {
+	case (query.Get("restype") == "directory") && (query.Get("comp") == "list"):
+		_, ok = server.dirs[path]
+		if !ok {
+			writeError(http.StatusNotFound, "ResourceNotFound")
+			return
+		}
+		for subPath = range server.dirs {
+			if strings.HasPrefix(subPath, path+"/") && !strings.Contains(subPath[len(path)+1:], "/") {
+				subPaths = append(subPaths, subPath)
+			}
+		}
+		for subPath = range server.files {
+			if strings.HasPrefix(subPath, path+"/") && !strings.Contains(subPath[len(path)+1:], "/") {
+				subPaths = append(subPaths, subPath)
+			}
+		}
+		sort.Strings(subPaths)
+		for _, subPath = range subPaths {
+			content, ok = server.files[subPath]
+			if ok {
+				_, _ = fmt.Fprintf(&entries, "<File><Name>%s</Name><Properties><Content-Length>%d</Content-Length><LastWriteTime>2025-01-02T03:04:05.1234567Z</LastWriteTime><Last-Modified>Thu, 02 Jan 2025 03:04:06 GMT</Last-Modified><Etag>\"%s\"</Etag></Properties></File>", subPath[len(path)+1:], len(content), server.fileETags[subPath])
+			} else {
+				_, _ = fmt.Fprintf(&entries, "<Directory><Name>%s</Name></Directory>", subPath[len(path)+1:])
+			}
+		}
+		_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?><EnumerationResults><Entries>%s</Entries><NextMarker /></EnumerationResults>", entries.String())
+	case query.Get("restype") == "directory":
+		switch r.Method {
+		case http.MethodHead:
+			_, ok = server.dirs[path]
+			if !ok {
+				writeError(http.StatusNotFound, "ResourceNotFound")
+				return
+			}
+		case http.MethodPut:
+			_, ok = server.dirs[parentPath]
+			if !ok {
+				writeError(http.StatusNotFound, "ParentNotFound")
+				return
+			}
+			_, ok = server.dirs[path]
+			if ok {
+				writeError(http.StatusConflict, "ResourceAlreadyExists")
+				return
+			}
+			server.dirs[path] = struct{}{}
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			_, ok = server.dirs[path]
+			if !ok {
+				writeError(http.StatusNotFound, "ResourceNotFound")
+				return
+			}
+			for subPath = range server.files {
+				if strings.HasPrefix(subPath, path+"/") {
+					writeError(http.StatusConflict, "DirectoryNotEmpty")
+					return
+				}
+			}
+			delete(server.dirs, path)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	case query.Get("comp") == "range":
+		content, ok = server.files[path]
+		if !ok {
+			writeError(http.StatusNotFound, "ResourceNotFound")
+			return
+		}
+		_, _ = fmt.Sscanf(r.Header.Get("x-ms-range"), "bytes=%d-%d", &rangeBegin, &rangeEnd)
+		body, _ = io.ReadAll(r.Body)
+		copy(content[rangeBegin:rangeEnd+1], body)
+		server.eTags++
+		server.fileETags[path] = fmt.Sprintf("0x%d", server.eTags)
+		w.Header().Set("ETag", "\""+server.fileETags[path]+"\"")
+		w.WriteHeader(http.StatusCreated)
+	default:
+		switch r.Method {
+		case http.MethodHead, http.MethodGet:
+			content, ok = server.files[path]
+			if !ok {
+				writeError(http.StatusNotFound, "ResourceNotFound")
+				return
+			}
+			w.Header().Set("ETag", "\""+server.fileETags[path]+"\"")
+			w.Header().Set("x-ms-type", "File")
+			w.Header().Set("x-ms-file-last-write-time", "2025-01-02T03:04:05.1234567Z")
+			w.Header().Set("Last-Modified", "Thu, 02 Jan 2025 03:04:06 GMT")
+			if r.Method == http.MethodHead {
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+				return
+			}
+			_, _ = fmt.Sscanf(r.Header.Get("x-ms-range"), "bytes=%d-%d", &rangeBegin, &rangeEnd)
+			rangeEnd = min(rangeEnd+1, len(content))
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", rangeEnd-rangeBegin))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(content[rangeBegin:rangeEnd])
+		case http.MethodPut:
+			_, ok = server.dirs[parentPath]
+			if !ok {
+				writeError(http.StatusNotFound, "ParentNotFound")
+				return
+			}
+			_, _ = fmt.Sscanf(r.Header.Get("x-ms-content-length"), "%d", &size)
+			server.files[path] = make([]byte, size)
+			server.eTags++
+			server.fileETags[path] = fmt.Sprintf("0x%d", server.eTags)
+			w.Header().Set("ETag", "\""+server.fileETags[path]+"\"")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			_, ok = server.files[path]
+			if !ok {
+				writeError(http.StatusNotFound, "ResourceNotFound")
+				return
+			}
+			delete(server.files, path)
+			delete(server.fileETags, path)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}
+}
+
+// `testAzureFilesContext` returns an azureFilesContextStruct of a backend (whose
+// bucket_container_name is "share") served by testServer.
+func testAzureFilesContext(testServer *httptest.Server, backendAzureFiles *backendConfigAzureFilesStruct) (azureFilesContext *azureFilesContextStruct) {
+	backendAzureFiles.accountName = "account"
+	backendAzureFiles.endpoint = testServer.URL
+	backendAzureFiles.retryAttempts = 1
+
+	azureFilesContext = &azureFilesContextStruct{
+		backend: &backendStruct{
+			dirName:              "azure",
+			bucketContainerName:  "share",
+			backendTypeSpecifics: backendAzureFiles,
+		},
+		httpClient: testServer.Client(),
+	}
+
+	return
+}
+
+func TestAzureFilesBackend(t *testing.T) {
+	var (
+		accountKey          = base64.StdEncoding.EncodeToString([]byte("testAccountKey"))
+		azureFilesContext   *azureFilesContextStruct
+		content             = []byte(strings.Repeat("0123456789", (azureFilesPutRangeMax/10)+1))
+		err                 error
+		listDirectoryOutput *listDirectoryOutputStruct
+		listObjectsOutput   *listObjectsOutputStruct
+		readFileOutput      *readFileOutputStruct
+		statFileOutput      *statFileOutputStruct
+		testServer          *httptest.Server
+		writeFileOutput     *writeFileOutputStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	testServer = httptest.NewServer(&testAzureFilesServerStruct{
+		accountKey: accountKey,
+		dirs:       map[string]struct{}{"/share": {}},
+		files:      make(map[string][]byte),
+		fileETags:  make(map[string]string),
+	})
+	defer testServer.Close()
+
+	azureFilesContext = testAzureFilesContext(testServer, &backendConfigAzureFilesStruct{
+		accountKey: accountKey,
+	})
+
+	// Writing dir1/dir2/fileA creates dir1/ & dir1/dir2/ and requires two Put Range's
+
+	writeFileOutput, err = azureFilesContext.writeFile(&writeFileInputStruct{
+		filePath: "dir1/dir2/fileA",
+		buf:      content,
+	})
+	if err != nil {
+		t.Fatalf("writeFile(\"dir1/dir2/fileA\") failed: %v", err)
+	}
+	if writeFileOutput.eTag != "0x3" {
+		t.Fatalf("writeFile(\"dir1/dir2/fileA\") returned eTag \"%s\" (expected \"0x3\")", writeFileOutput.eTag)
+	}
+
+	_, err = azureFilesContext.writeFile(&writeFileInputStruct{
+		filePath: "file B",
+	})
+	if err != nil {
+		t.Fatalf("writeFile(\"file B\") failed: %v", err)
+	}
+
+	_, err = azureFilesContext.writeFile(&writeFileInputStruct{
+		filePath: "dir3/",
+		buf:      []byte{},
+	})
+	if err != nil {
+		t.Fatalf("writeFile(\"dir3/\") failed: %v", err)
+	}
+
+	_, err = azureFilesContext.statDirectory(&statDirectoryInputStruct{
+		dirPath: "dir1/dir2/",
+	})
+	if err != nil {
+		t.Fatalf("statDirectory(\"dir1/dir2/\") failed: %v", err)
+	}
+
+	_, err = azureFilesContext.statDirectory(&statDirectoryInputStruct{
+		dirPath: "dir4/",
+	})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statDirectory(\"dir4/\") returned %v (expected ENOENT)", err)
+	}
+
+	statFileOutput, err = azureFilesContext.statFile(&statFileInputStruct{
+		filePath: "dir1/dir2/fileA",
+	})
+	if err != nil {
+		t.Fatalf("statFile(\"dir1/dir2/fileA\") failed: %v", err)
+	}
+	if (statFileOutput.eTag != "0x3") || (statFileOutput.size != uint64(len(content))) || !statFileOutput.mTime.Equal(time.Date(2025, 1, 2, 3, 4, 5, 123456700, time.UTC)) {
+		t.Fatalf("statFile(\"dir1/dir2/fileA\") returned %+v", statFileOutput)
+	}
+
+	readFileOutput, err = azureFilesContext.readFile(&readFileInputStruct{
+		filePath:        "dir1/dir2/fileA",
+		offsetCacheLine: 1,
+		cacheLineSize:   4,
+		ifMatch:         "\"0x3\"",
+	})
+	if err != nil {
+		t.Fatalf("readFile(\"dir1/dir2/fileA\") failed: %v", err)
+	}
+	if (string(readFileOutput.buf) != "4567") || (readFileOutput.eTag != "0x3") {
+		t.Fatalf("readFile(\"dir1/dir2/fileA\") returned buf \"%s\" & eTag \"%s\"", string(readFileOutput.buf), readFileOutput.eTag)
+	}
+
+	readFileOutput, err = azureFilesContext.readFile(&readFileInputStruct{
+		filePath:      "dir1/dir2/fileA",
+		cacheLineSize: 4,
+		ifNoneMatch:   "0x3",
+	})
+	if (err != nil) || !readFileOutput.notModified {
+		t.Fatalf("readFile(\"dir1/dir2/fileA\", ifNoneMatch: \"0x3\") did not report notModified (err: %v)", err)
+	}
+
+	_, err = azureFilesContext.readFile(&readFileInputStruct{
+		filePath:      "dir1/dir2/fileA",
+		cacheLineSize: 4,
+		ifMatch:       "0x0",
+	})
+	if backendErrno(err) != syscall.ESTALE {
+		t.Fatalf("readFile(\"dir1/dir2/fileA\", ifMatch: \"0x0\") returned %v (expected ESTALE)", err)
+	}
+
+	listDirectoryOutput, err = azureFilesContext.listDirectory(&listDirectoryInputStruct{})
+	if err != nil {
+		t.Fatalf("listDirectory(\"\") failed: %v", err)
+	}
+	if listDirectoryOutput.isTruncated || (len(listDirectoryOutput.subdirectory) != 2) || (listDirectoryOutput.subdirectory[0] != "dir1") || (listDirectoryOutput.subdirectory[1] != "dir3") || (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "file B") || (listDirectoryOutput.file[0].eTag != "0x4") {
+		t.Fatalf("listDirectory(\"\") returned %+v", listDirectoryOutput)
+	}
+
+	// listObjects() walks the tree: "file B" is found first, then (once dir1/, dir3/, & dir1/dir2/ are listed) dir1/dir2/fileA
+
+	listObjectsOutput, err = azureFilesContext.listObjects(&listObjectsInputStruct{})
+	if err != nil {
+		t.Fatalf("listObjects() failed: %v", err)
+	}
+	if !listObjectsOutput.isTruncated || (len(listObjectsOutput.object) != 1) || (listObjectsOutput.object[0].path != "file B") {
+		t.Fatalf("listObjects() returned %+v", listObjectsOutput)
+	}
+
+	listObjectsOutput, err = azureFilesContext.listObjects(&listObjectsInputStruct{
+		continuationToken: listObjectsOutput.nextContinuationToken,
+	})
+	if err != nil {
+		t.Fatalf("listObjects() continuation failed: %v", err)
+	}
+	if listObjectsOutput.isTruncated || (len(listObjectsOutput.object) != 1) || (listObjectsOutput.object[0].path != "dir1/dir2/fileA") || (listObjectsOutput.object[0].size != uint64(len(content))) {
+		t.Fatalf("listObjects() continuation returned %+v", listObjectsOutput)
+	}
+
+	_, err = azureFilesContext.deleteFile(&deleteFileInputStruct{
+		filePath: "dir1/dir2/",
+	})
+	if backendErrno(err) != syscall.ENOTEMPTY {
+		t.Fatalf("deleteFile(\"dir1/dir2/\") of a non-empty directory returned %v (expected ENOTEMPTY)", err)
+	}
+
+	_, err = azureFilesContext.deleteFile(&deleteFileInputStruct{
+		filePath: "dir1/dir2/fileA",
+		ifMatch:  "0x0",
+	})
+	if backendErrno(err) != syscall.ESTALE {
+		t.Fatalf("deleteFile(\"dir1/dir2/fileA\", ifMatch: \"0x0\") returned %v (expected ESTALE)", err)
+	}
+
+	_, err = azureFilesContext.deleteFiles(&deleteFilesInputStruct{
+		filePaths: []string{"dir1/dir2/fileA", "dir1/dir2/", "fileC"},
+	})
+	if err != nil {
+		t.Fatalf("deleteFiles() failed: %v", err)
+	}
+
+	_, err = azureFilesContext.statDirectory(&statDirectoryInputStruct{
+		dirPath: "dir1/dir2/",
+	})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statDirectory(\"dir1/dir2/\") after deleteFiles() returned %v (expected ENOENT)", err)
+	}
+}
+
+func TestAzureFilesSASToken(t *testing.T) {
+	var (
+		azureFilesContext *azureFilesContextStruct
+		err               error
+		testServer        *httptest.Server
+	)
+
+	testServer = httptest.NewServer(&testAzureFilesServerStruct{
+		sasToken:  "secret",
+		dirs:      map[string]struct{}{"/share": {}},
+		files:     map[string][]byte{"/share/fileA": []byte("A")},
+		fileETags: map[string]string{"/share/fileA": "0x1"},
+	})
+	defer testServer.Close()
+
+	azureFilesContext = testAzureFilesContext(testServer, &backendConfigAzureFilesStruct{
+		sasToken: "?sv=2022-11-02&sig=wrong",
+	})
+
+	_, err = azureFilesContext.statFile(&statFileInputStruct{
+		filePath: "fileA",
+	})
+	if backendErrno(err) != syscall.EACCES {
+		t.Fatalf("statFile(\"fileA\") with a bad sas_token returned %v (expected EACCES)", err)
+	}
+
+	azureFilesContext.rotateCredentials(&backendConfigAzureFilesStruct{
+		sasToken: "?sv=2022-11-02&sig=secret",
+	})
+
+	_, err = azureFilesContext.statFile(&statFileInputStruct{
+		filePath: "fileA",
+	})
+	if err != nil {
+		t.Fatalf("statFile(\"fileA\") with a rotated sas_token failed: %v", err)
+	}
+}
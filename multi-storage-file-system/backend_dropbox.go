@@ -0,0 +1,922 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// `dropboxContextStruct` holds the Dropbox-specific backend details. Unlike the S3 and
+// AIStore backends, no SDK is used: each operation is a single HTTP request to either
+// the RPC (api_endpoint) or content (content_endpoint) API.
+type dropboxContextStruct struct {
+	sync.Mutex                       // Protects accessToken, accessTokenExpiry, & backendConfigDropboxStruct.{access_token|refresh_token|app_key|app_secret}
+	backend           *backendStruct //
+	httpClient        *http.Client   //
+	accessToken       string         // Presented (as a Bearer token) by each request
+	accessTokenExpiry time.Time      // If refresh_token != "", when accessToken expires
+}
+
+// `dropboxListFolderLimitMax` caps the limit of each files/list_folder request.
+const dropboxListFolderLimitMax = 2000
+
+// `dropboxUploadMax` is the largest file that a single files/upload request may create.
+const dropboxUploadMax = 150 * 1024 * 1024
+
+// `dropboxErrorBodyMax` caps how much of a failed response's body is read for its error_summary.
+const dropboxErrorBodyMax = 4096
+
+// `dropboxAccessTokenRefreshWindow` is how long before it expires that an access token
+// obtained via refresh_token is replaced.
+const dropboxAccessTokenRefreshWindow = 5 * time.Minute
+
+// `dropboxMetadataStruct` is the subset of a file or folder's metadata (or of a
+// files/list_folder entry) used. Only files have a rev, size, and server_modified.
+type dropboxMetadataStruct struct {
+	Tag            string    `json:".tag"` // One of "file", "folder", or "deleted"
+	Name           string    `json:"name"`
+	PathDisplay    string    `json:"path_display"`
+	Rev            string    `json:"rev"`
+	Size           uint64    `json:"size"`
+	ServerModified time.Time `json:"server_modified"`
+}
+
+// `dropboxListFolderArgStruct` is the argument of files/list_folder.
+type dropboxListFolderArgStruct struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+	Limit     uint64 `json:"limit,omitempty"`
+}
+
+// `dropboxListFolderContinueArgStruct` is the argument of files/list_folder/continue.
+type dropboxListFolderContinueArgStruct struct {
+	Cursor string `json:"cursor"`
+}
+
+// `dropboxListFolderResultStruct` is the result of files/list_folder{|/continue}.
+type dropboxListFolderResultStruct struct {
+	Entries []dropboxMetadataStruct `json:"entries"`
+	Cursor  string                  `json:"cursor"`
+	HasMore bool                    `json:"has_more"`
+}
+
+// `dropboxPathArgStruct` is the argument of those routes taking just a path
+// (files/download, files/get_metadata, & files/create_folder_v2).
+type dropboxPathArgStruct struct {
+	Path string `json:"path"`
+}
+
+// `dropboxDeleteArgStruct` is the argument of files/delete_v2.
+type dropboxDeleteArgStruct struct {
+	Path      string `json:"path"`
+	ParentRev string `json:"parent_rev,omitempty"` // If != "", the file is only deleted should this still be its rev
+}
+
+// `dropboxDeleteResultStruct` is the result of files/delete_v2.
+type dropboxDeleteResultStruct struct {
+	Metadata dropboxMetadataStruct `json:"metadata"`
+}
+
+// `dropboxUploadArgStruct` is the argument of files/upload.
+type dropboxUploadArgStruct struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Mute bool   `json:"mute"`
+}
+
+// `dropboxErrorStruct` is the body of a failed (typically 409) response.
+type dropboxErrorStruct struct {
+	ErrorSummary string `json:"error_summary"`
+}
+
+// `dropboxTokenResultStruct` is the response to an OAuth 2 refresh_token grant.
+type dropboxTokenResultStruct struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"` // In seconds
+}
+
+// `backendCommon` is called to return a pointer to the context's common `backendStruct`.
+func (dropboxContext *dropboxContextStruct) backendCommon() (backendCommon *backendStruct) {
+	backendCommon = dropboxContext.backend
+	return
+}
+
+// `setupDropboxContext` establishes the Dropbox client context. Once set up, each
+// method defined in the `backendConfigIf` interface may be invoked.
+// Note that there is no `destroyContext` counterpart.
+func (backend *backendStruct) setupDropboxContext() (err error) {
+	var (
+		backendDropbox = backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+		dropboxContext *dropboxContextStruct
+		transport      = &http.Transport{}
+	)
+
+	backend.applyHTTPTransportOptions(transport)
+
+	dropboxContext = &dropboxContextStruct{
+		backend: backend,
+		httpClient: &http.Client{
+			Timeout:   backendDropbox.timeout,
+			Transport: transport,
+		},
+	}
+
+	err = dropboxContext.loadAccessToken()
+	if err != nil {
+		err = fmt.Errorf("[Dropbox] %v", err)
+		return
+	}
+
+	backend.context = dropboxContext
+
+	backend.backendPath = "dropbox:" + dropboxContext.dropboxPath("") + "/"
+
+	return
+}
+
+// `dropboxRootPath` returns the Dropbox path of the folder named by bucketContainerName
+// (with any leading or trailing "/" disregarded) or "" if it names the root folder.
+func dropboxRootPath(bucketContainerName string) (rootPath string) {
+	rootPath = strings.Trim(bucketContainerName, "/")
+	if rootPath != "" {
+		rootPath = "/" + rootPath
+	}
+
+	return
+}
+
+// `dropboxPath` returns the Dropbox path of path (relative to backend.prefix). As Dropbox
+// paths begin with (but never end with) "/" and the root folder's path is "", any trailing
+// "/" (e.g. of a directory's path) is removed.
+func (dropboxContext *dropboxContextStruct) dropboxPath(path string) (fullPath string) {
+	var (
+		backend = dropboxContext.backend
+	)
+
+	fullPath = dropboxRootPath(backend.bucketContainerName)
+
+	path = strings.TrimSuffix(backend.prefix+path, "/")
+	if path != "" {
+		fullPath += "/" + path
+	}
+
+	return
+}
+
+// `loadAccessToken` sets accessToken to access_token (or the secret it references) or, if
+// refresh_token != "", to one freshly obtained with it from api_endpoint's OAuth 2 token
+// route. It is called while dropboxContext.Lock() is held (or before dropboxContext is shared).
+func (dropboxContext *dropboxContextStruct) loadAccessToken() (err error) {
+	var (
+		backendDropbox = dropboxContext.backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+		body           []byte
+		form           url.Values
+		resolved       []string
+		resp           *http.Response
+		tokenResult    dropboxTokenResultStruct
+	)
+
+	resolved, _, err = resolveSecrets(backendDropbox.accessToken, backendDropbox.refreshToken, backendDropbox.appSecret)
+	if err != nil {
+		return
+	}
+
+	if resolved[1] == "" {
+		dropboxContext.accessToken = resolved[0]
+		dropboxContext.accessTokenExpiry = time.Time{}
+		return
+	}
+
+	form = url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"refresh_token": []string{resolved[1]},
+		"client_id":     []string{backendDropbox.appKey},
+	}
+	if resolved[2] != "" {
+		form.Set("client_secret", resolved[2])
+	}
+
+	resp, err = dropboxContext.httpClient.PostForm(backendDropbox.apiEndpoint+"/oauth2/token", form)
+	if err != nil {
+		err = fmt.Errorf("refreshing access token failed: %v", err)
+		return
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		err = fmt.Errorf("refreshing access token failed: %v", err)
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("refreshing access token failed (HTTP %d): %s: %w", resp.StatusCode, strings.TrimSpace(string(body)), syscall.EACCES)
+		return
+	}
+
+	err = json.Unmarshal(body, &tokenResult)
+	if (err != nil) || (tokenResult.AccessToken == "") {
+		err = fmt.Errorf("refreshing access token returned no access_token: %w", syscall.EACCES)
+		return
+	}
+
+	dropboxContext.accessToken = tokenResult.AccessToken
+	if tokenResult.ExpiresIn > 0 {
+		dropboxContext.accessTokenExpiry = time.Now().Add(time.Duration(tokenResult.ExpiresIn) * time.Second)
+	} else {
+		dropboxContext.accessTokenExpiry = time.Time{}
+	}
+
+	return
+}
+
+// `rotateCredentials` replaces the access_token, refresh_token, app_key, and app_secret
+// settings with those of backendDropboxNew and immediately obtains the access token
+// that subsequent requests will present.
+func (dropboxContext *dropboxContextStruct) rotateCredentials(backendDropboxNew *backendConfigDropboxStruct) {
+	var (
+		backendDropbox = dropboxContext.backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+		err            error
+	)
+
+	dropboxContext.Lock()
+	defer dropboxContext.Unlock()
+
+	backendDropbox.accessToken = backendDropboxNew.accessToken
+	backendDropbox.refreshToken = backendDropboxNew.refreshToken
+	backendDropbox.appKey = backendDropboxNew.appKey
+	backendDropbox.appSecret = backendDropboxNew.appSecret
+
+	err = dropboxContext.loadAccessToken()
+	if err != nil {
+		globals.logger.Printf("[WARN] [Dropbox] %v", err)
+	}
+}
+
+// `dropboxAPIArg` returns arg encoded as JSON for the Dropbox-API-Arg header of a content
+// route. As HTTP headers may only hold ASCII, any other character is escaped as "\uXXXX".
+func dropboxAPIArg(arg interface{}) (apiArg string, err error) {
+	var (
+		argJSON  []byte
+		r        rune
+		sb       strings.Builder
+		utf16Seq uint16
+	)
+
+	argJSON, err = json.Marshal(arg)
+	if err != nil {
+		return
+	}
+
+	for _, r = range string(argJSON) {
+		if r < utf8.RuneSelf {
+			_ = sb.WriteByte(byte(r))
+			continue
+		}
+
+		for _, utf16Seq = range utf16.Encode([]rune{r}) {
+			_, _ = fmt.Fprintf(&sb, "\\u%04x", utf16Seq)
+		}
+	}
+
+	apiArg = sb.String()
+
+	return
+}
+
+// `dropboxErrno` returns the syscall.Errno best describing a response of statusCode whose
+// error_summary (for a 409) is summary (e.g. "path/not_found/..").
+func dropboxErrno(statusCode int, summary string) (errno syscall.Errno) {
+	switch statusCode {
+	case http.StatusBadRequest:
+		errno = syscall.EINVAL
+	case http.StatusUnauthorized, http.StatusForbidden:
+		errno = syscall.EACCES
+	case http.StatusConflict:
+		switch {
+		case strings.Contains(summary, "not_found"):
+			errno = syscall.ENOENT
+		case strings.Contains(summary, "not_folder"):
+			errno = syscall.ENOTDIR
+		case strings.Contains(summary, "not_file"):
+			errno = syscall.EISDIR
+		case strings.Contains(summary, "conflict"):
+			errno = syscall.EEXIST
+		case strings.Contains(summary, "insufficient_space"):
+			errno = syscall.ENOSPC
+		case strings.Contains(summary, "too_many_write_operations"):
+			errno = syscall.EAGAIN
+		case strings.Contains(summary, "no_write_permission"), strings.Contains(summary, "restricted_content"), strings.Contains(summary, "disallowed_name"):
+			errno = syscall.EACCES
+		default:
+			errno = syscall.EIO
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		errno = syscall.EINVAL
+	case http.StatusTooManyRequests:
+		errno = syscall.EAGAIN
+	default:
+		errno = syscall.EIO
+	}
+
+	return
+}
+
+// `do` issues the request built by newRequest (called anew for each attempt) presenting
+// the current access token. Throttling (429), server (5xx), and transport failures are
+// retried per the retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} settings
+// (unless shed by the retry budget, if any). Should the access token be rejected (401) and
+// be refreshable (via refresh_token or a re-fetched secret), a fresh one is obtained and the
+// request reissued once. Concurrent failures using the same rejected token only trigger one
+// refresh. Only a successful (2xx) response is returned (its body to be closed by the caller).
+func (dropboxContext *dropboxContextStruct) do(route string, newRequest func() (req *http.Request, err error)) (resp *http.Response, err error) {
+	var (
+		accessToken    string
+		attempt        int
+		backend        = dropboxContext.backend
+		backendDropbox = backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+		body           []byte
+		dropboxError   dropboxErrorStruct
+		errRefresh     error
+		refreshed      bool
+		req            *http.Request
+		retryDelay     = backendDropbox.retryBaseDelay
+		statusCode     int
+	)
+
+	for attempt = 1; ; attempt++ {
+		dropboxContext.Lock()
+		if !dropboxContext.accessTokenExpiry.IsZero() && time.Now().After(dropboxContext.accessTokenExpiry.Add(-dropboxAccessTokenRefreshWindow)) {
+			errRefresh = dropboxContext.loadAccessToken()
+			if errRefresh != nil {
+				globals.logger.Printf("[WARN] [Dropbox] %v", errRefresh)
+			}
+		}
+		accessToken = dropboxContext.accessToken
+		dropboxContext.Unlock()
+
+		req, err = newRequest()
+		if err != nil {
+			err = fmt.Errorf("[Dropbox] %s failed: %v", route, err)
+			return
+		}
+
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err = dropboxContext.httpClient.Do(req)
+		if err != nil {
+			statusCode = 0
+			err = fmt.Errorf("[Dropbox] %s failed: %w", route, err)
+		} else if resp.StatusCode < http.StatusMultipleChoices {
+			return
+		} else {
+			statusCode = resp.StatusCode
+			body, _ = io.ReadAll(io.LimitReader(resp.Body, dropboxErrorBodyMax))
+			_ = resp.Body.Close()
+			resp = nil
+			dropboxError = dropboxErrorStruct{}
+			if (json.Unmarshal(body, &dropboxError) != nil) || (dropboxError.ErrorSummary == "") {
+				dropboxError.ErrorSummary = strings.TrimSpace(string(body))
+			}
+			err = fmt.Errorf("[Dropbox] %s failed (HTTP %d): %s: %w", route, statusCode, dropboxError.ErrorSummary, dropboxErrno(statusCode, dropboxError.ErrorSummary))
+		}
+
+		if (statusCode == http.StatusUnauthorized) && !refreshed && ((backendDropbox.refreshToken != "") || isSecretRef(backendDropbox.accessToken)) {
+			refreshed = true
+
+			dropboxContext.Lock()
+			if dropboxContext.accessToken == accessToken {
+				// The referenced secret may since have been rotated, so fetch it anew
+				invalidateSecret(backendDropbox.accessToken)
+				errRefresh = dropboxContext.loadAccessToken()
+				if errRefresh != nil {
+					globals.logger.Printf("[WARN] [Dropbox] %v", errRefresh)
+				} else if dropboxContext.accessToken != accessToken {
+					globals.logger.Printf("[INFO] [Dropbox] refreshed access token for backend \"%s\"", backend.dirName)
+				}
+			}
+			if dropboxContext.accessToken != accessToken {
+				dropboxContext.Unlock()
+				attempt--
+				continue
+			}
+			dropboxContext.Unlock()
+			return
+		}
+
+		if ((statusCode != 0) && (statusCode != http.StatusTooManyRequests) && (statusCode < http.StatusInternalServerError)) || (attempt >= backendDropbox.retryAttempts) {
+			return
+		}
+
+		if !globals.retryBudget.withdraw() {
+			err = fmt.Errorf("retry budget exhausted: %w", err)
+			return
+		}
+
+		backend.retries.Add(1)
+		if (statusCode == http.StatusTooManyRequests) || (statusCode == http.StatusServiceUnavailable) {
+			backend.throttles.Add(1)
+		}
+
+		time.Sleep(min(retryDelay, backendDropbox.retryMaxDelay))
+
+		retryDelay = time.Duration(float64(retryDelay) * backendDropbox.retryNextDelayMultiplier)
+	}
+}
+
+// `rpc` issues a request to route of the RPC API (at api_endpoint) with arg as its JSON
+// body decoding the JSON response into result (unless result is nil).
+func (dropboxContext *dropboxContextStruct) rpc(route string, arg interface{}, result interface{}) (err error) {
+	var (
+		argJSON        []byte
+		backendDropbox = dropboxContext.backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+		resp           *http.Response
+	)
+
+	argJSON, err = json.Marshal(arg)
+	if err != nil {
+		err = fmt.Errorf("[Dropbox] %s failed: %v", route, err)
+		return
+	}
+
+	resp, err = dropboxContext.do(route, func() (req *http.Request, err error) {
+		req, err = http.NewRequest(http.MethodPost, backendDropbox.apiEndpoint+"/2/"+route, bytes.NewReader(argJSON))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+		if err != nil {
+			err = fmt.Errorf("[Dropbox] %s returned an undecodable result: %v", route, err)
+		}
+	}
+
+	return
+}
+
+// `content` issues a request to route of the content API (at content_endpoint) with arg
+// in its Dropbox-API-Arg header and body (if != nil) as its content. Should rangeHeader be
+// != "", it is sent as the Range header. The (successful) response is returned.
+func (dropboxContext *dropboxContextStruct) content(route string, arg interface{}, body []byte, rangeHeader string) (resp *http.Response, err error) {
+	var (
+		apiArg         string
+		backendDropbox = dropboxContext.backend.backendTypeSpecifics.(*backendConfigDropboxStruct)
+	)
+
+	apiArg, err = dropboxAPIArg(arg)
+	if err != nil {
+		err = fmt.Errorf("[Dropbox] %s failed: %v", route, err)
+		return
+	}
+
+	resp, err = dropboxContext.do(route, func() (req *http.Request, err error) {
+		if body == nil {
+			req, err = http.NewRequest(http.MethodPost, backendDropbox.contentEndpoint+"/2/"+route, nil)
+		} else {
+			req, err = http.NewRequest(http.MethodPost, backendDropbox.contentEndpoint+"/2/"+route, bytes.NewReader(body))
+		}
+		if err != nil {
+			return
+		}
+		req.Header.Set("Dropbox-API-Arg", apiArg)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/octet-stream")
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		return
+	})
+
+	return
+}
+
+// `getMetadata` fetches the metadata of the file or folder at fullPath (a Dropbox path).
+func (dropboxContext *dropboxContextStruct) getMetadata(fullPath string) (metadata *dropboxMetadataStruct, err error) {
+	metadata = &dropboxMetadataStruct{}
+
+	err = dropboxContext.rpc("files/get_metadata", &dropboxPathArgStruct{Path: fullPath}, metadata)
+	if err != nil {
+		metadata = nil
+	}
+
+	return
+}
+
+// `getFileMetadata` fetches the metadata of the file at fullPath (a Dropbox path). Should
+// a folder be found there instead, an error wrapping syscall.ENOENT is returned (as
+// would an object store should a "file" be looked for where there is a "directory").
+func (dropboxContext *dropboxContextStruct) getFileMetadata(fullPath string) (metadata *dropboxMetadataStruct, err error) {
+	metadata, err = dropboxContext.getMetadata(fullPath)
+	if (err == nil) && (metadata.Tag != "file") {
+		metadata = nil
+		err = fmt.Errorf("[Dropbox] %s is not a file: %w", fullPath, syscall.ENOENT)
+	}
+
+	return
+}
+
+// `deleteFile` is called to remove a "file" at the specified path. As files/delete_v2
+// would remove a folder (and all of its contents), the file's metadata is fetched first
+// and the delete made conditional on its rev (so that neither a folder nor a file since
+// replaced is removed). If the path ends in "/", the (real) folder at that path is instead
+// removed should it be empty (as with a directory marker of an object store). Note that a
+// file created in the folder after it was found empty would be removed along with it.
+func (dropboxContext *dropboxContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
+	var (
+		fullFilePath     = dropboxContext.dropboxPath(deleteFileInput.filePath)
+		listFolderResult dropboxListFolderResultStruct
+		metadata         *dropboxMetadataStruct
+	)
+
+	if strings.HasSuffix(deleteFileInput.filePath, "/") {
+		err = dropboxContext.rpc("files/list_folder", &dropboxListFolderArgStruct{Path: fullFilePath, Limit: 1}, &listFolderResult)
+		if err != nil {
+			return
+		}
+		if len(listFolderResult.Entries) != 0 {
+			err = fmt.Errorf("[Dropbox] %s is not empty: %w", fullFilePath, syscall.ENOTEMPTY)
+			return
+		}
+
+		err = dropboxContext.rpc("files/delete_v2", &dropboxDeleteArgStruct{Path: fullFilePath}, nil)
+		if err == nil {
+			deleteFileOutput = &deleteFileOutputStruct{}
+		}
+
+		return
+	}
+
+	metadata, err = dropboxContext.getFileMetadata(fullFilePath)
+	if err != nil {
+		return
+	}
+
+	if (deleteFileInput.ifMatch != "") && (metadata.Rev != deleteFileInput.ifMatch) {
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	err = dropboxContext.rpc("files/delete_v2", &dropboxDeleteArgStruct{Path: fullFilePath, ParentRev: metadata.Rev}, &dropboxDeleteResultStruct{})
+	if err == nil {
+		deleteFileOutput = &deleteFileOutputStruct{}
+	}
+
+	return
+}
+
+// `deleteFiles` is called to remove the "files" at the specified paths. As files/delete_batch
+// is asynchronous (and would remove folders), each is removed in turn via deleteFile().
+// Paths at which nothing is found are silently skipped.
+func (dropboxContext *dropboxContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		filePath string
+	)
+
+	for _, filePath = range deleteFilesInput.filePaths {
+		_, err = dropboxContext.deleteFile(&deleteFileInputStruct{
+			filePath: filePath,
+		})
+		if (err != nil) && (backendErrno(err) != syscall.ENOENT) {
+			return
+		}
+	}
+
+	err = nil
+	deleteFilesOutput = &deleteFilesOutputStruct{}
+
+	return
+}
+
+// `listDirectory` is called to fetch a `page` of the `directory` at the specified path.
+// An empty continuationToken or empty list of directory elements (`subdirectories` and `files`)
+// indicates the `directory` has been completely enumerated. The `isTruncated` field will also
+// align with this convention. The cursor of files/list_folder{|/continue} serves as the
+// continuation token and each file's rev as its eTag.
+func (dropboxContext *dropboxContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		entry             dropboxMetadataStruct
+		listFolderResult  dropboxListFolderResultStruct
+		subdirectoryCount int
+	)
+
+	if listDirectoryInput.continuationToken == "" {
+		err = dropboxContext.rpc("files/list_folder", &dropboxListFolderArgStruct{
+			Path:  dropboxContext.dropboxPath(listDirectoryInput.dirPath),
+			Limit: min(listDirectoryInput.maxItems, dropboxListFolderLimitMax),
+		}, &listFolderResult)
+	} else {
+		err = dropboxContext.rpc("files/list_folder/continue", &dropboxListFolderContinueArgStruct{
+			Cursor: listDirectoryInput.continuationToken,
+		}, &listFolderResult)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, entry = range listFolderResult.Entries {
+		if entry.Tag == "folder" {
+			subdirectoryCount++
+		}
+	}
+
+	listDirectoryOutput = newListDirectoryOutput(listDirectoryInput, subdirectoryCount, len(listFolderResult.Entries)-subdirectoryCount)
+
+	for _, entry = range listFolderResult.Entries {
+		switch entry.Tag {
+		case "file":
+			listDirectoryOutput.file = append(listDirectoryOutput.file, listDirectoryOutputFileStruct{
+				basename: entry.Name,
+				eTag:     entry.Rev,
+				mTime:    entry.ServerModified,
+				size:     entry.Size,
+			})
+		case "folder":
+			listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, entry.Name)
+		default:
+			// Skip "deleted" entries
+		}
+	}
+
+	if listFolderResult.HasMore {
+		listDirectoryOutput.nextContinuationToken = listFolderResult.Cursor
+		listDirectoryOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `listMultipartUploads` is called to fetch the multipart uploads in progress of `files` whose
+// paths begin with the specified prefix. Dropbox's API provides no means to enumerate them.
+func (dropboxContext *dropboxContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	err = fmt.Errorf("[Dropbox] listMultipartUploads not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts
+// uploaded. As createMultipartUpload() is not supported, there are none to abort.
+func (dropboxContext *dropboxContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Dropbox] abortMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
+// empty list of elements (`objects`) indicates the list of `objects` has been completely
+// enumerated. The `isTruncated` field will also align with this convention. Files are
+// enumerated by a recursive files/list_folder of the folder at backend.prefix.
+func (dropboxContext *dropboxContextStruct) listObjects(listObjectsInput *listObjectsInputStruct) (listObjectsOutput *listObjectsOutputStruct, err error) {
+	var (
+		entry            dropboxMetadataStruct
+		fullPrefixPath   = dropboxContext.dropboxPath("")
+		listFolderResult dropboxListFolderResultStruct
+	)
+
+	if listObjectsInput.continuationToken == "" {
+		err = dropboxContext.rpc("files/list_folder", &dropboxListFolderArgStruct{
+			Path:      fullPrefixPath,
+			Recursive: true,
+			Limit:     min(listObjectsInput.maxItems, dropboxListFolderLimitMax),
+		}, &listFolderResult)
+	} else {
+		err = dropboxContext.rpc("files/list_folder/continue", &dropboxListFolderContinueArgStruct{
+			Cursor: listObjectsInput.continuationToken,
+		}, &listFolderResult)
+	}
+	if err != nil {
+		return
+	}
+
+	listObjectsOutput = &listObjectsOutputStruct{
+		object: make([]listObjectsOutputObjectStruct, 0, len(listFolderResult.Entries)),
+	}
+
+	for _, entry = range listFolderResult.Entries {
+		// Note that only the last component of path_display is reliably cased, so rather than
+		// matching fullPrefixPath, the path relative to it is found simply by its length
+
+		if (entry.Tag != "file") || (len(entry.PathDisplay) <= len(fullPrefixPath)+1) {
+			continue
+		}
+
+		listObjectsOutput.object = append(listObjectsOutput.object, listObjectsOutputObjectStruct{
+			path:  entry.PathDisplay[len(fullPrefixPath)+1:],
+			eTag:  entry.Rev,
+			mTime: entry.ServerModified,
+			size:  entry.Size,
+		})
+	}
+
+	if listFolderResult.HasMore {
+		listObjectsOutput.nextContinuationToken = listFolderResult.Cursor
+		listObjectsOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `readFile` is called to read a range of a `file` at the specified path via a ranged
+// files/download. An error is returned if either the specified path is not a `file` or
+// non-existent. As files/download is not conditional, ifNoneMatch is checked by fetching
+// the file's metadata first while ifMatch is checked against the rev of what was downloaded.
+func (dropboxContext *dropboxContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		apiResult    dropboxMetadataStruct
+		fullFilePath = dropboxContext.dropboxPath(readFileInput.filePath)
+		metadata     *dropboxMetadataStruct
+		rangeBegin   uint64
+		rangeSize    uint64
+		resp         *http.Response
+	)
+
+	rangeBegin, rangeSize = readFileInput.byteRange()
+
+	if readFileInput.ifNoneMatch != "" {
+		metadata, err = dropboxContext.getFileMetadata(fullFilePath)
+		if err != nil {
+			return
+		}
+		if metadata.Rev == readFileInput.ifNoneMatch {
+			readFileOutput = &readFileOutputStruct{
+				eTag:        readFileInput.ifNoneMatch,
+				buf:         make([]byte, 0),
+				notModified: true,
+			}
+			return
+		}
+	}
+
+	resp, err = dropboxContext.content("files/download", &dropboxPathArgStruct{Path: fullFilePath}, nil, fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeBegin+rangeSize-1))
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal([]byte(resp.Header.Get("Dropbox-API-Result")), &apiResult)
+	if err != nil {
+		_ = resp.Body.Close()
+		err = fmt.Errorf("[Dropbox] files/download returned an undecodable Dropbox-API-Result: %v", err)
+		return
+	}
+
+	if (readFileInput.ifMatch != "") && (apiResult.Rev != readFileInput.ifMatch) {
+		_ = resp.Body.Close()
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	readFileOutput = &readFileOutputStruct{
+		eTag: apiResult.Rev,
+	}
+
+	readFileOutput.buf, err = readS3Body(resp.Body, &resp.ContentLength, rangeSize)
+	if err != nil {
+		readFileOutput = nil
+		err = fmt.Errorf("[Dropbox] files/download failed: %v", err)
+	}
+
+	return
+}
+
+// `prefetchFiles` is called to hint that the "files" at the specified paths
+// are likely to be read soon. Dropbox offers no such facility, so this is a no-op.
+func (dropboxContext *dropboxContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	return
+}
+
+// `statDirectory` is called to verify that the specified path refers to a `directory`.
+// An error is returned if either the specified path is not a `directory` or non-existent.
+// As Dropbox folders are real (and files/get_metadata does not support the root folder),
+// a files/list_folder of (at most) one entry suffices even for an empty folder.
+func (dropboxContext *dropboxContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	err = dropboxContext.rpc("files/list_folder", &dropboxListFolderArgStruct{
+		Path:  dropboxContext.dropboxPath(statDirectoryInput.dirPath),
+		Limit: 1,
+	}, &dropboxListFolderResultStruct{})
+	if err == nil {
+		statDirectoryOutput = &statDirectoryOutputStruct{}
+	}
+
+	return
+}
+
+// `statFile` is called to fetch the `file` metadata at the specified path.
+// An error is returned if either the specified path is not a `file` or non-existent.
+func (dropboxContext *dropboxContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	var (
+		metadata *dropboxMetadataStruct
+	)
+
+	metadata, err = dropboxContext.getFileMetadata(dropboxContext.dropboxPath(statFileInput.filePath))
+	if err != nil {
+		return
+	}
+
+	if (statFileInput.ifMatch != "") && (metadata.Rev != statFileInput.ifMatch) {
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	statFileOutput = &statFileOutputStruct{
+		eTag:  metadata.Rev,
+		mTime: metadata.ServerModified,
+		size:  metadata.Size,
+	}
+
+	return
+}
+
+// `writeFile` is called to create (or replace) the "file" at the specified path with a
+// single files/upload (limiting it to dropboxUploadMax bytes). A zero-byte "file" whose path
+// ends in "/" (i.e. a directory marker) instead creates a (real) folder at that path.
+func (dropboxContext *dropboxContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		buf          = writeFileInput.buf
+		fullFilePath = dropboxContext.dropboxPath(writeFileInput.filePath)
+		metadata     dropboxMetadataStruct
+		resp         *http.Response
+	)
+
+	if strings.HasSuffix(writeFileInput.filePath, "/") && (len(writeFileInput.buf) == 0) {
+		err = dropboxContext.rpc("files/create_folder_v2", &dropboxPathArgStruct{Path: fullFilePath}, nil)
+		if (err != nil) && (backendErrno(err) == syscall.EEXIST) {
+			_, err = dropboxContext.statDirectory(&statDirectoryInputStruct{dirPath: writeFileInput.filePath})
+		}
+		if err == nil {
+			writeFileOutput = &writeFileOutputStruct{}
+		}
+		return
+	}
+
+	if buf == nil {
+		buf = []byte{} // So that content() sends it (as an empty application/octet-stream)
+	}
+
+	if len(buf) > dropboxUploadMax {
+		err = fmt.Errorf("[Dropbox] files/upload limited to %d bytes: %w", dropboxUploadMax, syscall.EFBIG)
+		return
+	}
+
+	resp, err = dropboxContext.content("files/upload", &dropboxUploadArgStruct{
+		Path: fullFilePath,
+		Mode: "overwrite",
+		Mute: true,
+	}, buf, "")
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	err = json.NewDecoder(resp.Body).Decode(&metadata)
+	if err != nil {
+		err = fmt.Errorf("[Dropbox] files/upload returned an undecodable result: %v", err)
+		return
+	}
+
+	writeFileOutput = &writeFileOutputStruct{
+		eTag: metadata.Rev,
+	}
+
+	return
+}
+
+// `createMultipartUpload` is called to begin creating (or replacing) the `file` at the specified
+// path via a sequence of uploadPart() calls concluded by completeMultipartUpload(). Dropbox's
+// upload sessions require each part's offset (unknown until the preceding parts' sizes are),
+// so this is not supported (and writeFile() must be used).
+func (dropboxContext *dropboxContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Dropbox] createMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `uploadPart` is called to upload one part of the specified multipart upload (not supported).
+func (dropboxContext *dropboxContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	err = fmt.Errorf("[Dropbox] uploadPart not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `completeMultipartUpload` is called to conclude the specified multipart upload (not supported).
+func (dropboxContext *dropboxContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Dropbox] completeMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// `testDropboxServerStruct` is a minimal (flat, single folder) fake of the Dropbox API
+// routes used by the Dropbox backend. Only requests bearing accessToken are accepted.
+type testDropboxServerStruct struct {
+	sync.Mutex
+	accessToken  string
+	refreshCalls int
+	revs         int
+	files        map[string][]byte // Keyed by Dropbox path
+	fileRevs     map[string]string // Keyed by Dropbox path
+}
+
+func (server *testDropboxServerStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		apiArg      string
+		arg         map[string]interface{}
+		body        []byte
+		content     []byte
+		entries     []map[string]interface{}
+		ok          bool
+		path        string
+		paths       []string
+		rangeBegin  int
+		rangeEnd    int
+		writeResult = func(result interface{}) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(result)
+		}
+		writeError = func(summary string) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error_summary": summary})
+		}
+	)
+
+	server.Lock()
+	defer server.Unlock()
+
+	if r.URL.Path == "/oauth2/token" {
+		if (r.FormValue("grant_type") != "refresh_token") || (r.FormValue("refresh_token") != "refresh") || (r.FormValue("client_id") != "key") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		server.refreshCalls++
+		server.accessToken = fmt.Sprintf("token%d", server.refreshCalls)
+		writeResult(map[string]interface{}{"access_token": server.accessToken, "expires_in": 14400})
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+server.accessToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error_summary": "expired_access_token/.."}`))
+		return
+	}
+
+	apiArg = r.Header.Get("Dropbox-API-Arg")
+	if apiArg == "" {
+		body, _ = io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &arg)
+	} else {
+		_ = json.Unmarshal([]byte(apiArg), &arg)
+		body, _ = io.ReadAll(r.Body)
+	}
+
+	path, _ = arg["path"].(string)
+
+	switch r.URL.Path {
+	case "/2/files/list_folder", "/2/files/list_folder/continue":
+		if (r.URL.Path == "/2/files/list_folder") && (path != "/root") {
+			writeError("path/not_found/..")
+			return
+		}
+		for path = range server.files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path = range paths {
+			entries = append(entries, map[string]interface{}{
+				".tag":            "file",
+				"name":            strings.TrimPrefix(path, "/root/"),
+				"path_display":    path,
+				"rev":             server.fileRevs[path],
+				"size":            len(server.files[path]),
+				"server_modified": "2025-01-02T03:04:05Z",
+			})
+		}
+		// The first page lists only the first file, the continuation the rest
+		if r.URL.Path == "/2/files/list_folder" {
+			writeResult(map[string]interface{}{"entries": entries[:1], "cursor": "next", "has_more": true})
+		} else {
+			writeResult(map[string]interface{}{"entries": entries[1:], "cursor": "done", "has_more": false})
+		}
+	case "/2/files/get_metadata":
+		_, ok = server.files[path]
+		if !ok {
+			writeError("path/not_found/..")
+			return
+		}
+		writeResult(map[string]interface{}{".tag": "file", "name": path, "path_display": path, "rev": server.fileRevs[path], "size": len(server.files[path]), "server_modified": "2025-01-02T03:04:05Z"})
+	case "/2/files/delete_v2":
+		_, ok = server.files[path]
+		if !ok {
+			writeError("path_lookup/not_found/..")
+			return
+		}
+		if (arg["parent_rev"] != nil) && (arg["parent_rev"] != server.fileRevs[path]) {
+			writeError("path_lookup/not_found/..")
+			return
+		}
+		delete(server.files, path)
+		delete(server.fileRevs, path)
+		writeResult(map[string]interface{}{"metadata": map[string]interface{}{".tag": "file", "path_display": path}})
+	case "/2/files/upload":
+		server.revs++
+		server.files[path] = body
+		server.fileRevs[path] = fmt.Sprintf("rev%d", server.revs)
+		writeResult(map[string]interface{}{".tag": "file", "path_display": path, "rev": server.fileRevs[path], "size": len(body)})
+	case "/2/files/download":
+		content, ok = server.files[path]
+		if !ok {
+			writeError("path/not_found/..")
+			return
+		}
+		_, _ = fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &rangeBegin, &rangeEnd)
+		rangeEnd = min(rangeEnd+1, len(content))
+		w.Header().Set("Dropbox-API-Result", fmt.Sprintf(`{"path_display": "%s", "rev": "%s", "size": %d}`, path, server.fileRevs[path], len(content)))
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", rangeEnd-rangeBegin))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content[rangeBegin:rangeEnd])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// `testDropboxContext` returns a dropboxContextStruct of a backend (whose bucket_container_name
+// is "root") served by server at testServer.
+func testDropboxContext(testServer *httptest.Server, backendDropbox *backendConfigDropboxStruct) (dropboxContext *dropboxContextStruct) {
+	if globals.logger == nil {
+		globals.logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmsgprefix)
+	}
+
+	backendDropbox.apiEndpoint = testServer.URL
+	backendDropbox.contentEndpoint = testServer.URL
+	backendDropbox.retryAttempts = 1
+
+	dropboxContext = &dropboxContextStruct{
+		backend: &backendStruct{
+			dirName:              "dropbox",
+			bucketContainerName:  "root",
+			backendTypeSpecifics: backendDropbox,
+		},
+		httpClient: testServer.Client(),
+	}
+
+	return
+}
+
+func TestDropboxBackend(t *testing.T) {
+	var (
+		dropboxContext      *dropboxContextStruct
+		err                 error
+		listDirectoryOutput *listDirectoryOutputStruct
+		listObjectsOutput   *listObjectsOutputStruct
+		readFileOutput      *readFileOutputStruct
+		statFileOutput      *statFileOutputStruct
+		testServer          *httptest.Server
+		testServerState     *testDropboxServerStruct
+		writeFileOutput     *writeFileOutputStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	testServerState = &testDropboxServerStruct{
+		accessToken: "static",
+		files:       make(map[string][]byte),
+		fileRevs:    make(map[string]string),
+	}
+
+	testServer = httptest.NewServer(testServerState)
+	defer testServer.Close()
+
+	dropboxContext = testDropboxContext(testServer, &backendConfigDropboxStruct{
+		accessToken: "static",
+	})
+
+	err = dropboxContext.loadAccessToken()
+	if err != nil {
+		t.Fatalf("loadAccessToken() failed: %v", err)
+	}
+
+	if dropboxContext.dropboxPath("dir1/") != "/root/dir1" {
+		t.Fatalf("dropboxPath(\"dir1/\") returned \"%s\" (expected \"/root/dir1\")", dropboxContext.dropboxPath("dir1/"))
+	}
+
+	writeFileOutput, err = dropboxContext.writeFile(&writeFileInputStruct{
+		filePath: "fileA",
+		buf:      []byte("0123456789"),
+	})
+	if err != nil {
+		t.Fatalf("writeFile(\"fileA\") failed: %v", err)
+	}
+	if writeFileOutput.eTag != "rev1" {
+		t.Fatalf("writeFile(\"fileA\") returned eTag \"%s\" (expected \"rev1\")", writeFileOutput.eTag)
+	}
+
+	_, err = dropboxContext.writeFile(&writeFileInputStruct{
+		filePath: "fileB",
+	})
+	if err != nil {
+		t.Fatalf("writeFile(\"fileB\") failed: %v", err)
+	}
+
+	statFileOutput, err = dropboxContext.statFile(&statFileInputStruct{
+		filePath: "fileA",
+	})
+	if err != nil {
+		t.Fatalf("statFile(\"fileA\") failed: %v", err)
+	}
+	if (statFileOutput.eTag != "rev1") || (statFileOutput.size != 10) || !statFileOutput.mTime.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("statFile(\"fileA\") returned %+v", statFileOutput)
+	}
+
+	_, err = dropboxContext.statFile(&statFileInputStruct{
+		filePath: "fileC",
+	})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statFile(\"fileC\") returned %v (expected ENOENT)", err)
+	}
+
+	readFileOutput, err = dropboxContext.readFile(&readFileInputStruct{
+		filePath:        "fileA",
+		offsetCacheLine: 1,
+		cacheLineSize:   4,
+		ifMatch:         "rev1",
+	})
+	if err != nil {
+		t.Fatalf("readFile(\"fileA\") failed: %v", err)
+	}
+	if (string(readFileOutput.buf) != "4567") || (readFileOutput.eTag != "rev1") {
+		t.Fatalf("readFile(\"fileA\") returned buf \"%s\" & eTag \"%s\"", string(readFileOutput.buf), readFileOutput.eTag)
+	}
+
+	readFileOutput, err = dropboxContext.readFile(&readFileInputStruct{
+		filePath:      "fileA",
+		cacheLineSize: 4,
+		ifNoneMatch:   "rev1",
+	})
+	if (err != nil) || !readFileOutput.notModified {
+		t.Fatalf("readFile(\"fileA\", ifNoneMatch: \"rev1\") did not report notModified (err: %v)", err)
+	}
+
+	_, err = dropboxContext.readFile(&readFileInputStruct{
+		filePath:      "fileA",
+		cacheLineSize: 4,
+		ifMatch:       "rev0",
+	})
+	if backendErrno(err) != syscall.ESTALE {
+		t.Fatalf("readFile(\"fileA\", ifMatch: \"rev0\") returned %v (expected ESTALE)", err)
+	}
+
+	listDirectoryOutput, err = dropboxContext.listDirectory(&listDirectoryInputStruct{})
+	if err != nil {
+		t.Fatalf("listDirectory(\"\") failed: %v", err)
+	}
+	if !listDirectoryOutput.isTruncated || (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "fileA") || (listDirectoryOutput.file[0].eTag != "rev1") {
+		t.Fatalf("listDirectory(\"\") returned %+v", listDirectoryOutput)
+	}
+
+	listDirectoryOutput, err = dropboxContext.listDirectory(&listDirectoryInputStruct{
+		continuationToken: listDirectoryOutput.nextContinuationToken,
+	})
+	if err != nil {
+		t.Fatalf("listDirectory(\"\") continuation failed: %v", err)
+	}
+	if listDirectoryOutput.isTruncated || (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "fileB") {
+		t.Fatalf("listDirectory(\"\") continuation returned %+v", listDirectoryOutput)
+	}
+
+	listObjectsOutput, err = dropboxContext.listObjects(&listObjectsInputStruct{})
+	if err != nil {
+		t.Fatalf("listObjects() failed: %v", err)
+	}
+	if (len(listObjectsOutput.object) != 1) || (listObjectsOutput.object[0].path != "fileA") {
+		t.Fatalf("listObjects() returned %+v", listObjectsOutput)
+	}
+
+	_, err = dropboxContext.deleteFile(&deleteFileInputStruct{
+		filePath: "fileA",
+		ifMatch:  "rev0",
+	})
+	if backendErrno(err) != syscall.ESTALE {
+		t.Fatalf("deleteFile(\"fileA\", ifMatch: \"rev0\") returned %v (expected ESTALE)", err)
+	}
+
+	_, err = dropboxContext.deleteFiles(&deleteFilesInputStruct{
+		filePaths: []string{"fileA", "fileC"},
+	})
+	if err != nil {
+		t.Fatalf("deleteFiles(\"fileA\",\"fileC\") failed: %v", err)
+	}
+
+	_, err = dropboxContext.readFile(&readFileInputStruct{
+		filePath:      "fileA",
+		cacheLineSize: 4,
+	})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("readFile(\"fileA\") after deleteFiles() returned %v (expected ENOENT)", err)
+	}
+}
+
+func TestDropboxAccessTokenRefresh(t *testing.T) {
+	var (
+		dropboxContext  *dropboxContextStruct
+		err             error
+		testServer      *httptest.Server
+		testServerState *testDropboxServerStruct
+	)
+
+	testServerState = &testDropboxServerStruct{
+		files:    map[string][]byte{"/root/fileA": []byte("A")},
+		fileRevs: map[string]string{"/root/fileA": "rev1"},
+	}
+
+	testServer = httptest.NewServer(testServerState)
+	defer testServer.Close()
+
+	dropboxContext = testDropboxContext(testServer, &backendConfigDropboxStruct{
+		refreshToken: "refresh",
+		appKey:       "key",
+	})
+
+	err = dropboxContext.loadAccessToken()
+	if err != nil {
+		t.Fatalf("loadAccessToken() failed: %v", err)
+	}
+	if (dropboxContext.accessToken != "token1") || dropboxContext.accessTokenExpiry.IsZero() {
+		t.Fatalf("loadAccessToken() obtained access token \"%s\" expiring %v", dropboxContext.accessToken, dropboxContext.accessTokenExpiry)
+	}
+
+	// Have the server revoke the access token such that the next request is rejected (once)
+
+	testServerState.Lock()
+	testServerState.accessToken = "revoked"
+	testServerState.Unlock()
+
+	_, err = dropboxContext.statFile(&statFileInputStruct{
+		filePath: "fileA",
+	})
+	if err != nil {
+		t.Fatalf("statFile(\"fileA\") with a revoked access token failed: %v", err)
+	}
+	if (testServerState.refreshCalls != 2) || (dropboxContext.accessToken != "token2") {
+		t.Fatalf("statFile(\"fileA\") with a revoked access token made %v refreshes & left access token \"%s\"", testServerState.refreshCalls, dropboxContext.accessToken)
+	}
+}
+
+func TestDropboxAPIArg(t *testing.T) {
+	var (
+		apiArg string
+		err    error
+	)
+
+	apiArg, err = dropboxAPIArg(&dropboxPathArgStruct{Path: "/café/😀"})
+	if err != nil {
+		t.Fatalf("dropboxAPIArg() failed: %v", err)
+	}
+	if apiArg != `{"path":"/caf\u00e9/\ud83d\ude00"}` {
+		t.Fatalf("dropboxAPIArg() returned %s", apiArg)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/NVIDIA/sortedmap"
@@ -60,6 +61,8 @@ func (backend *backendStruct) setupRAMContext() (err error) {
 
 // `deleteFile` is called to remove a "file" at the specified path.
 // If a `subdirectory` or nothing is found at that path, an error will be returned.
+// A path ending in "/" (i.e. a directory marker) instead removes that (real) directory
+// should it be empty.
 func (ramContext *ramContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
 	var (
 		dirName     []string
@@ -73,16 +76,38 @@ func (ramContext *ramContextStruct) deleteFile(deleteFileInput *deleteFileInputS
 	dirName, fileName, ramDir = ramContext.findFullPathElements(ramContext.canonicalFilePath(deleteFileInput.filePath))
 	if (len(dirName) + 1) > len(ramDir) {
 		// Not all directories in the path exist... so we know fileName does not exist
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
 	ramDirIndex = len(ramDir) - 1
 
+	if fileName == "" {
+		// As RAM is hierarchical, removing a "dir/" marker instead removes the real directory (should it be empty)
+
+		if ramDirIndex == 0 {
+			err = fmt.Errorf("file not found: %w", syscall.ENOENT)
+			return
+		}
+		if (ramDir[ramDirIndex].dirMap.Len() > 0) || (ramDir[ramDirIndex].fileMap.Len() > 0) {
+			err = fmt.Errorf("directory not empty: %w", syscall.ENOTEMPTY)
+			return
+		}
+
+		ok = ramDir[ramDirIndex-1].dirMap.DeleteByKey(ramDir[ramDirIndex].dirName)
+		if !ok {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] ramDir[ramDirIndex-1].dirMap.DeleteByKey(ramDir[ramDirIndex].dirName) returned !ok")
+		}
+
+		err = nil
+		return
+	}
+
 	fileContent, ok = ramDir[ramDirIndex].fileMap.GetByKey(fileName)
 	if !ok {
 		// Didn't find fileName in leaf ramDir... so we know fileName does not exist
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
@@ -121,6 +146,25 @@ func (ramContext *ramContextStruct) deleteFile(deleteFileInput *deleteFileInputS
 	return
 }
 
+// `deleteFiles` is called to remove the "files" at the specified paths.
+// Paths at which nothing is found are silently skipped.
+func (ramContext *ramContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		filePath string
+	)
+
+	for _, filePath = range deleteFilesInput.filePaths {
+		// The only possible failure is "file not found"... which we ignore
+		_, _ = ramContext.deleteFile(&deleteFileInputStruct{
+			filePath: filePath,
+			ifMatch:  "",
+		})
+	}
+
+	err = nil
+	return
+}
+
 // `listDirectory` is called to fetch a `page` of the `directory` at the specified path.
 // An empty continuationToken or empty list of directory elements (`subdirectories` and `files`)
 // indicates the `directory` has been completely enumerated. The `isTruncated` field will also
@@ -197,25 +241,28 @@ func (ramContext *ramContextStruct) listDirectory(listDirectoryInput *listDirect
 		numDirToReturn = 0
 	}
 
+	if continuationTokenAsUint64 < ramDirLeafDirMapLen {
+		numFileToReturn = ramDirLeafFileMapLen
+	} else if continuationTokenAsUint64 < (ramDirLeafDirMapLen + ramDirLeafFileMapLen) {
+		numFileToReturn = ramDirLeafDirMapLen + ramDirLeafFileMapLen - continuationTokenAsUint64
+	} else {
+		numFileToReturn = 0
+	}
+
 	if maxItems != 0 {
 		if maxItems <= numDirToReturn {
 			numDirToReturn = maxItems
 			numFileToReturn = 0
-		} else {
+		} else if (maxItems - numDirToReturn) < numFileToReturn {
 			numFileToReturn = maxItems - numDirToReturn
 		}
-	} else {
-		numFileToReturn = ramDirLeafFileMapLen
 	}
 
 	itemLimit = continuationTokenAsUint64 + numDirToReturn + numFileToReturn
 
-	listDirectoryOutput = &listDirectoryOutputStruct{
-		subdirectory:          make([]string, 0, numDirToReturn),
-		file:                  make([]listDirectoryOutputFileStruct, 0, numFileToReturn),
-		nextContinuationToken: strconv.FormatUint(itemLimit, 10),
-		isTruncated:           (itemLimit < (ramDirLeafDirMapLen + ramDirLeafFileMapLen)),
-	}
+	listDirectoryOutput = newListDirectoryOutput(listDirectoryInput, int(numDirToReturn), int(numFileToReturn))
+	listDirectoryOutput.nextContinuationToken = strconv.FormatUint(itemLimit, 10)
+	listDirectoryOutput.isTruncated = (itemLimit < (ramDirLeafDirMapLen + ramDirLeafFileMapLen))
 
 	for itemIndex = continuationTokenAsUint64; itemIndex < itemLimit; itemIndex++ {
 		if itemIndex < ramDirLeafDirMapLen {
@@ -297,6 +344,25 @@ func (ramContext *ramContextStruct) appendObjects(thisDir *ramDirStruct, thisDir
 	}
 }
 
+// `listMultipartUploads` is called to fetch the multipart uploads in progress of `files`
+// whose paths begin with the specified prefix. As the RAM backend performs each writeFile()
+// in its entirety, there are never any.
+func (ramContext *ramContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	listMultipartUploadsOutput = &listMultipartUploadsOutputStruct{
+		upload: make([]listMultipartUploadsOutputUploadStruct, 0),
+	}
+
+	err = nil
+	return
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts
+// uploaded. As listMultipartUploads() never reports any, this always fails.
+func (ramContext *ramContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("multipart upload \"%s\" of \"%s\" not found", abortMultipartUploadInput.uploadID, abortMultipartUploadInput.filePath)
+	return
+}
+
 // `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
 // empty list of elements (`objects`) indicates the list of `objects` has been completely
 // enumerated. The `isTruncated` field will also align with this convention.
@@ -406,7 +472,7 @@ func (ramContext *ramContextStruct) readFile(readFileInput *readFileInputStruct)
 	dirName, fileName, ramDir = ramContext.findFullPathElements(ramContext.canonicalFilePath(readFileInput.filePath))
 	if (len(dirName) + 1) > len(ramDir) {
 		// Not all directories in the path exist... so we know fileName does not exist
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
@@ -415,7 +481,7 @@ func (ramContext *ramContextStruct) readFile(readFileInput *readFileInputStruct)
 	fileContent, ok = ramDir[ramDirIndex].fileMap.GetByKey(fileName)
 	if !ok {
 		// Didn't find fileName in leaf ramDir... so we know fileName does not exist
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
@@ -425,8 +491,8 @@ func (ramContext *ramContextStruct) readFile(readFileInput *readFileInputStruct)
 
 	// Fetch copy of bytes to return
 
-	offset = readFileInput.offsetCacheLine * globals.config.cacheLineSize
-	limit = offset + globals.config.cacheLineSize
+	offset, limit = readFileInput.byteRange()
+	limit += offset
 
 	switch {
 	case offset >= uint64(len(fileContent)):
@@ -448,6 +514,13 @@ func (ramContext *ramContextStruct) readFile(readFileInput *readFileInputStruct)
 	return
 }
 
+// `prefetchFiles` is called to hint that the "files" at the specified paths
+// are likely to be read soon. As all "files" are already in memory, this is a no-op.
+func (ramContext *ramContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	err = nil
+	return
+}
+
 // `statDirectory` is called to verify that the specified path refers to a `directory`.
 // An error is returned if either the specified path is not a `directory` or non-existent.
 func (ramContext *ramContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
@@ -460,7 +533,7 @@ func (ramContext *ramContextStruct) statDirectory(statDirectoryInput *statDirect
 	dirName, fileName, ramDir = ramContext.findFullPathElements(ramContext.canonicalDirPath(statDirectoryInput.dirPath))
 	if (len(dirName)+1 > len(ramDir)) || (fileName != "") {
 		// Either not all directories in the path exist... or this is actually a reference to a file... so we know directory does not exist
-		err = errors.New("directory not found")
+		err = fmt.Errorf("directory not found: %w", syscall.ENOENT)
 		return
 	}
 
@@ -484,14 +557,14 @@ func (ramContext *ramContextStruct) statFile(statFileInput *statFileInputStruct)
 	dirName, fileName, ramDir = ramContext.findFullPathElements(ramContext.canonicalFilePath(statFileInput.filePath))
 	if (len(dirName)+1 > len(ramDir)) || (fileName == "") {
 		// Either not all directories in the path exist... or this is actually not a reference to a file... so we know file does not exist
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
 	fileContent, ok = ramDir[len(ramDir)-1].fileMap.GetByKey(fileName)
 	if !ok {
 		// Containing directory existed, but file didn't
-		err = errors.New("file not found")
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
 		return
 	}
 
@@ -505,6 +578,133 @@ func (ramContext *ramContextStruct) statFile(statFileInput *statFileInputStruct)
 	return
 }
 
+// `writeFile` is called to create (or replace) the "file" at the specified path,
+// creating any missing directories along the way. An error is returned if a
+// "subdirectory" exists at that path or max_total_object{s|_space} would be exceeded.
+// An empty "file" at a path ending in "/" (i.e. a directory marker) instead creates
+// that (real) directory.
+func (ramContext *ramContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		backendRAM      = ramContext.backend.backendTypeSpecifics.(*backendConfigRAMStruct)
+		dirName         []string
+		fileContent     []byte
+		fileName        string
+		ok              bool
+		oldFileContent  []byte
+		oldFileReplaced bool
+		ramDir          []*ramDirStruct
+	)
+
+	dirName, fileName, ramDir = ramContext.findFullPathElements(ramContext.canonicalFilePath(writeFileInput.filePath))
+	if fileName == "" {
+		if (len(dirName) == 0) || (len(writeFileInput.buf) != 0) {
+			err = errors.New("missing file name")
+			return
+		}
+
+		// As RAM is hierarchical, a (zero-byte) "dir/" marker instead creates a real (and possibly empty) directory
+
+		_, err = ramContext.createMissingDirectories(dirName, ramDir)
+		if err != nil {
+			return
+		}
+
+		writeFileOutput = &writeFileOutputStruct{
+			eTag: "",
+		}
+
+		return
+	}
+
+	if (len(dirName) + 1) == len(ramDir) {
+		_, ok = ramDir[len(ramDir)-1].dirMap.GetByKey(fileName)
+		if ok {
+			err = errors.New("directory found")
+			return
+		}
+		oldFileContent, oldFileReplaced = ramDir[len(ramDir)-1].fileMap.GetByKey(fileName)
+	}
+
+	if !oldFileReplaced && (ramContext.curTotalObjects >= backendRAM.maxTotalObjects) {
+		err = errors.New("max_total_objects exceeded")
+		return
+	}
+	if (ramContext.curTotalObjectSpace - uint64(len(oldFileContent)) + uint64(len(writeFileInput.buf))) > backendRAM.maxTotalObjectSpace {
+		err = errors.New("max_total_object_space exceeded")
+		return
+	}
+
+	// At this point, we know we will succeed... so first create any missing directories
+
+	ramDir, err = ramContext.createMissingDirectories(dirName, ramDir)
+	if err != nil {
+		return
+	}
+
+	fileContent = make([]byte, len(writeFileInput.buf))
+	_ = copy(fileContent, writeFileInput.buf)
+
+	if oldFileReplaced {
+		ok = ramDir[len(ramDir)-1].fileMap.DeleteByKey(fileName)
+		if !ok {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] ramDir[len(ramDir)-1].fileMap.DeleteByKey(fileName) returned !ok")
+		}
+		ramContext.curTotalObjects--
+		ramContext.curTotalObjectSpace -= uint64(len(oldFileContent))
+	}
+
+	ok = ramDir[len(ramDir)-1].fileMap.Put(fileName, fileContent)
+	if !ok {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] ramDir[len(ramDir)-1].fileMap.Put(fileName, fileContent) returned !ok")
+	}
+
+	ramContext.curTotalObjects++
+	ramContext.curTotalObjectSpace += uint64(len(fileContent))
+
+	writeFileOutput = &writeFileOutputStruct{
+		eTag: "",
+	}
+
+	err = nil
+	return
+}
+
+// `createMissingDirectories` creates those elements of dirName beyond the ramDir's found
+// by findFullPathElements(), returning ramDir extended by each. An error is returned if a
+// "file" is found where a directory is expected.
+func (ramContext *ramContextStruct) createMissingDirectories(dirName []string, ramDir []*ramDirStruct) (fullRAMDir []*ramDirStruct, err error) {
+	var (
+		dirNameElement string
+		newRAMDir      *ramDirStruct
+		ok             bool
+	)
+
+	fullRAMDir = ramDir
+
+	for _, dirNameElement = range dirName[len(fullRAMDir)-1:] {
+		_, ok = fullRAMDir[len(fullRAMDir)-1].fileMap.GetByKey(dirNameElement)
+		if ok {
+			err = errors.New("file found where directory expected")
+			return
+		}
+
+		newRAMDir = newRamDir(dirNameElement)
+
+		ok = fullRAMDir[len(fullRAMDir)-1].dirMap.Put(dirNameElement, newRAMDir)
+		if !ok {
+			dumpStack()
+			globals.logger.Fatalf("[FATAL] fullRAMDir[len(fullRAMDir)-1].dirMap.Put(dirNameElement, newRAMDir) returned !ok")
+		}
+
+		fullRAMDir = append(fullRAMDir, newRAMDir)
+	}
+
+	err = nil
+	return
+}
+
 // `canonicalDirPath` converts the supplied dirPath to `/[dirName/]*` (including ramContext.backend.prefix).
 func (ramContext *ramContextStruct) canonicalDirPath(dirPath string) (canonicalDirPath string) {
 	if ramContext.backend.prefix == "" {
@@ -787,3 +987,21 @@ func (*ramDirEntryFileMapStruct) DumpValue(value sortedmap.Value) (valueAsString
 	err = nil
 	return
 }
+
+// `createMultipartUpload` is not supported as the RAM backend performs each writeFile() in its entirety.
+func (ramContext *ramContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("createMultipartUpload not supported: %w", syscall.ENOTSUP)
+	return
+}
+
+// `uploadPart` is not supported as createMultipartUpload() never succeeds.
+func (ramContext *ramContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	err = fmt.Errorf("multipart upload \"%s\" of \"%s\" not found", uploadPartInput.uploadID, uploadPartInput.filePath)
+	return
+}
+
+// `completeMultipartUpload` is not supported as createMultipartUpload() never succeeds.
+func (ramContext *ramContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("multipart upload \"%s\" of \"%s\" not found", completeMultipartUploadInput.uploadID, completeMultipartUploadInput.filePath)
+	return
+}
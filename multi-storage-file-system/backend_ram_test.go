@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"syscall"
 	"testing"
 
@@ -73,3 +74,178 @@ func TestRAMBackend(t *testing.T) {
 		t.Fatalf("DoReleaseDir(ramDirFH) unexpectedly failed (errno: %v)", errno)
 	}
 }
+
+func TestRAMDeleteFiles(t *testing.T) {
+	var (
+		backend    = &backendStruct{}
+		err        error
+		ramContext *ramContextStruct
+	)
+
+	err = backend.setupRAMContext()
+	if err != nil {
+		t.Fatalf("setupRAMContext() failed: %v", err)
+	}
+
+	ramContext = backend.context.(*ramContextStruct)
+
+	for _, basename := range []string{"fileA", "fileB", "fileC"} {
+		if !ramContext.rootDir.fileMap.Put(basename, []byte(basename)) {
+			t.Fatalf("ramContext.rootDir.fileMap.Put(\"%s\") returned !ok", basename)
+		}
+		ramContext.curTotalObjects++
+		ramContext.curTotalObjectSpace += uint64(len(basename))
+	}
+
+	_, err = ramContext.deleteFiles(&deleteFilesInputStruct{
+		filePaths: []string{"fileA", "fileC", "fileZ"},
+	})
+	if err != nil {
+		t.Fatalf("deleteFiles() failed: %v", err)
+	}
+
+	if (ramContext.curTotalObjects != 1) || (ramContext.rootDir.fileMap.Len() != 1) {
+		t.Fatalf("deleteFiles() left %v objects (expected 1)", ramContext.curTotalObjects)
+	}
+	if _, ok := ramContext.rootDir.fileMap.GetByKey("fileB"); !ok {
+		t.Fatalf("deleteFiles() unexpectedly removed fileB")
+	}
+}
+
+func TestRAMListDirectoryReuse(t *testing.T) {
+	var (
+		allocsFresh         float64
+		allocsReused        float64
+		backend             = &backendStruct{}
+		err                 error
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		listed              []string
+		ramContext          *ramContextStruct
+	)
+
+	err = backend.setupRAMContext()
+	if err != nil {
+		t.Fatalf("setupRAMContext() failed: %v", err)
+	}
+
+	ramContext = backend.context.(*ramContextStruct)
+
+	for _, basename := range []string{"fileA", "fileB", "fileC", "fileD", "fileE"} {
+		if !ramContext.rootDir.fileMap.Put(basename, []byte(basename)) {
+			t.Fatalf("ramContext.rootDir.fileMap.Put(\"%s\") returned !ok", basename)
+		}
+	}
+
+	// Paging through the directory reusing each page for the next lists it in full
+
+	listDirectoryInput = &listDirectoryInputStruct{maxItems: 2}
+
+	for {
+		listDirectoryOutput, err = ramContext.listDirectory(listDirectoryInput)
+		if err != nil {
+			t.Fatalf("listDirectory() failed: %v", err)
+		}
+
+		for _, file := range listDirectoryOutput.file {
+			listed = append(listed, file.basename)
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			break
+		}
+
+		if (listDirectoryInput.reuse != nil) && (listDirectoryOutput != listDirectoryInput.reuse) {
+			t.Fatalf("listDirectory() did not reuse listDirectoryInput.reuse")
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+
+	if strings.Join(listed, ",") != "fileA,fileB,fileC,fileD,fileE" {
+		t.Fatalf("listDirectory() listed %v", listed)
+	}
+
+	// Reusing a page avoids allocating it and its slices
+
+	allocsFresh = testing.AllocsPerRun(100, func() {
+		_, _ = ramContext.listDirectory(&listDirectoryInputStruct{maxItems: 2})
+	})
+
+	listDirectoryInput = &listDirectoryInputStruct{maxItems: 2, reuse: listDirectoryOutput}
+
+	allocsReused = testing.AllocsPerRun(100, func() {
+		_, _ = ramContext.listDirectory(listDirectoryInput)
+	})
+
+	if allocsFresh-allocsReused < 2 {
+		t.Fatalf("listDirectory() reusing a page made %v allocations (vs %v without)", allocsReused, allocsFresh)
+	}
+}
+
+func TestRAMDirectoryMarker(t *testing.T) {
+	var (
+		backend = &backendStruct{
+			backendTypeSpecifics: &backendConfigRAMStruct{
+				maxTotalObjects:     10,
+				maxTotalObjectSpace: 1024,
+			},
+		}
+		err        error
+		ramContext *ramContextStruct
+	)
+
+	err = backend.setupRAMContext()
+	if err != nil {
+		t.Fatalf("setupRAMContext() failed: %v", err)
+	}
+
+	ramContext = backend.context.(*ramContextStruct)
+
+	// A "dir/" marker creates a real (empty) directory rather than an object
+
+	_, err = ramContext.writeFile(&writeFileInputStruct{filePath: "dirA/dirB/", buf: []byte{}})
+	if err != nil {
+		t.Fatalf("writeFile(\"dirA/dirB/\") failed: %v", err)
+	}
+	if ramContext.curTotalObjects != 0 {
+		t.Fatalf("writeFile(\"dirA/dirB/\") unexpectedly counted %v objects", ramContext.curTotalObjects)
+	}
+
+	_, err = ramContext.statDirectory(&statDirectoryInputStruct{dirPath: "dirA/dirB/"})
+	if err != nil {
+		t.Fatalf("statDirectory(\"dirA/dirB/\") failed: %v", err)
+	}
+
+	_, err = ramContext.writeFile(&writeFileInputStruct{filePath: "dirA/", buf: []byte("x")})
+	if err == nil {
+		t.Fatalf("writeFile(\"dirA/\") of a non-empty marker unexpectedly succeeded")
+	}
+
+	// Removing a "dir/" marker removes the directory only if empty (and leaves its parent)
+
+	_, err = ramContext.deleteFile(&deleteFileInputStruct{filePath: "dirA/"})
+	if backendErrno(err) != syscall.ENOTEMPTY {
+		t.Fatalf("deleteFile(\"dirA/\") returned err %v (expected ENOTEMPTY)", err)
+	}
+
+	_, err = ramContext.deleteFile(&deleteFileInputStruct{filePath: "dirA/dirB/"})
+	if err != nil {
+		t.Fatalf("deleteFile(\"dirA/dirB/\") failed: %v", err)
+	}
+
+	_, err = ramContext.statDirectory(&statDirectoryInputStruct{dirPath: "dirA/dirB/"})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statDirectory(\"dirA/dirB/\") after deleteFile() returned err %v (expected ENOENT)", err)
+	}
+	_, err = ramContext.statDirectory(&statDirectoryInputStruct{dirPath: "dirA/"})
+	if err != nil {
+		t.Fatalf("statDirectory(\"dirA/\") after deleteFile(\"dirA/dirB/\") failed: %v", err)
+	}
+
+	_, err = ramContext.deleteFile(&deleteFileInputStruct{filePath: "dirA/dirB/"})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("deleteFile(\"dirA/dirB/\") again returned err %v (expected ENOENT)", err)
+	}
+}
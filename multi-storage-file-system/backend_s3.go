@@ -1,30 +1,53 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyendpoints "github.com/aws/smithy-go/endpoints"
 )
 
 // `s3ContextStruct` holds the S3-specific backend details.
 type s3ContextStruct struct {
-	backend  *backendStruct
-	s3Client *s3.Client
+	sync.Mutex                                             // Protects conditionalRequests, nextProbe, sharedCredentials, refreshedCredentials, expiredLogged, and backendConfigS3Struct.{accessKeyID|secretAccessKey|sessionToken|sessionTokenExpiry}
+	backend              *backendStruct                    //
+	s3Client             *s3.Client                        //
+	conditionalRequests  string                            // One of S3ConditionalRequests*; if == S3ConditionalRequestsProbe, awaiting a conclusive probe
+	nextProbe            time.Time                         // If conditionalRequests == S3ConditionalRequestsProbe, no probe is issued before this time (following one underway or inconclusive)
+	quirks               s3ProviderQuirksStruct            // Those of S3.provider
+	credentialsCache     *aws.CredentialsCache             // Caches the credentials until invalidated by rotateCredentials() or reloadSharedCredentials()
+	configOptions        []func(*config.LoadOptions) error // If use_credentials_env == true, options with which reloadSharedCredentials() reloads the shared config & credentials files
+	sharedCredentials    aws.CredentialsProvider           // If use_credentials_env == true, provider resolved from the shared config & credentials files
+	refreshedCredentials *aws.Credentials                  // If != nil, most recently obtained from credential_refresh_{command|endpoint} (superseding the static credentials)
+	expiredLogged        bool                              // If true, the expiry of the static session_token (with no means to refresh it) has been logged
 }
 
+// `s3DeleteObjectsMax` is the maximum number of keys S3 accepts in a single DeleteObjects request.
+const s3DeleteObjectsMax = 1000
+
+// `s3ConditionalRequestsProbeInterval` is the minimum interval between probes of whether
+// or not If-Match is honored (see ifMatchHonored()) while they remain inconclusive.
+const s3ConditionalRequestsProbeInterval = time.Minute
+
 // `backendCommon` is called to return a pointer to the context's common `backendStruct`.
 func (backend *s3ContextStruct) backendCommon() (backendCommon *backendStruct) {
 	backendCommon = backend.backend
@@ -36,13 +59,29 @@ func (backend *s3ContextStruct) backendCommon() (backendCommon *backendStruct) {
 // Note that there is no `destroyContext` counterpart.
 func (backend *backendStruct) setupS3Context() (err error) {
 	var (
-		backendPathParsed *url.URL
-		backendS3         = backend.backendTypeSpecifics.(*backendConfigS3Struct)
-		configOptions     []func(*config.LoadOptions) error
-		s3Config          aws.Config
-		s3Endpoint        string
+		backendPathParsed    *url.URL
+		backendS3            = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+		bucketARN            arn.ARN
+		configOptions        []func(*config.LoadOptions) error
+		isAccessPointARN     bool
+		s3Context            *s3ContextStruct
+		s3Config             aws.Config
+		s3Endpoint           smithyendpoints.Endpoint
+		s3EndpointParameters s3.EndpointParameters
 	)
 
+	s3Context = &s3ContextStruct{
+		backend:             backend,
+		conditionalRequests: backendS3.conditionalRequests,
+		quirks:              s3ProviderQuirks[backendS3.provider],
+	}
+
+	backend.eTagValidator = eTagValidators[backendS3.eTagValidator]
+
+	if (s3Context.conditionalRequests == S3ConditionalRequestsProbe) && (s3Context.quirks.conditionalRequests != "") {
+		s3Context.conditionalRequests = s3Context.quirks.conditionalRequests
+	}
+
 	configOptions = []func(*config.LoadOptions) error{}
 
 	if backendS3.useConfigEnv || backendS3.useCredentialsEnv {
@@ -58,26 +97,28 @@ func (backend *backendStruct) setupS3Context() (err error) {
 	if backendS3.useCredentialsEnv {
 		configOptions = append(configOptions, config.WithSharedCredentialsFiles(([]string{backendS3.credentialsFilePath})))
 	} else {
-		configOptions = append(configOptions, config.WithSharedCredentialsFiles(nil), config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
-			Value: aws.Credentials{
-				AccessKeyID:     backendS3.accessKeyID,
-				SecretAccessKey: backendS3.secretAccessKey,
-			}}))
+		// The static credentials are fetched (via s3Context.credentialsCache) from backendS3
+		// at the time of each request so that a SIGHUP may rotate them (see rotateCredentials())
+		// and those referencing a leased secret are fetched anew as the lease nears expiry
+
+		s3Context.credentialsCache = aws.NewCredentialsCache(aws.CredentialsProviderFunc(s3Context.retrieveCredentials), func(o *aws.CredentialsCacheOptions) {
+			o.ExpiryWindow = backendS3.credentialRefreshWindow
+		})
+		configOptions = append(configOptions, config.WithSharedCredentialsFiles(nil), config.WithCredentialsProvider(s3Context.credentialsCache))
 	}
 
-	if backendS3.skipTLSCertificateVerify {
-		configOptions = append(configOptions, config.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+	configOptions = append(configOptions, config.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		backend.applyHTTPTransportOptions(t)
+		if backendS3.skipTLSCertificateVerify {
 			if t.TLSClientConfig == nil {
 				t.TLSClientConfig = &tls.Config{}
 			}
 			t.TLSClientConfig.InsecureSkipVerify = true
 			t.TLSClientConfig.MinVersion = tls.VersionTLS12
-		})))
-	}
+		}
+	})))
 
-	configOptions = append(configOptions, config.WithRetryer(func() aws.Retryer {
-		return backend
-	}))
+	configOptions = append(configOptions, config.WithRetryer(backend.newRetryer))
 
 	s3Config, err = config.LoadDefaultConfig(context.Background(), configOptions...)
 	if err != nil {
@@ -85,46 +126,305 @@ func (backend *backendStruct) setupS3Context() (err error) {
 		return
 	}
 
-	if backendS3.useConfigEnv {
-		if s3Config.BaseEndpoint == nil {
-			err = errors.New("s3Config.BaseEndpoint == nil")
-			return
+	if backendS3.useCredentialsEnv && (s3Config.Credentials != nil) {
+		// The provider resolved from the shared config & credentials files is replaced
+		// by reloadSharedCredentials() should either file change (see credwatch.go)
+
+		s3Context.configOptions = configOptions
+		s3Context.sharedCredentials = s3Config.Credentials
+		s3Context.credentialsCache = aws.NewCredentialsCache(aws.CredentialsProviderFunc(s3Context.retrieveSharedCredentials))
+		s3Config.Credentials = s3Context.credentialsCache
+	}
+
+	// Unless an endpoint is specified, it is resolved by the SDK from the
+	// region (or an Access Point ARN) including its partition (e.g. "aws-cn"
+	// or "aws-us-gov"). For a custom partition, dns_suffix supplies the
+	// endpoint's domain. Either way, the SDK applies the bucket name to the
+	// endpoint's host or path as appropriate. If use_config_env, the config
+	// file must specify the endpoint (unless an Access Point ARN supplies it).
+
+	if !backendS3.useConfigEnv {
+		if backendS3.endpoint != "" {
+			s3Config.BaseEndpoint = aws.String(backendS3.endpoint)
+		} else if backendS3.dnsSuffix != "" {
+			s3Config.BaseEndpoint = aws.String("https://s3." + backendS3.region + "." + backendS3.dnsSuffix)
 		}
-		backendPathParsed, err = url.Parse(*s3Config.BaseEndpoint)
+	}
+
+	bucketARN, isAccessPointARN = s3AccessPointARN(backend.bucketContainerName)
+	if isAccessPointARN && (bucketARN.Region != "") {
+		// Requests of an Access Point are resolved to (and signed for) the region,
+		// and hence partition, of its ARN rather than that configured
+
+		s3Config.Region = bucketARN.Region
+	}
+
+	if backendS3.useConfigEnv && (s3Config.BaseEndpoint == nil) && !isAccessPointARN {
+		err = errors.New("s3Config.BaseEndpoint == nil")
+		return
+	}
+
+	s3EndpointParameters = s3.EndpointParameters{
+		Bucket:         aws.String(backend.bucketContainerName),
+		Region:         aws.String(s3Config.Region),
+		Endpoint:       s3Config.BaseEndpoint,
+		ForcePathStyle: aws.Bool(!backendS3.virtualHostedStyleRequest && !isAccessPointARN),
+	}
+
+	s3Endpoint, err = s3.NewDefaultEndpointResolverV2().ResolveEndpoint(context.Background(), s3EndpointParameters.WithDefaults())
+	if err != nil {
+		err = fmt.Errorf("[S3] ResolveEndpoint() failed: %v", err)
+		return
+	}
+
+	backendPathParsed = &s3Endpoint.URI
+
+	if backend.prefix == "" {
+		backend.backendPath = strings.TrimSuffix(backendPathParsed.String(), "/") + "/"
+	} else {
+		backendPathParsed.Path = strings.TrimSuffix(backendPathParsed.Path, "/") + "/" + backend.prefix
+		backend.backendPath = backendPathParsed.String()
+	}
+
+	if !backendS3.useCredentialsEnv && (isSecretRef(backendS3.accessKeyID) || isSecretRef(backendS3.secretAccessKey) || isSecretRef(backendS3.sessionToken) || backendS3.credentialRefreshConfigured()) {
+		// Fetch referenced secrets (or refreshed credentials) now so that any failure to do so prevents mounting
+
+		_, err = s3Context.credentialsCache.Retrieve(context.Background())
 		if err != nil {
-			err = fmt.Errorf("url.Parse(*s3Config.BaseEndpoint) failed: %v", err)
+			err = fmt.Errorf("[S3] retrieving credentials failed: %v", err)
 			return
 		}
-	} else {
-		backendPathParsed, err = url.Parse(backendS3.endpoint)
+	}
+
+	if (backendS3.signatureVersion == S3SignatureVersionV2) && isAccessPointARN {
+		err = errors.New("[S3] signature_version \"v2\" not supported for an Access Point ARN")
+		return
+	}
+
+	s3Context.s3Client = s3.NewFromConfig(s3Config, func(o *s3.Options) {
+		o.UsePathStyle = !backendS3.virtualHostedStyleRequest && !isAccessPointARN
+		o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		if s3Context.quirks.requestChecksumsUnsupported {
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		}
+		if backendS3.signatureVersion == S3SignatureVersionV2 {
+			// Legacy gateways understand neither SigV4 nor the (aws-chunked) trailing
+			// checksums the SDK would otherwise add to uploads
+
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.APIOptions = append(o.APIOptions, useSignatureV2(o.Credentials, backend.bucketContainerName, o.UsePathStyle))
+		}
+	})
+
+	backend.context = s3Context
+
+	return
+}
+
+// `retrieveCredentials` returns the static credentials (resolving any that reference a
+// secret). Should any referenced secret have a lease, the credentials expire with it (or
+// at session_token_expiry if sooner). Once the static credentials are within
+// credential_refresh_window of expiring (or if none were supplied), those obtained from
+// credential_refresh_{command|endpoint} (if configured) are returned instead.
+func (s3Context *s3ContextStruct) retrieveCredentials(ctx context.Context) (awsCredentials aws.Credentials, err error) {
+	var (
+		backendS3            = s3Context.backend.backendTypeSpecifics.(*backendConfigS3Struct)
+		expiry               time.Time
+		refreshedCredentials *aws.Credentials
+		resolved             []string
+		sessionTokenExpiry   time.Time
+	)
+
+	s3Context.Lock()
+	resolved = []string{backendS3.accessKeyID, backendS3.secretAccessKey, backendS3.sessionToken}
+	sessionTokenExpiry = backendS3.sessionTokenExpiry
+	refreshedCredentials = s3Context.refreshedCredentials
+	s3Context.Unlock()
+
+	if backendS3.credentialRefreshConfigured() && ((refreshedCredentials != nil) || (resolved[0] == "") || sessionTokenExpiry.IsZero() || time.Now().Add(backendS3.credentialRefreshWindow).After(sessionTokenExpiry)) {
+		awsCredentials, err = backendS3.refreshCredentials(ctx)
 		if err != nil {
-			err = fmt.Errorf("url.Parse(backendS3.endpoint) failed: %v", err)
+			globals.logger.Printf("[WARN] [credentials] %s unable to refresh credentials: %v", s3Context.backend.dirName, err)
+			if (refreshedCredentials != nil) || (resolved[0] == "") || sessionTokenExpiry.IsZero() || !time.Now().Before(sessionTokenExpiry) {
+				return
+			}
+
+			// Fall back to the (not yet expired) static credentials
+
+			err = nil
+		} else {
+			s3Context.Lock()
+			s3Context.refreshedCredentials = &awsCredentials
+			s3Context.Unlock()
+
+			globals.logger.Printf("[INFO] [credentials] %s refreshed credentials (expiring %v)", s3Context.backend.dirName, awsCredentials.Expires)
+
 			return
 		}
 	}
 
-	if backendS3.virtualHostedStyleRequest {
-		backendPathParsed.Host = backend.bucketContainerName + "." + backendPathParsed.Host
-		s3Endpoint = backendPathParsed.Scheme + "://" + backendPathParsed.Host + backendPathParsed.Path
-	} else {
-		s3Endpoint = backendPathParsed.Scheme + "://" + backendPathParsed.Host + backendPathParsed.Path
-		backendPathParsed.Path += "/" + backend.bucketContainerName
+	resolved, expiry, err = resolveSecrets(resolved...)
+	if err != nil {
+		return
 	}
 
-	if backend.prefix == "" {
-		backend.backendPath = backendPathParsed.String() + "/"
-	} else {
-		backendPathParsed.Path += "/" + backend.prefix
-		backend.backendPath = backendPathParsed.String()
+	awsCredentials, err = credentials.StaticCredentialsProvider{
+		Value: aws.Credentials{
+			AccessKeyID:     resolved[0],
+			SecretAccessKey: resolved[1],
+			SessionToken:    resolved[2],
+		}}.Retrieve(ctx)
+	if err != nil {
+		return
 	}
 
-	backend.context = &s3ContextStruct{
-		backend: backend,
-		s3Client: s3.NewFromConfig(s3Config, func(o *s3.Options) {
-			o.BaseEndpoint = aws.String(s3Endpoint)
-			o.UsePathStyle = !backendS3.virtualHostedStyleRequest
-			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
-		}),
+	if !sessionTokenExpiry.IsZero() && (expiry.IsZero() || sessionTokenExpiry.Before(expiry)) {
+		expiry = sessionTokenExpiry
+
+		if !time.Now().Before(expiry) && !backendS3.credentialRefreshConfigured() {
+			s3Context.Lock()
+			if !s3Context.expiredLogged {
+				s3Context.expiredLogged = true
+				globals.logger.Printf("[WARN] [credentials] %s session_token expired at %v (and neither credential_refresh_command nor credential_refresh_endpoint is configured)", s3Context.backend.dirName, expiry)
+				globals.webhooks.notify(webhookEventCredentialExpiry, s3Context.backend.dirName, fmt.Sprintf("session_token expired at %v (and neither credential_refresh_command nor credential_refresh_endpoint is configured)", expiry))
+			}
+			s3Context.Unlock()
+		}
+	}
+
+	if !expiry.IsZero() {
+		awsCredentials.CanExpire = true
+		awsCredentials.Expires = expiry
+	}
+
+	return
+}
+
+// `retrieveSharedCredentials` returns the credentials of the provider most recently
+// resolved from the shared config & credentials files.
+func (s3Context *s3ContextStruct) retrieveSharedCredentials(ctx context.Context) (awsCredentials aws.Credentials, err error) {
+	var (
+		sharedCredentials aws.CredentialsProvider
+	)
+
+	s3Context.Lock()
+	sharedCredentials = s3Context.sharedCredentials
+	s3Context.Unlock()
+
+	awsCredentials, err = sharedCredentials.Retrieve(ctx)
+
+	return
+}
+
+// `reloadSharedCredentials` re-resolves the credentials provider from the shared config
+// & credentials files such that subsequent requests use the credentials they now specify.
+// Should the files fail to load, the prior credentials continue to be used.
+func (s3Context *s3ContextStruct) reloadSharedCredentials() (err error) {
+	var (
+		s3Config aws.Config
+	)
+
+	if s3Context.sharedCredentials == nil {
+		return
+	}
+
+	s3Config, err = config.LoadDefaultConfig(context.Background(), s3Context.configOptions...)
+	if err != nil {
+		err = fmt.Errorf("[S3] config.LoadDefaultConfig() failed: %v", err)
+		return
+	}
+	if s3Config.Credentials == nil {
+		err = errors.New("[S3] config.LoadDefaultConfig() resolved no credentials")
+		return
+	}
+
+	s3Context.Lock()
+	s3Context.sharedCredentials = s3Config.Credentials
+	s3Context.Unlock()
+
+	s3Context.credentialsCache.Invalidate()
+
+	return
+}
+
+// `rotateCredentials` replaces the static access_key_id, secret_access_key, session_token,
+// & session_token_expiry used by subsequent requests with those of backendS3New (discarding
+// any refreshed credentials). Requests already signed with the prior credentials are unaffected.
+func (s3Context *s3ContextStruct) rotateCredentials(backendS3New *backendConfigS3Struct) {
+	var (
+		backendS3 = s3Context.backend.backendTypeSpecifics.(*backendConfigS3Struct)
+	)
+
+	s3Context.Lock()
+	backendS3.accessKeyID = backendS3New.accessKeyID
+	backendS3.secretAccessKey = backendS3New.secretAccessKey
+	backendS3.sessionToken = backendS3New.sessionToken
+	backendS3.sessionTokenExpiry = backendS3New.sessionTokenExpiry
+	s3Context.refreshedCredentials = nil
+	s3Context.expiredLogged = false
+	s3Context.Unlock()
+
+	if s3Context.credentialsCache != nil {
+		s3Context.credentialsCache.Invalidate()
+	}
+}
+
+// `s3AccessPointARN` determines if bucketContainerName is an S3 Access Point
+// ARN rather than a bucket name. If the returned ARN has no Region, it refers
+// to a Multi-Region Access Point (MRAP).
+func s3AccessPointARN(bucketContainerName string) (bucketARN arn.ARN, isAccessPointARN bool) {
+	var (
+		err error
+	)
+
+	if !arn.IsARN(bucketContainerName) {
+		return
+	}
+
+	bucketARN, err = arn.Parse(bucketContainerName)
+	if err != nil {
+		return
+	}
+
+	isAccessPointARN = (bucketARN.Service == "s3") && strings.HasPrefix(bucketARN.Resource, "accesspoint/")
+
+	return
+}
+
+// `retryErrorClass` classifies a failed request's error in order to select
+// the per-error-class retry delay settings. Throttling responses (429 and
+// 503, the latter being how S3 reports "SlowDown") are distinguished from
+// other server errors and from failures where no HTTP response was received.
+func retryErrorClass(err error) (errorClass string) {
+	var (
+		httpErr *awshttp.ResponseError
+	)
+
+	if !errors.As(err, &httpErr) {
+		errorClass = "transport"
+		return
+	}
+
+	switch httpErr.HTTPStatusCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		errorClass = "throttle"
+	default:
+		errorClass = "server"
+	}
+
+	return
+}
+
+// `retryDelayBounds` returns the base and max delay applicable to the
+// supplied error's class.
+func (backendS3 *backendConfigS3Struct) retryDelayBounds(err error) (baseDelay, maxDelay time.Duration) {
+	switch retryErrorClass(err) {
+	case "throttle":
+		baseDelay, maxDelay = backendS3.retryThrottleBaseDelay, backendS3.retryThrottleMaxDelay
+	case "server":
+		baseDelay, maxDelay = backendS3.retryServerBaseDelay, backendS3.retryServerMaxDelay
+	default:
+		baseDelay, maxDelay = backendS3.retryTransportBaseDelay, backendS3.retryTransportMaxDelay
 	}
 
 	return
@@ -135,6 +435,7 @@ func (backend *backendStruct) setupS3Context() (err error) {
 // https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/aws/retry#AdaptiveMode.IsErrorRetryable.
 func (backend *backendStruct) IsErrorRetryable(err error) bool {
 	var (
+		baseDelay         time.Duration
 		httpErr           *awshttp.ResponseError
 		httpErrStatusCode int
 	)
@@ -143,6 +444,11 @@ func (backend *backendStruct) IsErrorRetryable(err error) bool {
 		return false
 	}
 
+	baseDelay, _ = backend.backendTypeSpecifics.(*backendConfigS3Struct).retryDelayBounds(err)
+	if baseDelay == time.Duration(0) {
+		return false
+	}
+
 	if !errors.As(err, &httpErr) {
 		return true
 	}
@@ -165,24 +471,134 @@ func (backend *backendStruct) IsErrorRetryable(err error) bool {
 // (including the initial attempt) to be made for a retryable request.
 // See https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/aws/retry#Standard.MaxAttempts.
 func (backend *backendStruct) MaxAttempts() int {
-	return len(backend.backendTypeSpecifics.(*backendConfigS3Struct).retryDelay) + 1
+	return backend.backendTypeSpecifics.(*backendConfigS3Struct).retryAttempts
 }
 
 // `RetryDelay` is an aws.Retryer callback that returns the delay before a previously
-// failed request should be retried.
+// failed request should be retried. The delay grows exponentially from the error
+// class's base delay, is capped at the error class's max delay, and then has the
+// configured jitter applied so that concurrent retries do not arrive in lockstep.
 // See https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/aws/retry#Standard.RetryDelay.
-func (backend *backendStruct) RetryDelay(attempt int, _ error) (time.Duration, error) {
-	if (attempt < 1) || (attempt > len(backend.backendTypeSpecifics.(*backendConfigS3Struct).retryDelay)) {
-		return time.Duration(0), fmt.Errorf("unexpected attempt: %v (should have been in [1:%v])", attempt, len(backend.backendTypeSpecifics.(*backendConfigS3Struct).retryDelay))
+func (backend *backendStruct) RetryDelay(attempt int, opErr error) (time.Duration, error) {
+	var (
+		backendS3 = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+		baseDelay time.Duration
+		delay     float64
+		maxDelay  time.Duration
+		step      int
+	)
+
+	if (attempt < 1) || (attempt >= backendS3.retryAttempts) {
+		return time.Duration(0), fmt.Errorf("unexpected attempt: %v (should have been in [1:%v])", attempt, backendS3.retryAttempts-1)
+	}
+
+	baseDelay, maxDelay = backendS3.retryDelayBounds(opErr)
+
+	delay = float64(baseDelay)
+
+	for step = 1; (step < attempt) && (delay < float64(maxDelay)); step++ {
+		delay *= backendS3.retryNextDelayMultiplier
+	}
+
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
 	}
 
-	return backend.backendTypeSpecifics.(*backendConfigS3Struct).retryDelay[attempt-1], nil
+	switch backendS3.retryJitter {
+	case S3RetryJitterFull:
+		delay = rand.Float64() * delay
+	case S3RetryJitterEqual:
+		delay = (delay / 2) + (rand.Float64() * (delay / 2))
+	default:
+		// No jitter applied
+	}
+
+	return time.Duration(delay), nil
+}
+
+// `newRetryer` returns the aws.Retryer to be used by the S3 client. In the
+// standard retry mode, the backend itself is the aws.Retryer. In the adaptive
+// retry mode, the SDK's AdaptiveMode wraps the backend's callbacks in order to
+// additionally rate limit attempts while throttling responses are being received.
+func (backend *backendStruct) newRetryer() (retryer aws.Retryer) {
+	if backend.backendTypeSpecifics.(*backendConfigS3Struct).retryMode != S3RetryModeAdaptive {
+		retryer = backend
+		return
+	}
+
+	retryer = &s3AdaptiveRetryerStruct{backend: backend, RetryerV2: retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+			so.MaxAttempts = backend.MaxAttempts()
+			so.Backoff = retry.BackoffDelayerFunc(backend.RetryDelay)
+			so.Retryables = []retry.IsErrorRetryable{
+				retry.IsErrorRetryableFunc(func(err error) aws.Ternary {
+					return aws.BoolTernary(backend.IsErrorRetryable(err))
+				}),
+			}
+		})
+	})}
+
+	return
+}
+
+// `s3AdaptiveRetryerStruct` wraps the SDK's AdaptiveMode such that its retries (and those due to
+// throttling) are counted (as they are in the standard retry mode by the backend's own GetRetryToken).
+type s3AdaptiveRetryerStruct struct {
+	aws.RetryerV2
+	backend *backendStruct
+}
+
+// `GetRetryToken` sheds the retry about to be performed should the retry budget (if any) be
+// exhausted and otherwise counts it before deferring to AdaptiveMode.
+func (retryer *s3AdaptiveRetryerStruct) GetRetryToken(ctx context.Context, opErr error) (releaseToken func(error) error, err error) {
+	if !globals.retryBudget.withdraw() {
+		err = fmt.Errorf("retry budget exhausted: %w", opErr)
+		return
+	}
+
+	retryer.backend.retries.Add(1)
+	if retryErrorClass(opErr) == "throttle" {
+		retryer.backend.throttles.Add(1)
+	}
+
+	return retryer.RetryerV2.GetRetryToken(ctx, opErr)
+}
+
+// `newRequestContext` returns the context.Context to be used for a single
+// backend operation (including all of its retries). If retry_max_elapsed
+// was specified, the returned context.Context will expire after that long.
+// The caller must invoke the returned context.CancelFunc once the operation
+// (including consuming any response body) has completed.
+func (s3Context *s3ContextStruct) newRequestContext() (ctx context.Context, cancel context.CancelFunc) {
+	var (
+		retryMaxElapsed = s3Context.backend.backendTypeSpecifics.(*backendConfigS3Struct).retryMaxElapsed
+	)
+
+	if retryMaxElapsed == time.Duration(0) {
+		ctx, cancel = context.WithCancel(context.Background())
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), retryMaxElapsed)
+	}
+
+	return
 }
 
 // `GetRetryToken` is an aws.Retryer callback that returns a func used to additionally
 // apply a retry `cost` for performing a retry of a previously failed request.
 // See https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/aws/retry#Standard.GetRetryToken.
+// As it is invoked just prior to each retry, it is also where retries (and those due to
+// throttling) are counted and where retries beyond the retry budget (if any) are shed.
 func (backend *backendStruct) GetRetryToken(ctx context.Context, opErr error) (releaseToken func(error) error, err error) {
+	if !globals.retryBudget.withdraw() {
+		err = fmt.Errorf("retry budget exhausted: %w", opErr)
+		return
+	}
+
+	backend.retries.Add(1)
+	if retryErrorClass(opErr) == "throttle" {
+		backend.throttles.Add(1)
+	}
+
 	return func(error) error {
 		return nil
 	}, nil
@@ -208,49 +624,228 @@ func (backend *backendStruct) GetAttemptToken(context.Context) (func(error) erro
 	}, nil
 }
 
+// `ifMatchHonored` reports whether or not the endpoint is known to honor
+// If-Match such that a single conditional request may be issued rather than
+// preceding it with a HeadObject to (non-atomically) compare ETags. While
+// still probing, a HeadObject of fullFilePath is issued with an If-Match
+// that cannot possibly match: a 412 (Precondition Failed) response indicates
+// If-Match is honored while a success indicates it was ignored. Any other
+// outcome is inconclusive, so If-Match is presumed ignored (without probing
+// again) until s3ConditionalRequestsProbeInterval has passed. Likewise, calls
+// made while a probe is underway do not issue one of their own.
+func (s3Context *s3ContextStruct) ifMatchHonored(ctx context.Context, fullFilePath string) (honored bool) {
+	var (
+		conditionalRequests string
+		err                 error
+		now                 = time.Now()
+	)
+
+	if !s3Context.backend.eTagValidatorOf().ifMatchReliable() {
+		honored = false
+		return
+	}
+
+	s3Context.Lock()
+	conditionalRequests = s3Context.conditionalRequests
+	if conditionalRequests == S3ConditionalRequestsProbe {
+		if now.Before(s3Context.nextProbe) {
+			conditionalRequests = S3ConditionalRequestsUnsupported
+		} else {
+			s3Context.nextProbe = now.Add(s3ConditionalRequestsProbeInterval)
+		}
+	}
+	s3Context.Unlock()
+
+	switch conditionalRequests {
+	case S3ConditionalRequestsSupported:
+		honored = true
+		return
+	case S3ConditionalRequestsUnsupported:
+		honored = false
+		return
+	}
+
+	_, err = s3Context.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:  aws.String(s3Context.backend.bucketContainerName),
+		Key:     aws.String(fullFilePath),
+		IfMatch: aws.String("\"msfs-conditional-request-probe\""),
+	})
+
+	switch {
+	case err == nil:
+		conditionalRequests = S3ConditionalRequestsUnsupported
+	case isPreconditionFailed(err):
+		conditionalRequests = S3ConditionalRequestsSupported
+	default:
+		honored = false
+		return
+	}
+
+	s3Context.Lock()
+	if s3Context.conditionalRequests == S3ConditionalRequestsProbe {
+		s3Context.conditionalRequests = conditionalRequests
+		globals.logger.Printf("[INFO] %s conditional requests probe concluded: %s", s3Context.backend.dirName, conditionalRequests)
+	}
+	s3Context.Unlock()
+
+	honored = (conditionalRequests == S3ConditionalRequestsSupported)
+
+	return
+}
+
+// `ifMatch` returns eTag as the value of an If-Match request header or, should eTag be
+// == "" or the backend's eTag validator not rely upon If-Match, nil (i.e. none).
+func (s3Context *s3ContextStruct) ifMatch(eTag string) *string {
+	if (eTag == "") || !s3Context.backend.eTagValidatorOf().ifMatchReliable() {
+		return nil
+	}
+
+	return aws.String(eTag)
+}
+
+// `isNotModified` reports whether or not err resulted from a 304 (Not Modified) response.
+func isNotModified(err error) (notModified bool) {
+	var (
+		httpErr *awshttp.ResponseError
+	)
+
+	notModified = errors.As(err, &httpErr) && (httpErr.HTTPStatusCode() == http.StatusNotModified)
+
+	return
+}
+
+// `isPreconditionFailed` reports whether or not err resulted from a 412 (Precondition Failed) response.
+func isPreconditionFailed(err error) (preconditionFailed bool) {
+	var (
+		httpErr *awshttp.ResponseError
+	)
+
+	preconditionFailed = errors.As(err, &httpErr) && (httpErr.HTTPStatusCode() == http.StatusPreconditionFailed)
+
+	return
+}
+
 // `deleteFile` is called to remove a "file" at the specified path.
 // If a `subdirectory` or nothing is found at that path, an error will be returned.
 func (s3Context *s3ContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
 	var (
 		backend             = s3Context.backend
+		cancel              context.CancelFunc
+		ctx                 context.Context
+		depth               int
 		fullFilePath        = backend.prefix + deleteFileInput.filePath
 		s3DeleteObjectInput *s3.DeleteObjectInput
 		s3HeadObjectInput   *s3.HeadObjectInput
 		s3HeadObjectOutput  *s3.HeadObjectOutput
 	)
 
-	// Note: .IfMatch not necessarily supported, so we must (also) do the non-atomic manual ETag comparison check
-
-	s3HeadObjectInput = &s3.HeadObjectInput{
-		Bucket: aws.String(backend.bucketContainerName),
-		Key:    aws.String(fullFilePath),
-	}
-	if deleteFileInput.ifMatch != "" {
-		s3HeadObjectInput.IfMatch = aws.String(deleteFileInput.ifMatch)
-	}
-
-	s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(context.Background(), s3HeadObjectInput)
-	if err != nil {
+	_, _, _, depth = s3Context.versionsPath(deleteFileInput.filePath)
+	if depth != 0 {
+		err = fmt.Errorf("[S3] deleteFile failed: %w", syscall.EROFS)
 		return
 	}
-	if deleteFileInput.ifMatch != "" {
-		if s3HeadObjectOutput.ETag != nil {
-			if deleteFileInput.ifMatch != strings.TrimLeft(strings.TrimRight(*s3HeadObjectOutput.ETag, "\""), "\"") {
-				err = errors.New("eTag mismatch")
-				return
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	// Note: .IfMatch not necessarily supported, so unless the endpoint is known to honor it,
+	//       we must (also) do the non-atomic manual ETag comparison check
+
+	if (deleteFileInput.ifMatch == "") || !s3Context.ifMatchHonored(ctx, fullFilePath) {
+		s3HeadObjectInput = &s3.HeadObjectInput{
+			Bucket:  aws.String(backend.bucketContainerName),
+			Key:     aws.String(fullFilePath),
+			IfMatch: s3Context.ifMatch(deleteFileInput.ifMatch),
+		}
+
+		s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(ctx, s3HeadObjectInput)
+		if err != nil {
+			return
+		}
+		if deleteFileInput.ifMatch != "" {
+			if s3HeadObjectOutput.ETag != nil {
+				if !backend.eTagValidatorOf().match(deleteFileInput.ifMatch, *s3HeadObjectOutput.ETag) {
+					err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+					return
+				}
 			}
 		}
 	}
 
 	s3DeleteObjectInput = &s3.DeleteObjectInput{
-		Bucket: aws.String(backend.bucketContainerName),
-		Key:    aws.String(fullFilePath),
+		Bucket:  aws.String(backend.bucketContainerName),
+		Key:     aws.String(fullFilePath),
+		IfMatch: s3Context.ifMatch(deleteFileInput.ifMatch),
 	}
-	if deleteFileInput.ifMatch != "" {
-		s3DeleteObjectInput.IfMatch = aws.String(deleteFileInput.ifMatch)
+
+	_, err = s3Context.s3Client.DeleteObject(ctx, s3DeleteObjectInput)
+	if isPreconditionFailed(err) {
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 	}
 
-	_, err = s3Context.s3Client.DeleteObject(context.Background(), s3DeleteObjectInput)
+	return
+}
+
+// `deleteFiles` is called to remove the "files" at the specified paths using
+// DeleteObjects requests of up to s3DeleteObjectsMax keys each.
+// Paths at which nothing is found are silently skipped.
+func (s3Context *s3ContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		backend               = s3Context.backend
+		batchEnd              int
+		batchStart            int
+		cancel                context.CancelFunc
+		ctx                   context.Context
+		filePath              string
+		fullFilePaths         []string
+		s3DeleteObjectsInput  *s3.DeleteObjectsInput
+		s3DeleteObjectsOutput *s3.DeleteObjectsOutput
+		s3ObjectIdentifiers   []types.ObjectIdentifier
+	)
+
+	if s3Context.quirks.multiObjectDeleteUnsupported {
+		fullFilePaths = make([]string, 0, len(deleteFilesInput.filePaths))
+		for _, filePath = range deleteFilesInput.filePaths {
+			fullFilePaths = append(fullFilePaths, backend.prefix+filePath)
+		}
+
+		err = s3Context.deleteObjects(fullFilePaths)
+
+		return
+	}
+
+	for batchStart = 0; batchStart < len(deleteFilesInput.filePaths); batchStart = batchEnd {
+		batchEnd = min(batchStart+s3DeleteObjectsMax, len(deleteFilesInput.filePaths))
+
+		s3ObjectIdentifiers = make([]types.ObjectIdentifier, 0, batchEnd-batchStart)
+		for _, filePath = range deleteFilesInput.filePaths[batchStart:batchEnd] {
+			s3ObjectIdentifiers = append(s3ObjectIdentifiers, types.ObjectIdentifier{
+				Key: aws.String(backend.prefix + filePath),
+			})
+		}
+
+		s3DeleteObjectsInput = &s3.DeleteObjectsInput{
+			Bucket: aws.String(backend.bucketContainerName),
+			Delete: &types.Delete{
+				Objects: s3ObjectIdentifiers,
+				Quiet:   aws.Bool(true),
+			},
+		}
+
+		ctx, cancel = s3Context.newRequestContext()
+		s3DeleteObjectsOutput, err = s3Context.s3Client.DeleteObjects(ctx, s3DeleteObjectsInput)
+		cancel()
+		if err != nil {
+			return
+		}
+
+		// Note: Deleting a non-existent key is reported as success, so any error here is genuine
+
+		if len(s3DeleteObjectsOutput.Errors) > 0 {
+			err = fmt.Errorf("DeleteObjects failed for %v key(s) (first: \"%s\": %s)", len(s3DeleteObjectsOutput.Errors), aws.ToString(s3DeleteObjectsOutput.Errors[0].Key), aws.ToString(s3DeleteObjectsOutput.Errors[0].Message))
+			return
+		}
+	}
 
 	return
 }
@@ -262,13 +857,28 @@ func (s3Context *s3ContextStruct) deleteFile(deleteFileInput *deleteFileInputStr
 func (s3Context *s3ContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
 	var (
 		backend               = s3Context.backend
+		basename              string
+		cancel                context.CancelFunc
+		ctx                   context.Context
+		depth                 int
 		fullDirPath           = backend.prefix + listDirectoryInput.dirPath
+		nextContinuationToken string
+		parentDirPath         string
 		s3CommonPrefix        types.CommonPrefix
 		s3ListObjectsV2Input  *s3.ListObjectsV2Input
 		s3ListObjectsV2Output *s3.ListObjectsV2Output
 		s3Object              types.Object
 	)
 
+	parentDirPath, basename, _, depth = s3Context.versionsPath(listDirectoryInput.dirPath)
+	if depth != 0 {
+		listDirectoryOutput, err = s3Context.listVersionsDirectory(listDirectoryInput, parentDirPath, basename, depth)
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
 	s3ListObjectsV2Input = &s3.ListObjectsV2Input{
 		Bucket:    aws.String(backend.bucketContainerName),
 		Prefix:    aws.String(fullDirPath),
@@ -276,34 +886,30 @@ func (s3Context *s3ContextStruct) listDirectory(listDirectoryInput *listDirector
 	}
 	if listDirectoryInput.continuationToken != "" {
 		s3ListObjectsV2Input.ContinuationToken = aws.String(listDirectoryInput.continuationToken)
+	} else if listDirectoryInput.startAfter != "" {
+		s3ListObjectsV2Input.StartAfter = aws.String(fullDirPath + listDirectoryInput.startAfter)
 	}
 	if listDirectoryInput.maxItems != 0 {
 		s3ListObjectsV2Input.MaxKeys = aws.Int32(int32(listDirectoryInput.maxItems))
 	}
 
-	s3ListObjectsV2Output, err = s3Context.s3Client.ListObjectsV2(context.Background(), s3ListObjectsV2Input)
+	s3ListObjectsV2Output, nextContinuationToken, err = s3Context.listObjectsV2(ctx, s3ListObjectsV2Input)
 	if err != nil {
 		err = fmt.Errorf("[S3] listDirectory failed: %v", err)
 		return
 	}
 
-	listDirectoryOutput = &listDirectoryOutputStruct{
-		subdirectory: make([]string, 0, len(s3ListObjectsV2Output.CommonPrefixes)),
-		file:         make([]listDirectoryOutputFileStruct, 0, len(s3ListObjectsV2Output.Contents)),
-	}
+	// Note the (possible) additional subdirectory for s3VersionsDirName
 
-	if s3ListObjectsV2Output.NextContinuationToken == nil {
-		listDirectoryOutput.nextContinuationToken = ""
-	} else {
-		listDirectoryOutput.nextContinuationToken = *s3ListObjectsV2Output.NextContinuationToken
-	}
-
-	// AWS S3 neglects to set s3ListObjectsV2Output.IsTruncated properly, so we
-	// instead compute our listDirectoryOutput.isTruncated value on whether or now
-	// listDirectoryOutput.nextContinuationToken is above set to a non-empty string
+	listDirectoryOutput = newListDirectoryOutput(listDirectoryInput, len(s3ListObjectsV2Output.CommonPrefixes)+1, len(s3ListObjectsV2Output.Contents))
 
+	listDirectoryOutput.nextContinuationToken = nextContinuationToken
 	listDirectoryOutput.isTruncated = (listDirectoryOutput.nextContinuationToken != "")
 
+	if (listDirectoryInput.continuationToken == "") && (listDirectoryInput.startAfter == "") && backend.backendTypeSpecifics.(*backendConfigS3Struct).exposeVersions {
+		listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, s3VersionsDirName)
+	}
+
 	for _, s3CommonPrefix = range s3ListObjectsV2Output.CommonPrefixes {
 		listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, strings.TrimSuffix(strings.TrimPrefix(*s3CommonPrefix.Prefix, fullDirPath), "/"))
 	}
@@ -320,17 +926,94 @@ func (s3Context *s3ContextStruct) listDirectory(listDirectoryInput *listDirector
 	return
 }
 
+// `listMultipartUploads` is called to fetch the multipart uploads in progress of `files`
+// whose paths begin with the specified prefix (issuing as many ListMultipartUploads
+// requests as necessary to enumerate them all).
+func (s3Context *s3ContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	var (
+		backend                      = s3Context.backend
+		cancel                       context.CancelFunc
+		ctx                          context.Context
+		s3ListMultipartUploadsInput  *s3.ListMultipartUploadsInput
+		s3ListMultipartUploadsOutput *s3.ListMultipartUploadsOutput
+		s3MultipartUpload            types.MultipartUpload
+	)
+
+	listMultipartUploadsOutput = &listMultipartUploadsOutputStruct{
+		upload: make([]listMultipartUploadsOutputUploadStruct, 0),
+	}
+
+	s3ListMultipartUploadsInput = &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(backend.bucketContainerName),
+		Prefix: aws.String(backend.prefix + listMultipartUploadsInput.prefix),
+	}
+
+	for {
+		ctx, cancel = s3Context.newRequestContext()
+		s3ListMultipartUploadsOutput, err = s3Context.s3Client.ListMultipartUploads(ctx, s3ListMultipartUploadsInput)
+		cancel()
+		if err != nil {
+			err = fmt.Errorf("[S3] listMultipartUploads failed: %v", err)
+			return
+		}
+
+		for _, s3MultipartUpload = range s3ListMultipartUploadsOutput.Uploads {
+			listMultipartUploadsOutput.upload = append(listMultipartUploadsOutput.upload, listMultipartUploadsOutputUploadStruct{
+				filePath:  strings.TrimPrefix(aws.ToString(s3MultipartUpload.Key), backend.prefix),
+				uploadID:  aws.ToString(s3MultipartUpload.UploadId),
+				initiated: aws.ToTime(s3MultipartUpload.Initiated),
+			})
+		}
+
+		if !aws.ToBool(s3ListMultipartUploadsOutput.IsTruncated) {
+			return
+		}
+
+		s3ListMultipartUploadsInput.KeyMarker = s3ListMultipartUploadsOutput.NextKeyMarker
+		s3ListMultipartUploadsInput.UploadIdMarker = s3ListMultipartUploadsOutput.NextUploadIdMarker
+	}
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload discarding any parts uploaded.
+func (s3Context *s3ContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	var (
+		backend = s3Context.backend
+		cancel  context.CancelFunc
+		ctx     context.Context
+	)
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	_, err = s3Context.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(backend.bucketContainerName),
+		Key:      aws.String(backend.prefix + abortMultipartUploadInput.filePath),
+		UploadId: aws.String(abortMultipartUploadInput.uploadID),
+	})
+	if err == nil {
+		abortMultipartUploadOutput = &abortMultipartUploadOutputStruct{}
+	}
+
+	return
+}
+
 // `listObjects` is called to fetch a `page` of the objects. An empty continuationToken or
 // empty list of elements (`objects`) indicates the list of `objects` has been completely
 // enumerated. The `isTruncated` field will also align with this convention.
 func (s3Context *s3ContextStruct) listObjects(listObjectsInput *listObjectsInputStruct) (listObjectsOutput *listObjectsOutputStruct, err error) {
 	var (
 		backend               = s3Context.backend
+		cancel                context.CancelFunc
+		ctx                   context.Context
+		nextContinuationToken string
 		s3ListObjectsV2Input  *s3.ListObjectsV2Input
 		s3ListObjectsV2Output *s3.ListObjectsV2Output
 		s3Object              types.Object
 	)
 
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
 	s3ListObjectsV2Input = &s3.ListObjectsV2Input{
 		Bucket: aws.String(backend.bucketContainerName),
 		Prefix: aws.String(backend.prefix),
@@ -342,7 +1025,7 @@ func (s3Context *s3ContextStruct) listObjects(listObjectsInput *listObjectsInput
 		s3ListObjectsV2Input.MaxKeys = aws.Int32(int32(listObjectsInput.maxItems))
 	}
 
-	s3ListObjectsV2Output, err = s3Context.s3Client.ListObjectsV2(context.Background(), s3ListObjectsV2Input)
+	s3ListObjectsV2Output, nextContinuationToken, err = s3Context.listObjectsV2(ctx, s3ListObjectsV2Input)
 	if err != nil {
 		err = fmt.Errorf("[S3] listDirectory failed: %v", err)
 		return
@@ -352,16 +1035,7 @@ func (s3Context *s3ContextStruct) listObjects(listObjectsInput *listObjectsInput
 		object: make([]listObjectsOutputObjectStruct, 0, len(s3ListObjectsV2Output.Contents)),
 	}
 
-	if s3ListObjectsV2Output.NextContinuationToken == nil {
-		listObjectsOutput.nextContinuationToken = ""
-	} else {
-		listObjectsOutput.nextContinuationToken = *s3ListObjectsV2Output.NextContinuationToken
-	}
-
-	// AWS S3 neglects to set s3ListObjectsV2Output.IsTruncated properly, so we
-	// instead compute our listDirectoryOutput.isTruncated value on whether or now
-	// listDirectoryOutput.nextContinuationToken is above set to a non-empty string
-
+	listObjectsOutput.nextContinuationToken = nextContinuationToken
 	listObjectsOutput.isTruncated = (listObjectsOutput.nextContinuationToken != "")
 
 	for _, s3Object = range s3ListObjectsV2Output.Contents {
@@ -381,48 +1055,80 @@ func (s3Context *s3ContextStruct) listObjects(listObjectsInput *listObjectsInput
 func (s3Context *s3ContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
 	var (
 		backend            = s3Context.backend
+		cancel             context.CancelFunc
+		ctx                context.Context
 		fullFilePath       = backend.prefix + readFileInput.filePath
-		rangeBegin         = readFileInput.offsetCacheLine * globals.config.cacheLineSize
-		rangeEnd           = rangeBegin + globals.config.cacheLineSize - 1
+		rangeBegin         uint64
+		rangeEnd           uint64
+		rangeSize          uint64
 		s3GetObjectInput   *s3.GetObjectInput
 		s3GetObjectOutput  *s3.GetObjectOutput
 		s3HeadObjectInput  *s3.HeadObjectInput
 		s3HeadObjectOutput *s3.HeadObjectOutput
+		basename           string
+		depth              int
+		parentDirPath      string
+		versionName        string
 	)
 
-	// Note: .IfMatch not necessarily supported, so we must (also) do the non-atomic manual ETag comparison check
+	rangeBegin, rangeSize = readFileInput.byteRange()
+	rangeEnd = rangeBegin + rangeSize - 1
 
-	s3HeadObjectInput = &s3.HeadObjectInput{
-		Bucket: aws.String(backend.bucketContainerName),
-		Key:    aws.String(fullFilePath),
-	}
-	if readFileInput.ifMatch != "" {
-		s3HeadObjectInput.IfMatch = aws.String(readFileInput.ifMatch)
-	}
-
-	s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(context.Background(), s3HeadObjectInput)
-	if err != nil {
+	parentDirPath, basename, versionName, depth = s3Context.versionsPath(readFileInput.filePath)
+	if depth != 0 {
+		readFileOutput, err = s3Context.readVersionFile(readFileInput, parentDirPath, basename, versionName, depth)
 		return
 	}
-	if readFileInput.ifMatch != "" {
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	// Note: .IfMatch not necessarily supported, so unless the endpoint is known to honor it,
+	//       we must (also) do the non-atomic manual ETag comparison check
+
+	if (readFileInput.ifMatch != "") && !s3Context.ifMatchHonored(ctx, fullFilePath) {
+		s3HeadObjectInput = &s3.HeadObjectInput{
+			Bucket:  aws.String(backend.bucketContainerName),
+			Key:     aws.String(fullFilePath),
+			IfMatch: s3Context.ifMatch(readFileInput.ifMatch),
+		}
+
+		s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(ctx, s3HeadObjectInput)
+		if err != nil {
+			return
+		}
 		if s3HeadObjectOutput.ETag != nil {
-			if readFileInput.ifMatch != strings.TrimLeft(strings.TrimRight(*s3HeadObjectOutput.ETag, "\""), "\"") {
-				err = errors.New("eTag mismatch")
+			if !backend.eTagValidatorOf().match(readFileInput.ifMatch, *s3HeadObjectOutput.ETag) {
+				err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 				return
 			}
 		}
 	}
 
 	s3GetObjectInput = &s3.GetObjectInput{
-		Bucket: aws.String(backend.bucketContainerName),
-		Key:    aws.String(fullFilePath),
-		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd)),
+		Bucket:  aws.String(backend.bucketContainerName),
+		Key:     aws.String(fullFilePath),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd)),
+		IfMatch: s3Context.ifMatch(readFileInput.ifMatch),
 	}
-	if readFileInput.ifMatch != "" {
-		s3GetObjectInput.IfMatch = aws.String(readFileInput.ifMatch)
+	if readFileInput.ifNoneMatch != "" {
+		s3GetObjectInput.IfNoneMatch = aws.String(readFileInput.ifNoneMatch)
 	}
 
-	s3GetObjectOutput, err = s3Context.s3Client.GetObject(context.Background(), s3GetObjectInput)
+	s3GetObjectOutput, err = s3Context.s3Client.GetObject(ctx, s3GetObjectInput)
+	if isPreconditionFailed(err) {
+		err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+	if (readFileInput.ifNoneMatch != "") && isNotModified(err) {
+		readFileOutput = &readFileOutputStruct{
+			eTag:        readFileInput.ifNoneMatch,
+			buf:         make([]byte, 0),
+			notModified: true,
+		}
+		err = nil
+		return
+	}
 	if err == nil {
 		readFileOutput = &readFileOutputStruct{}
 		if s3GetObjectOutput.ETag == nil {
@@ -430,32 +1136,84 @@ func (s3Context *s3ContextStruct) readFile(readFileInput *readFileInputStruct) (
 		} else {
 			readFileOutput.eTag = *s3GetObjectOutput.ETag
 		}
-		readFileOutput.buf, err = io.ReadAll(s3GetObjectOutput.Body)
+		readFileOutput.buf, err = readS3Body(s3GetObjectOutput.Body, s3GetObjectOutput.ContentLength, rangeSize)
 	}
 
 	return
 }
 
+// `readS3Body` reads (and closes) the body of a ranged GetObject response. As its length
+// (if reported) cannot exceed rangeSize, it is read directly into a buffer obtained from
+// getCacheLineBuf() rather than one repeatedly grown (and copied) by io.ReadAll(). Should the
+// endpoint not report the length or have ignored the requested range, io.ReadAll() is used.
+func readS3Body(body io.ReadCloser, contentLength *int64, rangeSize uint64) (buf []byte, err error) {
+	var (
+		n int
+	)
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	if (contentLength == nil) || (*contentLength < 0) || (uint64(*contentLength) > rangeSize) {
+		buf, err = io.ReadAll(body)
+		return
+	}
+
+	buf = getCacheLineBuf(rangeSize)
+
+	n, err = io.ReadFull(body, buf[:*contentLength])
+	if err != nil {
+		putCacheLineBuf(buf)
+		buf = nil
+		return
+	}
+
+	buf = buf[:n]
+
+	return
+}
+
+// `prefetchFiles` is called to hint that the "files" at the specified paths
+// are likely to be read soon. S3 offers no such facility, so this is a no-op.
+func (s3Context *s3ContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	return
+}
+
 // `statDirectory` is called to verify that the specified path refers to a `directory`.
 // An error is returned if either the specified path is not a `directory` or non-existent.
 func (s3Context *s3ContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
 	var (
 		backend               = s3Context.backend
+		cancel                context.CancelFunc
+		ctx                   context.Context
 		fullDirPath           = backend.prefix + statDirectoryInput.dirPath
 		s3ListObjectsV2Input  *s3.ListObjectsV2Input
 		s3ListObjectsV2Output *s3.ListObjectsV2Output
+		basename              string
+		depth                 int
+		parentDirPath         string
 	)
 
+	parentDirPath, basename, _, depth = s3Context.versionsPath(statDirectoryInput.dirPath)
+	if depth != 0 {
+		statDirectoryOutput, err = s3Context.statVersionsDirectory(parentDirPath, basename, depth)
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
 	s3ListObjectsV2Input = &s3.ListObjectsV2Input{
 		Bucket:  aws.String(backend.bucketContainerName),
 		MaxKeys: aws.Int32(1),
 		Prefix:  aws.String(fullDirPath),
 	}
 
-	s3ListObjectsV2Output, err = s3Context.s3Client.ListObjectsV2(context.Background(), s3ListObjectsV2Input)
+	s3ListObjectsV2Output, _, err = s3Context.listObjectsV2(ctx, s3ListObjectsV2Input)
 	if err == nil {
 		if (fullDirPath != "") && ((len(s3ListObjectsV2Output.CommonPrefixes) + len(s3ListObjectsV2Output.Contents)) == 0) {
-			err = errors.New("missing directory")
+			err = fmt.Errorf("missing directory: %w", syscall.ENOENT)
 			return
 		}
 
@@ -470,29 +1228,42 @@ func (s3Context *s3ContextStruct) statDirectory(statDirectoryInput *statDirector
 func (s3Context *s3ContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
 	var (
 		backend            = s3Context.backend
+		cancel             context.CancelFunc
+		ctx                context.Context
 		fullFilePath       = backend.prefix + statFileInput.filePath
 		s3HeadObjectInput  *s3.HeadObjectInput
 		s3HeadObjectOutput *s3.HeadObjectOutput
+		basename           string
+		depth              int
+		parentDirPath      string
+		versionName        string
 	)
 
+	parentDirPath, basename, versionName, depth = s3Context.versionsPath(statFileInput.filePath)
+	if depth != 0 {
+		statFileOutput, err = s3Context.statVersionFile(parentDirPath, basename, versionName, depth)
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
 	// Note: .IfMatch not necessarily supported, so we must (also) do the non-atomic manual ETag comparison check
 
 	s3HeadObjectInput = &s3.HeadObjectInput{
-		Bucket: aws.String(backend.bucketContainerName),
-		Key:    aws.String(fullFilePath),
-	}
-	if statFileInput.ifMatch != "" {
-		s3HeadObjectInput.IfMatch = aws.String(statFileInput.ifMatch)
+		Bucket:  aws.String(backend.bucketContainerName),
+		Key:     aws.String(fullFilePath),
+		IfMatch: s3Context.ifMatch(statFileInput.ifMatch),
 	}
 
-	s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(context.Background(), s3HeadObjectInput)
+	s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(ctx, s3HeadObjectInput)
 	if err != nil {
 		return
 	}
 	if statFileInput.ifMatch != "" {
 		if s3HeadObjectOutput.ETag != nil {
-			if statFileInput.ifMatch != strings.TrimLeft(strings.TrimRight(*s3HeadObjectOutput.ETag, "\""), "\"") {
-				err = errors.New("eTag mismatch")
+			if !backend.eTagValidatorOf().match(statFileInput.ifMatch, *s3HeadObjectOutput.ETag) {
+				err = fmt.Errorf("eTag mismatch: %w", syscall.ESTALE)
 				return
 			}
 		}
@@ -506,3 +1277,151 @@ func (s3Context *s3ContextStruct) statFile(statFileInput *statFileInputStruct) (
 
 	return
 }
+
+// `writeFile` is called to create (or replace) the "file" at the specified path
+// with a single PutObject request.
+func (s3Context *s3ContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		backend           = s3Context.backend
+		cancel            context.CancelFunc
+		ctx               context.Context
+		s3PutObjectOutput *s3.PutObjectOutput
+		depth             int
+	)
+
+	_, _, _, depth = s3Context.versionsPath(writeFileInput.filePath)
+	if depth != 0 {
+		err = fmt.Errorf("[S3] writeFile failed: %w", syscall.EROFS)
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3PutObjectOutput, err = s3Context.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(backend.bucketContainerName),
+		Key:           aws.String(backend.prefix + writeFileInput.filePath),
+		Body:          bytes.NewReader(writeFileInput.buf),
+		ContentLength: aws.Int64(int64(len(writeFileInput.buf))),
+	})
+	if err != nil {
+		return
+	}
+
+	writeFileOutput = &writeFileOutputStruct{
+		eTag: strings.TrimLeft(strings.TrimRight(aws.ToString(s3PutObjectOutput.ETag), "\""), "\""),
+	}
+
+	return
+}
+
+// `createMultipartUpload` is called to begin creating (or replacing) the `file` at the specified
+// path via a sequence of uploadPart() calls concluded by completeMultipartUpload().
+func (s3Context *s3ContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	var (
+		backend                       = s3Context.backend
+		cancel                        context.CancelFunc
+		ctx                           context.Context
+		depth                         int
+		s3CreateMultipartUploadOutput *s3.CreateMultipartUploadOutput
+	)
+
+	_, _, _, depth = s3Context.versionsPath(createMultipartUploadInput.filePath)
+	if depth != 0 {
+		err = fmt.Errorf("[S3] createMultipartUpload failed: %w", syscall.EROFS)
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3CreateMultipartUploadOutput, err = s3Context.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(backend.bucketContainerName),
+		Key:    aws.String(backend.prefix + createMultipartUploadInput.filePath),
+	})
+	if err != nil {
+		err = fmt.Errorf("[S3] createMultipartUpload failed: %v", err)
+		return
+	}
+
+	createMultipartUploadOutput = &createMultipartUploadOutputStruct{
+		uploadID: aws.ToString(s3CreateMultipartUploadOutput.UploadId),
+	}
+
+	return
+}
+
+// `uploadPart` is called to upload one part of the specified multipart upload.
+func (s3Context *s3ContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	var (
+		backend            = s3Context.backend
+		cancel             context.CancelFunc
+		ctx                context.Context
+		s3UploadPartOutput *s3.UploadPartOutput
+	)
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3UploadPartOutput, err = s3Context.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(backend.bucketContainerName),
+		Key:           aws.String(backend.prefix + uploadPartInput.filePath),
+		UploadId:      aws.String(uploadPartInput.uploadID),
+		PartNumber:    aws.Int32(int32(uploadPartInput.partNumber)),
+		Body:          bytes.NewReader(uploadPartInput.buf),
+		ContentLength: aws.Int64(int64(len(uploadPartInput.buf))),
+	})
+	if err != nil {
+		err = fmt.Errorf("[S3] uploadPart failed: %v", err)
+		return
+	}
+
+	uploadPartOutput = &uploadPartOutputStruct{
+		eTag: aws.ToString(s3UploadPartOutput.ETag),
+	}
+
+	return
+}
+
+// `completeMultipartUpload` is called to assemble the parts uploaded (in partNumber order)
+// into the `file` at the specified path concluding the specified multipart upload.
+func (s3Context *s3ContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	var (
+		backend                         = s3Context.backend
+		cancel                          context.CancelFunc
+		ctx                             context.Context
+		partETag                        string
+		partIndex                       int
+		s3CompleteMultipartUploadOutput *s3.CompleteMultipartUploadOutput
+		s3CompletedParts                = make([]types.CompletedPart, 0, len(completeMultipartUploadInput.partETag))
+	)
+
+	for partIndex, partETag = range completeMultipartUploadInput.partETag {
+		s3CompletedParts = append(s3CompletedParts, types.CompletedPart{
+			ETag:       aws.String(partETag),
+			PartNumber: aws.Int32(int32(partIndex + 1)),
+		})
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3CompleteMultipartUploadOutput, err = s3Context.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(backend.bucketContainerName),
+		Key:      aws.String(backend.prefix + completeMultipartUploadInput.filePath),
+		UploadId: aws.String(completeMultipartUploadInput.uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: s3CompletedParts,
+		},
+	})
+	if err != nil {
+		err = fmt.Errorf("[S3] completeMultipartUpload failed: %v", err)
+		return
+	}
+
+	completeMultipartUploadOutput = &completeMultipartUploadOutputStruct{
+		eTag: strings.TrimLeft(strings.TrimRight(aws.ToString(s3CompleteMultipartUploadOutput.ETag), "\""), "\""),
+	}
+
+	return
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// `s3ProviderQuirksStruct` describes how an S3-compatible provider departs from the
+// behavior the S3 backend otherwise assumes (i.e. that of AWS S3).
+type s3ProviderQuirksStruct struct {
+	conditionalRequests          string // If != "", supersedes a conditional_requests of S3ConditionalRequestsProbe (whose HeadObject probe would mislead)
+	eTagValidator                string // The default etag_validator (one of ETagValidator*)
+	listObjectsV1                bool   // If true, ListObjectsV2 is unreliable so listings instead issue (Marker-based) ListObjects requests
+	listIsTruncatedHonored       bool   // If true, a listing's IsTruncated (rather than the presence of a NextContinuationToken) determines if another page follows
+	multiObjectDeleteUnsupported bool   // If true, DeleteObjects is unavailable so deleteFiles() issues a DeleteObject per file
+	requestChecksumsUnsupported  bool   // If true, checksums (and aws-chunked uploads) are only sent with requests that require them
+}
+
+// `s3ProviderQuirks` maps each supported S3.provider to its quirks.
+var s3ProviderQuirks = map[string]s3ProviderQuirksStruct{
+	S3ProviderAWS: {
+		eTagValidator: ETagValidatorStrong,
+	},
+
+	// Ceph's RGW sets IsTruncated faithfully but may return a NextContinuationToken
+	// along with the final page of a listing. Some releases have also reported the eTag
+	// of a multipart upload without its "-<number of parts>" suffix
+
+	S3ProviderCeph: {
+		eTagValidator:          ETagValidatorMultipart,
+		listIsTruncatedHonored: true,
+	},
+
+	// The GCS XML API's interoperability with S3 covers neither multi-object delete
+	// nor the aws-chunked encoding of uploads bearing trailing checksums. Its support
+	// of ListObjectsV2 pagination (in particular, with a Delimiter) is incomplete, while
+	// conditional headers are honored by reads but not by DeleteObject. An object stored
+	// gzip-compressed may be served decompressed with a weak eTag
+
+	S3ProviderGCS: {
+		conditionalRequests:          S3ConditionalRequestsUnsupported,
+		eTagValidator:                ETagValidatorWeak,
+		listObjectsV1:                true,
+		listIsTruncatedHonored:       true,
+		multiObjectDeleteUnsupported: true,
+		requestChecksumsUnsupported:  true,
+	},
+
+	// R2 honors If-Match on reads (so a probe would succeed) but not on DeleteObject.
+	// Responses compressed by Cloudflare's network carry weak eTags
+
+	S3ProviderR2: {
+		conditionalRequests: S3ConditionalRequestsUnsupported,
+		eTagValidator:       ETagValidatorWeak,
+	},
+}
+
+// `listObjectsV2` issues s3ListObjectsV2Input as a ListObjectsV2 (or, should the provider's
+// quirks call for it, an equivalent ListObjects) request. Along with its output, the
+// continuation token (if any) of the following page is returned as determined per the
+// provider's quirks.
+func (s3Context *s3ContextStruct) listObjectsV2(ctx context.Context, s3ListObjectsV2Input *s3.ListObjectsV2Input) (s3ListObjectsV2Output *s3.ListObjectsV2Output, nextContinuationToken string, err error) {
+	var (
+		s3ListObjectsInput  *s3.ListObjectsInput
+		s3ListObjectsOutput *s3.ListObjectsOutput
+	)
+
+	if !s3Context.quirks.listObjectsV1 {
+		s3ListObjectsV2Output, err = s3Context.s3Client.ListObjectsV2(ctx, s3ListObjectsV2Input)
+		if err != nil {
+			return
+		}
+
+		// AWS S3 neglects to set s3ListObjectsV2Output.IsTruncated properly, so unless the
+		// provider is known to honor it, whether or not a NextContinuationToken was returned
+		// determines if another page follows
+
+		if !s3Context.quirks.listIsTruncatedHonored || aws.ToBool(s3ListObjectsV2Output.IsTruncated) {
+			nextContinuationToken = aws.ToString(s3ListObjectsV2Output.NextContinuationToken)
+		}
+
+		return
+	}
+
+	s3ListObjectsInput = &s3.ListObjectsInput{
+		Bucket:    s3ListObjectsV2Input.Bucket,
+		Prefix:    s3ListObjectsV2Input.Prefix,
+		Delimiter: s3ListObjectsV2Input.Delimiter,
+		MaxKeys:   s3ListObjectsV2Input.MaxKeys,
+	}
+	if s3ListObjectsV2Input.ContinuationToken != nil {
+		s3ListObjectsInput.Marker = s3ListObjectsV2Input.ContinuationToken
+	} else {
+		s3ListObjectsInput.Marker = s3ListObjectsV2Input.StartAfter
+	}
+
+	s3ListObjectsOutput, err = s3Context.s3Client.ListObjects(ctx, s3ListObjectsInput)
+	if err != nil {
+		return
+	}
+
+	s3ListObjectsV2Output = &s3.ListObjectsV2Output{
+		CommonPrefixes: s3ListObjectsOutput.CommonPrefixes,
+		Contents:       s3ListObjectsOutput.Contents,
+		IsTruncated:    s3ListObjectsOutput.IsTruncated,
+	}
+
+	if aws.ToBool(s3ListObjectsOutput.IsTruncated) {
+		// NextMarker is only returned if a Delimiter was specified, else (or should it
+		// be missing anyway) the page's last key or common prefix serves as the marker
+
+		nextContinuationToken = aws.ToString(s3ListObjectsOutput.NextMarker)
+		if nextContinuationToken == "" {
+			if len(s3ListObjectsOutput.Contents) > 0 {
+				nextContinuationToken = aws.ToString(s3ListObjectsOutput.Contents[len(s3ListObjectsOutput.Contents)-1].Key)
+			}
+			if (len(s3ListObjectsOutput.CommonPrefixes) > 0) && (aws.ToString(s3ListObjectsOutput.CommonPrefixes[len(s3ListObjectsOutput.CommonPrefixes)-1].Prefix) > nextContinuationToken) {
+				nextContinuationToken = aws.ToString(s3ListObjectsOutput.CommonPrefixes[len(s3ListObjectsOutput.CommonPrefixes)-1].Prefix)
+			}
+		}
+	}
+
+	return
+}
+
+// `deleteObjects` removes each of fullFilePaths with a DeleteObject request of its own
+// for a provider lacking DeleteObjects. As with DeleteObjects, a missing key is not an error.
+func (s3Context *s3ContextStruct) deleteObjects(fullFilePaths []string) (err error) {
+	var (
+		cancel       context.CancelFunc
+		ctx          context.Context
+		fullFilePath string
+	)
+
+	for _, fullFilePath = range fullFilePaths {
+		ctx, cancel = s3Context.newRequestContext()
+		_, err = s3Context.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s3Context.backend.bucketContainerName),
+			Key:    aws.String(fullFilePath),
+		})
+		cancel()
+		if (err != nil) && (backendErrno(err) != syscall.ENOENT) {
+			return
+		}
+	}
+
+	err = nil
+
+	return
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// `s3SignatureV2SubResources` are the query parameters that, per Signature Version 2,
+// are included in the CanonicalizedResource of a request's StringToSign.
+var s3SignatureV2SubResources = []string{
+	"acl", "cors", "delete", "lifecycle", "location", "logging", "notification", "partNumber",
+	"policy", "requestPayment", "response-cache-control", "response-content-disposition",
+	"response-content-encoding", "response-content-language", "response-content-type",
+	"response-expires", "restore", "tagging", "torrent", "uploadId", "uploads", "versionId",
+	"versioning", "versions", "website",
+}
+
+// `s3SignatureV2MiddlewareStruct` replaces the SDK's (Signature Version 4) "Signing"
+// finalize middleware of a backend whose signature_version == S3SignatureVersionV2. As
+// it follows the SDK's retry middleware, each attempt is signed anew.
+type s3SignatureV2MiddlewareStruct struct {
+	credentials aws.CredentialsProvider // If nil, requests are sent unsigned
+	bucket      string                  //
+	pathStyle   bool                    // If false, the bucket is named by the host (so prepended to the CanonicalizedResource)
+}
+
+// `ID` identifies the middleware as that which it replaces.
+func (*s3SignatureV2MiddlewareStruct) ID() string {
+	return "Signing"
+}
+
+// `HandleFinalize` signs the request with the current credentials (if any).
+func (m *s3SignatureV2MiddlewareStruct) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (out middleware.FinalizeOutput, metadata middleware.Metadata, err error) {
+	var (
+		awsCredentials aws.Credentials
+		ok             bool
+		req            *smithyhttp.Request
+	)
+
+	req, ok = in.Request.(*smithyhttp.Request)
+	if !ok {
+		err = fmt.Errorf("[S3] unexpected transport type %T", in.Request)
+		return
+	}
+
+	if m.credentials != nil {
+		awsCredentials, err = m.credentials.Retrieve(ctx)
+		if err != nil {
+			err = fmt.Errorf("[S3] retrieving credentials failed: %w", err)
+			return
+		}
+
+		if awsCredentials.AccessKeyID != "" {
+			s3SignRequestV2(req.Request, m.bucket, m.pathStyle, awsCredentials, time.Now())
+		}
+	}
+
+	return next.HandleFinalize(ctx, in)
+}
+
+// `useSignatureV2` returns an APIOptions function replacing the SDK's request signing
+// with that of s3SignatureV2MiddlewareStruct. As the PresignClient's middleware is put
+// in place before any APIOptions apply, it is left as is (see s3PresignURLV2()).
+func useSignatureV2(credentials aws.CredentialsProvider, bucket string, pathStyle bool) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) (err error) {
+		var (
+			finalizeMiddleware middleware.FinalizeMiddleware
+			ok                 bool
+		)
+
+		finalizeMiddleware, ok = stack.Finalize.Get("Signing")
+		if !ok {
+			return
+		}
+		if _, ok = finalizeMiddleware.(*v4.PresignHTTPRequestMiddleware); ok {
+			return
+		}
+
+		_, err = stack.Finalize.Swap("Signing", &s3SignatureV2MiddlewareStruct{
+			credentials: credentials,
+			bucket:      bucket,
+			pathStyle:   pathStyle,
+		})
+		return
+	}
+}
+
+// `s3SignatureV2Resource` returns the CanonicalizedResource of a request to u (of bucket).
+func s3SignatureV2Resource(u *url.URL, bucket string, pathStyle bool) (resource string) {
+	var (
+		name        string
+		query       = u.Query()
+		subResource []string
+		value       string
+	)
+
+	resource = u.EscapedPath()
+	if resource == "" {
+		resource = "/"
+	}
+	if !pathStyle {
+		resource = "/" + bucket + resource
+	}
+
+	for name = range query {
+		if slices.Contains(s3SignatureV2SubResources, name) {
+			value = query.Get(name)
+			if value == "" {
+				subResource = append(subResource, name)
+			} else {
+				subResource = append(subResource, name+"="+value)
+			}
+		}
+	}
+
+	if len(subResource) > 0 {
+		sort.Strings(subResource)
+		resource += "?" + strings.Join(subResource, "&")
+	}
+
+	return
+}
+
+// `s3SignatureV2AmzHeaders` returns the CanonicalizedAmzHeaders of header.
+func s3SignatureV2AmzHeaders(header http.Header) (amzHeaders string) {
+	var (
+		name   string
+		names  []string
+		sb     strings.Builder
+		value  string
+		values []string
+	)
+
+	for name = range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			names = append(names, name)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	for _, name = range names {
+		values = values[:0]
+		for _, value = range header[name] {
+			values = append(values, strings.TrimSpace(value))
+		}
+		_, _ = fmt.Fprintf(&sb, "%s:%s\n", strings.ToLower(name), strings.Join(values, ","))
+	}
+
+	amzHeaders = sb.String()
+
+	return
+}
+
+// `s3SignatureV2` returns the (base64-encoded) HMAC-SHA1 of stringToSign keyed by secretAccessKey.
+func s3SignatureV2(secretAccessKey string, stringToSign string) string {
+	var (
+		mac = hmac.New(sha1.New, []byte(secretAccessKey))
+	)
+
+	_, _ = mac.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// `s3SignRequestV2` signs req (of bucket) with awsCredentials per Signature Version 2 (as of
+// now) setting its Date, X-Amz-Security-Token (if needed), and Authorization headers. Any
+// X-Amz-Date header is removed so that the Date header is that signed.
+func s3SignRequestV2(req *http.Request, bucket string, pathStyle bool, awsCredentials aws.Credentials, now time.Time) {
+	var (
+		stringToSign string
+	)
+
+	req.Header.Del("Authorization")
+	req.Header.Del("X-Amz-Date")
+	req.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+
+	if awsCredentials.SessionToken == "" {
+		req.Header.Del("X-Amz-Security-Token")
+	} else {
+		req.Header.Set("X-Amz-Security-Token", awsCredentials.SessionToken)
+	}
+
+	stringToSign = req.Method + "\n" +
+		req.Header.Get("Content-MD5") + "\n" +
+		req.Header.Get("Content-Type") + "\n" +
+		req.Header.Get("Date") + "\n" +
+		s3SignatureV2AmzHeaders(req.Header) +
+		s3SignatureV2Resource(req.URL, bucket, pathStyle)
+
+	req.Header.Set("Authorization", "AWS "+awsCredentials.AccessKeyID+":"+s3SignatureV2(awsCredentials.SecretAccessKey, stringToSign))
+}
+
+// `s3PresignURLV2` returns rawURL (as presigned by the SDK per Signature Version 4) instead
+// presigned for method by awsCredentials per Signature Version 2 (via query string
+// authentication) so as to expire at expiresAt.
+func s3PresignURLV2(rawURL string, method string, bucket string, pathStyle bool, awsCredentials aws.Credentials, expiresAt time.Time) (presignedURL string, err error) {
+	var (
+		amzHeaders   string
+		expires      = strconv.FormatInt(expiresAt.Unix(), 10)
+		name         string
+		query        url.Values
+		stringToSign string
+		u            *url.URL
+	)
+
+	u, err = url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	query = u.Query()
+	for name = range query {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-") {
+			query.Del(name)
+		}
+	}
+
+	if awsCredentials.SessionToken != "" {
+		amzHeaders = "x-amz-security-token:" + awsCredentials.SessionToken + "\n"
+		query.Set("x-amz-security-token", awsCredentials.SessionToken)
+	}
+
+	u.RawQuery = query.Encode()
+
+	stringToSign = method + "\n\n\n" + expires + "\n" + amzHeaders + s3SignatureV2Resource(u, bucket, pathStyle)
+
+	query.Set("AWSAccessKeyId", awsCredentials.AccessKeyID)
+	query.Set("Expires", expires)
+	query.Set("Signature", s3SignatureV2(awsCredentials.SecretAccessKey, stringToSign))
+
+	u.RawQuery = query.Encode()
+
+	presignedURL = u.String()
+
+	return
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// When S3.expose_versions is true, each directory of an S3 backend presents a virtual
+// subdirectory named s3VersionsDirName. Within it, each file of that directory is
+// presented as a further subdirectory holding one read-only file per (non-deleted)
+// version of that file named "<LastModified as s3VersionTimeFormat>-<VersionId>":
+//
+//	<dir>/.versions/<basename>/20260102T030405Z-<VersionId>
+const (
+	s3VersionsDirName   = ".versions"
+	s3VersionTimeFormat = "20060102T150405Z"
+)
+
+// `parseS3VersionsPath` locates the s3VersionsDirName component (if any) of path returning
+// the path of the directory containing it (with a trailing "/" if != "") and depth:
+//
+//	0 - path is not within a s3VersionsDirName directory
+//	1 - path is "<parentDirPath>.versions/"
+//	2 - path is "<parentDirPath>.versions/<basename>/"
+//	3 - path is "<parentDirPath>.versions/<basename>/<versionName>"
+//
+// Any other path within a s3VersionsDirName directory returns a depth of -1.
+func parseS3VersionsPath(path string) (parentDirPath, basename, versionName string, depth int) {
+	var (
+		components = strings.Split(path, "/")
+		index      int
+		rest       []string
+	)
+
+	for index = range components {
+		if components[index] != s3VersionsDirName {
+			continue
+		}
+
+		if index > 0 {
+			parentDirPath = strings.Join(components[:index], "/") + "/"
+		}
+
+		rest = components[index+1:]
+
+		switch {
+		case (len(rest) == 1) && (rest[0] == ""):
+			depth = 1
+		case (len(rest) == 2) && (rest[0] != "") && (rest[1] == ""):
+			basename = rest[0]
+			depth = 2
+		case (len(rest) == 2) && (rest[0] != ""):
+			basename = rest[0]
+			versionName = rest[1]
+			depth = 3
+		default:
+			depth = -1
+		}
+
+		return
+	}
+
+	depth = 0
+
+	return
+}
+
+// `s3VersionName` returns the name of the file presenting a version of a file.
+func s3VersionName(lastModified time.Time, versionID string) (versionName string) {
+	versionName = lastModified.UTC().Format(s3VersionTimeFormat) + "-" + versionID
+	return
+}
+
+// `s3VersionID` returns the VersionId encoded in the name of the file presenting a version of a file.
+func s3VersionID(versionName string) (versionID string, err error) {
+	var (
+		found bool
+	)
+
+	_, versionID, found = strings.Cut(versionName, "-")
+	if !found || (versionID == "") {
+		err = fmt.Errorf("bad version name %q", versionName)
+	}
+
+	return
+}
+
+// `listVersions` returns the (non-deleted) versions of the object at fullFilePath
+// (issuing as many ListObjectVersions requests as necessary to enumerate them all).
+func (s3Context *s3ContextStruct) listVersions(fullFilePath string) (versions []types.ObjectVersion, err error) {
+	var (
+		cancel                     context.CancelFunc
+		ctx                        context.Context
+		s3ListObjectVersionsInput  *s3.ListObjectVersionsInput
+		s3ListObjectVersionsOutput *s3.ListObjectVersionsOutput
+		s3ObjectVersion            types.ObjectVersion
+	)
+
+	versions = make([]types.ObjectVersion, 0)
+
+	s3ListObjectVersionsInput = &s3.ListObjectVersionsInput{
+		Bucket: aws.String(s3Context.backend.bucketContainerName),
+		Prefix: aws.String(fullFilePath),
+	}
+
+	for {
+		ctx, cancel = s3Context.newRequestContext()
+		s3ListObjectVersionsOutput, err = s3Context.s3Client.ListObjectVersions(ctx, s3ListObjectVersionsInput)
+		cancel()
+		if err != nil {
+			err = fmt.Errorf("[S3] listVersions failed: %v", err)
+			return
+		}
+
+		// Note that Prefix also matches the versions of other objects whose keys merely begin with fullFilePath
+
+		for _, s3ObjectVersion = range s3ListObjectVersionsOutput.Versions {
+			if aws.ToString(s3ObjectVersion.Key) == fullFilePath {
+				versions = append(versions, s3ObjectVersion)
+			}
+		}
+
+		if !aws.ToBool(s3ListObjectVersionsOutput.IsTruncated) {
+			return
+		}
+
+		s3ListObjectVersionsInput.KeyMarker = s3ListObjectVersionsOutput.NextKeyMarker
+		s3ListObjectVersionsInput.VersionIdMarker = s3ListObjectVersionsOutput.NextVersionIdMarker
+	}
+}
+
+// `listVersionsDirectory` is called by listDirectory for a dirPath within a s3VersionsDirName directory.
+// At depth 1, the files of the parent directory are presented as subdirectories. At depth 2, the
+// versions of the file named basename in the parent directory are presented as files.
+func (s3Context *s3ContextStruct) listVersionsDirectory(listDirectoryInput *listDirectoryInputStruct, parentDirPath, basename string, depth int) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		parentListDirectoryOutput     *listDirectoryOutputStruct
+		parentListDirectoryOutputFile listDirectoryOutputFileStruct
+		s3ObjectVersion               types.ObjectVersion
+		versions                      []types.ObjectVersion
+	)
+
+	switch depth {
+	case 1:
+		parentListDirectoryOutput, err = s3Context.listDirectory(&listDirectoryInputStruct{
+			continuationToken: listDirectoryInput.continuationToken,
+			maxItems:          listDirectoryInput.maxItems,
+			dirPath:           parentDirPath,
+			bulk:              listDirectoryInput.bulk,
+		})
+		if err != nil {
+			return
+		}
+
+		listDirectoryOutput = &listDirectoryOutputStruct{
+			subdirectory:          make([]string, 0, len(parentListDirectoryOutput.file)),
+			file:                  make([]listDirectoryOutputFileStruct, 0),
+			nextContinuationToken: parentListDirectoryOutput.nextContinuationToken,
+			isTruncated:           parentListDirectoryOutput.isTruncated,
+		}
+
+		for _, parentListDirectoryOutputFile = range parentListDirectoryOutput.file {
+			listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, parentListDirectoryOutputFile.basename)
+		}
+	case 2:
+		versions, err = s3Context.listVersions(s3Context.backend.prefix + parentDirPath + basename)
+		if err != nil {
+			return
+		}
+
+		listDirectoryOutput = &listDirectoryOutputStruct{
+			subdirectory: make([]string, 0),
+			file:         make([]listDirectoryOutputFileStruct, 0, len(versions)),
+		}
+
+		for _, s3ObjectVersion = range versions {
+			listDirectoryOutput.file = append(listDirectoryOutput.file, listDirectoryOutputFileStruct{
+				basename: s3VersionName(aws.ToTime(s3ObjectVersion.LastModified), aws.ToString(s3ObjectVersion.VersionId)),
+				eTag:     strings.TrimLeft(strings.TrimRight(aws.ToString(s3ObjectVersion.ETag), "\""), "\""),
+				mTime:    aws.ToTime(s3ObjectVersion.LastModified),
+				size:     uint64(aws.ToInt64(s3ObjectVersion.Size)),
+			})
+		}
+	default:
+		err = fmt.Errorf("missing directory: %w", syscall.ENOENT)
+	}
+
+	return
+}
+
+// `statVersionsDirectory` is called by statDirectory for a dirPath within a s3VersionsDirName directory.
+func (s3Context *s3ContextStruct) statVersionsDirectory(parentDirPath, basename string, depth int) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	var (
+		versions []types.ObjectVersion
+	)
+
+	switch depth {
+	case 1:
+		statDirectoryOutput, err = s3Context.statDirectory(&statDirectoryInputStruct{
+			dirPath: parentDirPath,
+		})
+	case 2:
+		versions, err = s3Context.listVersions(s3Context.backend.prefix + parentDirPath + basename)
+		if err != nil {
+			return
+		}
+		if len(versions) == 0 {
+			err = fmt.Errorf("missing directory: %w", syscall.ENOENT)
+			return
+		}
+
+		statDirectoryOutput = &statDirectoryOutputStruct{}
+	default:
+		err = fmt.Errorf("missing directory: %w", syscall.ENOENT)
+	}
+
+	return
+}
+
+// `statVersionFile` is called by statFile for a filePath within a s3VersionsDirName directory.
+// As versions are immutable, any ifMatch is disregarded.
+func (s3Context *s3ContextStruct) statVersionFile(parentDirPath, basename, versionName string, depth int) (statFileOutput *statFileOutputStruct, err error) {
+	var (
+		cancel             context.CancelFunc
+		ctx                context.Context
+		s3HeadObjectOutput *s3.HeadObjectOutput
+		versionID          string
+	)
+
+	if depth != 3 {
+		err = fmt.Errorf("missing file: %w", syscall.ENOENT)
+		return
+	}
+
+	versionID, err = s3VersionID(versionName)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3HeadObjectOutput, err = s3Context.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:    aws.String(s3Context.backend.bucketContainerName),
+		Key:       aws.String(s3Context.backend.prefix + parentDirPath + basename),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return
+	}
+
+	statFileOutput = &statFileOutputStruct{
+		eTag:  strings.TrimLeft(strings.TrimRight(aws.ToString(s3HeadObjectOutput.ETag), "\""), "\""),
+		mTime: aws.ToTime(s3HeadObjectOutput.LastModified),
+		size:  uint64(aws.ToInt64(s3HeadObjectOutput.ContentLength)),
+	}
+
+	return
+}
+
+// `readVersionFile` is called by readFile for a filePath within a s3VersionsDirName directory.
+// As versions are immutable, any ifMatch is disregarded and any ifNoneMatch is presumed to match.
+func (s3Context *s3ContextStruct) readVersionFile(readFileInput *readFileInputStruct, parentDirPath, basename, versionName string, depth int) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		cancel            context.CancelFunc
+		ctx               context.Context
+		rangeBegin        uint64
+		rangeEnd          uint64
+		rangeSize         uint64
+		s3GetObjectOutput *s3.GetObjectOutput
+		versionID         string
+	)
+
+	rangeBegin, rangeSize = readFileInput.byteRange()
+	rangeEnd = rangeBegin + rangeSize - 1
+
+	if depth != 3 {
+		err = fmt.Errorf("missing file: %w", syscall.ENOENT)
+		return
+	}
+
+	versionID, err = s3VersionID(versionName)
+	if err != nil {
+		return
+	}
+
+	if readFileInput.ifNoneMatch != "" {
+		readFileOutput = &readFileOutputStruct{
+			eTag:        readFileInput.ifNoneMatch,
+			buf:         make([]byte, 0),
+			notModified: true,
+		}
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	s3GetObjectOutput, err = s3Context.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:    aws.String(s3Context.backend.bucketContainerName),
+		Key:       aws.String(s3Context.backend.prefix + parentDirPath + basename),
+		VersionId: aws.String(versionID),
+		Range:     aws.String(fmt.Sprintf("bytes=%d-%d", rangeBegin, rangeEnd)),
+	})
+	if err != nil {
+		return
+	}
+
+	readFileOutput = &readFileOutputStruct{
+		eTag: aws.ToString(s3GetObjectOutput.ETag),
+	}
+	readFileOutput.buf, err = readS3Body(s3GetObjectOutput.Body, s3GetObjectOutput.ContentLength, rangeSize)
+
+	return
+}
+
+// `versionsPath` returns the parseS3VersionsPath() of path if S3.expose_versions is true
+// and, otherwise, a depth of 0 (i.e. path is not within a s3VersionsDirName directory).
+func (s3Context *s3ContextStruct) versionsPath(path string) (parentDirPath, basename, versionName string, depth int) {
+	if s3Context.backend.backendTypeSpecifics.(*backendConfigS3Struct).exposeVersions {
+		parentDirPath, basename, versionName, depth = parseS3VersionsPath(path)
+	}
+	return
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// `shardedRingPointStruct` is a point on the consistent hash ring of a Sharded backend.
+type shardedRingPointStruct struct {
+	hash       uint64
+	shardIndex int // Index into backendConfigShardedStruct.backends (and shardedContextStruct.shards)
+}
+
+// `shardedContextStruct` holds the Sharded-specific backend details. Each file is held by the
+// shard owning the first point on the ring at or following the hash of its path. As each shard
+// contributes virtual_nodes points, adding a shard relocates only ~1/N of the files.
+type shardedContextStruct struct {
+	backend *backendStruct           //
+	ring    []shardedRingPointStruct // Sorted by hash
+	shards  []*backendStruct         // Set by refreshShardedAlreadyLocked(); nil entries not (yet) mounted
+}
+
+// `shardedHash` returns the (well mixed) hash of s used to place both ring points and paths.
+func shardedHash(s string) (hash uint64) {
+	var (
+		h = fnv.New64a()
+	)
+
+	_, _ = h.Write([]byte(s))
+
+	// FNV-1a disperses short, similar strings poorly, so finish with the splitmix64 finalizer
+
+	hash = h.Sum64()
+	hash = (hash ^ (hash >> 30)) * 0xbf58476d1ce4e5b9
+	hash = (hash ^ (hash >> 27)) * 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return
+}
+
+// `backendCommon` is called to return a pointer to the context's common `backendStruct`.
+func (shardedContext *shardedContextStruct) backendCommon() (backendCommon *backendStruct) {
+	backendCommon = shardedContext.backend
+	return
+}
+
+// `setupShardedContext` establishes the Sharded client context. Once set up, each
+// method defined in the `backendConfigIf` interface may be invoked.
+// Note that there is no `destroyContext` counterpart.
+func (backend *backendStruct) setupShardedContext() (err error) {
+	var (
+		backendSharded = backend.backendTypeSpecifics.(*backendConfigShardedStruct)
+		ring           = make([]shardedRingPointStruct, 0, uint64(len(backendSharded.backends))*backendSharded.virtualNodes)
+		shardIndex     int
+		virtualNode    uint64
+	)
+
+	// Ring points are derived from each shard's dir_name (rather than its position) so that
+	// the placement of files is unaffected by reordering backendSharded.backends
+
+	for shardIndex = range backendSharded.backends {
+		for virtualNode = 0; virtualNode < backendSharded.virtualNodes; virtualNode++ {
+			ring = append(ring, shardedRingPointStruct{
+				hash:       shardedHash(backendSharded.backends[shardIndex] + "#" + strconv.FormatUint(virtualNode, 10)),
+				shardIndex: shardIndex,
+			})
+		}
+	}
+
+	slices.SortFunc(ring, func(a, b shardedRingPointStruct) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		default:
+			return strings.Compare(backendSharded.backends[a.shardIndex], backendSharded.backends[b.shardIndex])
+		}
+	})
+
+	backend.context = &shardedContextStruct{
+		backend: backend,
+		ring:    ring,
+		shards:  make([]*backendStruct, len(backendSharded.backends)),
+	}
+
+	backend.backendPath = "sharded://" + strings.Join(backendSharded.backends, ",") + "/"
+
+	return
+}
+
+// `shardIndex` returns the index of the shard holding the file at filePath.
+func (shardedContext *shardedContextStruct) shardIndex(filePath string) (shardIndex int) {
+	var (
+		hash      = shardedHash(filePath)
+		ringIndex int
+	)
+
+	ringIndex = sort.Search(len(shardedContext.ring), func(i int) bool {
+		return shardedContext.ring[i].hash >= hash
+	})
+	if ringIndex == len(shardedContext.ring) {
+		ringIndex = 0
+	}
+
+	shardIndex = shardedContext.ring[ringIndex].shardIndex
+
+	return
+}
+
+// `shard` returns the context of the shard at shardIndex.
+func (shardedContext *shardedContextStruct) shard(shardIndex int) (shardContext backendContextIf, err error) {
+	if shardedContext.shards[shardIndex] == nil {
+		err = fmt.Errorf("[Sharded] backend \"%s\" not mounted", shardedContext.backend.backendTypeSpecifics.(*backendConfigShardedStruct).backends[shardIndex])
+		return
+	}
+
+	shardContext = shardedContext.shards[shardIndex].context
+
+	return
+}
+
+// `shardFor` returns the context of the shard holding the file at filePath.
+func (shardedContext *shardedContextStruct) shardFor(filePath string) (shardContext backendContextIf, err error) {
+	shardContext, err = shardedContext.shard(shardedContext.shardIndex(filePath))
+	return
+}
+
+// `groupByShard` partitions filePaths by the index of the shard holding each.
+func (shardedContext *shardedContextStruct) groupByShard(filePaths []string) (filePathsByShard map[int][]string) {
+	var (
+		filePath   string
+		shardIndex int
+	)
+
+	filePathsByShard = make(map[int][]string)
+
+	for _, filePath = range filePaths {
+		shardIndex = shardedContext.shardIndex(filePath)
+		filePathsByShard[shardIndex] = append(filePathsByShard[shardIndex], filePath)
+	}
+
+	return
+}
+
+// Note that each method below invokes the shard backends' contexts directly (rather than
+// via the *Wrapper() functions) as the request has already been admitted (e.g. by
+// globals.qosScheduler) on our behalf.
+
+// `deleteFile` is called to remove a `file` at the specified path from the shard holding it.
+func (shardedContext *shardedContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(deleteFileInput.filePath)
+	if err != nil {
+		return
+	}
+
+	deleteFileOutput, err = shardContext.deleteFile(deleteFileInput)
+
+	return
+}
+
+// `deleteFiles` is called to remove the `files` at the specified paths from the shards holding them.
+func (shardedContext *shardedContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	var (
+		filePaths    []string
+		shardContext backendContextIf
+		shardIndex   int
+	)
+
+	for shardIndex, filePaths = range shardedContext.groupByShard(deleteFilesInput.filePaths) {
+		shardContext, err = shardedContext.shard(shardIndex)
+		if err != nil {
+			return
+		}
+
+		_, err = shardContext.deleteFiles(&deleteFilesInputStruct{
+			filePaths: filePaths,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	deleteFilesOutput = &deleteFilesOutputStruct{}
+
+	return
+}
+
+// `listDirectory` is called to fetch the `directory` at the specified path. As the contents
+// of any directory are spread across all shards, each shard's listing is enumerated in full
+// and merged such that the entire `directory` is returned as a single (untruncated) `page`.
+func (shardedContext *shardedContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		continuationToken        string
+		shardContext             backendContextIf
+		shardIndex               int
+		shardListDirectoryOutput *listDirectoryOutputStruct
+	)
+
+	listDirectoryOutput = &listDirectoryOutputStruct{
+		subdirectory: make([]string, 0),
+		file:         make([]listDirectoryOutputFileStruct, 0),
+	}
+
+	for shardIndex = range shardedContext.shards {
+		shardContext, err = shardedContext.shard(shardIndex)
+		if err != nil {
+			return
+		}
+
+		continuationToken = ""
+
+		for {
+			shardListDirectoryOutput, err = shardContext.listDirectory(&listDirectoryInputStruct{
+				continuationToken: continuationToken,
+				maxItems:          listDirectoryInput.maxItems,
+				dirPath:           listDirectoryInput.dirPath,
+				bulk:              listDirectoryInput.bulk,
+			})
+			if err != nil {
+				return
+			}
+
+			listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, shardListDirectoryOutput.subdirectory...)
+			listDirectoryOutput.file = append(listDirectoryOutput.file, shardListDirectoryOutput.file...)
+
+			if !shardListDirectoryOutput.isTruncated {
+				break
+			}
+
+			continuationToken = shardListDirectoryOutput.nextContinuationToken
+		}
+	}
+
+	// Subdirectories will typically be reported by more than one shard
+
+	slices.Sort(listDirectoryOutput.subdirectory)
+	listDirectoryOutput.subdirectory = slices.Compact(listDirectoryOutput.subdirectory)
+
+	slices.SortFunc(listDirectoryOutput.file, func(a, b listDirectoryOutputFileStruct) int { return strings.Compare(a.basename, b.basename) })
+
+	return
+}
+
+// `listMultipartUploads` is called to fetch the multipart uploads in progress across all shards.
+func (shardedContext *shardedContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	var (
+		shardContext                    backendContextIf
+		shardIndex                      int
+		shardListMultipartUploadsOutput *listMultipartUploadsOutputStruct
+	)
+
+	listMultipartUploadsOutput = &listMultipartUploadsOutputStruct{
+		upload: make([]listMultipartUploadsOutputUploadStruct, 0),
+	}
+
+	for shardIndex = range shardedContext.shards {
+		shardContext, err = shardedContext.shard(shardIndex)
+		if err != nil {
+			return
+		}
+
+		shardListMultipartUploadsOutput, err = shardContext.listMultipartUploads(listMultipartUploadsInput)
+		if err != nil {
+			return
+		}
+
+		listMultipartUploadsOutput.upload = append(listMultipartUploadsOutput.upload, shardListMultipartUploadsOutput.upload...)
+	}
+
+	return
+}
+
+// `abortMultipartUpload` is called to abort the specified multipart upload on the shard holding its `file`.
+func (shardedContext *shardedContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(abortMultipartUploadInput.filePath)
+	if err != nil {
+		return
+	}
+
+	abortMultipartUploadOutput, err = shardContext.abortMultipartUpload(abortMultipartUploadInput)
+
+	return
+}
+
+// `listObjects` is called to fetch a `page` of the objects enumerating each shard in turn.
+// The continuationToken is "<shard index>:<that shard's continuationToken>".
+func (shardedContext *shardedContextStruct) listObjects(listObjectsInput *listObjectsInputStruct) (listObjectsOutput *listObjectsOutputStruct, err error) {
+	var (
+		found                  bool
+		shardContext           backendContextIf
+		shardContinuationToken string
+		shardIndex             int
+		shardIndexAsString     string
+	)
+
+	if listObjectsInput.continuationToken != "" {
+		shardIndexAsString, shardContinuationToken, found = strings.Cut(listObjectsInput.continuationToken, ":")
+		if found {
+			shardIndex, err = strconv.Atoi(shardIndexAsString)
+		}
+		if !found || (err != nil) || (shardIndex < 0) || (shardIndex >= len(shardedContext.shards)) {
+			err = fmt.Errorf("[Sharded] listObjects failed: bad continuationToken %q", listObjectsInput.continuationToken)
+			return
+		}
+	}
+
+	shardContext, err = shardedContext.shard(shardIndex)
+	if err != nil {
+		return
+	}
+
+	listObjectsOutput, err = shardContext.listObjects(&listObjectsInputStruct{
+		continuationToken: shardContinuationToken,
+		maxItems:          listObjectsInput.maxItems,
+	})
+	if err != nil {
+		return
+	}
+
+	if listObjectsOutput.isTruncated {
+		listObjectsOutput.nextContinuationToken = strconv.Itoa(shardIndex) + ":" + listObjectsOutput.nextContinuationToken
+	} else if (shardIndex + 1) < len(shardedContext.shards) {
+		listObjectsOutput.nextContinuationToken = strconv.Itoa(shardIndex+1) + ":"
+		listObjectsOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `readFile` is called to read a range of a `file` at the specified path from the shard holding it.
+func (shardedContext *shardedContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(readFileInput.filePath)
+	if err != nil {
+		return
+	}
+
+	readFileOutput, err = shardContext.readFile(readFileInput)
+
+	return
+}
+
+// `prefetchFiles` passes the hint along to the shards holding the `files` at the specified paths.
+func (shardedContext *shardedContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	var (
+		filePaths    []string
+		shardContext backendContextIf
+		shardIndex   int
+	)
+
+	for shardIndex, filePaths = range shardedContext.groupByShard(prefetchFilesInput.filePaths) {
+		shardContext, err = shardedContext.shard(shardIndex)
+		if err != nil {
+			return
+		}
+
+		_, err = shardContext.prefetchFiles(&prefetchFilesInputStruct{
+			filePaths: filePaths,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	prefetchFilesOutput = &prefetchFilesOutputStruct{}
+
+	return
+}
+
+// `statDirectory` is called to verify that the specified path refers to a `directory`
+// in any shard.
+func (shardedContext *shardedContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+		shardIndex   int
+	)
+
+	for shardIndex = range shardedContext.shards {
+		shardContext, err = shardedContext.shard(shardIndex)
+		if err != nil {
+			return
+		}
+
+		statDirectoryOutput, err = shardContext.statDirectory(statDirectoryInput)
+		if err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `statFile` is called to fetch the `file` metadata at the specified path from the shard holding it.
+func (shardedContext *shardedContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(statFileInput.filePath)
+	if err != nil {
+		return
+	}
+
+	statFileOutput, err = shardContext.statFile(statFileInput)
+
+	return
+}
+
+// `writeFile` is called to create (or replace) the `file` at the specified path in the shard holding it.
+func (shardedContext *shardedContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(writeFileInput.filePath)
+	if err != nil {
+		return
+	}
+
+	writeFileOutput, err = shardContext.writeFile(writeFileInput)
+
+	return
+}
+
+// `createMultipartUpload` is called to begin a multipart upload of the `file` at the specified path in the shard holding it.
+func (shardedContext *shardedContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(createMultipartUploadInput.filePath)
+	if err != nil {
+		return
+	}
+
+	createMultipartUploadOutput, err = shardContext.createMultipartUpload(createMultipartUploadInput)
+
+	return
+}
+
+// `uploadPart` is called to upload one part of the specified multipart upload in the shard holding its `file`.
+func (shardedContext *shardedContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(uploadPartInput.filePath)
+	if err != nil {
+		return
+	}
+
+	uploadPartOutput, err = shardContext.uploadPart(uploadPartInput)
+
+	return
+}
+
+// `completeMultipartUpload` is called to conclude the specified multipart upload in the shard holding its `file`.
+func (shardedContext *shardedContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	var (
+		shardContext backendContextIf
+	)
+
+	shardContext, err = shardedContext.shardFor(completeMultipartUploadInput.filePath)
+	if err != nil {
+		return
+	}
+
+	completeMultipartUploadOutput, err = shardContext.completeMultipartUpload(completeMultipartUploadInput)
+
+	return
+}
+
+// `refreshShardedAlreadyLocked` is called while globals.Lock() is held, after backends
+// have been mounted, to connect each Sharded backend to the backends holding its shards.
+func refreshShardedAlreadyLocked() {
+	var (
+		backend        *backendStruct
+		ok             bool
+		shardedContext *shardedContextStruct
+		shardIndex     int
+		shardDirName   string
+	)
+
+	for _, backend = range globals.config.backends {
+		shardedContext, ok = backend.context.(*shardedContextStruct)
+		if !ok {
+			continue
+		}
+
+		for shardIndex, shardDirName = range backend.backendTypeSpecifics.(*backendConfigShardedStruct).backends {
+			if shardedContext.shards[shardIndex] != nil {
+				continue
+			}
+
+			shardedContext.shards[shardIndex], ok = globals.config.backends[shardDirName]
+			if !ok || (shardedContext.shards[shardIndex].context == nil) {
+				shardedContext.shards[shardIndex] = nil
+				globals.logger.Printf("[WARN] [sharded] %s unable to find backend \"%s\"", backend.dirName, shardDirName)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestShardedBackend(t *testing.T) {
+	var (
+		err                 error
+		fileContent         []byte
+		filesPerShard       = make(map[string]int)
+		listDirectoryOutput *listDirectoryOutputStruct
+		listObjectsInput    *listObjectsInputStruct
+		listObjectsOutput   *listObjectsOutputStruct
+		numObjects          int
+		ok                  bool
+		shardDirName        string
+		shardedBackend      *backendStruct
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "shard0",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "shard1",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "shard2",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "sharded",
+				"bucket_container_name": "ignored",
+				"backend_type": "Sharded",
+				"readonly": false,
+				"Sharded": {
+					"backends": ["shard0", "shard1", "shard2"]
+				}
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	shardedBackend, ok = globals.config.backends["sharded"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"sharded\"] returned !ok")
+	}
+
+	for i := range 90 {
+		_, err = writeFileWrapper(shardedBackend.context, &writeFileInputStruct{filePath: fmt.Sprintf("dir/file%02d", i), buf: []byte{byte(i)}})
+		if err != nil {
+			t.Fatalf("writeFileWrapper(\"dir/file%02d\") failed: %v", i, err)
+		}
+	}
+
+	// Each file should reside in precisely one shard with the files spread across all of them
+
+	for _, shardDirName = range []string{"shard0", "shard1", "shard2"} {
+		listDirectoryOutput, err = listDirectoryWrapper(globals.config.backends[shardDirName].context, &listDirectoryInputStruct{dirPath: "dir/"})
+		if err != nil {
+			t.Fatalf("listDirectoryWrapper(\"dir/\") of %s failed: %v", shardDirName, err)
+		}
+		filesPerShard[shardDirName] = len(listDirectoryOutput.file)
+		numObjects += len(listDirectoryOutput.file)
+	}
+	if numObjects != 90 {
+		t.Fatalf("shards held %v files (expected 90)", numObjects)
+	}
+	for shardDirName, numObjects = range filesPerShard {
+		if numObjects < 10 {
+			t.Fatalf("shard %s held only %v of 90 files", shardDirName, numObjects)
+		}
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(shardedBackend.context, &listDirectoryInputStruct{dirPath: ""})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(\"\") failed: %v", err)
+	}
+	if !slices.Equal(listDirectoryOutput.subdirectory, []string{"dir"}) || listDirectoryOutput.isTruncated {
+		t.Fatalf("listDirectoryWrapper(\"\") returned %+v (expected only subdirectory [dir])", listDirectoryOutput)
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(shardedBackend.context, &listDirectoryInputStruct{dirPath: "dir/"})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(\"dir/\") failed: %v", err)
+	}
+	if (len(listDirectoryOutput.file) != 90) || (listDirectoryOutput.file[0].basename != "file00") || (listDirectoryOutput.file[89].basename != "file89") {
+		t.Fatalf("listDirectoryWrapper(\"dir/\") returned %v files (expected sorted file00..file89)", len(listDirectoryOutput.file))
+	}
+
+	fileContent, _, err = readWholeFile(shardedBackend.context, "dir/file42")
+	if (err != nil) || !bytes.Equal(fileContent, []byte{42}) {
+		t.Fatalf("readWholeFile(\"dir/file42\") returned %v, %v", fileContent, err)
+	}
+
+	numObjects = 0
+	listObjectsInput = &listObjectsInputStruct{}
+
+	for {
+		listObjectsOutput, err = shardedBackend.context.listObjects(listObjectsInput)
+		if err != nil {
+			t.Fatalf("listObjects() failed: %v", err)
+		}
+
+		numObjects += len(listObjectsOutput.object)
+
+		if !listObjectsOutput.isTruncated {
+			break
+		}
+
+		listObjectsInput.continuationToken = listObjectsOutput.nextContinuationToken
+	}
+	if numObjects != 90 {
+		t.Fatalf("listObjects() enumerated %v objects (expected 90)", numObjects)
+	}
+
+	_, err = deleteFilesWrapper(shardedBackend.context, &deleteFilesInputStruct{filePaths: []string{"dir/file00", "dir/file01", "dir/file02"}})
+	if err != nil {
+		t.Fatalf("deleteFilesWrapper() failed: %v", err)
+	}
+
+	_, err = statFileWrapper(shardedBackend.context, &statFileInputStruct{filePath: "dir/file01"})
+	if err == nil {
+		t.Fatalf("statFileWrapper(\"dir/file01\") after deleteFilesWrapper() unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// `snapshotContextStruct` holds the Snapshot-specific backend details. Listings and
+// metadata are served from the snapshot's manifest while file content is read from
+// the backend the snapshot was taken of (insisting the file's eTag is unchanged).
+type snapshotContextStruct struct {
+	backend  *backendStruct          //
+	manifest *snapshotManifestStruct //
+	source   *backendStruct          // Set by refreshSnapshotsAlreadyLocked()
+}
+
+// `backendCommon` is called to return a pointer to the context's common `backendStruct`.
+func (snapshotContext *snapshotContextStruct) backendCommon() (backendCommon *backendStruct) {
+	backendCommon = snapshotContext.backend
+	return
+}
+
+// `setupSnapshotContext` establishes the Snapshot client context. Once set up, each
+// method defined in the `backendConfigIf` interface may be invoked.
+// Note that there is no `destroyContext` counterpart.
+func (backend *backendStruct) setupSnapshotContext() (err error) {
+	var (
+		backendSnapshot = backend.backendTypeSpecifics.(*backendConfigSnapshotStruct)
+		manifest        *snapshotManifestStruct
+	)
+
+	manifest, err = loadSnapshotManifest(backendSnapshot.manifestFilePath)
+	if err != nil {
+		return
+	}
+
+	backend.context = &snapshotContextStruct{
+		backend:  backend,
+		manifest: manifest,
+	}
+
+	backend.backendPath = "snapshot://" + backendSnapshot.backend + "/" + backendSnapshot.name
+
+	return
+}
+
+// `find` returns the index of the first object in the manifest whose path is >= path.
+func (snapshotContext *snapshotContextStruct) find(path string) (index int) {
+	index = sort.Search(len(snapshotContext.manifest.Objects), func(i int) bool {
+		return snapshotContext.manifest.Objects[i].Path >= path
+	})
+	return
+}
+
+// `lookup` returns the object in the manifest at precisely path.
+func (snapshotContext *snapshotContextStruct) lookup(path string) (object *snapshotManifestObjectStruct, ok bool) {
+	var (
+		index = snapshotContext.find(path)
+	)
+
+	ok = (index < len(snapshotContext.manifest.Objects)) && (snapshotContext.manifest.Objects[index].Path == path)
+	if ok {
+		object = &snapshotContext.manifest.Objects[index]
+	}
+
+	return
+}
+
+// `deleteFile` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) deleteFile(deleteFileInput *deleteFileInputStruct) (deleteFileOutput *deleteFileOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] deleteFile failed: %w", syscall.EROFS)
+	return
+}
+
+// `deleteFiles` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) deleteFiles(deleteFilesInput *deleteFilesInputStruct) (deleteFilesOutput *deleteFilesOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] deleteFiles failed: %w", syscall.EROFS)
+	return
+}
+
+// `listDirectory` is called to fetch a `page` of the `directory` at the specified path.
+// The continuationToken is the (decimal) index in the manifest at which to resume.
+func (snapshotContext *snapshotContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		index        int
+		itemsListed  uint64
+		object       *snapshotManifestObjectStruct
+		rest         string
+		slashIndex   int
+		subdirectory string
+	)
+
+	if listDirectoryInput.continuationToken == "" {
+		index = snapshotContext.find(listDirectoryInput.dirPath)
+	} else {
+		index, err = strconv.Atoi(listDirectoryInput.continuationToken)
+		if (err != nil) || (index < 0) || (index > len(snapshotContext.manifest.Objects)) {
+			err = fmt.Errorf("[Snapshot] listDirectory failed: bad continuationToken %q", listDirectoryInput.continuationToken)
+			return
+		}
+	}
+
+	listDirectoryOutput = &listDirectoryOutputStruct{
+		subdirectory: make([]string, 0),
+		file:         make([]listDirectoryOutputFileStruct, 0),
+	}
+
+	// As the manifest is sorted, all paths beginning with dirPath (and with each subdirectory thereof) are contiguous
+
+	for ; index < len(snapshotContext.manifest.Objects); index++ {
+		object = &snapshotContext.manifest.Objects[index]
+
+		if !strings.HasPrefix(object.Path, listDirectoryInput.dirPath) {
+			break
+		}
+
+		rest = object.Path[len(listDirectoryInput.dirPath):]
+		if rest == "" {
+			continue
+		}
+
+		slashIndex = strings.Index(rest, "/")
+		if slashIndex >= 0 {
+			if rest[:slashIndex] == subdirectory {
+				continue
+			}
+		}
+
+		if (listDirectoryInput.maxItems != 0) && (itemsListed == listDirectoryInput.maxItems) {
+			listDirectoryOutput.nextContinuationToken = strconv.Itoa(index)
+			listDirectoryOutput.isTruncated = true
+			return
+		}
+
+		if slashIndex >= 0 {
+			subdirectory = rest[:slashIndex]
+			listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, subdirectory)
+		} else {
+			listDirectoryOutput.file = append(listDirectoryOutput.file, listDirectoryOutputFileStruct{
+				basename: rest,
+				eTag:     object.ETag,
+				mTime:    object.MTime,
+				size:     object.Size,
+			})
+		}
+
+		itemsListed++
+	}
+
+	return
+}
+
+// `listMultipartUploads` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] listMultipartUploads failed: %w", syscall.ENOTSUP)
+	return
+}
+
+// `abortMultipartUpload` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] abortMultipartUpload failed: %w", syscall.EROFS)
+	return
+}
+
+// `listObjects` is called to fetch a `page` of the objects in the manifest.
+// The continuationToken is the (decimal) index in the manifest at which to resume.
+func (snapshotContext *snapshotContextStruct) listObjects(listObjectsInput *listObjectsInputStruct) (listObjectsOutput *listObjectsOutputStruct, err error) {
+	var (
+		index  int
+		limit  = len(snapshotContext.manifest.Objects)
+		object snapshotManifestObjectStruct
+	)
+
+	if listObjectsInput.continuationToken != "" {
+		index, err = strconv.Atoi(listObjectsInput.continuationToken)
+		if (err != nil) || (index < 0) || (index > limit) {
+			err = fmt.Errorf("[Snapshot] listObjects failed: bad continuationToken %q", listObjectsInput.continuationToken)
+			return
+		}
+	}
+
+	if (listObjectsInput.maxItems != 0) && (uint64(limit-index) > listObjectsInput.maxItems) {
+		limit = index + int(listObjectsInput.maxItems)
+	}
+
+	listObjectsOutput = &listObjectsOutputStruct{
+		object: make([]listObjectsOutputObjectStruct, 0, limit-index),
+	}
+
+	for _, object = range snapshotContext.manifest.Objects[index:limit] {
+		listObjectsOutput.object = append(listObjectsOutput.object, listObjectsOutputObjectStruct{
+			path:  object.Path,
+			eTag:  object.ETag,
+			mTime: object.MTime,
+			size:  object.Size,
+		})
+	}
+
+	if limit < len(snapshotContext.manifest.Objects) {
+		listObjectsOutput.nextContinuationToken = strconv.Itoa(limit)
+		listObjectsOutput.isTruncated = true
+	}
+
+	return
+}
+
+// `readFile` is called to read a range of a `file` at the specified path from the
+// backend the snapshot was taken of. Should that file's eTag no longer match that
+// recorded in the manifest, the read fails rather than return content that differs
+// from that captured by the snapshot.
+func (snapshotContext *snapshotContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		object *snapshotManifestObjectStruct
+		ok     bool
+	)
+
+	object, ok = snapshotContext.lookup(readFileInput.filePath)
+	if !ok {
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
+		return
+	}
+
+	if (readFileInput.ifMatch != "") && (readFileInput.ifMatch != object.ETag) {
+		err = fmt.Errorf("[Snapshot] readFile failed: eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	if (readFileInput.ifNoneMatch != "") && (readFileInput.ifNoneMatch == object.ETag) {
+		// The content captured by the snapshot cannot have changed
+
+		readFileOutput = &readFileOutputStruct{
+			eTag:        object.ETag,
+			buf:         make([]byte, 0),
+			notModified: true,
+		}
+		return
+	}
+
+	if snapshotContext.source == nil {
+		err = fmt.Errorf("[Snapshot] readFile failed: backend \"%s\" not mounted", snapshotContext.manifest.Backend)
+		return
+	}
+
+	// Note that the source backend's context is invoked directly (rather than via readFileWrapper())
+	// as this request has already been admitted (e.g. by globals.qosScheduler) on our behalf
+
+	readFileOutput, err = snapshotContext.source.context.readFile(&readFileInputStruct{
+		filePath:        snapshotContext.manifest.Prefix + object.Path,
+		offsetCacheLine: readFileInput.offsetCacheLine,
+		cacheLineSize:   readFileInput.cacheLineSize,
+		cacheLines:      readFileInput.cacheLines,
+		ifMatch:         object.ETag,
+		bulk:            readFileInput.bulk,
+	})
+	if err != nil {
+		err = fmt.Errorf("[Snapshot] readFile of \"%s\" failed (changed since snapshot?): %w", object.Path, err)
+		return
+	}
+
+	readFileOutput.eTag = object.ETag
+
+	return
+}
+
+// `prefetchFiles` passes the hint along to the backend the snapshot was taken of.
+func (snapshotContext *snapshotContextStruct) prefetchFiles(prefetchFilesInput *prefetchFilesInputStruct) (prefetchFilesOutput *prefetchFilesOutputStruct, err error) {
+	var (
+		filePath  string
+		filePaths = make([]string, 0, len(prefetchFilesInput.filePaths))
+	)
+
+	if snapshotContext.source == nil {
+		prefetchFilesOutput = &prefetchFilesOutputStruct{}
+		return
+	}
+
+	for _, filePath = range prefetchFilesInput.filePaths {
+		filePaths = append(filePaths, snapshotContext.manifest.Prefix+filePath)
+	}
+
+	prefetchFilesOutput, err = snapshotContext.source.context.prefetchFiles(&prefetchFilesInputStruct{
+		filePaths: filePaths,
+	})
+
+	return
+}
+
+// `statDirectory` is called to verify that the specified path refers to a `directory`
+// (i.e. that the manifest contains at least one file beneath it).
+func (snapshotContext *snapshotContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	var (
+		index = snapshotContext.find(statDirectoryInput.dirPath)
+	)
+
+	if (statDirectoryInput.dirPath != "") && ((index == len(snapshotContext.manifest.Objects)) || !strings.HasPrefix(snapshotContext.manifest.Objects[index].Path, statDirectoryInput.dirPath)) {
+		err = fmt.Errorf("directory not found: %w", syscall.ENOENT)
+		return
+	}
+
+	statDirectoryOutput = &statDirectoryOutputStruct{}
+
+	return
+}
+
+// `statFile` is called to fetch the `file` metadata at the specified path from the manifest.
+func (snapshotContext *snapshotContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	var (
+		object *snapshotManifestObjectStruct
+		ok     bool
+	)
+
+	object, ok = snapshotContext.lookup(statFileInput.filePath)
+	if !ok {
+		err = fmt.Errorf("file not found: %w", syscall.ENOENT)
+		return
+	}
+
+	if (statFileInput.ifMatch != "") && (statFileInput.ifMatch != object.ETag) {
+		err = fmt.Errorf("[Snapshot] statFile failed: eTag mismatch: %w", syscall.ESTALE)
+		return
+	}
+
+	statFileOutput = &statFileOutputStruct{
+		eTag:  object.ETag,
+		mTime: object.MTime,
+		size:  object.Size,
+	}
+
+	return
+}
+
+// `writeFile` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) writeFile(writeFileInput *writeFileInputStruct) (writeFileOutput *writeFileOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] writeFile failed: %w", syscall.EROFS)
+	return
+}
+
+// `createMultipartUpload` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] createMultipartUpload failed: %w", syscall.EROFS)
+	return
+}
+
+// `uploadPart` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] uploadPart failed: %w", syscall.EROFS)
+	return
+}
+
+// `completeMultipartUpload` is not supported as snapshots are read-only.
+func (snapshotContext *snapshotContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	err = fmt.Errorf("[Snapshot] completeMultipartUpload failed: %w", syscall.EROFS)
+	return
+}
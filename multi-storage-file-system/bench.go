@@ -0,0 +1,352 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+const (
+	BenchPatternSequential = "seq"         // Reads of blockSize proceeding sequentially through a file (wrapping at its end)
+	BenchPatternRandom     = "random"      // Reads of blockSize at random (blockSize aligned) offsets of a file
+	BenchPatternSmallFiles = "small-files" // Lookup, open, read (in its entirety), and release of each file of a directory in turn
+	BenchPatternWrite      = "write"       // Uploads of new files of blockSize to a prefix (subsequently deleted)
+
+	benchBlockSizeDefault = uint64(128 * 1024)
+	benchThreadsDefault   = uint64(1)
+	benchThreadsMax       = uint64(256)
+	benchDurationDefault  = 10 * time.Second
+	benchWriteFilePrefix  = "msfs-bench-"
+)
+
+// `benchPatterns` enumerates the values accepted for {-pattern|--pattern}.
+var benchPatterns = []string{BenchPatternSequential, BenchPatternRandom, BenchPatternSmallFiles, BenchPatternWrite}
+
+// `benchOptionsStruct` holds the options of runBench().
+type benchOptionsStruct struct {
+	pattern   string        // One of benchPatterns
+	blockSize uint64        // Size of each read (or write); at most maxRead
+	threads   uint64        // Number of concurrent issuers of operations
+	duration  time.Duration // How long operations are issued
+}
+
+// `benchStruct` tracks the progress of runBench().
+type benchStruct struct {
+	options    *benchOptionsStruct //
+	backend    *backendStruct      //
+	path       string              // Relative to backend.prefix (without a trailing "/")
+	inode      uint64              // For BenchPatternSequential or BenchPatternRandom, that of the file; for BenchPatternSmallFiles, that of the directory
+	size       uint64              // For BenchPatternSequential or BenchPatternRandom, that of the file
+	basenames  []string            // For BenchPatternSmallFiles, the files of the directory
+	nextFile   atomic.Uint64       // For BenchPatternSmallFiles, index (modulo len(basenames)) of the next file to read
+	deadline   time.Time           //
+	stopped    atomic.Bool         // Set upon the first failure to end the benchmark early
+	sync.Mutex                     // Protects the following
+	ops        uint64              //
+	bytes      uint64              //
+	latencies  []time.Duration     // Of each op
+	written    []string            // For BenchPatternWrite, each file uploaded (to be deleted once done)
+	lastErr    error               // If != nil, the first failure (which ends the benchmark)
+}
+
+// `runBench` drives operations of options.pattern against target (of the form <dir_name>[/<path>]
+// of a mounted backend) for options.duration and reports to w the resulting throughput, IOPS, and
+// latency percentiles. Reads are issued via the FUSE callbacks (bypassing only the kernel) such that
+// they are satisfied by the cache layer exactly as if mounted. As writes are not yet supported by the
+// cache layer, BenchPatternWrite instead uploads directly (via writeFileWrapper()).
+func runBench(w io.Writer, target string, options *benchOptionsStruct) (err error) {
+	var (
+		bench   *benchStruct
+		dirName string
+		elapsed time.Duration
+		ok      bool
+		wg      sync.WaitGroup
+	)
+
+	bench = &benchStruct{
+		options: options,
+	}
+
+	dirName, bench.path, _ = strings.Cut(target, "/")
+	bench.path = strings.TrimSuffix(bench.path, "/")
+
+	globals.Lock()
+	bench.backend, ok = globals.config.backends[dirName]
+	globals.Unlock()
+	if !ok || (bench.backend.inode == nil) {
+		err = fmt.Errorf("no mounted backend with dir_name \"%s\"", dirName)
+		return
+	}
+
+	switch options.pattern {
+	case BenchPatternSequential, BenchPatternRandom:
+		err = bench.setupFile(dirName)
+	case BenchPatternSmallFiles:
+		err = bench.setupSmallFiles(dirName)
+	case BenchPatternWrite:
+		if bench.backend.readOnly {
+			err = fmt.Errorf("backend \"%s\" is readonly", dirName)
+		}
+	default:
+		err = fmt.Errorf("unknown pattern \"%s\" (must be one of %s)", options.pattern, strings.Join(benchPatterns, ", "))
+	}
+	if err != nil {
+		return
+	}
+
+	startTime := time.Now()
+	bench.deadline = startTime.Add(options.duration)
+
+	for threadID := range options.threads {
+		wg.Go(func() {
+			bench.thread(threadID)
+		})
+	}
+
+	wg.Wait()
+
+	elapsed = time.Since(startTime)
+
+	if len(bench.written) > 0 {
+		_, err = deleteFilesWrapper(bench.backend.context, &deleteFilesInputStruct{filePaths: bench.written})
+		if err != nil {
+			globals.logger.Printf("[WARN] [bench] unable to delete files written to %s: %v", dirName, err)
+		}
+	}
+
+	if bench.lastErr != nil {
+		err = bench.lastErr
+		return
+	}
+
+	bench.report(w, target, elapsed)
+
+	return
+}
+
+// `setupFile` locates the file read by BenchPatternSequential or BenchPatternRandom.
+func (bench *benchStruct) setupFile(dirName string) (err error) {
+	var (
+		entryOut *fission.EntryOut
+	)
+
+	entryOut, err = fissionLookupPath(dirName, bench.path)
+	if err != nil {
+		return
+	}
+	if (entryOut.Attr.Mode & syscall.S_IFMT) != syscall.S_IFREG {
+		err = fmt.Errorf("\"%s/%s\" is not a file", dirName, bench.path)
+		return
+	}
+	if entryOut.Attr.Size == 0 {
+		err = fmt.Errorf("\"%s/%s\" is empty", dirName, bench.path)
+		return
+	}
+
+	bench.inode = entryOut.NodeID
+	bench.size = entryOut.Attr.Size
+
+	return
+}
+
+// `setupSmallFiles` locates the directory (and its files) read by BenchPatternSmallFiles.
+func (bench *benchStruct) setupSmallFiles(dirName string) (err error) {
+	var (
+		entryOut            *fission.EntryOut
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+	)
+
+	entryOut, err = fissionLookupPath(dirName, bench.path)
+	if err != nil {
+		return
+	}
+	if (entryOut.Attr.Mode & syscall.S_IFMT) != syscall.S_IFDIR {
+		err = fmt.Errorf("\"%s/%s\" is not a directory", dirName, bench.path)
+		return
+	}
+
+	bench.inode = entryOut.NodeID
+
+	listDirectoryInput = &listDirectoryInputStruct{maxItems: bench.backend.directoryPageSize}
+	if bench.path != "" {
+		listDirectoryInput.dirPath = bench.path + "/"
+	}
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(bench.backend.context, listDirectoryInput)
+		if err != nil {
+			return
+		}
+
+		for _, file := range listDirectoryOutput.file {
+			bench.basenames = append(bench.basenames, file.basename)
+		}
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			break
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+
+	if len(bench.basenames) == 0 {
+		err = fmt.Errorf("\"%s/%s\" contains no files", dirName, bench.path)
+	}
+
+	return
+}
+
+// `thread` issues operations until bench.deadline (or a failure).
+func (bench *benchStruct) thread(threadID uint64) {
+	var (
+		bytes     uint64
+		err       error
+		fh        uint64
+		latencies []time.Duration
+		n         uint64
+		offset    uint64
+		ops       uint64
+		opBytes   uint64
+		opStart   time.Time
+		written   []string
+	)
+
+	if (bench.options.pattern == BenchPatternSequential) || (bench.options.pattern == BenchPatternRandom) {
+		fh, err = fissionOpen(bench.inode)
+		if err != nil {
+			bench.fail(err)
+			return
+		}
+		defer fissionRelease(bench.inode, fh)
+
+		// Spread the sequential readers evenly across the file
+
+		offset = ((bench.size / bench.options.threads) * threadID) / bench.options.blockSize * bench.options.blockSize
+	}
+
+	for n = 0; time.Now().Before(bench.deadline) && !bench.stopped.Load(); n++ {
+		opStart = time.Now()
+
+		switch bench.options.pattern {
+		case BenchPatternSequential:
+			if offset >= bench.size {
+				offset = 0
+			}
+			opBytes, err = fissionRead(bench.inode, fh, offset, bench.options.blockSize)
+			offset += opBytes
+		case BenchPatternRandom:
+			offset = rand.Uint64N((bench.size+bench.options.blockSize-1)/bench.options.blockSize) * bench.options.blockSize
+			opBytes, err = fissionRead(bench.inode, fh, offset, bench.options.blockSize)
+		case BenchPatternSmallFiles:
+			opBytes, err = bench.readSmallFile(bench.basenames[bench.nextFile.Add(1)%uint64(len(bench.basenames))])
+		case BenchPatternWrite:
+			filePath := fmt.Sprintf("%s%d-%d", benchWriteFilePrefix, threadID, n)
+			if bench.path != "" {
+				filePath = bench.path + "/" + filePath
+			}
+			_, err = writeFileWrapper(bench.backend.context, &writeFileInputStruct{
+				filePath: filePath,
+				buf:      make([]byte, bench.options.blockSize),
+			})
+			if err == nil {
+				written = append(written, filePath)
+			}
+			opBytes = bench.options.blockSize
+		}
+
+		if err != nil {
+			break
+		}
+
+		latencies = append(latencies, time.Since(opStart))
+		ops++
+		bytes += opBytes
+	}
+
+	bench.Lock()
+	bench.ops += ops
+	bench.bytes += bytes
+	bench.latencies = append(bench.latencies, latencies...)
+	bench.written = append(bench.written, written...)
+	bench.Unlock()
+
+	if err != nil {
+		bench.fail(err)
+	}
+}
+
+// `fail` records err (should it be the first failure) and ends the benchmark.
+func (bench *benchStruct) fail(err error) {
+	bench.Lock()
+	if bench.lastErr == nil {
+		bench.lastErr = err
+	}
+	bench.Unlock()
+
+	bench.stopped.Store(true)
+}
+
+// `readSmallFile` looks up, opens, reads (in its entirety), and releases basename of bench.inode.
+func (bench *benchStruct) readSmallFile(basename string) (bytes uint64, err error) {
+	var (
+		errno     syscall.Errno
+		lookupOut *fission.LookupOut
+	)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: bench.inode}, &fission.LookupIn{Name: []byte(basename)})
+	if errno != 0 {
+		err = fmt.Errorf("unable to lookup \"%s\": %v", basename, errno)
+		return
+	}
+
+	bytes, err = fissionReadFile(lookupOut.EntryOut.NodeID, lookupOut.EntryOut.Attr.Size)
+
+	return
+}
+
+// `report` writes the outcome of the benchmark to w.
+func (bench *benchStruct) report(w io.Writer, target string, elapsed time.Duration) {
+	var (
+		mib = float64(bench.bytes) / (1024 * 1024)
+	)
+
+	slices.SortFunc(bench.latencies, func(a, b time.Duration) int {
+		return cmp.Compare(a, b)
+	})
+
+	_, _ = fmt.Fprintf(w, "pattern:         %s\n", bench.options.pattern)
+	_, _ = fmt.Fprintf(w, "target:          %s\n", target)
+	_, _ = fmt.Fprintf(w, "block_size:      %v\n", bench.options.blockSize)
+	_, _ = fmt.Fprintf(w, "threads:         %v\n", bench.options.threads)
+	_, _ = fmt.Fprintf(w, "cache_line_size: %v\n", globals.config.cacheLineSize)
+	_, _ = fmt.Fprintf(w, "cache_lines:     %v\n", globals.config.cacheLines)
+	_, _ = fmt.Fprintf(w, "elapsed:         %v\n", elapsed.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(w, "ops:             %v\n", bench.ops)
+	_, _ = fmt.Fprintf(w, "bytes:           %v\n", bench.bytes)
+	_, _ = fmt.Fprintf(w, "throughput:      %.2f MiB/s\n", mib/elapsed.Seconds())
+	_, _ = fmt.Fprintf(w, "iops:            %.2f\n", float64(bench.ops)/elapsed.Seconds())
+	_, _ = fmt.Fprintf(w, "latency p50:     %v\n", benchPercentile(bench.latencies, 0.50))
+	_, _ = fmt.Fprintf(w, "latency p95:     %v\n", benchPercentile(bench.latencies, 0.95))
+	_, _ = fmt.Fprintf(w, "latency p99:     %v\n", benchPercentile(bench.latencies, 0.99))
+	_, _ = fmt.Fprintf(w, "latency max:     %v\n", benchPercentile(bench.latencies, 1.00))
+}
+
+// `benchPercentile` returns the latency at quantile q (in [0,1]) of sortedLatencies.
+func benchPercentile(sortedLatencies []time.Duration, q float64) time.Duration {
+	if len(sortedLatencies) == 0 {
+		return 0
+	}
+
+	return sortedLatencies[int(q*float64(len(sortedLatencies)-1))]
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBench(t *testing.T) {
+	var (
+		err    error
+		output bytes.Buffer
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// Lest BenchPatternWrite exhaust the RAM backend's limits before its duration expires
+
+	backendRAM := globals.config.backends["ram"].backendTypeSpecifics.(*backendConfigRAMStruct)
+	backendRAM.maxTotalObjects = math.MaxUint64
+	backendRAM.maxTotalObjectSpace = math.MaxUint64
+
+	for _, testCase := range []struct {
+		pattern   string
+		target    string
+		blockSize uint64
+		threads   uint64
+	}{
+		{BenchPatternSequential, "ram/fileB", 1000, 1},
+		{BenchPatternRandom, "ram/fileB", 4096, 4},
+		{BenchPatternSmallFiles, "ram", 0, 2},
+		{BenchPatternSmallFiles, "ram/dir1/", 0, 1},
+		{BenchPatternWrite, "ram/dir2", 16, 1}, // The RAM backend does not support concurrent writes
+	} {
+		output.Reset()
+		err = runBench(&output, testCase.target, &benchOptionsStruct{
+			pattern:   testCase.pattern,
+			blockSize: testCase.blockSize,
+			threads:   testCase.threads,
+			duration:  50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("runBench(%s,\"%s\") failed: %v", testCase.pattern, testCase.target, err)
+		}
+		if !strings.HasPrefix(output.String(), "pattern:         "+testCase.pattern+"\n") || strings.Contains(output.String(), "ops:             0\n") || !strings.Contains(output.String(), "latency p99:     ") {
+			t.Fatalf("runBench(%s,\"%s\") reported:\n%s", testCase.pattern, testCase.target, output.String())
+		}
+	}
+
+	// Files uploaded by BenchPatternWrite are deleted once done
+
+	listDirectoryOutput, err := globals.config.backends["ram"].context.listDirectory(&listDirectoryInputStruct{dirPath: "dir2/"})
+	if (err != nil) || (len(listDirectoryOutput.file) != 0) {
+		t.Fatalf("runBench(write) left behind files (err: %v)", err)
+	}
+
+	for _, target := range []string{"ram/dir1", "ram/fileZ", "none/fileB"} {
+		err = runBench(&output, target, &benchOptionsStruct{pattern: BenchPatternSequential, blockSize: 1000, threads: 1, duration: time.Millisecond})
+		if err == nil {
+			t.Fatalf("runBench(seq,\"%s\") should have failed", target)
+		}
+	}
+
+	err = runBench(&output, "ram/dir2", &benchOptionsStruct{pattern: BenchPatternSmallFiles, threads: 1, duration: time.Millisecond})
+	if (err == nil) || !strings.Contains(err.Error(), "contains no files") {
+		t.Fatalf("runBench(small-files,\"ram/dir2/dir4\") returned err: %v", err)
+	}
+
+	if benchPercentile(nil, 0.5) != 0 || benchPercentile([]time.Duration{1, 2, 3, 4, 5}, 0.5) != 3 || benchPercentile([]time.Duration{1, 2, 3, 4, 5}, 1.0) != 5 {
+		t.Fatalf("benchPercentile() returned unexpected values")
+	}
+}
@@ -2,44 +2,92 @@ package main
 
 import (
 	"container/list"
+	"context"
+	"fmt"
+	"io"
 	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // `fetch` is run in a goroutine for an allocated cacheLineStruct that
-// is to be populated with a portion of the object's contents. Completion of
-// the fetch operation is indicated by signaling as done the sync.WaitGroup
-// in the cacheLineStruct itself.
+// is to be populated with a portion of the object's contents (along with any
+// cache lines coalesced with it). Should the cacheLineStruct be being revalidated
+// (see revalidate()), it is only refetched if its eTag no longer matches. Should the
+// content fetched be of a file since changed by another writer (i.e. its eTag no longer
+// that of the inode), the conflict is resolved per etag_conflict_policy (see
+// resolveETagConflict()). Completion of the fetch operation is indicated by signaling
+// as done the sync.WaitGroup in the cacheLineStruct itself.
 func (cacheLine *cacheLineStruct) fetch() {
 	var (
-		backend        *backendStruct
-		err            error
-		inode          *inodeStruct
-		ok             bool
-		readFileInput  *readFileInputStruct
-		readFileOutput *readFileOutputStruct
+		backend            *backendStruct
+		cacheLines         []*cacheLineStruct
+		content            [][]byte
+		eTagConflictErrno  syscall.Errno
+		eTagConflictPolicy string
+		err                error
+		inode              *inodeStruct
+		inodeETag          string
+		lineIndex          int
+		ok                 bool
+		readFileInput      *readFileInputStruct
+		readFileOutput     *readFileOutputStruct
+		span               trace.Span
+		staleContent       []byte // If cacheLine is being revalidated, its content (retained should its eTag still match)
+		statFileOutput     *statFileOutputStruct
+		traceCtx           context.Context
 	)
 
 	globals.Lock()
 
+	cacheLines = append([]*cacheLineStruct{cacheLine}, cacheLine.coalesced...)
+	cacheLine.coalesced = nil
+
+	staleContent = cacheLine.content
+	cacheLine.content = nil
+
+	traceCtx, span = msfsTracer.Start(traceContextOrBackground(cacheLine.traceCtx), "cache.fetch", trace.WithAttributes(
+		attribute.Int64("msfs.cache_line", int64(cacheLine.lineNumber)),
+		attribute.Int64("msfs.cache_lines", int64(len(cacheLines))),
+		attribute.Bool("msfs.prefetch", cacheLine.prefetch),
+		attribute.Bool("msfs.revalidate", cacheLine.eTag != ""),
+	))
+	defer func() {
+		endSpanWithErr(span, err)
+	}()
+
+	cacheLine.traceCtx = nil
+
 	inode, ok = globals.inodeMap[cacheLine.inodeNumber]
 	if !ok {
-		globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 1]")
-		cacheLine.state = CacheLineClean
-		cacheLine.eTag = ""
-		cacheLine.content = make([]byte, 0)
-		globals.inboundCacheLineCount--
-		cacheLine.listElement = globals.cleanCacheLineLRU.PushBack(cacheLine)
-		cacheLine.notifyWaiters()
+		globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 1] (inode: %v line: %v)", cacheLine.inodeNumber, cacheLine.lineNumber)
+		globals.webhooks.notify(webhookEventCacheCorruption, "", fmt.Sprintf("cache line %v of inode %v fetched for a missing inodeStruct [case 1]", cacheLine.lineNumber, cacheLine.inodeNumber))
+		putCacheLineBuf(staleContent)
+		for _, cacheLine = range cacheLines {
+			cacheLine.complete(nil, "", make([]byte, 0))
+		}
 		globals.Unlock()
 		return
 	}
 
 	backend = inode.backend
+	eTagConflictPolicy = backend.pathSettings(inode.objectPath).eTagConflictPolicy
+	inodeETag = inode.eTag
+
+	span.SetAttributes(attribute.String("msfs.backend", backend.dirName), attribute.String("msfs.path", inode.objectPath))
 
 	readFileInput = &readFileInputStruct{
 		filePath:        inode.objectPath,
 		offsetCacheLine: cacheLine.lineNumber,
+		cacheLineSize:   inode.cacheLineSize,
+		cacheLines:      uint64(len(cacheLines)),
 		ifMatch:         "",
+		ifNoneMatch:     cacheLine.eTag,
+		bulk:            cacheLine.prefetch,
+		traceCtx:        traceCtx,
 	}
 
 	globals.Unlock()
@@ -49,35 +97,125 @@ func (cacheLine *cacheLineStruct) fetch() {
 		globals.Lock()
 		globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle error reading cache line")
 		inode, ok = globals.inodeMap[cacheLine.inodeNumber]
-		if ok {
-			inode.inboundCacheLineCount--
-		} else {
-			globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 2]")
+		if !ok {
+			inode = nil
+			globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 2] (inode: %v line: %v)", cacheLine.inodeNumber, cacheLine.lineNumber)
+			globals.webhooks.notify(webhookEventCacheCorruption, "", fmt.Sprintf("cache line %v of inode %v fetched for a missing inodeStruct [case 2]", cacheLine.lineNumber, cacheLine.inodeNumber))
+		}
+		putCacheLineBuf(staleContent)
+		for _, cacheLine = range cacheLines {
+			cacheLine.errno = backendErrno(err)
+			cacheLine.complete(inode, "", make([]byte, 0))
 		}
-		cacheLine.state = CacheLineClean
-		cacheLine.eTag = ""
-		cacheLine.content = make([]byte, 0)
-		globals.inboundCacheLineCount--
-		cacheLine.listElement = globals.cleanCacheLineLRU.PushBack(cacheLine)
-		cacheLine.notifyWaiters()
 		globals.Unlock()
 		return
 	}
 
+	if !readFileOutput.notModified && (eTagConflictPolicy != ETagConflictPolicyFail) && backend.eTagsConflict(inodeETag, readFileOutput.eTag) {
+		// Learn (without holding globals.Lock) what the file has become for resolveETagConflict()
+
+		statFileOutput, err = statFileWrapper(backend.context, &statFileInputStruct{
+			filePath: readFileInput.filePath,
+			ifMatch:  "",
+		})
+		if err != nil {
+			statFileOutput = nil
+		}
+	}
+
 	globals.Lock()
 	inode, ok = globals.inodeMap[cacheLine.inodeNumber]
-	if ok {
+	if !ok {
+		inode = nil
+		globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 3] (inode: %v line: %v)", cacheLine.inodeNumber, cacheLine.lineNumber)
+		globals.webhooks.notify(webhookEventCacheCorruption, "", fmt.Sprintf("cache line %v of inode %v fetched for a missing inodeStruct [case 3]", cacheLine.lineNumber, cacheLine.inodeNumber))
+	}
+	if !readFileOutput.notModified && (inode != nil) && backend.eTagsConflict(inode.eTag, readFileOutput.eTag) {
+		eTagConflictErrno = inode.resolveETagConflict(eTagConflictPolicy, readFileOutput.eTag, statFileOutput)
+		if eTagConflictErrno != 0 {
+			putCacheLineBuf(staleContent)
+			putCacheLineBuf(readFileOutput.buf)
+			for _, cacheLine = range cacheLines {
+				cacheLine.errno = eTagConflictErrno
+				cacheLine.complete(inode, "", make([]byte, 0))
+			}
+			globals.Unlock()
+			return
+		}
+	}
+	if readFileOutput.notModified {
+		// Only a (lone) cache line being revalidated is fetched with readFileInput.ifNoneMatch set
+
+		globals.cacheMetrics.LineRevalidationsUnchanged.Inc()
+		cacheLine.complete(inode, readFileOutput.eTag, staleContent)
+		globals.Unlock()
+		return
+	}
+	putCacheLineBuf(staleContent)
+	content = splitCacheLines(readFileOutput.buf, readFileInput.cacheLineSize, len(cacheLines))
+	for lineIndex, cacheLine = range cacheLines {
+		cacheLine.complete(inode, readFileOutput.eTag, content[lineIndex])
+	}
+	globals.Unlock()
+}
+
+// `complete` is called while globals.Lock() is held to transition a fetched cacheLine
+// (of inode, unless it has gone missing) from CacheLineInbound to CacheLineClean.
+func (cacheLine *cacheLineStruct) complete(inode *inodeStruct, eTag string, content []byte) {
+	if inode != nil {
 		inode.inboundCacheLineCount--
-	} else {
-		globals.logger.Printf("[WARN] [TODO] (*cacheLineStruct) fetch() needs to handle missing inodeStruct [case 3]")
 	}
 	cacheLine.state = CacheLineClean
-	cacheLine.eTag = readFileOutput.eTag
-	cacheLine.content = readFileOutput.buf
+	cacheLine.eTag = eTag
+	cacheLine.fetchTime = time.Now()
+	cacheLine.content = content
 	globals.inboundCacheLineCount--
 	cacheLine.listElement = globals.cleanCacheLineLRU.PushBack(cacheLine)
 	cacheLine.notifyWaiters()
-	globals.Unlock()
+}
+
+// `revalidate` is called while globals.Lock() is held to transition a CacheLineClean cacheLine
+// (of inode) older than cache_line_ttl back to CacheLineInbound (retaining its eTag and content)
+// such that fetch() will refetch it only should its eTag no longer match. The caller then
+// issues the fetch (via startFetch()).
+func (cacheLine *cacheLineStruct) revalidate(inode *inodeStruct, traceCtx context.Context) {
+	_ = globals.cleanCacheLineLRU.Remove(cacheLine.listElement)
+	cacheLine.listElement = nil
+	cacheLine.state = CacheLineInbound
+	cacheLine.prefetch = false
+	cacheLine.traceCtx = traceCtx
+	inode.inboundCacheLineCount++
+	globals.inboundCacheLineCount++
+	globals.cacheMetrics.LineRevalidations.Inc()
+}
+
+// `splitCacheLines` splits buf (read from the start of a run of cacheLines consecutive
+// cache lines) into the content of each. Those beyond the end of buf are empty. As each
+// is capped at (no more than) cacheLineSize, each may be passed to putCacheLineBuf() on its own.
+func splitCacheLines(buf []byte, cacheLineSize uint64, cacheLines int) (content [][]byte) {
+	var (
+		lineBegin uint64
+		lineCap   uint64 // One greater than offset to last byte of buf's capacity available to the line
+		lineEnd   uint64
+		lineIndex int
+	)
+
+	content = make([][]byte, cacheLines)
+
+	for lineIndex = range cacheLines {
+		lineBegin = uint64(lineIndex) * cacheLineSize
+		if lineBegin >= uint64(len(buf)) {
+			content[lineIndex] = make([]byte, 0)
+			continue
+		}
+
+		lineCap = min(lineBegin+cacheLineSize, uint64(cap(buf)))
+		lineEnd = min(lineCap, uint64(len(buf)))
+
+		content[lineIndex] = buf[lineBegin:lineEnd:lineCap]
+	}
+
+	return
 }
 
 // `touch` is called while globals.Lock() is held to update the placement of
@@ -118,42 +256,135 @@ func (cacheLine *cacheLineStruct) notifyWaiters() {
 
 // `cachePrune` is called to immediately attempt to trim globals.cleanCacheLineLRU
 // in an attempt to keep the sum of all cache lines at or below the configured cap.
+// Pinned cache lines (see cachePinned()) are skipped over (and, hence, may leave the
+// sum above the configured cap).
 // Note: This call must be made while holding the globals.Lock().
 func cachePrune() {
 	var (
-		cacheLineToEvict *cacheLineStruct
-		inode            *inodeStruct
-		listElement      *list.Element
-		ok               bool
+		listElement *list.Element
+		scanned     int
 	)
 
-	for (globals.inboundCacheLineCount + uint64(globals.cleanCacheLineLRU.Len())) >= globals.config.cacheLines {
+	for scanned = globals.cleanCacheLineLRU.Len(); (scanned > 0) && ((globals.inboundCacheLineCount + uint64(globals.cleanCacheLineLRU.Len())) >= globals.config.cacheLines); scanned-- {
 		listElement = globals.cleanCacheLineLRU.Front()
-		if listElement == nil {
-			return
-		}
 
-		cacheLineToEvict, ok = listElement.Value.(*cacheLineStruct)
-		if !ok {
-			dumpStack()
-			globals.logger.Fatalf("[FATAL] listElement.Value.(*cacheLineStruct) returned !ok")
+		if cacheLinePinned(listElement) {
+			globals.cleanCacheLineLRU.MoveToBack(listElement)
+			continue
 		}
 
-		_ = globals.cleanCacheLineLRU.Remove(listElement)
-		cacheLineToEvict.listElement = nil
+		evictCleanCacheLine(listElement)
+	}
+}
+
+// `cacheDropClean` is called while globals.Lock() is held to evict every clean cache
+// line not pinned (as if cache_lines had been reduced to zero), returning how many were evicted.
+func cacheDropClean() (numEvicted uint64) {
+	var (
+		listElement *list.Element
+		scanned     int
+	)
 
-		inode, ok = globals.inodeMap[cacheLineToEvict.inodeNumber]
-		if !ok {
-			dumpStack()
-			globals.logger.Fatalf("[FATAL] globals.inodeMap[cacheLineToEvict.inodeNumber] returned !ok [cachePrune()]")
-		}
+	for scanned = globals.cleanCacheLineLRU.Len(); scanned > 0; scanned-- {
+		listElement = globals.cleanCacheLineLRU.Front()
 
-		_, ok = inode.cache[cacheLineToEvict.lineNumber]
-		if !ok {
-			dumpStack()
-			globals.logger.Fatalf("[FATAL] inode.cache[cacheLineToEvict.lineNumber] returned !ok")
+		if cacheLinePinned(listElement) {
+			globals.cleanCacheLineLRU.MoveToBack(listElement)
+			continue
 		}
 
-		delete(inode.cache, cacheLineToEvict.lineNumber)
+		evictCleanCacheLine(listElement)
+
+		numEvicted++
+	}
+
+	return
+}
+
+// `evictCleanCacheLine` is called while globals.Lock() is held to evict the clean cache
+// line at listElement of globals.cleanCacheLineLRU.
+func evictCleanCacheLine(listElement *list.Element) {
+	var (
+		cacheLineToEvict *cacheLineStruct
+		inode            *inodeStruct
+		ok               bool
+	)
+
+	cacheLineToEvict, ok = listElement.Value.(*cacheLineStruct)
+	if !ok {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] listElement.Value.(*cacheLineStruct) returned !ok")
+	}
+
+	_ = globals.cleanCacheLineLRU.Remove(listElement)
+	cacheLineToEvict.listElement = nil
+
+	inode, ok = globals.inodeMap[cacheLineToEvict.inodeNumber]
+	if !ok {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] globals.inodeMap[cacheLineToEvict.inodeNumber] returned !ok [cachePrune()]")
+	}
+
+	_, ok = inode.cache[cacheLineToEvict.lineNumber]
+	if !ok {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] inode.cache[cacheLineToEvict.lineNumber] returned !ok")
+	}
+
+	delete(inode.cache, cacheLineToEvict.lineNumber)
+
+	putCacheLineBuf(cacheLineToEvict.content)
+	cacheLineToEvict.content = nil
+
+	globals.cacheMetrics.LineEvictions.Inc()
+}
+
+// `getCacheLineBuf` returns a buffer of len (and cap) cacheLineSize either recycled
+// from globals.cacheLineBufPool (only if cacheLineSize == globals.config.cacheLineSize)
+// or, if none is available, freshly allocated. Its contents are undefined.
+func getCacheLineBuf(cacheLineSize uint64) (buf []byte) {
+	var (
+		bufPtr *[]byte
+		ok     bool
+	)
+
+	if cacheLineSize != globals.config.cacheLineSize {
+		buf = make([]byte, cacheLineSize)
+		return
+	}
+
+	bufPtr, ok = globals.cacheLineBufPool.Get().(*[]byte)
+	if ok && (uint64(cap(*bufPtr)) == cacheLineSize) {
+		buf = (*bufPtr)[:cacheLineSize]
+	} else {
+		buf = make([]byte, cacheLineSize)
+	}
+
+	return
+}
+
+// `putCacheLineBuf` returns a buffer to globals.cacheLineBufPool for reuse by
+// getCacheLineBuf(). Buffers not of cap globals.config.cacheLineSize are ignored.
+// The caller must ensure buf is no longer referenced.
+func putCacheLineBuf(buf []byte) {
+	if uint64(cap(buf)) == globals.config.cacheLineSize {
+		globals.cacheLineBufPool.Put(&buf)
+	}
+}
+
+// `cacheLineBufWriterStruct` is an io.Writer that fills a buffer obtained from
+// getCacheLineBuf() without any reallocation. Writes beyond its len fail.
+type cacheLineBufWriterStruct struct {
+	buf []byte
+	len int
+}
+
+// `Write` is called to append p to cacheLineBufWriter.buf.
+func (cacheLineBufWriter *cacheLineBufWriterStruct) Write(p []byte) (n int, err error) {
+	n = copy(cacheLineBufWriter.buf[cacheLineBufWriter.len:], p)
+	cacheLineBufWriter.len += n
+	if n < len(p) {
+		err = io.ErrShortWrite
 	}
+	return
 }
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"cmp"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+// `cacheControlCommands` enumerates the commands accepted by {-cache|--cache} (each mapping
+// to the admin API endpoint /cache/<command>). Those listed in cacheControlTargetCommands
+// require a <dir_name>[/<path>] target and change state (so are sent as a POST).
+var (
+	cacheControlCommands       = []string{"stats", "ls", "drop", "pin", "unpin", "warm"}
+	cacheControlTargetCommands = []string{"drop", "pin", "unpin", "warm"}
+)
+
+// `adminCacheStatsStruct` is the response to GET /cache/stats.
+type adminCacheStatsStruct struct {
+	CacheLinesMax      uint64              `json:"cache_lines_max"`
+	InboundCacheLines  uint64              `json:"inbound_cache_lines"`
+	CleanCacheLines    uint64              `json:"clean_cache_lines"`
+	PinnedCacheLines   uint64              `json:"pinned_cache_lines"`
+	OutboundCacheLines uint64              `json:"outbound_cache_lines"`
+	DirtyCacheLines    uint64              `json:"dirty_cache_lines"`
+	Pins               []string            `json:"pins"`
+	Backends           []*adminCacheStruct `json:"backends"`
+}
+
+// `adminCacheFileStruct` is an element of the response to GET /cache/ls describing a file with cache lines.
+type adminCacheFileStruct struct {
+	Backend    string `json:"backend"`
+	Path       string `json:"path"`
+	Inode      uint64 `json:"inode"`
+	Size       uint64 `json:"size"`
+	CacheLines uint64 `json:"cache_lines"`
+	Bytes      uint64 `json:"bytes"`
+	Pinned     bool   `json:"pinned"`
+}
+
+// `adminCacheWarmStruct` is the response to POST /cache/warm.
+type adminCacheWarmStruct struct {
+	Files uint64 `json:"files"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// `cacheTargetMatches` returns whether the file at objectPath of the backend named dirName
+// is (or is beneath) target (of the form <dir_name>[/<path>] without a trailing "/").
+func cacheTargetMatches(target string, dirName string, objectPath string) bool {
+	var (
+		key = dirName + "/" + objectPath
+	)
+
+	return (key == target) || strings.HasPrefix(key, target+"/")
+}
+
+// `cachePinned` is called while globals.Lock() is held to determine whether the cache
+// lines of inode are exempt from eviction per globals.cachePins.
+func cachePinned(inode *inodeStruct) bool {
+	if (len(globals.cachePins) == 0) || (inode.inodeType != FileObject) || (inode.backend == nil) {
+		return false
+	}
+
+	for pin := range globals.cachePins {
+		if cacheTargetMatches(pin, inode.backend.dirName, inode.objectPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// `cacheLinePinned` is called while globals.Lock() is held to determine whether the
+// clean cache line at listElement of globals.cleanCacheLineLRU is exempt from eviction.
+func cacheLinePinned(listElement *list.Element) bool {
+	var (
+		cacheLine *cacheLineStruct
+		inode     *inodeStruct
+		ok        bool
+	)
+
+	if len(globals.cachePins) == 0 {
+		return false
+	}
+
+	cacheLine, ok = listElement.Value.(*cacheLineStruct)
+	if !ok {
+		return false
+	}
+
+	inode, ok = globals.inodeMap[cacheLine.inodeNumber]
+	if !ok {
+		return false
+	}
+
+	return cachePinned(inode)
+}
+
+// `cacheTarget` validates target (of the form <dir_name>[/<path>]) as naming a mounted
+// backend, returning it (without any trailing "/") along with its dir_name and path.
+func cacheTarget(target string) (normalizedTarget string, dirName string, path string, err error) {
+	normalizedTarget = strings.TrimSuffix(target, "/")
+	dirName, path, _ = strings.Cut(normalizedTarget, "/")
+
+	globals.Lock()
+	_, ok := globals.config.backends[dirName]
+	globals.Unlock()
+	if !ok {
+		err = fmt.Errorf("no mounted backend with dir_name \"%s\"", dirName)
+	}
+
+	return
+}
+
+// `adminCacheStats` returns the counts of cache lines (including those pinned) along with the pins and per backend usage.
+func adminCacheStats() (cacheStats *adminCacheStatsStruct) {
+	var (
+		inode *inodeStruct
+	)
+
+	cacheStats = &adminCacheStatsStruct{
+		Pins:     make([]string, 0),
+		Backends: adminCache(),
+	}
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	cacheStats.CacheLinesMax = globals.config.cacheLines
+	cacheStats.InboundCacheLines = globals.inboundCacheLineCount
+	cacheStats.CleanCacheLines = uint64(globals.cleanCacheLineLRU.Len())
+	cacheStats.OutboundCacheLines = globals.outboundCacheLineCount
+	cacheStats.DirtyCacheLines = uint64(globals.dirtyCacheLineLRU.Len())
+
+	for pin := range globals.cachePins {
+		cacheStats.Pins = append(cacheStats.Pins, pin)
+	}
+	slices.Sort(cacheStats.Pins)
+
+	for _, inode = range globals.inodeMap {
+		if cachePinned(inode) {
+			for _, cacheLine := range inode.cache {
+				if cacheLine.state == CacheLineClean {
+					cacheStats.PinnedCacheLines++
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// `adminCacheLs` returns each file with cache lines (ordered by backend then path).
+func adminCacheLs() (files []*adminCacheFileStruct) {
+	var (
+		file  *adminCacheFileStruct
+		inode *inodeStruct
+	)
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	files = make([]*adminCacheFileStruct, 0)
+
+	for _, inode = range globals.inodeMap {
+		if (inode.inodeType != FileObject) || (len(inode.cache) == 0) || (inode.backend == nil) {
+			continue
+		}
+
+		file = &adminCacheFileStruct{
+			Backend:    inode.backend.dirName,
+			Path:       inode.objectPath,
+			Inode:      inode.inodeNumber,
+			Size:       max(inode.sizeInBackend, inode.sizeInMemory),
+			CacheLines: uint64(len(inode.cache)),
+			Pinned:     cachePinned(inode),
+		}
+		for _, cacheLine := range inode.cache {
+			file.Bytes += uint64(len(cacheLine.content))
+		}
+
+		files = append(files, file)
+	}
+
+	slices.SortFunc(files, func(a, b *adminCacheFileStruct) int {
+		return cmp.Or(strings.Compare(a.Backend, b.Backend), strings.Compare(a.Path, b.Path))
+	})
+
+	return
+}
+
+// `cacheDrop` is called while globals.Lock() is held to evict every clean cache line (pinned
+// or not) of each file that is (or is beneath) target, returning how many were evicted.
+func cacheDrop(target string) (numEvicted uint64) {
+	var (
+		inode *inodeStruct
+	)
+
+	for _, inode = range globals.inodeMap {
+		if (inode.inodeType != FileObject) || (len(inode.cache) == 0) || (inode.backend == nil) || !cacheTargetMatches(target, inode.backend.dirName, inode.objectPath) {
+			continue
+		}
+
+		for _, cacheLine := range inode.cache {
+			if cacheLine.state == CacheLineClean {
+				evictCleanCacheLine(cacheLine.listElement)
+				numEvicted++
+			}
+		}
+	}
+
+	return
+}
+
+// `cacheWarm` reads (through the cache, exactly as if via the mount) the file identified by
+// target or, should target identify a directory, each file beneath it. Note that, unless
+// pinned, cache lines warmed beyond cache_lines will evict those warmed earlier.
+func cacheWarm(target string) (warm *adminCacheWarmStruct, err error) {
+	var (
+		backend  *backendStruct
+		dirName  string
+		entryOut *fission.EntryOut
+		path     string
+	)
+
+	_, dirName, path, err = cacheTarget(target)
+	if err != nil {
+		return
+	}
+
+	entryOut, err = fissionLookupPath(dirName, path)
+	if err != nil {
+		return
+	}
+
+	warm = &adminCacheWarmStruct{}
+
+	if (entryOut.Attr.Mode & syscall.S_IFMT) == syscall.S_IFREG {
+		warm.Bytes, err = fissionReadFile(entryOut.NodeID, entryOut.Attr.Size)
+		if err == nil {
+			warm.Files = 1
+		}
+		return
+	}
+
+	globals.Lock()
+	backend = globals.config.backends[dirName]
+	globals.Unlock()
+
+	if path != "" {
+		path += "/"
+	}
+
+	err = cacheWarmDirectory(warm, backend, entryOut.NodeID, path)
+
+	return
+}
+
+// `cacheWarmDirectory` warms each file beneath the directory at dirPath (and inode) of backend.
+func cacheWarmDirectory(warm *adminCacheWarmStruct, backend *backendStruct, inode uint64, dirPath string) (err error) {
+	var (
+		bytes               uint64
+		errno               syscall.Errno
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		lookupOut           *fission.LookupOut
+	)
+
+	listDirectoryInput = &listDirectoryInputStruct{
+		dirPath:  dirPath,
+		maxItems: backend.directoryPageSize,
+		bulk:     true,
+	}
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(backend.context, listDirectoryInput)
+		if err != nil {
+			return
+		}
+
+		for _, file := range listDirectoryOutput.file {
+			lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: inode}, &fission.LookupIn{Name: []byte(file.basename)})
+			if errno != 0 {
+				err = fmt.Errorf("unable to lookup \"%s%s\": %v", dirPath, file.basename, errno)
+				return
+			}
+
+			bytes, err = fissionReadFile(lookupOut.EntryOut.NodeID, lookupOut.EntryOut.Attr.Size)
+			if err != nil {
+				return
+			}
+
+			warm.Files++
+			warm.Bytes += bytes
+		}
+
+		for _, subdirectory := range listDirectoryOutput.subdirectory {
+			lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: inode}, &fission.LookupIn{Name: []byte(subdirectory)})
+			if errno != 0 {
+				err = fmt.Errorf("unable to lookup \"%s%s/\": %v", dirPath, subdirectory, errno)
+				return
+			}
+
+			err = cacheWarmDirectory(warm, backend, lookupOut.EntryOut.NodeID, dirPath+subdirectory+"/")
+			if err != nil {
+				return
+			}
+		}
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			return
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+}
+
+// `serveAdminCache` implements the /cache/<command> endpoints of the admin API. Those other
+// than stats and ls change state (so require a POST) and operate on the path query parameter.
+func serveAdminCache(w http.ResponseWriter, r *http.Request) {
+	var (
+		command    = strings.TrimPrefix(r.URL.Path, "/cache/")
+		err        error
+		numEvicted uint64
+		target     string
+		warm       *adminCacheWarmStruct
+	)
+
+	switch command {
+	case "stats":
+		writeAdminJSON(w, adminCacheStats())
+		return
+	case "ls":
+		writeAdminJSON(w, adminCacheLs())
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "POST required\n")
+		return
+	}
+
+	target, _, _, err = cacheTarget(r.URL.Query().Get("path"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "bad path: %v\n", err)
+		return
+	}
+
+	switch command {
+	case "drop":
+		globals.Lock()
+		numEvicted = cacheDrop(target)
+		globals.Unlock()
+
+		globals.logger.Printf("[INFO] [admin] cache drop of %s evicted %v cache line(s)", target, numEvicted)
+
+		writeAdminJSON(w, map[string]uint64{"cache_lines_evicted": numEvicted})
+
+	case "pin", "unpin":
+		globals.Lock()
+		if command == "pin" {
+			globals.cachePins[target] = struct{}{}
+		} else {
+			delete(globals.cachePins, target)
+		}
+		globals.Unlock()
+
+		globals.logger.Printf("[INFO] [admin] cache %s of %s", command, target)
+
+		writeAdminJSON(w, adminCacheStats().Pins)
+
+	case "warm":
+		warm, err = cacheWarm(target)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "warm failed: %v\n", err)
+			return
+		}
+
+		globals.logger.Printf("[INFO] [admin] cache warm of %s read %v file(s) totaling %v byte(s)", target, warm.Files, warm.Bytes)
+
+		writeAdminJSON(w, warm)
+	}
+}
+
+// `cacheControl` issues command (one of cacheControlCommands) against target (if required)
+// to the running daemon via the admin API on admin_socket, writing its response to w.
+func cacheControl(w io.Writer, command string, target string) (err error) {
+	var (
+		body         []byte
+		httpClient   *http.Client
+		httpRequest  *http.Request
+		httpResponse *http.Response
+		method       = http.MethodGet
+		uri          = "http://msfs/cache/" + command
+	)
+
+	if globals.config.adminSocket == "" {
+		err = errors.New("admin_socket not specified in config-file")
+		return
+	}
+
+	if slices.Contains(cacheControlTargetCommands, command) {
+		method = http.MethodPost
+		uri += "?path=" + url.QueryEscape(target)
+	}
+
+	httpClient = adminSocketClient()
+
+	httpRequest, err = http.NewRequest(method, uri, nil)
+	if err != nil {
+		return
+	}
+
+	httpResponse, err = httpClient.Do(httpRequest)
+	if err != nil {
+		err = fmt.Errorf("unable to reach daemon via admin_socket (\"%s\"): %v", globals.config.adminSocket, err)
+		return
+	}
+
+	body, err = io.ReadAll(httpResponse.Body)
+	_ = httpResponse.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		err = errors.New(strings.TrimSpace(string(body)))
+		return
+	}
+
+	_, err = w.Write(body)
+
+	return
+}
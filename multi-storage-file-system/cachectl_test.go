@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheControl(t *testing.T) {
+	var (
+		cacheStats *adminCacheStatsStruct
+		err        error
+		files      []*adminCacheFileStruct
+		output     bytes.Buffer
+		warm       *adminCacheWarmStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	err = cacheControl(&output, "stats", "")
+	if (err == nil) || !strings.Contains(err.Error(), "admin_socket") {
+		t.Fatalf("cacheControl(\"stats\") without admin_socket returned err: %v", err)
+	}
+
+	globals.config.adminSocket = filepath.Join(t.TempDir(), "admin.sock")
+	startAdminSocket()
+	defer stopAdminSocket()
+
+	cacheRequest := func(command string, target string, response interface{}) {
+		output.Reset()
+		err := cacheControl(&output, command, target)
+		if err != nil {
+			t.Fatalf("cacheControl(\"%s\",\"%s\") failed: %v", command, target, err)
+		}
+		if response != nil {
+			err = json.Unmarshal(output.Bytes(), response)
+			if err != nil {
+				t.Fatalf("cacheControl(\"%s\",\"%s\") returned unparseable %s: %v", command, target, output.String(), err)
+			}
+		}
+	}
+
+	// Warming a directory reads each file beneath it
+
+	cacheRequest("warm", "ram/dir1/", &warm)
+	if (warm.Files != 2) || (warm.Bytes != uint64(len("/dir1/fileC\n")+len("/dir1/dir3/fileD\n"))) {
+		t.Fatalf("cacheControl(\"warm\",\"ram/dir1/\") returned %+v", warm)
+	}
+
+	cacheRequest("warm", "ram/fileA", &warm)
+	if (warm.Files != 1) || (warm.Bytes != uint64(len("/fileA\n"))) {
+		t.Fatalf("cacheControl(\"warm\",\"ram/fileA\") returned %+v", warm)
+	}
+
+	cacheRequest("ls", "", &files)
+	if (len(files) != 3) || (files[0].Path != "dir1/dir3/fileD") || (files[1].Path != "dir1/fileC") || (files[2].Path != "fileA") || (files[2].Size != 7) || (files[2].Bytes != 7) || files[2].Pinned {
+		t.Fatalf("cacheControl(\"ls\") returned %s", output.String())
+	}
+
+	// Pinned cache lines survive both pruning and drop_caches
+
+	cacheRequest("pin", "ram/dir1", nil)
+
+	cacheRequest("stats", "", &cacheStats)
+	if (cacheStats.CleanCacheLines != 3) || (cacheStats.PinnedCacheLines != 2) || (len(cacheStats.Pins) != 1) || (cacheStats.Pins[0] != "ram/dir1") {
+		t.Fatalf("cacheControl(\"stats\") returned %s", output.String())
+	}
+
+	globals.Lock()
+	cacheLines := globals.config.cacheLines
+	globals.config.cacheLines = 1
+	cachePrune()
+	globals.config.cacheLines = cacheLines
+	numEvicted := cacheDropClean()
+	numDrained := inodeEvictorForceDrain()
+	globals.Unlock()
+
+	if numEvicted != 0 {
+		t.Fatalf("cacheDropClean() after cachePrune() evicted %v (expected 0)", numEvicted)
+	}
+
+	cacheRequest("ls", "", &files)
+	if (numDrained == 0) || (len(files) != 2) || !files[0].Pinned || !files[1].Pinned {
+		t.Fatalf("cacheControl(\"ls\") after pruning and draining (%v) returned %s", numDrained, output.String())
+	}
+
+	// Dropping evicts even pinned cache lines
+
+	cacheRequest("drop", "ram/dir1/fileC", nil)
+	if output.String() != "{\n  \"cache_lines_evicted\": 1\n}\n" {
+		t.Fatalf("cacheControl(\"drop\",\"ram/dir1/fileC\") returned %s", output.String())
+	}
+
+	cacheRequest("unpin", "ram/dir1/", nil)
+
+	cacheRequest("stats", "", &cacheStats)
+	if (cacheStats.CleanCacheLines != 1) || (cacheStats.PinnedCacheLines != 0) || (len(cacheStats.Pins) != 0) {
+		t.Fatalf("cacheControl(\"stats\") after unpin returned %s", output.String())
+	}
+
+	for _, target := range []string{"none/fileA", ""} {
+		err = cacheControl(&output, "pin", target)
+		if (err == nil) || !strings.Contains(err.Error(), "no mounted backend") {
+			t.Fatalf("cacheControl(\"pin\",\"%s\") returned err: %v", target, err)
+		}
+	}
+
+	err = cacheControl(&output, "warm", "ram/fileZ")
+	if (err == nil) || !strings.Contains(err.Error(), "warm failed") {
+		t.Fatalf("cacheControl(\"warm\",\"ram/fileZ\") returned err: %v", err)
+	}
+}
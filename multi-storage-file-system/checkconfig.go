@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// `checkBackends` is called, in lieu of mounting, after the configuration file has been
+// successfully parsed to set up (including resolving the credentials of) each backend on
+// the globals.backendsToMount list and probe its reachability by listing (at most) one
+// file or subdirectory at its prefix. The outcome for each backend is reported to w and
+// allReachable returned as true only if every backend was successfully set up and probed.
+//
+// Backends of backend_type "Sharded" and "Snapshot" are merely set up as they are
+// composed of other backends (each of which is probed in its own right).
+func checkBackends(w io.Writer) (allReachable bool) {
+	var (
+		backend  *backendStruct
+		dirName  string
+		dirNames []string
+		err      error
+	)
+
+	if globals.secrets == nil {
+		globals.secrets = newSecrets()
+	}
+
+	dirNames = make([]string, 0, len(globals.backendsToMount))
+	for dirName = range globals.backendsToMount {
+		dirNames = append(dirNames, dirName)
+	}
+	slices.Sort(dirNames)
+
+	allReachable = true
+
+	for _, dirName = range dirNames {
+		backend = globals.backendsToMount[dirName]
+
+		err = backend.setupContext()
+		if err == nil {
+			err = backend.probe()
+		}
+
+		if err == nil {
+			_, _ = fmt.Fprintf(w, "backends[\"%s\"] (%s %s): reachable\n", dirName, backend.backendType, backend.backendPath)
+		} else {
+			_, _ = fmt.Fprintf(w, "backends[\"%s\"] (%s %s): unreachable: %v\n", dirName, backend.backendType, backend.backendPath, strings.TrimSpace(err.Error()))
+			allReachable = false
+		}
+	}
+
+	return
+}
+
+// `probe` is called after backend's context has been set up to verify that it is
+// reachable with the configured credentials by listing (at most) one file or
+// subdirectory at its prefix. The backend need not (and, indeed, should not yet)
+// be mounted.
+func (backend *backendStruct) probe() (err error) {
+	switch backend.backendType {
+	case "Sharded", "Snapshot":
+		return
+	}
+
+	_, err = backend.context.listDirectory(&listDirectoryInputStruct{
+		maxItems: 1,
+		dirPath:  "",
+	})
+
+	return
+}
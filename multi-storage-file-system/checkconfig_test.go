@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckBackends(t *testing.T) {
+	var (
+		allReachable bool
+		err          error
+		report       bytes.Buffer
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+  },
+  {
+    dir_name: s3,
+    bucket_container_name: test,
+    backend_type: S3,
+	S3: {
+	  region: us-east-1,
+	  endpoint: "http://127.0.0.1:1",
+	  access_key_id: minioadmin,
+	  secret_access_key: minioadmin,
+	  retry_max_attempts: 1,
+	},
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	allReachable = checkBackends(&report)
+	if allReachable {
+		t.Fatalf("checkBackends() unexpectedly reported all backends reachable:\n%s", report.String())
+	}
+
+	if !strings.HasPrefix(report.String(), "backends[\"ram\"] (RAM ") || !strings.Contains(report.String(), "): reachable\nbackends[\"s3\"] (S3 http://127.0.0.1:1/test/): unreachable: ") {
+		t.Fatalf("checkBackends() reported unexpectedly:\n%s", report.String())
+	}
+
+	// Nothing was mounted
+
+	if len(globals.config.backends) != 0 {
+		t.Fatalf("checkBackends() left len(globals.config.backends) == %v (expected 0)", len(globals.config.backends))
+	}
+}
@@ -1,29 +1,76 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/NVIDIA/multi-storage-client/multi-storage-file-system/telemetry"
 	"github.com/drone/envsubst"
-	"gopkg.in/yaml.v3"
 )
 
 const (
 	defaultMountPoint = "/mnt"
 
+	defaultHTTPMaxIdleConnsPerHost = uint64(256)
+	defaultHTTPIdleConnTimeout     = 90000 * time.Millisecond
+	defaultHTTPProtocol            = HTTPProtocolAuto
+	defaultDirectoryMarkers        = DirectoryMarkersSlash
+	defaultETagConflictPolicy      = ETagConflictPolicyFail
+	defaultHTTPKeepAlive           = 30000 * time.Millisecond
+	defaultMirrorReconcileInterval = 60000 * time.Millisecond
+	defaultTierInterval            = 3600000 * time.Millisecond
+	defaultQuotaReconcileInterval  = 300000 * time.Millisecond
+	defaultPriority                = "normal"
+	defaultHealthCheckFailures     = uint64(3)
+	defaultMultipartUploadMaxAge   = 86400000 * time.Millisecond
+	defaultReplicaProbeInterval    = 10000 * time.Millisecond
+	defaultAuditLogMaxSize         = uint64(104857600) // 100Mi
+	defaultAuditLogMaxFiles        = uint64(10)
+	defaultAuditLogSampleRate      = float64(1)
+	defaultAuditPrefix             = "audit/"
+	defaultSecretsRefreshInterval  = 300 * time.Second
+	defaultLogFormat               = logFormatText
+	defaultLogLevel                = "debug"
+
 	defaultAIStoreSkipTLSCertificateVerify = true
 	defaultAIStoreProvider                 = "s3"
 	defaultAIStoreTimeout                  = 30000 * time.Millisecond
+	defaultAIStoreClusterMapTTL            = 60000 * time.Millisecond
+	defaultAIStorePropsCacheTTL            = 1000 * time.Millisecond
+	defaultAIStoreRetryBaseDelay           = 10 * time.Millisecond
+	defaultAIStoreRetryNextDelayMultiplier = float64(2.0)
+	defaultAIStoreRetryMaxDelay            = 2000 * time.Millisecond
+
+	defaultAzureFilesTimeout                  = 30000 * time.Millisecond
+	defaultAzureFilesRetryBaseDelay           = 10 * time.Millisecond
+	defaultAzureFilesRetryNextDelayMultiplier = float64(2.0)
+	defaultAzureFilesRetryMaxDelay            = 2000 * time.Millisecond
+
+	defaultDropboxAPIEndpoint              = "https://api.dropboxapi.com"
+	defaultDropboxContentEndpoint          = "https://content.dropboxapi.com"
+	defaultDropboxTimeout                  = 30000 * time.Millisecond
+	defaultDropboxRetryBaseDelay           = 10 * time.Millisecond
+	defaultDropboxRetryNextDelayMultiplier = float64(2.0)
+	defaultDropboxRetryMaxDelay            = 2000 * time.Millisecond
 
 	defaultRAMMaxTotalObjects      = uint64(10000)
 	defaultRAMMaxTotalObjectSpace  = uint64(1073741824) // 2^30 == 1Gi
 	defaultRAMMaxDirectoryPageSize = uint64(100)
+
+	defaultS3ConditionalRequests     = S3ConditionalRequestsProbe
+	defaultS3CredentialRefreshWindow = 300000 * time.Millisecond
+	defaultS3RetryMode               = S3RetryModeStandard
+	defaultS3RetryJitter             = S3RetryJitterFull
+	defaultS3SignatureVersion        = S3SignatureVersionV4
+	defaultS3Provider                = S3ProviderAWS
 )
 
 // `parseAny` provides a convenient test for the existence of
@@ -136,6 +183,68 @@ func parseMilliseconds(m map[string]interface{}, key string, dflt interface{}) (
 	return
 }
 
+// `parseTimestamp` fetches what is expected to be an RFC3339 timestamp (e.g.
+// "2026-01-02T15:04:05Z") for the specified key from the map. If the key is
+// missing (or its value is ""), the zero time.Time is returned.
+func parseTimestamp(m map[string]interface{}, key string) (t time.Time, ok bool) {
+	var (
+		err error
+		s   string
+		v   interface{}
+	)
+
+	v, ok = m[key]
+	if !ok {
+		ok = true
+		return
+	}
+
+	s, ok = v.(string)
+	if !ok {
+		return
+	}
+
+	s = os.ExpandEnv(s)
+	if s == "" {
+		return
+	}
+
+	t, err = time.Parse(time.RFC3339, s)
+	ok = (err == nil)
+
+	return
+}
+
+// `computeRetryAttempts` computes the total number of attempts (including the first)
+// for a request given the retry_{max_attempts|base_delay|next_delay_multiplier|max_delay}
+// settings. If maxAttempts == 0, attempts stop once the delay before the next retry
+// would exceed maxDelay. If baseDelay == 0, retry is disabled.
+func computeRetryAttempts(maxAttempts uint64, baseDelay time.Duration, nextDelayMultiplier float64, maxDelay time.Duration) (attempts int) {
+	var (
+		nextRetryDelay time.Duration
+	)
+
+	switch {
+	case baseDelay == time.Duration(0):
+		attempts = 1
+	case maxAttempts != 0:
+		attempts = int(maxAttempts)
+	default:
+		attempts = 1
+		nextRetryDelay = baseDelay
+
+		for nextRetryDelay <= maxDelay {
+			attempts++
+			nextRetryDelay = time.Duration(float64(nextRetryDelay) * nextDelayMultiplier)
+			if nextDelayMultiplier == float64(1.0) {
+				break
+			}
+		}
+	}
+
+	return
+}
+
 // `parseSeconds` fetches what is expected to be a uint64 value for the
 // specified key from the map converting it to a time.Duration assuming the
 // uint64 specifies a number of seconds. If the key is missing and a
@@ -202,6 +311,47 @@ func parseString(m map[string]interface{}, key string, dflt interface{}) (s stri
 	return
 }
 
+// `parseStringSlice` fetches what is expected to be a list of string values
+// for the specified key from the map. If the key is missing and a non-nil
+// dflt is provided, the func will return this dflt.
+func parseStringSlice(m map[string]interface{}, key string, dflt interface{}) (ss []string, ok bool) {
+	var (
+		s  string
+		v  interface{}
+		vs []interface{}
+	)
+
+	v, ok = m[key]
+	if ok {
+		vs, ok = v.([]interface{})
+		if !ok {
+			return
+		}
+
+		ss = make([]string, 0, len(vs))
+
+		for _, v = range vs {
+			s, ok = v.(string)
+			if !ok {
+				return
+			}
+
+			ss = append(ss, os.ExpandEnv(s))
+		}
+
+		return
+	}
+
+	if dflt == nil {
+		ok = false
+		return
+	}
+
+	ss, ok = dflt.([]string)
+
+	return
+}
+
 // `parseUint64` fetches what is expected to be a uint64 value for the
 // specified key from the map. If the key is missing and a non-nil
 // dflt is provided, the func will return this dflt.
@@ -243,6 +393,47 @@ func parseUint64(m map[string]interface{}, key string, dflt interface{}) (u uint
 	return
 }
 
+// `parseUint64Slice` fetches what is expected to be a []uint64 value
+// for the specified key from the map. If the key is missing and a
+// non-nil dflt is provided, the func will return this dflt.
+func parseUint64Slice(m map[string]interface{}, key string, dflt interface{}) (us []uint64, ok bool) {
+	var (
+		u  uint64
+		v  interface{}
+		vs []interface{}
+	)
+
+	v, ok = m[key]
+	if ok {
+		vs, ok = v.([]interface{})
+		if !ok {
+			return
+		}
+
+		us = make([]uint64, 0, len(vs))
+
+		for _, v = range vs {
+			u, ok = parseUint64(map[string]interface{}{key: v}, key, nil)
+			if !ok {
+				return
+			}
+
+			us = append(us, u)
+		}
+
+		return
+	}
+
+	if dflt == nil {
+		ok = false
+		return
+	}
+
+	us, ok = dflt.([]uint64)
+
+	return
+}
+
 // `checkConfigFile` parses globals.configFilePath in either JSON or YAML
 // format following either the MSC Python-compatible or MSFS-specific
 // specification. Upon success, it will also populate both the
@@ -250,6 +441,13 @@ func parseUint64(m map[string]interface{}, key string, dflt interface{}) (u uint
 // case where an existing configuration is being updated.
 func checkConfigFile() (err error) {
 	var (
+		accessName                            string
+		accessRule                            backendAccessRuleStruct
+		accessRuleAsInterface                 interface{}
+		accessRuleAsMap                       map[string]interface{}
+		accessRulesAsInterface                interface{}
+		accessRulesAsInterfaceSlice           []interface{}
+		accessRulesAsInterfaceSliceIndex      int
 		backendAsInterface                    interface{}
 		backendsAsInterface                   interface{}
 		backendsAsInterfaceSlice              []interface{}
@@ -263,14 +461,29 @@ func checkConfigFile() (err error) {
 		backendConfigS3AsInterface            interface{}
 		backendConfigS3AsMap                  map[string]interface{}
 		backendConfigS3AsStruct               *backendConfigS3Struct
+		backendConfigShardedAsInterface       interface{}
+		backendConfigShardedAsMap             map[string]interface{}
+		backendConfigShardedAsStruct          *backendConfigShardedStruct
+		backendConfigSnapshotAsInterface      interface{}
+		backendConfigSnapshotAsMap            map[string]interface{}
+		backendConfigSnapshotAsStruct         *backendConfigSnapshotStruct
 		backendConfigAIStoreAsInterface       interface{}
 		backendConfigAIStoreAsMap             map[string]interface{}
 		backendConfigAIStoreAsStruct          *backendConfigAIStoreStruct
+		backendConfigAzureFilesAsInterface    interface{}
+		backendConfigAzureFilesAsMap          map[string]interface{}
+		backendConfigAzureFilesAsStruct       *backendConfigAzureFilesStruct
+		backendConfigDropboxAsInterface       interface{}
+		backendConfigDropboxAsMap             map[string]interface{}
+		backendConfigDropboxAsStruct          *backendConfigDropboxStruct
 		config                                *configStruct
 		configFileContent                     []byte
+		configFileDefaultLayers               []*configFileLayerStruct
+		configFileLayer                       *configFileLayerStruct
+		configFileLayers                      []*configFileLayerStruct
 		configFileMap                         map[string]interface{}
 		configFileMapTranslated               map[string]interface{}
-		configFilePathExt                     string
+		configSchemaPositionOf                func(path string) (position configSchemaPositionStruct, ok bool)
 		credentialsProviderAsInterface        interface{}
 		credentialsProviderAsMap              map[string]interface{}
 		credentialsProviderOptionsAsInterface interface{}
@@ -283,8 +496,36 @@ func checkConfigFile() (err error) {
 		dirtyCacheLinesFlushTriggerPercentage uint64
 		dirtyCacheLinesMaxPercentage          uint64
 		filePerm                              string
-		nextRetryDelay                        time.Duration
+		mirrorBackend                         *backendStruct
+		priorityName                          string
+		priorityPrefix                        backendPriorityPrefixStruct
+		priorityPrefixAsInterface             interface{}
+		priorityPrefixAsMap                   map[string]interface{}
+		priorityPrefixesAsInterface           interface{}
+		priorityPrefixesAsInterfaceSlice      []interface{}
+		priorityPrefixesAsInterfaceSliceIndex int
+		quota                                 backendQuotaStruct
+		quotaAsInterface                      interface{}
+		quotaAsMap                            map[string]interface{}
+		quotasAsInterface                     interface{}
+		quotasAsInterfaceSlice                []interface{}
+		quotasAsInterfaceSliceIndex           int
+		replicaBackend                        *backendStruct
+		replicaDirName                        string
+		replicaIndex                          int
+		shardBackend                          *backendStruct
+		shardBackendDirName                   string
+		shardBackendIndex                     int
+		snapshotSourceBackend                 *backendStruct
+		tierColdBackend                       *backendStruct
+		tierPathPattern                       string
 		ok                                    bool
+		pathOverride                          backendPathOverrideStruct
+		pathOverrideAsInterface               interface{}
+		pathOverrideAsMap                     map[string]interface{}
+		pathOverridesAsInterface              interface{}
+		pathOverridesAsInterfaceSlice         []interface{}
+		pathOverridesAsInterfaceSliceIndex    int
 		posixAllowOther                       bool
 		posixAsInterface                      interface{}
 		posixAsMap                            map[string]interface{}
@@ -316,26 +557,47 @@ func checkConfigFile() (err error) {
 		return
 	}
 
-	configFileMap = make(map[string]interface{})
+	configFileMap, err = parseConfigFileContent(globals.configFilePath, configFileContent)
+	if err != nil {
+		return
+	}
+
+	// Layer the config-file atop the files it (transitively) includes (if any)
 
-	configFilePathExt = filepath.Ext(globals.configFilePath)
+	configFileLayers, err = loadConfigFileLayers(globals.configFilePath, configFileContent, configFileMap, make(map[string]struct{}))
+	if err != nil {
+		return
+	}
+
+	// Layer the config-file (and its includes) atop any discovered defaults (e.g. in /etc/msfs/)
 
-	switch configFilePathExt {
-	case ".json":
-		err = json.Unmarshal(configFileContent, &configFileMap)
+	if len(globals.configFileDefaultPaths) != 0 {
+		configFileDefaultLayers, err = loadConfigFileDefaultLayers(globals.configFileDefaultPaths)
 		if err != nil {
-			err = fmt.Errorf("unable to parse config-file \"%s\" as JSON (err: %v)", globals.configFilePath, err)
 			return
 		}
-	case ".yaml", ".yml":
-		err = yaml.Unmarshal(configFileContent, &configFileMap)
+
+		configFileLayers = append(configFileDefaultLayers, configFileLayers...)
+	}
+
+	configFileMap = mergeConfigFileLayers(configFileLayers)
+
+	// Merge the selected (if any) of msfs_profiles atop the remaining settings
+
+	configFileMap, err = applyConfigProfile(configFileMap, globals.configProfile)
+	if err != nil {
+		return
+	}
+
+	// Apply any {-set|--set} <key>=<value> overrides supplied on the command line
+
+	if len(globals.configOverrides) != 0 {
+		configFileMap = cloneConfigFileValue(configFileMap).(map[string]interface{})
+
+		err = applyConfigOverrides(configFileMap, globals.configOverrides)
 		if err != nil {
-			err = fmt.Errorf("unable to parse config-file \"%s\" as YAML (err: %v)", globals.configFilePath, err)
 			return
 		}
-	default:
-		err = fmt.Errorf("unsupported extension (\"%s\") in config-file \"%s\" - must be one of \".json\" or \".yaml\"", configFilePathExt, globals.configFilePath)
-		return
 	}
 
 	config = &configStruct{
@@ -393,6 +655,8 @@ func checkConfigFile() (err error) {
 					// This one is supported
 				case "s8k":
 					// This is compatible with "s3", so simply operate as if storageProviderType == "s3"
+				case "gcs_s3":
+					// This is GCS's S3 interoperability, so operate as if "s3" with S3.provider "gcs"
 				default:
 					// Skip this one as storageProviderType not currently supported
 					_, ok = globals.backendsSkipped[profileName]
@@ -409,6 +673,10 @@ func checkConfigFile() (err error) {
 
 				backendConfigS3AsMap = make(map[string]interface{})
 
+				if storageProviderType == "gcs_s3" {
+					backendConfigS3AsMap["provider"] = S3ProviderGCS
+				}
+
 				storageProviderOptionsAsInterface, ok = storageProviderAsMap["options"]
 				if !ok {
 					err = fmt.Errorf("missing profile \"%s\" storage_provider options", profileName)
@@ -591,7 +859,32 @@ func checkConfigFile() (err error) {
 
 		configFileMap = configFileMapTranslated
 	case MSFSVersionOne:
-		// Nothing to do here
+		// Each layer is validated separately so that any error reports its own line & column
+
+		for _, configFileLayer = range configFileLayers {
+			switch filepath.Ext(configFileLayer.path) {
+			case ".json":
+				configSchemaPositionOf = jsonConfigPositions(configFileLayer.content)
+			case ".yaml", ".yml":
+				configSchemaPositionOf = yamlConfigPositions(configFileLayer.content)
+			default:
+				configSchemaPositionOf = nil
+			}
+
+			err = validateConfigSchema(configFileLayer.configFileMap, configSchema, "", configSchemaPositionOf)
+			if err != nil {
+				err = fmt.Errorf("config-file \"%s\" does not conform to schema: %v", configFileLayer.path, err)
+				return
+			}
+		}
+
+		if len(globals.configOverrides) != 0 {
+			err = validateConfigSchema(configFileMap, configSchema, "", nil)
+			if err != nil {
+				err = fmt.Errorf("config-file (with --set overrides) does not conform to schema: %v", err)
+				return
+			}
+		}
 	default:
 		err = fmt.Errorf("unsupported msfs_version: %v", config.msfsVersion)
 		return
@@ -693,6 +986,12 @@ func checkConfigFile() (err error) {
 		return
 	}
 
+	config.dirMTimeTTL, ok = parseMilliseconds(configFileMap, "dir_mtime_ttl", 60000*time.Millisecond)
+	if !ok {
+		err = errors.New("bad dir_mtime_ttl value")
+		return
+	}
+
 	config.cacheLineSize, ok = parseUint64(configFileMap, "cache_line_size", uint64(1048576))
 	if !ok {
 		err = errors.New("bad cache_line_size value")
@@ -711,6 +1010,30 @@ func checkConfigFile() (err error) {
 		return
 	}
 
+	config.directReadThreshold, ok = parseUint64(configFileMap, "direct_read_threshold", uint64(0))
+	if !ok {
+		err = errors.New("bad direct_read_threshold value")
+		return
+	}
+
+	config.cacheLineTTL, ok = parseMilliseconds(configFileMap, "cache_line_ttl", time.Duration(0))
+	if !ok {
+		err = errors.New("bad cache_line_ttl value")
+		return
+	}
+
+	config.eTagConflictPolicy, ok = parseString(configFileMap, "etag_conflict_policy", defaultETagConflictPolicy)
+	if !ok || !validETagConflictPolicy(config.eTagConflictPolicy) {
+		err = fmt.Errorf("bad etag_conflict_policy value (must be \"%s\", \"%s\", or \"%s\")", ETagConflictPolicyFail, ETagConflictPolicyRefetch, ETagConflictPolicyLastWriterWins)
+		return
+	}
+
+	config.statOnOpen, ok = parseBool(configFileMap, "stat_on_open", false)
+	if !ok {
+		err = errors.New("bad stat_on_open value")
+		return
+	}
+
 	dirtyCacheLinesFlushTriggerPercentage, ok = parseUint64(configFileMap, "dirty_cache_lines_flush_trigger", uint64(80))
 	if !ok {
 		err = errors.New("missing or bad dirty_cache_lines_flush_trigger value")
@@ -809,6 +1132,56 @@ func checkConfigFile() (err error) {
 			}
 		}
 
+		// Parse traces section - opentelemetry.traces.{exporter, sampler}
+		tracesAsInterface, ok := opentelemetryAsMap["traces"]
+		if ok {
+			tracesAsMap, ok := tracesAsInterface.(map[string]interface{})
+			if !ok {
+				err = errors.New("bad opentelemetry.traces section")
+				return
+			}
+
+			if exporterAsInterface, ok := tracesAsMap["exporter"]; ok {
+				if exporterAsMap, ok := exporterAsInterface.(map[string]interface{}); ok {
+					exporter := &exporterStruct{}
+					exporter.Type, _ = parseString(exporterAsMap, "type", "")
+					if optionsAsInterface, ok := exporterAsMap["options"]; ok {
+						if optionsAsMap, ok := optionsAsInterface.(map[string]interface{}); ok {
+							exporter.Options = optionsAsMap
+						}
+					}
+					obs.tracesExporter = exporter
+				}
+			}
+
+			sampler := &samplerStruct{
+				Type:  telemetry.DefaultSamplerType,
+				Ratio: telemetry.DefaultSamplerRatio,
+			}
+			if samplerAsInterface, ok := tracesAsMap["sampler"]; ok {
+				samplerAsMap, ok := samplerAsInterface.(map[string]interface{})
+				if !ok {
+					err = errors.New("bad opentelemetry.traces.sampler section")
+					return
+				}
+				sampler.Type, ok = parseString(samplerAsMap, "type", telemetry.DefaultSamplerType)
+				if !ok || ((sampler.Type != telemetry.SamplerAlwaysOn) && (sampler.Type != telemetry.SamplerAlwaysOff) && (sampler.Type != telemetry.SamplerTraceIDRatio) && (sampler.Type != telemetry.SamplerParentBased)) {
+					err = errors.New("bad opentelemetry.traces.sampler.type value")
+					return
+				}
+				if optionsAsInterface, ok := samplerAsMap["options"]; ok {
+					if optionsAsMap, ok := optionsAsInterface.(map[string]interface{}); ok {
+						sampler.Ratio, ok = parseFloat64(optionsAsMap, "ratio", telemetry.DefaultSamplerRatio)
+						if !ok || (sampler.Ratio < 0) || (sampler.Ratio > 1) {
+							err = errors.New("bad opentelemetry.traces.sampler.options.ratio value")
+							return
+						}
+					}
+				}
+			}
+			obs.tracesSampler = sampler
+		}
+
 		config.observability = obs
 	}
 
@@ -819,79 +1192,299 @@ func checkConfigFile() (err error) {
 		return
 	}
 
-	backendsAsInterface, ok = configFileMap["backends"]
-	if ok {
-		backendsAsInterfaceSlice, ok = backendsAsInterface.([]interface{})
-		if !ok {
-			err = errors.New("bad backends section")
-			return
-		}
+	config.migrationStateDir, ok = parseString(configFileMap, "migration_state_dir", "")
+	if !ok {
+		err = errors.New("bad migration_state_dir value")
+		return
+	}
 
-		for backendsAsInterfaceSliceIndex, backendAsInterface = range backendsAsInterfaceSlice {
-			backendAsMap, ok = backendAsInterface.(map[string]interface{})
-			if !ok {
-				err = errors.New("bad backends section")
-				return
-			}
+	config.maxConcurrentBackendRequests, ok = parseUint64(configFileMap, "max_concurrent_backend_requests", uint64(0))
+	if !ok {
+		err = errors.New("bad max_concurrent_backend_requests value")
+		return
+	}
 
-			backendAsStructNew = &backendStruct{}
+	config.retryBudgetRatio, ok = parseFloat64(configFileMap, "retry_budget_ratio", float64(0))
+	if !ok || (config.retryBudgetRatio < 0) {
+		err = errors.New("bad retry_budget_ratio value")
+		return
+	}
 
-			backendAsStructNew.dirName, ok = parseString(backendAsMap, "dir_name", nil)
-			if !ok {
-				err = fmt.Errorf("missing or bad dir_name at backends[%v]", backendsAsInterfaceSliceIndex)
-				return
-			}
-			if (backendAsStructNew.dirName == DotDirEntryBasename) || (backendAsStructNew.dirName == DotDotDirEntryBasename) {
-				err = fmt.Errorf("dir_name cannot be either \"%s\" or \"%s\"", DotDirEntryBasename, DotDotDirEntryBasename)
-				return
-			}
+	config.retryBudgetMinPerSecond, ok = parseFloat64(configFileMap, "retry_budget_min_per_second", float64(10))
+	if !ok || (config.retryBudgetMinPerSecond < 0) {
+		err = errors.New("bad retry_budget_min_per_second value")
+		return
+	}
 
-			backendAsStructNew.readOnly, ok = parseBool(backendAsMap, "readonly", true)
-			if !ok {
-				err = fmt.Errorf("bad readonly at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+	config.retryBudgetBurst, ok = parseUint64(configFileMap, "retry_budget_burst", uint64(100))
+	if !ok || (config.retryBudgetBurst == 0) {
+		err = errors.New("bad retry_budget_burst value")
+		return
+	}
 
-			backendAsStructNew.flushOnClose, ok = parseBool(backendAsMap, "flush_on_close", true)
-			if !ok {
-				err = fmt.Errorf("bad flush_on_close at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+	config.auditLogFile, ok = parseString(configFileMap, "audit_log_file", "")
+	if !ok {
+		err = errors.New("bad audit_log_file value")
+		return
+	}
 
-			backendAsStructNew.uid, ok = parseUint64(backendAsMap, "uid", uint64(os.Geteuid()))
-			if !ok {
-				err = fmt.Errorf("bad uid at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+	config.auditLogMaxSize, ok = parseUint64(configFileMap, "audit_log_max_size", defaultAuditLogMaxSize)
+	if !ok || (config.auditLogMaxSize == 0) {
+		err = errors.New("bad audit_log_max_size value")
+		return
+	}
 
-			backendAsStructNew.gid, ok = parseUint64(backendAsMap, "gid", uint64(os.Getegid()))
-			if !ok {
-				err = fmt.Errorf("bad gid at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+	config.auditLogMaxFiles, ok = parseUint64(configFileMap, "audit_log_max_files", defaultAuditLogMaxFiles)
+	if !ok {
+		err = errors.New("bad audit_log_max_files value")
+		return
+	}
 
-			if backendAsStructNew.readOnly {
-				dirPerm, ok = parseString(backendAsMap, "dir_perm", "555")
-			} else {
-				dirPerm, ok = parseString(backendAsMap, "dir_perm", "777")
-			}
-			if !ok {
-				err = fmt.Errorf("bad dir_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
-			backendAsStructNew.dirPerm, err = strconv.ParseUint(dirPerm, 8, 64)
-			if (err != nil) || (backendAsStructNew.dirPerm > 0o777) {
-				err = fmt.Errorf("bad dir_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+	config.auditLogOps, ok = parseStringSlice(configFileMap, "audit_log_ops", defaultAuditLogOps)
+	if !ok {
+		err = errors.New("bad audit_log_ops value")
+		return
+	}
+	for _, auditLogOp := range config.auditLogOps {
+		if !slices.Contains(auditLogOps, auditLogOp) {
+			err = fmt.Errorf("bad audit_log_ops value: \"%s\"", auditLogOp)
+			return
+		}
+	}
 
-			if backendAsStructNew.readOnly {
-				filePerm, ok = parseString(backendAsMap, "file_perm", "444")
-			} else {
-				filePerm, ok = parseString(backendAsMap, "file_perm", "666")
-			}
-			if !ok {
-				err = fmt.Errorf("bad file_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+	config.auditLogSampleRate, ok = parseFloat64(configFileMap, "audit_log_sample_rate", defaultAuditLogSampleRate)
+	if !ok || (config.auditLogSampleRate <= 0) || (config.auditLogSampleRate > 1) {
+		err = errors.New("bad audit_log_sample_rate value")
+		return
+	}
+
+	config.auditBackend, ok = parseString(configFileMap, "audit_backend", "")
+	if !ok || ((config.auditBackend != "") && (config.auditLogFile == "")) {
+		err = errors.New("bad audit_backend value")
+		return
+	}
+
+	config.auditPrefix, ok = parseString(configFileMap, "audit_prefix", defaultAuditPrefix)
+	if !ok {
+		err = errors.New("bad audit_prefix value")
+		return
+	}
+
+	config.vaultAddress, ok = parseString(configFileMap, "vault_address", "${VAULT_ADDR}")
+	if !ok {
+		err = errors.New("bad vault_address value")
+		return
+	}
+
+	config.vaultToken, ok = parseString(configFileMap, "vault_token", "${VAULT_TOKEN}")
+	if !ok {
+		err = errors.New("bad vault_token value")
+		return
+	}
+
+	config.vaultTokenFile, ok = parseString(configFileMap, "vault_token_file", "")
+	if !ok {
+		err = errors.New("bad vault_token_file value")
+		return
+	}
+
+	config.vaultNamespace, ok = parseString(configFileMap, "vault_namespace", "${VAULT_NAMESPACE}")
+	if !ok {
+		err = errors.New("bad vault_namespace value")
+		return
+	}
+
+	config.awsSecretsRegion, ok = parseString(configFileMap, "aws_secrets_region", "${AWS_REGION:-us-east-1}")
+	if !ok {
+		err = errors.New("bad aws_secrets_region value")
+		return
+	}
+
+	config.awsSecretsEndpoint, ok = parseString(configFileMap, "aws_secrets_endpoint", "")
+	if !ok {
+		err = errors.New("bad aws_secrets_endpoint value")
+		return
+	}
+
+	config.secretsRefreshInterval, ok = parseSeconds(configFileMap, "secrets_refresh_interval", defaultSecretsRefreshInterval)
+	if !ok {
+		err = errors.New("bad secrets_refresh_interval value")
+		return
+	}
+
+	config.logFormat, ok = parseString(configFileMap, "log_format", defaultLogFormat)
+	if !ok || ((config.logFormat != logFormatText) && (config.logFormat != logFormatJSON) && (config.logFormat != logFormatLogfmt)) {
+		err = errors.New("bad log_format value")
+		return
+	}
+
+	config.logLevel, ok = parseString(configFileMap, "log_level", defaultLogLevel)
+	if ok {
+		_, ok = logLevelIndex(config.logLevel)
+	}
+	if !ok {
+		err = errors.New("bad log_level value")
+		return
+	}
+
+	config.logLevels = make(map[string]string)
+	if logLevelsAsInterface, ok := configFileMap["log_levels"]; ok {
+		logLevelsAsMap, ok := logLevelsAsInterface.(map[string]interface{})
+		if !ok {
+			err = errors.New("bad log_levels section")
+			return
+		}
+		for subsystem := range logLevelsAsMap {
+			config.logLevels[subsystem], ok = parseString(logLevelsAsMap, subsystem, nil)
+			if ok {
+				_, ok = logLevelIndex(config.logLevels[subsystem])
+			}
+			if !ok {
+				err = fmt.Errorf("bad log_levels[\"%s\"] value", subsystem)
+				return
+			}
+		}
+	}
+
+	config.logLevelFile, ok = parseString(configFileMap, "log_level_file", "")
+	if !ok {
+		err = errors.New("bad log_level_file value")
+		return
+	}
+
+	config.adminSocket, ok = parseString(configFileMap, "admin_socket", "")
+	if !ok {
+		err = errors.New("bad admin_socket value")
+		return
+	}
+
+	config.slowBackendRequestThreshold, ok = parseMilliseconds(configFileMap, "slow_backend_request_threshold", time.Duration(0))
+	if !ok {
+		err = errors.New("bad slow_backend_request_threshold value")
+		return
+	}
+
+	config.slowFUSEOpThreshold, ok = parseMilliseconds(configFileMap, "slow_fuse_op_threshold", time.Duration(0))
+	if !ok {
+		err = errors.New("bad slow_fuse_op_threshold value")
+		return
+	}
+
+	config.stateDumpDir, ok = parseString(configFileMap, "state_dump_dir", "")
+	if !ok {
+		err = errors.New("bad state_dump_dir value")
+		return
+	}
+
+	config.webhookURLs, ok = parseStringSlice(configFileMap, "webhook_urls", []string{})
+	if !ok {
+		err = errors.New("bad webhook_urls value")
+		return
+	}
+
+	config.webhookEvents, ok = parseStringSlice(configFileMap, "webhook_events", webhookEvents)
+	if !ok {
+		err = errors.New("bad webhook_events value")
+		return
+	}
+	for _, webhookEvent := range config.webhookEvents {
+		if !slices.Contains(webhookEvents, webhookEvent) {
+			err = fmt.Errorf("bad webhook_events value: \"%s\"", webhookEvent)
+			return
+		}
+	}
+
+	config.webhookTimeout, ok = parseMilliseconds(configFileMap, "webhook_timeout", 5000*time.Millisecond)
+	if !ok || (config.webhookTimeout == 0) {
+		err = errors.New("bad webhook_timeout value")
+		return
+	}
+
+	config.webhookRepeatInterval, ok = parseSeconds(configFileMap, "webhook_repeat_interval", 300*time.Second)
+	if !ok {
+		err = errors.New("bad webhook_repeat_interval value")
+		return
+	}
+
+	config.shutdownTimeout, ok = parseMilliseconds(configFileMap, "shutdown_timeout", 30000*time.Millisecond)
+	if !ok {
+		err = errors.New("bad shutdown_timeout value")
+		return
+	}
+
+	backendsAsInterface, ok = configFileMap["backends"]
+	if ok {
+		backendsAsInterfaceSlice, ok = backendsAsInterface.([]interface{})
+		if !ok {
+			err = errors.New("bad backends section")
+			return
+		}
+
+		for backendsAsInterfaceSliceIndex, backendAsInterface = range backendsAsInterfaceSlice {
+			backendAsMap, ok = backendAsInterface.(map[string]interface{})
+			if !ok {
+				err = errors.New("bad backends section")
+				return
+			}
+
+			backendAsStructNew = &backendStruct{}
+
+			backendAsStructNew.dirName, ok = parseString(backendAsMap, "dir_name", nil)
+			if !ok {
+				err = fmt.Errorf("missing or bad dir_name at backends[%v]", backendsAsInterfaceSliceIndex)
+				return
+			}
+			if (backendAsStructNew.dirName == DotDirEntryBasename) || (backendAsStructNew.dirName == DotDotDirEntryBasename) {
+				err = fmt.Errorf("dir_name cannot be either \"%s\" or \"%s\"", DotDirEntryBasename, DotDotDirEntryBasename)
+				return
+			}
+
+			backendAsStructNew.readOnly, ok = parseBool(backendAsMap, "readonly", true)
+			if !ok {
+				err = fmt.Errorf("bad readonly at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.flushOnClose, ok = parseBool(backendAsMap, "flush_on_close", true)
+			if !ok {
+				err = fmt.Errorf("bad flush_on_close at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.uid, ok = parseUint64(backendAsMap, "uid", uint64(os.Geteuid()))
+			if !ok {
+				err = fmt.Errorf("bad uid at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.gid, ok = parseUint64(backendAsMap, "gid", uint64(os.Getegid()))
+			if !ok {
+				err = fmt.Errorf("bad gid at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			if backendAsStructNew.readOnly {
+				dirPerm, ok = parseString(backendAsMap, "dir_perm", "555")
+			} else {
+				dirPerm, ok = parseString(backendAsMap, "dir_perm", "777")
+			}
+			if !ok {
+				err = fmt.Errorf("bad dir_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+			backendAsStructNew.dirPerm, err = strconv.ParseUint(dirPerm, 8, 64)
+			if (err != nil) || (backendAsStructNew.dirPerm > 0o777) {
+				err = fmt.Errorf("bad dir_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			if backendAsStructNew.readOnly {
+				filePerm, ok = parseString(backendAsMap, "file_perm", "444")
+			} else {
+				filePerm, ok = parseString(backendAsMap, "file_perm", "666")
+			}
+			if !ok {
+				err = fmt.Errorf("bad file_perm at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 				return
 			}
 			backendAsStructNew.filePerm, err = strconv.ParseUint(filePerm, 8, 64)
@@ -906,6 +1499,12 @@ func checkConfigFile() (err error) {
 				return
 			}
 
+			backendAsStructNew.directoryMarkers, ok = parseString(backendAsMap, "directory_markers", defaultDirectoryMarkers)
+			if !ok || ((backendAsStructNew.directoryMarkers != DirectoryMarkersNone) && (backendAsStructNew.directoryMarkers != DirectoryMarkersSlash) && (backendAsStructNew.directoryMarkers != DirectoryMarkersFolder)) {
+				err = fmt.Errorf("bad directory_markers at backends[%v (\"%s\")] (must be \"%s\", \"%s\", or \"%s\")", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, DirectoryMarkersNone, DirectoryMarkersSlash, DirectoryMarkersFolder)
+				return
+			}
+
 			backendAsStructNew.multiPartCacheLineThreshold, ok = parseUint64(backendAsMap, "multipart_cache_line_threshold", uint64(512))
 			if !ok {
 				err = fmt.Errorf("bad multipart_cache_line_threshold at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
@@ -924,6 +1523,30 @@ func checkConfigFile() (err error) {
 				return
 			}
 
+			backendAsStructNew.fetchWorkers, ok = parseUint64(backendAsMap, "fetch_workers", uint64(64))
+			if !ok {
+				err = fmt.Errorf("bad fetch_workers at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.fetchWorkersAdaptive, ok = parseBool(backendAsMap, "fetch_workers_adaptive", false)
+			if !ok || (backendAsStructNew.fetchWorkersAdaptive && (backendAsStructNew.fetchWorkers == 0)) {
+				err = fmt.Errorf("bad fetch_workers_adaptive at backends[%v (\"%s\")] (requires fetch_workers != 0)", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.fetchLatencyTarget, ok = parseMilliseconds(backendAsMap, "fetch_latency_target", time.Duration(0))
+			if !ok {
+				err = fmt.Errorf("bad fetch_latency_target at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.listPartitions, ok = parseUint64(backendAsMap, "list_partitions", uint64(0))
+			if !ok || (backendAsStructNew.listPartitions > listPartitionsMax) {
+				err = fmt.Errorf("bad list_partitions at backends[%v (\"%s\")] (must be <= %v)", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, listPartitionsMax)
+				return
+			}
+
 			backendAsStructNew.bucketContainerName, ok = parseString(backendAsMap, "bucket_container_name", nil)
 			if !ok {
 				err = fmt.Errorf("missing or bad bucket_container_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
@@ -940,77 +1563,795 @@ func checkConfigFile() (err error) {
 				return
 			}
 
-			backendAsStructNew.traceLevel, ok = parseUint64(backendAsMap, "trace_level", uint64(0))
-			if !ok {
-				err = fmt.Errorf("bad trace_level at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+			backendAsStructNew.traceLevel, ok = parseUint64(backendAsMap, "trace_level", uint64(0))
+			if !ok {
+				err = fmt.Errorf("bad trace_level at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.httpMaxIdleConnsPerHost, ok = parseUint64(backendAsMap, "http_max_idle_conns_per_host", defaultHTTPMaxIdleConnsPerHost)
+			if !ok {
+				err = fmt.Errorf("bad http_max_idle_conns_per_host at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.httpMaxConnsPerHost, ok = parseUint64(backendAsMap, "http_max_conns_per_host", uint64(0))
+			if !ok {
+				err = fmt.Errorf("bad http_max_conns_per_host at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.httpIdleConnTimeout, ok = parseMilliseconds(backendAsMap, "http_idle_conn_timeout", defaultHTTPIdleConnTimeout)
+			if !ok {
+				err = fmt.Errorf("bad http_idle_conn_timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.httpResponseHeaderTimeout, ok = parseMilliseconds(backendAsMap, "http_response_header_timeout", time.Duration(0))
+			if !ok {
+				err = fmt.Errorf("bad http_response_header_timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.httpProtocol, ok = parseString(backendAsMap, "http_protocol", defaultHTTPProtocol)
+			if !ok || ((backendAsStructNew.httpProtocol != HTTPProtocolAuto) && (backendAsStructNew.httpProtocol != HTTPProtocolHTTP1) && (backendAsStructNew.httpProtocol != HTTPProtocolHTTP2)) {
+				err = fmt.Errorf("bad http_protocol at backends[%v (\"%s\")] (must be \"%s\", \"%s\", or \"%s\")", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, HTTPProtocolAuto, HTTPProtocolHTTP1, HTTPProtocolHTTP2)
+				return
+			}
+
+			backendAsStructNew.httpKeepAlive, ok = parseMilliseconds(backendAsMap, "http_keep_alive", defaultHTTPKeepAlive)
+			if !ok {
+				err = fmt.Errorf("bad http_keep_alive at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.mirror, ok = parseString(backendAsMap, "mirror", "")
+			if !ok {
+				err = fmt.Errorf("bad mirror at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.mirrorJournalFile, ok = parseString(backendAsMap, "mirror_journal_file", "")
+			if !ok || ((backendAsStructNew.mirror != "") && (backendAsStructNew.mirrorJournalFile == "")) {
+				err = fmt.Errorf("missing or bad mirror_journal_file at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.mirrorReconcileInterval, ok = parseMilliseconds(backendAsMap, "mirror_reconcile_interval", defaultMirrorReconcileInterval)
+			if !ok || (backendAsStructNew.mirrorReconcileInterval == 0) {
+				err = fmt.Errorf("bad mirror_reconcile_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierColdBackend, ok = parseString(backendAsMap, "tier_cold_backend", "")
+			if !ok {
+				err = fmt.Errorf("bad tier_cold_backend at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierLocationMapFile, ok = parseString(backendAsMap, "tier_location_map_file", "")
+			if !ok || ((backendAsStructNew.tierColdBackend != "") && (backendAsStructNew.tierLocationMapFile == "")) {
+				err = fmt.Errorf("missing or bad tier_location_map_file at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierInterval, ok = parseMilliseconds(backendAsMap, "tier_interval", defaultTierInterval)
+			if !ok || (backendAsStructNew.tierInterval == 0) {
+				err = fmt.Errorf("bad tier_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierMinAge, ok = parseMilliseconds(backendAsMap, "tier_min_age", time.Duration(0))
+			if !ok {
+				err = fmt.Errorf("bad tier_min_age at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierMaxAccessCount, ok = parseUint64(backendAsMap, "tier_max_access_count", uint64(0))
+			if !ok {
+				err = fmt.Errorf("bad tier_max_access_count at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierPromoteAccessCount, ok = parseUint64(backendAsMap, "tier_promote_access_count", uint64(0))
+			if !ok {
+				err = fmt.Errorf("bad tier_promote_access_count at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.tierPathPatterns, ok = parseStringSlice(backendAsMap, "tier_path_patterns", []string{})
+			if ok {
+				for _, tierPathPattern = range backendAsStructNew.tierPathPatterns {
+					_, err = path.Match(tierPathPattern, "")
+					if err != nil {
+						ok = false
+						break
+					}
+				}
+			}
+			if !ok {
+				err = fmt.Errorf("bad tier_path_patterns at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.quotas = make([]backendQuotaStruct, 0)
+			quotasAsInterface, ok = backendAsMap["quotas"]
+			if ok {
+				quotasAsInterfaceSlice, ok = quotasAsInterface.([]interface{})
+				if !ok {
+					err = fmt.Errorf("bad quotas at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				for quotasAsInterfaceSliceIndex, quotaAsInterface = range quotasAsInterfaceSlice {
+					quotaAsMap, ok = quotaAsInterface.(map[string]interface{})
+					if ok {
+						quota.prefix, ok = parseString(quotaAsMap, "prefix", "")
+					}
+					if ok {
+						ok = (quota.prefix == "") || (strings.HasSuffix(quota.prefix, "/") && !strings.HasPrefix(quota.prefix, "/"))
+					}
+					if ok {
+						quota.maxBytes, ok = parseUint64(quotaAsMap, "max_bytes", nil)
+					}
+					if !ok {
+						err = fmt.Errorf("bad quotas[%v] at backends[%v (\"%s\")]", quotasAsInterfaceSliceIndex, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendAsStructNew.quotas = append(backendAsStructNew.quotas, quota)
+				}
+			}
+
+			backendAsStructNew.quotaReconcileInterval, ok = parseMilliseconds(backendAsMap, "quota_reconcile_interval", defaultQuotaReconcileInterval)
+			if !ok || (backendAsStructNew.quotaReconcileInterval == 0) {
+				err = fmt.Errorf("bad quota_reconcile_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			priorityName, ok = parseString(backendAsMap, "priority", defaultPriority)
+			if ok {
+				backendAsStructNew.priority, ok = qosClassByName[priorityName]
+			}
+			if !ok {
+				err = fmt.Errorf("bad priority at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.priorityPrefixes = make([]backendPriorityPrefixStruct, 0)
+			priorityPrefixesAsInterface, ok = backendAsMap["priority_prefixes"]
+			if ok {
+				priorityPrefixesAsInterfaceSlice, ok = priorityPrefixesAsInterface.([]interface{})
+				if !ok {
+					err = fmt.Errorf("bad priority_prefixes at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				for priorityPrefixesAsInterfaceSliceIndex, priorityPrefixAsInterface = range priorityPrefixesAsInterfaceSlice {
+					priorityPrefixAsMap, ok = priorityPrefixAsInterface.(map[string]interface{})
+					if ok {
+						priorityPrefix.prefix, ok = parseString(priorityPrefixAsMap, "prefix", nil)
+					}
+					if ok {
+						ok = (priorityPrefix.prefix != "") && !strings.HasPrefix(priorityPrefix.prefix, "/")
+					}
+					if ok {
+						priorityName, ok = parseString(priorityPrefixAsMap, "priority", nil)
+					}
+					if ok {
+						priorityPrefix.priority, ok = qosClassByName[priorityName]
+					}
+					if !ok {
+						err = fmt.Errorf("bad priority_prefixes[%v] at backends[%v (\"%s\")]", priorityPrefixesAsInterfaceSliceIndex, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendAsStructNew.priorityPrefixes = append(backendAsStructNew.priorityPrefixes, priorityPrefix)
+				}
+			}
+
+			backendAsStructNew.healthCheckInterval, ok = parseMilliseconds(backendAsMap, "health_check_interval", time.Duration(0))
+			if !ok {
+				err = fmt.Errorf("bad health_check_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.healthCheckFailureThreshold, ok = parseUint64(backendAsMap, "health_check_failure_threshold", defaultHealthCheckFailures)
+			if !ok || (backendAsStructNew.healthCheckFailureThreshold == 0) {
+				err = fmt.Errorf("bad health_check_failure_threshold at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.healthCheckServeStale, ok = parseBool(backendAsMap, "health_check_serve_stale", false)
+			if !ok {
+				err = fmt.Errorf("bad health_check_serve_stale at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.multipartUploadGCInterval, ok = parseMilliseconds(backendAsMap, "multipart_upload_gc_interval", time.Duration(0))
+			if !ok || ((backendAsStructNew.multipartUploadGCInterval != 0) && backendAsStructNew.readOnly) {
+				err = fmt.Errorf("bad multipart_upload_gc_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.multipartUploadMaxAge, ok = parseMilliseconds(backendAsMap, "multipart_upload_max_age", defaultMultipartUploadMaxAge)
+			if !ok || (backendAsStructNew.multipartUploadMaxAge == 0) {
+				err = fmt.Errorf("bad multipart_upload_max_age at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.accessRules = make([]backendAccessRuleStruct, 0)
+			accessRulesAsInterface, ok = backendAsMap["access_rules"]
+			if ok {
+				accessRulesAsInterfaceSlice, ok = accessRulesAsInterface.([]interface{})
+				if !ok {
+					err = fmt.Errorf("bad access_rules at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				for accessRulesAsInterfaceSliceIndex, accessRuleAsInterface = range accessRulesAsInterfaceSlice {
+					accessRuleAsMap, ok = accessRuleAsInterface.(map[string]interface{})
+					if ok {
+						accessRule.prefix, ok = parseString(accessRuleAsMap, "prefix", "")
+					}
+					if ok {
+						ok = !strings.HasPrefix(accessRule.prefix, "/")
+					}
+					if ok {
+						accessRule.uids, ok = parseUint64Slice(accessRuleAsMap, "uids", []uint64{})
+					}
+					if ok {
+						accessRule.gids, ok = parseUint64Slice(accessRuleAsMap, "gids", []uint64{})
+					}
+					if ok {
+						accessName, ok = parseString(accessRuleAsMap, "access", nil)
+					}
+					if ok {
+						switch accessName {
+						case "allow":
+							accessRule.allow = true
+						case "deny":
+							accessRule.allow = false
+						default:
+							ok = false
+						}
+					}
+					if !ok {
+						err = fmt.Errorf("bad access_rules[%v] at backends[%v (\"%s\")]", accessRulesAsInterfaceSliceIndex, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendAsStructNew.accessRules = append(backendAsStructNew.accessRules, accessRule)
+				}
+			}
+
+			backendAsStructNew.pathOverrides = make([]backendPathOverrideStruct, 0)
+			pathOverridesAsInterface, ok = backendAsMap["path_overrides"]
+			if ok {
+				pathOverridesAsInterfaceSlice, ok = pathOverridesAsInterface.([]interface{})
+				if !ok {
+					err = fmt.Errorf("bad path_overrides at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				for pathOverridesAsInterfaceSliceIndex, pathOverrideAsInterface = range pathOverridesAsInterfaceSlice {
+					pathOverrideAsMap, ok = pathOverrideAsInterface.(map[string]interface{})
+					if ok {
+						pathOverride.prefix, ok = parseString(pathOverrideAsMap, "prefix", nil)
+					}
+					if ok {
+						ok = (pathOverride.prefix != "") && !strings.HasPrefix(pathOverride.prefix, "/")
+					}
+					if ok {
+						pathOverride.readOnly, ok = parseBool(pathOverrideAsMap, "readonly", backendAsStructNew.readOnly)
+					}
+					if ok {
+						// A path override may make a prefix of a read-write backend readonly but not vice versa
+
+						ok = pathOverride.readOnly || !backendAsStructNew.readOnly
+					}
+					if ok {
+						pathOverride.cacheLineSize, ok = parseUint64(pathOverrideAsMap, "cache_line_size", config.cacheLineSize)
+					}
+					if ok {
+						ok = pathOverride.cacheLineSize != 0
+					}
+					if ok {
+						pathOverride.cacheLinesToPrefetch, ok = parseUint64(pathOverrideAsMap, "cache_lines_to_prefetch", config.cacheLinesToPrefetch)
+					}
+					if ok {
+						pathOverride.directReadThreshold, ok = parseUint64(pathOverrideAsMap, "direct_read_threshold", config.directReadThreshold)
+					}
+					if ok {
+						pathOverride.cacheLineTTL, ok = parseMilliseconds(pathOverrideAsMap, "cache_line_ttl", config.cacheLineTTL)
+					}
+					if ok {
+						pathOverride.eTagConflictPolicy, ok = parseString(pathOverrideAsMap, "etag_conflict_policy", config.eTagConflictPolicy)
+					}
+					if ok {
+						ok = validETagConflictPolicy(pathOverride.eTagConflictPolicy)
+					}
+					if ok {
+						pathOverride.statOnOpen, ok = parseBool(pathOverrideAsMap, "stat_on_open", config.statOnOpen)
+					}
+					if ok {
+						pathOverride.entryAttrTTL, ok = parseMilliseconds(pathOverrideAsMap, "entry_attr_ttl", config.entryAttrTTL)
+					}
+					if !ok {
+						err = fmt.Errorf("bad path_overrides[%v] at backends[%v (\"%s\")]", pathOverridesAsInterfaceSliceIndex, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendAsStructNew.pathOverrides = append(backendAsStructNew.pathOverrides, pathOverride)
+				}
+			}
+
+			backendAsStructNew.snapshotDir, ok = parseString(backendAsMap, "snapshot_dir", "")
+			if !ok {
+				err = fmt.Errorf("bad snapshot_dir at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.replicas, ok = parseStringSlice(backendAsMap, "replicas", []string{})
+			if !ok || ((len(backendAsStructNew.replicas) != 0) && !backendAsStructNew.readOnly) {
+				err = fmt.Errorf("bad replicas at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.replicaProbeInterval, ok = parseMilliseconds(backendAsMap, "replica_probe_interval", defaultReplicaProbeInterval)
+			if !ok || (backendAsStructNew.replicaProbeInterval == 0) {
+				err = fmt.Errorf("bad replica_probe_interval at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.replicaHedgeDelay, ok = parseMilliseconds(backendAsMap, "replica_hedge_delay", time.Duration(0))
+			if !ok || ((backendAsStructNew.replicaHedgeDelay != 0) && (len(backendAsStructNew.replicas) == 0)) {
+				err = fmt.Errorf("bad replica_hedge_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			backendAsStructNew.backendType, ok = parseString(backendAsMap, "backend_type", nil)
+			if !ok {
+				err = fmt.Errorf("missing or bad bucket_container_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			switch backendAsStructNew.backendType {
+			case "AIStore":
+				backendConfigAIStoreAsInterface, ok = backendAsMap["AIStore"]
+				if ok {
+					backendConfigAIStoreAsMap, ok = backendConfigAIStoreAsInterface.(map[string]interface{})
+					if !ok {
+						err = fmt.Errorf("bad AIStore section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct = &backendConfigAIStoreStruct{}
+
+					backendConfigAIStoreAsStruct.endpoint, ok = parseString(backendConfigAIStoreAsMap, "endpoint", "${AIS_ENDPOINT}")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.skipTLSCertificateVerify, ok = parseBool(backendConfigAIStoreAsMap, "skip_tls_certificate_verify", defaultAIStoreSkipTLSCertificateVerify)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.skip_tls_certificate_verify at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.authnToken, ok = parseString(backendConfigAIStoreAsMap, "authn_token", "${AIS_AUTHN_TOKEN}")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.authn_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.authnTokenFile, ok = parseString(backendConfigAIStoreAsMap, "authn_token_file", "${AIS_AUTHN_TOKEN_FILE:-${HOME}/.config/ais/cli/auth.token}")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.authn_token_file at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.provider, ok = parseString(backendConfigAIStoreAsMap, "provider", defaultAIStoreProvider)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.provider at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.timeout, ok = parseMilliseconds(backendConfigAIStoreAsMap, "timeout", defaultAIStoreTimeout)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.authnEndpoint, ok = parseString(backendConfigAIStoreAsMap, "authn_endpoint", "${AIS_AUTHN_URL}")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.authn_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.authnUsername, ok = parseString(backendConfigAIStoreAsMap, "authn_username", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.authn_username at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.authnPassword, ok = parseString(backendConfigAIStoreAsMap, "authn_password", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.authn_password at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					for key, value := range map[string]string{
+						"authn_token":    backendConfigAIStoreAsStruct.authnToken,
+						"authn_password": backendConfigAIStoreAsStruct.authnPassword,
+					} {
+						err = checkSecretRef(config, value)
+						if err != nil {
+							err = fmt.Errorf("bad AIStore.%s at backends[%v (\"%s\")]: %v", key, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, err)
+							return
+						}
+					}
+
+					if (backendConfigAIStoreAsStruct.authnUsername != "") && (backendConfigAIStoreAsStruct.authnEndpoint == "") {
+						err = fmt.Errorf("missing AIStore.authn_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.etlName, ok = parseString(backendConfigAIStoreAsMap, "etl_name", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.etl_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.etlArgs, ok = parseString(backendConfigAIStoreAsMap, "etl_args", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.etl_args at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.blobDownloadThreshold, ok = parseUint64(backendConfigAIStoreAsMap, "blob_download_threshold", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad AIStore.blob_download_threshold at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.blobDownloadChunkSize, ok = parseUint64(backendConfigAIStoreAsMap, "blob_download_chunk_size", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad AIStore.blob_download_chunk_size at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.blobDownloadWorkers, ok = parseUint64(backendConfigAIStoreAsMap, "blob_download_workers", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad AIStore.blob_download_workers at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.directTargetReads, ok = parseBool(backendConfigAIStoreAsMap, "direct_target_reads", false)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.direct_target_reads at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.clusterMapTTL, ok = parseMilliseconds(backendConfigAIStoreAsMap, "cluster_map_ttl", defaultAIStoreClusterMapTTL)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.cluster_map_ttl at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.retryMaxAttempts, ok = parseUint64(backendConfigAIStoreAsMap, "retry_max_attempts", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad AIStore.retry_max_attempts at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.retryBaseDelay, ok = parseMilliseconds(backendConfigAIStoreAsMap, "retry_base_delay", defaultAIStoreRetryBaseDelay)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.retry_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.retryNextDelayMultiplier, ok = parseFloat64(backendConfigAIStoreAsMap, "retry_next_delay_multiplier", defaultAIStoreRetryNextDelayMultiplier)
+					if !ok || (backendConfigAIStoreAsStruct.retryNextDelayMultiplier < float64(1.0)) {
+						err = fmt.Errorf("bad AIStore.retry_next_delay_multiplier at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.retryMaxDelay, ok = parseMilliseconds(backendConfigAIStoreAsMap, "retry_max_delay", defaultAIStoreRetryMaxDelay)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.retry_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.namespaceUUID, ok = parseString(backendConfigAIStoreAsMap, "namespace_uuid", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.namespace_uuid at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.namespaceName, ok = parseString(backendConfigAIStoreAsMap, "namespace_name", "")
+					if !ok {
+						err = fmt.Errorf("bad AIStore.namespace_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.propsCacheTTL, ok = parseMilliseconds(backendConfigAIStoreAsMap, "props_cache_ttl", defaultAIStorePropsCacheTTL)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.props_cache_ttl at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAIStoreAsStruct.prefetchListedFiles, ok = parseBool(backendConfigAIStoreAsMap, "prefetch_listed_files", false)
+					if !ok {
+						err = fmt.Errorf("bad AIStore.prefetch_listed_files at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+				} else {
+					backendConfigAIStoreAsStruct = &backendConfigAIStoreStruct{
+						endpoint:                 os.Getenv("AIS_ENDPOINT"),
+						skipTLSCertificateVerify: defaultAIStoreSkipTLSCertificateVerify,
+						authnToken:               os.Getenv("AIS_AUTHN_TOKEN"),
+						authnTokenFile:           os.Getenv("AIS_AUTHN_TOKEN_FILE"),
+						provider:                 defaultAIStoreProvider,
+						timeout:                  defaultAIStoreTimeout,
+						authnEndpoint:            os.Getenv("AIS_AUTHN_URL"),
+						authnUsername:            "",
+						authnPassword:            "",
+						etlName:                  "",
+						etlArgs:                  "",
+						blobDownloadThreshold:    0,
+						blobDownloadChunkSize:    0,
+						blobDownloadWorkers:      0,
+						directTargetReads:        false,
+						clusterMapTTL:            defaultAIStoreClusterMapTTL,
+						retryMaxAttempts:         0,
+						retryBaseDelay:           defaultAIStoreRetryBaseDelay,
+						retryNextDelayMultiplier: defaultAIStoreRetryNextDelayMultiplier,
+						retryMaxDelay:            defaultAIStoreRetryMaxDelay,
+						namespaceUUID:            "",
+						namespaceName:            "",
+						propsCacheTTL:            defaultAIStorePropsCacheTTL,
+						prefetchListedFiles:      false,
+					}
+				}
+
+				_, err = backendConfigAIStoreAsStruct.bucket(backendAsStructNew.bucketContainerName)
+				if err != nil {
+					err = fmt.Errorf("bad AIStore bucket_container_name at backends[%v (\"%s\")]: %v", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, err)
+					return
+				}
+
+				backendConfigAIStoreAsStruct.retryAttempts = computeRetryAttempts(backendConfigAIStoreAsStruct.retryMaxAttempts, backendConfigAIStoreAsStruct.retryBaseDelay, backendConfigAIStoreAsStruct.retryNextDelayMultiplier, backendConfigAIStoreAsStruct.retryMaxDelay)
+
+				backendAsStructNew.backendTypeSpecifics = backendConfigAIStoreAsStruct
+			case "AzureFiles":
+				backendConfigAzureFilesAsInterface, ok = backendAsMap["AzureFiles"]
+				if ok {
+					backendConfigAzureFilesAsMap, ok = backendConfigAzureFilesAsInterface.(map[string]interface{})
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct = &backendConfigAzureFilesStruct{}
+
+					backendConfigAzureFilesAsStruct.accountName, ok = parseString(backendConfigAzureFilesAsMap, "account_name", "${AZURE_STORAGE_ACCOUNT}")
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.account_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.accountKey, ok = parseString(backendConfigAzureFilesAsMap, "account_key", "${AZURE_STORAGE_KEY}")
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.account_key at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.sasToken, ok = parseString(backendConfigAzureFilesAsMap, "sas_token", "${AZURE_STORAGE_SAS_TOKEN}")
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.sas_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.endpoint, ok = parseString(backendConfigAzureFilesAsMap, "endpoint", "")
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.timeout, ok = parseMilliseconds(backendConfigAzureFilesAsMap, "timeout", defaultAzureFilesTimeout)
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.retryMaxAttempts, ok = parseUint64(backendConfigAzureFilesAsMap, "retry_max_attempts", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.retry_max_attempts at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.retryBaseDelay, ok = parseMilliseconds(backendConfigAzureFilesAsMap, "retry_base_delay", defaultAzureFilesRetryBaseDelay)
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.retry_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.retryNextDelayMultiplier, ok = parseFloat64(backendConfigAzureFilesAsMap, "retry_next_delay_multiplier", defaultAzureFilesRetryNextDelayMultiplier)
+					if !ok || (backendConfigAzureFilesAsStruct.retryNextDelayMultiplier < float64(1.0)) {
+						err = fmt.Errorf("bad AzureFiles.retry_next_delay_multiplier at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigAzureFilesAsStruct.retryMaxDelay, ok = parseMilliseconds(backendConfigAzureFilesAsMap, "retry_max_delay", defaultAzureFilesRetryMaxDelay)
+					if !ok {
+						err = fmt.Errorf("bad AzureFiles.retry_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+				} else {
+					backendConfigAzureFilesAsStruct = &backendConfigAzureFilesStruct{
+						accountName:              os.Getenv("AZURE_STORAGE_ACCOUNT"),
+						accountKey:               os.Getenv("AZURE_STORAGE_KEY"),
+						sasToken:                 os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+						endpoint:                 "",
+						timeout:                  defaultAzureFilesTimeout,
+						retryMaxAttempts:         0,
+						retryBaseDelay:           defaultAzureFilesRetryBaseDelay,
+						retryNextDelayMultiplier: defaultAzureFilesRetryNextDelayMultiplier,
+						retryMaxDelay:            defaultAzureFilesRetryMaxDelay,
+					}
+				}
+
+				for key, value := range map[string]string{
+					"account_key": backendConfigAzureFilesAsStruct.accountKey,
+					"sas_token":   backendConfigAzureFilesAsStruct.sasToken,
+				} {
+					err = checkSecretRef(config, value)
+					if err != nil {
+						err = fmt.Errorf("bad AzureFiles.%s at backends[%v (\"%s\")]: %v", key, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, err)
+						return
+					}
+				}
+
+				if backendConfigAzureFilesAsStruct.accountName == "" {
+					err = fmt.Errorf("missing AzureFiles.account_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+				if (backendConfigAzureFilesAsStruct.accountKey == "") && (backendConfigAzureFilesAsStruct.sasToken == "") {
+					err = fmt.Errorf("missing AzureFiles.account_key (or AzureFiles.sas_token) at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+				if (backendAsStructNew.bucketContainerName == "") || strings.Contains(backendAsStructNew.bucketContainerName, "/") {
+					err = fmt.Errorf("bad AzureFiles bucket_container_name (must name a share) at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				if backendConfigAzureFilesAsStruct.endpoint == "" {
+					backendConfigAzureFilesAsStruct.endpoint = "https://" + backendConfigAzureFilesAsStruct.accountName + ".file.core.windows.net"
+				} else {
+					backendConfigAzureFilesAsStruct.endpoint = strings.TrimSuffix(backendConfigAzureFilesAsStruct.endpoint, "/")
+				}
 
-			backendAsStructNew.backendType, ok = parseString(backendAsMap, "backend_type", nil)
-			if !ok {
-				err = fmt.Errorf("missing or bad bucket_container_name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-				return
-			}
+				backendConfigAzureFilesAsStruct.retryAttempts = computeRetryAttempts(backendConfigAzureFilesAsStruct.retryMaxAttempts, backendConfigAzureFilesAsStruct.retryBaseDelay, backendConfigAzureFilesAsStruct.retryNextDelayMultiplier, backendConfigAzureFilesAsStruct.retryMaxDelay)
 
-			switch backendAsStructNew.backendType {
-			case "AIStore":
-				backendConfigAIStoreAsInterface, ok = backendAsMap["AIStore"]
+				backendAsStructNew.backendTypeSpecifics = backendConfigAzureFilesAsStruct
+			case "Dropbox":
+				backendConfigDropboxAsInterface, ok = backendAsMap["Dropbox"]
 				if ok {
-					backendConfigAIStoreAsMap, ok = backendConfigAIStoreAsInterface.(map[string]interface{})
+					backendConfigDropboxAsMap, ok = backendConfigDropboxAsInterface.(map[string]interface{})
 					if !ok {
-						err = fmt.Errorf("bad AIStore section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct = &backendConfigAIStoreStruct{}
+					backendConfigDropboxAsStruct = &backendConfigDropboxStruct{}
 
-					backendConfigAIStoreAsStruct.endpoint, ok = parseString(backendConfigAIStoreAsMap, "endpoint", "${AIS_ENDPOINT}")
+					backendConfigDropboxAsStruct.accessToken, ok = parseString(backendConfigDropboxAsMap, "access_token", "${DROPBOX_ACCESS_TOKEN}")
 					if !ok {
-						err = fmt.Errorf("bad AIStore.endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.access_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct.skipTLSCertificateVerify, ok = parseBool(backendConfigAIStoreAsMap, "skip_tls_certificate_verify", defaultAIStoreSkipTLSCertificateVerify)
+					backendConfigDropboxAsStruct.refreshToken, ok = parseString(backendConfigDropboxAsMap, "refresh_token", "${DROPBOX_REFRESH_TOKEN}")
 					if !ok {
-						err = fmt.Errorf("bad AIStore.skip_tls_certificate_verify at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.refresh_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct.authnToken, ok = parseString(backendConfigAIStoreAsMap, "authn_token", "${AIS_AUTHN_TOKEN}")
+					backendConfigDropboxAsStruct.appKey, ok = parseString(backendConfigDropboxAsMap, "app_key", "${DROPBOX_APP_KEY}")
 					if !ok {
-						err = fmt.Errorf("bad AIStore.authn_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.app_key at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct.authnTokenFile, ok = parseString(backendConfigAIStoreAsMap, "authn_token_file", "${AIS_AUTHN_TOKEN_FILE:-${HOME}/.config/ais/cli/auth.token}")
+					backendConfigDropboxAsStruct.appSecret, ok = parseString(backendConfigDropboxAsMap, "app_secret", "${DROPBOX_APP_SECRET}")
 					if !ok {
-						err = fmt.Errorf("bad AIStore.authn_token_file at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.app_secret at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct.provider, ok = parseString(backendConfigAIStoreAsMap, "provider", defaultAIStoreProvider)
+					backendConfigDropboxAsStruct.apiEndpoint, ok = parseString(backendConfigDropboxAsMap, "api_endpoint", defaultDropboxAPIEndpoint)
 					if !ok {
-						err = fmt.Errorf("bad AIStore.provider at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.api_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 
-					backendConfigAIStoreAsStruct.timeout, ok = parseMilliseconds(backendConfigAIStoreAsMap, "timeout", defaultAIStoreTimeout)
+					backendConfigDropboxAsStruct.contentEndpoint, ok = parseString(backendConfigDropboxAsMap, "content_endpoint", defaultDropboxContentEndpoint)
 					if !ok {
-						err = fmt.Errorf("bad AIStore.timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						err = fmt.Errorf("bad Dropbox.content_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigDropboxAsStruct.timeout, ok = parseMilliseconds(backendConfigDropboxAsMap, "timeout", defaultDropboxTimeout)
+					if !ok {
+						err = fmt.Errorf("bad Dropbox.timeout at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigDropboxAsStruct.retryMaxAttempts, ok = parseUint64(backendConfigDropboxAsMap, "retry_max_attempts", uint64(0))
+					if !ok {
+						err = fmt.Errorf("bad Dropbox.retry_max_attempts at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigDropboxAsStruct.retryBaseDelay, ok = parseMilliseconds(backendConfigDropboxAsMap, "retry_base_delay", defaultDropboxRetryBaseDelay)
+					if !ok {
+						err = fmt.Errorf("bad Dropbox.retry_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigDropboxAsStruct.retryNextDelayMultiplier, ok = parseFloat64(backendConfigDropboxAsMap, "retry_next_delay_multiplier", defaultDropboxRetryNextDelayMultiplier)
+					if !ok || (backendConfigDropboxAsStruct.retryNextDelayMultiplier < float64(1.0)) {
+						err = fmt.Errorf("bad Dropbox.retry_next_delay_multiplier at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigDropboxAsStruct.retryMaxDelay, ok = parseMilliseconds(backendConfigDropboxAsMap, "retry_max_delay", defaultDropboxRetryMaxDelay)
+					if !ok {
+						err = fmt.Errorf("bad Dropbox.retry_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
 				} else {
-					backendConfigAIStoreAsStruct = &backendConfigAIStoreStruct{
-						endpoint:                 os.Getenv("AIS_ENDPOINT"),
-						skipTLSCertificateVerify: defaultAIStoreSkipTLSCertificateVerify,
-						authnToken:               os.Getenv("AIS_AUTHN_TOKEN"),
-						authnTokenFile:           os.Getenv("AIS_AUTHN_TOKEN_FILE"),
-						provider:                 defaultAIStoreProvider,
-						timeout:                  defaultAIStoreTimeout,
+					backendConfigDropboxAsStruct = &backendConfigDropboxStruct{
+						accessToken:              os.Getenv("DROPBOX_ACCESS_TOKEN"),
+						refreshToken:             os.Getenv("DROPBOX_REFRESH_TOKEN"),
+						appKey:                   os.Getenv("DROPBOX_APP_KEY"),
+						appSecret:                os.Getenv("DROPBOX_APP_SECRET"),
+						apiEndpoint:              defaultDropboxAPIEndpoint,
+						contentEndpoint:          defaultDropboxContentEndpoint,
+						timeout:                  defaultDropboxTimeout,
+						retryMaxAttempts:         0,
+						retryBaseDelay:           defaultDropboxRetryBaseDelay,
+						retryNextDelayMultiplier: defaultDropboxRetryNextDelayMultiplier,
+						retryMaxDelay:            defaultDropboxRetryMaxDelay,
 					}
 				}
 
-				backendAsStructNew.backendTypeSpecifics = backendConfigAIStoreAsStruct
+				for key, value := range map[string]string{
+					"access_token":  backendConfigDropboxAsStruct.accessToken,
+					"refresh_token": backendConfigDropboxAsStruct.refreshToken,
+					"app_secret":    backendConfigDropboxAsStruct.appSecret,
+				} {
+					err = checkSecretRef(config, value)
+					if err != nil {
+						err = fmt.Errorf("bad Dropbox.%s at backends[%v (\"%s\")]: %v", key, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, err)
+						return
+					}
+				}
+
+				if (backendConfigDropboxAsStruct.accessToken == "") && (backendConfigDropboxAsStruct.refreshToken == "") {
+					err = fmt.Errorf("missing Dropbox.access_token (or Dropbox.refresh_token) at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+				if (backendConfigDropboxAsStruct.refreshToken != "") && (backendConfigDropboxAsStruct.appKey == "") {
+					err = fmt.Errorf("missing Dropbox.app_key at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigDropboxAsStruct.retryAttempts = computeRetryAttempts(backendConfigDropboxAsStruct.retryMaxAttempts, backendConfigDropboxAsStruct.retryBaseDelay, backendConfigDropboxAsStruct.retryNextDelayMultiplier, backendConfigDropboxAsStruct.retryMaxDelay)
+
+				backendAsStructNew.backendTypeSpecifics = backendConfigDropboxAsStruct
 			case "RAM":
 				backendConfigRAMAsInterface, ok = backendAsMap["RAM"]
 				if ok {
@@ -1084,6 +2425,7 @@ func checkConfigFile() (err error) {
 
 					backendConfigS3AsStruct.region = ""
 					backendConfigS3AsStruct.endpoint = ""
+					backendConfigS3AsStruct.dnsSuffix = ""
 				} else {
 					backendConfigS3AsStruct.configFilePath = ""
 
@@ -1098,6 +2440,12 @@ func checkConfigFile() (err error) {
 						err = fmt.Errorf("bad S3.endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
+
+					backendConfigS3AsStruct.dnsSuffix, ok = parseString(backendConfigS3AsMap, "dns_suffix", "")
+					if !ok {
+						err = fmt.Errorf("bad S3.dns_suffix at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
 				}
 
 				backendConfigS3AsStruct.useCredentialsEnv, ok = parseBool(backendConfigS3AsMap, "use_credentials_env", false)
@@ -1115,15 +2463,36 @@ func checkConfigFile() (err error) {
 
 					backendConfigS3AsStruct.accessKeyID = ""
 					backendConfigS3AsStruct.secretAccessKey = ""
+					backendConfigS3AsStruct.sessionToken = ""
+					backendConfigS3AsStruct.sessionTokenExpiry = time.Time{}
+					backendConfigS3AsStruct.credentialRefreshCommand = ""
+					backendConfigS3AsStruct.credentialRefreshEndpoint = ""
+					backendConfigS3AsStruct.credentialRefreshWindow = 0
 				} else {
 					backendConfigS3AsStruct.credentialsFilePath = ""
 
+					backendConfigS3AsStruct.credentialRefreshCommand, ok = parseString(backendConfigS3AsMap, "credential_refresh_command", "")
+					if !ok {
+						err = fmt.Errorf("bad S3.credential_refresh_command at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigS3AsStruct.credentialRefreshEndpoint, ok = parseString(backendConfigS3AsMap, "credential_refresh_endpoint", "")
+					if !ok {
+						err = fmt.Errorf("bad S3.credential_refresh_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+					if (backendConfigS3AsStruct.credentialRefreshCommand != "") && (backendConfigS3AsStruct.credentialRefreshEndpoint != "") {
+						err = fmt.Errorf("cannot specify both S3.credential_refresh_command and S3.credential_refresh_endpoint at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
 					backendConfigS3AsStruct.accessKeyID, ok = parseString(backendConfigS3AsMap, "access_key_id", "${AWS_ACCESS_KEY_ID}")
 					if !ok {
 						err = fmt.Errorf("bad S3.access_key_id at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
-					if backendConfigS3AsStruct.accessKeyID == "" {
+					if (backendConfigS3AsStruct.accessKeyID == "") && !backendConfigS3AsStruct.credentialRefreshConfigured() {
 						err = fmt.Errorf("empty S3.access_key_id at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
@@ -1133,10 +2502,40 @@ func checkConfigFile() (err error) {
 						err = fmt.Errorf("bad S3.secret_access_key at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
-					if backendConfigS3AsStruct.secretAccessKey == "" {
+					if (backendConfigS3AsStruct.secretAccessKey == "") && !backendConfigS3AsStruct.credentialRefreshConfigured() {
 						err = fmt.Errorf("empty S3.secret_access_key at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
 						return
 					}
+
+					backendConfigS3AsStruct.sessionToken, ok = parseString(backendConfigS3AsMap, "session_token", "")
+					if !ok {
+						err = fmt.Errorf("bad S3.session_token at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigS3AsStruct.sessionTokenExpiry, ok = parseTimestamp(backendConfigS3AsMap, "session_token_expiry")
+					if !ok {
+						err = fmt.Errorf("bad S3.session_token_expiry at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					backendConfigS3AsStruct.credentialRefreshWindow, ok = parseMilliseconds(backendConfigS3AsMap, "credential_refresh_window", defaultS3CredentialRefreshWindow)
+					if !ok {
+						err = fmt.Errorf("bad S3.credential_refresh_window at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+						return
+					}
+
+					for key, value := range map[string]string{
+						"access_key_id":     backendConfigS3AsStruct.accessKeyID,
+						"secret_access_key": backendConfigS3AsStruct.secretAccessKey,
+						"session_token":     backendConfigS3AsStruct.sessionToken,
+					} {
+						err = checkSecretRef(config, value)
+						if err != nil {
+							err = fmt.Errorf("bad S3.%s at backends[%v (\"%s\")]: %v", key, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, err)
+							return
+						}
+					}
 				}
 
 				backendConfigS3AsStruct.skipTLSCertificateVerify, ok = parseBool(backendConfigS3AsMap, "skip_tls_certificate_verify", true)
@@ -1157,6 +2556,54 @@ func checkConfigFile() (err error) {
 					return
 				}
 
+				backendConfigS3AsStruct.signatureVersion, ok = parseString(backendConfigS3AsMap, "signature_version", defaultS3SignatureVersion)
+				if !ok || ((backendConfigS3AsStruct.signatureVersion != S3SignatureVersionV4) && (backendConfigS3AsStruct.signatureVersion != S3SignatureVersionV2)) {
+					err = fmt.Errorf("bad S3.signature_version at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.exposeVersions, ok = parseBool(backendConfigS3AsMap, "expose_versions", false)
+				if !ok {
+					err = fmt.Errorf("bad S3.expose_versions at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.provider, ok = parseString(backendConfigS3AsMap, "provider", defaultS3Provider)
+				if ok {
+					_, ok = s3ProviderQuirks[backendConfigS3AsStruct.provider]
+				}
+				if !ok {
+					err = fmt.Errorf("bad S3.provider at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.eTagValidator, ok = parseString(backendConfigS3AsMap, "etag_validator", s3ProviderQuirks[backendConfigS3AsStruct.provider].eTagValidator)
+				if ok {
+					_, ok = eTagValidators[backendConfigS3AsStruct.eTagValidator]
+				}
+				if !ok {
+					err = fmt.Errorf("bad S3.etag_validator at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.conditionalRequests, ok = parseString(backendConfigS3AsMap, "conditional_requests", defaultS3ConditionalRequests)
+				if !ok || ((backendConfigS3AsStruct.conditionalRequests != S3ConditionalRequestsProbe) && (backendConfigS3AsStruct.conditionalRequests != S3ConditionalRequestsSupported) && (backendConfigS3AsStruct.conditionalRequests != S3ConditionalRequestsUnsupported)) {
+					err = fmt.Errorf("bad S3.conditional_requests at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryMode, ok = parseString(backendConfigS3AsMap, "retry_mode", defaultS3RetryMode)
+				if !ok || ((backendConfigS3AsStruct.retryMode != S3RetryModeStandard) && (backendConfigS3AsStruct.retryMode != S3RetryModeAdaptive)) {
+					err = fmt.Errorf("bad S3.retry_mode at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryMaxAttempts, ok = parseUint64(backendConfigS3AsMap, "retry_max_attempts", uint64(0))
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_max_attempts at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
 				backendConfigS3AsStruct.retryBaseDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_base_delay", 10*time.Millisecond)
 				if !ok {
 					err = fmt.Errorf("bad S3.retry_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
@@ -1169,40 +2616,301 @@ func checkConfigFile() (err error) {
 					return
 				}
 
-				backendConfigS3AsStruct.retryMaxDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_max_delay", 2000*time.Millisecond)
-				if !ok {
-					err = fmt.Errorf("bad S3.retry_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
-					return
-				}
+				backendConfigS3AsStruct.retryMaxDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_max_delay", 2000*time.Millisecond)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryJitter, ok = parseString(backendConfigS3AsMap, "retry_jitter", defaultS3RetryJitter)
+				if !ok || ((backendConfigS3AsStruct.retryJitter != S3RetryJitterNone) && (backendConfigS3AsStruct.retryJitter != S3RetryJitterFull) && (backendConfigS3AsStruct.retryJitter != S3RetryJitterEqual)) {
+					err = fmt.Errorf("bad S3.retry_jitter at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryMaxElapsed, ok = parseMilliseconds(backendConfigS3AsMap, "retry_max_elapsed", time.Duration(0))
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_max_elapsed at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryThrottleBaseDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_throttle_base_delay", backendConfigS3AsStruct.retryBaseDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_throttle_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryThrottleMaxDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_throttle_max_delay", backendConfigS3AsStruct.retryMaxDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_throttle_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryServerBaseDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_server_base_delay", backendConfigS3AsStruct.retryBaseDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_server_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryServerMaxDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_server_max_delay", backendConfigS3AsStruct.retryMaxDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_server_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryTransportBaseDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_transport_base_delay", backendConfigS3AsStruct.retryBaseDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_transport_base_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigS3AsStruct.retryTransportMaxDelay, ok = parseMilliseconds(backendConfigS3AsMap, "retry_transport_max_delay", backendConfigS3AsStruct.retryMaxDelay)
+				if !ok {
+					err = fmt.Errorf("bad S3.retry_transport_max_delay at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				// As MaxAttempts() applies to every error class, it must permit the most attempts of any
+				// class (e.g. retry_throttle_base_delay may enable retries despite a retry_base_delay of 0)
+
+				backendConfigS3AsStruct.retryAttempts = max(
+					computeRetryAttempts(backendConfigS3AsStruct.retryMaxAttempts, backendConfigS3AsStruct.retryThrottleBaseDelay, backendConfigS3AsStruct.retryNextDelayMultiplier, backendConfigS3AsStruct.retryThrottleMaxDelay),
+					computeRetryAttempts(backendConfigS3AsStruct.retryMaxAttempts, backendConfigS3AsStruct.retryServerBaseDelay, backendConfigS3AsStruct.retryNextDelayMultiplier, backendConfigS3AsStruct.retryServerMaxDelay),
+					computeRetryAttempts(backendConfigS3AsStruct.retryMaxAttempts, backendConfigS3AsStruct.retryTransportBaseDelay, backendConfigS3AsStruct.retryNextDelayMultiplier, backendConfigS3AsStruct.retryTransportMaxDelay))
+
+				backendAsStructNew.backendTypeSpecifics = backendConfigS3AsStruct
+			case "Sharded":
+				backendConfigShardedAsInterface, ok = backendAsMap["Sharded"]
+				if !ok {
+					err = fmt.Errorf("missing or bad Sharded section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigShardedAsMap, ok = backendConfigShardedAsInterface.(map[string]interface{})
+				if !ok {
+					err = fmt.Errorf("bad Sharded section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigShardedAsStruct = &backendConfigShardedStruct{}
+
+				backendConfigShardedAsStruct.backends, ok = parseStringSlice(backendConfigShardedAsMap, "backends", nil)
+				if !ok || (len(backendConfigShardedAsStruct.backends) == 0) {
+					err = fmt.Errorf("missing or bad Sharded.backends at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigShardedAsStruct.virtualNodes, ok = parseUint64(backendConfigShardedAsMap, "virtual_nodes", uint64(128))
+				if !ok || (backendConfigShardedAsStruct.virtualNodes == 0) {
+					err = fmt.Errorf("bad Sharded.virtual_nodes at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendAsStructNew.backendTypeSpecifics = backendConfigShardedAsStruct
+			case "Snapshot":
+				backendConfigSnapshotAsInterface, ok = backendAsMap["Snapshot"]
+				if !ok {
+					err = fmt.Errorf("missing or bad Snapshot section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigSnapshotAsMap, ok = backendConfigSnapshotAsInterface.(map[string]interface{})
+				if !ok {
+					err = fmt.Errorf("bad Snapshot section at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigSnapshotAsStruct = &backendConfigSnapshotStruct{}
+
+				backendConfigSnapshotAsStruct.backend, ok = parseString(backendConfigSnapshotAsMap, "backend", nil)
+				if !ok {
+					err = fmt.Errorf("missing or bad Snapshot.backend at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendConfigSnapshotAsStruct.name, ok = parseString(backendConfigSnapshotAsMap, "name", nil)
+				if !ok {
+					err = fmt.Errorf("missing or bad Snapshot.name at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				if !backendAsStructNew.readOnly {
+					err = fmt.Errorf("Snapshot backend must be readonly at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+					return
+				}
+
+				backendAsStructNew.backendTypeSpecifics = backendConfigSnapshotAsStruct
+			default:
+				err = fmt.Errorf("backends[%v (\"%s\")] specified unsupported backend_type \"%s\"", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, backendAsStructNew.backendType)
+				return
+			}
+
+			if (backendAsStructNew.listPartitions > 1) && (backendAsStructNew.backendType != "S3") {
+				err = fmt.Errorf("list_partitions not supported for backend_type \"%s\" at backends[%v (\"%s\")]", backendAsStructNew.backendType, backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			_, ok = config.backends[backendAsStructNew.dirName]
+			if ok {
+				err = fmt.Errorf("duplicate backend at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+				return
+			}
+
+			config.backends[backendAsStructNew.dirName] = backendAsStructNew
+		}
+
+		// Ensure each mirror is another (writable if necessary) backend that is not itself mirrored
+
+		for dirName, backendAsStructNew = range config.backends {
+			if backendAsStructNew.mirror == "" {
+				continue
+			}
+
+			mirrorBackend, ok = config.backends[backendAsStructNew.mirror]
+			if !ok {
+				err = fmt.Errorf("backends[\"%s\"] specified unknown mirror \"%s\"", dirName, backendAsStructNew.mirror)
+				return
+			}
+			if mirrorBackend == backendAsStructNew {
+				err = fmt.Errorf("backends[\"%s\"] cannot specify itself as its mirror", dirName)
+				return
+			}
+			if mirrorBackend.mirror != "" {
+				err = fmt.Errorf("backends[\"%s\"] specified mirror \"%s\" that itself specifies a mirror", dirName, backendAsStructNew.mirror)
+				return
+			}
+			if !backendAsStructNew.readOnly && mirrorBackend.readOnly {
+				err = fmt.Errorf("backends[\"%s\"] specified readonly mirror \"%s\"", dirName, backendAsStructNew.mirror)
+				return
+			}
+		}
+
+		// Ensure each tier_cold_backend is another writable backend that does not itself tier (and this one is writable)
+
+		for dirName, backendAsStructNew = range config.backends {
+			if backendAsStructNew.tierColdBackend == "" {
+				continue
+			}
+
+			tierColdBackend, ok = config.backends[backendAsStructNew.tierColdBackend]
+			if !ok {
+				err = fmt.Errorf("backends[\"%s\"] specified unknown tier_cold_backend \"%s\"", dirName, backendAsStructNew.tierColdBackend)
+				return
+			}
+			if tierColdBackend == backendAsStructNew {
+				err = fmt.Errorf("backends[\"%s\"] cannot specify itself as its tier_cold_backend", dirName)
+				return
+			}
+			if tierColdBackend.tierColdBackend != "" {
+				err = fmt.Errorf("backends[\"%s\"] specified tier_cold_backend \"%s\" that itself specifies a tier_cold_backend", dirName, backendAsStructNew.tierColdBackend)
+				return
+			}
+			if backendAsStructNew.readOnly || tierColdBackend.readOnly {
+				err = fmt.Errorf("backends[\"%s\"] and its tier_cold_backend \"%s\" must both be writable", dirName, backendAsStructNew.tierColdBackend)
+				return
+			}
+		}
+	}
+
+	// Ensure each Snapshot backend refers to a snapshot of another (non-Snapshot) backend specifying a snapshot_dir
+
+	for dirName, backendAsStructNew = range config.backends {
+		if backendAsStructNew.backendType != "Snapshot" {
+			continue
+		}
+
+		backendConfigSnapshotAsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigSnapshotStruct)
+
+		snapshotSourceBackend, ok = config.backends[backendConfigSnapshotAsStruct.backend]
+		if !ok {
+			err = fmt.Errorf("backends[\"%s\"] specified unknown Snapshot.backend \"%s\"", dirName, backendConfigSnapshotAsStruct.backend)
+			return
+		}
+		if snapshotSourceBackend.backendType == "Snapshot" {
+			err = fmt.Errorf("backends[\"%s\"] specified Snapshot.backend \"%s\" that is itself a Snapshot", dirName, backendConfigSnapshotAsStruct.backend)
+			return
+		}
+
+		backendConfigSnapshotAsStruct.manifestFilePath, err = snapshotSourceBackend.snapshotManifestFilePath(backendConfigSnapshotAsStruct.name)
+		if err != nil {
+			err = fmt.Errorf("backends[\"%s\"] specified bad Snapshot: %v", dirName, err)
+			return
+		}
+	}
 
-				backendConfigS3AsStruct.retryDelay = make([]time.Duration, 0)
+	// Ensure each Sharded backend's shards are distinct other (non-Sharded, non-Snapshot, writable if necessary) backends
 
-				if backendConfigS3AsStruct.retryBaseDelay != time.Duration(0) {
-					nextRetryDelay = backendConfigS3AsStruct.retryBaseDelay
+	for dirName, backendAsStructNew = range config.backends {
+		if backendAsStructNew.backendType != "Sharded" {
+			continue
+		}
 
-					for nextRetryDelay <= backendConfigS3AsStruct.retryMaxDelay {
-						backendConfigS3AsStruct.retryDelay = append(backendConfigS3AsStruct.retryDelay, nextRetryDelay)
-						nextRetryDelay = time.Duration(float64(nextRetryDelay) * backendConfigS3AsStruct.retryNextDelayMultiplier)
-					}
-				}
+		backendConfigShardedAsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigShardedStruct)
 
-				backendAsStructNew.backendTypeSpecifics = backendConfigS3AsStruct
-			default:
-				err = fmt.Errorf("backends[%v (\"%s\")] specified unsupported backend_type \"%s\"", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName, backendAsStructNew.backendType)
+		for shardBackendIndex, shardBackendDirName = range backendConfigShardedAsStruct.backends {
+			shardBackend, ok = config.backends[shardBackendDirName]
+			if !ok {
+				err = fmt.Errorf("backends[\"%s\"] specified unknown Sharded.backends entry \"%s\"", dirName, shardBackendDirName)
+				return
+			}
+			if (shardBackend.backendType == "Sharded") || (shardBackend.backendType == "Snapshot") {
+				err = fmt.Errorf("backends[\"%s\"] specified Sharded.backends entry \"%s\" that is itself a %s", dirName, shardBackendDirName, shardBackend.backendType)
+				return
+			}
+			if !backendAsStructNew.readOnly && shardBackend.readOnly {
+				err = fmt.Errorf("backends[\"%s\"] specified readonly Sharded.backends entry \"%s\"", dirName, shardBackendDirName)
+				return
+			}
+			if slices.Contains(backendConfigShardedAsStruct.backends[:shardBackendIndex], shardBackendDirName) {
+				err = fmt.Errorf("backends[\"%s\"] specified duplicate Sharded.backends entry \"%s\"", dirName, shardBackendDirName)
 				return
 			}
+		}
+	}
 
-			_, ok = config.backends[backendAsStructNew.dirName]
-			if ok {
-				err = fmt.Errorf("duplicate backend at backends[%v (\"%s\")]", backendsAsInterfaceSliceIndex, backendAsStructNew.dirName)
+	// Ensure each replica is another distinct backend that does not itself specify replicas
+
+	for dirName, backendAsStructNew = range config.backends {
+		for replicaIndex, replicaDirName = range backendAsStructNew.replicas {
+			replicaBackend, ok = config.backends[replicaDirName]
+			if !ok {
+				err = fmt.Errorf("backends[\"%s\"] specified unknown replica \"%s\"", dirName, replicaDirName)
 				return
 			}
+			if replicaBackend == backendAsStructNew {
+				err = fmt.Errorf("backends[\"%s\"] cannot specify itself as a replica", dirName)
+				return
+			}
+			if len(replicaBackend.replicas) != 0 {
+				err = fmt.Errorf("backends[\"%s\"] specified replica \"%s\" that itself specifies replicas", dirName, replicaDirName)
+				return
+			}
+			if slices.Contains(backendAsStructNew.replicas[:replicaIndex], replicaDirName) {
+				err = fmt.Errorf("backends[\"%s\"] specified duplicate replica \"%s\"", dirName, replicaDirName)
+				return
+			}
+		}
+	}
 
-			config.backends[backendAsStructNew.dirName] = backendAsStructNew
+	// Ensure any audit_backend is a writable backend
+
+	if config.auditBackend != "" {
+		backendAsStructNew, ok = config.backends[config.auditBackend]
+		if !ok {
+			err = fmt.Errorf("audit_backend specified unknown backend \"%s\"", config.auditBackend)
+			return
+		}
+		if backendAsStructNew.readOnly {
+			err = fmt.Errorf("audit_backend specified readonly backend \"%s\"", config.auditBackend)
+			return
 		}
 	}
 
 	if globals.config == nil {
+		// Apply logging settings (before config.backends are moved out below)
+
+		globals.logSink.configure(config)
+
 		// Move all (local) config.backends to globals.backendsToMount
 
 		for dirName, backendAsStructNew = range config.backends {
@@ -1282,108 +2990,405 @@ func checkConfigFile() (err error) {
 			return
 		}
 
+		if globals.config.dirMTimeTTL != config.dirMTimeTTL {
+			err = errors.New("cannot change dir_mtime_ttl via SIGHUP")
+			return
+		}
+
 		if globals.config.cacheLineSize != config.cacheLineSize {
 			err = errors.New("cannot change cache_line_size via SIGHUP")
 			return
 		}
 
-		if globals.config.cacheLines != config.cacheLines {
-			err = errors.New("cannot change cache_lines via SIGHUP")
-			return
-		}
+		if globals.config.autoSIGHUPInterval != config.autoSIGHUPInterval {
+			err = errors.New("cannot change auto_sighup_interval via SIGHUP")
+			return
+		}
+
+		if globals.config.endpoint != config.endpoint {
+			err = errors.New("cannot change endpoint via SIGHUP")
+			return
+		}
+
+		if globals.config.migrationStateDir != config.migrationStateDir {
+			err = errors.New("cannot change migration_state_dir via SIGHUP")
+			return
+		}
+
+		if globals.config.maxConcurrentBackendRequests != config.maxConcurrentBackendRequests {
+			err = errors.New("cannot change max_concurrent_backend_requests via SIGHUP")
+			return
+		}
+
+		if globals.config.retryBudgetRatio != config.retryBudgetRatio {
+			err = errors.New("cannot change retry_budget_ratio via SIGHUP")
+			return
+		}
+
+		if globals.config.retryBudgetMinPerSecond != config.retryBudgetMinPerSecond {
+			err = errors.New("cannot change retry_budget_min_per_second via SIGHUP")
+			return
+		}
+
+		if globals.config.retryBudgetBurst != config.retryBudgetBurst {
+			err = errors.New("cannot change retry_budget_burst via SIGHUP")
+			return
+		}
+
+		if globals.config.auditLogFile != config.auditLogFile {
+			err = errors.New("cannot change audit_log_file via SIGHUP")
+			return
+		}
+
+		if globals.config.auditLogMaxSize != config.auditLogMaxSize {
+			err = errors.New("cannot change audit_log_max_size via SIGHUP")
+			return
+		}
+
+		if globals.config.auditLogMaxFiles != config.auditLogMaxFiles {
+			err = errors.New("cannot change audit_log_max_files via SIGHUP")
+			return
+		}
+
+		if !slices.Equal(globals.config.auditLogOps, config.auditLogOps) {
+			err = errors.New("cannot change audit_log_ops via SIGHUP")
+			return
+		}
+
+		if globals.config.auditLogSampleRate != config.auditLogSampleRate {
+			err = errors.New("cannot change audit_log_sample_rate via SIGHUP")
+			return
+		}
+
+		if globals.config.auditBackend != config.auditBackend {
+			err = errors.New("cannot change audit_backend via SIGHUP")
+			return
+		}
+
+		if globals.config.auditPrefix != config.auditPrefix {
+			err = errors.New("cannot change audit_prefix via SIGHUP")
+			return
+		}
+
+		if globals.config.vaultAddress != config.vaultAddress {
+			err = errors.New("cannot change vault_address via SIGHUP")
+			return
+		}
+
+		if globals.config.vaultToken != config.vaultToken {
+			err = errors.New("cannot change vault_token via SIGHUP")
+			return
+		}
+
+		if globals.config.vaultTokenFile != config.vaultTokenFile {
+			err = errors.New("cannot change vault_token_file via SIGHUP")
+			return
+		}
+
+		if globals.config.vaultNamespace != config.vaultNamespace {
+			err = errors.New("cannot change vault_namespace via SIGHUP")
+			return
+		}
+
+		if globals.config.awsSecretsRegion != config.awsSecretsRegion {
+			err = errors.New("cannot change aws_secrets_region via SIGHUP")
+			return
+		}
+
+		if globals.config.awsSecretsEndpoint != config.awsSecretsEndpoint {
+			err = errors.New("cannot change aws_secrets_endpoint via SIGHUP")
+			return
+		}
+
+		if globals.config.secretsRefreshInterval != config.secretsRefreshInterval {
+			err = errors.New("cannot change secrets_refresh_interval via SIGHUP")
+			return
+		}
+
+		if globals.config.logLevelFile != config.logLevelFile {
+			err = errors.New("cannot change log_level_file via SIGHUP")
+			return
+		}
+
+		if globals.config.adminSocket != config.adminSocket {
+			err = errors.New("cannot change admin_socket via SIGHUP")
+			return
+		}
+
+		if globals.config.slowBackendRequestThreshold != config.slowBackendRequestThreshold {
+			err = errors.New("cannot change slow_backend_request_threshold via SIGHUP")
+			return
+		}
+
+		if globals.config.slowFUSEOpThreshold != config.slowFUSEOpThreshold {
+			err = errors.New("cannot change slow_fuse_op_threshold via SIGHUP")
+			return
+		}
+
+		if !slices.Equal(globals.config.webhookURLs, config.webhookURLs) {
+			err = errors.New("cannot change webhook_urls via SIGHUP")
+			return
+		}
+
+		if !slices.Equal(globals.config.webhookEvents, config.webhookEvents) {
+			err = errors.New("cannot change webhook_events via SIGHUP")
+			return
+		}
+
+		if globals.config.webhookTimeout != config.webhookTimeout {
+			err = errors.New("cannot change webhook_timeout via SIGHUP")
+			return
+		}
+
+		if globals.config.shutdownTimeout != config.shutdownTimeout {
+			err = errors.New("cannot change shutdown_timeout via SIGHUP")
+			return
+		}
+
+		// Verify that all backends common to our (local) config.backends and globals.backends contain no changes
+
+		for dirName, backendAsStructOld = range globals.config.backends {
+			backendAsStructNew, ok = config.backends[dirName]
+			if ok {
+				if backendAsStructOld.readOnly != backendAsStructNew.readOnly {
+					err = fmt.Errorf("cannot change readonly in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.flushOnClose != backendAsStructNew.flushOnClose {
+					err = fmt.Errorf("cannot change flush_on_close in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.uid != backendAsStructNew.uid {
+					err = fmt.Errorf("cannot change uid in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.gid != backendAsStructNew.gid {
+					err = fmt.Errorf("cannot change gid in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.dirPerm != backendAsStructNew.dirPerm {
+					err = fmt.Errorf("cannot change dir_perm in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.filePerm != backendAsStructNew.filePerm {
+					err = fmt.Errorf("cannot change file_perm in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.directoryPageSize != backendAsStructNew.directoryPageSize {
+					err = fmt.Errorf("cannot change directory_page_size in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.directoryMarkers != backendAsStructNew.directoryMarkers {
+					err = fmt.Errorf("cannot change directory_markers in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.multiPartCacheLineThreshold != backendAsStructNew.multiPartCacheLineThreshold {
+					err = fmt.Errorf("cannot change multipart_cache_line_threshold in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.uploadPartCacheLines != backendAsStructNew.uploadPartCacheLines {
+					err = fmt.Errorf("cannot change upload_part_cache_lines in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.uploadPartConcurrency != backendAsStructNew.uploadPartConcurrency {
+					err = fmt.Errorf("cannot change upload_part_concurrency in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.fetchWorkers != backendAsStructNew.fetchWorkers {
+					err = fmt.Errorf("cannot change fetch_workers in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.fetchWorkersAdaptive != backendAsStructNew.fetchWorkersAdaptive {
+					err = fmt.Errorf("cannot change fetch_workers_adaptive in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.fetchLatencyTarget != backendAsStructNew.fetchLatencyTarget {
+					err = fmt.Errorf("cannot change fetch_latency_target in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.listPartitions != backendAsStructNew.listPartitions {
+					err = fmt.Errorf("cannot change list_partitions in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.bucketContainerName != backendAsStructNew.bucketContainerName {
+					err = fmt.Errorf("cannot change bucket_container_name in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.prefix != backendAsStructNew.prefix {
+					err = fmt.Errorf("cannot change prefix in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.traceLevel != backendAsStructNew.traceLevel {
+					err = fmt.Errorf("cannot change trace_level in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpMaxIdleConnsPerHost != backendAsStructNew.httpMaxIdleConnsPerHost {
+					err = fmt.Errorf("cannot change http_max_idle_conns_per_host in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpMaxConnsPerHost != backendAsStructNew.httpMaxConnsPerHost {
+					err = fmt.Errorf("cannot change http_max_conns_per_host in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpIdleConnTimeout != backendAsStructNew.httpIdleConnTimeout {
+					err = fmt.Errorf("cannot change http_idle_conn_timeout in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpResponseHeaderTimeout != backendAsStructNew.httpResponseHeaderTimeout {
+					err = fmt.Errorf("cannot change http_response_header_timeout in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpProtocol != backendAsStructNew.httpProtocol {
+					err = fmt.Errorf("cannot change http_protocol in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.httpKeepAlive != backendAsStructNew.httpKeepAlive {
+					err = fmt.Errorf("cannot change http_keep_alive in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.mirror != backendAsStructNew.mirror {
+					err = fmt.Errorf("cannot change mirror in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.mirrorJournalFile != backendAsStructNew.mirrorJournalFile {
+					err = fmt.Errorf("cannot change mirror_journal_file in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.mirrorReconcileInterval != backendAsStructNew.mirrorReconcileInterval {
+					err = fmt.Errorf("cannot change mirror_reconcile_interval in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.tierColdBackend != backendAsStructNew.tierColdBackend {
+					err = fmt.Errorf("cannot change tier_cold_backend in backends[\"%s\"]", dirName)
+					return
+				}
+
+				if backendAsStructOld.tierLocationMapFile != backendAsStructNew.tierLocationMapFile {
+					err = fmt.Errorf("cannot change tier_location_map_file in backends[\"%s\"]", dirName)
+					return
+				}
 
-		if globals.config.cacheLinesToPrefetch != config.cacheLinesToPrefetch {
-			err = errors.New("cannot change cache_lines_to_prefetch via SIGHUP")
-			return
-		}
+				if backendAsStructOld.tierInterval != backendAsStructNew.tierInterval {
+					err = fmt.Errorf("cannot change tier_interval in backends[\"%s\"]", dirName)
+					return
+				}
 
-		if globals.config.dirtyCacheLinesFlushTrigger != config.dirtyCacheLinesFlushTrigger {
-			err = errors.New("cannot change dirty_cache_lines_flush_trigger via SIGHUP")
-			return
-		}
+				if backendAsStructOld.tierMinAge != backendAsStructNew.tierMinAge {
+					err = fmt.Errorf("cannot change tier_min_age in backends[\"%s\"]", dirName)
+					return
+				}
 
-		if globals.config.dirtyCacheLinesMax != config.dirtyCacheLinesMax {
-			err = errors.New("cannot change dirty_cache_lines_max via SIGHUP")
-			return
-		}
+				if backendAsStructOld.tierMaxAccessCount != backendAsStructNew.tierMaxAccessCount {
+					err = fmt.Errorf("cannot change tier_max_access_count in backends[\"%s\"]", dirName)
+					return
+				}
 
-		if globals.config.autoSIGHUPInterval != config.autoSIGHUPInterval {
-			err = errors.New("cannot change auto_sighup_interval via SIGHUP")
-			return
-		}
+				if backendAsStructOld.tierPromoteAccessCount != backendAsStructNew.tierPromoteAccessCount {
+					err = fmt.Errorf("cannot change tier_promote_access_count in backends[\"%s\"]", dirName)
+					return
+				}
 
-		if globals.config.endpoint != config.endpoint {
-			err = errors.New("cannot change endpoint via SIGHUP")
-			return
-		}
+				if !slices.Equal(backendAsStructOld.tierPathPatterns, backendAsStructNew.tierPathPatterns) {
+					err = fmt.Errorf("cannot change tier_path_patterns in backends[\"%s\"]", dirName)
+					return
+				}
 
-		// Verify that all backends common to our (local) config.backends and globals.backends contain no changes
+				if !slices.Equal(backendAsStructOld.quotas, backendAsStructNew.quotas) {
+					err = fmt.Errorf("cannot change quotas in backends[\"%s\"]", dirName)
+					return
+				}
 
-		for dirName, backendAsStructOld = range globals.config.backends {
-			backendAsStructNew, ok = config.backends[dirName]
-			if ok {
-				if backendAsStructOld.readOnly != backendAsStructNew.readOnly {
-					err = fmt.Errorf("cannot change readonly in backends[\"%s\"]", dirName)
+				if backendAsStructOld.quotaReconcileInterval != backendAsStructNew.quotaReconcileInterval {
+					err = fmt.Errorf("cannot change quota_reconcile_interval in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.flushOnClose != backendAsStructNew.flushOnClose {
-					err = fmt.Errorf("cannot change flush_on_close in backends[\"%s\"]", dirName)
+				if backendAsStructOld.priority != backendAsStructNew.priority {
+					err = fmt.Errorf("cannot change priority in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.uid != backendAsStructNew.uid {
-					err = fmt.Errorf("cannot change uid in backends[\"%s\"]", dirName)
+				if !slices.Equal(backendAsStructOld.priorityPrefixes, backendAsStructNew.priorityPrefixes) {
+					err = fmt.Errorf("cannot change priority_prefixes in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.gid != backendAsStructNew.gid {
-					err = fmt.Errorf("cannot change gid in backends[\"%s\"]", dirName)
+				if backendAsStructOld.healthCheckInterval != backendAsStructNew.healthCheckInterval {
+					err = fmt.Errorf("cannot change health_check_interval in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.dirPerm != backendAsStructNew.dirPerm {
-					err = fmt.Errorf("cannot change dir_perm in backends[\"%s\"]", dirName)
+				if backendAsStructOld.healthCheckFailureThreshold != backendAsStructNew.healthCheckFailureThreshold {
+					err = fmt.Errorf("cannot change health_check_failure_threshold in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.filePerm != backendAsStructNew.filePerm {
-					err = fmt.Errorf("cannot change file_perm in backends[\"%s\"]", dirName)
+				if backendAsStructOld.healthCheckServeStale != backendAsStructNew.healthCheckServeStale {
+					err = fmt.Errorf("cannot change health_check_serve_stale in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.directoryPageSize != backendAsStructNew.directoryPageSize {
-					err = fmt.Errorf("cannot change directory_page_size in backends[\"%s\"]", dirName)
+				if backendAsStructOld.multipartUploadGCInterval != backendAsStructNew.multipartUploadGCInterval {
+					err = fmt.Errorf("cannot change multipart_upload_gc_interval in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.multiPartCacheLineThreshold != backendAsStructNew.multiPartCacheLineThreshold {
-					err = fmt.Errorf("cannot change multipart_cache_line_threshold in backends[\"%s\"]", dirName)
+				if backendAsStructOld.multipartUploadMaxAge != backendAsStructNew.multipartUploadMaxAge {
+					err = fmt.Errorf("cannot change multipart_upload_max_age in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.uploadPartCacheLines != backendAsStructNew.uploadPartCacheLines {
-					err = fmt.Errorf("cannot change upload_part_cache_lines in backends[\"%s\"]", dirName)
+				if backendAsStructOld.snapshotDir != backendAsStructNew.snapshotDir {
+					err = fmt.Errorf("cannot change snapshot_dir in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.uploadPartConcurrency != backendAsStructNew.uploadPartConcurrency {
-					err = fmt.Errorf("cannot change upload_part_concurrency in backends[\"%s\"]", dirName)
+				if !slices.Equal(backendAsStructOld.replicas, backendAsStructNew.replicas) {
+					err = fmt.Errorf("cannot change replicas in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.bucketContainerName != backendAsStructNew.bucketContainerName {
-					err = fmt.Errorf("cannot change bucket_container_name in backends[\"%s\"]", dirName)
+				if backendAsStructOld.replicaProbeInterval != backendAsStructNew.replicaProbeInterval {
+					err = fmt.Errorf("cannot change replica_probe_interval in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.prefix != backendAsStructNew.prefix {
-					err = fmt.Errorf("cannot change prefix in backends[\"%s\"]", dirName)
+				if backendAsStructOld.replicaHedgeDelay != backendAsStructNew.replicaHedgeDelay {
+					err = fmt.Errorf("cannot change replica_hedge_delay in backends[\"%s\"]", dirName)
 					return
 				}
 
-				if backendAsStructOld.traceLevel != backendAsStructNew.traceLevel {
-					err = fmt.Errorf("cannot change trace_level in backends[\"%s\"]", dirName)
+				if !slices.EqualFunc(backendAsStructOld.accessRules, backendAsStructNew.accessRules, func(accessRuleOld, accessRuleNew backendAccessRuleStruct) bool {
+					return (accessRuleOld.prefix == accessRuleNew.prefix) && slices.Equal(accessRuleOld.uids, accessRuleNew.uids) && slices.Equal(accessRuleOld.gids, accessRuleNew.gids) && (accessRuleOld.allow == accessRuleNew.allow)
+				}) {
+					err = fmt.Errorf("cannot change access_rules in backends[\"%s\"]", dirName)
 					return
 				}
 
@@ -1404,23 +3409,152 @@ func checkConfigFile() (err error) {
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnToken != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnToken {
-						err = fmt.Errorf("cannot change AIStore.authn_token in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).provider != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).provider {
+						err = fmt.Errorf("cannot change AIStore.provider in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).timeout != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).timeout {
+						err = fmt.Errorf("cannot change AIStore.timeout in backends[\"%s\"]", dirName)
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnTokenFile != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnTokenFile {
-						err = fmt.Errorf("cannot change AIStore.authn_token_file in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).etlName != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).etlName {
+						err = fmt.Errorf("cannot change AIStore.etl_name in backends[\"%s\"]", dirName)
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).provider != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).provider {
-						err = fmt.Errorf("cannot change AIStore.provider in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).etlArgs != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).etlArgs {
+						err = fmt.Errorf("cannot change AIStore.etl_args in backends[\"%s\"]", dirName)
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).timeout != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).timeout {
-						err = fmt.Errorf("cannot change AIStore.timeout in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadThreshold != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadThreshold {
+						err = fmt.Errorf("cannot change AIStore.blob_download_threshold in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadChunkSize != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadChunkSize {
+						err = fmt.Errorf("cannot change AIStore.blob_download_chunk_size in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadWorkers != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).blobDownloadWorkers {
+						err = fmt.Errorf("cannot change AIStore.blob_download_workers in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).directTargetReads != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).directTargetReads {
+						err = fmt.Errorf("cannot change AIStore.direct_target_reads in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).clusterMapTTL != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).clusterMapTTL {
+						err = fmt.Errorf("cannot change AIStore.cluster_map_ttl in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryMaxAttempts != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryMaxAttempts {
+						err = fmt.Errorf("cannot change AIStore.retry_max_attempts in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryBaseDelay {
+						err = fmt.Errorf("cannot change AIStore.retry_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryNextDelayMultiplier != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryNextDelayMultiplier {
+						err = fmt.Errorf("cannot change AIStore.retry_next_delay_multiplier in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).retryMaxDelay {
+						err = fmt.Errorf("cannot change AIStore.retry_max_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).namespaceUUID != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).namespaceUUID {
+						err = fmt.Errorf("cannot change AIStore.namespace_uuid in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).namespaceName != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).namespaceName {
+						err = fmt.Errorf("cannot change AIStore.namespace_name in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).propsCacheTTL != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).propsCacheTTL {
+						err = fmt.Errorf("cannot change AIStore.props_cache_ttl in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).prefetchListedFiles != backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct).prefetchListedFiles {
+						err = fmt.Errorf("cannot change AIStore.prefetch_listed_files in backends[\"%s\"]", dirName)
+						return
+					}
+				case "AzureFiles":
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).accountName != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).accountName {
+						err = fmt.Errorf("cannot change AzureFiles.account_name in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).endpoint != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).endpoint {
+						err = fmt.Errorf("cannot change AzureFiles.endpoint in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).timeout != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).timeout {
+						err = fmt.Errorf("cannot change AzureFiles.timeout in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryMaxAttempts != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryMaxAttempts {
+						err = fmt.Errorf("cannot change AzureFiles.retry_max_attempts in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryBaseDelay {
+						err = fmt.Errorf("cannot change AzureFiles.retry_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryNextDelayMultiplier != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryNextDelayMultiplier {
+						err = fmt.Errorf("cannot change AzureFiles.retry_next_delay_multiplier in backends[\"%s\"]", dirName)
+						return
+					}
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct).retryMaxDelay {
+						err = fmt.Errorf("cannot change AzureFiles.retry_max_delay in backends[\"%s\"]", dirName)
+						return
+					}
+				case "Dropbox":
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).apiEndpoint != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).apiEndpoint {
+						err = fmt.Errorf("cannot change Dropbox.api_endpoint in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).contentEndpoint != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).contentEndpoint {
+						err = fmt.Errorf("cannot change Dropbox.content_endpoint in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).timeout != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).timeout {
+						err = fmt.Errorf("cannot change Dropbox.timeout in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).retryMaxAttempts != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).retryMaxAttempts {
+						err = fmt.Errorf("cannot change Dropbox.retry_max_attempts in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).retryBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).retryBaseDelay {
+						err = fmt.Errorf("cannot change Dropbox.retry_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).retryNextDelayMultiplier != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).retryNextDelayMultiplier {
+						err = fmt.Errorf("cannot change Dropbox.retry_next_delay_multiplier in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).retryMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct).retryMaxDelay {
+						err = fmt.Errorf("cannot change Dropbox.retry_max_delay in backends[\"%s\"]", dirName)
 						return
 					}
 				case "RAM":
@@ -1464,6 +3598,11 @@ func checkConfigFile() (err error) {
 						return
 					}
 
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).dnsSuffix != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).dnsSuffix {
+						err = fmt.Errorf("cannot change S3.dns_suffix in backends[\"%s\"]", dirName)
+						return
+					}
+
 					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).useCredentialsEnv != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).useCredentialsEnv {
 						err = fmt.Errorf("cannot change S3.use_credentials_env in backends[\"%s\"]", dirName)
 						return
@@ -1474,13 +3613,18 @@ func checkConfigFile() (err error) {
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).accessKeyID != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).accessKeyID {
-						err = fmt.Errorf("cannot change S3.access_key_id in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshCommand != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshCommand {
+						err = fmt.Errorf("cannot change S3.credential_refresh_command in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshEndpoint != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshEndpoint {
+						err = fmt.Errorf("cannot change S3.credential_refresh_endpoint in backends[\"%s\"]", dirName)
 						return
 					}
 
-					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).secretAccessKey != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).secretAccessKey {
-						err = fmt.Errorf("cannot change S3.secret_access_key in backends[\"%s\"]", dirName)
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshWindow != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).credentialRefreshWindow {
+						err = fmt.Errorf("cannot change S3.credential_refresh_window in backends[\"%s\"]", dirName)
 						return
 					}
 
@@ -1499,6 +3643,41 @@ func checkConfigFile() (err error) {
 						return
 					}
 
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).signatureVersion != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).signatureVersion {
+						err = fmt.Errorf("cannot change S3.signature_version in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).exposeVersions != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).exposeVersions {
+						err = fmt.Errorf("cannot change S3.expose_versions in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).provider != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).provider {
+						err = fmt.Errorf("cannot change S3.provider in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).eTagValidator != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).eTagValidator {
+						err = fmt.Errorf("cannot change S3.etag_validator in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).conditionalRequests != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).conditionalRequests {
+						err = fmt.Errorf("cannot change S3.conditional_requests in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryMode != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryMode {
+						err = fmt.Errorf("cannot change S3.retry_mode in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryMaxAttempts != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryMaxAttempts {
+						err = fmt.Errorf("cannot change S3.retry_max_attempts in backends[\"%s\"]", dirName)
+						return
+					}
+
 					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryBaseDelay {
 						err = fmt.Errorf("cannot change S3.retry_base_delay in backends[\"%s\"]", dirName)
 						return
@@ -1513,6 +3692,66 @@ func checkConfigFile() (err error) {
 						err = fmt.Errorf("cannot change S3.retry_max_delay in backends[\"%s\"]", dirName)
 						return
 					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryJitter != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryJitter {
+						err = fmt.Errorf("cannot change S3.retry_jitter in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryMaxElapsed != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryMaxElapsed {
+						err = fmt.Errorf("cannot change S3.retry_max_elapsed in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryThrottleBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryThrottleBaseDelay {
+						err = fmt.Errorf("cannot change S3.retry_throttle_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryThrottleMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryThrottleMaxDelay {
+						err = fmt.Errorf("cannot change S3.retry_throttle_max_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryServerBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryServerBaseDelay {
+						err = fmt.Errorf("cannot change S3.retry_server_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryServerMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryServerMaxDelay {
+						err = fmt.Errorf("cannot change S3.retry_server_max_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryTransportBaseDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryTransportBaseDelay {
+						err = fmt.Errorf("cannot change S3.retry_transport_base_delay in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).retryTransportMaxDelay != backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct).retryTransportMaxDelay {
+						err = fmt.Errorf("cannot change S3.retry_transport_max_delay in backends[\"%s\"]", dirName)
+						return
+					}
+				case "Sharded":
+					if !slices.Equal(backendAsStructOld.backendTypeSpecifics.(*backendConfigShardedStruct).backends, backendAsStructNew.backendTypeSpecifics.(*backendConfigShardedStruct).backends) {
+						err = fmt.Errorf("cannot change Sharded.backends in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigShardedStruct).virtualNodes != backendAsStructNew.backendTypeSpecifics.(*backendConfigShardedStruct).virtualNodes {
+						err = fmt.Errorf("cannot change Sharded.virtual_nodes in backends[\"%s\"]", dirName)
+						return
+					}
+				case "Snapshot":
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigSnapshotStruct).backend != backendAsStructNew.backendTypeSpecifics.(*backendConfigSnapshotStruct).backend {
+						err = fmt.Errorf("cannot change Snapshot.backend in backends[\"%s\"]", dirName)
+						return
+					}
+
+					if backendAsStructOld.backendTypeSpecifics.(*backendConfigSnapshotStruct).name != backendAsStructNew.backendTypeSpecifics.(*backendConfigSnapshotStruct).name {
+						err = fmt.Errorf("cannot change Snapshot.name in backends[\"%s\"]", dirName)
+						return
+					}
 				default:
 					err = fmt.Errorf("logic error comparing backend_type specifics in backends[\"%s\"] - backend_type \"%s\" unrecognized", dirName, backendAsStructOld.backendType)
 					return
@@ -1537,6 +3776,135 @@ func checkConfigFile() (err error) {
 				globals.backendsToMount[dirName] = backendAsStructNew
 			}
 		}
+
+		// Now that the new config has been fully validated, apply changes to cache limits
+
+		globals.Lock()
+
+		if globals.config.cacheLines != config.cacheLines {
+			globals.logger.Printf("[INFO] cache_lines changed from %v to %v", globals.config.cacheLines, config.cacheLines)
+		}
+		if globals.config.cacheLinesToPrefetch != config.cacheLinesToPrefetch {
+			globals.logger.Printf("[INFO] cache_lines_to_prefetch changed from %v to %v", globals.config.cacheLinesToPrefetch, config.cacheLinesToPrefetch)
+		}
+		if globals.config.directReadThreshold != config.directReadThreshold {
+			globals.logger.Printf("[INFO] direct_read_threshold changed from %v to %v", globals.config.directReadThreshold, config.directReadThreshold)
+		}
+		if globals.config.cacheLineTTL != config.cacheLineTTL {
+			globals.logger.Printf("[INFO] cache_line_ttl changed from %v to %v", globals.config.cacheLineTTL, config.cacheLineTTL)
+		}
+		if globals.config.eTagConflictPolicy != config.eTagConflictPolicy {
+			globals.logger.Printf("[INFO] etag_conflict_policy changed from %v to %v", globals.config.eTagConflictPolicy, config.eTagConflictPolicy)
+		}
+		if globals.config.statOnOpen != config.statOnOpen {
+			globals.logger.Printf("[INFO] stat_on_open changed from %v to %v", globals.config.statOnOpen, config.statOnOpen)
+		}
+
+		globals.config.cacheLines = config.cacheLines
+		globals.config.cacheLinesToPrefetch = config.cacheLinesToPrefetch
+		globals.config.directReadThreshold = config.directReadThreshold
+		globals.config.cacheLineTTL = config.cacheLineTTL
+		globals.config.eTagConflictPolicy = config.eTagConflictPolicy
+		globals.config.statOnOpen = config.statOnOpen
+
+		// Apply changes to logging settings
+
+		if (globals.config.logFormat != config.logFormat) || (globals.config.logLevel != config.logLevel) || !maps.Equal(globals.config.logLevels, config.logLevels) {
+			globals.logger.Printf("[INFO] logging settings changed to log_format: \"%s\" log_level: \"%s\" log_levels: %v", config.logFormat, config.logLevel, config.logLevels)
+		}
+
+		globals.config.logFormat = config.logFormat
+		globals.config.logLevel = config.logLevel
+		globals.config.logLevels = config.logLevels
+
+		globals.logSink.configure(config)
+
+		globals.config.dirtyCacheLinesFlushTrigger = config.dirtyCacheLinesFlushTrigger
+		globals.config.dirtyCacheLinesMax = config.dirtyCacheLinesMax
+
+		// Apply changes to path overrides (taking effect for a file's cache line size once none are cached)
+
+		for dirName, backendAsStructOld = range globals.config.backends {
+			backendAsStructNew, ok = config.backends[dirName]
+			if ok && !slices.Equal(backendAsStructOld.pathOverrides, backendAsStructNew.pathOverrides) {
+				globals.logger.Printf("[INFO] path_overrides changed in backends[\"%s\"]", dirName)
+				backendAsStructOld.pathOverrides = backendAsStructNew.pathOverrides
+			}
+		}
+
+		// Should cache_lines have been reduced, evict clean cache lines down to the new limit
+
+		cachePrune()
+
+		globals.Unlock()
+
+		// Rotate credentials of (still) mounted backends in place
+
+		for dirName, backendAsStructOld = range globals.config.backends {
+			backendAsStructNew, ok = config.backends[dirName]
+			if !ok {
+				continue
+			}
+
+			switch backendAsStructOld.backendType {
+			case "AIStore":
+				backendConfigAIStoreAsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigAIStoreStruct)
+				if (backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnToken != backendConfigAIStoreAsStruct.authnToken) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnTokenFile != backendConfigAIStoreAsStruct.authnTokenFile) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnEndpoint != backendConfigAIStoreAsStruct.authnEndpoint) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnUsername != backendConfigAIStoreAsStruct.authnUsername) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnPassword != backendConfigAIStoreAsStruct.authnPassword) {
+					aisContext, ok := backendAsStructOld.context.(*aistoreContextStruct)
+					if ok {
+						authnTokenFileChanged := backendAsStructOld.backendTypeSpecifics.(*backendConfigAIStoreStruct).authnTokenFile != backendConfigAIStoreAsStruct.authnTokenFile
+						aisContext.rotateCredentials(backendConfigAIStoreAsStruct)
+						globals.logger.Printf("[INFO] rotated AIStore AuthN credentials of backends[\"%s\"]", dirName)
+						if authnTokenFileChanged {
+							// Watch the new authn_token_file rather than the old one
+
+							globals.Lock()
+							backendAsStructOld.stopCredentialWatchAlreadyLocked()
+							backendAsStructOld.startCredentialWatchAlreadyLocked()
+							globals.Unlock()
+						}
+					}
+				}
+			case "AzureFiles":
+				backendConfigAzureFilesAsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigAzureFilesStruct)
+				if (backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).accountKey != backendConfigAzureFilesAsStruct.accountKey) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigAzureFilesStruct).sasToken != backendConfigAzureFilesAsStruct.sasToken) {
+					azureFilesContext, ok := backendAsStructOld.context.(*azureFilesContextStruct)
+					if ok {
+						azureFilesContext.rotateCredentials(backendConfigAzureFilesAsStruct)
+						globals.logger.Printf("[INFO] rotated AzureFiles credentials of backends[\"%s\"]", dirName)
+					}
+				}
+			case "Dropbox":
+				backendConfigDropboxAsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigDropboxStruct)
+				if (backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).accessToken != backendConfigDropboxAsStruct.accessToken) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).refreshToken != backendConfigDropboxAsStruct.refreshToken) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).appKey != backendConfigDropboxAsStruct.appKey) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigDropboxStruct).appSecret != backendConfigDropboxAsStruct.appSecret) {
+					dropboxContext, ok := backendAsStructOld.context.(*dropboxContextStruct)
+					if ok {
+						dropboxContext.rotateCredentials(backendConfigDropboxAsStruct)
+						globals.logger.Printf("[INFO] rotated Dropbox credentials of backends[\"%s\"]", dirName)
+					}
+				}
+			case "S3":
+				backendConfigS3AsStruct = backendAsStructNew.backendTypeSpecifics.(*backendConfigS3Struct)
+				if (backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).accessKeyID != backendConfigS3AsStruct.accessKeyID) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).secretAccessKey != backendConfigS3AsStruct.secretAccessKey) ||
+					(backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).sessionToken != backendConfigS3AsStruct.sessionToken) ||
+					!backendAsStructOld.backendTypeSpecifics.(*backendConfigS3Struct).sessionTokenExpiry.Equal(backendConfigS3AsStruct.sessionTokenExpiry) {
+					s3Context, ok := backendAsStructOld.context.(*s3ContextStruct)
+					if ok {
+						s3Context.rotateCredentials(backendConfigS3AsStruct)
+						globals.logger.Printf("[INFO] rotated S3 credentials of backends[\"%s\"]", dirName)
+					}
+				}
+			}
+		}
 	}
 
 	// All done
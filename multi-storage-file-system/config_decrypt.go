@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// Environment variables (shared with the sops CLI) locating the age identities with
+// which encrypted config-file values are decrypted.
+const (
+	EnvSOPSAgeKey     = "SOPS_AGE_KEY"      // Identities themselves (one per line)
+	EnvSOPSAgeKeyFile = "SOPS_AGE_KEY_FILE" // File holding identities (default: ${XDG_CONFIG_HOME:-${HOME}/.config}/sops/age/keys.txt)
+)
+
+// `ageArmorHeader` begins an ASCII-armored age ciphertext.
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// `sopsEncryptedValueRegexp` matches a value encrypted by sops, capturing its data, iv, tag, & type.
+var sopsEncryptedValueRegexp = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// `configDecryptStruct` holds the state of decrypting the values of a config-file.
+type configDecryptStruct struct {
+	configFilePath string         //
+	sopsDataKey    []byte         // If the config-file was encrypted by sops, the key decrypting each ENC[AES256_GCM,...] value
+	ageIdentities  []age.Identity // Loaded upon first need
+}
+
+// `decryptConfigFileMap` decrypts, in place, each encrypted value in the (just parsed)
+// configFileMap of the config-file (or a file it includes) at configFilePath. Values
+// may be encrypted individually with age (i.e. an ASCII-armored age ciphertext) or the
+// file may have been encrypted by sops (using either age or AWS KMS keys), in which
+// case its "sops" metadata section is removed. Note that sops' MAC covering the file's
+// unencrypted values is not verified (each encrypted value remains authenticated).
+func decryptConfigFileMap(configFilePath string, configFileMap map[string]interface{}) (err error) {
+	var (
+		configDecrypt = &configDecryptStruct{configFilePath: configFilePath}
+		key           string
+		ok            bool
+		sopsAsMap     map[string]interface{}
+		value         interface{}
+	)
+
+	value, ok = configFileMap["sops"]
+	if ok {
+		sopsAsMap, ok = value.(map[string]interface{})
+		if !ok {
+			err = fmt.Errorf("bad sops metadata in config-file \"%s\"", configFilePath)
+			return
+		}
+
+		configDecrypt.sopsDataKey, err = configDecrypt.fetchSOPSDataKey(sopsAsMap)
+		if err != nil {
+			err = fmt.Errorf("unable to fetch sops data key of config-file \"%s\": %v", configFilePath, err)
+			return
+		}
+
+		delete(configFileMap, "sops")
+	}
+
+	for key, value = range configFileMap {
+		configFileMap[key], err = configDecrypt.decryptValue(value, []string{key})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `decryptValue` returns value (found at path) with any encrypted values it contains decrypted.
+func (configDecrypt *configDecryptStruct) decryptValue(value interface{}, path []string) (decrypted interface{}, err error) {
+	var (
+		element interface{}
+		index   int
+		key     string
+	)
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, element = range value {
+			value[key], err = configDecrypt.decryptValue(element, append(path[:len(path):len(path)], key))
+			if err != nil {
+				return
+			}
+		}
+		decrypted = value
+	case []interface{}:
+		// As with sops, the elements of an array share the array's path
+
+		for index, element = range value {
+			value[index], err = configDecrypt.decryptValue(element, path)
+			if err != nil {
+				return
+			}
+		}
+		decrypted = value
+	case string:
+		switch {
+		case strings.HasPrefix(value, "ENC["):
+			decrypted, err = configDecrypt.decryptSOPSValue(value, path)
+		case strings.HasPrefix(strings.TrimSpace(value), ageArmorHeader):
+			decrypted, err = configDecrypt.decryptAgeValue(value)
+		default:
+			decrypted = value
+		}
+		if err != nil {
+			err = fmt.Errorf("unable to decrypt %s in config-file \"%s\": %v", strings.Join(path, "."), configDecrypt.configFilePath, err)
+		}
+	default:
+		decrypted = value
+	}
+
+	return
+}
+
+// `decryptSOPSValue` decrypts a value encrypted by sops (found at path). As sops binds each
+// value to its path (via the GCM additional data), a value moved elsewhere fails to decrypt.
+func (configDecrypt *configDecryptStruct) decryptSOPSValue(value string, path []string) (decrypted interface{}, err error) {
+	var (
+		aesBlock  cipher.Block
+		aesGCM    cipher.AEAD
+		data      []byte
+		iv        []byte
+		matches   []string
+		plaintext []byte
+		tag       []byte
+	)
+
+	if configDecrypt.sopsDataKey == nil {
+		err = errors.New("ENC[] value found but config-file has no sops metadata")
+		return
+	}
+
+	matches = sopsEncryptedValueRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		err = errors.New("malformed ENC[] value")
+		return
+	}
+
+	data, err = base64.StdEncoding.DecodeString(matches[1])
+	if err == nil {
+		iv, err = base64.StdEncoding.DecodeString(matches[2])
+	}
+	if err == nil {
+		tag, err = base64.StdEncoding.DecodeString(matches[3])
+	}
+	if err != nil {
+		err = fmt.Errorf("malformed ENC[] value: %v", err)
+		return
+	}
+
+	aesBlock, err = aes.NewCipher(configDecrypt.sopsDataKey)
+	if err != nil {
+		return
+	}
+	aesGCM, err = cipher.NewGCMWithNonceSize(aesBlock, len(iv))
+	if err != nil {
+		return
+	}
+
+	plaintext, err = aesGCM.Open(nil, iv, append(data, tag...), []byte(strings.Join(path, ":")+":"))
+	if err != nil {
+		return
+	}
+
+	switch matches[4] {
+	case "str", "bytes":
+		decrypted = string(plaintext)
+	case "int":
+		decrypted, err = strconv.Atoi(string(plaintext))
+	case "float":
+		decrypted, err = strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		decrypted, err = strconv.ParseBool(string(plaintext))
+	default:
+		err = fmt.Errorf("unsupported ENC[] value type \"%s\"", matches[4])
+	}
+
+	return
+}
+
+// `decryptAgeValue` decrypts an individually encrypted (ASCII-armored) age ciphertext.
+func (configDecrypt *configDecryptStruct) decryptAgeValue(value string) (decrypted interface{}, err error) {
+	var (
+		plaintext []byte
+	)
+
+	plaintext, err = configDecrypt.decryptAge(value)
+	if err != nil {
+		return
+	}
+
+	decrypted = string(plaintext)
+
+	return
+}
+
+// `decryptAge` decrypts an ASCII-armored age ciphertext with the age identities found
+// via ${SOPS_AGE_KEY} and/or ${SOPS_AGE_KEY_FILE}.
+func (configDecrypt *configDecryptStruct) decryptAge(ciphertext string) (plaintext []byte, err error) {
+	var (
+		plaintextReader io.Reader
+	)
+
+	if configDecrypt.ageIdentities == nil {
+		configDecrypt.ageIdentities, err = loadAgeIdentities()
+		if err != nil {
+			return
+		}
+	}
+
+	plaintextReader, err = age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(ciphertext))), configDecrypt.ageIdentities...)
+	if err != nil {
+		return
+	}
+
+	plaintext, err = io.ReadAll(plaintextReader)
+
+	return
+}
+
+// `loadAgeIdentities` returns the age identities held by ${SOPS_AGE_KEY} and in the
+// file at ${SOPS_AGE_KEY_FILE} (or, if unset, its default location should it exist).
+func loadAgeIdentities() (ageIdentities []age.Identity, err error) {
+	var (
+		ageIdentitiesFromFile []age.Identity
+		ageKey                = os.Getenv(EnvSOPSAgeKey)
+		ageKeyFile            = os.Getenv(EnvSOPSAgeKeyFile)
+		ageKeyFileContent     []byte
+		xdgConfigHome         = os.Getenv("XDG_CONFIG_HOME")
+	)
+
+	if ageKey != "" {
+		ageIdentities, err = age.ParseIdentities(strings.NewReader(ageKey))
+		if err != nil {
+			err = fmt.Errorf("bad %s: %v", EnvSOPSAgeKey, err)
+			return
+		}
+	}
+
+	if ageKeyFile == "" {
+		if xdgConfigHome == "" {
+			xdgConfigHome = filepath.Join(os.Getenv("HOME"), ".config")
+		}
+		ageKeyFile = filepath.Join(xdgConfigHome, "sops", "age", "keys.txt")
+		if !checkForFile(ageKeyFile) {
+			ageKeyFile = ""
+		}
+	}
+
+	if ageKeyFile != "" {
+		ageKeyFileContent, err = os.ReadFile(ageKeyFile)
+		if err != nil {
+			err = fmt.Errorf("unable to read age identities: %v", err)
+			return
+		}
+		ageIdentitiesFromFile, err = age.ParseIdentities(bytes.NewReader(ageKeyFileContent))
+		if err != nil {
+			err = fmt.Errorf("bad age identities in \"%s\": %v", ageKeyFile, err)
+			return
+		}
+		ageIdentities = append(ageIdentities, ageIdentitiesFromFile...)
+	}
+
+	if len(ageIdentities) == 0 {
+		err = fmt.Errorf("no age identities found (set %s or %s)", EnvSOPSAgeKey, EnvSOPSAgeKeyFile)
+	}
+
+	return
+}
+
+// `fetchSOPSDataKey` returns the data key of a config-file encrypted by sops by decrypting
+// whichever of the age or AWS KMS encrypted copies listed in its sops metadata first succeeds.
+func (configDecrypt *configDecryptStruct) fetchSOPSDataKey(sopsAsMap map[string]interface{}) (dataKey []byte, err error) {
+	var (
+		ageEntries []interface{}
+		entry      interface{}
+		entryAsMap map[string]interface{}
+		errs       []error
+		kmsEntries []interface{}
+		ok         bool
+	)
+
+	_, ok = sopsAsMap["key_groups"]
+	if ok {
+		err = errors.New("sops key_groups (i.e. Shamir secret sharing) not supported")
+		return
+	}
+
+	ageEntries, _ = sopsAsMap["age"].([]interface{})
+	kmsEntries, _ = sopsAsMap["kms"].([]interface{})
+
+	if (len(ageEntries) == 0) && (len(kmsEntries) == 0) {
+		err = errors.New("sops metadata lists no age or kms keys (others are not supported)")
+		return
+	}
+
+	for _, entry = range ageEntries {
+		entryAsMap, ok = entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dataKey, err = configDecrypt.decryptAge(fmt.Sprint(entryAsMap["enc"]))
+		if err == nil {
+			return
+		}
+		errs = append(errs, fmt.Errorf("age recipient %v: %v", entryAsMap["recipient"], err))
+	}
+
+	for _, entry = range kmsEntries {
+		entryAsMap, ok = entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dataKey, err = decryptKMSDataKey(entryAsMap)
+		if err == nil {
+			return
+		}
+		errs = append(errs, fmt.Errorf("kms key %v: %v", entryAsMap["arn"], err))
+	}
+
+	err = errors.Join(errs...)
+
+	return
+}
+
+// `decryptKMSDataKey` decrypts the copy of a sops data key encrypted by the AWS KMS key
+// described by kmsEntry (in the key's region and using the credentials located by the
+// AWS SDK's default chain).
+func decryptKMSDataKey(kmsEntry map[string]interface{}) (dataKey []byte, err error) {
+	var (
+		awsConfig         aws.Config
+		ciphertextBlob    []byte
+		contextAsMap      map[string]interface{}
+		decryptOutput     *kms.DecryptOutput
+		encryptionContext map[string]string
+		key               string
+		keyARN            arn.ARN
+		ok                bool
+		value             interface{}
+	)
+
+	keyARN, err = arn.Parse(fmt.Sprint(kmsEntry["arn"]))
+	if err != nil {
+		return
+	}
+
+	ciphertextBlob, err = base64.StdEncoding.DecodeString(fmt.Sprint(kmsEntry["enc"]))
+	if err != nil {
+		return
+	}
+
+	contextAsMap, _ = kmsEntry["context"].(map[string]interface{})
+	if len(contextAsMap) != 0 {
+		encryptionContext = make(map[string]string, len(contextAsMap))
+		for key, value = range contextAsMap {
+			encryptionContext[key], ok = value.(string)
+			if !ok {
+				err = fmt.Errorf("bad context \"%s\"", key)
+				return
+			}
+		}
+	}
+
+	awsConfig, err = config.LoadDefaultConfig(context.Background(), config.WithRegion(keyARN.Region), config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(secretsHTTPTimeout)))
+	if err != nil {
+		err = fmt.Errorf("config.LoadDefaultConfig() failed: %v", err)
+		return
+	}
+
+	decryptOutput, err = kms.NewFromConfig(awsConfig).Decrypt(context.Background(), &kms.DecryptInput{
+		CiphertextBlob:    ciphertextBlob,
+		KeyId:             aws.String(keyARN.String()),
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return
+	}
+
+	dataKey = decryptOutput.Plaintext
+
+	return
+}
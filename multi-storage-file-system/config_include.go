@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// `configFileLayerStruct` holds one of the files making up the config-file: either the
+// config-file itself or one of the files it (transitively) includes.
+type configFileLayerStruct struct {
+	path          string                 //
+	content       []byte                 //
+	configFileMap map[string]interface{} // As parsed from content (still including any "include" key)
+}
+
+// `parseConfigFileContent` parses the content of the config-file (or a file it includes)
+// at configFilePath according to its extension (i.e. ".json", ".yaml", ".yml", or ".toml")
+// and decrypts any encrypted values (see decryptConfigFileMap()).
+func parseConfigFileContent(configFilePath string, configFileContent []byte) (configFileMap map[string]interface{}, err error) {
+	var (
+		configFilePathExt = filepath.Ext(configFilePath)
+	)
+
+	configFileMap = make(map[string]interface{})
+
+	switch configFilePathExt {
+	case ".json":
+		err = json.Unmarshal(configFileContent, &configFileMap)
+		if err != nil {
+			err = fmt.Errorf("unable to parse config-file \"%s\" as JSON (err: %v)", configFilePath, jsonSyntaxErrorPosition(configFileContent, err))
+			return
+		}
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(configFileContent, &configFileMap)
+		if err != nil {
+			err = fmt.Errorf("unable to parse config-file \"%s\" as YAML (err: %v)", configFilePath, err)
+			return
+		}
+	case ".toml":
+		err = toml.Unmarshal(configFileContent, &configFileMap)
+		if err != nil {
+			err = fmt.Errorf("unable to parse config-file \"%s\" as TOML (err: %v)", configFilePath, tomlErrorPosition(configFileContent, err))
+			return
+		}
+		configFileMap = normalizeTOMLValue(configFileMap).(map[string]interface{})
+	default:
+		err = fmt.Errorf("unsupported extension (\"%s\") in config-file \"%s\" - must be one of \".json\", \".yaml\", or \".toml\"", configFilePathExt, configFilePath)
+		return
+	}
+
+	if configFileMap == nil {
+		// An empty (e.g. YAML) file parses as nil
+
+		configFileMap = make(map[string]interface{})
+	}
+
+	err = decryptConfigFileMap(configFilePath, configFileMap)
+
+	return
+}
+
+// `loadConfigFileLayers` returns the layers making up the config-file (or a file it
+// includes) at configFilePath in the order they are to be merged: each file matched
+// by each glob pattern of its "include" setting (in the order listed and, for each
+// pattern, in lexical order of the matched paths) preceded by whatever that file
+// itself includes, followed finally by the file at configFilePath. Relative patterns
+// are relative to the directory containing the including file. The set of files in
+// the process of being loaded is tracked in includeStack so that cycles are rejected.
+func loadConfigFileLayers(configFilePath string, configFileContent []byte, configFileMap map[string]interface{}, includeStack map[string]struct{}) (configFileLayers []*configFileLayerStruct, err error) {
+	var (
+		configFileLayersIncluded []*configFileLayerStruct
+		includeContent           []byte
+		includeMap               map[string]interface{}
+		includePath              string
+		includePaths             []string
+		includePattern           string
+		includePatterns          []string
+		ok                       bool
+		stackPath                string
+	)
+
+	stackPath, err = filepath.Abs(configFilePath)
+	if err != nil {
+		err = fmt.Errorf("unable to resolve config-file \"%s\": %v", configFilePath, err)
+		return
+	}
+	_, ok = includeStack[stackPath]
+	if ok {
+		err = fmt.Errorf("config-file \"%s\" (transitively) includes itself", configFilePath)
+		return
+	}
+	includeStack[stackPath] = struct{}{}
+	defer delete(includeStack, stackPath)
+
+	includePatterns, ok = parseStringSlice(configFileMap, "include", []string{})
+	if !ok {
+		err = fmt.Errorf("bad include value in config-file \"%s\"", configFilePath)
+		return
+	}
+
+	for _, includePattern = range includePatterns {
+		if !filepath.IsAbs(includePattern) {
+			includePattern = filepath.Join(filepath.Dir(configFilePath), includePattern)
+		}
+
+		includePaths, err = filepath.Glob(includePattern)
+		if err != nil {
+			err = fmt.Errorf("bad include pattern \"%s\" in config-file \"%s\": %v", includePattern, configFilePath, err)
+			return
+		}
+		if (len(includePaths) == 0) && !hasGlobMeta(includePattern) {
+			err = fmt.Errorf("include \"%s\" in config-file \"%s\" not found", includePattern, configFilePath)
+			return
+		}
+
+		slices.Sort(includePaths)
+
+		for _, includePath = range includePaths {
+			includeContent, err = os.ReadFile(includePath)
+			if err != nil {
+				err = fmt.Errorf("unable to read config-file \"%s\" included by \"%s\": %v", includePath, configFilePath, err)
+				return
+			}
+
+			includeMap, err = parseConfigFileContent(includePath, includeContent)
+			if err != nil {
+				return
+			}
+
+			configFileLayersIncluded, err = loadConfigFileLayers(includePath, includeContent, includeMap, includeStack)
+			if err != nil {
+				return
+			}
+
+			configFileLayers = append(configFileLayers, configFileLayersIncluded...)
+		}
+	}
+
+	configFileLayers = append(configFileLayers, &configFileLayerStruct{
+		path:          configFilePath,
+		content:       configFileContent,
+		configFileMap: configFileMap,
+	})
+
+	return
+}
+
+// `loadConfigFileDefaultLayers` returns the layers making up each of the discovered
+// config-files at configFileDefaultPaths (each preceded by whatever it includes) in
+// the order they are to be merged beneath the config-file itself.
+func loadConfigFileDefaultLayers(configFileDefaultPaths []string) (configFileLayers []*configFileLayerStruct, err error) {
+	var (
+		configFileDefaultContent []byte
+		configFileDefaultLayers  []*configFileLayerStruct
+		configFileDefaultMap     map[string]interface{}
+		configFileDefaultPath    string
+	)
+
+	for _, configFileDefaultPath = range configFileDefaultPaths {
+		configFileDefaultContent, err = os.ReadFile(configFileDefaultPath)
+		if err != nil {
+			err = fmt.Errorf("unable to read config-file defaults \"%s\": %v", configFileDefaultPath, err)
+			return
+		}
+
+		configFileDefaultMap, err = parseConfigFileContent(configFileDefaultPath, configFileDefaultContent)
+		if err != nil {
+			return
+		}
+
+		configFileDefaultLayers, err = loadConfigFileLayers(configFileDefaultPath, configFileDefaultContent, configFileDefaultMap, make(map[string]struct{}))
+		if err != nil {
+			return
+		}
+
+		configFileLayers = append(configFileLayers, configFileDefaultLayers...)
+	}
+
+	return
+}
+
+// `hasGlobMeta` reports whether pattern contains any of the special characters
+// recognized by filepath.Match().
+func hasGlobMeta(pattern string) bool {
+	return slices.ContainsFunc([]rune(pattern), func(r rune) bool {
+		return (r == '*') || (r == '?') || (r == '[') || (r == '\\')
+	})
+}
+
+// `mergeConfigFileLayers` merges configFileLayers in order such that each layer's
+// settings override those of the layers before it. Nested sections are merged key
+// by key, and the elements of "backends" are merged by dir_name (with backends
+// not found in an earlier layer appended). Any other value (including any other
+// array) simply replaces that of an earlier layer. Each "include" key is dropped.
+func mergeConfigFileLayers(configFileLayers []*configFileLayerStruct) (configFileMap map[string]interface{}) {
+	var (
+		configFileLayer *configFileLayerStruct
+	)
+
+	configFileMap = make(map[string]interface{})
+
+	for _, configFileLayer = range configFileLayers {
+		configFileMap = mergeConfigFileValue(configFileMap, configFileLayer.configFileMap, "").(map[string]interface{})
+	}
+
+	delete(configFileMap, "include")
+
+	return
+}
+
+// `mergeConfigFileValue` returns the result of merging overlay atop base (each found at
+// path in their respective layers). Neither base nor overlay is modified.
+func mergeConfigFileValue(base interface{}, overlay interface{}, path string) (merged interface{}) {
+	var (
+		baseAsMap      map[string]interface{}
+		baseAsSlice    []interface{}
+		baseElement    interface{}
+		baseIndex      int
+		dirName        string
+		dirNameIndex   map[string]int
+		key            string
+		mergedAsMap    map[string]interface{}
+		mergedAsSlice  []interface{}
+		ok             bool
+		overlayAsMap   map[string]interface{}
+		overlayAsSlice []interface{}
+		overlayElement interface{}
+		overlayValue   interface{}
+	)
+
+	overlayAsMap, ok = overlay.(map[string]interface{})
+	if ok {
+		baseAsMap, ok = base.(map[string]interface{})
+		if !ok {
+			merged = overlay
+			return
+		}
+
+		mergedAsMap = make(map[string]interface{}, len(baseAsMap)+len(overlayAsMap))
+		for key, baseElement = range baseAsMap {
+			mergedAsMap[key] = baseElement
+		}
+		for key, overlayValue = range overlayAsMap {
+			baseElement, ok = mergedAsMap[key]
+			if ok {
+				mergedAsMap[key] = mergeConfigFileValue(baseElement, overlayValue, path+"."+key)
+			} else {
+				mergedAsMap[key] = overlayValue
+			}
+		}
+
+		merged = mergedAsMap
+		return
+	}
+
+	overlayAsSlice, ok = overlay.([]interface{})
+	if ok && (path == ".backends") {
+		baseAsSlice, ok = base.([]interface{})
+		if !ok {
+			merged = overlay
+			return
+		}
+
+		mergedAsSlice = slices.Clone(baseAsSlice)
+
+		dirNameIndex = make(map[string]int, len(mergedAsSlice))
+		for baseIndex, baseElement = range mergedAsSlice {
+			dirName, ok = configFileBackendDirName(baseElement)
+			if ok {
+				dirNameIndex[dirName] = baseIndex
+			}
+		}
+
+		for _, overlayElement = range overlayAsSlice {
+			dirName, ok = configFileBackendDirName(overlayElement)
+			if !ok {
+				mergedAsSlice = append(mergedAsSlice, overlayElement)
+				continue
+			}
+
+			baseIndex, ok = dirNameIndex[dirName]
+			if ok {
+				mergedAsSlice[baseIndex] = mergeConfigFileValue(mergedAsSlice[baseIndex], overlayElement, path+"[]")
+			} else {
+				dirNameIndex[dirName] = len(mergedAsSlice)
+				mergedAsSlice = append(mergedAsSlice, overlayElement)
+			}
+		}
+
+		merged = mergedAsSlice
+		return
+	}
+
+	merged = overlay
+
+	return
+}
+
+// `configFileBackendDirName` returns the dir_name of a (yet to be parsed) element of "backends".
+func configFileBackendDirName(backend interface{}) (dirName string, ok bool) {
+	var (
+		backendAsMap map[string]interface{}
+	)
+
+	backendAsMap, ok = backend.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	dirName, ok = parseString(backendAsMap, "dir_name", nil)
+
+	return
+}
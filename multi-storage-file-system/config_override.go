@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// `configOverrideStruct` describes a setting supplied on the command line (via
+// {-set|--set} <key>=<value>) overriding that of the config-file.
+type configOverrideStruct struct {
+	key   string      // Dot-separated path (e.g. "cache_lines" or "backends.s3.S3.endpoint")
+	value interface{} // Parsed as a YAML (flow) value (e.g. 100, false, "text", or [a, b])
+}
+
+// `extractConfigOverrides` removes each {-set|--set} <key>=<value> (or {-set|--set}=<key>=<value>)
+// from osArgs returning the remaining arguments and the overrides in the order supplied.
+func extractConfigOverrides(osArgs []string) (osArgsRemaining []string, configOverrides []configOverrideStruct, err error) {
+	var (
+		configOverride configOverrideStruct
+		osArg          string
+		osArgIndex     int
+		setting        string
+	)
+
+	osArgsRemaining = make([]string, 0, len(osArgs))
+
+	for osArgIndex = 0; osArgIndex < len(osArgs); osArgIndex++ {
+		osArg = osArgs[osArgIndex]
+
+		switch {
+		case (osArgIndex > 0) && ((osArg == "-set") || (osArg == "--set")):
+			osArgIndex++
+			if osArgIndex == len(osArgs) {
+				err = fmt.Errorf("missing <key>=<value> following %s", osArg)
+				return
+			}
+			setting = osArgs[osArgIndex]
+		case (osArgIndex > 0) && (strings.HasPrefix(osArg, "-set=") || strings.HasPrefix(osArg, "--set=")):
+			_, setting, _ = strings.Cut(osArg, "=")
+		default:
+			osArgsRemaining = append(osArgsRemaining, osArg)
+			continue
+		}
+
+		configOverride, err = parseConfigOverride(setting)
+		if err != nil {
+			return
+		}
+
+		configOverrides = append(configOverrides, configOverride)
+	}
+
+	return
+}
+
+// `parseConfigOverride` parses a <key>=<value> setting supplied on the command line.
+func parseConfigOverride(setting string) (configOverride configOverrideStruct, err error) {
+	var (
+		ok    bool
+		value string
+	)
+
+	configOverride.key, value, ok = strings.Cut(setting, "=")
+	if !ok || (configOverride.key == "") || strings.Contains("."+configOverride.key+".", "..") {
+		err = fmt.Errorf("bad --set \"%s\" (must be of the form <key>=<value>)", setting)
+		return
+	}
+
+	if value == "" {
+		configOverride.value = ""
+		return
+	}
+
+	err = yaml.Unmarshal([]byte(value), &configOverride.value)
+	if err != nil {
+		err = fmt.Errorf("bad --set \"%s\" value: %v", setting, err)
+		return
+	}
+
+	return
+}
+
+// `applyConfigOverrides` applies each of configOverrides (in order) to configFileMap. Each
+// element of a key's path selects a key of a section or, for an array, either an index or
+// (e.g. for backends) the element whose dir_name matches. Missing sections are created.
+// As configFileMap may share sections with the layers it was merged from, the caller
+// should supply a copy (see cloneConfigFileValue()).
+func applyConfigOverrides(configFileMap map[string]interface{}, configOverrides []configOverrideStruct) (err error) {
+	var (
+		configOverride configOverrideStruct
+		element        interface{}
+		elementIndex   int
+		elements       []interface{}
+		found          bool
+		keyElement     string
+		keyElements    []string
+		keyIndex       int
+		ok             bool
+		parent         interface{}
+		section        map[string]interface{}
+	)
+
+	for _, configOverride = range configOverrides {
+		keyElements = strings.Split(configOverride.key, ".")
+		parent = configFileMap
+
+		for keyIndex, keyElement = range keyElements {
+			switch parentAsType := parent.(type) {
+			case map[string]interface{}:
+				if keyIndex == (len(keyElements) - 1) {
+					parentAsType[keyElement] = configOverride.value
+					continue
+				}
+
+				element, ok = parentAsType[keyElement]
+				if !ok || (element == nil) {
+					section = make(map[string]interface{})
+					parentAsType[keyElement] = section
+					element = section
+				}
+
+				parent = element
+			case []interface{}:
+				elements = parentAsType
+
+				elementIndex, err = strconv.Atoi(keyElement)
+				if err == nil {
+					found = (elementIndex >= 0) && (elementIndex < len(elements))
+				} else {
+					err = nil
+					found = false
+					for elementIndex, element = range elements {
+						section, ok = element.(map[string]interface{})
+						if ok && (section["dir_name"] == keyElement) {
+							found = true
+							break
+						}
+					}
+				}
+				if !found {
+					err = fmt.Errorf("bad --set \"%s\": no element \"%s\" in %s", configOverride.key, keyElement, strings.Join(keyElements[:keyIndex], "."))
+					return
+				}
+
+				if keyIndex == (len(keyElements) - 1) {
+					elements[elementIndex] = configOverride.value
+					continue
+				}
+
+				parent = elements[elementIndex]
+			default:
+				err = fmt.Errorf("bad --set \"%s\": %s is neither a section nor an array", configOverride.key, strings.Join(keyElements[:keyIndex], "."))
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// `cloneConfigFileValue` returns a deep copy of the sections and arrays of value.
+func cloneConfigFileValue(value interface{}) (clone interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		cloneAsMap := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			cloneAsMap[key] = cloneConfigFileValue(element)
+		}
+		clone = cloneAsMap
+	case []interface{}:
+		cloneAsSlice := make([]interface{}, len(value))
+		for index, element := range value {
+			cloneAsSlice[index] = cloneConfigFileValue(element)
+		}
+		clone = cloneAsSlice
+	default:
+		clone = value
+	}
+
+	return
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// `extractConfigProfile` removes the (last) {-profile|--profile} <name> (or {-profile|--profile}=<name>)
+// from osArgs returning the remaining arguments and <name> (or "" if not supplied).
+func extractConfigProfile(osArgs []string) (osArgsRemaining []string, configProfile string, err error) {
+	var (
+		osArg      string
+		osArgIndex int
+	)
+
+	osArgsRemaining = make([]string, 0, len(osArgs))
+
+	for osArgIndex = 0; osArgIndex < len(osArgs); osArgIndex++ {
+		osArg = osArgs[osArgIndex]
+
+		switch {
+		case (osArgIndex > 0) && ((osArg == "-profile") || (osArg == "--profile")):
+			osArgIndex++
+			if osArgIndex == len(osArgs) {
+				err = fmt.Errorf("missing <name> following %s", osArg)
+				return
+			}
+			configProfile = osArgs[osArgIndex]
+		case (osArgIndex > 0) && (strings.HasPrefix(osArg, "-profile=") || strings.HasPrefix(osArg, "--profile=")):
+			_, configProfile, _ = strings.Cut(osArg, "=")
+		default:
+			osArgsRemaining = append(osArgsRemaining, osArg)
+			continue
+		}
+
+		if configProfile == "" {
+			err = fmt.Errorf("empty <name> following %s", osArg)
+			return
+		}
+	}
+
+	return
+}
+
+// `applyConfigProfile` returns configFileMap with the settings of the msfs_profiles entry
+// named configProfile (if non-empty) merged atop it just as an included file's settings
+// would be (see mergeConfigFileLayers()). The msfs_profiles key itself is dropped.
+func applyConfigProfile(configFileMap map[string]interface{}, configProfile string) (configFileMapWithProfile map[string]interface{}, err error) {
+	var (
+		configFileMapSansProfile map[string]interface{}
+		key                      string
+		name                     string
+		names                    []string
+		ok                       bool
+		profileAsInterface       interface{}
+		profileAsMap             map[string]interface{}
+		profilesAsInterface      interface{}
+		profilesAsMap            map[string]interface{}
+		value                    interface{}
+	)
+
+	profilesAsInterface, ok = configFileMap["msfs_profiles"]
+	if ok && (profilesAsInterface != nil) {
+		profilesAsMap, ok = profilesAsInterface.(map[string]interface{})
+		if !ok {
+			err = errors.New("bad msfs_profiles section")
+			return
+		}
+	}
+
+	configFileMapSansProfile = make(map[string]interface{}, len(configFileMap))
+	for key, value = range configFileMap {
+		if key != "msfs_profiles" {
+			configFileMapSansProfile[key] = value
+		}
+	}
+
+	if configProfile == "" {
+		configFileMapWithProfile = configFileMapSansProfile
+		return
+	}
+
+	profileAsInterface, ok = profilesAsMap[configProfile]
+	if !ok {
+		names = make([]string, 0, len(profilesAsMap))
+		for name = range profilesAsMap {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		err = fmt.Errorf("profile \"%s\" not found in msfs_profiles (available: [%s])", configProfile, strings.Join(names, ", "))
+		return
+	}
+	if profileAsInterface == nil {
+		configFileMapWithProfile = configFileMapSansProfile
+		return
+	}
+
+	profileAsMap, ok = profileAsInterface.(map[string]interface{})
+	if !ok {
+		err = fmt.Errorf("bad msfs_profiles.%s section", configProfile)
+		return
+	}
+
+	configFileMapWithProfile = mergeConfigFileValue(configFileMapSansProfile, profileAsMap, "").(map[string]interface{})
+
+	return
+}
@@ -0,0 +1,749 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configSchemaKindAny     = "any"     // Any value (e.g. a section whose contents are passed along verbatim)
+	configSchemaKindArray   = "array"   //
+	configSchemaKindBoolean = "boolean" //
+	configSchemaKindInteger = "integer" // A non-negative whole number
+	configSchemaKindMap     = "map"     // An object whose (arbitrary) keys each map to a value described by items
+	configSchemaKindNumber  = "number"  //
+	configSchemaKindObject  = "object"  //
+	configSchemaKindString  = "string"  //
+)
+
+// `configSchemaNodeStruct` describes the permitted content of a value in a (msfs_version 1)
+// config-file. Note that the semantic checks (e.g. ranges and cross-references) remain
+// the responsibility of checkConfigFile().
+type configSchemaNodeStruct struct {
+	kind       string                             // One of configSchemaKind*
+	enum       []string                           // If kind == configSchemaKindString && len(enum) != 0, the permitted values
+	properties map[string]*configSchemaNodeStruct // If kind == configSchemaKindObject, the permitted keys
+	items      *configSchemaNodeStruct            // If kind == configSchemaKindArray or configSchemaKindMap, describes each element
+}
+
+// `configSchemaPositionStruct` locates a key (or value) within a config-file.
+type configSchemaPositionStruct struct {
+	line   int // Starting at 1
+	column int // Starting at 1
+}
+
+func configSchemaValue(kind string) *configSchemaNodeStruct {
+	return &configSchemaNodeStruct{kind: kind}
+}
+
+func configSchemaEnum(enum ...string) *configSchemaNodeStruct {
+	return &configSchemaNodeStruct{kind: configSchemaKindString, enum: enum}
+}
+
+func configSchemaObject(properties map[string]*configSchemaNodeStruct) *configSchemaNodeStruct {
+	return &configSchemaNodeStruct{kind: configSchemaKindObject, properties: properties}
+}
+
+func configSchemaArray(items *configSchemaNodeStruct) *configSchemaNodeStruct {
+	return &configSchemaNodeStruct{kind: configSchemaKindArray, items: items}
+}
+
+func configSchemaMap(items *configSchemaNodeStruct) *configSchemaNodeStruct {
+	return &configSchemaNodeStruct{kind: configSchemaKindMap, items: items}
+}
+
+var (
+	configSchemaAny     = configSchemaValue(configSchemaKindAny)
+	configSchemaBoolean = configSchemaValue(configSchemaKindBoolean)
+	configSchemaInteger = configSchemaValue(configSchemaKindInteger)
+	configSchemaNumber  = configSchemaValue(configSchemaKindNumber)
+	configSchemaString  = configSchemaValue(configSchemaKindString)
+
+	configSchemaStringSlice = configSchemaArray(configSchemaString)
+	configSchemaUint64Slice = configSchemaArray(configSchemaInteger)
+)
+
+// `configSchema` describes a msfs_version 1 config-file. Any key not described here is rejected.
+var configSchema = configSchemaObject(map[string]*configSchemaNodeStruct{
+	"msfs_version":                    configSchemaInteger,
+	"include":                         configSchemaStringSlice,
+	"mountname":                       configSchemaString,
+	"mountpoint":                      configSchemaString,
+	"uid":                             configSchemaInteger,
+	"gid":                             configSchemaInteger,
+	"dir_perm":                        configSchemaString,
+	"allow_other":                     configSchemaBoolean,
+	"max_write":                       configSchemaInteger,
+	"entry_attr_ttl":                  configSchemaInteger,
+	"evictable_inode_ttl":             configSchemaInteger,
+	"virtual_dir_ttl":                 configSchemaInteger,
+	"virtual_file_ttl":                configSchemaInteger,
+	"ttl_check_interval":              configSchemaInteger,
+	"dir_mtime_ttl":                   configSchemaInteger,
+	"cache_line_size":                 configSchemaInteger,
+	"cache_lines":                     configSchemaInteger,
+	"cache_lines_to_prefetch":         configSchemaInteger,
+	"direct_read_threshold":           configSchemaInteger,
+	"cache_line_ttl":                  configSchemaInteger,
+	"etag_conflict_policy":            configSchemaEnum("fail", "refetch", "last_writer_wins"),
+	"stat_on_open":                    configSchemaBoolean,
+	"dirty_cache_lines_flush_trigger": configSchemaInteger,
+	"dirty_cache_lines_max":           configSchemaInteger,
+	"auto_sighup_interval":            configSchemaInteger,
+	"endpoint":                        configSchemaString,
+	"migration_state_dir":             configSchemaString,
+	"max_concurrent_backend_requests": configSchemaInteger,
+	"retry_budget_ratio":              configSchemaNumber,
+	"retry_budget_min_per_second":     configSchemaNumber,
+	"retry_budget_burst":              configSchemaInteger,
+	"audit_log_file":                  configSchemaString,
+	"audit_log_max_size":              configSchemaInteger,
+	"audit_log_max_files":             configSchemaInteger,
+	"audit_log_ops":                   configSchemaArray(configSchemaEnum(auditLogOps...)),
+	"audit_log_sample_rate":           configSchemaNumber,
+	"audit_backend":                   configSchemaString,
+	"audit_prefix":                    configSchemaString,
+	"vault_address":                   configSchemaString,
+	"vault_token":                     configSchemaString,
+	"vault_token_file":                configSchemaString,
+	"vault_namespace":                 configSchemaString,
+	"aws_secrets_region":              configSchemaString,
+	"aws_secrets_endpoint":            configSchemaString,
+	"secrets_refresh_interval":        configSchemaInteger,
+	"log_format":                      configSchemaEnum("text", "json", "logfmt"),
+	"log_level":                       configSchemaEnum("trace", "debug", "info", "warn", "error", "fatal"),
+	"log_levels":                      configSchemaMap(configSchemaEnum("trace", "debug", "info", "warn", "error", "fatal")),
+	"log_level_file":                  configSchemaString,
+	"admin_socket":                    configSchemaString,
+	"slow_backend_request_threshold":  configSchemaInteger,
+	"slow_fuse_op_threshold":          configSchemaInteger,
+	"state_dump_dir":                  configSchemaString,
+	"webhook_urls":                    configSchemaArray(configSchemaString),
+	"webhook_events":                  configSchemaArray(configSchemaEnum(webhookEvents...)),
+	"webhook_timeout":                 configSchemaInteger,
+	"webhook_repeat_interval":         configSchemaInteger,
+	"shutdown_timeout":                configSchemaInteger,
+	"opentelemetry":                   configSchemaAny,
+	"backends":                        configSchemaArray(configSchemaBackend),
+})
+
+func init() {
+	var (
+		key               string
+		profileProperties = make(map[string]*configSchemaNodeStruct, len(configSchema.properties))
+		propertyNode      *configSchemaNodeStruct
+	)
+
+	// Each of msfs_profiles may specify any of the top-level settings other than those
+	// pertaining to the config-file as a whole
+
+	for key, propertyNode = range configSchema.properties {
+		switch key {
+		case "msfs_version", "include":
+		default:
+			profileProperties[key] = propertyNode
+		}
+	}
+
+	configSchema.properties["msfs_profiles"] = configSchemaMap(configSchemaObject(profileProperties))
+}
+
+// `configSchemaBackend` describes each element of the backends array.
+var configSchemaBackend = configSchemaObject(map[string]*configSchemaNodeStruct{
+	"dir_name":                       configSchemaString,
+	"readonly":                       configSchemaBoolean,
+	"flush_on_close":                 configSchemaBoolean,
+	"uid":                            configSchemaInteger,
+	"gid":                            configSchemaInteger,
+	"dir_perm":                       configSchemaString,
+	"file_perm":                      configSchemaString,
+	"directory_page_size":            configSchemaInteger,
+	"directory_markers":              configSchemaEnum("none", "slash", "folder"),
+	"multipart_cache_line_threshold": configSchemaInteger,
+	"upload_part_cache_lines":        configSchemaInteger,
+	"upload_part_concurrency":        configSchemaInteger,
+	"fetch_workers":                  configSchemaInteger,
+	"fetch_workers_adaptive":         configSchemaBoolean,
+	"fetch_latency_target":           configSchemaInteger,
+	"list_partitions":                configSchemaInteger,
+	"bucket_container_name":          configSchemaString,
+	"prefix":                         configSchemaString,
+	"trace_level":                    configSchemaInteger,
+	"http_max_idle_conns_per_host":   configSchemaInteger,
+	"http_max_conns_per_host":        configSchemaInteger,
+	"http_idle_conn_timeout":         configSchemaInteger,
+	"http_response_header_timeout":   configSchemaInteger,
+	"http_protocol":                  configSchemaEnum("auto", "http1", "http2"),
+	"http_keep_alive":                configSchemaInteger,
+	"mirror":                         configSchemaString,
+	"mirror_journal_file":            configSchemaString,
+	"mirror_reconcile_interval":      configSchemaInteger,
+	"tier_cold_backend":              configSchemaString,
+	"tier_location_map_file":         configSchemaString,
+	"tier_interval":                  configSchemaInteger,
+	"tier_min_age":                   configSchemaInteger,
+	"tier_max_access_count":          configSchemaInteger,
+	"tier_promote_access_count":      configSchemaInteger,
+	"tier_path_patterns":             configSchemaStringSlice,
+	"quotas": configSchemaArray(configSchemaObject(map[string]*configSchemaNodeStruct{
+		"prefix":    configSchemaString,
+		"max_bytes": configSchemaInteger,
+	})),
+	"quota_reconcile_interval": configSchemaInteger,
+	"priority":                 configSchemaString,
+	"priority_prefixes": configSchemaArray(configSchemaObject(map[string]*configSchemaNodeStruct{
+		"prefix":   configSchemaString,
+		"priority": configSchemaString,
+	})),
+	"health_check_interval":          configSchemaInteger,
+	"health_check_failure_threshold": configSchemaInteger,
+	"health_check_serve_stale":       configSchemaBoolean,
+	"multipart_upload_gc_interval":   configSchemaInteger,
+	"multipart_upload_max_age":       configSchemaInteger,
+	"access_rules": configSchemaArray(configSchemaObject(map[string]*configSchemaNodeStruct{
+		"prefix": configSchemaString,
+		"uids":   configSchemaUint64Slice,
+		"gids":   configSchemaUint64Slice,
+		"access": configSchemaString,
+	})),
+	"path_overrides": configSchemaArray(configSchemaObject(map[string]*configSchemaNodeStruct{
+		"prefix":                  configSchemaString,
+		"readonly":                configSchemaBoolean,
+		"cache_line_size":         configSchemaInteger,
+		"cache_lines_to_prefetch": configSchemaInteger,
+		"direct_read_threshold":   configSchemaInteger,
+		"cache_line_ttl":          configSchemaInteger,
+		"etag_conflict_policy":    configSchemaEnum("fail", "refetch", "last_writer_wins"),
+		"stat_on_open":            configSchemaBoolean,
+		"entry_attr_ttl":          configSchemaInteger,
+	})),
+	"snapshot_dir":           configSchemaString,
+	"replicas":               configSchemaStringSlice,
+	"replica_probe_interval": configSchemaInteger,
+	"replica_hedge_delay":    configSchemaInteger,
+	"backend_type":           configSchemaEnum("AIStore", "AzureFiles", "Dropbox", "RAM", "S3", "Sharded", "Snapshot"),
+	"AIStore": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"endpoint":                    configSchemaString,
+		"skip_tls_certificate_verify": configSchemaBoolean,
+		"authn_token":                 configSchemaString,
+		"authn_token_file":            configSchemaString,
+		"provider":                    configSchemaString,
+		"timeout":                     configSchemaInteger,
+		"authn_endpoint":              configSchemaString,
+		"authn_username":              configSchemaString,
+		"authn_password":              configSchemaString,
+		"etl_name":                    configSchemaString,
+		"etl_args":                    configSchemaString,
+		"blob_download_threshold":     configSchemaInteger,
+		"blob_download_chunk_size":    configSchemaInteger,
+		"blob_download_workers":       configSchemaInteger,
+		"direct_target_reads":         configSchemaBoolean,
+		"cluster_map_ttl":             configSchemaInteger,
+		"retry_max_attempts":          configSchemaInteger,
+		"retry_base_delay":            configSchemaInteger,
+		"retry_next_delay_multiplier": configSchemaNumber,
+		"retry_max_delay":             configSchemaInteger,
+		"namespace_uuid":              configSchemaString,
+		"namespace_name":              configSchemaString,
+		"props_cache_ttl":             configSchemaInteger,
+		"prefetch_listed_files":       configSchemaBoolean,
+	}),
+	"AzureFiles": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"account_name":                configSchemaString,
+		"account_key":                 configSchemaString,
+		"sas_token":                   configSchemaString,
+		"endpoint":                    configSchemaString,
+		"timeout":                     configSchemaInteger,
+		"retry_max_attempts":          configSchemaInteger,
+		"retry_base_delay":            configSchemaInteger,
+		"retry_next_delay_multiplier": configSchemaNumber,
+		"retry_max_delay":             configSchemaInteger,
+	}),
+	"Dropbox": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"access_token":                configSchemaString,
+		"refresh_token":               configSchemaString,
+		"app_key":                     configSchemaString,
+		"app_secret":                  configSchemaString,
+		"api_endpoint":                configSchemaString,
+		"content_endpoint":            configSchemaString,
+		"timeout":                     configSchemaInteger,
+		"retry_max_attempts":          configSchemaInteger,
+		"retry_base_delay":            configSchemaInteger,
+		"retry_next_delay_multiplier": configSchemaNumber,
+		"retry_max_delay":             configSchemaInteger,
+	}),
+	"RAM": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"max_total_objects":       configSchemaInteger,
+		"max_total_object_space":  configSchemaInteger,
+		"max_directory_page_size": configSchemaInteger,
+	}),
+	"S3": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"config_credentials_profile":   configSchemaString,
+		"use_config_env":               configSchemaBoolean,
+		"config_file_path":             configSchemaString,
+		"region":                       configSchemaString,
+		"endpoint":                     configSchemaString,
+		"dns_suffix":                   configSchemaString,
+		"use_credentials_env":          configSchemaBoolean,
+		"credentials_file_path":        configSchemaString,
+		"access_key_id":                configSchemaString,
+		"secret_access_key":            configSchemaString,
+		"session_token":                configSchemaString,
+		"session_token_expiry":         configSchemaString,
+		"credential_refresh_command":   configSchemaString,
+		"credential_refresh_endpoint":  configSchemaString,
+		"credential_refresh_window":    configSchemaInteger,
+		"skip_tls_certificate_verify":  configSchemaBoolean,
+		"virtual_hosted_style_request": configSchemaBoolean,
+		"unsigned_payload":             configSchemaBoolean,
+		"signature_version":            configSchemaEnum("v4", "v2"),
+		"expose_versions":              configSchemaBoolean,
+		"provider":                     configSchemaEnum("aws", "ceph", "gcs", "r2"),
+		"etag_validator":               configSchemaEnum("strong", "weak", "multipart"),
+		"conditional_requests":         configSchemaEnum("probe", "supported", "unsupported"),
+		"retry_mode":                   configSchemaEnum("standard", "adaptive"),
+		"retry_max_attempts":           configSchemaInteger,
+		"retry_base_delay":             configSchemaInteger,
+		"retry_next_delay_multiplier":  configSchemaNumber,
+		"retry_max_delay":              configSchemaInteger,
+		"retry_jitter":                 configSchemaEnum("none", "full", "equal"),
+		"retry_max_elapsed":            configSchemaInteger,
+		"retry_throttle_base_delay":    configSchemaInteger,
+		"retry_throttle_max_delay":     configSchemaInteger,
+		"retry_server_base_delay":      configSchemaInteger,
+		"retry_server_max_delay":       configSchemaInteger,
+		"retry_transport_base_delay":   configSchemaInteger,
+		"retry_transport_max_delay":    configSchemaInteger,
+	}),
+	"Sharded": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"backends":      configSchemaStringSlice,
+		"virtual_nodes": configSchemaInteger,
+	}),
+	"Snapshot": configSchemaObject(map[string]*configSchemaNodeStruct{
+		"backend": configSchemaString,
+		"name":    configSchemaString,
+	}),
+})
+
+// `validateConfigSchema` checks that value (decoded from a config-file) conforms to node
+// returning an error identifying (by path, e.g. `backends[1].S3.region`) the first value
+// found not to. If positionOf != nil, it is consulted to add the line & column of that value.
+func validateConfigSchema(value interface{}, node *configSchemaNodeStruct, path string, positionOf func(path string) (position configSchemaPositionStruct, ok bool)) (err error) {
+	var (
+		arrayAsInterfaceSlice []interface{}
+		elementIndex          int
+		elementPath           string
+		key                   string
+		keys                  []string
+		objectAsMap           map[string]interface{}
+		ok                    bool
+		propertyNode          *configSchemaNodeStruct
+	)
+
+	fail := func(path string, format string, args ...interface{}) error {
+		var (
+			found    bool
+			position configSchemaPositionStruct
+		)
+
+		err := fmt.Errorf(format, args...)
+
+		if positionOf != nil {
+			position, found = positionOf(path)
+			if found {
+				err = fmt.Errorf("%v (line %d, column %d)", err, position.line, position.column)
+			}
+		}
+
+		return err
+	}
+
+	switch node.kind {
+	case configSchemaKindAny:
+		// Anything goes
+	case configSchemaKindObject:
+		objectAsMap, ok = value.(map[string]interface{})
+		if !ok {
+			return fail(path, "%s must be an object", configSchemaPathDisplay(path))
+		}
+
+		// Visit keys in sorted order such that the error reported is deterministic
+
+		keys = make([]string, 0, len(objectAsMap))
+		for key = range objectAsMap {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key = range keys {
+			elementPath = configSchemaPathJoin(path, key)
+
+			propertyNode, ok = node.properties[key]
+			if !ok {
+				return fail(elementPath, "unknown key \"%s\" in %s", key, configSchemaPathDisplay(path))
+			}
+
+			err = validateConfigSchema(objectAsMap[key], propertyNode, elementPath, positionOf)
+			if err != nil {
+				return
+			}
+		}
+	case configSchemaKindMap:
+		objectAsMap, ok = value.(map[string]interface{})
+		if !ok {
+			return fail(path, "%s must be an object", configSchemaPathDisplay(path))
+		}
+
+		keys = make([]string, 0, len(objectAsMap))
+		for key = range objectAsMap {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key = range keys {
+			err = validateConfigSchema(objectAsMap[key], node.items, configSchemaPathJoin(path, key), positionOf)
+			if err != nil {
+				return
+			}
+		}
+	case configSchemaKindArray:
+		arrayAsInterfaceSlice, ok = value.([]interface{})
+		if !ok {
+			return fail(path, "%s must be an array", configSchemaPathDisplay(path))
+		}
+
+		for elementIndex = range arrayAsInterfaceSlice {
+			err = validateConfigSchema(arrayAsInterfaceSlice[elementIndex], node.items, path+"["+strconv.Itoa(elementIndex)+"]", positionOf)
+			if err != nil {
+				return
+			}
+		}
+	case configSchemaKindBoolean:
+		_, ok = value.(bool)
+		if !ok {
+			return fail(path, "%s must be a boolean", configSchemaPathDisplay(path))
+		}
+	case configSchemaKindInteger:
+		if !configSchemaIsInteger(value) {
+			return fail(path, "%s must be a non-negative integer", configSchemaPathDisplay(path))
+		}
+	case configSchemaKindNumber:
+		switch value.(type) {
+		case float64, int, int64, uint64:
+		default:
+			return fail(path, "%s must be a number", configSchemaPathDisplay(path))
+		}
+	case configSchemaKindString:
+		_, ok = value.(string)
+		if !ok {
+			return fail(path, "%s must be a string", configSchemaPathDisplay(path))
+		}
+		if (len(node.enum) != 0) && !slices.Contains(node.enum, value.(string)) {
+			return fail(path, "%s must be one of \"%s\"", configSchemaPathDisplay(path), strings.Join(node.enum, "\", \""))
+		}
+	}
+
+	return
+}
+
+// `configSchemaIsInteger` returns whether value (as decoded from JSON, YAML, or TOML) is a non-negative whole number.
+func configSchemaIsInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return (v >= 0) && (v == math.Trunc(v))
+	case int:
+		return v >= 0
+	case int64:
+		return v >= 0
+	case uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// `configSchemaPathJoin` returns the path of key within the object at path.
+func configSchemaPathJoin(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// `configSchemaPathDisplay` returns path suitable for inclusion in an error message.
+func configSchemaPathDisplay(path string) string {
+	if path == "" {
+		return "config-file"
+	}
+	return path
+}
+
+// `jsonSchema` returns the JSON Schema (draft 2020-12) equivalent of node.
+func (node *configSchemaNodeStruct) jsonSchema() (schema map[string]interface{}) {
+	var (
+		key          string
+		properties   map[string]interface{}
+		propertyNode *configSchemaNodeStruct
+	)
+
+	schema = make(map[string]interface{})
+
+	switch node.kind {
+	case configSchemaKindAny:
+		// An empty schema permits anything
+	case configSchemaKindObject:
+		properties = make(map[string]interface{}, len(node.properties))
+		for key, propertyNode = range node.properties {
+			properties[key] = propertyNode.jsonSchema()
+		}
+
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	case configSchemaKindMap:
+		schema["type"] = "object"
+		schema["additionalProperties"] = node.items.jsonSchema()
+	case configSchemaKindArray:
+		schema["type"] = "array"
+		schema["items"] = node.items.jsonSchema()
+	case configSchemaKindInteger:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+	default:
+		schema["type"] = node.kind
+		if len(node.enum) != 0 {
+			schema["enum"] = node.enum
+		}
+	}
+
+	return
+}
+
+// `configSchemaJSON` returns the published JSON Schema of a msfs_version 1 config-file.
+func configSchemaJSON() (schemaJSON []byte) {
+	var (
+		schema = configSchema.jsonSchema()
+	)
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "multi-storage-file-system config-file (msfs_version 1)"
+
+	schemaJSON, _ = json.MarshalIndent(schema, "", "  ")
+	schemaJSON = append(schemaJSON, '\n')
+
+	return
+}
+
+// `configOffsetPosition` converts a byte offset within content to a line & column.
+func configOffsetPosition(content []byte, offset int64) (position configSchemaPositionStruct) {
+	var (
+		lineStart int
+	)
+
+	offset = min(max(offset, 0), int64(len(content)))
+
+	position.line = bytes.Count(content[:offset], []byte("\n")) + 1
+	lineStart = bytes.LastIndexByte(content[:offset], '\n') + 1
+	position.column = int(offset) - lineStart + 1
+
+	return
+}
+
+// `jsonConfigPositions` returns a func locating (by path, as reported by validateConfigSchema())
+// each value in JSON-formatted content. If content is not well formed, nil is returned.
+func jsonConfigPositions(content []byte) (positionOf func(path string) (position configSchemaPositionStruct, ok bool)) {
+	var (
+		decoder   = json.NewDecoder(bytes.NewReader(content))
+		err       error
+		positions = make(map[string]configSchemaPositionStruct)
+		walk      func(path string) (err error)
+	)
+
+	// `walk` consumes the value at path (whose position has already been recorded).
+	walk = func(path string) (err error) {
+		var (
+			delim        json.Delim
+			elementIndex int
+			key          string
+			offset       int64
+			ok           bool
+			token        json.Token
+		)
+
+		token, err = decoder.Token()
+		if err != nil {
+			return
+		}
+
+		delim, ok = token.(json.Delim)
+		if !ok {
+			return
+		}
+
+		for decoder.More() {
+			if delim == '{' {
+				token, err = decoder.Token()
+				if err != nil {
+					return
+				}
+				key, _ = token.(string)
+				offset = decoder.InputOffset() - int64(len(strconv.Quote(key)))
+				positions[configSchemaPathJoin(path, key)] = configOffsetPosition(content, offset)
+				err = walk(configSchemaPathJoin(path, key))
+			} else {
+				offset = decoder.InputOffset()
+				for (offset < int64(len(content))) && strings.ContainsRune(", \t\r\n", rune(content[offset])) {
+					offset++
+				}
+				positions[path+"["+strconv.Itoa(elementIndex)+"]"] = configOffsetPosition(content, offset)
+				err = walk(path + "[" + strconv.Itoa(elementIndex) + "]")
+				elementIndex++
+			}
+			if err != nil {
+				return
+			}
+		}
+
+		_, err = decoder.Token() // Consume the closing delimiter
+
+		return
+	}
+
+	err = walk("")
+	if err != nil {
+		return nil
+	}
+
+	positionOf = func(path string) (position configSchemaPositionStruct, ok bool) {
+		position, ok = positions[path]
+		return
+	}
+
+	return
+}
+
+// `yamlConfigPositions` returns a func locating (by path, as reported by validateConfigSchema())
+// each value in YAML-formatted content. If content is not well formed, nil is returned.
+func yamlConfigPositions(content []byte) (positionOf func(path string) (position configSchemaPositionStruct, ok bool)) {
+	var (
+		err       error
+		positions = make(map[string]configSchemaPositionStruct)
+		root      yaml.Node
+		walk      func(node *yaml.Node, path string)
+	)
+
+	walk = func(node *yaml.Node, path string) {
+		var (
+			index int
+		)
+
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for index = range node.Content {
+				walk(node.Content[index], path)
+			}
+		case yaml.MappingNode:
+			for index = 0; (index + 1) < len(node.Content); index += 2 {
+				positions[configSchemaPathJoin(path, node.Content[index].Value)] = configSchemaPositionStruct{line: node.Content[index].Line, column: node.Content[index].Column}
+				walk(node.Content[index+1], configSchemaPathJoin(path, node.Content[index].Value))
+			}
+		case yaml.SequenceNode:
+			for index = range node.Content {
+				positions[path+"["+strconv.Itoa(index)+"]"] = configSchemaPositionStruct{line: node.Content[index].Line, column: node.Content[index].Column}
+				walk(node.Content[index], path+"["+strconv.Itoa(index)+"]")
+			}
+		}
+	}
+
+	err = yaml.Unmarshal(content, &root)
+	if err != nil {
+		return nil
+	}
+
+	walk(&root, "")
+
+	positionOf = func(path string) (position configSchemaPositionStruct, ok bool) {
+		position, ok = positions[path]
+		return
+	}
+
+	return
+}
+
+// `jsonSyntaxErrorPosition` returns err annotated with the line & column at
+// which content failed to parse as JSON (if err conveys such an offset).
+func jsonSyntaxErrorPosition(content []byte, err error) error {
+	var (
+		position           configSchemaPositionStruct
+		syntaxError        *json.SyntaxError
+		unmarshalTypeError *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxError):
+		position = configOffsetPosition(content, syntaxError.Offset)
+	case errors.As(err, &unmarshalTypeError):
+		position = configOffsetPosition(content, unmarshalTypeError.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		position = configOffsetPosition(content, int64(len(content)))
+	default:
+		return err
+	}
+
+	return fmt.Errorf("line %d, column %d: %v", position.line, position.column, err)
+}
+
+// `tomlErrorPosition` returns err annotated with the line & column at
+// which content failed to parse as TOML (if err conveys such a position).
+func tomlErrorPosition(content []byte, err error) error {
+	var (
+		parseError toml.ParseError
+		position   configSchemaPositionStruct
+	)
+
+	if !errors.As(err, &parseError) {
+		return err
+	}
+
+	position = configOffsetPosition(content, int64(parseError.Position.Start))
+
+	return fmt.Errorf("line %d, column %d: %s", position.line, position.column, parseError.Message)
+}
+
+// `normalizeTOMLValue` converts value (as decoded from TOML) to the equivalent decoded from
+// JSON or YAML (i.e. integers as int and arrays of tables as []interface{}) such that the
+// same parse*() functions may be applied.
+func normalizeTOMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = normalizeTOMLValue(element)
+		}
+		return v
+	case []map[string]interface{}:
+		elements := make([]interface{}, len(v))
+		for index, element := range v {
+			elements[index] = normalizeTOMLValue(element)
+		}
+		return elements
+	case []interface{}:
+		for index, element := range v {
+			v[index] = normalizeTOMLValue(element)
+		}
+		return v
+	case int64:
+		return int(v)
+	default:
+		return v
+	}
+}
@@ -16,8 +16,23 @@
 package main
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
 )
 
 // TestObservabilityConfigParsing verifies that observability config is parsed correctly
@@ -517,6 +532,489 @@ profiles:
 	}
 }
 
+func TestInternalGoodTOMLConfig(t *testing.T) {
+	var (
+		err error
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".toml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version = 1
+cache_lines = 64
+
+[[backends]]
+dir_name = "ram"
+bucket_container_name = "ignored"
+backend_type = "RAM"
+
+[[backends]]
+dir_name = "s3"
+bucket_container_name = "test"
+backend_type = "S3"
+
+[backends.S3]
+region = "us-east-1"
+endpoint = "http://minio:9000"
+access_key_id = "minioadmin"
+secret_access_key = "minioadmin"
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	if (globals.config.cacheLines != 64) || (len(globals.backendsToMount) != 2) || (globals.backendsToMount["s3"].backendType != "S3") {
+		t.Fatalf("checkConfigFile() of TOML config-file produced unexpected config")
+	}
+}
+
+func TestInternalBadTOMLConfig(t *testing.T) {
+	var (
+		err error
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".toml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version = 1
+
+[[backends]]
+dir_name = "ram"
+backend_type = RAM
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "line 6, column") {
+		t.Fatalf("checkConfigFile() returned %v (expected an error at line 6)", err)
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	var (
+		err             error
+		publishedSchema []byte
+		schema          map[string]interface{}
+	)
+
+	// A syntax error is reported with its line & column
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`{
+	"msfs_version": 1,
+	"backends": [
+		{ "dir_name": "ram", }
+	]
+}`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "line 4, column") {
+		t.Fatalf("checkConfigFile() returned %v (expected an error at line 4)", err)
+	}
+
+	// An unknown key is reported with its path, line, & column
+
+	err = os.WriteFile(globals.configFilePath, []byte(`{
+	"msfs_version": 1,
+	"backends": [
+		{
+			"dir_name": "s3",
+			"bucket_container_name": "test",
+			"backend_type": "S3",
+			"S3": {
+				"regoin": "us-east-1"
+			}
+		}
+	]
+}`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), `unknown key "regoin" in backends[0].S3 (line 9, column 5)`) {
+		t.Fatalf("checkConfigFile() returned %v (expected unknown key \"regoin\" at line 9, column 5)", err)
+	}
+
+	// As is a value of the wrong type
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+backends:
+  - dir_name: ram
+    bucket_container_name: ignored
+    backend_type: RAM
+    readonly: "no"
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "backends[0].readonly must be a boolean (line 6, column 5)") {
+		t.Fatalf("checkConfigFile() returned %v (expected backends[0].readonly type mismatch at line 6, column 5)", err)
+	}
+
+	// The published schema is itself valid JSON
+
+	err = json.Unmarshal(configSchemaJSON(), &schema)
+	if err != nil {
+		t.Fatalf("json.Unmarshal(configSchemaJSON()) failed: %v", err)
+	}
+	if schema["additionalProperties"] != false {
+		t.Fatalf("configSchemaJSON() does not reject unknown keys")
+	}
+
+	publishedSchema, err = os.ReadFile("msfs_config.schema.json")
+	if err != nil {
+		t.Fatalf("os.ReadFile(\"msfs_config.schema.json\") failed: %v", err)
+	}
+	if !bytes.Equal(publishedSchema, configSchemaJSON()) {
+		t.Fatalf("msfs_config.schema.json is stale (regenerate via `msfs --schema > msfs_config.schema.json`)")
+	}
+}
+
+func TestConfigInclude(t *testing.T) {
+	var (
+		backend    *backendStruct
+		err        error
+		includeDir = t.TempDir()
+		ok         bool
+	)
+
+	// A base config (JSON) and a per-cluster layer (YAML) are merged in lexical order
+
+	err = os.WriteFile(includeDir+"/00-base.json", []byte(`{
+	"cache_lines": 50,
+	"cache_lines_to_prefetch": 2,
+	"backends": [
+		{ "dir_name": "ram1", "bucket_container_name": "ignored", "backend_type": "RAM" },
+		{ "dir_name": "ram2", "bucket_container_name": "ignored", "backend_type": "RAM" }
+	]
+}`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = os.WriteFile(includeDir+"/10-cluster.yaml", []byte(`cache_lines_to_prefetch: 3
+backends:
+  - dir_name: ram2
+    readonly: false
+  - dir_name: ram3
+    bucket_container_name: ignored
+    backend_type: RAM
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+include: ["`+includeDir+`/*.yaml", "`+includeDir+`/*.json"]
+cache_lines_to_prefetch: 4
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	if (globals.config.cacheLines != 50) || (globals.config.cacheLinesToPrefetch != 4) {
+		t.Fatalf("checkConfigFile() set cache_lines == %v & cache_lines_to_prefetch == %v (expected 50 & 4)", globals.config.cacheLines, globals.config.cacheLinesToPrefetch)
+	}
+	if len(globals.backendsToMount) != 3 {
+		t.Fatalf("checkConfigFile() set len(globals.backendsToMount) == %v (expected 3)", len(globals.backendsToMount))
+	}
+	backend, ok = globals.backendsToMount["ram2"]
+	if !ok || backend.readOnly {
+		t.Fatalf("checkConfigFile() failed to merge backends[\"ram2\"] (ok: %v)", ok)
+	}
+	backend, ok = globals.backendsToMount["ram1"]
+	if !ok || !backend.readOnly {
+		t.Fatalf("checkConfigFile() failed to include backends[\"ram1\"] (ok: %v)", ok)
+	}
+
+	// A schema violation in an included file is reported with its own path, line, & column
+
+	err = os.WriteFile(includeDir+"/10-cluster.yaml", []byte(`backends:
+  - dir_name: ram2
+    readonly: "no"
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), includeDir+"/10-cluster.yaml") || !strings.Contains(err.Error(), "(line 3, column 5)") {
+		t.Fatalf("checkConfigFile() returned %v (expected backends[0].readonly type mismatch in 10-cluster.yaml)", err)
+	}
+
+	// A missing (non-glob) include and an include cycle are each rejected
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+include: ["`+includeDir+`/missing.yaml"]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("checkConfigFile() returned %v (expected missing include to be rejected)", err)
+	}
+
+	err = os.WriteFile(includeDir+"/10-cluster.yaml", []byte(`include: ["`+globals.configFilePath+`"]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+include: ["`+includeDir+`/*.yaml"]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "includes itself") {
+		t.Fatalf("checkConfigFile() returned %v (expected include cycle to be rejected)", err)
+	}
+}
+
+// `testSOPSEncrypt` encrypts value (of sops type valueType) found at path as would sops with dataKey.
+func testSOPSEncrypt(t *testing.T, dataKey []byte, value string, valueType string, path ...string) string {
+	var (
+		aesBlock   cipher.Block
+		aesGCM     cipher.AEAD
+		ciphertext []byte
+		err        error
+		iv         = make([]byte, 32)
+	)
+
+	_, _ = rand.Read(iv)
+
+	aesBlock, err = aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatalf("aes.NewCipher() failed: %v", err)
+	}
+	aesGCM, err = cipher.NewGCMWithNonceSize(aesBlock, len(iv))
+	if err != nil {
+		t.Fatalf("cipher.NewGCMWithNonceSize() failed: %v", err)
+	}
+
+	ciphertext = aesGCM.Seal(nil, iv, []byte(value), []byte(strings.Join(path, ":")+":"))
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(ciphertext[:len(ciphertext)-aesGCM.Overhead()]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(ciphertext[len(ciphertext)-aesGCM.Overhead():]),
+		valueType)
+}
+
+// `testAgeEncrypt` encrypts plaintext to ageRecipient returning the ASCII-armored ciphertext
+// indented (as a YAML block scalar) by indent.
+func testAgeEncrypt(t *testing.T, ageRecipient age.Recipient, plaintext []byte, indent string) string {
+	var (
+		armorWriter io.WriteCloser
+		ciphertext  bytes.Buffer
+		err         error
+		ageWriter   io.WriteCloser
+	)
+
+	armorWriter = armor.NewWriter(&ciphertext)
+	ageWriter, err = age.Encrypt(armorWriter, ageRecipient)
+	if err != nil {
+		t.Fatalf("age.Encrypt() failed: %v", err)
+	}
+	_, _ = ageWriter.Write(plaintext)
+	_ = ageWriter.Close()
+	_ = armorWriter.Close()
+
+	return indent + strings.ReplaceAll(strings.TrimSpace(ciphertext.String()), "\n", "\n"+indent)
+}
+
+func TestConfigDecrypt(t *testing.T) {
+	var (
+		ageIdentity *age.X25519Identity
+		dataKey     = make([]byte, 32)
+		err         error
+		kmsServer   *httptest.Server
+		ok          bool
+	)
+
+	ageIdentity, err = age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("age.GenerateX25519Identity() failed: %v", err)
+	}
+
+	t.Setenv(EnvSOPSAgeKey, ageIdentity.String())
+	t.Setenv(EnvSOPSAgeKeyFile, "")
+
+	_, _ = rand.Read(dataKey)
+
+	// A config-file encrypted by sops with an age key
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: `+testSOPSEncrypt(t, dataKey, "1", "int", "msfs_version")+`
+cache_lines: `+testSOPSEncrypt(t, dataKey, "50", "int", "cache_lines")+`
+backends:
+  - dir_name: `+testSOPSEncrypt(t, dataKey, "s3", "str", "backends", "dir_name")+`
+    bucket_container_name: test
+    backend_type: S3
+    S3:
+      region: us-east-1
+      access_key_id: `+testSOPSEncrypt(t, dataKey, "sopsAccessKeyID", "str", "backends", "S3", "access_key_id")+`
+      secret_access_key: `+testSOPSEncrypt(t, dataKey, "sopsSecretAccessKey", "str", "backends", "S3", "secret_access_key")+`
+sops:
+  age:
+    - recipient: `+ageIdentity.Recipient().String()+`
+      enc: |
+`+testAgeEncrypt(t, ageIdentity.Recipient(), dataKey, "        ")+`
+  version: 3.9.0
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() of sops (age) encrypted config-file failed: %v", err)
+	}
+	if (globals.config.cacheLines != 50) || (len(globals.backendsToMount) != 1) {
+		t.Fatalf("checkConfigFile() of sops (age) encrypted config-file set cache_lines == %v & len(backendsToMount) == %v", globals.config.cacheLines, len(globals.backendsToMount))
+	}
+	_, ok = globals.backendsToMount["s3"]
+	if !ok || (globals.backendsToMount["s3"].backendTypeSpecifics.(*backendConfigS3Struct).secretAccessKey != "sopsSecretAccessKey") {
+		t.Fatalf("checkConfigFile() of sops (age) encrypted config-file failed to decrypt backends[\"s3\"]")
+	}
+
+	// A sops value moved to a different path fails to decrypt
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+mountname: `+testSOPSEncrypt(t, dataKey, "msfs", "str", "mountpoint")+`
+sops:
+  age:
+    - recipient: `+ageIdentity.Recipient().String()+`
+      enc: |
+`+testAgeEncrypt(t, ageIdentity.Recipient(), dataKey, "        ")+`
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "unable to decrypt mountname") {
+		t.Fatalf("checkConfigFile() returned %v (expected moved value to fail to decrypt)", err)
+	}
+
+	// A config-file encrypted by sops with an AWS KMS key
+
+	kmsServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			request struct {
+				CiphertextBlob    []byte
+				EncryptionContext map[string]string
+			}
+		)
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		if (r.Header.Get("X-Amz-Target") != "TrentService.Decrypt") || (string(request.CiphertextBlob) != "wrappedDataKey") || (request.EncryptionContext["cluster"] != "test") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"__type":"InvalidCiphertextException","message":"bad ciphertext"}`)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"KeyId":"arn:aws:kms:us-west-2:111122223333:key/test","Plaintext":"%s"}`, base64.StdEncoding.EncodeToString(dataKey))
+	}))
+	defer kmsServer.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "testAccessKeyID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "testSecretAccessKey")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ENDPOINT_URL_KMS", kmsServer.URL)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+mountname: `+testSOPSEncrypt(t, dataKey, "kmsMountName", "str", "mountname")+`
+sops:
+  kms:
+    - arn: arn:aws:kms:us-west-2:111122223333:key/test
+      enc: `+base64.StdEncoding.EncodeToString([]byte("wrappedDataKey"))+`
+      context:
+        cluster: test
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if (err != nil) || (globals.config.mountName != "kmsMountName") {
+		t.Fatalf("checkConfigFile() of sops (kms) encrypted config-file returned %v (mountname: \"%s\")", err, globals.config.mountName)
+	}
+
+	// An individually age encrypted value (without sops)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+backends:
+  - dir_name: s3
+    bucket_container_name: test
+    backend_type: S3
+    S3:
+      region: us-east-1
+      access_key_id: plainAccessKeyID
+      secret_access_key: |
+`+testAgeEncrypt(t, ageIdentity.Recipient(), []byte("ageSecretAccessKey"), "        ")+`
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() of age encrypted value failed: %v", err)
+	}
+	if globals.backendsToMount["s3"].backendTypeSpecifics.(*backendConfigS3Struct).secretAccessKey != "ageSecretAccessKey" {
+		t.Fatalf("checkConfigFile() failed to decrypt age encrypted secret_access_key")
+	}
+
+	// Without the identity, decryption fails
+
+	t.Setenv(EnvSOPSAgeKey, "")
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "no age identities found") {
+		t.Fatalf("checkConfigFile() returned %v (expected missing age identity to be reported)", err)
+	}
+}
+
 func TestBadOtherSuffixConfig(t *testing.T) {
 	var (
 		err error
@@ -750,3 +1248,450 @@ backends: [
 		t.Fatalf("checkConfigFile() unexpectedly succeeded")
 	}
 }
+
+func TestConfigFileHotReloadConfigFileUpdate(t *testing.T) {
+	var (
+		err error
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_lines: 100
+cache_lines_to_prefetch: 4
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	defer drainFS()
+
+	processToMountList()
+
+	// Cache limits are applied without remounting
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_lines: 50
+cache_lines_to_prefetch: 2
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	if (globals.config.cacheLines != 50) || (globals.config.cacheLinesToPrefetch != 2) {
+		t.Fatalf("cache limits not applied (cache_lines: %v, cache_lines_to_prefetch: %v)", globals.config.cacheLines, globals.config.cacheLinesToPrefetch)
+	}
+	if (globals.config.dirtyCacheLinesFlushTrigger != 40) || (globals.config.dirtyCacheLinesMax != 45) {
+		t.Fatalf("dirty cache line limits not recomputed (dirty_cache_lines_flush_trigger: %v, dirty_cache_lines_max: %v)", globals.config.dirtyCacheLinesFlushTrigger, globals.config.dirtyCacheLinesMax)
+	}
+	if len(globals.backendsToUnmount) != 0 {
+		t.Fatalf("changing cache limits unexpectedly scheduled backends to be unmounted")
+	}
+
+	// A config that fails validation leaves the cache limits unchanged
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_lines: 25
+cache_line_size: 1024
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err == nil {
+		t.Fatalf("checkConfigFile() unexpectedly succeeded")
+	}
+
+	if globals.config.cacheLines != 50 {
+		t.Fatalf("cache_lines changed by a rejected config (cache_lines: %v)", globals.config.cacheLines)
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	var (
+		backend            *backendStruct
+		backendConfigS3    *backendConfigS3Struct
+		err                error
+		ok                 bool
+		osArgs             []string
+		osArgsRemaining    []string
+		configOverrides    []configOverrideStruct
+		testConfigFileYAML = `msfs_version: 1
+cache_lines: 50
+backends:
+  - dir_name: ram
+    bucket_container_name: ignored
+    backend_type: RAM
+  - dir_name: s3
+    bucket_container_name: test
+    backend_type: S3
+    S3:
+      region: us-east-1
+      endpoint: "http://minio:9000"
+      access_key_id: minioadmin
+      secret_access_key: minioadmin
+`
+	)
+
+	// Both {-set|--set} <key>=<value> and {-set|--set}=<key>=<value> forms are extracted
+
+	osArgs = append(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]), "--set", "cache_lines=100", "-set=backends.s3.S3.endpoint=http://other:9000", "--set", "backends.1.readonly=false")
+
+	osArgsRemaining, configOverrides, err = extractConfigOverrides(osArgs)
+	if err != nil {
+		t.Fatalf("extractConfigOverrides() unexpectedly failed: %v", err)
+	}
+	if (len(osArgsRemaining) != 2) || (len(configOverrides) != 3) {
+		t.Fatalf("extractConfigOverrides() returned %v & %v (expected 2 arguments & 3 overrides)", osArgsRemaining, configOverrides)
+	}
+
+	initGlobals(osArgs)
+
+	err = os.WriteFile(globals.configFilePath, []byte(testConfigFileYAML), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	if globals.config.cacheLines != 100 {
+		t.Fatalf("checkConfigFile() set cache_lines == %v (expected 100)", globals.config.cacheLines)
+	}
+	backend, ok = globals.backendsToMount["s3"]
+	if !ok || backend.readOnly {
+		t.Fatalf("checkConfigFile() failed to override backends[\"s3\"].readonly (ok: %v)", ok)
+	}
+	backendConfigS3, ok = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+	if !ok || (backendConfigS3.endpoint != "http://other:9000") {
+		t.Fatalf("checkConfigFile() failed to override backends[\"s3\"].S3.endpoint (ok: %v)", ok)
+	}
+	backend, ok = globals.backendsToMount["ram"]
+	if !ok || !backend.readOnly {
+		t.Fatalf("checkConfigFile() unexpectedly altered backends[\"ram\"] (ok: %v)", ok)
+	}
+
+	// An override naming a missing backend or violating the schema is rejected
+
+	initGlobals(append(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]), "--set", "backends.missing.readonly=false"))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "no element \"missing\"") {
+		t.Fatalf("checkConfigFile() returned %v (expected missing backend to be rejected)", err)
+	}
+
+	initGlobals(append(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]), "--set", "cache_lines=lots"))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "--set") {
+		t.Fatalf("checkConfigFile() returned %v (expected cache_lines type mismatch to be rejected)", err)
+	}
+
+	// Malformed settings are rejected
+
+	_, _, err = extractConfigOverrides([]string{os.Args[0], "--set", "cache_lines"})
+	if err == nil {
+		t.Fatalf("extractConfigOverrides() unexpectedly accepted a setting lacking \"=\"")
+	}
+	_, _, err = extractConfigOverrides([]string{os.Args[0], "--set"})
+	if err == nil {
+		t.Fatalf("extractConfigOverrides() unexpectedly accepted a missing setting")
+	}
+}
+
+func TestConfigRetryAttemptsPerClass(t *testing.T) {
+	var (
+		backend            *backendStruct
+		backendConfigS3    *backendConfigS3Struct
+		err                error
+		ok                 bool
+		testConfigFileYAML = `msfs_version: 1
+backends:
+  - dir_name: s3
+    bucket_container_name: test
+    backend_type: S3
+    S3:
+      region: us-east-1
+      endpoint: "http://minio:9000"
+      access_key_id: minioadmin
+      secret_access_key: minioadmin
+      retry_base_delay: 0
+      retry_next_delay_multiplier: 2.0
+      retry_throttle_base_delay: 100
+      retry_throttle_max_delay: 1000
+`
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(testConfigFileYAML), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	backend, ok = globals.backendsToMount["s3"]
+	if !ok {
+		t.Fatalf("checkConfigFile() failed to set up backends[\"s3\"]")
+	}
+	backendConfigS3 = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+
+	// Despite retry_base_delay == 0, throttling responses are retried (with delays of 100, 200, 400, & 800ms)
+
+	if backendConfigS3.retryAttempts != 5 {
+		t.Fatalf("checkConfigFile() set retryAttempts == %v (expected 5)", backendConfigS3.retryAttempts)
+	}
+	if !backend.IsErrorRetryable(testS3ResponseError(http.StatusServiceUnavailable)) {
+		t.Fatalf("IsErrorRetryable(503) returned false (expected true per retry_throttle_base_delay)")
+	}
+	if backend.IsErrorRetryable(testS3ResponseError(http.StatusInternalServerError)) {
+		t.Fatalf("IsErrorRetryable(500) returned true (expected false per retry_base_delay)")
+	}
+}
+
+func TestConfigProfile(t *testing.T) {
+	var (
+		backend            *backendStruct
+		configProfile      string
+		err                error
+		ok                 bool
+		osArgsRemaining    []string
+		testConfigFileYAML = `msfs_version: 1
+cache_lines: 50
+backends:
+  - dir_name: ram
+    bucket_container_name: dev
+    backend_type: RAM
+msfs_profiles:
+  staging:
+    cache_lines: 100
+  prod:
+    cache_lines: 200
+    backends:
+      - dir_name: ram
+        bucket_container_name: prod
+        readonly: false
+      - dir_name: ram2
+        bucket_container_name: prod2
+        backend_type: RAM
+`
+	)
+
+	// Both {-profile|--profile} <name> and {-profile|--profile}=<name> forms are extracted
+
+	osArgsRemaining, configProfile, err = extractConfigProfile([]string{os.Args[0], "--profile", "staging", "config.yaml"})
+	if (err != nil) || (configProfile != "staging") || (len(osArgsRemaining) != 2) {
+		t.Fatalf("extractConfigProfile() returned %v, \"%s\", %v (expected 2 arguments & \"staging\")", osArgsRemaining, configProfile, err)
+	}
+	_, configProfile, err = extractConfigProfile([]string{os.Args[0], "config.yaml", "-profile=prod"})
+	if (err != nil) || (configProfile != "prod") {
+		t.Fatalf("extractConfigProfile() returned \"%s\", %v (expected \"prod\")", configProfile, err)
+	}
+	_, _, err = extractConfigProfile([]string{os.Args[0], "--profile"})
+	if err == nil {
+		t.Fatalf("extractConfigProfile() unexpectedly accepted a missing <name>")
+	}
+
+	// Absent a selected profile, only the settings outside msfs_profiles apply
+
+	t.Setenv(EnvMSFSProfile, "")
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(testConfigFileYAML), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+	if (globals.config.cacheLines != 50) || (len(globals.backendsToMount) != 1) {
+		t.Fatalf("checkConfigFile() set cache_lines == %v & len(globals.backendsToMount) == %v (expected 50 & 1)", globals.config.cacheLines, len(globals.backendsToMount))
+	}
+
+	// A profile selected via ${MSFS_PROFILE} is merged atop the remaining settings
+
+	t.Setenv(EnvMSFSProfile, "staging")
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+	if globals.config.cacheLines != 100 {
+		t.Fatalf("checkConfigFile() set cache_lines == %v (expected 100)", globals.config.cacheLines)
+	}
+
+	// A profile selected via --profile takes precedence and merges its backends by dir_name
+
+	initGlobals(append(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]), "--profile", "prod"))
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+	if (globals.config.cacheLines != 200) || (len(globals.backendsToMount) != 2) {
+		t.Fatalf("checkConfigFile() set cache_lines == %v & len(globals.backendsToMount) == %v (expected 200 & 2)", globals.config.cacheLines, len(globals.backendsToMount))
+	}
+	backend, ok = globals.backendsToMount["ram"]
+	if !ok || backend.readOnly || (backend.bucketContainerName != "prod") {
+		t.Fatalf("checkConfigFile() failed to merge backends[\"ram\"] of profile \"prod\" (ok: %v)", ok)
+	}
+
+	// An unknown profile is rejected
+
+	initGlobals(append(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]), "--profile", "qa"))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "profile \"qa\" not found") {
+		t.Fatalf("checkConfigFile() returned %v (expected unknown profile to be rejected)", err)
+	}
+
+	// A schema violation within a profile is reported
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+msfs_profiles:
+  dev:
+    msfs_version: 1
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	t.Setenv(EnvMSFSProfile, "")
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "unknown key \"msfs_version\" in msfs_profiles.dev") {
+		t.Fatalf("checkConfigFile() returned %v (expected msfs_version within a profile to be rejected)", err)
+	}
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	var (
+		err                 error
+		homeDir             = t.TempDir()
+		msfsConfigFilePaths []string
+		systemDir           = t.TempDir()
+		xdgConfigHomeDir    = t.TempDir()
+	)
+
+	err = os.MkdirAll(homeDir+"/.config/msfs", 0o755)
+	if err != nil {
+		t.Fatalf("os.MkdirAll() failed: %v", err)
+	}
+	err = os.WriteFile(homeDir+"/.config/msfs/config.json", []byte(`{
+	"msfs_version": 1,
+	"cache_lines": 50,
+	"cache_lines_to_prefetch": 2,
+	"backends": [
+		{ "dir_name": "ram", "bucket_container_name": "ignored", "backend_type": "RAM" }
+	]
+}`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = os.MkdirAll(systemDir+"/msfs", 0o755)
+	if err != nil {
+		t.Fatalf("os.MkdirAll() failed: %v", err)
+	}
+	err = os.WriteFile(systemDir+"/msfs/config.yaml", []byte(`msfs_version: 1
+cache_lines: 25
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	// Directories without a config-file are skipped & a directory reached twice is searched once
+
+	msfsConfigFilePaths = findMSFSConfigFiles([]string{xdgConfigHomeDir + "/msfs", homeDir + "/.config/msfs", homeDir + "/.config/../.config/msfs", systemDir + "/msfs"})
+	if !slices.Equal(msfsConfigFilePaths, []string{homeDir + "/.config/msfs/config.json", systemDir + "/msfs/config.yaml"}) {
+		t.Fatalf("findMSFSConfigFiles() returned %v", msfsConfigFilePaths)
+	}
+
+	// Absent a config-file path (or ${MSC_CONFIG}), the per-user config-file is found atop any others
+
+	err = os.MkdirAll(xdgConfigHomeDir+"/msfs", 0o755)
+	if err != nil {
+		t.Fatalf("os.MkdirAll() failed: %v", err)
+	}
+	err = os.WriteFile(xdgConfigHomeDir+"/msfs/config.yaml", []byte(`msfs_version: 1
+cache_lines_to_prefetch: 3
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	t.Setenv("MSC_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", xdgConfigHomeDir)
+	t.Setenv("HOME", homeDir)
+
+	initGlobals([]string{os.Args[0]})
+
+	if globals.configFilePath != xdgConfigHomeDir+"/msfs/config.yaml" {
+		t.Fatalf("initGlobals() set globals.configFilePath == \"%s\"", globals.configFilePath)
+	}
+	if (len(globals.configFileDefaultPaths) == 0) || (globals.configFileDefaultPaths[len(globals.configFileDefaultPaths)-1] != homeDir+"/.config/msfs/config.json") {
+		t.Fatalf("initGlobals() set globals.configFileDefaultPaths == %v", globals.configFileDefaultPaths)
+	}
+
+	// Discovered defaults are merged (system-wide first) beneath the config-file
+
+	globals.configFileDefaultPaths = []string{systemDir + "/msfs/config.yaml", homeDir + "/.config/msfs/config.json"}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	if (globals.config.cacheLines != 50) || (globals.config.cacheLinesToPrefetch != 3) || (len(globals.backendsToMount) != 1) {
+		t.Fatalf("checkConfigFile() set cache_lines == %v & cache_lines_to_prefetch == %v & len(globals.backendsToMount) == %v (expected 50 & 3 & 1)", globals.config.cacheLines, globals.config.cacheLinesToPrefetch, len(globals.backendsToMount))
+	}
+}
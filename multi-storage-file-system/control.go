@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	controlVersion      = 1       // Of the request and response schemas served by POST /control
+	controlRequestMax   = 1 << 20 // Largest request body accepted by POST /control
+	controlCodeBadReq   = "bad_request"
+	controlCodeUnknown  = "unknown_command"
+	controlCodeVersion  = "unsupported_version"
+	controlCodeUnavail  = "unavailable"
+	controlCodeFailed   = "failed"
+	controlCommandsList = "commands"
+)
+
+// `controlCommands` are the commands accepted by POST /control. Each (other than
+// controlCommandsList) is performed by the admin API endpoint of the same name with
+// its result being that endpoint's response.
+var controlCommands = []string{
+	controlCommandsList,
+	"stats",
+	"cache",
+	"cache/stats",
+	"cache/ls",
+	"cache/drop",
+	"cache/pin",
+	"cache/unpin",
+	"cache/warm",
+	"inodes",
+	"health",
+	"latency",
+	"io",
+	"top",
+	"reset_io",
+	"drop_caches",
+	"flush",
+	"reload",
+}
+
+// `controlRequestStruct` is the body of a POST /control request.
+type controlRequestStruct struct {
+	Version uint64                     `json:"version"`        // Must be controlVersion
+	ID      json.RawMessage            `json:"id,omitempty"`   // Echoed in the response (so that it may be matched with its request)
+	Command string                     `json:"command"`        // One of controlCommands
+	Args    map[string]json.RawMessage `json:"args,omitempty"` // Each a string, number, or boolean passed as the endpoint's query parameter of the same name
+}
+
+// `controlResponseStruct` is the body of the response to a POST /control request.
+// Exactly one of Result (if OK) or Error (if !OK) is present.
+type controlResponseStruct struct {
+	Version uint64                      `json:"version"`
+	ID      json.RawMessage             `json:"id,omitempty"`
+	OK      bool                        `json:"ok"`
+	Result  json.RawMessage             `json:"result,omitempty"`
+	Error   *controlResponseErrorStruct `json:"error,omitempty"`
+}
+
+// `controlResponseErrorStruct` describes the failure of a POST /control request.
+type controlResponseErrorStruct struct {
+	Code    string `json:"code"` // One of the controlCode* constants
+	Message string `json:"message"`
+}
+
+// `controlResponseWriterStruct` captures the response of the admin API endpoint performing a command.
+type controlResponseWriterStruct struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (controlResponseWriter *controlResponseWriterStruct) Header() http.Header {
+	return controlResponseWriter.header
+}
+
+func (controlResponseWriter *controlResponseWriterStruct) Write(buf []byte) (int, error) {
+	if controlResponseWriter.statusCode == 0 {
+		controlResponseWriter.statusCode = http.StatusOK
+	}
+
+	return controlResponseWriter.body.Write(buf)
+}
+
+func (controlResponseWriter *controlResponseWriterStruct) WriteHeader(statusCode int) {
+	if controlResponseWriter.statusCode == 0 {
+		controlResponseWriter.statusCode = statusCode
+	}
+}
+
+// `serveAdminControl` implements POST /control, the scriptable counterpart of the rest of
+// the admin API. Whereas the other endpoints respond to a failure with a plain text message
+// and an HTTP status, each request here is a single JSON object (see controlRequestStruct)
+// and each response (always with HTTP status 200 OK) a single JSON object (see
+// controlResponseStruct) such that a client need only parse the latter to learn the outcome.
+func serveAdminControl(w http.ResponseWriter, r *http.Request) {
+	var (
+		body     []byte
+		err      error
+		request  controlRequestStruct
+		response *controlResponseStruct
+	)
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "POST required\n")
+		return
+	}
+
+	body, err = io.ReadAll(io.LimitReader(r.Body, controlRequestMax+1))
+	switch {
+	case err != nil:
+		response = controlError(nil, controlCodeBadReq, fmt.Sprintf("unable to read request: %v", err))
+	case len(body) > controlRequestMax:
+		response = controlError(nil, controlCodeBadReq, fmt.Sprintf("request exceeds %v bytes", controlRequestMax))
+	default:
+		err = json.Unmarshal(body, &request)
+		if err != nil {
+			response = controlError(nil, controlCodeBadReq, fmt.Sprintf("unable to parse request: %v", err))
+		} else {
+			response = controlPerform(&request)
+		}
+	}
+
+	writeAdminJSON(w, response)
+}
+
+// `controlPerform` performs request returning the response to it.
+func controlPerform(request *controlRequestStruct) (response *controlResponseStruct) {
+	var (
+		arg                   string
+		argName               string
+		argValue              json.RawMessage
+		controlResponseWriter *controlResponseWriterStruct
+		endpointRequest       *http.Request
+		err                   error
+		query                 = url.Values{}
+	)
+
+	if request.Version != controlVersion {
+		response = controlError(request.ID, controlCodeVersion, fmt.Sprintf("version must be %v", controlVersion))
+		return
+	}
+
+	if !slices.Contains(controlCommands, request.Command) {
+		response = controlError(request.ID, controlCodeUnknown, fmt.Sprintf("unknown command \"%s\"", request.Command))
+		return
+	}
+
+	if request.Command == controlCommandsList {
+		response = controlResult(request.ID, controlCommands)
+		return
+	}
+
+	for argName, argValue = range request.Args {
+		arg, err = controlArg(argValue)
+		if err != nil {
+			response = controlError(request.ID, controlCodeBadReq, fmt.Sprintf("bad arg \"%s\": %v", argName, err))
+			return
+		}
+		query.Set(argName, arg)
+	}
+
+	endpointRequest, err = http.NewRequest(http.MethodPost, "/"+request.Command+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		response = controlError(request.ID, controlCodeBadReq, err.Error())
+		return
+	}
+
+	controlResponseWriter = &controlResponseWriterStruct{header: make(http.Header)}
+
+	(&adminHandlerStruct{}).ServeHTTP(controlResponseWriter, endpointRequest)
+
+	switch controlResponseWriter.statusCode {
+	case http.StatusOK:
+		response = &controlResponseStruct{
+			Version: controlVersion,
+			ID:      request.ID,
+			OK:      true,
+			Result:  json.RawMessage(bytes.TrimSpace(controlResponseWriter.body.Bytes())),
+		}
+	case http.StatusBadRequest, http.StatusMethodNotAllowed:
+		response = controlError(request.ID, controlCodeBadReq, strings.TrimSpace(controlResponseWriter.body.String()))
+	case http.StatusServiceUnavailable:
+		response = controlError(request.ID, controlCodeUnavail, strings.TrimSpace(controlResponseWriter.body.String()))
+	default:
+		response = controlError(request.ID, controlCodeFailed, strings.TrimSpace(controlResponseWriter.body.String()))
+	}
+
+	return
+}
+
+// `controlArg` returns the query parameter value corresponding to a (string, number, or
+// boolean) arg of a POST /control request.
+func controlArg(argValue json.RawMessage) (arg string, err error) {
+	var (
+		value interface{}
+	)
+
+	err = json.Unmarshal(argValue, &value)
+	if err != nil {
+		return
+	}
+
+	switch value := value.(type) {
+	case string:
+		arg = value
+	case bool:
+		arg = fmt.Sprint(value)
+	case float64:
+		arg = string(bytes.TrimSpace(argValue)) // Preserve the number as given (e.g. not as 1e+06)
+	default:
+		err = fmt.Errorf("must be a string, number, or boolean (not %s)", string(argValue))
+	}
+
+	return
+}
+
+// `controlResult` returns the successful response (to the request identified by id) of result.
+func controlResult(id json.RawMessage, result interface{}) (response *controlResponseStruct) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		response = controlError(id, controlCodeFailed, err.Error())
+		return
+	}
+
+	response = &controlResponseStruct{
+		Version: controlVersion,
+		ID:      id,
+		OK:      true,
+		Result:  resultJSON,
+	}
+
+	return
+}
+
+// `controlError` returns the failed response (to the request identified by id) of code and message.
+func controlError(id json.RawMessage, code string, message string) (response *controlResponseStruct) {
+	response = &controlResponseStruct{
+		Version: controlVersion,
+		ID:      id,
+		OK:      false,
+		Error: &controlResponseErrorStruct{
+			Code:    code,
+			Message: message,
+		},
+	}
+
+	return
+}
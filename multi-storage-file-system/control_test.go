@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAdminControl(t *testing.T) {
+	var (
+		cacheStats *adminCacheStatsStruct
+		commands   []string
+		err        error
+		ioTop      *ioTopStruct
+		response   *controlResponseStruct
+		stats      *adminStatsStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	globals.config.adminSocket = filepath.Join(t.TempDir(), "admin.sock")
+	startAdminSocket()
+	defer stopAdminSocket()
+
+	control := func(method string, body string) (response *controlResponseStruct) {
+		httpRequest, err := http.NewRequest(method, "http://msfs/control", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("http.NewRequest() failed: %v", err)
+		}
+
+		httpResponse, err := adminSocketClient().Do(httpRequest)
+		if err != nil {
+			t.Fatalf("%s /control failed: %v", method, err)
+		}
+		defer func() {
+			_ = httpResponse.Body.Close()
+		}()
+
+		responseBody, err := io.ReadAll(httpResponse.Body)
+		if err != nil {
+			t.Fatalf("reading response to %s /control failed: %v", method, err)
+		}
+
+		if method != http.MethodPost {
+			if httpResponse.StatusCode != http.StatusMethodNotAllowed {
+				t.Fatalf("%s /control returned %v", method, httpResponse.StatusCode)
+			}
+			return nil
+		}
+
+		if httpResponse.StatusCode != http.StatusOK {
+			t.Fatalf("POST /control %s returned %v: %s", body, httpResponse.StatusCode, string(responseBody))
+		}
+
+		response = &controlResponseStruct{}
+
+		err = json.Unmarshal(responseBody, response)
+		if (err != nil) || (response.Version != controlVersion) || (response.OK != (response.Error == nil)) {
+			t.Fatalf("POST /control %s returned (err: %v): %s", body, err, string(responseBody))
+		}
+
+		return
+	}
+
+	_ = control(http.MethodGet, "")
+
+	// Successful commands return the response of the corresponding endpoint (echoing the id)
+
+	response = control(http.MethodPost, `{"version": 1, "id": "req-1", "command": "commands"}`)
+	err = json.Unmarshal(response.Result, &commands)
+	if !response.OK || (string(response.ID) != `"req-1"`) || (err != nil) || !slices.Equal(commands, controlCommands) {
+		t.Fatalf("commands returned %+v", response)
+	}
+
+	response = control(http.MethodPost, `{"version": 1, "id": 2, "command": "stats"}`)
+	err = json.Unmarshal(response.Result, &stats)
+	if !response.OK || (string(response.ID) != "2") || (err != nil) || (stats.Backends != 1) {
+		t.Fatalf("stats returned %+v", response)
+	}
+
+	response = control(http.MethodPost, `{"version": 1, "command": "io", "args": {"top": 3}}`)
+	err = json.Unmarshal(response.Result, &ioTop)
+	if !response.OK || (response.ID != nil) || (err != nil) || (ioTop == nil) {
+		t.Fatalf("io returned %+v", response)
+	}
+
+	response = control(http.MethodPost, `{"version": 1, "command": "cache/pin", "args": {"path": "ram/dir1"}}`)
+	if !response.OK {
+		t.Fatalf("cache/pin returned %+v", response)
+	}
+
+	response = control(http.MethodPost, `{"version": 1, "command": "cache/stats"}`)
+	err = json.Unmarshal(response.Result, &cacheStats)
+	if !response.OK || (err != nil) || !slices.Equal(cacheStats.Pins, []string{"ram/dir1"}) {
+		t.Fatalf("cache/stats returned %+v", response)
+	}
+
+	response = control(http.MethodPost, `{"version": 1, "command": "drop_caches", "args": {"inodes": true}}`)
+	if !response.OK || !strings.Contains(string(response.Result), `"inodes_drained"`) {
+		t.Fatalf("drop_caches returned %+v", response)
+	}
+
+	// Failures are reported with a stable code
+
+	for _, testCase := range []struct {
+		body string
+		code string
+	}{
+		{`not json`, controlCodeBadReq},
+		{`{"version": 2, "command": "stats"}`, controlCodeVersion},
+		{`{"command": "stats"}`, controlCodeVersion},
+		{`{"version": 1, "command": "metrics"}`, controlCodeUnknown},
+		{`{"version": 1, "command": "io", "args": {"top": -1}}`, controlCodeBadReq},
+		{`{"version": 1, "command": "io", "args": {"top": [1]}}`, controlCodeBadReq},
+		{`{"version": 1, "command": "cache/drop", "args": {"path": "missing"}}`, controlCodeBadReq},
+	} {
+		response = control(http.MethodPost, testCase.body)
+		if response.OK || (response.Error.Code != testCase.code) || (response.Error.Message == "") {
+			t.Fatalf("POST /control %s returned %+v (expected code %s)", testCase.body, response, testCase.code)
+		}
+	}
+}
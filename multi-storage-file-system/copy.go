@@ -0,0 +1,556 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	CopyStateRunning = "running"
+	CopyStateDone    = "done"
+	CopyStateFailed  = "failed"
+)
+
+// `copyStruct` tracks a copy (or rename) of a single file from one mounted backend to
+// (possibly a different path of) another. The file is streamed a cache line at a time
+// into the parts of a multipart upload to the dst backend such that the whole file need
+// never be held in memory. As each part is uploaded, the progress of the multipart upload
+// is recorded in stateFile (if globals.config.migrationStateDir != "") such that a
+// subsequent copy of the same file resumes with the first part not yet uploaded.
+type copyStruct struct {
+	sync.Mutex                     // Protects endTime through lastErr
+	id            string           // Derived from srcDirName, srcPath, dstDirName, & dstPath
+	srcDirName    string           //
+	srcPath       string           // Relative to the src backend.prefix
+	dstDirName    string           //
+	dstPath       string           // Relative to the dst backend.prefix
+	rename        bool             // If true, srcPath is deleted once the copy has been verified
+	stateFile     string           // If == "", progress is not recorded
+	startTime     time.Time        //
+	endTime       time.Time        // If state == CopyStateRunning, time.Time{}
+	state         string           // One of CopyState*
+	size          uint64           // Of srcPath (once known)
+	bytesCopied   uint64           // Includes bytes uploaded by a prior (interrupted) copy
+	partsUploaded uint64           //
+	partsResumed  uint64           // Uploaded by a prior (interrupted) copy
+	lastErr       error            // If != nil, the failure
+	doneChan      chan struct{}    // Closed once state != CopyStateRunning
+	srcBackend    *backendStruct   //
+	dstBackend    *backendStruct   //
+	srcContext    backendContextIf //
+	dstContext    backendContextIf //
+}
+
+// `copyResumeStateStruct` is the content of copyStruct.stateFile. Should the
+// src file's eTag or size (or the dst backend's part size) differ from that
+// recorded, the multipart upload is abandoned and the copy starts afresh.
+type copyResumeStateStruct struct {
+	SrcETag  string   `json:"src_etag"`
+	Size     uint64   `json:"size"`
+	PartSize uint64   `json:"part_size"`
+	UploadID string   `json:"upload_id"`
+	PartETag []string `json:"part_etag"` // PartETag[i] is that of partNumber i+1
+}
+
+// `copyID` returns the identifier of a copy of srcPath of srcDirName to dstPath of dstDirName.
+func copyID(srcDirName string, srcPath string, dstDirName string, dstPath string) (id string) {
+	var (
+		sum = sha256.Sum256([]byte(srcDirName + "\x00" + srcPath + "\x00" + dstDirName + "\x00" + dstPath))
+	)
+
+	id = hex.EncodeToString(sum[:8])
+
+	return
+}
+
+// `startCopy` is called to begin (or resume) copying srcPath of the srcDirName backend to
+// dstPath (if == "", srcPath) of the dstDirName backend. If rename is true, srcPath is
+// deleted once the copy has been verified. Both backends must be mounted (and remain so
+// for the duration), must differ, and the dstDirName backend (as well as the srcDirName
+// backend if rename is true) must be writable. The copy proceeds in the background.
+func startCopy(srcDirName string, srcPath string, dstDirName string, dstPath string, rename bool) (cp *copyStruct, err error) {
+	var (
+		dstBackend *backendStruct
+		id         string
+		ok         bool
+		srcBackend *backendStruct
+	)
+
+	if dstPath == "" {
+		dstPath = srcPath
+	}
+
+	if !isValidFilePath(srcPath) {
+		err = fmt.Errorf("bad src_path \"%s\"", srcPath)
+		return
+	}
+	if !isValidFilePath(dstPath) {
+		err = fmt.Errorf("bad dst_path \"%s\"", dstPath)
+		return
+	}
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	srcBackend, ok = globals.config.backends[srcDirName]
+	if !ok || !srcBackend.mounted {
+		err = fmt.Errorf("src backend \"%s\" not mounted", srcDirName)
+		return
+	}
+	dstBackend, ok = globals.config.backends[dstDirName]
+	if !ok || !dstBackend.mounted {
+		err = fmt.Errorf("dst backend \"%s\" not mounted", dstDirName)
+		return
+	}
+	if srcBackend == dstBackend {
+		err = errors.New("src and dst backends must differ")
+		return
+	}
+	if dstBackend.readOnlyAt(dstPath) {
+		err = fmt.Errorf("dst_path \"%s\" of backend \"%s\" is readonly", dstPath, dstDirName)
+		return
+	}
+	if rename && srcBackend.readOnlyAt(srcPath) {
+		err = fmt.Errorf("src_path \"%s\" of backend \"%s\" is readonly", srcPath, srcDirName)
+		return
+	}
+
+	id = copyID(srcDirName, srcPath, dstDirName, dstPath)
+
+	if globals.copies == nil {
+		globals.copies = make(map[string]*copyStruct)
+	}
+
+	cp, ok = globals.copies[id]
+	if ok && (cp.currentState() == CopyStateRunning) {
+		err = fmt.Errorf("copy %s already running", id)
+		return
+	}
+
+	cp = &copyStruct{
+		id:         id,
+		srcDirName: srcDirName,
+		srcPath:    srcPath,
+		dstDirName: dstDirName,
+		dstPath:    dstPath,
+		rename:     rename,
+		startTime:  time.Now(),
+		state:      CopyStateRunning,
+		doneChan:   make(chan struct{}),
+		srcBackend: srcBackend,
+		dstBackend: dstBackend,
+		srcContext: srcBackend.context,
+		dstContext: dstBackend.context,
+	}
+
+	if globals.config.migrationStateDir != "" {
+		err = os.MkdirAll(globals.config.migrationStateDir, 0o700)
+		if err != nil {
+			return
+		}
+
+		cp.stateFile = filepath.Join(globals.config.migrationStateDir, id+".copy")
+	}
+
+	globals.copies[id] = cp
+
+	go cp.run()
+
+	globals.logger.Printf("[INFO] [copy] %s started copying \"%s\" of %s to \"%s\" of %s (rename:%v)", id, srcPath, srcDirName, dstPath, dstDirName, rename)
+
+	return
+}
+
+// `isValidFilePath` returns whether filePath may name a `file` (relative to a backend.prefix).
+func isValidFilePath(filePath string) bool {
+	return (filePath != "") && (filePath[0] != '/') && (filePath[len(filePath)-1] != '/')
+}
+
+// `currentState` returns cp.state.
+func (cp *copyStruct) currentState() (state string) {
+	cp.Lock()
+	state = cp.state
+	cp.Unlock()
+
+	return
+}
+
+// `run` is the background driver of a copy. Files no larger than the dst backend's
+// multipart_cache_line_threshold (or destined for a backend unable to perform multipart
+// uploads) are copied with a single writeFile(). Otherwise, the file is streamed into a
+// (possibly resumed) multipart upload. Either way, the copy is then verified and, for a
+// rename, the src file deleted.
+func (cp *copyStruct) run() {
+	var (
+		err            error
+		srcETag        string
+		statFileOutput *statFileOutputStruct
+	)
+
+	statFileOutput, err = statFileWrapper(cp.srcContext, &statFileInputStruct{
+		filePath: cp.srcPath,
+		bulk:     true,
+	})
+	if err != nil {
+		cp.finish(fmt.Errorf("unable to stat \"%s\" of %s: %v", cp.srcPath, cp.srcDirName, err))
+		return
+	}
+
+	srcETag = statFileOutput.eTag
+
+	cp.Lock()
+	cp.size = statFileOutput.size
+	cp.Unlock()
+
+	if statFileOutput.size <= (cp.dstBackend.multiPartCacheLineThreshold * globals.config.cacheLineSize) {
+		err = cp.copyWhole(srcETag)
+	} else {
+		err = cp.copyMultipart(srcETag, statFileOutput.size)
+		if errors.Is(err, syscall.ENOTSUP) {
+			err = cp.copyWhole(srcETag)
+		}
+	}
+	if err != nil {
+		cp.finish(err)
+		return
+	}
+
+	statFileOutput, err = statFileWrapper(cp.dstContext, &statFileInputStruct{
+		filePath: cp.dstPath,
+		bulk:     true,
+	})
+	if err != nil {
+		cp.finish(fmt.Errorf("unable to stat \"%s\" of %s after copy: %v", cp.dstPath, cp.dstDirName, err))
+		return
+	}
+	if statFileOutput.size != cp.size {
+		cp.finish(fmt.Errorf("\"%s\" of %s has %v bytes after copy (expected %v)", cp.dstPath, cp.dstDirName, statFileOutput.size, cp.size))
+		return
+	}
+
+	if cp.rename {
+		_, err = deleteFileWrapper(cp.srcContext, &deleteFileInputStruct{
+			filePath: cp.srcPath,
+			ifMatch:  srcETag,
+		})
+		if err != nil {
+			cp.finish(fmt.Errorf("unable to delete \"%s\" of %s after copy: %v", cp.srcPath, cp.srcDirName, err))
+			return
+		}
+	}
+
+	cp.finish(nil)
+}
+
+// `copyWhole` copies the src file with a single writeFile() to the dst backend.
+func (cp *copyStruct) copyWhole(srcETag string) (err error) {
+	var (
+		buf  []byte
+		eTag string
+	)
+
+	buf, eTag, err = readWholeFile(cp.srcContext, cp.srcPath)
+	if err != nil {
+		return
+	}
+	if eTag != srcETag {
+		err = fmt.Errorf("\"%s\" of %s changed during copy", cp.srcPath, cp.srcDirName)
+		return
+	}
+
+	_, err = writeFileWrapper(cp.dstContext, &writeFileInputStruct{
+		filePath: cp.dstPath,
+		buf:      buf,
+	})
+	if err != nil {
+		return
+	}
+
+	cp.Lock()
+	cp.bytesCopied = uint64(len(buf))
+	cp.Unlock()
+
+	return
+}
+
+// `copyMultipart` streams the src file into a multipart upload to the dst backend
+// of parts each upload_part_cache_lines (of the dst backend) cache lines in size.
+// Each part is read (insisting the src file's eTag remains srcETag) just before it
+// is uploaded. If the dst backend is unable to perform multipart uploads, an error
+// wrapping syscall.ENOTSUP is returned (before anything has been uploaded).
+func (cp *copyStruct) copyMultipart(srcETag string, size uint64) (err error) {
+	var (
+		completeMultipartUploadOutput *completeMultipartUploadOutputStruct
+		createMultipartUploadOutput   *createMultipartUploadOutputStruct
+		numParts                      uint64
+		partBuf                       []byte
+		partNumber                    uint64
+		partSize                      = cp.dstBackend.uploadPartCacheLines * globals.config.cacheLineSize
+		resumeState                   *copyResumeStateStruct
+		uploadPartOutput              *uploadPartOutputStruct
+	)
+
+	numParts = (size + partSize - 1) / partSize
+
+	resumeState = cp.loadStateFile()
+	if (resumeState != nil) && ((resumeState.SrcETag != srcETag) || (resumeState.Size != size) || (resumeState.PartSize != partSize) || (uint64(len(resumeState.PartETag)) > numParts)) {
+		globals.logger.Printf("[INFO] [copy] %s abandoning multipart upload %s (\"%s\" of %s changed since it was begun)", cp.id, resumeState.UploadID, cp.srcPath, cp.srcDirName)
+
+		_, _ = cp.dstContext.abortMultipartUpload(&abortMultipartUploadInputStruct{
+			filePath: cp.dstPath,
+			uploadID: resumeState.UploadID,
+		})
+
+		resumeState = nil
+	}
+
+	if resumeState == nil {
+		createMultipartUploadOutput, err = cp.dstContext.createMultipartUpload(&createMultipartUploadInputStruct{
+			filePath: cp.dstPath,
+		})
+		if err != nil {
+			return
+		}
+
+		resumeState = &copyResumeStateStruct{
+			SrcETag:  srcETag,
+			Size:     size,
+			PartSize: partSize,
+			UploadID: createMultipartUploadOutput.uploadID,
+			PartETag: make([]string, 0, numParts),
+		}
+
+		err = cp.saveStateFile(resumeState)
+		if err != nil {
+			return
+		}
+	} else {
+		cp.Lock()
+		cp.partsResumed = uint64(len(resumeState.PartETag))
+		cp.bytesCopied = cp.partsResumed * partSize
+		cp.Unlock()
+
+		globals.logger.Printf("[INFO] [copy] %s resuming multipart upload %s at part %v of %v", cp.id, resumeState.UploadID, cp.partsResumed+1, numParts)
+	}
+
+	for partNumber = uint64(len(resumeState.PartETag)) + 1; partNumber <= numParts; partNumber++ {
+		partBuf, err = cp.readPart(srcETag, partNumber, size)
+		if err != nil {
+			return
+		}
+
+		globals.qosScheduler.acquire(cp.dstBackend.qosClass(cp.dstPath, true))
+		uploadPartOutput, err = cp.dstContext.uploadPart(&uploadPartInputStruct{
+			filePath:   cp.dstPath,
+			uploadID:   resumeState.UploadID,
+			partNumber: partNumber,
+			buf:        partBuf,
+		})
+		globals.qosScheduler.release()
+		if err != nil {
+			err = fmt.Errorf("unable to upload part %v of \"%s\" of %s: %v", partNumber, cp.dstPath, cp.dstDirName, err)
+			return
+		}
+
+		resumeState.PartETag = append(resumeState.PartETag, uploadPartOutput.eTag)
+
+		err = cp.saveStateFile(resumeState)
+		if err != nil {
+			return
+		}
+
+		cp.Lock()
+		cp.partsUploaded++
+		cp.bytesCopied += uint64(len(partBuf))
+		cp.Unlock()
+	}
+
+	globals.qosScheduler.acquire(cp.dstBackend.qosClass(cp.dstPath, true))
+	completeMultipartUploadOutput, err = cp.dstContext.completeMultipartUpload(&completeMultipartUploadInputStruct{
+		filePath: cp.dstPath,
+		uploadID: resumeState.UploadID,
+		partETag: resumeState.PartETag,
+	})
+	globals.qosScheduler.release()
+	if err != nil {
+		err = fmt.Errorf("unable to complete multipart upload of \"%s\" of %s: %v", cp.dstPath, cp.dstDirName, err)
+		return
+	}
+
+	globals.logger.Printf("[INFO] [copy] %s completed multipart upload %s of %v parts (eTag:\"%s\")", cp.id, resumeState.UploadID, numParts, completeMultipartUploadOutput.eTag)
+
+	return
+}
+
+// `readPart` reads, a cache line at a time, the content of the src file to be uploaded as partNumber.
+func (cp *copyStruct) readPart(srcETag string, partNumber uint64, size uint64) (partBuf []byte, err error) {
+	var (
+		cacheLinesPerPart = cp.dstBackend.uploadPartCacheLines
+		offsetCacheLine   uint64
+		partBegin         = (partNumber - 1) * cacheLinesPerPart * globals.config.cacheLineSize
+		partEnd           = min(partBegin+(cacheLinesPerPart*globals.config.cacheLineSize), size)
+		readFileOutput    *readFileOutputStruct
+	)
+
+	partBuf = make([]byte, 0, partEnd-partBegin)
+
+	for offsetCacheLine = (partNumber - 1) * cacheLinesPerPart; uint64(len(partBuf)) < (partEnd - partBegin); offsetCacheLine++ {
+		readFileOutput, err = readFileWrapper(cp.srcContext, &readFileInputStruct{
+			filePath:        cp.srcPath,
+			offsetCacheLine: offsetCacheLine,
+			cacheLineSize:   globals.config.cacheLineSize,
+			ifMatch:         srcETag,
+			bulk:            true,
+		})
+		if err != nil {
+			err = fmt.Errorf("unable to read \"%s\" of %s (changed during copy?): %v", cp.srcPath, cp.srcDirName, err)
+			return
+		}
+		if len(readFileOutput.buf) == 0 {
+			err = fmt.Errorf("\"%s\" of %s truncated during copy", cp.srcPath, cp.srcDirName)
+			return
+		}
+
+		partBuf = append(partBuf, readFileOutput.buf...)
+
+		putCacheLineBuf(readFileOutput.buf)
+	}
+
+	if uint64(len(partBuf)) != (partEnd - partBegin) {
+		err = fmt.Errorf("\"%s\" of %s changed size during copy", cp.srcPath, cp.srcDirName)
+	}
+
+	return
+}
+
+// `loadStateFile` returns the progress recorded in cp.stateFile by a prior (interrupted)
+// copy, if any. An unreadable state file is treated as if no progress had been recorded.
+func (cp *copyStruct) loadStateFile() (resumeState *copyResumeStateStruct) {
+	var (
+		err              error
+		stateFileContent []byte
+	)
+
+	if cp.stateFile == "" {
+		return
+	}
+
+	stateFileContent, err = os.ReadFile(cp.stateFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			globals.logger.Printf("[WARN] [copy] %s unable to read \"%s\": %v", cp.id, cp.stateFile, err)
+		}
+		return
+	}
+
+	resumeState = &copyResumeStateStruct{}
+
+	err = json.Unmarshal(stateFileContent, resumeState)
+	if err != nil {
+		globals.logger.Printf("[WARN] [copy] %s unable to parse \"%s\": %v", cp.id, cp.stateFile, err)
+		resumeState = nil
+	}
+
+	return
+}
+
+// `saveStateFile` atomically replaces cp.stateFile (if != "") with resumeState.
+func (cp *copyStruct) saveStateFile(resumeState *copyResumeStateStruct) (err error) {
+	var (
+		stateFileContent []byte
+		tmpStateFile     string
+	)
+
+	if cp.stateFile == "" {
+		return
+	}
+
+	stateFileContent, err = json.Marshal(resumeState)
+	if err != nil {
+		return
+	}
+
+	tmpStateFile = cp.stateFile + ".tmp"
+
+	err = os.WriteFile(tmpStateFile, stateFileContent, 0o600)
+	if err == nil {
+		err = os.Rename(tmpStateFile, cp.stateFile)
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to record progress in \"%s\": %v", cp.stateFile, err)
+	}
+
+	return
+}
+
+// `finish` concludes a copy. If it succeeded, the state file is no longer needed
+// (a subsequent copy of the same file should start afresh) and is removed. Otherwise,
+// it is retained such that requesting the copy again resumes any multipart upload.
+func (cp *copyStruct) finish(err error) {
+	cp.Lock()
+
+	if err != nil {
+		cp.lastErr = err
+		cp.state = CopyStateFailed
+	} else {
+		cp.state = CopyStateDone
+	}
+
+	cp.endTime = time.Now()
+
+	if (cp.state == CopyStateDone) && (cp.stateFile != "") {
+		err = os.Remove(cp.stateFile)
+		if (err != nil) && !errors.Is(err, fs.ErrNotExist) {
+			globals.logger.Printf("[WARN] [copy] %s unable to remove \"%s\": %v", cp.id, cp.stateFile, err)
+		}
+	}
+
+	globals.logger.Printf("[INFO] [copy] %s", cp.statusAlreadyLocked())
+
+	cp.Unlock()
+
+	close(cp.doneChan)
+}
+
+// `status` returns a single line summarizing the progress of a copy.
+func (cp *copyStruct) status() (status string) {
+	cp.Lock()
+	status = cp.statusAlreadyLocked()
+	cp.Unlock()
+
+	return
+}
+
+// `statusAlreadyLocked` is called while cp.Lock() is held to
+// return a single line summarizing the progress of a copy.
+func (cp *copyStruct) statusAlreadyLocked() (status string) {
+	var (
+		elapsed time.Duration
+	)
+
+	if cp.state == CopyStateRunning {
+		elapsed = time.Since(cp.startTime)
+	} else {
+		elapsed = cp.endTime.Sub(cp.startTime)
+	}
+
+	status = fmt.Sprintf("%s %s:\"%s\" -> %s:\"%s\" rename:%v state:%s bytes:%v/%v parts:%v resumed:%v elapsed:%v",
+		cp.id, cp.srcDirName, cp.srcPath, cp.dstDirName, cp.dstPath, cp.rename, cp.state,
+		cp.bytesCopied, cp.size, cp.partsUploaded, cp.partsResumed,
+		elapsed.Truncate(time.Millisecond))
+
+	if cp.lastErr != nil {
+		status += fmt.Sprintf(" last_err:\"%v\"", cp.lastErr)
+	}
+
+	return
+}
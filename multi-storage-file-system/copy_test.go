@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// `testCopyMultipartContextStruct` overlays a backend's context with multipart upload support,
+// staging the parts of each upload and writing them out in their entirety upon completion.
+// Once failParts reaches zero, each subsequent uploadPart() fails.
+type testCopyMultipartContextStruct struct {
+	backendContextIf
+	uploads   map[string][][]byte // Key: uploadID
+	nextID    int
+	failParts int
+}
+
+func (testCopyMultipartContext *testCopyMultipartContextStruct) createMultipartUpload(createMultipartUploadInput *createMultipartUploadInputStruct) (createMultipartUploadOutput *createMultipartUploadOutputStruct, err error) {
+	testCopyMultipartContext.nextID++
+	createMultipartUploadOutput = &createMultipartUploadOutputStruct{
+		uploadID: strconv.Itoa(testCopyMultipartContext.nextID),
+	}
+	testCopyMultipartContext.uploads[createMultipartUploadOutput.uploadID] = nil
+	return
+}
+
+func (testCopyMultipartContext *testCopyMultipartContextStruct) uploadPart(uploadPartInput *uploadPartInputStruct) (uploadPartOutput *uploadPartOutputStruct, err error) {
+	var (
+		parts [][]byte
+	)
+
+	if testCopyMultipartContext.failParts == 0 {
+		err = errors.New("injected failure")
+		return
+	}
+	testCopyMultipartContext.failParts--
+
+	parts = testCopyMultipartContext.uploads[uploadPartInput.uploadID]
+	if uint64(len(parts)) != (uploadPartInput.partNumber - 1) {
+		err = errors.New("part uploaded out of order")
+		return
+	}
+
+	testCopyMultipartContext.uploads[uploadPartInput.uploadID] = append(parts, bytes.Clone(uploadPartInput.buf))
+	uploadPartOutput = &uploadPartOutputStruct{
+		eTag: strconv.FormatUint(uploadPartInput.partNumber, 10),
+	}
+	return
+}
+
+func (testCopyMultipartContext *testCopyMultipartContextStruct) completeMultipartUpload(completeMultipartUploadInput *completeMultipartUploadInputStruct) (completeMultipartUploadOutput *completeMultipartUploadOutputStruct, err error) {
+	var (
+		writeFileOutput *writeFileOutputStruct
+	)
+
+	writeFileOutput, err = testCopyMultipartContext.backendContextIf.writeFile(&writeFileInputStruct{
+		filePath: completeMultipartUploadInput.filePath,
+		buf:      bytes.Join(testCopyMultipartContext.uploads[completeMultipartUploadInput.uploadID], nil),
+	})
+	if err == nil {
+		completeMultipartUploadOutput = &completeMultipartUploadOutputStruct{
+			eTag: writeFileOutput.eTag,
+		}
+	}
+	return
+}
+
+func waitForCopy(t *testing.T, cp *copyStruct) {
+	select {
+	case <-cp.doneChan:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("copy failed to complete: %s", cp.status())
+	}
+}
+
+func TestCopy(t *testing.T) {
+	var (
+		cp                       *copyStruct
+		dstBackend               *backendStruct
+		err                      error
+		fileContent              []byte
+		largeContent             = []byte("abcdefghijklmnopqrstuvwxyz0123")
+		migrationStateDir        = t.TempDir()
+		ok                       bool
+		srcBackend               *backendStruct
+		testCopyMultipartContext *testCopyMultipartContextStruct
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"cache_line_size": 4,
+		"migration_state_dir": "`+migrationStateDir+`",
+		"backends": [
+			{
+				"dir_name": "src",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "dst",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"multipart_cache_line_threshold": 1,
+				"upload_part_cache_lines": 2
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	srcBackend, ok = globals.config.backends["src"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"src\"] returned !ok")
+	}
+	dstBackend, ok = globals.config.backends["dst"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"dst\"] returned !ok")
+	}
+
+	for filePath, content := range map[string][]byte{"small": []byte("tiny"), "large": largeContent} {
+		_, err = writeFileWrapper(srcBackend.context, &writeFileInputStruct{filePath: filePath, buf: content})
+		if err != nil {
+			t.Fatalf("writeFileWrapper(src, \"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	_, err = startCopy("src", "small", "src", "copy", false)
+	if err == nil {
+		t.Fatalf("startCopy(\"src\", ..., \"src\", ...) unexpectedly succeeded")
+	}
+	_, err = startCopy("src", "dir/", "dst", "", false)
+	if err == nil {
+		t.Fatalf("startCopy(..., \"dir/\", ...) unexpectedly succeeded")
+	}
+
+	// A file within multipart_cache_line_threshold is renamed with a single writeFile()
+
+	cp, err = startCopy("src", "small", "dst", "renamed", true)
+	if err != nil {
+		t.Fatalf("startCopy(\"src\", \"small\", \"dst\", \"renamed\", true) failed: %v", err)
+	}
+
+	waitForCopy(t, cp)
+
+	if cp.state != CopyStateDone {
+		t.Fatalf("rename concluded with unexpected status: %s", cp.status())
+	}
+
+	fileContent, _, err = readWholeFile(dstBackend.context, "renamed")
+	if (err != nil) || !bytes.Equal(fileContent, []byte("tiny")) {
+		t.Fatalf("readWholeFile(dst, \"renamed\") returned %q, %v", fileContent, err)
+	}
+
+	_, err = statFileWrapper(srcBackend.context, &statFileInputStruct{filePath: "small"})
+	if err == nil {
+		t.Fatalf("statFileWrapper(src, \"small\") after rename unexpectedly succeeded")
+	}
+
+	// A larger file falls back to a single writeFile() as the RAM backend cannot perform multipart uploads
+
+	_, err = dstBackend.context.createMultipartUpload(&createMultipartUploadInputStruct{filePath: "large"})
+	if !errors.Is(err, syscall.ENOTSUP) {
+		t.Fatalf("createMultipartUpload() of RAM backend returned %v (expected ENOTSUP)", err)
+	}
+
+	cp, err = startCopy("src", "large", "dst", "fallback", false)
+	if err != nil {
+		t.Fatalf("startCopy(\"src\", \"large\", \"dst\", \"fallback\", false) failed: %v", err)
+	}
+
+	waitForCopy(t, cp)
+
+	if (cp.state != CopyStateDone) || (cp.partsUploaded != 0) || (cp.bytesCopied != uint64(len(largeContent))) {
+		t.Fatalf("fallback copy concluded with unexpected status: %s", cp.status())
+	}
+
+	// With multipart support, an interrupted copy (after 2 of its 4 parts) resumes with the 3rd part
+
+	testCopyMultipartContext = &testCopyMultipartContextStruct{
+		backendContextIf: dstBackend.context,
+		uploads:          make(map[string][][]byte),
+		failParts:        2,
+	}
+	dstBackend.context = testCopyMultipartContext
+
+	cp, err = startCopy("src", "large", "dst", "", false)
+	if err != nil {
+		t.Fatalf("startCopy(\"src\", \"large\", \"dst\", \"\", false) failed: %v", err)
+	}
+
+	waitForCopy(t, cp)
+
+	if (cp.state != CopyStateFailed) || (cp.partsUploaded != 2) {
+		t.Fatalf("interrupted copy concluded with unexpected status: %s", cp.status())
+	}
+
+	testCopyMultipartContext.failParts = -1
+
+	cp, err = startCopy("src", "large", "dst", "", false)
+	if err != nil {
+		t.Fatalf("startCopy(\"src\", \"large\", \"dst\", \"\", false) [resume] failed: %v", err)
+	}
+
+	waitForCopy(t, cp)
+
+	if (cp.state != CopyStateDone) || (cp.partsResumed != 2) || (cp.partsUploaded != 2) || (cp.bytesCopied != uint64(len(largeContent))) {
+		t.Fatalf("resumed copy concluded with unexpected status: %s", cp.status())
+	}
+	if testCopyMultipartContext.nextID != 1 {
+		t.Fatalf("resumed copy created %v multipart uploads (expected 1)", testCopyMultipartContext.nextID)
+	}
+
+	fileContent, _, err = readWholeFile(dstBackend.context, "large")
+	if (err != nil) || !bytes.Equal(fileContent, largeContent) {
+		t.Fatalf("readWholeFile(dst, \"large\") returned %q, %v", fileContent, err)
+	}
+
+	_, err = os.Stat(cp.stateFile)
+	if err == nil {
+		t.Fatalf("state file \"%s\" not removed after successful copy", cp.stateFile)
+	}
+}
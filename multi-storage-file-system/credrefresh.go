@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// `credentialRefreshTimeout` bounds how long credential_refresh_{command|endpoint} may
+// take to produce fresh credentials.
+const credentialRefreshTimeout = 30 * time.Second
+
+// `credentialRefreshOutputStruct` is the JSON object output by credential_refresh_command
+// or returned by credential_refresh_endpoint. This is the format produced by an AWS
+// `credential_process` (with SessionToken) as well as by a container credentials endpoint
+// (with Token). If Expiration is "", the credentials do not expire.
+type credentialRefreshOutputStruct struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+	Token           string `json:"Token"`
+	Expiration      string `json:"Expiration"`
+}
+
+// `credentialRefreshConfigured` returns whether fresh credentials may be obtained
+// from either credential_refresh_command or credential_refresh_endpoint.
+func (backendS3 *backendConfigS3Struct) credentialRefreshConfigured() bool {
+	return (backendS3.credentialRefreshCommand != "") || (backendS3.credentialRefreshEndpoint != "")
+}
+
+// `refreshCredentials` obtains fresh credentials by running credential_refresh_command
+// (via "/bin/sh -c") or by fetching credential_refresh_endpoint.
+func (backendS3 *backendConfigS3Struct) refreshCredentials(ctx context.Context) (awsCredentials aws.Credentials, err error) {
+	var (
+		cancel                  context.CancelFunc
+		credentialRefreshOutput credentialRefreshOutputStruct
+		httpRequest             *http.Request
+		httpResponse            *http.Response
+		output                  []byte
+	)
+
+	ctx, cancel = context.WithTimeout(ctx, credentialRefreshTimeout)
+	defer cancel()
+
+	if backendS3.credentialRefreshCommand != "" {
+		output, err = exec.CommandContext(ctx, "/bin/sh", "-c", backendS3.credentialRefreshCommand).Output()
+		if err != nil {
+			err = fmt.Errorf("credential_refresh_command failed: %v", err)
+			return
+		}
+	} else {
+		httpRequest, err = http.NewRequestWithContext(ctx, http.MethodGet, backendS3.credentialRefreshEndpoint, nil)
+		if err != nil {
+			err = fmt.Errorf("credential_refresh_endpoint unusable: %v", err)
+			return
+		}
+
+		httpResponse, err = http.DefaultClient.Do(httpRequest)
+		if err != nil {
+			err = fmt.Errorf("credential_refresh_endpoint failed: %v", err)
+			return
+		}
+
+		output, err = io.ReadAll(httpResponse.Body)
+		_ = httpResponse.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("credential_refresh_endpoint failed: %v", err)
+			return
+		}
+
+		if httpResponse.StatusCode != http.StatusOK {
+			err = fmt.Errorf("credential_refresh_endpoint returned %s", httpResponse.Status)
+			return
+		}
+	}
+
+	err = json.Unmarshal(output, &credentialRefreshOutput)
+	if err != nil {
+		err = fmt.Errorf("refreshed credentials unparseable: %v", err)
+		return
+	}
+
+	if (credentialRefreshOutput.AccessKeyID == "") || (credentialRefreshOutput.SecretAccessKey == "") {
+		err = errors.New("refreshed credentials missing AccessKeyId or SecretAccessKey")
+		return
+	}
+
+	awsCredentials = aws.Credentials{
+		AccessKeyID:     credentialRefreshOutput.AccessKeyID,
+		SecretAccessKey: credentialRefreshOutput.SecretAccessKey,
+		SessionToken:    credentialRefreshOutput.SessionToken,
+		Source:          "credential_refresh",
+	}
+	if awsCredentials.SessionToken == "" {
+		awsCredentials.SessionToken = credentialRefreshOutput.Token
+	}
+
+	if strings.TrimSpace(credentialRefreshOutput.Expiration) != "" {
+		awsCredentials.Expires, err = time.Parse(time.RFC3339, strings.TrimSpace(credentialRefreshOutput.Expiration))
+		if err != nil {
+			err = fmt.Errorf("refreshed credentials Expiration unparseable: %v", err)
+			return
+		}
+		awsCredentials.CanExpire = true
+	}
+
+	return
+}
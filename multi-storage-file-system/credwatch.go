@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// `credentialWatchSettleDelay` is how long after the first change observed in a watched
+// directory the watched files are re-read (so that a burst of changes, such as Kubernetes
+// swapping the "..data" symlink of a mounted Secret, is applied just once).
+const credentialWatchSettleDelay = 100 * time.Millisecond
+
+// `credentialWatchStruct` tracks, for an S3 backend using use_credentials_env or an AIStore backend specifying authn_token_file, the background
+// worker watching those files and reloading the backend's credentials as they change.
+type credentialWatchStruct struct {
+	backend       *backendStruct    //
+	watcher       *fsnotify.Watcher // Watches the directory containing each of filePaths
+	filePaths     []string          // Files from which the backend's credentials are loaded
+	contents      map[string][]byte // Contents of each of filePaths as last read (nil if unreadable)
+	stopChan      chan struct{}     // Closed to stop monitor()
+	stopWaitGroup sync.WaitGroup    // Awaited after closing stopChan
+}
+
+// `credentialFilePaths` returns the files from which backend's credentials are loaded.
+func (backend *backendStruct) credentialFilePaths() (filePaths []string) {
+	switch backendTypeSpecifics := backend.backendTypeSpecifics.(type) {
+	case *backendConfigAIStoreStruct:
+		if backendTypeSpecifics.authnTokenFile != "" {
+			filePaths = append(filePaths, backendTypeSpecifics.authnTokenFile)
+		}
+	case *backendConfigS3Struct:
+		// The config file only matters if it may specify how credentials are obtained
+
+		if backendTypeSpecifics.useCredentialsEnv {
+			filePaths = append(filePaths, backendTypeSpecifics.credentialsFilePath)
+			if backendTypeSpecifics.useConfigEnv {
+				filePaths = append(filePaths, backendTypeSpecifics.configFilePath)
+			}
+		}
+	}
+
+	return
+}
+
+// `refreshCredentialWatchesAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start watching the credential files of each newly
+// mounted backend loading its credentials from any.
+func refreshCredentialWatchesAlreadyLocked() {
+	var (
+		backend *backendStruct
+	)
+
+	for _, backend = range globals.config.backends {
+		if backend.credentialWatch == nil {
+			backend.startCredentialWatchAlreadyLocked()
+		}
+	}
+}
+
+// `startCredentialWatchAlreadyLocked` is called while globals.Lock() is held to start
+// watching the credential files of backend (if it loads its credentials from any).
+// The directory containing each file is watched, rather than the file itself, so that
+// a file replaced by a rename or a symlink swap continues to be watched.
+func (backend *backendStruct) startCredentialWatchAlreadyLocked() {
+	var (
+		credentialWatch *credentialWatchStruct
+		dirPath         string
+		dirPathsWatched = make(map[string]struct{})
+		err             error
+		filePath        string
+		filePaths       = backend.credentialFilePaths()
+		ok              bool
+	)
+
+	if len(filePaths) == 0 {
+		return
+	}
+
+	credentialWatch = &credentialWatchStruct{
+		backend:  backend,
+		contents: make(map[string][]byte),
+		stopChan: make(chan struct{}),
+	}
+
+	credentialWatch.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		globals.logger.Printf("[WARN] [credentials] %s unable to watch credential files: %v", backend.dirName, err)
+		return
+	}
+
+	for _, filePath = range filePaths {
+		filePath = filepath.Clean(filePath)
+		credentialWatch.filePaths = append(credentialWatch.filePaths, filePath)
+		credentialWatch.contents[filePath], _ = os.ReadFile(filePath)
+
+		dirPath = filepath.Dir(filePath)
+		_, ok = dirPathsWatched[dirPath]
+		if ok {
+			continue
+		}
+
+		err = credentialWatch.watcher.Add(dirPath)
+		if err != nil {
+			globals.logger.Printf("[WARN] [credentials] %s unable to watch \"%s\": %v", backend.dirName, dirPath, err)
+			continue
+		}
+
+		dirPathsWatched[dirPath] = struct{}{}
+	}
+
+	if len(dirPathsWatched) == 0 {
+		_ = credentialWatch.watcher.Close()
+		return
+	}
+
+	backend.credentialWatch = credentialWatch
+
+	backend.credentialWatch.stopWaitGroup.Go(backend.credentialWatch.monitor)
+}
+
+// `stopCredentialWatchAlreadyLocked` is called while globals.Lock() is held as backend
+// is unmounted (or its credential files are changed via SIGHUP) to stop watching its
+// credential files (if it was doing so).
+func (backend *backendStruct) stopCredentialWatchAlreadyLocked() {
+	if backend.credentialWatch != nil {
+		close(backend.credentialWatch.stopChan)
+		backend.credentialWatch.stopWaitGroup.Wait()
+		_ = backend.credentialWatch.watcher.Close()
+		backend.credentialWatch = nil
+	}
+}
+
+// `monitor` is run as a background worker while the backend is mounted to reload its
+// credentials once any of its credential files has changed.
+func (credentialWatch *credentialWatchStruct) monitor() {
+	var (
+		backend     = credentialWatch.backend
+		err         error
+		ok          bool
+		settleTimer <-chan time.Time
+	)
+
+	for {
+		select {
+		case <-credentialWatch.stopChan:
+			return
+		case _, ok = <-credentialWatch.watcher.Events:
+			if !ok {
+				return
+			}
+			if settleTimer == nil {
+				settleTimer = time.After(credentialWatchSettleDelay)
+			}
+		case err, ok = <-credentialWatch.watcher.Errors:
+			if !ok {
+				return
+			}
+			globals.logger.Printf("[WARN] [credentials] %s error watching credential files: %v", backend.dirName, err)
+		case <-settleTimer:
+			settleTimer = nil
+			credentialWatch.reloadIfChanged()
+		}
+	}
+}
+
+// `reloadIfChanged` re-reads each credential file and, should any have changed, reloads
+// the backend's credentials such that subsequent requests use them.
+func (credentialWatch *credentialWatchStruct) reloadIfChanged() {
+	var (
+		backend         = credentialWatch.backend
+		changedFilePath string
+		contents        []byte
+		err             error
+		filePath        string
+	)
+
+	for _, filePath = range credentialWatch.filePaths {
+		contents, _ = os.ReadFile(filePath)
+		if bytes.Equal(contents, credentialWatch.contents[filePath]) {
+			continue
+		}
+		credentialWatch.contents[filePath] = contents
+		if changedFilePath == "" {
+			changedFilePath = filePath
+		}
+	}
+
+	if changedFilePath == "" {
+		return
+	}
+
+	switch backendContext := backend.context.(type) {
+	case *aistoreContextStruct:
+		backendContext.reloadAuthnTokenFile()
+	case *s3ContextStruct:
+		err = backendContext.reloadSharedCredentials()
+	}
+	if err != nil {
+		globals.logger.Printf("[WARN] [credentials] %s unable to reload credentials after \"%s\" changed: %v", backend.dirName, changedFilePath, err)
+		return
+	}
+
+	globals.logger.Printf("[INFO] [credentials] %s reloaded credentials after \"%s\" changed", backend.dirName, changedFilePath)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// `testUpdateSecretDir` updates the files in dirPath the way Kubernetes updates a mounted
+// Secret: each file is a symlink to ..data/<file> and ..data is a symlink atomically
+// swapped to a newly written timestamped directory holding every file's contents.
+func testUpdateSecretDir(t *testing.T, dirPath string, files map[string]string) {
+	var (
+		contents    string
+		dataDirName = time.Now().Format("..2006_01_02_15_04_05.000000000")
+		err         error
+		fileName    string
+	)
+
+	err = os.Mkdir(filepath.Join(dirPath, dataDirName), 0o755)
+	if err != nil {
+		t.Fatalf("os.Mkdir() failed: %v", err)
+	}
+	for fileName, contents = range files {
+		err = os.WriteFile(filepath.Join(dirPath, dataDirName, fileName), []byte(contents), 0o600)
+		if err != nil {
+			t.Fatalf("os.WriteFile() failed: %v", err)
+		}
+	}
+	err = os.Symlink(dataDirName, filepath.Join(dirPath, "..data_tmp"))
+	if err != nil {
+		t.Fatalf("os.Symlink(\"..data_tmp\") failed: %v", err)
+	}
+	err = os.Rename(filepath.Join(dirPath, "..data_tmp"), filepath.Join(dirPath, "..data"))
+	if err != nil {
+		t.Fatalf("os.Rename(\"..data_tmp\", \"..data\") failed: %v", err)
+	}
+	for fileName = range files {
+		_, err = os.Lstat(filepath.Join(dirPath, fileName))
+		if err != nil {
+			err = os.Symlink(filepath.Join("..data", fileName), filepath.Join(dirPath, fileName))
+			if err != nil {
+				t.Fatalf("os.Symlink(\"%s\") failed: %v", fileName, err)
+			}
+		}
+	}
+}
+
+func TestCredentialWatch(t *testing.T) {
+	var (
+		aisBackend     *backendStruct
+		aisContext     *aistoreContextStruct
+		awsCredentials aws.Credentials
+		err            error
+		ok             bool
+		s3Backend      *backendStruct
+		s3Context      *s3ContextStruct
+		secretDirPath  = t.TempDir()
+		token          string
+	)
+
+	if globals.logger == nil {
+		globals.logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmsgprefix)
+	}
+
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	testUpdateSecretDir(t, secretDirPath, map[string]string{
+		"credentials": "[msfs]\naws_access_key_id = accessKeyID1\naws_secret_access_key = secretAccessKey1\n",
+		"token":       `{"token":"token1"}`,
+	})
+
+	// An S3 backend using use_credentials_env picks up a rotated credentials file
+
+	s3Backend = &backendStruct{
+		dirName:             "s3",
+		bucketContainerName: "dev",
+		backendTypeSpecifics: &backendConfigS3Struct{
+			configCredentialsProfile: "msfs",
+			useCredentialsEnv:        true,
+			credentialsFilePath:      filepath.Join(secretDirPath, "credentials"),
+			region:                   "us-east-1",
+			endpoint:                 "http://minio:9000",
+			retryMode:                S3RetryModeStandard,
+			retryAttempts:            1,
+		},
+	}
+
+	err = s3Backend.setupS3Context()
+	if err != nil {
+		t.Fatalf("setupS3Context() failed: %v", err)
+	}
+
+	s3Context, ok = s3Backend.context.(*s3ContextStruct)
+	if !ok {
+		t.Fatalf("s3Backend.context.(*s3ContextStruct) returned !ok")
+	}
+
+	// An AIStore backend specifying authn_token_file picks up a rotated token file
+
+	aisBackend = &backendStruct{
+		dirName: "ais",
+		backendTypeSpecifics: &backendConfigAIStoreStruct{
+			authnTokenFile: filepath.Join(secretDirPath, "token"),
+		},
+	}
+
+	aisContext = &aistoreContextStruct{
+		backend: aisBackend,
+		baseParams: api.BaseParams{
+			Token: "token1",
+		},
+	}
+	aisBackend.context = aisContext
+
+	s3Backend.startCredentialWatchAlreadyLocked()
+	defer s3Backend.stopCredentialWatchAlreadyLocked()
+	aisBackend.startCredentialWatchAlreadyLocked()
+	defer aisBackend.stopCredentialWatchAlreadyLocked()
+
+	if (s3Backend.credentialWatch == nil) || (aisBackend.credentialWatch == nil) {
+		t.Fatalf("startCredentialWatchAlreadyLocked() did not start watching")
+	}
+
+	awsCredentials, err = s3Context.s3Client.Options().Credentials.Retrieve(context.Background())
+	if (err != nil) || (awsCredentials.AccessKeyID != "accessKeyID1") || (awsCredentials.SecretAccessKey != "secretAccessKey1") {
+		t.Fatalf("Credentials.Retrieve() returned %+v, %v (expected accessKeyID1)", awsCredentials, err)
+	}
+
+	testUpdateSecretDir(t, secretDirPath, map[string]string{
+		"credentials": "[msfs]\naws_access_key_id = accessKeyID2\naws_secret_access_key = secretAccessKey2\n",
+		"token":       `{"token":"token2"}`,
+	})
+
+	for range 50 {
+		time.Sleep(100 * time.Millisecond)
+
+		awsCredentials, err = s3Context.s3Client.Options().Credentials.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("Credentials.Retrieve() failed: %v", err)
+		}
+
+		aisContext.Lock()
+		token = aisContext.baseParams.Token
+		aisContext.Unlock()
+
+		if (awsCredentials.AccessKeyID == "accessKeyID2") && (token == "token2") {
+			break
+		}
+	}
+
+	if (awsCredentials.AccessKeyID != "accessKeyID2") || (awsCredentials.SecretAccessKey != "secretAccessKey2") {
+		t.Fatalf("Credentials.Retrieve() after rotation returned %+v (expected accessKeyID2)", awsCredentials)
+	}
+	if token != "token2" {
+		t.Fatalf("aisContext.baseParams.Token after rotation == \"%s\" (expected \"token2\")", token)
+	}
+
+	// Stopping the watch is idempotent
+
+	s3Backend.stopCredentialWatchAlreadyLocked()
+	if s3Backend.credentialWatch != nil {
+		t.Fatalf("stopCredentialWatchAlreadyLocked() left s3Backend.credentialWatch != nil")
+	}
+}
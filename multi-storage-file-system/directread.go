@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+)
+
+// `directReadLine` is called while globals.Lock() is held to return the directReadLineStruct
+// (possibly still being fetched) of lineNumber of fh's file. Should its fetch (or those of the
+// up to cacheLinesToPrefetch cache lines that follow it) not yet have been started, they are
+// started now with the count of those started returned as bypasses. Any of fh.directReadLines
+// outside that range are discarded.
+func (fh *fhStruct) directReadLine(lineNumber uint64, cacheLinesToPrefetch uint64, traceCtx context.Context) (directReadLine *directReadLineStruct, bypasses uint64) {
+	var (
+		directReadLines      []*directReadLineStruct
+		inode                = fh.inode
+		lineNumberMax        uint64
+		lineNumberMaxInInode uint64
+		nextLineNumber       uint64
+	)
+
+	lineNumberMaxInInode = ((inode.sizeInBackend + inode.cacheLineSize - 1) / inode.cacheLineSize) - 1
+	lineNumberMax = min(lineNumber+cacheLinesToPrefetch, lineNumberMaxInInode)
+
+	directReadLines = make([]*directReadLineStruct, 0, lineNumberMax-lineNumber+1)
+
+	// As fh.directReadLines is in lineNumber order, each of those retained may simply be
+	// preceded by those (of [lineNumber:lineNumberMax]) it is missing
+
+	nextLineNumber = lineNumber
+
+	for _, directReadLine = range fh.directReadLines {
+		if (directReadLine.lineNumber < lineNumber) || (directReadLine.lineNumber > lineNumberMax) {
+			if directReadLine.fetched {
+				putCacheLineBuf(directReadLine.content)
+			}
+			continue
+		}
+
+		for ; nextLineNumber < directReadLine.lineNumber; nextLineNumber++ {
+			directReadLines = append(directReadLines, fh.startDirectReadLine(nextLineNumber, nextLineNumber != lineNumber, traceCtx))
+			bypasses++
+		}
+
+		directReadLines = append(directReadLines, directReadLine)
+		nextLineNumber++
+	}
+
+	for ; nextLineNumber <= lineNumberMax; nextLineNumber++ {
+		directReadLines = append(directReadLines, fh.startDirectReadLine(nextLineNumber, nextLineNumber != lineNumber, traceCtx))
+		bypasses++
+	}
+
+	fh.directReadLines = directReadLines
+
+	directReadLine = directReadLines[0]
+
+	return
+}
+
+// `startDirectReadLine` is called while globals.Lock() is held to start fetching lineNumber
+// of fh's file (as a bulk request if it is merely being read ahead).
+func (fh *fhStruct) startDirectReadLine(lineNumber uint64, bulk bool, traceCtx context.Context) (directReadLine *directReadLineStruct) {
+	var (
+		backend       *backendStruct
+		readFileInput *readFileInputStruct
+	)
+
+	directReadLine = &directReadLineStruct{
+		lineNumber: lineNumber,
+	}
+
+	directReadLine.done.Add(1)
+
+	backend = fh.inode.backend
+	readFileInput = &readFileInputStruct{
+		filePath:        fh.inode.objectPath,
+		offsetCacheLine: lineNumber,
+		cacheLineSize:   fh.inode.cacheLineSize,
+		ifMatch:         "",
+		bulk:            bulk,
+		traceCtx:        traceCtx,
+	}
+
+	globals.directReadWaitGroup.Go(func() { directReadLine.fetch(backend, readFileInput) })
+
+	return
+}
+
+// `fetch` reads directReadLine (per readFileInput) from backend.
+func (directReadLine *directReadLineStruct) fetch(backend *backendStruct, readFileInput *readFileInputStruct) {
+	var (
+		err            error
+		readFileOutput *readFileOutputStruct
+	)
+
+	readFileOutput, err = readFileWrapper(backend.context, readFileInput)
+
+	globals.Lock()
+	if err == nil {
+		directReadLine.content = readFileOutput.buf
+	} else {
+		directReadLine.err = err
+	}
+	directReadLine.fetched = true
+	globals.Unlock()
+
+	directReadLine.done.Done()
+}
+
+// `discardDirectReadLines` is called while globals.Lock() is held to discard fh.directReadLines
+// (e.g. once fh's reads are no longer sequential).
+func (fh *fhStruct) discardDirectReadLines() {
+	var (
+		directReadLine *directReadLineStruct
+	)
+
+	for _, directReadLine = range fh.directReadLines {
+		if directReadLine.fetched {
+			putCacheLineBuf(directReadLine.content)
+		}
+	}
+
+	fh.directReadLines = nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestDirectRead(t *testing.T) {
+	var (
+		bypassesAtStart uint64
+		cacheLineNumber uint64
+		errno           syscall.Errno
+		fh              *fhStruct
+		fileBFH         uint64
+		fileBIno        uint64
+		fileBOffset     uint64
+		inHeader        *fission.InHeader
+		inode           *inodeStruct
+		lookupIn        *fission.LookupIn
+		lookupOut       *fission.LookupOut
+		openIn          *fission.OpenIn
+		openOut         *fission.OpenOut
+		ramDirIno       uint64
+		readIn          *fission.ReadIn
+		readOut         *fission.ReadOut
+		releaseIn       *fission.ReleaseIn
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// With 1 MiB cache lines (prefetching 4), direct reads begin at the first cache miss at or beyond 8 MiB
+
+	globals.Lock()
+	globals.config.directReadThreshold = 8 * globals.config.cacheLineSize
+	globals.Unlock()
+
+	bypassesAtStart = counterValue(globals.fissionMetrics.ReadCacheBypasses)
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileB"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	openIn = &fission.OpenIn{
+		Flags: fission.FOpenRequestRDONLY,
+	}
+	openOut, errno = globals.DoOpen(inHeader, openIn)
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBFH = openOut.FH
+
+	for fileBOffset = 0; fileBOffset < (16 * globals.config.cacheLineSize); fileBOffset += uint64(len(readOut.Data)) {
+		inHeader = &fission.InHeader{
+			NodeID: fileBIno,
+		}
+		readIn = &fission.ReadIn{
+			FH:     fileBFH,
+			Offset: fileBOffset,
+			Size:   uint32(globals.config.cacheLineSize),
+		}
+		readOut, errno = globals.DoRead(inHeader, readIn)
+		if errno != 0 {
+			t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly failed (errno: %v)", readIn.Offset, errno)
+		}
+		if !bytes.Equal(readOut.Data, testFissionFileBContent[fileBOffset:(fileBOffset+uint64(len(readOut.Data)))]) {
+			t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly returned mismatched bytes", readIn.Offset)
+		}
+	}
+
+	if counterValue(globals.fissionMetrics.ReadCacheBypasses) == bypassesAtStart {
+		t.Fatalf("sequential DoRead()'s beyond direct_read_threshold unexpectedly bypassed no cache lines")
+	}
+
+	globals.Lock()
+
+	inode = globals.inodeMap[fileBIno]
+	fh = inode.fhMap[fileBFH]
+
+	for cacheLineNumber = range inode.cache {
+		if cacheLineNumber >= 12 {
+			globals.Unlock()
+			t.Fatalf("sequential DoRead()'s beyond direct_read_threshold unexpectedly cached line %v", cacheLineNumber)
+		}
+	}
+
+	if len(fh.directReadLines) == 0 {
+		globals.Unlock()
+		t.Fatalf("sequential DoRead()'s beyond direct_read_threshold unexpectedly retained no read ahead")
+	}
+
+	globals.Unlock()
+
+	// A non-sequential read discards what was read ahead and restarts the count
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	readIn = &fission.ReadIn{
+		FH:     fileBFH,
+		Offset: 0,
+		Size:   uint32(testFissionReadBufSize),
+	}
+	readOut, errno = globals.DoRead(inHeader, readIn)
+	if errno != 0 {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: 0) unexpectedly failed (errno: %v)", errno)
+	}
+	if !bytes.Equal(readOut.Data, testFissionFileBContent[:len(readOut.Data)]) {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: 0) unexpectedly returned mismatched bytes")
+	}
+
+	globals.Lock()
+	if (len(fh.directReadLines) != 0) || (fh.sequentialBytes != uint64(len(readOut.Data))) {
+		globals.Unlock()
+		t.Fatalf("non-sequential DoRead() left %v direct read lines & sequentialBytes == %v", len(fh.directReadLines), fh.sequentialBytes)
+	}
+	globals.Unlock()
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	releaseIn = &fission.ReleaseIn{
+		FH: fileBFH,
+	}
+	errno = globals.DoRelease(inHeader, releaseIn)
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileBFH) unexpectedly failed (errno: %v)", errno)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+)
+
+// `directoryMarkerFolderSuffix` is appended to a directory's path (less its trailing "/")
+// to form the path of its marker object when directory_markers == DirectoryMarkersFolder.
+const directoryMarkerFolderSuffix = "_$folder$"
+
+// `directoryMarkerPath` returns the path of the marker object for dirPath (ending in "/")
+// according to backend.directoryMarkers or "" if directories are not marked.
+func (backend *backendStruct) directoryMarkerPath(dirPath string) (markerPath string) {
+	switch backend.directoryMarkers {
+	case DirectoryMarkersSlash:
+		markerPath = dirPath
+	case DirectoryMarkersFolder:
+		markerPath = strings.TrimSuffix(dirPath, "/") + directoryMarkerFolderSuffix
+	default: // DirectoryMarkersNone
+		markerPath = ""
+	}
+
+	return
+}
+
+// `createDirectoryMarker` writes the zero-byte marker object for dirPath (ending in "/")
+// such that the (otherwise empty) directory is seen by later mounts and other tools.
+func (backend *backendStruct) createDirectoryMarker(dirPath string) (err error) {
+	var (
+		markerPath = backend.directoryMarkerPath(dirPath)
+	)
+
+	if markerPath == "" {
+		return
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{
+		filePath: markerPath,
+		buf:      []byte{},
+	})
+
+	return
+}
+
+// `deleteDirectoryMarkers` removes any marker objects of dirPath (ending in "/"), that is
+// any "dir/" marker (as these are honored whatever backend.directoryMarkers) as well as,
+// if directory_markers == DirectoryMarkersFolder, any "<dir>_$folder$" marker.
+func (backend *backendStruct) deleteDirectoryMarkers(dirPath string) (err error) {
+	var (
+		deleteFilesInput = &deleteFilesInputStruct{
+			filePaths: []string{dirPath},
+		}
+	)
+
+	if backend.directoryMarkers == DirectoryMarkersFolder {
+		deleteFilesInput.filePaths = append(deleteFilesInput.filePaths, backend.directoryMarkerPath(dirPath))
+	}
+
+	_, err = deleteFilesWrapper(backend.context, deleteFilesInput)
+
+	return
+}
+
+// `directoryMarkerMergeListDirectory` is called with each page of a listDirectory() of
+// backend to omit the "dir/" marker of dirPath itself (listed by S3 as a file with an
+// empty basename) and, if directory_markers == DirectoryMarkersFolder, to list each
+// "<subdirectory>_$folder$" marker as the (possibly otherwise empty) subdirectory.
+func (backend *backendStruct) directoryMarkerMergeListDirectory(listDirectoryOutput *listDirectoryOutputStruct) {
+	var (
+		file               listDirectoryOutputFileStruct
+		files              []listDirectoryOutputFileStruct
+		listedSubdirectory string
+		ok                 bool
+		subdirectory       string
+		subdirectorySet    map[string]struct{}
+	)
+
+	files = listDirectoryOutput.file[:0]
+
+	for _, file = range listDirectoryOutput.file {
+		if file.basename == "" {
+			continue
+		}
+
+		if backend.directoryMarkers == DirectoryMarkersFolder {
+			subdirectory, ok = strings.CutSuffix(file.basename, directoryMarkerFolderSuffix)
+			if ok && (subdirectory != "") {
+				if subdirectorySet == nil {
+					subdirectorySet = make(map[string]struct{}, len(listDirectoryOutput.subdirectory))
+					for _, listedSubdirectory = range listDirectoryOutput.subdirectory {
+						subdirectorySet[listedSubdirectory] = struct{}{}
+					}
+				}
+				_, ok = subdirectorySet[subdirectory]
+				if !ok {
+					subdirectorySet[subdirectory] = struct{}{}
+					listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, subdirectory)
+				}
+				continue
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	listDirectoryOutput.file = files
+}
+
+// `directoryMarkerHasDirectory` is called when a statDirectory() of backend found
+// nothing beneath dirPath (ending in "/") to report whether it is nonetheless marked
+// by a "<dir>_$folder$" marker (a "dir/" marker would itself have been found). The
+// marker is sought (via statFileWrapper()) only after statDirectory() released its
+// QoS slot.
+func (backend *backendStruct) directoryMarkerHasDirectory(dirPath string) (hasDirectory bool) {
+	var (
+		err error
+	)
+
+	if backend.directoryMarkers != DirectoryMarkersFolder {
+		return
+	}
+
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{
+		filePath: backend.directoryMarkerPath(dirPath),
+	})
+
+	hasDirectory = (err == nil)
+
+	return
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	duDepthDefault    = uint64(1)
+	duParallelDefault = uint64(16)
+	duParallelMax     = uint64(256)
+)
+
+// `duOptionsStruct` holds the options of diskUsage().
+type duOptionsStruct struct {
+	depth    uint64 // Subdirectories up to this many levels beneath the target are reported individually
+	parallel uint64 // Number of directories listed concurrently
+}
+
+// `duTotalStruct` accumulates the files within (at any depth) a reported directory.
+type duTotalStruct struct {
+	bytes   uint64
+	objects uint64
+}
+
+// `duWalkerStruct` tracks the progress of diskUsage().
+type duWalkerStruct struct {
+	sync.Mutex                             // Protects the fields below
+	cond         *sync.Cond                // Signaled as dirPathQueue grows or the walk completes
+	backend      *backendStruct            //
+	rootDirPath  string                    // Relative to backend.prefix; if != "", ends with a trailing "/"
+	options      *duOptionsStruct          //
+	skipVersions bool                      // If true, each s3VersionsDirName subdirectory is not walked
+	dirPathQueue []string                  // Each relative to backend.prefix with a trailing "/" yet to be listed
+	listing      uint64                    // Number of directories currently being listed
+	totals       map[string]*duTotalStruct // Key is relative to rootDirPath without a trailing "/" (the root being "")
+	dirsListed   uint64
+	dirsFailed   uint64
+	firstErr     error
+}
+
+// `diskUsage` reports to w, as would `du`, the total bytes and number of objects beneath
+// the directory identified by target (of the form <dir_name>[/<path>]) and each of its
+// subdirectories up to options.depth levels beneath it. Rather than walking the tree one
+// directory at a time, options.parallel directories are listed (page by page) concurrently
+// with each subdirectory found queued for the next available lister. Subdirectories are
+// reported (sorted by path) before the target itself. An error is returned if any
+// directory could not be listed (in which case the totals reported are incomplete).
+func diskUsage(w io.Writer, target string, options *duOptionsStruct) (err error) {
+	var (
+		backend      *backendStruct
+		ok           bool
+		path         string
+		relDirPath   string
+		relDirPaths  []string
+		s3ConfigData *backendConfigS3Struct
+		total        *duTotalStruct
+		walker       *duWalkerStruct
+		workerGroup  sync.WaitGroup
+	)
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+
+	walker = &duWalkerStruct{
+		backend: backend,
+		options: options,
+		totals:  make(map[string]*duTotalStruct),
+	}
+	walker.cond = sync.NewCond(&walker.Mutex)
+
+	if path != "" {
+		walker.rootDirPath = path + "/"
+
+		_, err = backend.context.statDirectory(&statDirectoryInputStruct{dirPath: walker.rootDirPath})
+		if err != nil {
+			err = inspectStatError(target, "no such directory", err)
+			return
+		}
+	}
+
+	s3ConfigData, ok = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+	walker.skipVersions = ok && s3ConfigData.exposeVersions
+
+	walker.totals[""] = &duTotalStruct{}
+	walker.dirPathQueue = []string{walker.rootDirPath}
+
+	for range max(options.parallel, 1) {
+		workerGroup.Go(walker.worker)
+	}
+
+	workerGroup.Wait()
+
+	relDirPaths = make([]string, 0, len(walker.totals))
+	for relDirPath = range walker.totals {
+		if relDirPath != "" {
+			relDirPaths = append(relDirPaths, relDirPath)
+		}
+	}
+	slices.Sort(relDirPaths)
+
+	target = strings.TrimSuffix(target, "/")
+
+	for _, relDirPath = range relDirPaths {
+		total = walker.totals[relDirPath]
+		_, _ = fmt.Fprintf(w, "%15d  %12d  %s/%s/\n", total.bytes, total.objects, target, relDirPath)
+	}
+
+	total = walker.totals[""]
+	_, _ = fmt.Fprintf(w, "%15d  %12d  %s/\n", total.bytes, total.objects, target)
+
+	if walker.dirsFailed != 0 {
+		err = fmt.Errorf("%v of %v directories could not be listed (first err: %v)", walker.dirsFailed, walker.dirsListed+walker.dirsFailed, walker.firstErr)
+	}
+
+	return
+}
+
+// `worker` lists each directory popped from walker.dirPathQueue until the queue is empty
+// and no other worker is still listing a directory (that might yet queue subdirectories).
+func (walker *duWalkerStruct) worker() {
+	var (
+		dirPath string
+		err     error
+	)
+
+	walker.Lock()
+
+	for {
+		for (len(walker.dirPathQueue) == 0) && (walker.listing != 0) {
+			walker.cond.Wait()
+		}
+
+		if len(walker.dirPathQueue) == 0 {
+			walker.Unlock()
+			return
+		}
+
+		dirPath = walker.dirPathQueue[len(walker.dirPathQueue)-1]
+		walker.dirPathQueue = walker.dirPathQueue[:len(walker.dirPathQueue)-1]
+		walker.listing++
+
+		walker.Unlock()
+
+		err = walker.list(dirPath)
+
+		walker.Lock()
+
+		walker.listing--
+
+		if err != nil {
+			walker.dirsFailed++
+			if walker.firstErr == nil {
+				walker.firstErr = err
+			}
+			globals.logger.Printf("[WARN] du: %v", err)
+		} else {
+			walker.dirsListed++
+		}
+
+		if (len(walker.dirPathQueue) == 0) && (walker.listing == 0) {
+			walker.cond.Broadcast()
+		}
+	}
+}
+
+// `list` lists (page by page) dirPath adding the size of each file found to the totals
+// of dirPath's reported ancestors (and itself, if reported) and queueing each subdirectory.
+func (walker *duWalkerStruct) list(dirPath string) (err error) {
+	var (
+		file                listDirectoryOutputFileStruct
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		pageBytes           uint64
+		subdirectory        string
+		total               *duTotalStruct
+		totals              []*duTotalStruct
+	)
+
+	totals = walker.reportedTotals(dirPath)
+
+	listDirectoryInput = &listDirectoryInputStruct{
+		maxItems: walker.backend.directoryPageSize,
+		dirPath:  dirPath,
+		bulk:     true,
+	}
+
+	for {
+		listDirectoryOutput, err = walker.backend.context.listDirectory(listDirectoryInput)
+		if err != nil {
+			err = fmt.Errorf("unable to list \"%s\" of %s: %v", dirPath, walker.backend.dirName, err)
+			return
+		}
+
+		pageBytes = 0
+		for _, file = range listDirectoryOutput.file {
+			pageBytes += file.size
+		}
+
+		walker.Lock()
+
+		for _, total = range totals {
+			total.bytes += pageBytes
+			total.objects += uint64(len(listDirectoryOutput.file))
+		}
+
+		for _, subdirectory = range listDirectoryOutput.subdirectory {
+			if walker.skipVersions && (subdirectory == s3VersionsDirName) {
+				continue
+			}
+			walker.dirPathQueue = append(walker.dirPathQueue, dirPath+subdirectory+"/")
+			walker.cond.Signal()
+		}
+
+		walker.Unlock()
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			return
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+}
+
+// `reportedTotals` returns the totals (created as needed) to which files directly within
+// dirPath contribute: that of the root and of each ancestor of dirPath (including itself)
+// no more than options.depth levels beneath the root.
+func (walker *duWalkerStruct) reportedTotals(dirPath string) (totals []*duTotalStruct) {
+	var (
+		components []string
+		depth      int
+		ok         bool
+		relDirPath string
+		total      *duTotalStruct
+	)
+
+	relDirPath = strings.TrimSuffix(strings.TrimPrefix(dirPath, walker.rootDirPath), "/")
+	if relDirPath != "" {
+		components = strings.Split(relDirPath, "/")
+	}
+
+	walker.Lock()
+	defer walker.Unlock()
+
+	totals = append(totals, walker.totals[""])
+
+	for depth = 1; (depth <= len(components)) && (uint64(depth) <= walker.options.depth); depth++ {
+		relDirPath = strings.Join(components[:depth], "/")
+
+		total, ok = walker.totals[relDirPath]
+		if !ok {
+			total = &duTotalStruct{}
+			walker.totals[relDirPath] = total
+		}
+
+		totals = append(totals, total)
+	}
+
+	return
+}
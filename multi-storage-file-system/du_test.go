@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	var (
+		err    error
+		output bytes.Buffer
+		ram    *backendStruct
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+    RAM: {
+      max_directory_page_size: 2,
+    },
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	ram = globals.backendsToMount["ram"]
+
+	// Lay out (with several pages per directory):
+	//
+	//   top          1 byte
+	//   a/           5 files of 10 bytes
+	//   a/x/         3 files of 100 bytes
+	//   a/x/deep/    1 file of 1000 bytes
+	//   b/           2 files of 7 bytes
+
+	for filePath, size := range map[string]int{
+		"top":         1,
+		"a/f0":        10,
+		"a/f1":        10,
+		"a/f2":        10,
+		"a/f3":        10,
+		"a/f4":        10,
+		"a/x/g0":      100,
+		"a/x/g1":      100,
+		"a/x/g2":      100,
+		"a/x/deep/h0": 1000,
+		"b/i0":        7,
+		"b/i1":        7,
+	} {
+		_, err = ram.context.writeFile(&writeFileInputStruct{filePath: filePath, buf: make([]byte, size)})
+		if err != nil {
+			t.Fatalf("writeFile(\"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	for _, testCase := range []struct {
+		target   string
+		options  *duOptionsStruct
+		expected []string
+	}{
+		{"ram", &duOptionsStruct{depth: 0, parallel: 1}, []string{
+			"1365 12 ram/",
+		}},
+		{"ram", &duOptionsStruct{depth: 1, parallel: 4}, []string{
+			"1350 9 ram/a/",
+			"14 2 ram/b/",
+			"1365 12 ram/",
+		}},
+		{"ram/a/", &duOptionsStruct{depth: 2, parallel: 3}, []string{
+			"1300 4 ram/a/x/",
+			"1000 1 ram/a/x/deep/",
+			"1350 9 ram/a/",
+		}},
+	} {
+		output.Reset()
+
+		err = diskUsage(&output, testCase.target, testCase.options)
+		if err != nil {
+			t.Fatalf("diskUsage(\"%s\", %+v) failed: %v", testCase.target, *testCase.options, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if len(lines) != len(testCase.expected) {
+			t.Fatalf("diskUsage(\"%s\", %+v) reported:\n%s", testCase.target, *testCase.options, output.String())
+		}
+		for lineIndex, line := range lines {
+			if strings.Join(strings.Fields(line), " ") != testCase.expected[lineIndex] {
+				t.Fatalf("diskUsage(\"%s\", %+v) line %v was %q (expected %q)", testCase.target, *testCase.options, lineIndex, line, testCase.expected[lineIndex])
+			}
+		}
+	}
+
+	for _, target := range []string{"missing", "ram/missing", "ram/top"} {
+		err = diskUsage(&output, target, &duOptionsStruct{depth: 1, parallel: 1})
+		if err == nil {
+			t.Fatalf("diskUsage(\"%s\") unexpectedly succeeded", target)
+		}
+	}
+}
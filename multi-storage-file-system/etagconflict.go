@@ -0,0 +1,67 @@
+package main
+
+import (
+	"syscall"
+)
+
+// `validETagConflictPolicy` reports whether policy is one of ETagConflictPolicy*.
+func validETagConflictPolicy(policy string) bool {
+	return (policy == ETagConflictPolicyFail) || (policy == ETagConflictPolicyRefetch) || (policy == ETagConflictPolicyLastWriterWins)
+}
+
+// `eTagsConflict` reports whether eTag (as returned by a read of a file of backend) differs
+// from expectedETag (that known for the file) such that another writer has since replaced it.
+// The eTags are compared per backend's eTag validator (see eTagValidatorOf()). Should either
+// not be reported (i.e. == ""), no conflict can be detected.
+func (backend *backendStruct) eTagsConflict(expectedETag string, eTag string) bool {
+	return (expectedETag != "") && (eTag != "") && !backend.eTagValidatorOf().match(expectedETag, eTag)
+}
+
+// `resolveETagConflict` is called while globals.Lock() is held once content of inode read
+// with eTag conflicts with inode.eTag. Per policy, either ESTALE is returned or, should
+// statFileOutput (obtained since the read) confirm eTag is now that of the file, inode is
+// updated to describe the changed file and 0 is returned (such that the content read may be
+// cached). If policy == ETagConflictPolicyRefetch, the file is deemed append-only, so only
+// those cache lines not wholly before its prior end are evicted (or ESTALE is returned
+// should it have shrunk). If policy == ETagConflictPolicyLastWriterWins, a warning is
+// logged and all of its cache lines are evicted. Cache lines being fetched are unaffected.
+func (inode *inodeStruct) resolveETagConflict(policy string, eTag string, statFileOutput *statFileOutputStruct) (errno syscall.Errno) {
+	var (
+		cacheLine       *cacheLineStruct
+		cacheLineNumber uint64
+		retainedLines   uint64
+	)
+
+	globals.cacheMetrics.LineETagConflicts.Inc()
+
+	if (policy == ETagConflictPolicyFail) || (statFileOutput == nil) || inode.backend.eTagsConflict(statFileOutput.eTag, eTag) {
+		errno = syscall.ESTALE
+		return
+	}
+
+	switch policy {
+	case ETagConflictPolicyRefetch:
+		if statFileOutput.size < inode.sizeInBackend {
+			errno = syscall.ESTALE
+			return
+		}
+		retainedLines = inode.sizeInBackend / inode.cacheLineSize
+	default: // ETagConflictPolicyLastWriterWins
+		globals.logger.Printf("[WARN] %s/%s changed (eTag %s => %s, size %v => %v) by another writer... discarding its cached content", inode.backend.dirName, inode.objectPath, inode.eTag, statFileOutput.eTag, inode.sizeInBackend, statFileOutput.size)
+		retainedLines = 0
+	}
+
+	for cacheLineNumber, cacheLine = range inode.cache {
+		if (cacheLineNumber >= retainedLines) && (cacheLine.state == CacheLineClean) {
+			evictCleanCacheLine(cacheLine.listElement)
+		}
+	}
+
+	inode.eTag = statFileOutput.eTag
+	inode.sizeInBackend = statFileOutput.size
+	inode.sizeInMemory = statFileOutput.size
+	inode.mTime = statFileOutput.mTime
+
+	errno = 0
+	return
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+)
+
+// `eTagValidatorIf` defines the semantics of a backend's eTags: when two (as reported by
+// the backend, perhaps by different requests) identify the same content and whether an
+// If-Match request header bearing one may be relied upon.
+type eTagValidatorIf interface {
+	match(expectedETag string, eTag string) bool // Neither expectedETag nor eTag is == ""
+	ifMatchReliable() bool                       // If false, If-Match is never sent so an eTag is instead verified by a match() with that reported by a HEAD
+}
+
+// `eTagValidators` maps each supported etag_validator to its implementation.
+var eTagValidators = map[string]eTagValidatorIf{
+	ETagValidatorStrong:    &strongETagValidatorStruct{},
+	ETagValidatorWeak:      &weakETagValidatorStruct{},
+	ETagValidatorMultipart: &multipartETagValidatorStruct{},
+}
+
+// `strongETagValidatorStruct` matches eTags exactly. As S3 reports eTags quoted by some
+// requests but not others, quotes are disregarded.
+type strongETagValidatorStruct struct{}
+
+func (*strongETagValidatorStruct) match(expectedETag string, eTag string) bool {
+	return strings.Trim(expectedETag, "\"") == strings.Trim(eTag, "\"")
+}
+
+func (*strongETagValidatorStruct) ifMatchReliable() bool {
+	return true
+}
+
+// `weakETagValidatorStruct` matches eTags per the weak comparison of RFC 9110 (i.e.
+// disregarding any "W/" prefix) for endpoints (or proxies before them) that report some
+// eTags as weak. As If-Match demands the strong comparison (so never matches a weak eTag),
+// it is not relied upon.
+type weakETagValidatorStruct struct{}
+
+func (*weakETagValidatorStruct) match(expectedETag string, eTag string) bool {
+	return weakETagOpaqueTag(expectedETag) == weakETagOpaqueTag(eTag)
+}
+
+func (*weakETagValidatorStruct) ifMatchReliable() bool {
+	return false
+}
+
+// `multipartETagValidatorStruct` extends weakETagValidatorStruct for endpoints that report
+// the eTag of an object uploaded in multiple parts (i.e. "<MD5 digest>-<number of parts>")
+// with or without its "-<number of parts>" suffix (and in either case) depending upon the
+// request. Such an eTag matches another whose digest is the same.
+type multipartETagValidatorStruct struct{}
+
+func (*multipartETagValidatorStruct) match(expectedETag string, eTag string) bool {
+	return strings.EqualFold(multipartETagDigest(weakETagOpaqueTag(expectedETag)), multipartETagDigest(weakETagOpaqueTag(eTag)))
+}
+
+func (*multipartETagValidatorStruct) ifMatchReliable() bool {
+	return false
+}
+
+// `weakETagOpaqueTag` returns eTag stripped of any "W/" prefix and of its quotes.
+func weakETagOpaqueTag(eTag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.Trim(eTag, "\""), "W/"), "\"")
+}
+
+// `multipartETagDigest` returns the digest of eTag should it have the form of the eTag of
+// an S3 multipart upload, else eTag itself.
+func multipartETagDigest(eTag string) string {
+	var (
+		ok bool
+	)
+
+	_, ok = multipartETagParts(eTag)
+	if ok {
+		return eTag[:syncMD5ETagHexDigits]
+	}
+
+	return eTag
+}
+
+// `eTagValidatorOf` returns backend's eTagValidator (or, if it has none, that of etag_validator "strong").
+func (backend *backendStruct) eTagValidatorOf() eTagValidatorIf {
+	if backend.eTagValidator == nil {
+		return eTagValidators[ETagValidatorStrong]
+	}
+
+	return backend.eTagValidator
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestETagValidators(t *testing.T) {
+	var (
+		backend = &backendStruct{}
+	)
+
+	for _, testCase := range []struct {
+		eTagValidator string
+		expectedETag  string
+		eTag          string
+		match         bool
+	}{
+		{ETagValidatorStrong, "abc", "\"abc\"", true},
+		{ETagValidatorStrong, "abc", "W/\"abc\"", false},
+		{ETagValidatorStrong, "0123456789abcdef0123456789abcdef-2", "0123456789abcdef0123456789abcdef", false},
+		{ETagValidatorWeak, "abc", "W/\"abc\"", true},
+		{ETagValidatorWeak, "W/\"abc", "\"abc\"", true}, // As trimmed of quotes by a listing
+		{ETagValidatorWeak, "abc", "W/\"abd\"", false},
+		{ETagValidatorWeak, "0123456789abcdef0123456789abcdef-2", "0123456789abcdef0123456789abcdef", false},
+		{ETagValidatorMultipart, "0123456789abcdef0123456789abcdef-2", "\"0123456789ABCDEF0123456789ABCDEF\"", true},
+		{ETagValidatorMultipart, "0123456789abcdef0123456789abcdef-2", "W/\"0123456789abcdef0123456789abcdef-2\"", true},
+		{ETagValidatorMultipart, "0123456789abcdef0123456789abcdef-2", "fedcba9876543210fedcba9876543210-2", false},
+		{ETagValidatorMultipart, "abc-2", "abc", false}, // Not a multipart eTag
+	} {
+		backend.eTagValidator = eTagValidators[testCase.eTagValidator]
+		if backend.eTagsConflict(testCase.expectedETag, testCase.eTag) == testCase.match {
+			t.Fatalf("eTagsConflict(%q, %q) per %s returned %v", testCase.expectedETag, testCase.eTag, testCase.eTagValidator, testCase.match)
+		}
+	}
+
+	backend.eTagValidator = nil
+	if !backend.eTagsConflict("abc", "W/\"abc\"") || backend.eTagsConflict("", "abc") || !backend.eTagValidatorOf().ifMatchReliable() {
+		t.Fatalf("backend lacking an eTag validator should compare eTags per %s", ETagValidatorStrong)
+	}
+	if eTagValidators[ETagValidatorWeak].ifMatchReliable() || eTagValidators[ETagValidatorMultipart].ifMatchReliable() {
+		t.Fatalf("If-Match cannot be relied upon for weak or multipart eTags")
+	}
+}
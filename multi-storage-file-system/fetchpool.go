@@ -0,0 +1,241 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// `fetchPoolStruct` limits the number of cache lines of a backend being fetched concurrently
+// to its fetch_workers. Beyond that limit, cache lines wait in FIFO queues: those being read in
+// one per QoS priority class (see backendStruct.qosClass()) of their file's path, all ahead of
+// those being prefetched. As each worker completes a fetch(), it takes the oldest cache line
+// from the most urgent non-empty queue. Workers exit once all queues are empty so that an idle
+// backend has none running.
+//
+// If fetch_workers_adaptive, the limit instead varies (AIMD-style) between 1 and fetch_workers:
+// it is halved whenever a fetch is congested (i.e. the backend throttled requests during it or it
+// took longer than fetch_latency_target) and otherwise grown by one once as many uncongested
+// fetches as the limit itself have completed.
+type fetchPoolStruct struct {
+	sync.Mutex                             // Protects workers, demandQueues, prefetchQueue, & (if adaptive) workersMax, successes, & lastDecrease
+	backend         *backendStruct         //
+	workersMax      uint64                 // == backend.fetchWorkers unless adaptive
+	workers         uint64                 // Goroutines running fetchPoolStruct.worker()
+	workerWaitGroup sync.WaitGroup         // Tracks those goroutines so that stop() may await them
+	demandQueues    [qosClasses]*list.List // Indexed by QoS priority class; each list.Element.Value is a *cacheLineStruct awaited by a read
+	prefetchQueue   *list.List             // Each list.Element.Value is a *cacheLineStruct with .prefetch == true
+	adaptive        bool                   // == backend.fetchWorkersAdaptive
+	workersCeiling  uint64                 // == backend.fetchWorkers
+	latencyTarget   time.Duration          // == backend.fetchLatencyTarget
+	successes       uint64                 // Uncongested fetches completed since workersMax last changed
+	lastDecrease    time.Time              // Congested fetches started before this do not (again) decrease workersMax
+}
+
+// `startFetch` is called while globals.Lock() is held (and never blocks) to arrange for
+// cacheLine (of an inode of this backend) to be fetched. If fetch_workers == 0, this is
+// done in a goroutine of its own. Otherwise, it is done by a worker of backend.fetchPool.
+func (backend *backendStruct) startFetch(cacheLine *cacheLineStruct) {
+	var (
+		inode    *inodeStruct
+		ok       bool
+		qosClass int
+		qosPath  string
+	)
+
+	if backend.fetchWorkers == 0 {
+		go cacheLine.fetch()
+		return
+	}
+
+	if backend.fetchPool == nil {
+		backend.fetchPool = &fetchPoolStruct{
+			backend:        backend,
+			workersMax:     backend.fetchWorkers,
+			prefetchQueue:  list.New(),
+			adaptive:       backend.fetchWorkersAdaptive,
+			workersCeiling: backend.fetchWorkers,
+			latencyTarget:  backend.fetchLatencyTarget,
+		}
+
+		for qosClass = range qosClasses {
+			backend.fetchPool.demandQueues[qosClass] = list.New()
+		}
+	}
+
+	inode, ok = globals.inodeMap[cacheLine.inodeNumber]
+	if ok {
+		qosPath = inode.objectPath
+	}
+
+	backend.fetchPool.submit(cacheLine, backend.qosClass(qosPath, false))
+}
+
+// `submit` hands cacheLine to a new worker (should fewer than workersMax be running) or
+// otherwise queues it (if being read, in the queue of qosClass) for the next available worker.
+func (fetchPool *fetchPoolStruct) submit(cacheLine *cacheLineStruct, qosClass uint8) {
+	fetchPool.Lock()
+	defer fetchPool.Unlock()
+
+	if fetchPool.workers < fetchPool.workersMax {
+		fetchPool.startWorker(cacheLine)
+		return
+	}
+
+	if cacheLine.prefetch {
+		_ = fetchPool.prefetchQueue.PushBack(cacheLine)
+	} else {
+		_ = fetchPool.demandQueues[qosClass].PushBack(cacheLine)
+	}
+}
+
+// `startWorker` is called while fetchPool.Lock() is held to start a worker fetching cacheLine.
+func (fetchPool *fetchPoolStruct) startWorker(cacheLine *cacheLineStruct) {
+	fetchPool.workers++
+	fetchPool.workerWaitGroup.Go(func() { fetchPool.worker(cacheLine) })
+}
+
+// `worker` fetches cacheLine and then each cache line queued until none remain (or, if
+// adaptive, workersMax has been decreased below the number of workers running).
+func (fetchPool *fetchPoolStruct) worker(cacheLine *cacheLineStruct) {
+	var (
+		congested        bool
+		queuedCacheLine  *cacheLineStruct
+		startTime        time.Time
+		throttlesAtStart uint64
+	)
+
+	for {
+		startTime = time.Now()
+		throttlesAtStart = fetchPool.backend.throttles.Load()
+
+		cacheLine.fetch()
+
+		congested = (fetchPool.backend.throttles.Load() != throttlesAtStart) || ((fetchPool.latencyTarget != 0) && (time.Since(startTime) > fetchPool.latencyTarget))
+
+		fetchPool.Lock()
+
+		if fetchPool.adaptive {
+			fetchPool.adapt(startTime, congested)
+
+			if fetchPool.workers > fetchPool.workersMax {
+				fetchPool.workers--
+				fetchPool.Unlock()
+				return
+			}
+		}
+
+		cacheLine = fetchPool.next()
+		if cacheLine == nil {
+			fetchPool.workers--
+			fetchPool.Unlock()
+			return
+		}
+
+		// Should workersMax have been increased, start additional workers for queued cache lines
+
+		for fetchPool.workers < fetchPool.workersMax {
+			queuedCacheLine = fetchPool.next()
+			if queuedCacheLine == nil {
+				break
+			}
+			fetchPool.startWorker(queuedCacheLine)
+		}
+
+		fetchPool.Unlock()
+	}
+}
+
+// `stop` is called (without globals.Lock() held, as each fetch() requires it) to await
+// the fetch of each cache line queued or in flight (i.e. the exit of every worker).
+func (fetchPool *fetchPoolStruct) stop() {
+	if fetchPool == nil {
+		return
+	}
+
+	fetchPool.workerWaitGroup.Wait()
+}
+
+// `next` is called while fetchPool.Lock() is held to dequeue the oldest cache line of the
+// most urgent non-empty queue. If all queues are empty, nil is returned.
+func (fetchPool *fetchPoolStruct) next() (cacheLine *cacheLineStruct) {
+	var (
+		demandQueue *list.List
+		listElement *list.Element
+	)
+
+	for _, demandQueue = range fetchPool.demandQueues {
+		listElement = demandQueue.Front()
+		if listElement != nil {
+			cacheLine = demandQueue.Remove(listElement).(*cacheLineStruct)
+			return
+		}
+	}
+
+	listElement = fetchPool.prefetchQueue.Front()
+	if listElement != nil {
+		cacheLine = fetchPool.prefetchQueue.Remove(listElement).(*cacheLineStruct)
+	}
+
+	return
+}
+
+// `adapt` is called while fetchPool.Lock() is held to apply the outcome of a fetch started
+// at startTime to workersMax. A congested fetch halves workersMax (to no less than 1) unless
+// it started before workersMax was last decreased (as the fetches in flight at that time
+// likely observed the very same congestion). Otherwise, once workersMax uncongested fetches
+// have completed, workersMax is increased by one (to no more than workersCeiling).
+func (fetchPool *fetchPoolStruct) adapt(startTime time.Time, congested bool) {
+	if congested {
+		if startTime.Before(fetchPool.lastDecrease) {
+			return
+		}
+
+		fetchPool.workersMax = max(fetchPool.workersMax/2, 1)
+		fetchPool.successes = 0
+		fetchPool.lastDecrease = time.Now()
+
+		return
+	}
+
+	fetchPool.successes++
+
+	if (fetchPool.successes >= fetchPool.workersMax) && (fetchPool.workersMax < fetchPool.workersCeiling) {
+		fetchPool.workersMax++
+		fetchPool.successes = 0
+	}
+}
+
+// `queued` returns the number of cache lines awaiting a worker.
+func (fetchPool *fetchPoolStruct) queued() (queued uint64) {
+	var (
+		demandQueue *list.List
+	)
+
+	if fetchPool == nil {
+		return
+	}
+
+	fetchPool.Lock()
+	queued = uint64(fetchPool.prefetchQueue.Len())
+	for _, demandQueue = range fetchPool.demandQueues {
+		queued += uint64(demandQueue.Len())
+	}
+	fetchPool.Unlock()
+
+	return
+}
+
+// `limit` returns the number of cache lines that may currently be fetched concurrently
+// (or 0 if no cache line has yet been fetched via the pool).
+func (fetchPool *fetchPoolStruct) limit() (limit uint64) {
+	if fetchPool == nil {
+		return
+	}
+
+	fetchPool.Lock()
+	limit = fetchPool.workersMax
+	fetchPool.Unlock()
+
+	return
+}
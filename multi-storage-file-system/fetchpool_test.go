@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestFetchPool(t *testing.T) {
+	var (
+		cacheLine     *cacheLineStruct
+		cacheLineSize uint64
+		cacheLines    []*cacheLineStruct
+		errno         syscall.Errno
+		fetchPool     *fetchPoolStruct
+		fileBIno      uint64
+		inode         *inodeStruct
+		lineNumber    uint64
+		lookupOut     *fission.LookupOut
+		openOut       *fission.OpenOut
+		ram           *backendStruct
+		readOut       *fission.ReadOut
+		waitGroup     sync.WaitGroup
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	cacheLineSize = globals.config.cacheLineSize
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileB")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileBIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Beyond fetch_workers, cache lines are queued (those read ahead of those prefetched)
+
+	globals.Lock()
+
+	ram = globals.config.backends["ram"]
+	if (ram.fetchWorkers != 64) || (ram.fetchPool != nil) {
+		globals.Unlock()
+		t.Fatalf("ram backend has fetch_workers %v (fetchPool: %v) before any read", ram.fetchWorkers, ram.fetchPool)
+	}
+
+	ram.fetchWorkers = 2
+
+	inode = globals.inodeMap[fileBIno]
+	inode.cacheLineSize = cacheLineSize
+
+	for lineNumber = range uint64(6) {
+		cacheLine = &cacheLineStruct{
+			state:       CacheLineInbound,
+			waiters:     []*sync.WaitGroup{&waitGroup},
+			inodeNumber: fileBIno,
+			lineNumber:  lineNumber,
+			prefetch:    lineNumber < 3,
+		}
+
+		waitGroup.Add(1)
+
+		inode.cache[lineNumber] = cacheLine
+		inode.inboundCacheLineCount++
+		globals.inboundCacheLineCount++
+
+		ram.startFetch(cacheLine)
+
+		cacheLines = append(cacheLines, cacheLine)
+	}
+
+	fetchPool = ram.fetchPool
+
+	fetchPool.Lock()
+	if (fetchPool.workers != 2) || (fetchPool.demandQueues[QoSClassNormal].Len() != 3) || (fetchPool.prefetchQueue.Len() != 1) {
+		t.Errorf("fetchPool has %v workers with %v demand & %v prefetch cache lines queued (expected 2, 3, & 1)", fetchPool.workers, fetchPool.demandQueues[QoSClassNormal].Len(), fetchPool.prefetchQueue.Len())
+	}
+	fetchPool.Unlock()
+
+	if fetchPool.queued() != 4 {
+		t.Errorf("fetchPool.queued() returned %v (expected 4)", fetchPool.queued())
+	}
+
+	globals.Unlock()
+
+	waitGroup.Wait()
+
+	for _, cacheLine = range cacheLines {
+		if (cacheLine.state != CacheLineClean) || !bytes.Equal(cacheLine.content, testFissionFileBContent[cacheLine.lineNumber*cacheLineSize:(cacheLine.lineNumber+1)*cacheLineSize]) {
+			t.Fatalf("cache line %v was not fetched correctly (state: %v)", cacheLine.lineNumber, cacheLine.state)
+		}
+	}
+
+	// Once the queues have drained, the workers exit
+
+	fetchPool.stop()
+
+	fetchPool.Lock()
+	if fetchPool.workers != 0 {
+		t.Errorf("fetchPool has %v workers after draining", fetchPool.workers)
+	}
+	fetchPool.Unlock()
+
+	if adminStats().QueuedFetches != 0 {
+		t.Fatalf("adminStats().QueuedFetches != 0 after draining")
+	}
+
+	// Reads fetch through the pool as well
+
+	readOut, errno = globals.DoRead(&fission.InHeader{NodeID: fileBIno}, &fission.ReadIn{FH: openOut.FH, Offset: 10 * cacheLineSize, Size: 16})
+	if (errno != 0) || !bytes.Equal(readOut.Data, testFissionFileBContent[10*cacheLineSize:10*cacheLineSize+16]) {
+		t.Fatalf("DoRead(fileBIno) unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+func TestFetchPoolPriority(t *testing.T) {
+	var (
+		cacheLine *cacheLineStruct
+		fetchPool = &fetchPoolStruct{
+			prefetchQueue: list.New(),
+		}
+		index      int
+		lineNumber uint64
+		qosClass   int
+	)
+
+	for qosClass = range qosClasses {
+		fetchPool.demandQueues[qosClass] = list.New()
+	}
+
+	// With no workers available, cache lines are queued and then dequeued (oldest first)
+	// from the most urgent QoS priority class of those read before any being prefetched
+
+	for index, qosClass = range []int{int(QoSClassBulk), int(QoSClassNormal), -1, int(QoSClassInteractive), int(QoSClassNormal)} {
+		cacheLine = &cacheLineStruct{lineNumber: uint64(index), prefetch: qosClass < 0}
+		fetchPool.submit(cacheLine, uint8(max(qosClass, 0)))
+	}
+
+	if fetchPool.queued() != 5 {
+		t.Fatalf("fetchPool.queued() returned %v (expected 5)", fetchPool.queued())
+	}
+
+	for _, lineNumber = range []uint64{3, 1, 4, 0, 2} {
+		cacheLine = fetchPool.next()
+		if (cacheLine == nil) || (cacheLine.lineNumber != lineNumber) {
+			t.Fatalf("fetchPool.next() returned %+v (expected cache line %v)", cacheLine, lineNumber)
+		}
+	}
+
+	if fetchPool.next() != nil {
+		t.Fatalf("fetchPool.next() of empty queues returned non-nil")
+	}
+}
+
+func TestFetchPoolAdapt(t *testing.T) {
+	var (
+		fetchPool = &fetchPoolStruct{
+			workersMax:     8,
+			adaptive:       true,
+			workersCeiling: 8,
+		}
+		startTime time.Time
+	)
+
+	// Congestion halves workersMax but only once for fetches already in flight at the time
+
+	startTime = time.Now()
+
+	fetchPool.adapt(startTime, true)
+	if fetchPool.workersMax != 4 {
+		t.Fatalf("adapt(congested) left workersMax == %v (expected 4)", fetchPool.workersMax)
+	}
+
+	fetchPool.adapt(startTime, true)
+	if fetchPool.workersMax != 4 {
+		t.Fatalf("adapt(congested) of a fetch started before the last decrease left workersMax == %v (expected 4)", fetchPool.workersMax)
+	}
+
+	for range 3 {
+		fetchPool.adapt(time.Now(), true)
+	}
+	if fetchPool.workersMax != 1 {
+		t.Fatalf("adapt(congested) repeatedly left workersMax == %v (expected 1)", fetchPool.workersMax)
+	}
+
+	// Uncongested fetches grow workersMax by one per workersMax of them (up to workersCeiling)
+
+	fetchPool.adapt(time.Now(), false)
+	if fetchPool.workersMax != 2 {
+		t.Fatalf("adapt(uncongested) left workersMax == %v (expected 2)", fetchPool.workersMax)
+	}
+
+	fetchPool.adapt(time.Now(), false)
+	if fetchPool.workersMax != 2 {
+		t.Fatalf("adapt(uncongested) left workersMax == %v before workersMax uncongested fetches (expected 2)", fetchPool.workersMax)
+	}
+
+	for range 100 {
+		fetchPool.adapt(time.Now(), false)
+	}
+	if fetchPool.workersMax != 8 {
+		t.Fatalf("adapt(uncongested) repeatedly left workersMax == %v (expected workersCeiling of 8)", fetchPool.workersMax)
+	}
+}
+
+func TestFetchPoolAdaptiveCongested(t *testing.T) {
+	var (
+		cacheLine  *cacheLineStruct
+		errno      syscall.Errno
+		fetchPool  *fetchPoolStruct
+		fileBIno   uint64
+		inode      *inodeStruct
+		lineNumber uint64
+		lookupOut  *fission.LookupOut
+		ram        *backendStruct
+		waitGroup  sync.WaitGroup
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileB")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	// With a fetch_latency_target no fetch can meet, congestion (at least once) halves the
+	// limit while the cache lines queued beyond it are nonetheless all fetched
+
+	globals.Lock()
+
+	ram = globals.config.backends["ram"]
+	ram.fetchWorkers = 4
+	ram.fetchWorkersAdaptive = true
+	ram.fetchLatencyTarget = time.Nanosecond
+	ram.fetchPool = nil
+
+	inode = globals.inodeMap[fileBIno]
+	inode.cacheLineSize = globals.config.cacheLineSize
+
+	for lineNumber = range uint64(8) {
+		cacheLine = &cacheLineStruct{
+			state:       CacheLineInbound,
+			waiters:     []*sync.WaitGroup{&waitGroup},
+			inodeNumber: fileBIno,
+			lineNumber:  lineNumber,
+		}
+
+		waitGroup.Add(1)
+
+		inode.cache[lineNumber] = cacheLine
+		inode.inboundCacheLineCount++
+		globals.inboundCacheLineCount++
+
+		ram.startFetch(cacheLine)
+	}
+
+	fetchPool = ram.fetchPool
+
+	globals.Unlock()
+
+	waitGroup.Wait()
+
+	// Await the workers (and, thus, their last adjustments to the limit) before checking it
+
+	fetchPool.stop()
+
+	if (fetchPool.limit() >= 4) || (fetchPool.queued() != 0) {
+		t.Fatalf("fetchPool.limit() == %v & fetchPool.queued() == %v after congestion (expected < 4 & 0)", fetchPool.limit(), fetchPool.queued())
+	}
+}
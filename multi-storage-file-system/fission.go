@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/NVIDIA/fission/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -149,7 +153,11 @@ func (*globalsStruct) DoLookup(inHeader *fission.InHeader, lookupIn *fission.Loo
 				parentInode.backend.fissionMetrics.LookupFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("lookup", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "lookup", parentInode, string(lookupIn.Name), 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "lookup", parentInode, string(lookupIn.Name), 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -190,16 +198,27 @@ func (*globalsStruct) DoLookup(inHeader *fission.InHeader, lookupIn *fission.Loo
 	} else {
 		// We only know parentInode is a BackendRootDir or a PseudoDir
 
-		childInode, ok = parentInode.findChildInode(string(lookupIn.Name))
-		if !ok || childInode.pendingDelete {
+		childInode, ok, errno = parentInode.findChildInode(string(lookupIn.Name))
+		if !ok {
+			globals.Unlock()
+			return
+		}
+		if childInode.pendingDelete {
 			globals.Unlock()
 			errno = syscall.ENOENT
 			return
 		}
 	}
 
-	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(globals.config.entryAttrTTL)
-	mTimeSec, mTimeNSec = timeTimeToAttrTime(childInode.mTime)
+	if !childInode.accessAllowed(inHeader) {
+		// Hide childInode from callers denied access to it
+		globals.Unlock()
+		errno = syscall.ENOENT
+		return
+	}
+
+	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(childInode.entryAttrTTL())
+	mTimeSec, mTimeNSec = timeTimeToAttrTime(childInode.reportedMTime())
 
 	lookupOut = &fission.LookupOut{
 		EntryOut: fission.EntryOut{
@@ -236,7 +255,9 @@ func (*globalsStruct) DoLookup(inHeader *fission.InHeader, lookupIn *fission.Loo
 
 // `DoForget` implements the package fission callback to note that
 // the kernel has removed an inode from its internal caches.
-func (*globalsStruct) DoForget(inHeader *fission.InHeader, forgetIn *fission.ForgetIn) {}
+func (*globalsStruct) DoForget(inHeader *fission.InHeader, forgetIn *fission.ForgetIn) {
+	recordFUSEOp("forget", nil, 0)
+}
 
 // `DoGetAttr` implements the package fission callback to fetch metadata
 // information about an inode.
@@ -272,7 +293,11 @@ func (*globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fission.G
 				thisInode.backend.fissionMetrics.GetAttrFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("getattr", thisInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "getattr", thisInode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "getattr", thisInode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -289,6 +314,11 @@ func (*globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fission.G
 		errno = syscall.ENOENT
 		return
 	}
+	if !thisInode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
 
 	thisInode.touch(nil)
 
@@ -310,8 +340,8 @@ func (*globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fission.G
 		globals.logger.Fatalf("[FATAL] unrecognized inodeType (%v)", thisInode.inodeType)
 	}
 
-	attrValidSec, attrValidNSec = timeDurationToAttrDuration(globals.config.entryAttrTTL)
-	mTimeSec, mTimeNSec = timeTimeToAttrTime(thisInode.mTime)
+	attrValidSec, attrValidNSec = timeDurationToAttrDuration(thisInode.entryAttrTTL())
+	mTimeSec, mTimeNSec = timeTimeToAttrTime(thisInode.reportedMTime())
 
 	getAttrOut = &fission.GetAttrOut{
 		AttrValidSec:  attrValidSec,
@@ -345,6 +375,7 @@ func (*globalsStruct) DoGetAttr(inHeader *fission.InHeader, getAttrIn *fission.G
 func (*globalsStruct) DoSetAttr(inHeader *fission.InHeader, setAttrIn *fission.SetAttrIn) (setAttrOut *fission.SetAttrOut, errno syscall.Errno) {
 	fmt.Println("[TODO] fission.go::DoSetAttr()")
 	errno = syscall.ENOSYS
+	recordFUSEOp("setattr", nil, errno)
 	return
 }
 
@@ -352,28 +383,35 @@ func (*globalsStruct) DoSetAttr(inHeader *fission.InHeader, setAttrIn *fission.S
 // of a symlink inode (not supported)
 func (*globalsStruct) DoReadLink(inHeader *fission.InHeader) (readLinkOut *fission.ReadLinkOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("readlink", nil, errno)
 	return
 }
 
 // `DoSymLink` implements the package fission callback to create a symlink inode (not supported)
 func (*globalsStruct) DoSymLink(inHeader *fission.InHeader, symLinkIn *fission.SymLinkIn) (symLinkOut *fission.SymLinkOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("symlink", nil, errno)
 	return
 }
 
 // `DoMkNod` implements the package fission callback to create a file inode.
 func (*globalsStruct) DoMkNod(inHeader *fission.InHeader, mkNodIn *fission.MkNodIn) (mkNodOut *fission.MkNodOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("mknod", nil, errno)
 	return
 }
 
 // `DoMkDir` implements the package fission callback to create a directory inode.
 func (*globalsStruct) DoMkDir(inHeader *fission.InHeader, mkDirIn *fission.MkDirIn) (mkDirOut *fission.MkDirOut, errno syscall.Errno) {
 	var (
+		backend            *backendStruct
 		basename           = string(mkDirIn.Name)
 		childInode         *inodeStruct
+		dirPath            string
 		entryAttrValidNSec uint32
 		entryAttrValidSec  uint64
+		err                error
+		isMarked           bool
 		latency            float64
 		mTimeNSec          uint32
 		mTimeSec           uint64
@@ -400,7 +438,11 @@ func (*globalsStruct) DoMkDir(inHeader *fission.InHeader, mkDirIn *fission.MkDir
 				parentInode.backend.fissionMetrics.MkDirFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("mkdir", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "mkdir", parentInode, basename, 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "mkdir", parentInode, basename, 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -425,27 +467,79 @@ func (*globalsStruct) DoMkDir(inHeader *fission.InHeader, mkDirIn *fission.MkDir
 		errno = syscall.EPERM
 		return
 	}
-	if parentInode.backend.readOnly {
-		// Never allowed in a readOnly backend
+	if parentInode.backend.readOnlyAt(parentInode.objectPath + basename + "/") {
+		// Never allowed in a readOnly backend (or beneath a readonly path override)
 		globals.Unlock()
 		errno = syscall.EPERM
 		return
 	}
 
-	_, ok = parentInode.findChildInode(basename)
+	if !parentInode.backend.accessAllowed(inHeader, parentInode.objectPath+basename+"/", true) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
+
+	_, ok, errno = parentInode.findChildInode(basename)
 	if ok {
 		// We just return EEXIST if we find a phys or virt child dir entry (whether or not it is a dir or a file)
 		globals.Unlock()
 		errno = syscall.EEXIST
 		return
 	}
+	if errno != syscall.ENOENT {
+		// We could not determine whether the child exists
+		globals.Unlock()
+		return
+	}
+
+	backend = parentInode.backend
+	dirPath = parentInode.objectPath + basename + "/"
+
+	isMarked = (backend.directoryMarkerPath(dirPath) != "")
+	if isMarked {
+		// Write the directory's marker (so that it is seen by later mounts and other tools) without holding globals.Lock
+
+		globals.Unlock()
+
+		err = backend.createDirectoryMarker(dirPath)
+		if err != nil {
+			globals.logger.Printf("[WARN] %s.createDirectoryMarker(\"%s\") failed: %v", backend.dirName, dirPath, err)
+			errno = backendErrno(err)
+			return
+		}
+
+		globals.Lock()
+
+		parentInode, ok = globals.inodeMap[inHeader.NodeID]
+		if !ok {
+			// The parentInode has been evicted while the marker was being written
+			parentInode = nil
+			globals.Unlock()
+			errno = syscall.ENOENT
+			return
+		}
+
+		_, ok = parentInode.physChildInodeMap.GetByKey(basename)
+		if !ok {
+			_, ok = parentInode.virtChildInodeMap.GetByKey(basename)
+		}
+		if ok {
+			// The child was created while the marker was being written
+			globals.Unlock()
+			errno = syscall.EEXIST
+			return
+		}
+	}
 
 	// From here, we know we will succeed
 
-	childInode = parentInode.createPseudoDirInode(true, basename)
+	childInode = parentInode.createPseudoDirInode(!isMarked, basename)
 
-	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(globals.config.entryAttrTTL)
-	mTimeSec, mTimeNSec = timeTimeToAttrTime(childInode.mTime)
+	parentInode.invalidateListing()
+
+	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(childInode.entryAttrTTL())
+	mTimeSec, mTimeNSec = timeTimeToAttrTime(childInode.reportedMTime())
 
 	mkDirOut = &fission.MkDirOut{
 		EntryOut: fission.EntryOut{
@@ -486,6 +580,7 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 	var (
 		basename    = string(unlinkIn.Name)
 		childInode  *inodeStruct
+		err         error
 		latency     float64
 		ok          bool
 		parentInode *inodeStruct
@@ -511,7 +606,11 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 				parentInode.backend.fissionMetrics.UnlinkFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("unlink", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "unlink", parentInode, basename, 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "unlink", parentInode, basename, 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -536,16 +635,15 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 		errno = syscall.ENOTDIR
 		return
 	}
-	if parentInode.backend.readOnly {
+	if parentInode.backend.readOnlyAt(parentInode.objectPath + basename) {
 		globals.Unlock()
 		errno = syscall.EPERM
 		return
 	}
 
-	childInode, ok = parentInode.findChildInode(basename)
+	childInode, ok, errno = parentInode.findChildInode(basename)
 	if !ok {
 		globals.Unlock()
-		errno = syscall.ENOENT
 		return
 	}
 
@@ -554,6 +652,11 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 		errno = syscall.ENOENT
 		return
 	}
+	if !childInode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
 	if childInode.inodeType != FileObject {
 		childInode.touch(nil)
 		globals.Unlock()
@@ -577,7 +680,11 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 
 	globals.Unlock()
 
-	childInode.finishPendingDelete()
+	err = childInode.finishPendingDelete()
+	if err != nil {
+		errno = backendErrno(err)
+		return
+	}
 
 	errno = 0
 	return
@@ -586,12 +693,17 @@ func (*globalsStruct) DoUnlink(inHeader *fission.InHeader, unlinkIn *fission.Unl
 // `DoRmDir` implements the package fission callback to remove a directory inode.
 func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDirIn) (errno syscall.Errno) {
 	var (
-		basename    = string(rmDirIn.Name)
-		childInode  *inodeStruct
-		latency     float64
-		ok          bool
-		parentInode *inodeStruct
-		startTime   = time.Now()
+		backend             *backendStruct
+		basename            = string(rmDirIn.Name)
+		childInode          *inodeStruct
+		childInodeNumber    uint64
+		dirPath             string
+		err                 error
+		latency             float64
+		listDirectoryOutput *listDirectoryOutputStruct
+		ok                  bool
+		parentInode         *inodeStruct
+		startTime           = time.Now()
 	)
 
 	defer func() {
@@ -612,7 +724,11 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 				parentInode.backend.fissionMetrics.RmDirFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("rmdir", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "rmdir", parentInode, basename, 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "rmdir", parentInode, basename, 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -637,18 +753,17 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 		errno = syscall.EPERM
 		return
 	}
-	if parentInode.backend.readOnly {
-		// Never allowed in a readOnly backend
+	if parentInode.backend.readOnlyAt(parentInode.objectPath + basename + "/") {
+		// Never allowed in a readOnly backend (or beneath a readonly path override)
 		globals.Unlock()
 		errno = syscall.EPERM
 		return
 	}
 
-	childInode, ok = parentInode.findChildInode(basename)
+	childInode, ok, errno = parentInode.findChildInode(basename)
 	if !ok {
-		// We didn't find the child directory, so just return ENOENT
+		// We didn't find the child directory, so just return ENOENT (unless we could not tell)
 		globals.Unlock()
-		errno = syscall.ENOENT
 		return
 	}
 	if childInode.inodeType != PseudoDir {
@@ -657,6 +772,11 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 		errno = syscall.ENOTDIR
 		return
 	}
+	if !childInode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
 	if len(childInode.fhMap) > 0 {
 		// We return EBUSY if the directory is currently "open"
 		globals.Unlock()
@@ -670,6 +790,62 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 		return
 	}
 
+	if !childInode.isVirt {
+		// Without holding globals.Lock, return ENOTEMPTY if anything (but its markers) resides beneath childInode in the backend... else remove its markers
+
+		backend = childInode.backend
+		childInodeNumber = childInode.inodeNumber
+		dirPath = childInode.objectPath
+
+		globals.Unlock()
+
+		// Two entries suffice as a "dir/" marker (omitted by listDirectoryWrapper) is listed first
+
+		listDirectoryOutput, err = listDirectoryWrapper(backend.context, &listDirectoryInputStruct{
+			maxItems: 2,
+			dirPath:  dirPath,
+		})
+		if err != nil {
+			errno = backendErrno(err)
+			return
+		}
+		if (len(listDirectoryOutput.subdirectory) > 0) || (len(listDirectoryOutput.file) > 0) {
+			errno = syscall.ENOTEMPTY
+			return
+		}
+
+		err = backend.deleteDirectoryMarkers(dirPath)
+		if err != nil {
+			errno = backendErrno(err)
+			return
+		}
+
+		globals.Lock()
+
+		parentInode, ok = globals.inodeMap[inHeader.NodeID]
+		if !ok {
+			// The parentInode has been evicted while the backend was consulted
+			parentInode = nil
+			globals.Unlock()
+			errno = syscall.ENOENT
+			return
+		}
+
+		childInode, ok = globals.inodeMap[childInodeNumber]
+		if !ok {
+			// The childInode has been removed (or evicted) while the backend was consulted
+			globals.Unlock()
+			errno = syscall.ENOENT
+			return
+		}
+		if (len(childInode.fhMap) > 0) || (childInode.physChildInodeMap.Len() > 0) || (childInode.virtChildInodeMap.Len() > 2) {
+			// The childInode has been opened or gained children while the backend was consulted
+			globals.Unlock()
+			errno = syscall.ENOTEMPTY
+			return
+		}
+	}
+
 	// From here, we know we will succeed
 
 	if childInode.listElement != nil {
@@ -697,6 +873,7 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 
 	delete(globals.inodeMap, childInode.inodeNumber)
 
+	parentInode.invalidateListing()
 	parentInode.touch(nil)
 
 	globals.Unlock()
@@ -708,27 +885,35 @@ func (*globalsStruct) DoRmDir(inHeader *fission.InHeader, rmDirIn *fission.RmDir
 // `DoRename` implements the package fission callback to rename a directory entry (not supported).
 func (*globalsStruct) DoRename(inHeader *fission.InHeader, renameIn *fission.RenameIn) (errno syscall.Errno) {
 	errno = syscall.EXDEV
+	recordFUSEOp("rename", nil, errno)
 	return
 }
 
 // `DoLink` implements the package fission callback to create a hardlink to an existing file inode (not supported).
 func (*globalsStruct) DoLink(inHeader *fission.InHeader, linkIn *fission.LinkIn) (linkOut *fission.LinkOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("link", nil, errno)
 	return
 }
 
 // `DoOpen` implements the package fission callback to open an existing file inode.
+// If stat_on_open applies to the file, it is first re-stat'd in its backend (see
+// refreshOnOpen()) rather than trusting what was learned when it was looked up.
 func (*globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn) (openOut *fission.OpenOut, errno syscall.Errno) {
 	var (
-		allowReads   bool
-		allowWrites  bool
-		appendWrites bool
-		fh           *fhStruct
-		inode        *inodeStruct
-		isExclusive  bool
-		latency      float64
-		ok           bool
-		startTime    = time.Now()
+		allowReads     bool
+		allowWrites    bool
+		appendWrites   bool
+		backend        *backendStruct
+		err            error
+		fh             *fhStruct
+		inode          *inodeStruct
+		isExclusive    bool
+		latency        float64
+		objectPath     string
+		ok             bool
+		startTime      = time.Now()
+		statFileOutput *statFileOutputStruct
 	)
 
 	defer func() {
@@ -749,11 +934,21 @@ func (*globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn)
 				inode.backend.fissionMetrics.OpenFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("open", inode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "open", inode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "open", inode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
 
+	if globals.shuttingDown {
+		globals.Unlock()
+		errno = syscall.ESHUTDOWN
+		return
+	}
+
 	inode, ok = globals.inodeMap[inHeader.NodeID]
 	if !ok {
 		inode = nil
@@ -771,6 +966,39 @@ func (*globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn)
 		errno = syscall.EISDIR
 		return
 	}
+	if !inode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
+
+	if !inode.isVirt && inode.backend.pathSettings(inode.objectPath).statOnOpen {
+		backend = inode.backend
+		objectPath = inode.objectPath
+
+		globals.Unlock()
+
+		statFileOutput, err = statFileWrapper(backend.context, &statFileInputStruct{
+			filePath: objectPath,
+			ifMatch:  "",
+		})
+		if err != nil {
+			errno = backendErrno(err)
+			return
+		}
+
+		globals.Lock()
+
+		inode, ok = globals.inodeMap[inHeader.NodeID]
+		if !ok || inode.pendingDelete {
+			inode = nil
+			globals.Unlock()
+			errno = syscall.ENOENT
+			return
+		}
+
+		inode.refreshOnOpen(statFileOutput)
+	}
 
 	if len(inode.fhMap) == 1 {
 		for _, fh = range inode.fhMap {
@@ -789,7 +1017,7 @@ func (*globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn)
 	allowWrites = (openIn.Flags & (fission.FOpenRequestRDONLY | fission.FOpenRequestWRONLY | fission.FOpenRequestRDWR)) != fission.FOpenRequestRDONLY
 	appendWrites = allowWrites && ((openIn.Flags & fission.FOpenRequestAPPEND) == fission.FOpenRequestAPPEND)
 
-	if allowWrites && inode.backend.readOnly {
+	if allowWrites && inode.backend.readOnlyAt(inode.objectPath) {
 		globals.Unlock()
 		errno = syscall.EACCES
 		return
@@ -823,29 +1051,58 @@ func (*globalsStruct) DoOpen(inHeader *fission.InHeader, openIn *fission.OpenIn)
 // `DoRead` implements the package fission callback to read a portion of a file inode's contents.
 func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn) (readOut *fission.ReadOut, errno syscall.Errno) {
 	var (
+		awaitedCacheLine                *cacheLineStruct
+		cacheHit                        bool
 		cacheLine                       *cacheLineStruct
-		cacheLineHits                   uint64 // As this is the fall-thru condition, includes +cacheMisses+cacheWaits
+		cacheLineBypasses               uint64
+		cacheLineContent                []byte
+		cacheLineHits                   uint64 // As this is the fall-thru condition, includes +cacheMisses+cacheWaits+cacheRevalidations
 		cacheLineNumber                 uint64
 		cacheLineNumberMaxInBackend     uint64
 		cacheLineMisses                 uint64
-		cacheLineOffsetLimit            uint64 // One greater than offset to last byte to return
-		cacheLineOffsetStart            uint64
+		cacheLineRevalidations          uint64
 		cacheLineWaiter                 sync.WaitGroup
 		cacheLineWaits                  uint64
 		cacheLinesToPotentiallyPrefetch uint64
+		coalescedCacheLine              *cacheLineStruct
+		coalescedCacheLineNumber        uint64
+		coalescedCacheLineNumberMax     uint64
 		curOffset                       = readIn.Offset
+		directRead                      bool
+		directReadLine                  *directReadLineStruct
+		directReadLineBypasses          uint64
 		fh                              *fhStruct
 		inode                           *inodeStruct
 		latency                         float64
 		ok                              bool
+		pathSettings                    backendPathOverrideStruct
 		prefetchCacheLinesIssued        uint64
 		prefetchCacheLineNumber         uint64
 		prefetchCacheLineNumberMax      uint64
 		prefetchCacheLineNumberMin      uint64
+		span                            trace.Span
 		startTime                       = time.Now()
+		traceCtx                        context.Context
 	)
 
+	traceCtx, span = msfsTracer.Start(context.Background(), "fuse.read", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.Int64("msfs.inode", int64(inHeader.NodeID)),
+		attribute.Int64("msfs.offset", int64(readIn.Offset)),
+		attribute.Int64("msfs.size", int64(readIn.Size)),
+	))
+
 	defer func() {
+		span.SetAttributes(
+			attribute.Int64("msfs.bytes", int64(len(readOut.Data))),
+			attribute.Int64("msfs.cache_hits", int64(cacheLineHits-cacheLineMisses-cacheLineWaits-cacheLineRevalidations)),
+			attribute.Int64("msfs.cache_misses", int64(cacheLineMisses)),
+			attribute.Int64("msfs.cache_waits", int64(cacheLineWaits)),
+			attribute.Int64("msfs.cache_prefetches", int64(prefetchCacheLinesIssued)),
+			attribute.Int64("msfs.cache_bypasses", int64(cacheLineBypasses)),
+			attribute.Int64("msfs.cache_revalidations", int64(cacheLineRevalidations)),
+		)
+		endSpanWithErrno(span, errno)
+
 		latency = time.Since(startTime).Seconds()
 		globals.Lock()
 		if errno == 0 {
@@ -867,17 +1124,27 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 				inode.backend.fissionMetrics.ReadFailureSizes.Observe(float64(readIn.Size))
 			}
 		}
-		globals.fissionMetrics.ReadCacheHits.Add(float64(cacheLineHits - cacheLineMisses - cacheLineWaits))
+		globals.fissionMetrics.ReadCacheHits.Add(float64(cacheLineHits - cacheLineMisses - cacheLineWaits - cacheLineRevalidations))
 		globals.fissionMetrics.ReadCacheMisses.Add(float64(cacheLineMisses))
 		globals.fissionMetrics.ReadCacheWaits.Add(float64(cacheLineWaits))
 		globals.fissionMetrics.ReadCachePrefetches.Add(float64(prefetchCacheLinesIssued))
+		globals.fissionMetrics.ReadCacheBypasses.Add(float64(cacheLineBypasses))
 		if (inode != nil) && (inode.backend != nil) {
-			inode.backend.fissionMetrics.ReadCacheHits.Add(float64(cacheLineHits - cacheLineMisses - cacheLineWaits))
+			inode.backend.fissionMetrics.ReadCacheHits.Add(float64(cacheLineHits - cacheLineMisses - cacheLineWaits - cacheLineRevalidations))
 			inode.backend.fissionMetrics.ReadCacheMisses.Add(float64(cacheLineMisses))
 			inode.backend.fissionMetrics.ReadCacheWaits.Add(float64(cacheLineWaits))
 			inode.backend.fissionMetrics.ReadCachePrefetches.Add(float64(prefetchCacheLinesIssued))
+			inode.backend.fissionMetrics.ReadCacheBypasses.Add(float64(cacheLineBypasses))
 		}
+		recordFUSEOp("read", inode, errno)
 		globals.Unlock()
+
+		cacheHit = (cacheLineMisses == 0) && (cacheLineWaits == 0) && (cacheLineBypasses == 0) && (cacheLineRevalidations == 0)
+		globals.audit.record(inHeader, "read", inode, "", uint64(len(readOut.Data)), startTime, &cacheHit, errno)
+		if (errno == 0) && (inode != nil) {
+			globals.ioAccounting.record(inHeader, inode, uint64(len(readOut.Data)), cacheLineMisses)
+		}
+		logSlowFUSEOp(inHeader, "read", inode, "", uint64(len(readOut.Data)), startTime, errno)
 	}()
 
 	readOut = &fission.ReadOut{
@@ -922,34 +1189,137 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 			break
 		}
 
-		cacheLineNumber = curOffset / globals.config.cacheLineSize
+		pathSettings = inode.backend.pathSettings(inode.objectPath)
+
+		if curOffset == readIn.Offset {
+			span.SetAttributes(attribute.String("msfs.backend", inode.backend.dirName), attribute.String("msfs.path", inode.objectPath))
+
+			// Once fh has read direct_read_threshold bytes sequentially, cache misses bypass inode.cache
+
+			if readIn.Offset != fh.sequentialOffset {
+				fh.sequentialBytes = 0
+				fh.discardDirectReadLines()
+			}
+
+			directRead = (pathSettings.directReadThreshold != 0) && (fh.sequentialBytes >= pathSettings.directReadThreshold)
+		}
+
+		if len(inode.cache) == 0 {
+			// Only now may the cache line size of inode change (e.g. per a SIGHUP-altered path override)
+
+			inode.cacheLineSize = pathSettings.cacheLineSize
+		}
+
+		cacheLineNumber = curOffset / inode.cacheLineSize
 
 		cacheLine, ok = inode.cache[cacheLineNumber]
+		if ok && (cacheLine.state == CacheLineClean) && (cacheLine.errno != 0) {
+			if cacheLine == awaitedCacheLine {
+				// The fetch we awaited failed
+
+				globals.Unlock()
+				errno = cacheLine.errno
+				return
+			}
+
+			// An earlier fetch failed, so evict the cache line in order to fetch it anew
+
+			evictCleanCacheLine(cacheLine.listElement)
+
+			ok = false
+		}
+		if !ok && directRead {
+			directReadLine, directReadLineBypasses = fh.directReadLine(cacheLineNumber, pathSettings.cacheLinesToPrefetch, traceCtx)
+			cacheLineBypasses += directReadLineBypasses
+
+			if !directReadLine.fetched {
+				span.AddEvent("cache.bypass", trace.WithAttributes(attribute.Int64("msfs.cache_line", int64(cacheLineNumber))))
+
+				globals.Unlock()
+
+				directReadLine.done.Wait()
+
+				continue
+			}
+
+			if directReadLine.err != nil {
+				errno = backendErrno(directReadLine.err)
+				fh.discardDirectReadLines()
+				globals.Unlock()
+				return
+			}
+
+			cacheLineContent = cacheLineSlice(directReadLine.content, cacheLineNumber, inode.cacheLineSize, curOffset, uint64(cap(readOut.Data)-len(readOut.Data)))
+			if len(cacheLineContent) == 0 {
+				// We have reached EOF
+
+				globals.Unlock()
+
+				break
+			}
+
+			readOut.Data = append(readOut.Data, cacheLineContent...)
+			curOffset += uint64(len(cacheLineContent))
+
+			globals.Unlock()
+
+			continue
+		}
 		if !ok {
 			cacheLineMisses++
 
+			span.AddEvent("cache.miss", trace.WithAttributes(attribute.Int64("msfs.cache_line", int64(cacheLineNumber))))
+
 			cacheLine = &cacheLineStruct{
 				state:       CacheLineInbound,
 				waiters:     make([]*sync.WaitGroup, 1),
 				inodeNumber: inode.inodeNumber,
 				lineNumber:  cacheLineNumber,
+				traceCtx:    traceCtx,
 			}
 
 			cacheLineWaiter.Add(1)
 			cacheLine.waiters[0] = &cacheLineWaiter
 
+			awaitedCacheLine = cacheLine
+
 			inode.cache[cacheLineNumber] = cacheLine
 
 			inode.inboundCacheLineCount++
 			globals.inboundCacheLineCount++
 
-			go cacheLine.fetch()
+			cacheLineNumberMaxInBackend = ((inode.sizeInBackend + inode.cacheLineSize - 1) / inode.cacheLineSize) - 1
+
+			// Any (uncached) cache lines immediately following that are also needed by this read are fetched along with it
 
-			if globals.config.cacheLinesToPrefetch > 0 {
-				cacheLineNumberMaxInBackend = ((inode.sizeInBackend + globals.config.cacheLineSize - 1) / globals.config.cacheLineSize) - 1
+			coalescedCacheLineNumberMax = min((curOffset+uint64(cap(readOut.Data)-len(readOut.Data))-1)/inode.cacheLineSize, cacheLineNumberMaxInBackend)
 
-				if cacheLineNumberMaxInBackend >= (cacheLineNumber + globals.config.cacheLinesToPrefetch) {
-					cacheLinesToPotentiallyPrefetch = globals.config.cacheLinesToPrefetch
+			for coalescedCacheLineNumber = cacheLineNumber + 1; coalescedCacheLineNumber <= coalescedCacheLineNumberMax; coalescedCacheLineNumber++ {
+				_, ok = inode.cache[coalescedCacheLineNumber]
+				if ok {
+					break
+				}
+
+				coalescedCacheLine = &cacheLineStruct{
+					state:       CacheLineInbound,
+					waiters:     make([]*sync.WaitGroup, 0, 1),
+					inodeNumber: inode.inodeNumber,
+					lineNumber:  coalescedCacheLineNumber,
+				}
+
+				cacheLine.coalesced = append(cacheLine.coalesced, coalescedCacheLine)
+
+				inode.cache[coalescedCacheLineNumber] = coalescedCacheLine
+
+				inode.inboundCacheLineCount++
+				globals.inboundCacheLineCount++
+			}
+
+			inode.backend.startFetch(cacheLine)
+
+			if pathSettings.cacheLinesToPrefetch > 0 {
+				if cacheLineNumberMaxInBackend >= (cacheLineNumber + pathSettings.cacheLinesToPrefetch) {
+					cacheLinesToPotentiallyPrefetch = pathSettings.cacheLinesToPrefetch
 				} else {
 					cacheLinesToPotentiallyPrefetch = cacheLineNumberMaxInBackend - cacheLineNumber
 				}
@@ -958,14 +1328,25 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 					prefetchCacheLineNumberMin = cacheLineNumber + 1
 					prefetchCacheLineNumberMax = prefetchCacheLineNumberMin + cacheLinesToPotentiallyPrefetch - 1
 
+					// Each run of consecutive (uncached) cache lines is fetched by a single read
+
+					coalescedCacheLine = nil
+
 					for prefetchCacheLineNumber = prefetchCacheLineNumberMin; prefetchCacheLineNumber <= prefetchCacheLineNumberMax; prefetchCacheLineNumber++ {
 						_, ok = inode.cache[prefetchCacheLineNumber]
-						if !ok {
+						if ok {
+							if coalescedCacheLine != nil {
+								inode.backend.startFetch(coalescedCacheLine)
+								coalescedCacheLine = nil
+							}
+						} else {
 							cacheLine = &cacheLineStruct{
 								state:       CacheLineInbound,
 								waiters:     make([]*sync.WaitGroup, 0, 1),
 								inodeNumber: inode.inodeNumber,
 								lineNumber:  prefetchCacheLineNumber,
+								prefetch:    true,
+								traceCtx:    traceCtx,
 							}
 
 							inode.cache[prefetchCacheLineNumber] = cacheLine
@@ -973,11 +1354,19 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 							inode.inboundCacheLineCount++
 							globals.inboundCacheLineCount++
 
-							go cacheLine.fetch()
+							if coalescedCacheLine == nil {
+								coalescedCacheLine = cacheLine
+							} else {
+								coalescedCacheLine.coalesced = append(coalescedCacheLine.coalesced, cacheLine)
+							}
 
 							prefetchCacheLinesIssued++
 						}
 					}
+
+					if coalescedCacheLine != nil {
+						inode.backend.startFetch(coalescedCacheLine)
+					}
 				}
 			}
 
@@ -991,9 +1380,13 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 		if cacheLine.state == CacheLineInbound {
 			cacheLineWaits++
 
+			span.AddEvent("cache.wait", trace.WithAttributes(attribute.Int64("msfs.cache_line", int64(cacheLineNumber))))
+
 			cacheLineWaiter.Add(1)
 			cacheLine.waiters = append(cacheLine.waiters, &cacheLineWaiter)
 
+			awaitedCacheLine = cacheLine
+
 			globals.Unlock()
 
 			cacheLineWaiter.Wait()
@@ -1001,21 +1394,35 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 			continue
 		}
 
-		cacheLineHits++ // Note that this is the fall-thru condition that counts resolved (cacheLine)Misses & (cacheLine)Waits as (subsequent) Hits
+		if (pathSettings.cacheLineTTL != 0) && (cacheLine != awaitedCacheLine) && (cacheLine.state == CacheLineClean) && (cacheLine.eTag != "") && (time.Since(cacheLine.fetchTime) >= pathSettings.cacheLineTTL) {
+			// The cache line may be stale (and wasn't just fetched for this read), so it is refetched... unless its eTag still matches
 
-		cacheLine.touch()
+			cacheLineRevalidations++
 
-		cacheLineOffsetStart = curOffset - (cacheLineNumber * globals.config.cacheLineSize)
+			span.AddEvent("cache.revalidate", trace.WithAttributes(attribute.Int64("msfs.cache_line", int64(cacheLineNumber))))
 
-		cacheLineOffsetLimit = cacheLineOffsetStart + uint64((cap(readOut.Data) - len(readOut.Data)))
-		if cacheLineOffsetLimit > globals.config.cacheLineSize {
-			cacheLineOffsetLimit = globals.config.cacheLineSize
-		}
-		if cacheLineOffsetLimit > uint64(len(cacheLine.content)) {
-			cacheLineOffsetLimit = uint64(len(cacheLine.content))
+			cacheLine.revalidate(inode, traceCtx)
+
+			cacheLineWaiter.Add(1)
+			cacheLine.waiters = append(cacheLine.waiters, &cacheLineWaiter)
+
+			awaitedCacheLine = cacheLine
+
+			inode.backend.startFetch(cacheLine)
+
+			globals.Unlock()
+
+			cacheLineWaiter.Wait()
+
+			continue
 		}
 
-		if cacheLineOffsetLimit == cacheLineOffsetStart {
+		cacheLineHits++ // Note that this is the fall-thru condition that counts resolved (cacheLine)Misses, (cacheLine)Waits, & (cacheLine)Revalidations as (subsequent) Hits
+
+		cacheLine.touch()
+
+		cacheLineContent = cacheLineSlice(cacheLine.content, cacheLineNumber, inode.cacheLineSize, curOffset, uint64(cap(readOut.Data)-len(readOut.Data)))
+		if len(cacheLineContent) == 0 {
 			// We have reached EOF
 
 			globals.Unlock()
@@ -1023,9 +1430,16 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 			break
 		}
 
-		readOut.Data = append(readOut.Data, cacheLine.content[cacheLineOffsetStart:cacheLineOffsetLimit]...)
-		curOffset += cacheLineOffsetLimit - cacheLineOffsetStart
+		readOut.Data = append(readOut.Data, cacheLineContent...)
+		curOffset += uint64(len(cacheLineContent))
+
+		globals.Unlock()
+	}
 
+	if fh != nil {
+		globals.Lock()
+		fh.sequentialOffset = curOffset
+		fh.sequentialBytes += curOffset - readIn.Offset
 		globals.Unlock()
 	}
 
@@ -1033,10 +1447,32 @@ func (*globalsStruct) DoRead(inHeader *fission.InHeader, readIn *fission.ReadIn)
 	return
 }
 
+// `cacheLineSlice` returns the portion of content (that of cacheLineNumber) starting at
+// curOffset of no more than sizeRemaining bytes. If curOffset is at EOF, this is empty.
+func cacheLineSlice(content []byte, cacheLineNumber uint64, cacheLineSize uint64, curOffset uint64, sizeRemaining uint64) []byte {
+	var (
+		cacheLineOffsetLimit uint64 // One greater than offset to last byte to return
+		cacheLineOffsetStart uint64
+	)
+
+	cacheLineOffsetStart = curOffset - (cacheLineNumber * cacheLineSize)
+
+	cacheLineOffsetLimit = cacheLineOffsetStart + sizeRemaining
+	if cacheLineOffsetLimit > cacheLineSize {
+		cacheLineOffsetLimit = cacheLineSize
+	}
+	if cacheLineOffsetLimit > uint64(len(content)) {
+		cacheLineOffsetLimit = uint64(len(content))
+	}
+
+	return content[cacheLineOffsetStart:cacheLineOffsetLimit]
+}
+
 // `DoWrite` implements the package fission callback to add or replace a portion of a file inode's contents.
 func (*globalsStruct) DoWrite(inHeader *fission.InHeader, writeIn *fission.WriteIn) (writeOut *fission.WriteOut, errno syscall.Errno) {
 	fmt.Println("[TODO] fission.go::DoWrite()")
 	errno = syscall.ENOSYS
+	recordFUSEOp("write", nil, errno)
 	return
 }
 
@@ -1064,6 +1500,7 @@ func (*globalsStruct) DoStatFS(inHeader *fission.InHeader) (statFSOut *fission.S
 	globals.Unlock()
 
 	errno = 0
+	recordFUSEOp("statfs", nil, errno)
 	return
 }
 
@@ -1095,7 +1532,11 @@ func (*globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fission.R
 				inode.backend.fissionMetrics.ReleaseFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("release", inode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "release", inode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "release", inode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -1124,6 +1565,8 @@ func (*globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fission.R
 
 	delete(inode.fhMap, fh.nonce)
 
+	fh.discardDirectReadLines()
+
 	inode.touch(nil)
 
 	if !inode.pendingDelete {
@@ -1135,7 +1578,7 @@ func (*globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fission.R
 
 	globals.Unlock()
 
-	inode.finishPendingDelete()
+	_ = inode.finishPendingDelete()
 
 	errno = 0
 	return
@@ -1146,6 +1589,7 @@ func (*globalsStruct) DoRelease(inHeader *fission.InHeader, releaseIn *fission.R
 func (*globalsStruct) DoFSync(inHeader *fission.InHeader, fSyncIn *fission.FSyncIn) (errno syscall.Errno) {
 	fmt.Println("[TODO] fission.go::DoFSync()")
 	errno = syscall.ENOSYS
+	recordFUSEOp("fsync", nil, errno)
 	return
 }
 
@@ -1153,6 +1597,7 @@ func (*globalsStruct) DoFSync(inHeader *fission.InHeader, fSyncIn *fission.FSync
 // for an inode (not supported).
 func (*globalsStruct) DoSetXAttr(inHeader *fission.InHeader, setXAttrIn *fission.SetXAttrIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("setxattr", nil, errno)
 	return
 }
 
@@ -1160,6 +1605,7 @@ func (*globalsStruct) DoSetXAttr(inHeader *fission.InHeader, setXAttrIn *fission
 // for an inode (not supported).
 func (*globalsStruct) DoGetXAttr(inHeader *fission.InHeader, getXAttrIn *fission.GetXAttrIn) (getXAttrOut *fission.GetXAttrOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("getxattr", nil, errno)
 	return
 }
 
@@ -1167,6 +1613,7 @@ func (*globalsStruct) DoGetXAttr(inHeader *fission.InHeader, getXAttrIn *fission
 // for an inode (not supported).
 func (*globalsStruct) DoListXAttr(inHeader *fission.InHeader, listXAttrIn *fission.ListXAttrIn) (listXAttrOut *fission.ListXAttrOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("listxattr", nil, errno)
 	return
 }
 
@@ -1174,6 +1621,7 @@ func (*globalsStruct) DoListXAttr(inHeader *fission.InHeader, listXAttrIn *fissi
 // for an inode (not supported).
 func (*globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAttrIn *fission.RemoveXAttrIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("removexattr", nil, errno)
 	return
 }
 
@@ -1182,6 +1630,7 @@ func (*globalsStruct) DoRemoveXAttr(inHeader *fission.InHeader, removeXAttrIn *f
 func (*globalsStruct) DoFlush(inHeader *fission.InHeader, flushIn *fission.FlushIn) (errno syscall.Errno) {
 	// fmt.Println("[TODO] fission.go::DoFlush()")
 	errno = syscall.ENOSYS
+	recordFUSEOp("flush", nil, errno)
 	return
 }
 
@@ -1205,6 +1654,7 @@ func (*globalsStruct) DoInit(inHeader *fission.InHeader, initIn *fission.InitIn)
 	}
 
 	errno = 0
+	recordFUSEOp("init", nil, errno)
 	return
 }
 
@@ -1236,11 +1686,21 @@ func (*globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fission.O
 				inode.backend.fissionMetrics.OpenDirFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("opendir", inode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "opendir", inode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "opendir", inode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
 
+	if globals.shuttingDown {
+		globals.Unlock()
+		errno = syscall.ESHUTDOWN
+		return
+	}
+
 	inode, ok = globals.inodeMap[inHeader.NodeID]
 	if !ok {
 		inode = nil
@@ -1253,6 +1713,11 @@ func (*globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fission.O
 		errno = syscall.ENOTDIR
 		return
 	}
+	if !inode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
 
 	if inode.inodeType == FUSERootDir {
 		fh = &fhStruct{
@@ -1266,13 +1731,12 @@ func (*globalsStruct) DoOpenDir(inHeader *fission.InHeader, openDirIn *fission.O
 			listDirectoryInProgress:               false,
 			listDirectorySequenceDone:             false,
 			prevListDirectoryOutput:               nil,
-			prevListDirectoryOutputFileLen:        0,
+			prevListDirectoryOutputEntryLen:       0,
 			prevListDirectoryOutputStartingOffset: 0,
 			nextListDirectoryOutput:               nil,
-			nextListDirectoryOutputFileLen:        0,
+			nextListDirectoryOutputEntryLen:       0,
 			nextListDirectoryOutputStartingOffset: 0,
 			listDirectorySubdirectorySet:          make(map[string]struct{}),
-			listDirectorySubdirectoryList:         make([]string, 0),
 		}
 	}
 
@@ -1325,30 +1789,30 @@ func (inode *inodeStruct) appendToReadDirOut(readDirInSize uint64, readDirOut *f
 // `DoReadDir` implements the package fission callback to enumerate a directory inode's entries (non-verbosely).
 func (*globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fission.ReadDirIn) (readDirOut *fission.ReadDirOut, errno syscall.Errno) {
 	var (
-		childDirMapIndex                            int
-		childDirMapLen                              uint64
-		childInode                                  *inodeStruct
-		childInodeBasename                          string
-		childInodeNumber                            uint64
-		curOffset                                   uint64
-		curOffsetInListDirectorySubdirectoryListCap uint64
-		curOffsetInNextListDirectoryOutputCap       uint64
-		curOffsetInPrevListDirectoryOutputCap       uint64
-		curOffsetInVirtChildInodeMapCap             uint64
-		curReadDirOutSize                           uint64
-		dirEntCountMax                              uint64
-		dirEntMinSize                               uint64
-		err                                         error
-		fh                                          *fhStruct
-		latency                                     float64
-		listDirectoryOutputFile                     *listDirectoryOutputFileStruct
-		listDirectoryInput                          *listDirectoryInputStruct
-		listDirectoryOutput                         *listDirectoryOutputStruct
-		ok                                          bool
-		parentInode                                 *inodeStruct
-		startTime                                   = time.Now()
-		subdirectory                                string
-		virtChildInodeMapIndex                      int
+		childDirMapIndex                      int
+		childDirMapLen                        uint64
+		childInode                            *inodeStruct
+		childInodeBasename                    string
+		childInodeNumber                      uint64
+		curOffset                             uint64
+		curOffsetInNextListDirectoryOutputCap uint64
+		curOffsetInPrevListDirectoryOutputCap uint64
+		curOffsetInVirtChildInodeMapCap       uint64
+		curReadDirOutSize                     uint64
+		dirEntCountMax                        uint64
+		dirEntMinSize                         uint64
+		err                                   error
+		fh                                    *fhStruct
+		latency                               float64
+		listDirectoryInput                    *listDirectoryInputStruct
+		listDirectoryOutput                   *listDirectoryOutputStruct
+		newSubdirectories                     []string
+		ok                                    bool
+		parentInode                           *inodeStruct
+		reusableListDirectoryOutput           *listDirectoryOutputStruct
+		startTime                             = time.Now()
+		subdirectory                          string
+		virtChildInodeMapIndex                int
 	)
 
 	defer func() {
@@ -1381,7 +1845,11 @@ func (*globalsStruct) DoReadDir(inHeader *fission.InHeader, readDirIn *fission.R
 				parentInode.backend.fissionMetrics.ReadDirFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("readdir", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "readdir", parentInode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "readdir", parentInode, "", 0, startTime, errno)
 	}()
 
 	dirEntMinSize = fission.DirEntFixedPortionSize + 1 + fission.DirEntAlignment - 1
@@ -1447,6 +1915,10 @@ Restart:
 
 			curOffset++
 
+			if !childInode.accessAllowed(inHeader) {
+				continue
+			}
+
 			ok = childInode.appendToReadDirOut(uint64(readDirIn.Size), readDirOut, curOffset, childInodeBasename, &curReadDirOutSize)
 			if !ok {
 				globals.Unlock()
@@ -1471,16 +1943,20 @@ Restart:
 	}
 
 	for {
-		if !fh.listDirectorySequenceDone && (curOffset >= (fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputFileLen)) {
+		if !fh.listDirectorySequenceDone && (curOffset >= (fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputEntryLen)) {
 			// Fetch the next listDirectoryOutput
 
+			reusableListDirectoryOutput = nil
+
 			if fh.nextListDirectoryOutput != nil {
+				reusableListDirectoryOutput = fh.prevListDirectoryOutput // No longer referenced once replaced below
+
 				fh.prevListDirectoryOutput = fh.nextListDirectoryOutput
-				fh.prevListDirectoryOutputFileLen = fh.nextListDirectoryOutputFileLen
+				fh.prevListDirectoryOutputEntryLen = fh.nextListDirectoryOutputEntryLen
 				fh.prevListDirectoryOutputStartingOffset = fh.nextListDirectoryOutputStartingOffset
 
 				fh.nextListDirectoryOutput = nil
-				fh.nextListDirectoryOutputFileLen = 0
+				fh.nextListDirectoryOutputEntryLen = 0
 			}
 
 			if fh.prevListDirectoryOutput == nil {
@@ -1494,6 +1970,7 @@ Restart:
 					continuationToken: fh.prevListDirectoryOutput.nextContinuationToken,
 					maxItems:          parentInode.backend.directoryPageSize,
 					dirPath:           parentInode.objectPath,
+					reuse:             reusableListDirectoryOutput,
 				}
 			}
 
@@ -1501,7 +1978,7 @@ Restart:
 
 			globals.Unlock()
 
-			listDirectoryOutput, err = listDirectoryWrapper(parentInode.backend.context, listDirectoryInput)
+			listDirectoryOutput, err = parentInode.backend.listDirectoryPartitioned(listDirectoryInput)
 
 			globals.Lock()
 
@@ -1520,28 +1997,32 @@ Restart:
 
 			fh.listDirectorySequenceDone = !listDirectoryOutput.isTruncated
 
+			// Omit any subdirectories already returned (e.g. by a prior page) such that each
+			// page's entries are simply its files followed by its (newly discovered) subdirectories
+			// and may be returned as soon as the page arrives (rather than once all have arrived)
+
+			newSubdirectories = listDirectoryOutput.subdirectory[:0]
+			for _, subdirectory = range listDirectoryOutput.subdirectory {
+				_, ok = fh.listDirectorySubdirectorySet[subdirectory]
+				if !ok {
+					fh.listDirectorySubdirectorySet[subdirectory] = struct{}{}
+					newSubdirectories = append(newSubdirectories, subdirectory)
+				}
+			}
+			listDirectoryOutput.subdirectory = newSubdirectories
+
 			if fh.prevListDirectoryOutput == nil {
 				fh.prevListDirectoryOutput = listDirectoryOutput
-				fh.prevListDirectoryOutputFileLen = uint64(len(listDirectoryOutput.file))
+				fh.prevListDirectoryOutputEntryLen = uint64(len(listDirectoryOutput.file) + len(listDirectoryOutput.subdirectory))
 				fh.prevListDirectoryOutputStartingOffset = 0
 
 				fh.nextListDirectoryOutput = nil
-				fh.nextListDirectoryOutputFileLen = 0
-				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputFileLen
+				fh.nextListDirectoryOutputEntryLen = 0
+				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputEntryLen
 			} else {
 				fh.nextListDirectoryOutput = listDirectoryOutput
-				fh.nextListDirectoryOutputFileLen = uint64(len(listDirectoryOutput.file))
-				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputStartingOffset + fh.prevListDirectoryOutputFileLen
-			}
-
-			// Ensure we remember all discovered subdirectories
-
-			for _, subdirectory = range listDirectoryOutput.subdirectory {
-				_, ok = fh.listDirectorySubdirectorySet[subdirectory]
-				if !ok {
-					fh.listDirectorySubdirectorySet[subdirectory] = struct{}{}
-					fh.listDirectorySubdirectoryList = append(fh.listDirectorySubdirectoryList, subdirectory)
-				}
+				fh.nextListDirectoryOutputEntryLen = uint64(len(listDirectoryOutput.file) + len(listDirectoryOutput.subdirectory))
+				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputStartingOffset + fh.prevListDirectoryOutputEntryLen
 			}
 
 			// Since we had to release globals.Lock during listDirectoryWrapper() call, we must restart from where we first grabbed it
@@ -1550,31 +2031,23 @@ Restart:
 		}
 
 		// At this point, we know either we are still reading fh.{prev|next}ListDirectoryOutput's
-		// or we are done with all of them and may proceed to return fh.listDirectorySubdirectoryList
-		// & parentInode.virtChildInodeMap entries
+		// or we are done with all of them and may proceed to return parentInode.virtChildInodeMap entries
 
 		curOffsetInPrevListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset
-		curOffsetInNextListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputFileLen
-		curOffsetInListDirectorySubdirectoryListCap = curOffsetInNextListDirectoryOutputCap + uint64(len(fh.listDirectorySubdirectoryList))
-		curOffsetInVirtChildInodeMapCap = curOffsetInListDirectorySubdirectoryListCap + uint64(parentInode.virtChildInodeMap.Len())
+		curOffsetInNextListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputEntryLen
+		curOffsetInVirtChildInodeMapCap = curOffsetInNextListDirectoryOutputCap + uint64(parentInode.virtChildInodeMap.Len())
 
 		switch {
 		case curOffset < curOffsetInPrevListDirectoryOutputCap:
-			listDirectoryOutputFile = &fh.prevListDirectoryOutput.file[curOffset-fh.prevListDirectoryOutputStartingOffset]
-			childInode = parentInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
+			childInode = parentInode.findListDirectoryOutputEntryInode(fh.prevListDirectoryOutput, curOffset-fh.prevListDirectoryOutputStartingOffset)
 			childInode.convertToPhysInodeIfNecessary()
 			childInodeBasename = childInode.basename
 		case curOffset < curOffsetInNextListDirectoryOutputCap:
-			listDirectoryOutputFile = &fh.nextListDirectoryOutput.file[curOffset-fh.nextListDirectoryOutputStartingOffset]
-			childInode = parentInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
-			childInode.convertToPhysInodeIfNecessary()
-			childInodeBasename = childInode.basename
-		case curOffset < curOffsetInListDirectorySubdirectoryListCap:
-			childInode = parentInode.findChildDirInode(fh.listDirectorySubdirectoryList[curOffset-curOffsetInNextListDirectoryOutputCap])
+			childInode = parentInode.findListDirectoryOutputEntryInode(fh.nextListDirectoryOutput, curOffset-fh.nextListDirectoryOutputStartingOffset)
 			childInode.convertToPhysInodeIfNecessary()
 			childInodeBasename = childInode.basename
 		case curOffset < curOffsetInVirtChildInodeMapCap:
-			virtChildInodeMapIndex = int(curOffset - curOffsetInListDirectorySubdirectoryListCap)
+			virtChildInodeMapIndex = int(curOffset - curOffsetInNextListDirectoryOutputCap)
 			childInodeBasename, childInodeNumber, ok = parentInode.virtChildInodeMap.GetByIndex(virtChildInodeMapIndex)
 			if !ok {
 				dumpStack()
@@ -1593,7 +2066,7 @@ Restart:
 
 		curOffset++
 
-		if !childInode.pendingDelete {
+		if !childInode.pendingDelete && childInode.accessAllowed(inHeader) {
 			ok = childInode.appendToReadDirOut(uint64(readDirIn.Size), readDirOut, curOffset, childInodeBasename, &curReadDirOutSize)
 			if !ok {
 				globals.Unlock()
@@ -1632,7 +2105,11 @@ func (*globalsStruct) DoReleaseDir(inHeader *fission.InHeader, releaseDirIn *fis
 				inode.backend.fissionMetrics.ReleaseDirFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("releasedir", inode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "releasedir", inode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "releasedir", inode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -1679,6 +2156,7 @@ func (*globalsStruct) DoReleaseDir(inHeader *fission.InHeader, releaseDirIn *fis
 // content for a directory inode is flushed (a no-op for this FUSE file system).
 func (*globalsStruct) DoFSyncDir(inHeader *fission.InHeader, fSyncDirIn *fission.FSyncDirIn) (errno syscall.Errno) {
 	errno = 0
+	recordFUSEOp("fsyncdir", nil, errno)
 	return
 }
 
@@ -1686,6 +2164,7 @@ func (*globalsStruct) DoFSyncDir(inHeader *fission.InHeader, fSyncDirIn *fission
 // of a POSIX lock on the file inode (not supported).
 func (*globalsStruct) DoGetLK(inHeader *fission.InHeader, getLKIn *fission.GetLKIn) (getLKOut *fission.GetLKOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("getlk", nil, errno)
 	return
 }
 
@@ -1693,6 +2172,7 @@ func (*globalsStruct) DoGetLK(inHeader *fission.InHeader, getLKIn *fission.GetLK
 // a POSIX lock (i.e. "trylock", non-blocking) on a file inode (not supported).
 func (*globalsStruct) DoSetLK(inHeader *fission.InHeader, setLKIn *fission.SetLKIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("setlk", nil, errno)
 	return
 }
 
@@ -1700,6 +2180,7 @@ func (*globalsStruct) DoSetLK(inHeader *fission.InHeader, setLKIn *fission.SetLK
 // (i.e. non-blocking) on a file inode (not supported).
 func (*globalsStruct) DoSetLKW(inHeader *fission.InHeader, setLKWIn *fission.SetLKWIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("setlkw", nil, errno)
 	return
 }
 
@@ -1709,6 +2190,7 @@ func (*globalsStruct) DoSetLKW(inHeader *fission.InHeader, setLKWIn *fission.Set
 // FUSE file system defers such authorization checks to the kernel.
 func (*globalsStruct) DoAccess(inHeader *fission.InHeader, accessIn *fission.AccessIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("access", nil, errno)
 	return
 }
 
@@ -1718,10 +2200,24 @@ func (*globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fission.Cre
 		basename    = string(createIn.Name)
 		ok          bool
 		parentInode *inodeStruct
+		startTime   = time.Now()
 	)
 
+	defer func() {
+		globals.Lock()
+		recordFUSEOp("create", parentInode, errno)
+		globals.Unlock()
+		globals.audit.record(inHeader, "create", parentInode, basename, 0, startTime, nil, errno)
+	}()
+
 	globals.Lock()
 
+	if globals.shuttingDown {
+		globals.Unlock()
+		errno = syscall.ESHUTDOWN
+		return
+	}
+
 	parentInode, ok = globals.inodeMap[inHeader.NodeID]
 	if !ok {
 		globals.Unlock()
@@ -1738,17 +2234,26 @@ func (*globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fission.Cre
 		errno = syscall.EPERM
 		return
 	}
-	if parentInode.backend.readOnly {
+	if parentInode.backend.readOnlyAt(parentInode.objectPath + basename) {
 		globals.Unlock()
 		errno = syscall.EPERM
 		return
 	}
-	_, ok = parentInode.findChildInode(basename)
+	if !parentInode.backend.accessAllowed(inHeader, parentInode.objectPath+basename, false) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
+	_, ok, errno = parentInode.findChildInode(basename)
 	if ok {
 		globals.Unlock()
 		errno = syscall.EEXIST
 		return
 	}
+	if errno != syscall.ENOENT {
+		globals.Unlock()
+		return
+	}
 
 	globals.Unlock()
 
@@ -1759,27 +2264,35 @@ func (*globalsStruct) DoCreate(inHeader *fission.InHeader, createIn *fission.Cre
 
 // `DoInterrupt` implements the package fission callback to interrupt another
 // active callback (not supported).
-func (*globalsStruct) DoInterrupt(inHeader *fission.InHeader, interruptIn *fission.InterruptIn) {}
+func (*globalsStruct) DoInterrupt(inHeader *fission.InHeader, interruptIn *fission.InterruptIn) {
+	recordFUSEOp("interrupt", nil, 0)
+}
 
 // `DoBMap` implements the package fission callback to map blocks of a FUSE "blkdev" device (not supported).
 func (*globalsStruct) DoBMap(inHeader *fission.InHeader, bMapIn *fission.BMapIn) (bMapOut *fission.BMapOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("bmap", nil, errno)
 	return
 }
 
 // `DoDestroy` implements the package fission callback to clean up this FUSE file system.
-func (*globalsStruct) DoDestroy(inHeader *fission.InHeader) (errno syscall.Errno) { return }
+func (*globalsStruct) DoDestroy(inHeader *fission.InHeader) (errno syscall.Errno) {
+	recordFUSEOp("destroy", nil, errno)
+	return
+}
 
 // `DoPoll` implements the package fission callback to poll for whether or not
 // another operation (e.g. DoRead) on a file handle has data available (not supported).
 func (*globalsStruct) DoPoll(inHeader *fission.InHeader, pollIn *fission.PollIn) (pollOut *fission.PollOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("poll", nil, errno)
 	return
 }
 
 // `DoBatchForget` implements the package fission callback to note that
 // the kernel has removed a set of inodes from its internal caches.
 func (*globalsStruct) DoBatchForget(inHeader *fission.InHeader, batchForgetIn *fission.BatchForgetIn) {
+	recordFUSEOp("batchforget", nil, 0)
 }
 
 // `DoFAllocate` implements the package fission callback to reserve space that
@@ -1787,19 +2300,22 @@ func (*globalsStruct) DoBatchForget(inHeader *fission.InHeader, batchForgetIn *f
 // to space allocation unavailable when that DoWrite callback is made (not supported).
 func (*globalsStruct) DoFAllocate(inHeader *fission.InHeader, fAllocateIn *fission.FAllocateIn) (errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("fallocate", nil, errno)
 	return
 }
 
 // `appendToReadDirPlusOut` appends the information about an inode in the form of a fission.DirEntPlus
 // to the accumulating fission.ReadDirPlusOut struct if there is room.
-func (inode *inodeStruct) appendToReadDirPlusOut(readDirPlusInSize uint64, readDirPlusOut *fission.ReadDirPlusOut, entryAttrValidSec uint64, entryAttrValidNSec uint32, dirEntPlusOff uint64, basename string, curReadDirOutSize *uint64) (ok bool) {
+func (inode *inodeStruct) appendToReadDirPlusOut(readDirPlusInSize uint64, readDirPlusOut *fission.ReadDirPlusOut, dirEntPlusOff uint64, basename string, curReadDirOutSize *uint64) (ok bool) {
 	var (
-		dirEntPlus     fission.DirEntPlus
-		dirEntPlusSize uint64
-		gid            uint64
-		mTimeNSec      uint32
-		mTimeSec       uint64
-		uid            uint64
+		dirEntPlus         fission.DirEntPlus
+		dirEntPlusSize     uint64
+		entryAttrValidNSec uint32
+		entryAttrValidSec  uint64
+		gid                uint64
+		mTimeNSec          uint32
+		mTimeSec           uint64
+		uid                uint64
 	)
 
 	dirEntPlusSize = fission.DirEntPlusFixedPortionSize + uint64(len(basename)) + fission.DirEntAlignment - 1
@@ -1814,7 +2330,9 @@ func (inode *inodeStruct) appendToReadDirPlusOut(readDirPlusInSize uint64, readD
 	*curReadDirOutSize += dirEntPlusSize
 	ok = true
 
-	mTimeSec, mTimeNSec = timeTimeToAttrTime(inode.mTime)
+	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(inode.entryAttrTTL())
+
+	mTimeSec, mTimeNSec = timeTimeToAttrTime(inode.reportedMTime())
 
 	if inode.inodeType == FUSERootDir {
 		uid = globals.config.uid
@@ -1866,32 +2384,30 @@ func (inode *inodeStruct) appendToReadDirPlusOut(readDirPlusInSize uint64, readD
 // `DoReadDirPlus` implements the package fission callback to enumerate a directory inode's entries (verbosely).
 func (*globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlusIn *fission.ReadDirPlusIn) (readDirPlusOut *fission.ReadDirPlusOut, errno syscall.Errno) {
 	var (
-		childDirMapIndex                            int
-		childDirMapLen                              uint64
-		childInode                                  *inodeStruct
-		childInodeBasename                          string
-		childInodeNumber                            uint64
-		curOffset                                   uint64
-		curOffsetInListDirectorySubdirectoryListCap uint64
-		curOffsetInNextListDirectoryOutputCap       uint64
-		curOffsetInPrevListDirectoryOutputCap       uint64
-		curOffsetInVirtChildInodeMapCap             uint64
-		curReadDirPlusOutSize                       uint64
-		dirEntPlusCountMax                          uint64
-		dirEntPlusMinSize                           uint64
-		entryAttrValidNSec                          uint32
-		entryAttrValidSec                           uint64
-		err                                         error
-		fh                                          *fhStruct
-		latency                                     float64
-		listDirectoryOutputFile                     *listDirectoryOutputFileStruct
-		listDirectoryInput                          *listDirectoryInputStruct
-		listDirectoryOutput                         *listDirectoryOutputStruct
-		ok                                          bool
-		parentInode                                 *inodeStruct
-		startTime                                   = time.Now()
-		subdirectory                                string
-		virtChildInodeMapIndex                      int
+		childDirMapIndex                      int
+		childDirMapLen                        uint64
+		childInode                            *inodeStruct
+		childInodeBasename                    string
+		childInodeNumber                      uint64
+		curOffset                             uint64
+		curOffsetInNextListDirectoryOutputCap uint64
+		curOffsetInPrevListDirectoryOutputCap uint64
+		curOffsetInVirtChildInodeMapCap       uint64
+		curReadDirPlusOutSize                 uint64
+		dirEntPlusCountMax                    uint64
+		dirEntPlusMinSize                     uint64
+		err                                   error
+		fh                                    *fhStruct
+		latency                               float64
+		listDirectoryInput                    *listDirectoryInputStruct
+		listDirectoryOutput                   *listDirectoryOutputStruct
+		newSubdirectories                     []string
+		ok                                    bool
+		parentInode                           *inodeStruct
+		reusableListDirectoryOutput           *listDirectoryOutputStruct
+		startTime                             = time.Now()
+		subdirectory                          string
+		virtChildInodeMapIndex                int
 	)
 
 	defer func() {
@@ -1924,7 +2440,11 @@ func (*globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlusIn *f
 				parentInode.backend.fissionMetrics.ReadDirPlusFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("readdirplus", parentInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "readdirplus", parentInode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "readdirplus", parentInode, "", 0, startTime, errno)
 	}()
 
 	dirEntPlusMinSize = fission.DirEntFixedPortionSize + 1 + fission.DirEntAlignment - 1
@@ -1939,8 +2459,6 @@ func (*globalsStruct) DoReadDirPlus(inHeader *fission.InHeader, readDirPlusIn *f
 	curReadDirPlusOutSize = 0
 	curOffset = readDirPlusIn.Offset
 
-	entryAttrValidSec, entryAttrValidNSec = timeDurationToAttrDuration(globals.config.entryAttrTTL)
-
 	globals.Lock()
 
 Restart:
@@ -1992,7 +2510,11 @@ Restart:
 
 			curOffset++
 
-			ok = childInode.appendToReadDirPlusOut(uint64(readDirPlusIn.Size), readDirPlusOut, entryAttrValidSec, entryAttrValidNSec, curOffset, childInodeBasename, &curReadDirPlusOutSize)
+			if !childInode.accessAllowed(inHeader) {
+				continue
+			}
+
+			ok = childInode.appendToReadDirPlusOut(uint64(readDirPlusIn.Size), readDirPlusOut, curOffset, childInodeBasename, &curReadDirPlusOutSize)
 			if !ok {
 				globals.Unlock()
 				errno = 0
@@ -2016,16 +2538,20 @@ Restart:
 	}
 
 	for {
-		if !fh.listDirectorySequenceDone && (curOffset >= (fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputFileLen)) {
+		if !fh.listDirectorySequenceDone && (curOffset >= (fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputEntryLen)) {
 			// Fetch the next listDirectoryOutput
 
+			reusableListDirectoryOutput = nil
+
 			if fh.nextListDirectoryOutput != nil {
+				reusableListDirectoryOutput = fh.prevListDirectoryOutput // No longer referenced once replaced below
+
 				fh.prevListDirectoryOutput = fh.nextListDirectoryOutput
-				fh.prevListDirectoryOutputFileLen = fh.nextListDirectoryOutputFileLen
+				fh.prevListDirectoryOutputEntryLen = fh.nextListDirectoryOutputEntryLen
 				fh.prevListDirectoryOutputStartingOffset = fh.nextListDirectoryOutputStartingOffset
 
 				fh.nextListDirectoryOutput = nil
-				fh.nextListDirectoryOutputFileLen = 0
+				fh.nextListDirectoryOutputEntryLen = 0
 			}
 
 			if fh.prevListDirectoryOutput == nil {
@@ -2039,6 +2565,7 @@ Restart:
 					continuationToken: fh.prevListDirectoryOutput.nextContinuationToken,
 					maxItems:          parentInode.backend.directoryPageSize,
 					dirPath:           parentInode.objectPath,
+					reuse:             reusableListDirectoryOutput,
 				}
 			}
 
@@ -2046,7 +2573,7 @@ Restart:
 
 			globals.Unlock()
 
-			listDirectoryOutput, err = listDirectoryWrapper(parentInode.backend.context, listDirectoryInput)
+			listDirectoryOutput, err = parentInode.backend.listDirectoryPartitioned(listDirectoryInput)
 
 			globals.Lock()
 
@@ -2065,28 +2592,32 @@ Restart:
 
 			fh.listDirectorySequenceDone = !listDirectoryOutput.isTruncated
 
+			// Omit any subdirectories already returned (e.g. by a prior page) such that each
+			// page's entries are simply its files followed by its (newly discovered) subdirectories
+			// and may be returned as soon as the page arrives (rather than once all have arrived)
+
+			newSubdirectories = listDirectoryOutput.subdirectory[:0]
+			for _, subdirectory = range listDirectoryOutput.subdirectory {
+				_, ok = fh.listDirectorySubdirectorySet[subdirectory]
+				if !ok {
+					fh.listDirectorySubdirectorySet[subdirectory] = struct{}{}
+					newSubdirectories = append(newSubdirectories, subdirectory)
+				}
+			}
+			listDirectoryOutput.subdirectory = newSubdirectories
+
 			if fh.prevListDirectoryOutput == nil {
 				fh.prevListDirectoryOutput = listDirectoryOutput
-				fh.prevListDirectoryOutputFileLen = uint64(len(listDirectoryOutput.file))
+				fh.prevListDirectoryOutputEntryLen = uint64(len(listDirectoryOutput.file) + len(listDirectoryOutput.subdirectory))
 				fh.prevListDirectoryOutputStartingOffset = 0
 
 				fh.nextListDirectoryOutput = nil
-				fh.nextListDirectoryOutputFileLen = 0
-				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputFileLen
+				fh.nextListDirectoryOutputEntryLen = 0
+				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputEntryLen
 			} else {
 				fh.nextListDirectoryOutput = listDirectoryOutput
-				fh.nextListDirectoryOutputFileLen = uint64(len(listDirectoryOutput.file))
-				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputStartingOffset + fh.prevListDirectoryOutputFileLen
-			}
-
-			// Ensure we remember all discovered subdirectories
-
-			for _, subdirectory = range listDirectoryOutput.subdirectory {
-				_, ok = fh.listDirectorySubdirectorySet[subdirectory]
-				if !ok {
-					fh.listDirectorySubdirectorySet[subdirectory] = struct{}{}
-					fh.listDirectorySubdirectoryList = append(fh.listDirectorySubdirectoryList, subdirectory)
-				}
+				fh.nextListDirectoryOutputEntryLen = uint64(len(listDirectoryOutput.file) + len(listDirectoryOutput.subdirectory))
+				fh.nextListDirectoryOutputStartingOffset = fh.prevListDirectoryOutputStartingOffset + fh.prevListDirectoryOutputEntryLen
 			}
 
 			// Since we had to release globals.Lock during listDirectoryWrapper() call, we must restart from where we first grabbed it
@@ -2095,31 +2626,23 @@ Restart:
 		}
 
 		// At this point, we know either we are still reading fh.{prev|next}ListDirectoryOutput's
-		// or we are done with all of them and may proceed to return fh.listDirectorySubdirectoryList
-		// & parentInode.virtChildInodeMap entries
+		// or we are done with all of them and may proceed to return parentInode.virtChildInodeMap entries
 
 		curOffsetInPrevListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset
-		curOffsetInNextListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputFileLen
-		curOffsetInListDirectorySubdirectoryListCap = curOffsetInNextListDirectoryOutputCap + uint64(len(fh.listDirectorySubdirectoryList))
-		curOffsetInVirtChildInodeMapCap = curOffsetInListDirectorySubdirectoryListCap + uint64(parentInode.virtChildInodeMap.Len())
+		curOffsetInNextListDirectoryOutputCap = fh.nextListDirectoryOutputStartingOffset + fh.nextListDirectoryOutputEntryLen
+		curOffsetInVirtChildInodeMapCap = curOffsetInNextListDirectoryOutputCap + uint64(parentInode.virtChildInodeMap.Len())
 
 		switch {
 		case curOffset < curOffsetInPrevListDirectoryOutputCap:
-			listDirectoryOutputFile = &fh.prevListDirectoryOutput.file[curOffset-fh.prevListDirectoryOutputStartingOffset]
-			childInode = parentInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
+			childInode = parentInode.findListDirectoryOutputEntryInode(fh.prevListDirectoryOutput, curOffset-fh.prevListDirectoryOutputStartingOffset)
 			childInode.convertToPhysInodeIfNecessary()
 			childInodeBasename = childInode.basename
 		case curOffset < curOffsetInNextListDirectoryOutputCap:
-			listDirectoryOutputFile = &fh.nextListDirectoryOutput.file[curOffset-fh.nextListDirectoryOutputStartingOffset]
-			childInode = parentInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
-			childInode.convertToPhysInodeIfNecessary()
-			childInodeBasename = childInode.basename
-		case curOffset < curOffsetInListDirectorySubdirectoryListCap:
-			childInode = parentInode.findChildDirInode(fh.listDirectorySubdirectoryList[curOffset-curOffsetInNextListDirectoryOutputCap])
+			childInode = parentInode.findListDirectoryOutputEntryInode(fh.nextListDirectoryOutput, curOffset-fh.nextListDirectoryOutputStartingOffset)
 			childInode.convertToPhysInodeIfNecessary()
 			childInodeBasename = childInode.basename
 		case curOffset < curOffsetInVirtChildInodeMapCap:
-			virtChildInodeMapIndex = int(curOffset - curOffsetInListDirectorySubdirectoryListCap)
+			virtChildInodeMapIndex = int(curOffset - curOffsetInNextListDirectoryOutputCap)
 			childInodeBasename, childInodeNumber, ok = parentInode.virtChildInodeMap.GetByIndex(virtChildInodeMapIndex)
 			if !ok {
 				dumpStack()
@@ -2138,8 +2661,8 @@ Restart:
 
 		curOffset++
 
-		if !childInode.pendingDelete {
-			ok = childInode.appendToReadDirPlusOut(uint64(readDirPlusIn.Size), readDirPlusOut, entryAttrValidSec, entryAttrValidNSec, curOffset, childInodeBasename, &curReadDirPlusOutSize)
+		if !childInode.pendingDelete && childInode.accessAllowed(inHeader) {
+			ok = childInode.appendToReadDirPlusOut(uint64(readDirPlusIn.Size), readDirPlusOut, curOffset, childInodeBasename, &curReadDirPlusOutSize)
 			if !ok {
 				globals.Unlock()
 				errno = 0
@@ -2152,6 +2675,7 @@ Restart:
 // `DoRename2` implements the package fission callback to rename a directory entry (not supported).
 func (*globalsStruct) DoRename2(inHeader *fission.InHeader, rename2In *fission.Rename2In) (errno syscall.Errno) {
 	errno = syscall.EXDEV
+	recordFUSEOp("rename2", nil, errno)
 	return
 }
 
@@ -2160,6 +2684,7 @@ func (*globalsStruct) DoRename2(inHeader *fission.InHeader, rename2In *fission.R
 // inode (not supported).
 func (*globalsStruct) DoLSeek(inHeader *fission.InHeader, lSeekIn *fission.LSeekIn) (lSeekOut *fission.LSeekOut, errno syscall.Errno) {
 	errno = syscall.ENOSYS
+	recordFUSEOp("lseek", nil, errno)
 	return
 }
 
@@ -2198,7 +2723,11 @@ func (*globalsStruct) DoStatX(inHeader *fission.InHeader, statXIn *fission.StatX
 				thisInode.backend.fissionMetrics.StatXFailureLatencies.Observe(latency)
 			}
 		}
+		recordFUSEOp("statx", thisInode, errno)
 		globals.Unlock()
+
+		globals.audit.record(inHeader, "statx", thisInode, "", 0, startTime, nil, errno)
+		logSlowFUSEOp(inHeader, "statx", thisInode, "", 0, startTime, errno)
 	}()
 
 	globals.Lock()
@@ -2215,6 +2744,11 @@ func (*globalsStruct) DoStatX(inHeader *fission.InHeader, statXIn *fission.StatX
 		errno = syscall.ENOENT
 		return
 	}
+	if !thisInode.accessAllowed(inHeader) {
+		globals.Unlock()
+		errno = syscall.EACCES
+		return
+	}
 
 	thisInode.touch(nil)
 
@@ -2236,8 +2770,8 @@ func (*globalsStruct) DoStatX(inHeader *fission.InHeader, statXIn *fission.StatX
 		globals.logger.Fatalf("[FATAL] unrecognized inodeType (%v)", thisInode.inodeType)
 	}
 
-	attrValidSec, attrValidNSec = timeDurationToAttrDuration(globals.config.entryAttrTTL)
-	mTimeSec, mTimeNSec = timeTimeToAttrTime(thisInode.mTime)
+	attrValidSec, attrValidNSec = timeDurationToAttrDuration(thisInode.entryAttrTTL())
+	mTimeSec, mTimeNSec = timeTimeToAttrTime(thisInode.reportedMTime())
 
 	statXOut = &fission.StatXOut{
 		AttrValidSec:  attrValidSec,
@@ -2288,3 +2822,87 @@ func (*globalsStruct) DoStatX(inHeader *fission.InHeader, statXIn *fission.StatX
 	errno = 0
 	return
 }
+
+// `fissionLookupPath` returns the inode (and its attributes) of dirName/path by looking up each element in turn.
+// As with fissionOpen(), fissionRead(), and fissionRelease(), the callbacks are invoked directly (i.e. bypassing
+// the kernel) such that, without a mount, the file system may be driven exactly as if mounted.
+func fissionLookupPath(dirName string, path string) (entryOut *fission.EntryOut, err error) {
+	var (
+		errno     syscall.Errno
+		inode     = uint64(FUSERootDirInodeNumber)
+		lookupOut *fission.LookupOut
+		name      string
+		names     = []string{dirName}
+	)
+
+	if path != "" {
+		names = append(names, strings.Split(path, "/")...)
+	}
+
+	for _, name = range names {
+		lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: inode}, &fission.LookupIn{Name: []byte(name)})
+		if errno != 0 {
+			err = fmt.Errorf("unable to lookup \"%s\" of \"%s/%s\": %v", name, dirName, path, errno)
+			return
+		}
+		inode = lookupOut.EntryOut.NodeID
+		entryOut = &lookupOut.EntryOut
+	}
+
+	return
+}
+
+// `fissionOpen` opens inode for reading.
+func fissionOpen(inode uint64) (fh uint64, err error) {
+	openOut, errno := globals.DoOpen(&fission.InHeader{NodeID: inode}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		err = fmt.Errorf("unable to open inode %v: %v", inode, errno)
+		return
+	}
+
+	fh = openOut.FH
+
+	return
+}
+
+// `fissionRelease` releases fh of inode.
+func fissionRelease(inode uint64, fh uint64) {
+	_ = globals.DoRelease(&fission.InHeader{NodeID: inode}, &fission.ReleaseIn{FH: fh})
+}
+
+// `fissionRead` reads (up to) size bytes at offset of fh of inode.
+func fissionRead(inode uint64, fh uint64, offset uint64, size uint64) (bytes uint64, err error) {
+	readOut, errno := globals.DoRead(&fission.InHeader{NodeID: inode}, &fission.ReadIn{FH: fh, Offset: offset, Size: uint32(size)})
+	if errno != 0 {
+		err = fmt.Errorf("unable to read inode %v at offset %v: %v", inode, offset, errno)
+		return
+	}
+
+	bytes = uint64(len(readOut.Data))
+
+	return
+}
+
+// `fissionReadFile` opens, reads (in its entirety, expected to be size bytes), and releases inode.
+func fissionReadFile(inode uint64, size uint64) (bytes uint64, err error) {
+	var (
+		fh        uint64
+		readBytes uint64
+	)
+
+	fh, err = fissionOpen(inode)
+	if err != nil {
+		return
+	}
+	defer fissionRelease(inode, fh)
+
+	for bytes < size {
+		readBytes, err = fissionRead(inode, fh, bytes, uint64(maxRead))
+		if (err != nil) || (readBytes == 0) {
+			return
+		}
+		bytes += readBytes
+	}
+
+	return
+}
@@ -6,8 +6,11 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"os"
+	"slices"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/NVIDIA/fission/v3"
 )
@@ -1693,3 +1696,1249 @@ func TestFissionConvertPhysicalToVirtual(t *testing.T) {
 
 	t.Logf("TestFissionConvertPhysicalToVirtual PASSED")
 }
+
+func TestFissionReadDirStreaming(t *testing.T) {
+	var (
+		errno      syscall.Errno
+		fh         *fhStruct
+		lookupOut  *fission.LookupOut
+		openDirOut *fission.OpenDirOut
+		ramDirIno  uint64
+		readDirIn  *fission.ReadDirIn
+		readDirOut *fission.ReadDirOut
+		returned   []string
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	// Listed a single entry per page (the RAM backend lists dir1 & dir2 before fileA & fileB)
+
+	globals.Lock()
+	globals.config.backends["ram"].directoryPageSize = 1
+	globals.Unlock()
+
+	openDirOut, errno = globals.DoOpenDir(&fission.InHeader{NodeID: ramDirIno}, &fission.OpenDirIn{})
+	if errno != 0 {
+		t.Fatalf("DoOpenDir(ramDirIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Each subdirectory is returned as soon as its page is listed
+
+	readDirIn = &fission.ReadDirIn{
+		FH:   openDirOut.FH,
+		Size: uint32(fission.DirEntFixedPortionSize + fission.DirEntAlignment), // Just enough for a single entry
+	}
+
+	readDirOut, errno = globals.DoReadDir(&fission.InHeader{NodeID: ramDirIno}, readDirIn)
+	if (errno != 0) || (len(readDirOut.DirEnt) != 1) || (string(readDirOut.DirEnt[0].Name) != "dir1") {
+		t.Fatalf("DoReadDir(ramDirFH, Offset: 0) returned %+v (errno: %v) (expected only dir1)", readDirOut, errno)
+	}
+
+	globals.Lock()
+	fh = globals.inodeMap[ramDirIno].fhMap[openDirOut.FH]
+	if fh.listDirectorySequenceDone {
+		globals.Unlock()
+		t.Fatalf("DoReadDir(ramDirFH, Offset: 0) listed the entire directory before returning dir1")
+	}
+	globals.Unlock()
+
+	// The remaining entries follow (each exactly once)
+
+	for len(readDirOut.DirEnt) > 0 {
+		returned = append(returned, string(readDirOut.DirEnt[0].Name))
+
+		readDirIn.Offset = readDirOut.DirEnt[0].Off
+
+		readDirOut, errno = globals.DoReadDir(&fission.InHeader{NodeID: ramDirIno}, readDirIn)
+		if errno != 0 {
+			t.Fatalf("DoReadDir(ramDirFH, Offset: %v) unexpectedly failed (errno: %v)", readDirIn.Offset, errno)
+		}
+	}
+
+	if strings.Join(returned, ",") != "dir1,dir2,fileA,fileB,.,.." {
+		t.Fatalf("DoReadDir(ramDirFH) returned %v", returned)
+	}
+
+	errno = globals.DoReleaseDir(&fission.InHeader{NodeID: ramDirIno}, &fission.ReleaseDirIn{FH: openDirOut.FH})
+	if errno != 0 {
+		t.Fatalf("DoReleaseDir(ramDirFH) unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+func TestFissionReadCoalescing(t *testing.T) {
+	var (
+		backend       *backendStruct
+		errno         syscall.Errno
+		fileBFH       uint64
+		fileBIno      uint64
+		inHeader      *fission.InHeader
+		lookupIn      *fission.LookupIn
+		lookupOut     *fission.LookupOut
+		openIn        *fission.OpenIn
+		openOut       *fission.OpenOut
+		ramDirIno     uint64
+		readIn        *fission.ReadIn
+		readOut       *fission.ReadOut
+		readsAtStart  uint64
+		readsExpected uint64
+		releaseIn     *fission.ReleaseIn
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileB"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	openIn = &fission.OpenIn{
+		Flags: fission.FOpenRequestRDONLY,
+	}
+	openOut, errno = globals.DoOpen(inHeader, openIn)
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBFH = openOut.FH
+
+	// A read spanning 3 uncached cache lines (prefetching none) should issue a single backend read
+
+	globals.Lock()
+	globals.config.cacheLinesToPrefetch = 0
+	globals.Unlock()
+
+	readsAtStart = counterValue(backend.backendMetrics.ReadFileSuccesses)
+	readsExpected = 1
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	readIn = &fission.ReadIn{
+		FH:     fileBFH,
+		Offset: globals.config.cacheLineSize - 1,
+		Size:   uint32(globals.config.cacheLineSize + 2),
+	}
+	readOut, errno = globals.DoRead(inHeader, readIn)
+	if errno != 0 {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly failed (errno: %v)", readIn.Offset, errno)
+	}
+	if !bytes.Equal(readOut.Data, testFissionFileBContent[readIn.Offset:readIn.Offset+uint64(readIn.Size)]) {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly returned mismatched bytes", readIn.Offset)
+	}
+	if counterValue(backend.backendMetrics.ReadFileSuccesses) != (readsAtStart + readsExpected) {
+		t.Fatalf("DoRead() spanning 3 cache lines issued %v backend reads (expected %v)", counterValue(backend.backendMetrics.ReadFileSuccesses)-readsAtStart, readsExpected)
+	}
+
+	// A cache miss prefetching 4 uncached cache lines should issue a backend read for the miss plus one for the prefetches
+
+	globals.Lock()
+	globals.config.cacheLinesToPrefetch = 4
+	globals.Unlock()
+
+	readsAtStart = counterValue(backend.backendMetrics.ReadFileSuccesses)
+	readsExpected = 2
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	readIn = &fission.ReadIn{
+		FH:     fileBFH,
+		Offset: 10 * globals.config.cacheLineSize,
+		Size:   uint32(testFissionReadBufSize),
+	}
+	_, errno = globals.DoRead(inHeader, readIn)
+	if errno != 0 {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly failed (errno: %v)", readIn.Offset, errno)
+	}
+
+	// Reading the last prefetched cache line awaits (and verifies) the completion of the prefetches
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	readIn = &fission.ReadIn{
+		FH:     fileBFH,
+		Offset: 15*globals.config.cacheLineSize - testFissionReadBufSize,
+		Size:   uint32(testFissionReadBufSize),
+	}
+	readOut, errno = globals.DoRead(inHeader, readIn)
+	if errno != 0 {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly failed (errno: %v)", readIn.Offset, errno)
+	}
+	if !bytes.Equal(readOut.Data, testFissionFileBContent[readIn.Offset:readIn.Offset+uint64(readIn.Size)]) {
+		t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly returned mismatched bytes", readIn.Offset)
+	}
+	if counterValue(backend.backendMetrics.ReadFileSuccesses) != (readsAtStart + readsExpected) {
+		t.Fatalf("DoRead() prefetching 4 cache lines issued %v backend reads (expected %v)", counterValue(backend.backendMetrics.ReadFileSuccesses)-readsAtStart, readsExpected)
+	}
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	releaseIn = &fission.ReleaseIn{
+		FH: fileBFH,
+	}
+	errno = globals.DoRelease(inHeader, releaseIn)
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileBFH) unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+func TestFissionReadBackendErrno(t *testing.T) {
+	var (
+		backend   *backendStruct
+		err       error
+		errno     syscall.Errno
+		fileAFH   uint64
+		fileAIno  uint64
+		inHeader  *fission.InHeader
+		lookupIn  *fission.LookupIn
+		lookupOut *fission.LookupOut
+		openIn    *fission.OpenIn
+		openOut   *fission.OpenOut
+		ramDirIno uint64
+		readIn    *fission.ReadIn
+		releaseIn *fission.ReleaseIn
+		unlinkIn  *fission.UnlinkIn
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileA"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: fileAIno,
+	}
+	openIn = &fission.OpenIn{
+		Flags: fission.FOpenRequestRDONLY,
+	}
+	openOut, errno = globals.DoOpen(inHeader, openIn)
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileAFH = openOut.FH
+
+	// Remove fileA behind our back so that fetching its content fails with ENOENT (each time it is read)
+
+	_, err = backend.context.deleteFile(&deleteFileInputStruct{
+		filePath: "fileA",
+	})
+	if err != nil {
+		t.Fatalf("deleteFile(\"fileA\") unexpectedly failed: %v", err)
+	}
+
+	inHeader = &fission.InHeader{
+		NodeID: fileAIno,
+	}
+	readIn = &fission.ReadIn{
+		FH:     fileAFH,
+		Offset: 0,
+		Size:   uint32(testFissionReadBufSize),
+	}
+	_, errno = globals.DoRead(inHeader, readIn)
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoRead(FH: fileAFH) of a removed object returned errno: %v (expected ENOENT)", errno)
+	}
+	_, errno = globals.DoRead(inHeader, readIn)
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoRead(FH: fileAFH) (again) of a removed object returned errno: %v (expected ENOENT)", errno)
+	}
+
+	releaseIn = &fission.ReleaseIn{
+		FH: fileAFH,
+	}
+	errno = globals.DoRelease(inHeader, releaseIn)
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileAFH) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Unlinking fileA (whose object is already gone) should nonetheless succeed
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	unlinkIn = &fission.UnlinkIn{
+		Name: []byte("fileA"),
+	}
+	errno = globals.DoUnlink(inHeader, unlinkIn)
+	if errno != 0 {
+		t.Fatalf("DoUnlink(ramDirIno,Name:\"fileA\") of a removed object unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+// `testETagContextStruct` overlays a backend's context such that every object has eTag (honoring ifNoneMatch).
+type testETagContextStruct struct {
+	backendContextIf
+	eTag string
+}
+
+func (testETagContext *testETagContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	if readFileInput.ifNoneMatch == testETagContext.eTag {
+		readFileOutput = &readFileOutputStruct{
+			eTag:        testETagContext.eTag,
+			buf:         make([]byte, 0),
+			notModified: true,
+		}
+		return
+	}
+
+	readFileOutput, err = testETagContext.backendContextIf.readFile(readFileInput)
+	if err == nil {
+		readFileOutput.eTag = testETagContext.eTag
+	}
+
+	return
+}
+
+func TestFissionReadRevalidation(t *testing.T) {
+	var (
+		backend              *backendStruct
+		eTag                 string
+		errno                syscall.Errno
+		fileBFH              uint64
+		fileBIno             uint64
+		inHeader             *fission.InHeader
+		lookupIn             *fission.LookupIn
+		lookupOut            *fission.LookupOut
+		openIn               *fission.OpenIn
+		openOut              *fission.OpenOut
+		ramDirIno            uint64
+		readIn               *fission.ReadIn
+		readOut              *fission.ReadOut
+		releaseIn            *fission.ReleaseIn
+		revalidations        uint64
+		revalidationsAtStart uint64
+		testETagContext      *testETagContextStruct
+		unchangedAtStart     uint64
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	testETagContext = &testETagContextStruct{
+		backendContextIf: backend.context,
+		eTag:             "v1",
+	}
+
+	globals.Lock()
+	backend.context = testETagContext
+	globals.config.cacheLinesToPrefetch = 0
+	globals.Unlock()
+
+	defer func() {
+		globals.Lock()
+		backend.context = testETagContext.backendContextIf
+		globals.config.cacheLineTTL = 0
+		globals.Unlock()
+	}()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileB"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	openIn = &fission.OpenIn{
+		Flags: fission.FOpenRequestRDONLY,
+	}
+	openOut, errno = globals.DoOpen(inHeader, openIn)
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBFH = openOut.FH
+
+	revalidationsAtStart = counterValue(globals.cacheMetrics.LineRevalidations)
+	unchangedAtStart = counterValue(globals.cacheMetrics.LineRevalidationsUnchanged)
+
+	read := func(step string) {
+		inHeader = &fission.InHeader{
+			NodeID: fileBIno,
+		}
+		readIn = &fission.ReadIn{
+			FH:     fileBFH,
+			Offset: 0,
+			Size:   uint32(testFissionReadBufSize),
+		}
+		readOut, errno = globals.DoRead(inHeader, readIn)
+		if errno != 0 {
+			t.Fatalf("%s DoRead(FH: fileBFH, Offset: 0) unexpectedly failed (errno: %v)", step, errno)
+		}
+		if !bytes.Equal(readOut.Data, testFissionFileBContent[:testFissionReadBufSize]) {
+			t.Fatalf("%s DoRead(FH: fileBFH, Offset: 0) unexpectedly returned mismatched bytes", step)
+		}
+	}
+
+	// Without a cache_line_ttl, a cached line is never revalidated
+
+	read("initial")
+	read("cached")
+
+	revalidations = counterValue(globals.cacheMetrics.LineRevalidations) - revalidationsAtStart
+	if revalidations != 0 {
+		t.Fatalf("DoRead() without cache_line_ttl revalidated %v cache lines (expected 0)", revalidations)
+	}
+
+	// Once older than cache_line_ttl, an unchanged cache line is revalidated (and retained)
+
+	globals.Lock()
+	globals.config.cacheLineTTL = time.Nanosecond
+	globals.Unlock()
+
+	read("unchanged")
+
+	if (counterValue(globals.cacheMetrics.LineRevalidations) != revalidationsAtStart+1) || (counterValue(globals.cacheMetrics.LineRevalidationsUnchanged) != unchangedAtStart+1) {
+		t.Fatalf("DoRead() of an unchanged cache line beyond cache_line_ttl left revalidations %v & unchanged %v (expected 1 & 1)", counterValue(globals.cacheMetrics.LineRevalidations)-revalidationsAtStart, counterValue(globals.cacheMetrics.LineRevalidationsUnchanged)-unchangedAtStart)
+	}
+
+	// A changed cache line is refetched
+
+	testETagContext.eTag = "v2"
+
+	read("changed")
+
+	if (counterValue(globals.cacheMetrics.LineRevalidations) != revalidationsAtStart+2) || (counterValue(globals.cacheMetrics.LineRevalidationsUnchanged) != unchangedAtStart+1) {
+		t.Fatalf("DoRead() of a changed cache line beyond cache_line_ttl left revalidations %v & unchanged %v (expected 2 & 1)", counterValue(globals.cacheMetrics.LineRevalidations)-revalidationsAtStart, counterValue(globals.cacheMetrics.LineRevalidationsUnchanged)-unchangedAtStart)
+	}
+
+	globals.Lock()
+	eTag = globals.inodeMap[fileBIno].cache[0].eTag
+	globals.Unlock()
+	if eTag != "v2" {
+		t.Fatalf("DoRead() of a changed cache line beyond cache_line_ttl left eTag \"%s\" (expected \"v2\")", eTag)
+	}
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	releaseIn = &fission.ReleaseIn{
+		FH: fileBFH,
+	}
+	errno = globals.DoRelease(inHeader, releaseIn)
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileBFH) unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+// `testMTimeContextStruct` overlays a backend's context such that every file listed was last modified at mTime.
+type testMTimeContextStruct struct {
+	backendContextIf
+	mTime time.Time
+}
+
+func (testMTimeContext *testMTimeContextStruct) listDirectory(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	listDirectoryOutput, err = testMTimeContext.backendContextIf.listDirectory(listDirectoryInput)
+	if err == nil {
+		for fileIndex := range listDirectoryOutput.file {
+			listDirectoryOutput.file[fileIndex].mTime = testMTimeContext.mTime
+		}
+	}
+
+	return
+}
+
+func TestFissionDirMTime(t *testing.T) {
+	var (
+		backend          *backendStruct
+		errno            syscall.Errno
+		getAttrOut       *fission.GetAttrOut
+		inHeader         *fission.InHeader
+		lookupIn         *fission.LookupIn
+		lookupOut        *fission.LookupOut
+		ramDirIno        uint64
+		testMTimeContext *testMTimeContextStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	testMTimeContext = &testMTimeContextStruct{
+		backendContextIf: backend.context,
+		mTime:            time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	globals.Lock()
+	backend.context = testMTimeContext
+	globals.Unlock()
+
+	defer func() {
+		globals.Lock()
+		backend.context = testMTimeContext.backendContextIf
+		globals.config.dirMTimeTTL = 60 * time.Second
+		globals.Unlock()
+	}()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	getAttr := func() (mTimeSec uint64) {
+		inHeader = &fission.InHeader{
+			NodeID: ramDirIno,
+		}
+		getAttrOut, errno = globals.DoGetAttr(inHeader, &fission.GetAttrIn{})
+		if errno != 0 {
+			t.Fatalf("DoGetAttr(ramDirIno) unexpectedly failed (errno: %v)", errno)
+		}
+		return getAttrOut.Attr.MTimeSec
+	}
+
+	awaitPrefetch := func() {
+		for {
+			globals.Lock()
+			if !globals.inodeMap[ramDirIno].isPrefetchInProgress {
+				globals.Unlock()
+				return
+			}
+			globals.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Once listed, a directory reports the mTime of its newest child
+
+	awaitPrefetch()
+
+	globals.Lock()
+	globals.inodeMap[ramDirIno].isPrefetchInProgress = true
+	globals.Unlock()
+
+	prefetchDirectory(ramDirIno, false)
+
+	if getAttr() != uint64(testMTimeContext.mTime.Unix()) {
+		t.Fatalf("DoGetAttr(ramDirIno) of listed directory returned MTimeSec %v (expected %v)", getAttrOut.Attr.MTimeSec, testMTimeContext.mTime.Unix())
+	}
+
+	// Beyond dir_mtime_ttl, the directory is (lazily) listed anew
+
+	globals.Lock()
+	globals.config.dirMTimeTTL = time.Nanosecond
+	globals.Unlock()
+
+	testMTimeContext.mTime = testMTimeContext.mTime.Add(time.Hour)
+
+	_ = getAttr()
+
+	awaitPrefetch()
+
+	globals.Lock()
+	globals.config.dirMTimeTTL = 60 * time.Second
+	globals.Unlock()
+
+	if getAttr() != uint64(testMTimeContext.mTime.Unix()) {
+		t.Fatalf("DoGetAttr(ramDirIno) beyond dir_mtime_ttl returned MTimeSec %v (expected %v)", getAttrOut.Attr.MTimeSec, testMTimeContext.mTime.Unix())
+	}
+}
+
+func TestFissionMkDirMarker(t *testing.T) {
+	var (
+		backend             *backendStruct
+		errno               syscall.Errno
+		err                 error
+		inHeader            *fission.InHeader
+		listDirectoryOutput *listDirectoryOutputStruct
+		lookupIn            *fission.LookupIn
+		lookupOut           *fission.LookupOut
+		mkDirIn             *fission.MkDirIn
+		mkDirOut            *fission.MkDirOut
+		ok                  bool
+		ramDirIno           uint64
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	globals.Lock()
+	backend.directoryMarkers = DirectoryMarkersFolder
+	globals.Unlock()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	mkDirIn = &fission.MkDirIn{
+		Name: []byte("marked_dir"),
+	}
+	mkDirOut, errno = globals.DoMkDir(inHeader, mkDirIn)
+	if errno != 0 {
+		t.Fatalf("DoMkDir(ramDirIno,Name:\"marked_dir\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	// The directory is marked in the backend (and hence "phys")
+
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: "marked_dir_$folder$"})
+	if err != nil {
+		t.Fatalf("statFileWrapper(\"marked_dir_$folder$\") unexpectedly failed: %v", err)
+	}
+
+	globals.Lock()
+	_, ok = globals.inodeMap[ramDirIno].physChildInodeMap.GetByKey("marked_dir")
+	if !ok || globals.inodeMap[mkDirOut.EntryOut.NodeID].isVirt {
+		globals.Unlock()
+		t.Fatalf("DoMkDir(ramDirIno,Name:\"marked_dir\") unexpectedly created a virt directory")
+	}
+	globals.Unlock()
+
+	// A later mount would find the (otherwise empty) directory rather than its marker
+
+	_, err = statDirectoryWrapper(backend.context, &statDirectoryInputStruct{dirPath: "marked_dir/"})
+	if err != nil {
+		t.Fatalf("statDirectoryWrapper(\"marked_dir/\") unexpectedly failed: %v", err)
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(backend.context, &listDirectoryInputStruct{dirPath: ""})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(\"\") unexpectedly failed: %v", err)
+	}
+	if !slices.Contains(listDirectoryOutput.subdirectory, "marked_dir") {
+		t.Fatalf("listDirectoryWrapper(\"\") unexpectedly omitted subdirectory \"marked_dir\"")
+	}
+	if slices.ContainsFunc(listDirectoryOutput.file, func(file listDirectoryOutputFileStruct) bool { return file.basename == "marked_dir_$folder$" }) {
+		t.Fatalf("listDirectoryWrapper(\"\") unexpectedly listed the marker of \"marked_dir\" as a file")
+	}
+
+	// A "dir/" marker is never listed (as a file with an empty basename) within the directory it marks
+
+	listDirectoryOutput = &listDirectoryOutputStruct{
+		subdirectory: []string{},
+		file:         []listDirectoryOutputFileStruct{{basename: ""}, {basename: "fileA"}},
+	}
+
+	backend.directoryMarkerMergeListDirectory(listDirectoryOutput)
+
+	if (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "fileA") {
+		t.Fatalf("directoryMarkerMergeListDirectory() unexpectedly returned files %#v", listDirectoryOutput.file)
+	}
+}
+
+func TestFissionDoRmDirMarker(t *testing.T) {
+	var (
+		backend   *backendStruct
+		err       error
+		errno     syscall.Errno
+		inHeader  *fission.InHeader
+		lookupIn  *fission.LookupIn
+		lookupOut *fission.LookupOut
+		mkDirIn   *fission.MkDirIn
+		ok        bool
+		ramDirIno uint64
+		rmDirIn   *fission.RmDirIn
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	globals.Lock()
+	backend.directoryMarkers = DirectoryMarkersFolder
+	globals.Unlock()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	mkDirIn = &fission.MkDirIn{
+		Name: []byte("marked_dir"),
+	}
+	_, errno = globals.DoMkDir(inHeader, mkDirIn)
+	if errno != 0 {
+		t.Fatalf("DoMkDir(ramDirIno,Name:\"marked_dir\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	// A file written beneath the directory by another client (so unknown to its inode) makes it not empty
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "marked_dir/fileX", buf: []byte("fileX")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"marked_dir/fileX\") unexpectedly failed: %v", err)
+	}
+
+	rmDirIn = &fission.RmDirIn{
+		Name: []byte("marked_dir"),
+	}
+	errno = globals.DoRmDir(inHeader, rmDirIn)
+	if errno != syscall.ENOTEMPTY {
+		t.Fatalf("DoRmDir(ramDirIno,Name:\"marked_dir\") with a file beneath it returned errno %v (expected ENOTEMPTY)", errno)
+	}
+
+	_, err = deleteFileWrapper(backend.context, &deleteFileInputStruct{filePath: "marked_dir/fileX"})
+	if err != nil {
+		t.Fatalf("deleteFileWrapper(\"marked_dir/fileX\") unexpectedly failed: %v", err)
+	}
+
+	// Once empty, the directory and its marker are removed and the listing of its parent is deemed stale
+
+	globals.Lock()
+	globals.inodeMap[ramDirIno].newestChildMTimeAsOf = time.Now()
+	globals.Unlock()
+
+	errno = globals.DoRmDir(inHeader, rmDirIn)
+	if errno != 0 {
+		t.Fatalf("DoRmDir(ramDirIno,Name:\"marked_dir\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: "marked_dir_$folder$"})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statFileWrapper(\"marked_dir_$folder$\") after DoRmDir() returned err %v (expected ENOENT)", err)
+	}
+
+	globals.Lock()
+	_, ok = globals.inodeMap[ramDirIno].physChildInodeMap.GetByKey("marked_dir")
+	if ok {
+		globals.Unlock()
+		t.Fatalf("DoRmDir(ramDirIno,Name:\"marked_dir\") unexpectedly retained the directory's inode")
+	}
+	if time.Since(globals.inodeMap[ramDirIno].newestChildMTimeAsOf) < globals.config.dirMTimeTTL {
+		globals.Unlock()
+		t.Fatalf("DoRmDir(ramDirIno,Name:\"marked_dir\") unexpectedly left the listing of its parent current")
+	}
+	globals.Unlock()
+
+	_, errno = globals.DoLookup(inHeader, &fission.LookupIn{Name: []byte("marked_dir")})
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"marked_dir\") after DoRmDir() returned errno %v (expected ENOENT)", errno)
+	}
+}
+
+func TestFissionDoMkDirBackendVisible(t *testing.T) {
+	var (
+		backend   *backendStruct
+		err       error
+		errno     syscall.Errno
+		inHeader  *fission.InHeader
+		lookupIn  *fission.LookupIn
+		lookupOut *fission.LookupOut
+		mkDirIn   *fission.MkDirIn
+		mkDirOut  *fission.MkDirOut
+		ramDirIno uint64
+		rmDirIn   *fission.RmDirIn
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	if backend.directoryMarkers != DirectoryMarkersSlash {
+		t.Fatalf("backend.directoryMarkers defaulted to \"%s\" (expected \"%s\")", backend.directoryMarkers, DirectoryMarkersSlash)
+	}
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	// As RAM is hierarchical, mkdir creates a real directory immediately visible to other clients
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	mkDirIn = &fission.MkDirIn{
+		Name: []byte("new_dir"),
+	}
+	mkDirOut, errno = globals.DoMkDir(inHeader, mkDirIn)
+	if errno != 0 {
+		t.Fatalf("DoMkDir(ramDirIno,Name:\"new_dir\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	globals.Lock()
+	if globals.inodeMap[mkDirOut.EntryOut.NodeID].isVirt {
+		globals.Unlock()
+		t.Fatalf("DoMkDir(ramDirIno,Name:\"new_dir\") unexpectedly created a virt directory")
+	}
+	globals.Unlock()
+
+	_, err = statDirectoryWrapper(backend.context, &statDirectoryInputStruct{dirPath: "new_dir/"})
+	if err != nil {
+		t.Fatalf("statDirectoryWrapper(\"new_dir/\") after DoMkDir() unexpectedly failed: %v", err)
+	}
+
+	rmDirIn = &fission.RmDirIn{
+		Name: []byte("new_dir"),
+	}
+	errno = globals.DoRmDir(inHeader, rmDirIn)
+	if errno != 0 {
+		t.Fatalf("DoRmDir(ramDirIno,Name:\"new_dir\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, err = statDirectoryWrapper(backend.context, &statDirectoryInputStruct{dirPath: "new_dir/"})
+	if backendErrno(err) != syscall.ENOENT {
+		t.Fatalf("statDirectoryWrapper(\"new_dir/\") after DoRmDir() returned err %v (expected ENOENT)", err)
+	}
+}
+
+// `testETagConflictContextStruct` overlays a backend's context such that every object read or stat'd has eTag.
+type testETagConflictContextStruct struct {
+	backendContextIf
+	eTag string
+}
+
+func (testETagConflictContext *testETagConflictContextStruct) readFile(readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	readFileOutput, err = testETagConflictContext.backendContextIf.readFile(readFileInput)
+	if err == nil {
+		readFileOutput.eTag = testETagConflictContext.eTag
+	}
+
+	return
+}
+
+func (testETagConflictContext *testETagConflictContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	statFileOutput, err = testETagConflictContext.backendContextIf.statFile(statFileInput)
+	if err == nil {
+		statFileOutput.eTag = testETagConflictContext.eTag
+	}
+
+	return
+}
+
+func TestFissionReadETagConflict(t *testing.T) {
+	var (
+		backend                  *backendStruct
+		cacheLineNumber          uint64
+		conflictsAtStart         uint64
+		errno                    syscall.Errno
+		fileBFH                  uint64
+		fileBIno                 uint64
+		inHeader                 *fission.InHeader
+		inode                    *inodeStruct
+		lookupIn                 *fission.LookupIn
+		lookupOut                *fission.LookupOut
+		openIn                   *fission.OpenIn
+		openOut                  *fission.OpenOut
+		ramDirIno                uint64
+		readIn                   *fission.ReadIn
+		readOut                  *fission.ReadOut
+		releaseIn                *fission.ReleaseIn
+		testETagConflictContext  *testETagConflictContextStruct
+		unexpectedCacheLineFound bool
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	testETagConflictContext = &testETagConflictContextStruct{
+		backendContextIf: backend.context,
+		eTag:             "v2",
+	}
+
+	globals.Lock()
+	backend.context = testETagConflictContext
+	globals.config.cacheLinesToPrefetch = 0
+	globals.Unlock()
+
+	defer func() {
+		globals.Lock()
+		backend.context = testETagConflictContext.backendContextIf
+		globals.config.eTagConflictPolicy = ETagConflictPolicyFail
+		globals.Unlock()
+	}()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileB"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	openIn = &fission.OpenIn{
+		Flags: fission.FOpenRequestRDONLY,
+	}
+	openOut, errno = globals.DoOpen(inHeader, openIn)
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileBFH = openOut.FH
+
+	conflictsAtStart = counterValue(globals.cacheMetrics.LineETagConflicts)
+
+	// Each read is of a not yet cached line of fileB after it was (supposedly) replaced by another writer
+
+	read := func(step string, policy string, lineNumber uint64, sizeInBackendDelta uint64) (errno syscall.Errno) {
+		globals.Lock()
+		globals.config.eTagConflictPolicy = policy
+		inode = globals.inodeMap[fileBIno]
+		inode.eTag = "v1"
+		inode.sizeInBackend += sizeInBackendDelta
+		globals.Unlock()
+
+		inHeader = &fission.InHeader{
+			NodeID: fileBIno,
+		}
+		readIn = &fission.ReadIn{
+			FH:     fileBFH,
+			Offset: lineNumber * globals.config.cacheLineSize,
+			Size:   uint32(testFissionReadBufSize),
+		}
+		readOut, errno = globals.DoRead(inHeader, readIn)
+		if (errno == 0) && !bytes.Equal(readOut.Data, testFissionFileBContent[readIn.Offset:(readIn.Offset+uint64(len(readOut.Data)))]) {
+			t.Fatalf("%s DoRead(FH: fileBFH, Offset: %v) unexpectedly returned mismatched bytes", step, readIn.Offset)
+		}
+
+		globals.Lock()
+		inode.sizeInBackend -= sizeInBackendDelta
+		globals.Unlock()
+
+		return
+	}
+
+	// Under etag_conflict_policy "fail", the read fails with ESTALE
+
+	errno = read("fail", ETagConflictPolicyFail, 0, 0)
+	if errno != syscall.ESTALE {
+		t.Fatalf("DoRead() of a conflicting line under \"fail\" returned errno %v (expected ESTALE)", errno)
+	}
+
+	// Under etag_conflict_policy "refetch", a grown (or unchanged in size) file adopts the new eTag
+
+	errno = read("refetch", ETagConflictPolicyRefetch, 1, 0)
+	if errno != 0 {
+		t.Fatalf("DoRead() of a conflicting line under \"refetch\" unexpectedly failed (errno: %v)", errno)
+	}
+
+	globals.Lock()
+	if inode.eTag != "v2" {
+		globals.Unlock()
+		t.Fatalf("DoRead() of a conflicting line under \"refetch\" left eTag \"%s\" (expected \"v2\")", inode.eTag)
+	}
+	globals.Unlock()
+
+	// ...but a shrunken file fails with ESTALE
+
+	errno = read("refetch (shrunk)", ETagConflictPolicyRefetch, 2, 1)
+	if errno != syscall.ESTALE {
+		t.Fatalf("DoRead() of a conflicting line of a shrunken file under \"refetch\" returned errno %v (expected ESTALE)", errno)
+	}
+
+	// Under etag_conflict_policy "last_writer_wins", the new eTag is adopted and all other cached lines are discarded
+
+	errno = read("last_writer_wins", ETagConflictPolicyLastWriterWins, 3, 0)
+	if errno != 0 {
+		t.Fatalf("DoRead() of a conflicting line under \"last_writer_wins\" unexpectedly failed (errno: %v)", errno)
+	}
+
+	globals.Lock()
+	for cacheLineNumber = range inode.cache {
+		if cacheLineNumber != 3 {
+			unexpectedCacheLineFound = true
+		}
+	}
+	if (inode.eTag != "v2") || unexpectedCacheLineFound {
+		globals.Unlock()
+		t.Fatalf("DoRead() of a conflicting line under \"last_writer_wins\" left eTag \"%s\" & %v cache lines (expected \"v2\" & 1)", inode.eTag, len(inode.cache))
+	}
+	globals.Unlock()
+
+	if counterValue(globals.cacheMetrics.LineETagConflicts) != conflictsAtStart+4 {
+		t.Fatalf("DoRead()'s of conflicting lines counted %v eTag conflicts (expected 4)", counterValue(globals.cacheMetrics.LineETagConflicts)-conflictsAtStart)
+	}
+
+	inHeader = &fission.InHeader{
+		NodeID: fileBIno,
+	}
+	releaseIn = &fission.ReleaseIn{
+		FH: fileBFH,
+	}
+	errno = globals.DoRelease(inHeader, releaseIn)
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileBFH) unexpectedly failed (errno: %v)", errno)
+	}
+}
+
+func TestFissionDoOpenStatOnOpen(t *testing.T) {
+	var (
+		backend   *backendStruct
+		data      []byte
+		err       error
+		errno     syscall.Errno
+		fileAIno  uint64
+		inHeader  *fission.InHeader
+		lookupIn  *fission.LookupIn
+		lookupOut *fission.LookupOut
+		ramDirIno uint64
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend = globals.config.backends["ram"]
+
+	defer func() {
+		globals.Lock()
+		globals.config.statOnOpen = false
+		globals.Unlock()
+	}()
+
+	inHeader = &fission.InHeader{
+		NodeID: FUSERootDirInodeNumber,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("ram"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{
+		NodeID: ramDirIno,
+	}
+	lookupIn = &fission.LookupIn{
+		Name: []byte("fileA"),
+	}
+	lookupOut, errno = globals.DoLookup(inHeader, lookupIn)
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	// Each open reads all of fileA via a fresh file handle
+
+	openAndRead := func(step string) (data []byte, errno syscall.Errno) {
+		var (
+			openIn    *fission.OpenIn
+			openOut   *fission.OpenOut
+			readIn    *fission.ReadIn
+			readOut   *fission.ReadOut
+			releaseIn *fission.ReleaseIn
+		)
+
+		inHeader = &fission.InHeader{
+			NodeID: fileAIno,
+		}
+		openIn = &fission.OpenIn{
+			Flags: fission.FOpenRequestRDONLY,
+		}
+		openOut, errno = globals.DoOpen(inHeader, openIn)
+		if errno != 0 {
+			return
+		}
+
+		readIn = &fission.ReadIn{
+			FH:     openOut.FH,
+			Offset: 0,
+			Size:   uint32(testFissionReadBufSize),
+		}
+		readOut, errno = globals.DoRead(inHeader, readIn)
+		if errno != 0 {
+			t.Fatalf("%s DoRead(fileAIno) unexpectedly failed (errno: %v)", step, errno)
+		}
+
+		data = readOut.Data
+
+		releaseIn = &fission.ReleaseIn{
+			FH: openOut.FH,
+		}
+		errno = globals.DoRelease(inHeader, releaseIn)
+		if errno != 0 {
+			t.Fatalf("%s DoRelease(fileAIno) unexpectedly failed (errno: %v)", step, errno)
+		}
+
+		return
+	}
+
+	data, errno = openAndRead("initial")
+	if (errno != 0) || (string(data) != "/fileA\n") {
+		t.Fatalf("initial open & read of fileA returned \"%s\" (errno: %v)", string(data), errno)
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{
+		filePath: "fileA",
+		buf:      []byte("/fileA overwritten\n"),
+	})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"fileA\") unexpectedly failed: %v", err)
+	}
+
+	// Without stat_on_open, what was learned at lookup (and since cached) is trusted
+
+	data, errno = openAndRead("cached")
+	if (errno != 0) || (string(data) != "/fileA\n") {
+		t.Fatalf("open & read of overwritten fileA without stat_on_open returned \"%s\" (errno: %v)", string(data), errno)
+	}
+
+	// With stat_on_open, the overwritten fileA is seen
+
+	globals.Lock()
+	globals.config.statOnOpen = true
+	globals.Unlock()
+
+	data, errno = openAndRead("refreshed")
+	if (errno != 0) || (string(data) != "/fileA overwritten\n") {
+		t.Fatalf("open & read of overwritten fileA with stat_on_open returned \"%s\" (errno: %v)", string(data), errno)
+	}
+
+	// ...as is its removal
+
+	_, err = deleteFileWrapper(backend.context, &deleteFileInputStruct{
+		filePath: "fileA",
+	})
+	if err != nil {
+		t.Fatalf("deleteFileWrapper(\"fileA\") unexpectedly failed: %v", err)
+	}
+
+	_, errno = openAndRead("removed")
+	if errno != syscall.ENOENT {
+		t.Fatalf("open of removed fileA with stat_on_open returned errno %v (expected ENOENT)", errno)
+	}
+}
@@ -13,6 +13,7 @@ import (
 // `initFS` initializes the root of the FUSE file system.
 func initFS() {
 	var (
+		err     error
 		timeNow time.Time
 	)
 
@@ -64,9 +65,25 @@ func initFS() {
 	globals.cleanCacheLineLRU = list.New()
 	globals.outboundCacheLineCount = 0
 	globals.dirtyCacheLineLRU = list.New()
+	globals.cachePins = make(map[string]struct{})
 
 	globals.fissionMetrics = newFissionMetrics()
 	globals.backendMetrics = newBackendMetrics()
+	globals.cacheMetrics = newCacheMetrics()
+
+	globals.qosScheduler = newQoSScheduler(globals.config.maxConcurrentBackendRequests)
+	globals.retryBudget = newRetryBudget(globals.config.retryBudgetRatio, globals.config.retryBudgetMinPerSecond, globals.config.retryBudgetBurst)
+
+	globals.secrets = newSecrets()
+
+	globals.ioAccounting = newIOAccounting()
+
+	globals.webhooks = newWebhooks()
+
+	globals.audit, err = newAudit()
+	if err != nil {
+		globals.logger.Fatalf("[FATAL] unable to open audit_log_file: %v", err)
+	}
 
 	globals.Unlock()
 }
@@ -74,13 +91,34 @@ func initFS() {
 // `drainFS` awaits all backend/asynchronous traffic to complete before
 func drainFS() {
 	var (
-		dirName string
-		backend *backendStruct
+		dirName    string
+		backend    *backendStruct
+		fetchPool  *fetchPoolStruct
+		fetchPools []*fetchPoolStruct
 	)
 
 	globals.inodeEvictorCancelFunc()
 	globals.inodeEvictorWaitGroup.Wait()
 
+	// Await any cache line (or direct read) fetches queued or in flight (each of which requires globals.Lock())
+
+	globals.Lock()
+	fetchPools = make([]*fetchPoolStruct, 0, len(globals.config.backends))
+	for _, backend = range globals.config.backends {
+		fetchPools = append(fetchPools, backend.fetchPool)
+	}
+	globals.Unlock()
+
+	for _, fetchPool = range fetchPools {
+		fetchPool.stop()
+	}
+
+	globals.directReadWaitGroup.Wait()
+
+	// Ship any remaining audit records while the audit_backend is still mounted
+
+	globals.audit.close()
+
 	globals.Lock()
 
 	for dirName, backend = range globals.config.backends {
@@ -90,6 +128,8 @@ func drainFS() {
 	processToUnmountListAlreadyLocked()
 
 	globals.Unlock()
+
+	globals.webhooks.wait()
 }
 
 // `processToMountList` creates a backend subdirectory of the FUSE
@@ -162,6 +202,16 @@ func processToMountList() {
 		globals.config.backends[dirName] = backend
 	}
 
+	refreshMirrorsAlreadyLocked()
+	refreshTieringAlreadyLocked()
+	refreshQuotasAlreadyLocked()
+	refreshHealthChecksAlreadyLocked()
+	refreshMultipartGCsAlreadyLocked()
+	refreshReplicasAlreadyLocked()
+	refreshCredentialWatchesAlreadyLocked()
+	refreshSnapshotsAlreadyLocked()
+	refreshShardedAlreadyLocked()
+
 	globals.Unlock()
 }
 
@@ -198,6 +248,14 @@ func processToUnmountListAlreadyLocked() {
 
 		backend.mounted = false
 
+		backend.stopMirrorAlreadyLocked()
+		backend.stopTieringAlreadyLocked()
+		backend.stopQuotasAlreadyLocked()
+		backend.stopHealthCheckAlreadyLocked()
+		backend.stopMultipartGCAlreadyLocked()
+		backend.stopReplicaRouterAlreadyLocked()
+		backend.stopCredentialWatchAlreadyLocked()
+
 		delete(globals.config.backends, dirName)
 	}
 }
@@ -557,6 +615,70 @@ func convertAncestorDirectoriesToVirtual(dirInode *inodeStruct) {
 	convertDirectoryToVirtual(dirInode)
 }
 
+// `reportedMTime` is called while globals.Lock() is held to return the mTime reported for
+// inode. For a directory, this is that of its newest child as of its most recent complete
+// listing (or, if it has yet to be listed or was empty, when its inodeStruct was created).
+// Should that listing be older than dir_mtime_ttl, the directory is listed anew (in the
+// background) so that a subsequent call reports a refreshed mTime.
+func (inode *inodeStruct) reportedMTime() time.Time {
+	if (inode.inodeType != BackendRootDir) && (inode.inodeType != PseudoDir) {
+		return inode.mTime
+	}
+
+	if (globals.config.dirMTimeTTL != 0) && !inode.isPrefetchInProgress && !inode.newestChildMTimeAsOf.IsZero() && (time.Since(inode.newestChildMTimeAsOf) >= globals.config.dirMTimeTTL) {
+		inode.isPrefetchInProgress = true
+		go prefetchDirectory(inode.inodeNumber, true)
+	}
+
+	if inode.newestChildMTime.IsZero() {
+		return inode.mTime
+	}
+
+	return inode.newestChildMTime
+}
+
+// `invalidateListing` is called, while globals.Lock() is held, once a child of dirInode
+// has been added or removed such that what was derived from its prior listing is no longer current.
+// Its newestChildMTime is deemed stale so that its next stat lists it anew.
+func (dirInode *inodeStruct) invalidateListing() {
+	if !dirInode.newestChildMTimeAsOf.IsZero() {
+		dirInode.newestChildMTimeAsOf = time.Now().Add(-globals.config.dirMTimeTTL)
+	}
+}
+
+// `refreshOnOpen` is called, while globals.Lock() is held, with what a statFile() of the
+// file inode being opened (with stat_on_open) found. Should the file have changed since it
+// was looked up (or last refreshed), inode takes on its new eTag, size, and mTime and any
+// clean cache lines of its prior content are evicted. As S3 reports mTime's to the second
+// via HeadObject but to the millisecond via ListObjects, mTime's are only compared should
+// eTags not be reported.
+func (inode *inodeStruct) refreshOnOpen(statFileOutput *statFileOutputStruct) {
+	var (
+		cacheLine *cacheLineStruct
+		changed   bool
+	)
+
+	if (inode.eTag != "") && (statFileOutput.eTag != "") {
+		changed = inode.backend.eTagsConflict(inode.eTag, statFileOutput.eTag) || (inode.sizeInBackend != statFileOutput.size)
+	} else {
+		changed = (inode.sizeInBackend != statFileOutput.size) || !inode.mTime.Equal(statFileOutput.mTime)
+	}
+	if !changed {
+		return
+	}
+
+	for _, cacheLine = range inode.cache {
+		if cacheLine.state == CacheLineClean {
+			evictCleanCacheLine(cacheLine.listElement)
+		}
+	}
+
+	inode.eTag = statFileOutput.eTag
+	inode.sizeInBackend = statFileOutput.size
+	inode.sizeInMemory = statFileOutput.size
+	inode.mTime = statFileOutput.mTime
+}
+
 // `touch` is called to ensure an inode that should be on globals.inodeEvictionLRU has the
 // appropriate .xTime. `touch` will optionally update .mTime as well. If the inode should
 // not be on globals.inodeEvictionLRU, its .listElement will be nil.
@@ -651,6 +773,15 @@ func inodeEvictor() {
 					globals.logger.Fatalf("[FATAL] globals.inodeMap[childInodeNumber] returned !ok")
 				}
 
+				if ((childInode.backend != nil) && childInode.backend.serveStale()) || cachePinned(childInode) {
+					// Retain the inode (and its cache lines) while its backend is down or it is pinned
+
+					childInode.xTime = timeNow.Add(globals.config.ttlCheckInterval)
+					childInode.listElement = globals.inodeEvictionLRU.Put(childInode.xTime, childInodeNumber)
+
+					continue
+				}
+
 				clearFileCacheLinesLocked(childInode)
 
 				parentInode, ok = globals.inodeMap[childInode.parentInodeNumber]
@@ -697,6 +828,7 @@ func inodeEvictorForceDrain() (numDrained uint64) {
 		listElement      *list.Element
 		ok               bool
 		parentInode      *inodeStruct
+		pinnedInodes     []*inodeStruct
 		xTime            time.Time
 	)
 
@@ -708,8 +840,6 @@ func inodeEvictorForceDrain() (numDrained uint64) {
 			break
 		}
 
-		numDrained++
-
 		globals.inodeEvictionLRU.Remove(xTime, listElement)
 
 		childInode, ok = globals.inodeMap[childInodeNumber]
@@ -718,6 +848,15 @@ func inodeEvictorForceDrain() (numDrained uint64) {
 			globals.logger.Fatalf("[FATAL] globals.inodeMap[childInodeNumber] returned !ok")
 		}
 
+		if cachePinned(childInode) {
+			// Set aside (to be restored to globals.inodeEvictionLRU below) the pinned inode
+
+			pinnedInodes = append(pinnedInodes, childInode)
+			continue
+		}
+
+		numDrained++
+
 		clearFileCacheLinesLocked(childInode)
 
 		parentInode, ok = globals.inodeMap[childInode.parentInodeNumber]
@@ -745,17 +884,24 @@ func inodeEvictorForceDrain() (numDrained uint64) {
 		parentInode.touch(nil)
 	}
 
+	for _, childInode = range pinnedInodes {
+		childInode.listElement = globals.inodeEvictionLRU.Put(childInode.xTime, childInode.inodeNumber)
+	}
+
 	return
 }
 
 // `findChildInode` is called to locate or create a child's inodeStruct. The return `ok` indicates
 // that either the child's inodeStruct was already known or has been created in the cases where
-// an existing object or object prefix is found. Callers should already hold globals.Lock().
-func (parentInode *inodeStruct) findChildInode(basename string) (childInode *inodeStruct, ok bool) {
+// an existing object or object prefix is found. Otherwise, errno is ENOENT unless the backend
+// failed to determine whether the child exists (see backendErrno()). Callers should already
+// hold globals.Lock().
+func (parentInode *inodeStruct) findChildInode(basename string) (childInode *inodeStruct, ok bool, errno syscall.Errno) {
 	var (
 		childInodeNumber   uint64
 		dirOrFilePath      string
 		err                error
+		statFileErrno      syscall.Errno
 		statDirectoryInput *statDirectoryInputStruct
 		statFileInput      *statFileInputStruct
 		statFileOutput     *statFileOutputStruct
@@ -818,13 +964,15 @@ func (parentInode *inodeStruct) findChildInode(basename string) (childInode *ino
 
 		if !parentInode.isPrefetchInProgress {
 			parentInode.isPrefetchInProgress = true
-			go prefetchDirectory(parentInode.inodeNumber)
+			go prefetchDirectory(parentInode.inodeNumber, false)
 		}
 
 		ok = true
 		return
 	}
 
+	statFileErrno = backendErrno(err)
+
 	// No object found in the backend... what about an object prefix?
 	// Note: By convention, we must modify dirOrFileOPath to end in "/"
 
@@ -842,28 +990,42 @@ func (parentInode *inodeStruct) findChildInode(basename string) (childInode *ino
 
 		if !parentInode.isPrefetchInProgress {
 			parentInode.isPrefetchInProgress = true
-			go prefetchDirectory(parentInode.inodeNumber)
+			go prefetchDirectory(parentInode.inodeNumber, false)
 		}
 
 		ok = true
 		return
 	}
 
-	// We found neither an object nor an object prefix in the backend... so we fail
+	// We found neither an object nor an object prefix in the backend... so we fail (reporting
+	// why should either not be simply due to the object or object prefix not existing)
 
 	childInode = nil
 	ok = false
 
+	errno = backendErrno(err)
+	if errno == syscall.ENOENT {
+		errno = statFileErrno
+	}
+	if errno != syscall.ENOENT {
+		// Unlike a missing object, a throttled or refused stat is worth noting
+		globals.logger.Printf("[WARN] unable to determine whether \"%s\" exists in backend \"%s\" (%s)", dirOrFilePath, parentInode.backend.dirName, errnoName(errno))
+	}
+
 	return
 }
 
 // `prefetchDirectory` is run as a background worker to populate globals.inodeMap
 // with inodeStruct's as would occur in DoReadDir() and DoReadDirPlus() to handle
 // the use cases where paths are known by users without the need to discover them
-// via directory listings that would normally trigger such population.
-func prefetchDirectory(dirInodeNumber uint64) {
+// via directory listings that would normally trigger such population. Once the
+// listing completes, the directory's newestChildMTime is updated. If refresh, the
+// directory is being listed anew only for that reason (see reportedMTime()), so the
+// files listed are not hinted to the backend via prefetchFilesWrapper().
+func prefetchDirectory(dirInodeNumber uint64, refresh bool) {
 	var (
 		basename                string
+		childDirInode           *inodeStruct
 		continuationToken       = string("")
 		dirInode                *inodeStruct
 		err                     error
@@ -871,7 +1033,9 @@ func prefetchDirectory(dirInodeNumber uint64) {
 		listDirectoryOutputFile listDirectoryOutputFileStruct
 		listDirectoryInput      *listDirectoryInputStruct
 		listDirectoryOutput     *listDirectoryOutputStruct
+		newestChildMTime        time.Time
 		ok                      bool
+		prefetchFilesInput      *prefetchFilesInputStruct
 		startTime               = time.Now()
 	)
 
@@ -889,6 +1053,7 @@ func prefetchDirectory(dirInodeNumber uint64) {
 			continuationToken: continuationToken,
 			maxItems:          dirInode.backend.directoryPageSize,
 			dirPath:           dirInode.objectPath,
+			bulk:              true,
 		}
 
 		globals.Unlock()
@@ -896,6 +1061,18 @@ func prefetchDirectory(dirInodeNumber uint64) {
 		listDirectoryOutput, err = listDirectoryWrapper(dirInode.backend.context, listDirectoryInput)
 		if err != nil {
 			globals.logger.Printf("[WARN] listDirectoryWrapper(dirInode.backend.context, listDirectoryInput) failed: %v", err)
+		} else if !refresh && (len(listDirectoryOutput.file) > 0) {
+			// Hint to the backend that the files just listed may well be read soon
+
+			prefetchFilesInput = &prefetchFilesInputStruct{
+				filePaths: make([]string, 0, len(listDirectoryOutput.file)),
+			}
+			for _, listDirectoryOutputFile = range listDirectoryOutput.file {
+				prefetchFilesInput.filePaths = append(prefetchFilesInput.filePaths, listDirectoryInput.dirPath+listDirectoryOutputFile.basename)
+			}
+
+			// Failures (traced by prefetchFilesWrapper) are harmless as this is merely a hint
+			_, _ = prefetchFilesWrapper(dirInode.backend.context, prefetchFilesInput)
 		}
 
 		globals.Lock()
@@ -920,12 +1097,18 @@ func prefetchDirectory(dirInodeNumber uint64) {
 
 		for _, basename = range listDirectoryOutput.subdirectory {
 			// The following will only create the childDirInode if necessary
-			_ = dirInode.findChildDirInode(basename)
+			childDirInode = dirInode.findChildDirInode(basename)
+			if childDirInode.newestChildMTime.After(newestChildMTime) {
+				newestChildMTime = childDirInode.newestChildMTime
+			}
 		}
 
 		for _, listDirectoryOutputFile = range listDirectoryOutput.file {
 			// The following will only create the childFileInode if necessary
 			_ = dirInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
+			if listDirectoryOutputFile.mTime.After(newestChildMTime) {
+				newestChildMTime = listDirectoryOutputFile.mTime
+			}
 		}
 
 		dirInode.touch(nil)
@@ -935,6 +1118,8 @@ func prefetchDirectory(dirInodeNumber uint64) {
 		} else {
 			// Finished prefetching directory
 			dirInode.isPrefetchInProgress = false
+			dirInode.newestChildMTime = newestChildMTime
+			dirInode.newestChildMTimeAsOf = time.Now()
 			latency = time.Since(startTime).Seconds()
 			globals.backendMetrics.DirectoryPrefetchLatencies.Observe(latency)
 			dirInode.backend.backendMetrics.DirectoryPrefetchLatencies.Observe(latency)
@@ -1042,6 +1227,24 @@ func (parentInode *inodeStruct) findChildFileInode(basename, eTag string, mTime
 	return
 }
 
+// `findListDirectoryOutputEntryInode` returns the child inode of the entry at entryIndex
+// of listDirectoryOutput whose entries are its files followed by its subdirectories.
+func (parentInode *inodeStruct) findListDirectoryOutputEntryInode(listDirectoryOutput *listDirectoryOutputStruct, entryIndex uint64) (childInode *inodeStruct) {
+	var (
+		listDirectoryOutputFile *listDirectoryOutputFileStruct
+	)
+
+	if entryIndex < uint64(len(listDirectoryOutput.file)) {
+		listDirectoryOutputFile = &listDirectoryOutput.file[entryIndex]
+		childInode = parentInode.findChildFileInode(listDirectoryOutputFile.basename, listDirectoryOutputFile.eTag, listDirectoryOutputFile.mTime, listDirectoryOutputFile.size)
+		return
+	}
+
+	childInode = parentInode.findChildDirInode(listDirectoryOutput.subdirectory[entryIndex-uint64(len(listDirectoryOutput.file))])
+
+	return
+}
+
 const (
 	DUMP_FS_DIR_INDENT = "    "
 )
@@ -1189,14 +1392,15 @@ func (thisInode *inodeStruct) dumpFS(w io.Writer, indent string, expectedInodeNu
 // FileInode that includes removing the corresponding backend
 // object (if any). As this may involve blocking (e.g. to await
 // various cache line operations), this function must be called
-// while unlocked.
-func (thisInode *inodeStruct) finishPendingDelete() {
+// while unlocked. Should the backend fail to delete the object (for
+// any reason other than it already being gone), the deletion is
+// abandoned (leaving thisInode in place) and the error returned.
+func (thisInode *inodeStruct) finishPendingDelete() (err error) {
 	var (
 		cacheLine       *cacheLineStruct
 		cacheLineNumber uint64
 		cacheLineWaiter sync.WaitGroup
 		deleteFileInput *deleteFileInputStruct
-		err             error
 		ok              bool
 		parentInode     *inodeStruct
 	)
@@ -1244,6 +1448,12 @@ Restart:
 		_, err = deleteFileWrapper(thisInode.backend.context, deleteFileInput)
 		if err != nil {
 			globals.logger.Printf("[WARN] deleteBackendObjectWhenAndIfNecessary() got deleteFileWrapper(thisInode.backend.context, deleteFileInput) err: %v", err)
+			if backendErrno(err) != syscall.ENOENT {
+				thisInode.pendingDelete = false
+				globals.Unlock()
+				return
+			}
+			err = nil
 		}
 	}
 
@@ -1274,4 +1484,6 @@ Restart:
 	parentInode.touch(nil)
 
 	globals.Unlock()
+
+	return
 }
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"syscall"
+)
+
+const (
+	fsckQuarantineSuffix = ".quarantine"
+)
+
+// `fsckStruct` tracks the progress of runFsck().
+type fsckStruct struct {
+	w               io.Writer //
+	repair          bool      // If true, inconsistencies are repaired (or quarantined) rather than merely reported
+	inconsistencies uint64    //
+	repaired        uint64    //
+	journaledPaths  uint64    // Paths journaled for (but yet to be applied to) a mirror
+}
+
+// `runFsck` is called, in lieu of mounting (and while no daemon is using the same
+// configuration file), after the backends have been set up by setupInspectBackends()
+// to check the state each backend persists on local disk. As the cache of file content
+// is held only in memory (and the mount has no write path to leave dirty data behind),
+// this state consists of each mirror_journal_file: operations applied to a backend but
+// perhaps not yet to its mirror.
+//
+// Journaled mirror operations are reported. Inconsistencies (unreadable journal entries
+// as well as journaled writes whose eTag no longer matches that of the backend) are
+// reported and, if repair is true, either quarantined (moved aside to a path with suffix
+// fsckQuarantineSuffix) or brought up to date. An error is returned if any inconsistency
+// remains unrepaired.
+func runFsck(w io.Writer, repair bool) (err error) {
+	var (
+		backend  *backendStruct
+		dirName  string
+		dirNames []string
+		fsck     = &fsckStruct{w: w, repair: repair}
+	)
+
+	for dirName = range globals.backendsToMount {
+		dirNames = append(dirNames, dirName)
+	}
+	slices.Sort(dirNames)
+
+	for _, dirName = range dirNames {
+		backend = globals.backendsToMount[dirName]
+
+		if (backend.mirror != "") && (backend.mirrorJournalFile != "") {
+			err = fsck.checkMirrorJournalFile(backend)
+			if err != nil {
+				err = fmt.Errorf("%s: unable to check mirror_journal_file (\"%s\"): %v", dirName, backend.mirrorJournalFile, err)
+				return
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "checked %v backend(s): %v inconsistencies (%v repaired), %v journaled mirror path(s)\n", len(dirNames), fsck.inconsistencies, fsck.repaired, fsck.journaledPaths)
+
+	if fsck.repaired < fsck.inconsistencies {
+		err = fmt.Errorf("%v inconsistencies remain (rerun with --repair)", fsck.inconsistencies-fsck.repaired)
+	}
+
+	return
+}
+
+// `inconsistency` reports an inconsistency described by format & args. If repairing, repairFunc
+// is invoked to repair it (with its success, or failure, reported along with the inconsistency).
+func (fsck *fsckStruct) inconsistency(repairFunc func() (action string, err error), format string, args ...interface{}) {
+	var (
+		action string
+		err    error
+	)
+
+	fsck.inconsistencies++
+
+	if !fsck.repair {
+		_, _ = fmt.Fprintf(fsck.w, format+"\n", args...)
+		return
+	}
+
+	action, err = repairFunc()
+	if err != nil {
+		_, _ = fmt.Fprintf(fsck.w, format+" [unable to repair: %v]\n", append(args, err)...)
+		return
+	}
+
+	fsck.repaired++
+
+	_, _ = fmt.Fprintf(fsck.w, format+" [%s]\n", append(args, action)...)
+}
+
+// `checkMirrorJournalFile` reports each path journaled in backend.mirrorJournalFile. Malformed
+// entries (presumably torn writes never acknowledged) are reported and, if repairing, moved to a
+// sibling file (with suffix fsckQuarantineSuffix). The latest journaled write of each path that
+// recorded an eTag is validated against a statFile() of backend. A mismatch (e.g. the path was
+// subsequently rewritten or deleted by another client) is reported and, if repairing, the entry
+// is updated to reflect what the mirror will be reconciled to (the backend's current state).
+func (fsck *fsckStruct) checkMirrorJournalFile(backend *backendStruct) (err error) {
+	var (
+		entries            []*mirrorJournalEntryStruct
+		filePath           string
+		filePaths          []string
+		journalContent     []byte
+		latestEntries      = make(map[string]*mirrorJournalEntryStruct)
+		malformed          [][]byte
+		mirrorJournalEntry *mirrorJournalEntryStruct
+		ok                 bool
+		retained           bytes.Buffer
+		rewrite            bool
+		scanner            *bufio.Scanner
+		statFileOutput     *statFileOutputStruct
+		unquarantined      [][]byte
+	)
+
+	journalContent, err = os.ReadFile(backend.mirrorJournalFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	scanner = bufio.NewScanner(bytes.NewReader(journalContent))
+	for scanner.Scan() {
+		mirrorJournalEntry = &mirrorJournalEntryStruct{}
+		err = json.Unmarshal(scanner.Bytes(), mirrorJournalEntry)
+		if (err != nil) || ((mirrorJournalEntry.Op != MirrorJournalOpDelete) && (mirrorJournalEntry.Op != MirrorJournalOpWrite)) {
+			malformed = append(malformed, slices.Clone(scanner.Bytes()))
+			continue
+		}
+
+		entries = append(entries, mirrorJournalEntry)
+
+		_, ok = latestEntries[mirrorJournalEntry.FilePath]
+		latestEntries[mirrorJournalEntry.FilePath] = mirrorJournalEntry
+		if ok {
+			continue
+		}
+		filePaths = append(filePaths, mirrorJournalEntry.FilePath)
+
+		fsck.journaledPaths++
+
+		_, _ = fmt.Fprintf(fsck.w, "%s: journaled %s of \"%s\" pending application to %s\n", backend.dirName, mirrorJournalEntry.Op, mirrorJournalEntry.FilePath, backend.mirror)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return
+	}
+
+	for _, entry := range malformed {
+		fsck.inconsistency(func() (action string, err error) {
+			var (
+				quarantineFile *os.File
+			)
+
+			quarantineFile, err = os.OpenFile(backend.mirrorJournalFile+fsckQuarantineSuffix, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err == nil {
+				_, err = quarantineFile.Write(append(entry, '\n'))
+				if err == nil {
+					err = quarantineFile.Close()
+				} else {
+					_ = quarantineFile.Close()
+				}
+			}
+			if err != nil {
+				unquarantined = append(unquarantined, entry)
+				return
+			}
+
+			rewrite = true
+			action = "quarantined to " + backend.mirrorJournalFile + fsckQuarantineSuffix
+			return
+		}, "%s: malformed entry in mirror_journal_file \"%s\": %s", backend.dirName, backend.mirrorJournalFile, entry)
+	}
+
+	if backend.context != nil {
+		for _, filePath = range filePaths {
+			mirrorJournalEntry = latestEntries[filePath]
+			if (mirrorJournalEntry.Op != MirrorJournalOpWrite) || (mirrorJournalEntry.ETag == "") {
+				continue
+			}
+
+			statFileOutput, err = statFileWrapper(backend.context, &statFileInputStruct{
+				filePath: filePath,
+				ifMatch:  "",
+				bulk:     true,
+			})
+			if err != nil {
+				if backendErrno(err) != syscall.ENOENT {
+					err = fmt.Errorf("unable to stat journaled \"%s\": %v", filePath, err)
+					return
+				}
+
+				fsck.inconsistency(func() (action string, err error) {
+					mirrorJournalEntry.Op = MirrorJournalOpDelete
+					mirrorJournalEntry.ETag = ""
+					rewrite = true
+					action = "journaled as deleted"
+					return
+				}, "%s: journaled write of \"%s\" (eTag \"%s\") no longer present", backend.dirName, filePath, mirrorJournalEntry.ETag)
+
+				continue
+			}
+
+			if (statFileOutput.eTag == "") || (statFileOutput.eTag == mirrorJournalEntry.ETag) {
+				continue
+			}
+
+			fsck.inconsistency(func() (action string, err error) {
+				mirrorJournalEntry.ETag = statFileOutput.eTag
+				rewrite = true
+				action = "journaled eTag updated"
+				return
+			}, "%s: journaled write of \"%s\" (eTag \"%s\") mismatches its eTag (\"%s\")", backend.dirName, filePath, mirrorJournalEntry.ETag, statFileOutput.eTag)
+		}
+
+		err = nil
+	}
+
+	if !rewrite {
+		return
+	}
+
+	// Rewrite (atomically) the journal with its repaired entries (and any malformed entries not quarantined)
+
+	for _, mirrorJournalEntry = range entries {
+		err = json.NewEncoder(&retained).Encode(mirrorJournalEntry)
+		if err != nil {
+			return
+		}
+	}
+	for _, entry := range unquarantined {
+		_, _ = retained.Write(entry)
+		_ = retained.WriteByte('\n')
+	}
+
+	err = os.WriteFile(backend.mirrorJournalFile+".tmp", retained.Bytes(), 0o600)
+	if err == nil {
+		err = os.Rename(backend.mirrorJournalFile+".tmp", backend.mirrorJournalFile)
+	}
+
+	return
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFsck(t *testing.T) {
+	var (
+		err               error
+		mirrorJournalFile = filepath.Join(t.TempDir(), "journal")
+		output            bytes.Buffer
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+backends: [
+  {
+    dir_name: mirrored,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+    mirror: mirror,
+    mirror_journal_file: "`+mirrorJournalFile+`",
+  },
+  {
+    dir_name: mirror,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	// Nothing on local disk is consistent
+
+	err = runFsck(&output, false)
+	if (err != nil) || (output.String() != "checked 2 backend(s): 0 inconsistencies (0 repaired), 0 journaled mirror path(s)\n") {
+		t.Fatalf("runFsck() of nothing unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	err = os.WriteFile(mirrorJournalFile, []byte("{\"op\":\"write\",\"file_path\":\"x\"}\n{\"op\":\"delete\",\"file_path\":\"x\"}\n{\"op\":\"wri\n"), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile(mirrorJournalFile) failed: %v", err)
+	}
+
+	// Without --repair, inconsistencies are merely reported
+
+	output.Reset()
+	err = runFsck(&output, false)
+	if (err == nil) || !strings.Contains(err.Error(), "1 inconsistencies remain") {
+		t.Fatalf("runFsck() returned err: %v", err)
+	}
+	for _, expected := range []string{
+		"mirrored: journaled write of \"x\" pending application to mirror\n",
+		"mirrored: malformed entry in mirror_journal_file \"" + mirrorJournalFile + "\": {\"op\":\"wri\n",
+		"checked 2 backend(s): 1 inconsistencies (0 repaired), 1 journaled mirror path(s)\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("runFsck() output lacks %q:\n%s", expected, output.String())
+		}
+	}
+
+	// With --repair, each is quarantined
+
+	output.Reset()
+	err = runFsck(&output, true)
+	if (err != nil) || !strings.HasSuffix(output.String(), "1 inconsistencies (1 repaired), 1 journaled mirror path(s)\n") {
+		t.Fatalf("runFsck(repair) unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	journalContent, err := os.ReadFile(mirrorJournalFile)
+	if (err != nil) || (string(journalContent) != "{\"op\":\"write\",\"file_path\":\"x\"}\n{\"op\":\"delete\",\"file_path\":\"x\"}\n") {
+		t.Fatalf("runFsck(repair) left mirror_journal_file with %q (err: %v)", journalContent, err)
+	}
+
+	quarantineContent, err := os.ReadFile(mirrorJournalFile + fsckQuarantineSuffix)
+	if (err != nil) || (string(quarantineContent) != "{\"op\":\"wri\n") {
+		t.Fatalf("runFsck(repair) quarantined %q (err: %v)", quarantineContent, err)
+	}
+
+	// Once repaired, only the journaled path remains
+
+	output.Reset()
+	err = runFsck(&output, false)
+	if (err != nil) || !strings.HasSuffix(output.String(), "0 inconsistencies (0 repaired), 1 journaled mirror path(s)\n") {
+		t.Fatalf("runFsck() after repair unexpected (err: %v):\n%s", err, output.String())
+	}
+}
+
+func TestFsckETags(t *testing.T) {
+	var (
+		err               error
+		httpServer        *httptest.Server
+		mirrorJournalFile = filepath.Join(t.TempDir(), "journal")
+		output            bytes.Buffer
+	)
+
+	// The primary holds only "current" (whose eTag is "new")
+
+	httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodHead) && (r.URL.Path == "/primary/current") {
+			w.Header().Set("ETag", "\"new\"")
+			w.Header().Set("Content-Length", "3")
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer httpServer.Close()
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+backends: [
+  {
+    dir_name: mirrored,
+    bucket_container_name: primary,
+    backend_type: S3,
+    readonly: false,
+    mirror: mirror,
+    mirror_journal_file: "`+mirrorJournalFile+`",
+    S3: {
+      region: us-east-1,
+      endpoint: "`+httpServer.URL+`",
+      access_key_id: accessKeyID,
+      secret_access_key: secretAccessKey,
+      retry_base_delay: 0,
+    },
+  },
+  {
+    dir_name: mirror,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	err = os.WriteFile(mirrorJournalFile, []byte("{\"op\":\"write\",\"file_path\":\"current\",\"etag\":\"new\"}\n{\"op\":\"write\",\"file_path\":\"rewritten\",\"etag\":\"old\"}\n{\"op\":\"write\",\"file_path\":\"current\",\"etag\":\"old\"}\n{\"op\":\"write\",\"file_path\":\"unvalidated\"}\n"), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile(mirrorJournalFile) failed: %v", err)
+	}
+
+	// Without --repair, the latest journaled write of "current" mismatches its eTag and "rewritten" is absent
+
+	err = runFsck(&output, false)
+	if (err == nil) || !strings.Contains(err.Error(), "2 inconsistencies remain") {
+		t.Fatalf("runFsck() returned err: %v", err)
+	}
+	for _, expected := range []string{
+		"mirrored: journaled write of \"current\" (eTag \"old\") mismatches its eTag (\"new\")\n",
+		"mirrored: journaled write of \"rewritten\" (eTag \"old\") no longer present\n",
+		"checked 2 backend(s): 2 inconsistencies (0 repaired), 3 journaled mirror path(s)\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("runFsck() output lacks %q:\n%s", expected, output.String())
+		}
+	}
+
+	// With --repair, the journal is brought up to date with the primary
+
+	output.Reset()
+	err = runFsck(&output, true)
+	if (err != nil) || !strings.HasSuffix(output.String(), "2 inconsistencies (2 repaired), 3 journaled mirror path(s)\n") {
+		t.Fatalf("runFsck(repair) unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	journalContent, err := os.ReadFile(mirrorJournalFile)
+	if (err != nil) || (string(journalContent) != "{\"op\":\"write\",\"file_path\":\"current\",\"etag\":\"new\"}\n{\"op\":\"delete\",\"file_path\":\"rewritten\"}\n{\"op\":\"write\",\"file_path\":\"current\",\"etag\":\"new\"}\n{\"op\":\"write\",\"file_path\":\"unvalidated\"}\n") {
+		t.Fatalf("runFsck(repair) left mirror_journal_file with %q (err: %v)", journalContent, err)
+	}
+
+	output.Reset()
+	err = runFsck(&output, false)
+	if (err != nil) || !strings.HasSuffix(output.String(), "0 inconsistencies (0 repaired), 3 journaled mirror path(s)\n") {
+		t.Fatalf("runFsck() after repair unexpected (err: %v):\n%s", err, output.String())
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"container/list"
 	"context"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/NVIDIA/fission/v3"
@@ -29,6 +34,60 @@ type backendConfigAIStoreStruct struct {
 	authnTokenFile           string        //  JSON/YAML "authn_token_file"             default:"${AIS_AUTHN_TOKEN_FILE:=~/.config/ais/cli/auth.token}"
 	provider                 string        //  JSON/YAML "provider"                     default:"s3"
 	timeout                  time.Duration //  JSON/YAML "timeout"                      default:30000
+	authnEndpoint            string        //  JSON/YAML "authn_endpoint"               default:"${AIS_AUTHN_URL}"
+	authnUsername            string        //  JSON/YAML "authn_username"               default:""
+	authnPassword            string        //  JSON/YAML "authn_password"               default:""
+	etlName                  string        //  JSON/YAML "etl_name"                     default:""
+	etlArgs                  string        //  JSON/YAML "etl_args"                     default:""
+	blobDownloadThreshold    uint64        //  JSON/YAML "blob_download_threshold"      default:0 (disabled)
+	blobDownloadChunkSize    uint64        //  JSON/YAML "blob_download_chunk_size"     default:0 (cluster determined)
+	blobDownloadWorkers      uint64        //  JSON/YAML "blob_download_workers"        default:0 (cluster determined)
+	directTargetReads        bool          //  JSON/YAML "direct_target_reads"          default:false
+	clusterMapTTL            time.Duration //  JSON/YAML "cluster_map_ttl"              default:60000
+	retryMaxAttempts         uint64        //  JSON/YAML "retry_max_attempts"           default:0 (derived from retry_{base|max}_delay)
+	retryBaseDelay           time.Duration //  JSON/YAML "retry_base_delay"             default:10
+	retryNextDelayMultiplier float64       //  JSON/YAML "retry_next_delay_multiplier"  default:2.0
+	retryMaxDelay            time.Duration //  JSON/YAML "retry_max_delay"              default:2000
+	namespaceUUID            string        //  JSON/YAML "namespace_uuid"               default:""
+	namespaceName            string        //  JSON/YAML "namespace_name"               default:""
+	propsCacheTTL            time.Duration //  JSON/YAML "props_cache_ttl"              default:1000
+	prefetchListedFiles      bool          //  JSON/YAML "prefetch_listed_files"        default:false
+	// Runtime state
+	retryAttempts int // Derived from retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} (including the initial attempt)
+}
+
+// `backendConfigAzureFilesStruct` describes a backend's Azure Files-specific settings.
+type backendConfigAzureFilesStruct struct {
+	// From <config-file>
+	accountName              string        // JSON/YAML "account_name"                 default:"${AZURE_STORAGE_ACCOUNT}"
+	accountKey               string        // JSON/YAML "account_key"                  default:"${AZURE_STORAGE_KEY}"
+	sasToken                 string        // JSON/YAML "sas_token"                    default:"${AZURE_STORAGE_SAS_TOKEN}" (used only if account_key == "")
+	endpoint                 string        // JSON/YAML "endpoint"                     default:"https://<account_name>.file.core.windows.net"
+	timeout                  time.Duration // JSON/YAML "timeout"                      default:30000
+	retryMaxAttempts         uint64        // JSON/YAML "retry_max_attempts"           default:0 (derived from retry_{base|max}_delay)
+	retryBaseDelay           time.Duration // JSON/YAML "retry_base_delay"             default:10
+	retryNextDelayMultiplier float64       // JSON/YAML "retry_next_delay_multiplier"  default:2.0
+	retryMaxDelay            time.Duration // JSON/YAML "retry_max_delay"              default:2000
+	// Runtime state
+	retryAttempts int // Derived from retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} (including the initial attempt)
+}
+
+// `backendConfigDropboxStruct` describes a backend's Dropbox-specific settings.
+type backendConfigDropboxStruct struct {
+	// From <config-file>
+	accessToken              string        // JSON/YAML "access_token"                 default:"${DROPBOX_ACCESS_TOKEN}"
+	refreshToken             string        // JSON/YAML "refresh_token"                default:"${DROPBOX_REFRESH_TOKEN}" (if != "", access tokens are obtained with it)
+	appKey                   string        // JSON/YAML "app_key"                      default:"${DROPBOX_APP_KEY}"
+	appSecret                string        // JSON/YAML "app_secret"                   default:"${DROPBOX_APP_SECRET}"
+	apiEndpoint              string        // JSON/YAML "api_endpoint"                 default:"https://api.dropboxapi.com"
+	contentEndpoint          string        // JSON/YAML "content_endpoint"             default:"https://content.dropboxapi.com"
+	timeout                  time.Duration // JSON/YAML "timeout"                      default:30000
+	retryMaxAttempts         uint64        // JSON/YAML "retry_max_attempts"           default:0 (derived from retry_{base|max}_delay)
+	retryBaseDelay           time.Duration // JSON/YAML "retry_base_delay"             default:10
+	retryNextDelayMultiplier float64       // JSON/YAML "retry_next_delay_multiplier"  default:2.0
+	retryMaxDelay            time.Duration // JSON/YAML "retry_max_delay"              default:2000
+	// Runtime state
+	retryAttempts int // Derived from retry_{max_attempts|base_delay|next_delay_multiplier|max_delay} (including the initial attempt)
 }
 
 // `backendConfigRAMStruct` describes a backend's RAM-specific settings.
@@ -39,6 +98,22 @@ type backendConfigRAMStruct struct {
 	maxDirectoryPageSize uint64 //             JSON/YAML "max_directory_page_size"      default:100
 }
 
+// `backendConfigShardedStruct` describes a backend's Sharded-specific settings.
+type backendConfigShardedStruct struct {
+	// From <config-file>
+	backends     []string //     JSON/YAML "backends"                     required (dir_names of the backends holding each shard)
+	virtualNodes uint64   //     JSON/YAML "virtual_nodes"                default:128 (points per shard on the consistent hash ring)
+}
+
+// `backendConfigSnapshotStruct` describes a backend's Snapshot-specific settings.
+type backendConfigSnapshotStruct struct {
+	// From <config-file>
+	backend string //             JSON/YAML "backend"                      required (dir_name of the backend snapshotted)
+	name    string //             JSON/YAML "name"                         required
+	// Runtime state
+	manifestFilePath string // Derived from the snapshot_dir of backend and name
+}
+
 // `backendConfigS3Struct` describes a backend's S3-specific settings.
 type backendConfigS3Struct struct {
 	// From <config-file>
@@ -47,74 +122,217 @@ type backendConfigS3Struct struct {
 	configFilePath            string        // YSON/YAML "config_file_path"             default:"${AWS_CONFIG_FILE:-~/.aws/config}"
 	region                    string        // JSON/YAML "region"                       default:"${AWS_REGION:-us-east-1}"
 	endpoint                  string        // JSON/YAML "endpoint"                     default:"${AWS_ENDPOINT}"
+	dnsSuffix                 string        // JSON/YAML "dns_suffix"                   default:"" (derived from region's partition)
 	useCredentialsEnv         bool          // JSON/YAML "use_credentials_env"          default:false
 	credentialsFilePath       string        // JSON/YAML "credentials_file_path"        default:"${AWS_SHARED_CREDENTIALS_FILE:-~/.aws/credentials}"
 	accessKeyID               string        // JSON/YAML "access_key_id"                default:"${AWS_ACCESS_KEY_ID}"
 	secretAccessKey           string        // JSON/YAML "secret_access_key"            default:"${AWS_SECRET_ACCESS_KEY}"
+	sessionToken              string        // JSON/YAML "session_token"                default:"" (none)
+	sessionTokenExpiry        time.Time     // JSON/YAML "session_token_expiry"         default:"" (none)
+	credentialRefreshCommand  string        // JSON/YAML "credential_refresh_command"   default:"" (none)
+	credentialRefreshEndpoint string        // JSON/YAML "credential_refresh_endpoint"  default:"" (none)
+	credentialRefreshWindow   time.Duration // JSON/YAML "credential_refresh_window"    default:300000
 	skipTLSCertificateVerify  bool          // JSON/YAML "skip_tls_certificate_verify"  default:true
 	virtualHostedStyleRequest bool          // JSON/YAML "virtual_hosted_style_request" default:false
 	unsignedPayload           bool          // JSON/YAML "unsigned_payload"             default:false
+	signatureVersion          string        // JSON/YAML "signature_version"            default:"v4"
+	exposeVersions            bool          // JSON/YAML "expose_versions"              default:false
+	provider                  string        // JSON/YAML "provider"                     default:"aws"
+	eTagValidator             string        // JSON/YAML "etag_validator"               default:<provider's>
+	conditionalRequests       string        // JSON/YAML "conditional_requests"         default:"probe"
+	retryMode                 string        // JSON/YAML "retry_mode"                   default:"standard"
+	retryMaxAttempts          uint64        // JSON/YAML "retry_max_attempts"           default:0 (derived from retry_{base|max}_delay)
 	retryBaseDelay            time.Duration // JSON/YAML "retry_base_delay"             default:10
 	retryNextDelayMultiplier  float64       // JSON/YAML "retry_next_delay_multiplier"  default:2.0
 	retryMaxDelay             time.Duration // JSON/YAML "retry_max_delay"              default:2000
+	retryJitter               string        // JSON/YAML "retry_jitter"                 default:"full"
+	retryMaxElapsed           time.Duration // JSON/YAML "retry_max_elapsed"            default:0 (unlimited)
+	retryThrottleBaseDelay    time.Duration // JSON/YAML "retry_throttle_base_delay"    default:<retry_base_delay>
+	retryThrottleMaxDelay     time.Duration // JSON/YAML "retry_throttle_max_delay"     default:<retry_max_delay>
+	retryServerBaseDelay      time.Duration // JSON/YAML "retry_server_base_delay"      default:<retry_base_delay>
+	retryServerMaxDelay       time.Duration // JSON/YAML "retry_server_max_delay"       default:<retry_max_delay>
+	retryTransportBaseDelay   time.Duration // JSON/YAML "retry_transport_base_delay"   default:<retry_base_delay>
+	retryTransportMaxDelay    time.Duration // JSON/YAML "retry_transport_max_delay"    default:<retry_max_delay>
 	// Runtime state
-	retryDelay []time.Duration //              Delay slice indexed by RetryDelay()'s attempt arg - 1
+	retryAttempts int //                       Value returned by MaxAttempts() (including the initial attempt)
 }
 
 // `backendStruct` contains the generic backend's settings and runtime
 // particulars as well is references to backendType-specific details.
 type backendStruct struct {
 	// From <config-file>
-	dirName                     string      // JSON/YAML "dir_name"                       required
-	readOnly                    bool        // JSON/YAML "readonly"                       default:true
-	flushOnClose                bool        // JSON/YAML "flush_on_close"                 default:true
-	uid                         uint64      // JSON/YAML "uid"                            default:<current euid>
-	gid                         uint64      // JSON/YAML "gid"                            default:<current egid>
-	dirPerm                     uint64      // JSON/YAML "dir_perm"                       default:0o555(ro)/0o777(rw)
-	filePerm                    uint64      // JSON/YAML "file_perm"                      default:0o444(ro)/0o666(rw)
-	directoryPageSize           uint64      // JSON/YAML "directory_page_size"            default:0(endpoint determined)
-	multiPartCacheLineThreshold uint64      // JSON/YAML "multipart_cache_line_threshold" default:512
-	uploadPartCacheLines        uint64      // JSON/YAML "upload_part_cache_lines"        default:32
-	uploadPartConcurrency       uint64      // JSON/YAML "upload_part_concurrency"        default:32
-	bucketContainerName         string      // JSON/YAML "bucket_container_name"          required
-	prefix                      string      // JSON/YAML "prefix"                         default:""
-	traceLevel                  uint64      // JSON/YAML "trace_level"                    default:0
-	backendType                 string      // JSON/YAML "backend_type"                   required(one of "AIStore", "RAM", "S3")
-	backendTypeSpecifics        interface{} //                                            required(one of *backendConfig{AIStore|S3|RAM}Struct)
+	dirName                     string                        // JSON/YAML "dir_name"                       required
+	readOnly                    bool                          // JSON/YAML "readonly"                       default:true
+	flushOnClose                bool                          // JSON/YAML "flush_on_close"                 default:true
+	uid                         uint64                        // JSON/YAML "uid"                            default:<current euid>
+	gid                         uint64                        // JSON/YAML "gid"                            default:<current egid>
+	dirPerm                     uint64                        // JSON/YAML "dir_perm"                       default:0o555(ro)/0o777(rw)
+	filePerm                    uint64                        // JSON/YAML "file_perm"                      default:0o444(ro)/0o666(rw)
+	directoryPageSize           uint64                        // JSON/YAML "directory_page_size"            default:0(endpoint determined)
+	directoryMarkers            string                        // JSON/YAML "directory_markers"              default:"slash" (one of DirectoryMarkers*)
+	multiPartCacheLineThreshold uint64                        // JSON/YAML "multipart_cache_line_threshold" default:512
+	uploadPartCacheLines        uint64                        // JSON/YAML "upload_part_cache_lines"        default:32
+	uploadPartConcurrency       uint64                        // JSON/YAML "upload_part_concurrency"        default:32
+	fetchWorkers                uint64                        // JSON/YAML "fetch_workers"                  default:64 (if 0, unlimited)
+	fetchWorkersAdaptive        bool                          // JSON/YAML "fetch_workers_adaptive"         default:false (if true, fetch_workers is the adaptive limit's maximum)
+	fetchLatencyTarget          time.Duration                 // JSON/YAML "fetch_latency_target"           default:0 (in milliseconds; if 0, latency not considered)
+	listPartitions              uint64                        // JSON/YAML "list_partitions"                default:0 (if <= 1, unpartitioned; limited to S3)
+	bucketContainerName         string                        // JSON/YAML "bucket_container_name"          required
+	prefix                      string                        // JSON/YAML "prefix"                         default:""
+	traceLevel                  uint64                        // JSON/YAML "trace_level"                    default:0
+	httpMaxIdleConnsPerHost     uint64                        // JSON/YAML "http_max_idle_conns_per_host"   default:256
+	httpMaxConnsPerHost         uint64                        // JSON/YAML "http_max_conns_per_host"        default:0 (unlimited)
+	httpIdleConnTimeout         time.Duration                 // JSON/YAML "http_idle_conn_timeout"         default:90000 (in milliseconds)
+	httpResponseHeaderTimeout   time.Duration                 // JSON/YAML "http_response_header_timeout"   default:0 (unlimited)
+	httpProtocol                string                        // JSON/YAML "http_protocol"                  default:"auto" (one of HTTPProtocol*)
+	httpKeepAlive               time.Duration                 // JSON/YAML "http_keep_alive"                default:30000 (in milliseconds; if 0, disabled)
+	mirror                      string                        // JSON/YAML "mirror"                         default:"" (none)
+	mirrorJournalFile           string                        // JSON/YAML "mirror_journal_file"            default:"" (required if mirror != "")
+	mirrorReconcileInterval     time.Duration                 // JSON/YAML "mirror_reconcile_interval"      default:60000 (in milliseconds)
+	tierColdBackend             string                        // JSON/YAML "tier_cold_backend"              default:"" (none)
+	tierLocationMapFile         string                        // JSON/YAML "tier_location_map_file"         default:"" (required if tier_cold_backend != "")
+	tierInterval                time.Duration                 // JSON/YAML "tier_interval"                  default:3600000 (in milliseconds)
+	tierMinAge                  time.Duration                 // JSON/YAML "tier_min_age"                   default:0 (in milliseconds; disabled)
+	tierMaxAccessCount          uint64                        // JSON/YAML "tier_max_access_count"          default:0
+	tierPromoteAccessCount      uint64                        // JSON/YAML "tier_promote_access_count"      default:0 (disabled)
+	tierPathPatterns            []string                      // JSON/YAML "tier_path_patterns"             default:[] (none)
+	quotas                      []backendQuotaStruct          // JSON/YAML "quotas"                         default:[] (none)
+	quotaReconcileInterval      time.Duration                 // JSON/YAML "quota_reconcile_interval"       default:300000 (in milliseconds)
+	priority                    uint8                         // JSON/YAML "priority"                       default:"normal" (one of "interactive", "normal", "bulk")
+	priorityPrefixes            []backendPriorityPrefixStruct // JSON/YAML "priority_prefixes"              default:[] (none)
+	healthCheckInterval         time.Duration                 // JSON/YAML "health_check_interval"          default:0 (in milliseconds; disabled)
+	healthCheckFailureThreshold uint64                        // JSON/YAML "health_check_failure_threshold" default:3
+	healthCheckServeStale       bool                          // JSON/YAML "health_check_serve_stale"       default:false
+	multipartUploadGCInterval   time.Duration                 // JSON/YAML "multipart_upload_gc_interval"   default:0 (in milliseconds; disabled)
+	multipartUploadMaxAge       time.Duration                 // JSON/YAML "multipart_upload_max_age"       default:86400000 (in milliseconds)
+	accessRules                 []backendAccessRuleStruct     // JSON/YAML "access_rules"                   default:[] (all access allowed)
+	pathOverrides               []backendPathOverrideStruct   // JSON/YAML "path_overrides"                 default:[] (none)
+	snapshotDir                 string                        // JSON/YAML "snapshot_dir"                   default:"" (snapshots may not be taken)
+	replicas                    []string                      // JSON/YAML "replicas"                       default:[] (none)
+	replicaProbeInterval        time.Duration                 // JSON/YAML "replica_probe_interval"         default:10000 (in milliseconds)
+	replicaHedgeDelay           time.Duration                 // JSON/YAML "replica_hedge_delay"            default:0 (in milliseconds; disabled)
+	backendType                 string                        // JSON/YAML "backend_type"                   required(one of "AIStore", "AzureFiles", "Dropbox", "RAM", "S3", "Sharded", "Snapshot")
+	backendTypeSpecifics        interface{}                   //                                            required(one of *backendConfig{AIStore|AzureFiles|Dropbox|S3|RAM|Sharded|Snapshot}Struct)
 	// Runtime state
-	backendPath    string                //  URL incorporating each of the above path-related values
-	context        backendContextIf      //
-	inode          *inodeStruct          //  Link to this backendStruct's inodeStruct with .inodeType == BackendRootDir
-	fissionMetrics *fissionMetricsStruct //
-	backendMetrics *backendMetricsStruct //
-	mounted        bool                  //  If false, backendStruct.dirName not in fuseRootDirInodeMAP
+	backendPath     string                 //  URL incorporating each of the above path-related values
+	context         backendContextIf       //
+	eTagValidator   eTagValidatorIf        //  If nil, eTags are compared per etag_validator "strong" (see eTagValidatorOf())
+	mirrorState     *mirrorStruct          //  If mirror != "", tracks the mirror backend & journal of operations yet to be applied to it
+	tieringState    *tieringStruct         //  If tier_cold_backend != "", tracks the cold backend & which files have been migrated to it
+	quotaState      *quotaStruct           //  If len(quotas) != 0, tracks the bytes used beneath each quota's prefix
+	healthState     *healthStruct          //  If health_check_interval != 0, tracks whether the backend is down (i.e. its circuit breaker is open)
+	multipartGC     *multipartGCStruct     //  If multipart_upload_gc_interval != 0, tracks the collector of orphaned multipart uploads
+	fetchPool       *fetchPoolStruct       //  If fetch_workers != 0, limits the cache lines fetched concurrently (created by startFetch())
+	replicaRouter   *replicaRouterStruct   //  If len(replicas) != 0, tracks which of this backend and its replicas reads are routed to
+	credentialWatch *credentialWatchStruct //  If credentials are loaded from files, tracks the watcher reloading them as the files change
+	inode           *inodeStruct           //  Link to this backendStruct's inodeStruct with .inodeType == BackendRootDir
+	fissionMetrics  *fissionMetricsStruct  //
+	backendMetrics  *backendMetricsStruct  //
+	retries         atomic.Uint64          //  Count of retries issued (by GetRetryToken() or withRetry()) reported by logSlowBackendRequest()
+	throttles       atomic.Uint64          //  Count of those retries due to throttling (429 or 503) consulted by fetchPoolStruct.worker()
+	requests        atomic.Uint64          //  Count of requests issued (counted by recordBackendRequest()) reported by GET /top
+	bytesRead       atomic.Uint64          //  Bytes fetched by readFileWrapper() reported by GET /top
+	bytesWritten    atomic.Uint64          //  Bytes uploaded by writeFileWrapper() reported by GET /top
+	mounted         bool                   //  If false, backendStruct.dirName not in fuseRootDirInodeMAP
+}
+
+// `backendQuotaStruct` describes the limit on the total size of files beneath a prefix of a backend.
+type backendQuotaStruct struct {
+	prefix   string // JSON/YAML "prefix"    default:"" (relative to backend.prefix; if != "", should end with "/")
+	maxBytes uint64 // JSON/YAML "max_bytes" required
+}
+
+// `backendAccessRuleStruct` allows or denies access to paths beneath a prefix of a backend
+// by callers matching any of uids or gids (or all callers if both are empty).
+type backendAccessRuleStruct struct {
+	prefix string   // JSON/YAML "prefix" default:"" (relative to backend.prefix)
+	uids   []uint64 // JSON/YAML "uids"   default:[]
+	gids   []uint64 // JSON/YAML "gids"   default:[]
+	allow  bool     // JSON/YAML "access" required (one of "allow", "deny")
+}
+
+// `backendPriorityPrefixStruct` overrides the priority of requests for files beneath a prefix of a backend.
+type backendPriorityPrefixStruct struct {
+	prefix   string // JSON/YAML "prefix"   required (relative to backend.prefix)
+	priority uint8  // JSON/YAML "priority" required (one of "interactive", "normal", "bulk")
+}
+
+// `backendPathOverrideStruct` overrides the caching (and readonly) behavior for files beneath a prefix
+// of a backend. Any setting not specified takes on that of the backend (or the global one) instead.
+type backendPathOverrideStruct struct {
+	prefix               string        // JSON/YAML "prefix"                  required (relative to backend.prefix)
+	readOnly             bool          // JSON/YAML "readonly"                default:<backend's readonly> (may only be false if that is)
+	cacheLineSize        uint64        // JSON/YAML "cache_line_size"         default:<cache_line_size>
+	cacheLinesToPrefetch uint64        // JSON/YAML "cache_lines_to_prefetch" default:<cache_lines_to_prefetch>
+	directReadThreshold  uint64        // JSON/YAML "direct_read_threshold"   default:<direct_read_threshold>
+	cacheLineTTL         time.Duration // JSON/YAML "cache_line_ttl"          default:<cache_line_ttl> (in milliseconds)
+	eTagConflictPolicy   string        // JSON/YAML "etag_conflict_policy"    default:<etag_conflict_policy>
+	statOnOpen           bool          // JSON/YAML "stat_on_open"            default:<stat_on_open>
+	entryAttrTTL         time.Duration // JSON/YAML "entry_attr_ttl"          default:<entry_attr_ttl> (in milliseconds)
 }
 
 // `configStruct` describes the global configuration settings as well as the array of backendStruct's configured.
 type configStruct struct {
 	// From <config-file>
-	msfsVersion                 uint64                     // JSON/YAML "msfs_version"                    default:0
-	mountName                   string                     // JSON/YAML "mountname"                       default:"msfs"
-	mountPoint                  string                     // JSON/YAML "mountpoint"                      default:"${MSFS_MOUNTPOINT:-/mnt}""
-	uid                         uint64                     // JSON/YAML "uid"                             default:<current euid>
-	gid                         uint64                     // JSON/YAML "gid"                             default:<current egid>
-	dirPerm                     uint64                     // JSON/YAML "dir_perm"                        default:0o555
-	allowOther                  bool                       // JSON/YAML "allow_other"                     default:true
-	maxWrite                    uint64                     // JSON/YAML "max_write"                       default:131072 (128Ki)
-	entryAttrTTL                time.Duration              // JSON/YAML "entry_attr_ttl"                  default:10000 (in milliseconds)
-	evictableInodeTTL           time.Duration              // JSON/YAML "evictable_inode_ttl"             default:1000000 (in milliseconds)
-	virtualDirTTL               time.Duration              // JSON/YAML "virtual_dir_ttl"                 default:1000000 (in milliseconds)
-	virtualFileTTL              time.Duration              // JSON/YAML "virtual_file_ttl"                default:1000000 (in milliseconds)
-	ttlCheckInterval            time.Duration              // JSON/YAML "ttl_check_interval"              default:250 (in milliseconds)
-	cacheLineSize               uint64                     // JSON/YAML "cache_line_size"                 default:1048576 (1Mi)
-	cacheLines                  uint64                     // JSON/YAML "cache_lines"                     default:4096
-	cacheLinesToPrefetch        uint64                     // JSON/YAML "cache_lines_to_prefetch"         default:4
-	dirtyCacheLinesFlushTrigger uint64                     // JSON/YAML "dirty_cache_lines_flush_trigger" default:80 (as a percentage)
-	dirtyCacheLinesMax          uint64                     // JSON/YAML "dirty_cache_lines_max"           default:90 (as a percentage)
-	autoSIGHUPInterval          time.Duration              // JSON/YAML "auto_sighup_interval"            default:0 (none)
-	observability               *observabilityConfigStruct // JSON/YAML "observability"                   default:nil (disabled)
-	endpoint                    string                     // JSON/YAML "endpoint"                        default:""
-	backends                    map[string]*backendStruct  // JSON/YAML "backends"                        Key == backendStruct.mountPointSubdirectoryName
+	msfsVersion                  uint64                     // JSON/YAML "msfs_version"                    default:0
+	mountName                    string                     // JSON/YAML "mountname"                       default:"msfs"
+	mountPoint                   string                     // JSON/YAML "mountpoint"                      default:"${MSFS_MOUNTPOINT:-/mnt}""
+	uid                          uint64                     // JSON/YAML "uid"                             default:<current euid>
+	gid                          uint64                     // JSON/YAML "gid"                             default:<current egid>
+	dirPerm                      uint64                     // JSON/YAML "dir_perm"                        default:0o555
+	allowOther                   bool                       // JSON/YAML "allow_other"                     default:true
+	maxWrite                     uint64                     // JSON/YAML "max_write"                       default:131072 (128Ki)
+	entryAttrTTL                 time.Duration              // JSON/YAML "entry_attr_ttl"                  default:10000 (in milliseconds)
+	evictableInodeTTL            time.Duration              // JSON/YAML "evictable_inode_ttl"             default:1000000 (in milliseconds)
+	virtualDirTTL                time.Duration              // JSON/YAML "virtual_dir_ttl"                 default:1000000 (in milliseconds)
+	virtualFileTTL               time.Duration              // JSON/YAML "virtual_file_ttl"                default:1000000 (in milliseconds)
+	ttlCheckInterval             time.Duration              // JSON/YAML "ttl_check_interval"              default:250 (in milliseconds)
+	dirMTimeTTL                  time.Duration              // JSON/YAML "dir_mtime_ttl"                   default:60000 (in milliseconds)
+	cacheLineSize                uint64                     // JSON/YAML "cache_line_size"                 default:1048576 (1Mi)
+	cacheLines                   uint64                     // JSON/YAML "cache_lines"                     default:4096
+	cacheLinesToPrefetch         uint64                     // JSON/YAML "cache_lines_to_prefetch"         default:4
+	directReadThreshold          uint64                     // JSON/YAML "direct_read_threshold"           default:0 (if 0, reads never bypass the cache)
+	cacheLineTTL                 time.Duration              // JSON/YAML "cache_line_ttl"                  default:0 (in milliseconds) (if 0, cache lines are never revalidated)
+	eTagConflictPolicy           string                     // JSON/YAML "etag_conflict_policy"            default:"fail" (one of ETagConflictPolicy*)
+	statOnOpen                   bool                       // JSON/YAML "stat_on_open"                    default:false (if true, each open re-stat's the file in its backend)
+	dirtyCacheLinesFlushTrigger  uint64                     // JSON/YAML "dirty_cache_lines_flush_trigger" default:80 (as a percentage)
+	dirtyCacheLinesMax           uint64                     // JSON/YAML "dirty_cache_lines_max"           default:90 (as a percentage)
+	autoSIGHUPInterval           time.Duration              // JSON/YAML "auto_sighup_interval"            default:0 (none)
+	observability                *observabilityConfigStruct // JSON/YAML "observability"                   default:nil (disabled)
+	endpoint                     string                     // JSON/YAML "endpoint"                        default:""
+	migrationStateDir            string                     // JSON/YAML "migration_state_dir"             default:"" (progress not recorded)
+	maxConcurrentBackendRequests uint64                     // JSON/YAML "max_concurrent_backend_requests" default:0 (unlimited)
+	retryBudgetRatio             float64                    // JSON/YAML "retry_budget_ratio"              default:0 (if 0, retries unbounded)
+	retryBudgetMinPerSecond      float64                    // JSON/YAML "retry_budget_min_per_second"     default:10
+	retryBudgetBurst             uint64                     // JSON/YAML "retry_budget_burst"              default:100
+	auditLogFile                 string                     // JSON/YAML "audit_log_file"                  default:"" (auditing disabled)
+	auditLogMaxSize              uint64                     // JSON/YAML "audit_log_max_size"              default:104857600 (100Mi)
+	auditLogMaxFiles             uint64                     // JSON/YAML "audit_log_max_files"             default:10 (rotated segments retained locally)
+	auditLogOps                  []string                   // JSON/YAML "audit_log_ops"                   default:["create","mkdir","open","opendir","read","rmdir","unlink"]
+	auditLogSampleRate           float64                    // JSON/YAML "audit_log_sample_rate"           default:1.0 (fraction of successful operations audited; failures always are)
+	auditBackend                 string                     // JSON/YAML "audit_backend"                   default:"" (rotated segments not uploaded)
+	auditPrefix                  string                     // JSON/YAML "audit_prefix"                    default:"audit/"
+	vaultAddress                 string                     // JSON/YAML "vault_address"                   default:"${VAULT_ADDR}"
+	vaultToken                   string                     // JSON/YAML "vault_token"                     default:"${VAULT_TOKEN}"
+	vaultTokenFile               string                     // JSON/YAML "vault_token_file"                default:"" (if != "", re-read for each fetch in place of vault_token)
+	vaultNamespace               string                     // JSON/YAML "vault_namespace"                 default:"${VAULT_NAMESPACE}"
+	awsSecretsRegion             string                     // JSON/YAML "aws_secrets_region"              default:"${AWS_REGION:-us-east-1}"
+	awsSecretsEndpoint           string                     // JSON/YAML "aws_secrets_endpoint"            default:"" (derived from aws_secrets_region)
+	secretsRefreshInterval       time.Duration              // JSON/YAML "secrets_refresh_interval"        default:300 (in seconds; if 0, unleased secrets are never fetched anew)
+	logFormat                    string                     // JSON/YAML "log_format"                      default:"text" (else "json" or "logfmt")
+	logLevel                     string                     // JSON/YAML "log_level"                       default:"debug" (least severe level logged)
+	logLevels                    map[string]string          // JSON/YAML "log_levels"                      default:{} (Key: subsystem; Value: least severe level logged for it in place of log_level)
+	logLevelFile                 string                     // JSON/YAML "log_level_file"                  default:"" (if != "", checked each second for levels overriding log_level and log_levels)
+	adminSocket                  string                     // JSON/YAML "admin_socket"                    default:"" (admin API not served)
+	slowBackendRequestThreshold  time.Duration              // JSON/YAML "slow_backend_request_threshold"  default:0 (in milliseconds; if 0, slow backend requests not logged)
+	slowFUSEOpThreshold          time.Duration              // JSON/YAML "slow_fuse_op_threshold"          default:0 (in milliseconds; if 0, slow FUSE ops not logged)
+	stateDumpDir                 string                     // JSON/YAML "state_dump_dir"                  default:"" (os.TempDir(); receives the file written upon each SIGUSR1)
+	webhookURLs                  []string                   // JSON/YAML "webhook_urls"                    default:[] (no webhooks)
+	webhookEvents                []string                   // JSON/YAML "webhook_events"                  default:["circuit_open","credential_expiry","cache_corruption"]
+	webhookTimeout               time.Duration              // JSON/YAML "webhook_timeout"                 default:5000 (in milliseconds)
+	webhookRepeatInterval        time.Duration              // JSON/YAML "webhook_repeat_interval"         default:300 (in seconds; if 0, repeats of an event for a backend are not suppressed)
+	shutdownTimeout              time.Duration              // JSON/YAML "shutdown_timeout"                default:30000 (in milliseconds; bounds awaiting quiescence before unmounting at SIGINT/SIGTERM)
+	backends                     map[string]*backendStruct  // JSON/YAML "backends"                        Key == backendStruct.mountPointSubdirectoryName
 }
 
 // observabilityConfigStruct holds observability configuration
@@ -124,6 +342,10 @@ type observabilityConfigStruct struct {
 	metricsAttributes    []attributeProviderStruct // JSON/YAML "metrics.attributes"
 	metricsReaderOptions *readerOptionsStruct      // JSON/YAML "metrics.reader.options"
 	metricsExporter      *exporterStruct           // JSON/YAML "metrics.exporter"
+
+	// Traces configuration
+	tracesExporter *exporterStruct // JSON/YAML "traces.exporter"
+	tracesSampler  *samplerStruct  // JSON/YAML "traces.sampler"
 }
 
 // attributeProviderStruct matches Python's EXTENSION_SCHEMA for attributes
@@ -140,12 +362,55 @@ type readerOptionsStruct struct {
 	ExportTimeoutMillis   uint64 // JSON/YAML "export_timeout_millis"   default:30000 (30 seconds)
 }
 
+// samplerStruct selects which traces are sampled
+type samplerStruct struct {
+	Type  string  // JSON/YAML "type"          one of "always_on", "always_off", "traceidratio", or "parentbased" (default)
+	Ratio float64 // JSON/YAML "options.ratio" default:0.01 (fraction of traces sampled if type is "traceidratio" or "parentbased")
+}
+
 // exporterStruct matches Python's EXTENSION_SCHEMA for exporter
 type exporterStruct struct {
 	Type    string                 // JSON/YAML "type"    e.g. "otlp", "console"
 	Options map[string]interface{} // JSON/YAML "options" e.g. {"endpoint": "http://localhost:4318/v1/metrics"}
 }
 
+const (
+	HTTPProtocolAuto  = "auto"  // HTTP/2 if negotiated (via TLS ALPN) with the endpoint, otherwise HTTP/1.1
+	HTTPProtocolHTTP1 = "http1" // HTTP/1.1 only
+	HTTPProtocolHTTP2 = "http2" // HTTP/2 only (with "http://" endpoints, unencrypted with prior knowledge)
+
+	S3RetryModeStandard = "standard" // Retries governed solely by the backend's own aws.Retryer callbacks
+	S3RetryModeAdaptive = "adaptive" // Additionally applies the SDK's client-side attempt rate limiting when throttled
+
+	S3RetryJitterNone  = "none"  // Delay is the computed exponential backoff
+	S3RetryJitterFull  = "full"  // Delay is uniformly chosen from [0:<computed exponential backoff>]
+	S3RetryJitterEqual = "equal" // Delay is half the computed exponential backoff plus a uniformly chosen remainder
+
+	S3SignatureVersionV4 = "v4" // Requests (and presigned URLs) are signed per AWS Signature Version 4
+	S3SignatureVersionV2 = "v2" // Requests (and presigned URLs) are signed per the legacy AWS Signature Version 2
+
+	S3ProviderAWS  = "aws"  // AWS S3 (or a provider behaving likewise)
+	S3ProviderCeph = "ceph" // Ceph's RADOS Gateway (RGW)
+	S3ProviderGCS  = "gcs"  // Google Cloud Storage's XML API (S3 interoperability)
+	S3ProviderR2   = "r2"   // Cloudflare R2
+
+	S3ConditionalRequestsProbe       = "probe"       // Whether or not If-Match is honored is determined by the first conditional request
+	S3ConditionalRequestsSupported   = "supported"   // If-Match is known to be honored so a single conditional request suffices
+	S3ConditionalRequestsUnsupported = "unsupported" // If-Match may be ignored so a HeadObject must precede each conditional request
+
+	ETagValidatorStrong    = "strong"    // eTags match only if identical (disregarding quotes)
+	ETagValidatorWeak      = "weak"      // eTags match per weak comparison (i.e. also disregarding any "W/" prefix) and If-Match is not relied upon
+	ETagValidatorMultipart = "multipart" // As with ETagValidatorWeak but a multipart upload's eTag also matches its digest sans "-<number of parts>"
+
+	ETagConflictPolicyFail           = "fail"             // A fetch finding a file's eTag changed fails with ESTALE
+	ETagConflictPolicyRefetch        = "refetch"          // The file is deemed append-only, so cache lines before its prior end are retained
+	ETagConflictPolicyLastWriterWins = "last_writer_wins" // The changed file (logged as a warning) supersedes any cache lines of its prior content
+
+	DirectoryMarkersNone   = "none"   // Directories made by mkdir exist only in memory (though existing "<dir>/" markers are honored)
+	DirectoryMarkersSlash  = "slash"  // Directories made by mkdir are marked by a zero-byte "<dir>/" object (as by the AWS S3 console) or, if hierarchical (e.g. RAM), made real
+	DirectoryMarkersFolder = "folder" // Directories made by mkdir are marked by a zero-byte "<dir>_$folder$" object (as by Hadoop's S3 connectors)
+)
+
 const (
 	FUSERootDirInodeNumber uint64 = 1
 )
@@ -153,6 +418,7 @@ const (
 const (
 	DefaultMountPoint = "/mnt"
 	EnvMSFSMountPoint = "MSFS_MOUNTPOINT"
+	EnvMSFSProfile    = "MSFS_PROFILE" // Selects one of msfs_profiles absent {-profile|--profile} <name>
 )
 
 const (
@@ -186,17 +452,20 @@ type fhStruct struct {
 	allowReads   bool
 	allowWrites  bool
 	appendWrites bool // Only applicable if allowWrites == true
+	// The following only applicable if inode.inodeType == FileObject && allowReads == true
+	sequentialOffset uint64                  // Offset just beyond that of the last read
+	sequentialBytes  uint64                  // Bytes read sequentially ending at sequentialOffset
+	directReadLines  []*directReadLineStruct // Once sequentialBytes >= direct_read_threshold, cache lines read (or read ahead) bypassing inode.cache (in lineNumber order)
 	// The following only applicable if inode.inodeType == BackendRootDir or PseudoDir after enumerating each dir_entry by walking .inode.childDirMap then .inode.childFileMap
 	listDirectoryInProgress               bool
 	listDirectorySequenceDone             bool
 	prevListDirectoryOutput               *listDirectoryOutputStruct
-	prevListDirectoryOutputFileLen        uint64
+	prevListDirectoryOutputEntryLen       uint64
 	prevListDirectoryOutputStartingOffset uint64
 	nextListDirectoryOutput               *listDirectoryOutputStruct
-	nextListDirectoryOutputFileLen        uint64
+	nextListDirectoryOutputEntryLen       uint64
 	nextListDirectoryOutputStartingOffset uint64
-	listDirectorySubdirectorySet          map[string]struct{}
-	listDirectorySubdirectoryList         []string
+	listDirectorySubdirectorySet          map[string]struct{} // Subdirectories already returned (so as to omit any subsequently listed again)
 	// For inode.inodeType == FUSERootDir, enumerating each dir_entry by walking .inode.childDirMap then .inode.childFileMap
 }
 
@@ -218,13 +487,28 @@ const (
 
 // `cacheLineStruct` contains both the stat and content of a cache line used to hold file inode content.
 type cacheLineStruct struct {
-	listElement *list.Element     // If state == CacheLineClean, link into globals.cleanCacheLineLRU; if state == CacheLineDirty, link into globals.dirtyCacheLineLRU; otherwise == nil
-	state       uint8             // One of CacheLine*; determines membership in one of globals.inboundCacheLineCount, globals.cleanCacheLineLRU, globals.outboundCacheLineCount, or globals.dirtyCacheLineLRU
-	waiters     []*sync.WaitGroup // List of those awaiting a state change
-	inodeNumber uint64            // Reference to an inodeStruct.inodeNumber
-	lineNumber  uint64            // Identifies file/object range covered by content as up to [lineNumber * globals.config.cacheLineSize:(lineNumber + 1) * global.config.cacheLineSize)
-	eTag        string            // If state == CacheLineClean, value of inodeStruct.eTag when when fetched from backend; Otherwise, == ""
-	content     []byte            // File/Object content for the range (up to) [lineNumber * globals.config.cacheLineSize:(lineNumber + 1) * global.config.cacheLineSize)
+	listElement *list.Element      // If state == CacheLineClean, link into globals.cleanCacheLineLRU; if state == CacheLineDirty, link into globals.dirtyCacheLineLRU; otherwise == nil
+	state       uint8              // One of CacheLine*; determines membership in one of globals.inboundCacheLineCount, globals.cleanCacheLineLRU, globals.outboundCacheLineCount, or globals.dirtyCacheLineLRU
+	waiters     []*sync.WaitGroup  // List of those awaiting a state change
+	inodeNumber uint64             // Reference to an inodeStruct.inodeNumber
+	lineNumber  uint64             // Identifies file/object range covered by content as up to [lineNumber * inode.cacheLineSize:(lineNumber + 1) * inode.cacheLineSize)
+	eTag        string             // If state == CacheLineClean, value of inodeStruct.eTag when when fetched from backend; if state == CacheLineInbound, != "" only if being revalidated; Otherwise, == ""
+	fetchTime   time.Time          // If state == CacheLineClean, when content was fetched (or last revalidated) from backend
+	content     []byte             // File/Object content for the range (up to) [lineNumber * inode.cacheLineSize:(lineNumber + 1) * inode.cacheLineSize)
+	prefetch    bool               // If true, fetched in anticipation of (rather than in response to) a read and, thus, scheduled as QoSClassBulk
+	traceCtx    context.Context    // If state == CacheLineInbound, context of the (traced) FUSE read that triggered the fetch
+	coalesced   []*cacheLineStruct // If state == CacheLineInbound, the consecutive cache lines following this one fetched along with it (by a single read)
+	errno       syscall.Errno      // If state == CacheLineClean and != 0, the fetch failed (see backendErrno()) leaving content empty
+}
+
+// `directReadLineStruct` is a cache line of a file read (or read ahead) on behalf of a single
+// file handle bypassing inode.cache (see fhStruct.directReadLines).
+type directReadLineStruct struct {
+	lineNumber uint64         // Identifies file/object range covered by content as up to [lineNumber * inode.cacheLineSize:(lineNumber + 1) * inode.cacheLineSize)
+	fetched    bool           // If true, content (or err) has been set
+	done       sync.WaitGroup // Signaled once fetched == true
+	content    []byte         // File/Object content for the range (up to) [lineNumber * inode.cacheLineSize:(lineNumber + 1) * inode.cacheLineSize)
+	err        error          // If != nil, the fetch failed
 }
 
 // `inodeStruct` contains the state of an inode.
@@ -247,7 +531,10 @@ type inodeStruct struct {
 	physChildInodeMap      *stringToUint64MapStruct    // [inodeType != FileObject] maps dirEntries of type FileObject or PseudoDir for which there are existing backend objects
 	virtChildInodeMap      *stringToUint64MapStruct    // [inodeType != FileObject] maps dirEntries "." and ".." as well as others of type BackendRootDir plus those of type FileObject or PseudoDir for which there doesn't yet exist backing objects
 	isPrefetchInProgress   bool                        // [inodeType == BackendRootDir || PseudoDir] indicates that a background prefetch of the directory is in progress
-	cache                  map[uint64]*cacheLineStruct // [inodeType == FileObject] Key == file offset / globals.config.cacheLineSize
+	newestChildMTime       time.Time                   // [inodeType == BackendRootDir || PseudoDir] if != time.Time{}, newest .mTime among children (reported in lieu of .mTime)
+	newestChildMTimeAsOf   time.Time                   // [inodeType == BackendRootDir || PseudoDir] if != time.Time{}, when newestChildMTime was last computed from a complete listing
+	cache                  map[uint64]*cacheLineStruct // [inodeType == FileObject] Key == file offset / .cacheLineSize
+	cacheLineSize          uint64                      // [inodeType == FileObject] size of each of .cache[] (chosen, per backend.pathSettings(), whenever .cache[] is empty)
 	inboundCacheLineCount  uint64                      // [inodeType == FileObject] cound of .cache[] elements in state CacheLineInbound
 	outboundCacheLineCount uint64                      // [inodeType == FileObject] cound of .cache[] elements in state CacheLineOutbound
 	dirtyCacheLineCount    uint64                      // [inodeType == FileObject] cound of .cache[] elements in state CacheLineDirty
@@ -256,31 +543,51 @@ type inodeStruct struct {
 
 // `globalsStruct` is the sync.Mutex protected global data structure under which all details about daemon state are tracked.
 type globalsStruct struct {
-	sync.Mutex                                       //
-	logger                 *log.Logger               //
-	metrics                interface{}               // observability.MSFSMetrics (nil if observability disabled)
-	meterProvider          interface{}               // *sdkmetric.MeterProvider (nil if observability disabled)
-	configFilePath         string                    //
-	config                 *configStruct             //
-	configFileMap          map[string]interface{}    // Parsed config map for msc_config attribute provider
-	backendsToUnmount      map[string]*backendStruct //
-	backendsToMount        map[string]*backendStruct //
-	backendsSkipped        map[string]struct{}       //
-	errChan                chan error                //
-	fissionVolume          fission.Volume            //
-	lastNonce              uint64                    // Used to safely allocate non-repeating values (initialized to FUSERootDirInodeNumber to ensure skipping it)
-	inode                  *inodeStruct              // Link to the lone inodeStruct with .inodeNumber == FUSERootDirInodeNumber && .inodeType == FUSERootDir
-	inodeMap               map[uint64]*inodeStruct   // Key: inodeStruct.inodeNumber
-	inodeEvictionLRU       *timeToUint64QueueStruct  // Contains inodeStruct.listElement's of inodeStruct.inodeNumber's ordered by inodeStruct.xTime
-	inodeEvictorContext    context.Context           //
-	inodeEvictorCancelFunc context.CancelFunc        //
-	inodeEvictorWaitGroup  sync.WaitGroup            //
-	inboundCacheLineCount  uint64                    // Count of cacheLineStruct's where state == CacheLineInbound
-	cleanCacheLineLRU      *list.List                // Contains cacheLineStruct.listElement's for state == CacheLineClean
-	outboundCacheLineCount uint64                    // Count of cacheLineStruct's where state == CacheLineOutbound
-	dirtyCacheLineLRU      *list.List                // Contains cacheLineStruct.listElement's for state == CacheLineDirty
-	fissionMetrics         *fissionMetricsStruct     //
-	backendMetrics         *backendMetricsStruct     //
+	sync.Mutex                                         //
+	logSink                *logSinkStruct              // Beneath logger, formats and filters each logged line per config.log{Format|Level|Levels}
+	logger                 *log.Logger                 //
+	metrics                interface{}                 // observability.MSFSMetrics (nil if observability disabled)
+	meterProvider          interface{}                 // *sdkmetric.MeterProvider (nil if observability disabled)
+	tracerProvider         interface{}                 // *sdktrace.TracerProvider (nil if tracing disabled)
+	configFilePath         string                      //
+	configFileDefaultPaths []string                    // Discovered config-files merged (in order) beneath configFilePath (see findMSFSConfigFiles())
+	configProfile          string                      // From {-profile|--profile} <name> on the command line (else ${MSFS_PROFILE})
+	configOverrides        []configOverrideStruct      // From each {-set|--set} <key>=<value> on the command line
+	config                 *configStruct               //
+	configFileMap          map[string]interface{}      // Parsed config map for msc_config attribute provider
+	backendsToUnmount      map[string]*backendStruct   //
+	backendsToMount        map[string]*backendStruct   //
+	backendsSkipped        map[string]struct{}         //
+	errChan                chan error                  //
+	fissionVolume          fission.Volume              //
+	lastNonce              uint64                      // Used to safely allocate non-repeating values (initialized to FUSERootDirInodeNumber to ensure skipping it)
+	inode                  *inodeStruct                // Link to the lone inodeStruct with .inodeNumber == FUSERootDirInodeNumber && .inodeType == FUSERootDir
+	inodeMap               map[uint64]*inodeStruct     // Key: inodeStruct.inodeNumber
+	inodeEvictionLRU       *timeToUint64QueueStruct    // Contains inodeStruct.listElement's of inodeStruct.inodeNumber's ordered by inodeStruct.xTime
+	inodeEvictorContext    context.Context             //
+	inodeEvictorCancelFunc context.CancelFunc          //
+	inodeEvictorWaitGroup  sync.WaitGroup              //
+	directReadWaitGroup    sync.WaitGroup              // Tracks goroutines running directReadLineStruct.fetch() (awaited by drainFS())
+	inboundCacheLineCount  uint64                      // Count of cacheLineStruct's where state == CacheLineInbound
+	cleanCacheLineLRU      *list.List                  // Contains cacheLineStruct.listElement's for state == CacheLineClean
+	outboundCacheLineCount uint64                      // Count of cacheLineStruct's where state == CacheLineOutbound
+	dirtyCacheLineLRU      *list.List                  // Contains cacheLineStruct.listElement's for state == CacheLineDirty
+	cachePins              map[string]struct{}         // Key: <dir_name>[/<path>] whose (clean) cache lines are exempt from eviction (see cachePinned())
+	cacheLineBufPool       sync.Pool                   // Recycled cacheLineStruct.content buffers (*[]byte's of cap == globals.config.cacheLineSize)
+	fissionMetrics         *fissionMetricsStruct       //
+	backendMetrics         *backendMetricsStruct       //
+	cacheMetrics           *cacheMetricsStruct         //
+	migrations             map[string]*migrationStruct // Key: migrationStruct.id
+	copies                 map[string]*copyStruct      // Key: copyStruct.id
+	qosScheduler           *qosSchedulerStruct         // If config.maxConcurrentBackendRequests != 0, schedules backend requests by priority
+	retryBudget            *retryBudgetStruct          // If config.retryBudgetRatio != 0, bounds retries (across all backends) to a fraction of requests
+	audit                  *auditStruct                // If config.auditLogFile != "", records audited FUSE operations
+	ioAccounting           *ioAccountingStruct         // Accumulates the I/O of FUSE reads per inode, PID, and UID
+	webhooks               *webhooksStruct             // If config.webhookURLs is not empty, notifies them of critical events
+	secrets                *secretsStruct              // Cache of secrets referenced by credential settings
+	adminListener          net.Listener                // If config.adminSocket != "", the listener on which the admin API is served
+	reloadChan             chan chan error             // Once mounted, receives requests (via the admin API) to re-parse the config-file as if SIGHUP'd
+	shuttingDown           bool                        // Set at SIGINT/SIGTERM so that new opens (and creates) fail while awaiting quiescence (see quiesceFS())
 }
 
 var globals globalsStruct
@@ -288,7 +595,11 @@ var globals globalsStruct
 // `initGlobals` initializes the globalsStruct and locates the configuration file's path.
 func initGlobals(osArgs []string) {
 	var (
+		configFileDefaultPath           string
+		err                             error
 		homeEnv                         = os.Getenv("HOME")
+		msfsConfigDirPaths              []string
+		msfsConfigFilePaths             []string
 		mscConfigEnv                    = os.Getenv("MSC_CONFIG")
 		xdgConfigDir                    string
 		xdgConfigDirContainedConfigFile bool
@@ -296,11 +607,28 @@ func initGlobals(osArgs []string) {
 		xdgConfigHomeEnv                = os.Getenv("XDG_CONFIG_HOME")
 	)
 
-	globals.logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmsgprefix) // |log.Lmicroseconds|log.Lshortfile
+	globals.logSink = newLogSink(os.Stdout)
+	globals.logger = log.New(globals.logSink, "", 0) // globals.logSink supplies the timestamp
 
 	globals.logger.Printf("[INFO] starting %s version %s", osArgs[0], GitTag)
 
 	globals.backendsSkipped = make(map[string]struct{})
+	globals.configFileDefaultPaths = nil
+
+	osArgs, globals.configOverrides, err = extractConfigOverrides(osArgs)
+	if err != nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] %v", err)
+	}
+
+	osArgs, globals.configProfile, err = extractConfigProfile(osArgs)
+	if err != nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] %v", err)
+	}
+	if globals.configProfile == "" {
+		globals.configProfile = os.Getenv(EnvMSFSProfile)
+	}
 
 	for {
 		if len(osArgs) == 2 {
@@ -321,6 +649,23 @@ func initGlobals(osArgs []string) {
 			break
 		}
 
+		msfsConfigDirPaths = make([]string, 0, 3)
+		if xdgConfigHomeEnv != "" {
+			msfsConfigDirPaths = append(msfsConfigDirPaths, xdgConfigHomeEnv+"/msfs")
+		}
+		if homeEnv != "" {
+			msfsConfigDirPaths = append(msfsConfigDirPaths, homeEnv+"/.config/msfs")
+		}
+		msfsConfigDirPaths = append(msfsConfigDirPaths, "/etc/msfs")
+
+		msfsConfigFilePaths = findMSFSConfigFiles(msfsConfigDirPaths)
+		if len(msfsConfigFilePaths) != 0 {
+			globals.configFilePath = msfsConfigFilePaths[0]
+			globals.configFileDefaultPaths = msfsConfigFilePaths[1:]
+			slices.Reverse(globals.configFileDefaultPaths)
+			break
+		}
+
 		if xdgConfigHomeEnv != "" {
 			if checkForFile(xdgConfigHomeEnv + "/msc/config.yaml") {
 				globals.configFilePath = xdgConfigHomeEnv + "/msc/config.yaml"
@@ -334,6 +679,10 @@ func initGlobals(osArgs []string) {
 				globals.configFilePath = xdgConfigHomeEnv + "/msc/config.json"
 				break
 			}
+			if checkForFile(xdgConfigHomeEnv + "/msc/config.toml") {
+				globals.configFilePath = xdgConfigHomeEnv + "/msc/config.toml"
+				break
+			}
 		}
 
 		if homeEnv != "" {
@@ -349,6 +698,10 @@ func initGlobals(osArgs []string) {
 				globals.configFilePath = homeEnv + "/.msc_config.json"
 				break
 			}
+			if checkForFile(homeEnv + "/.msc_config.toml") {
+				globals.configFilePath = homeEnv + "/.msc_config.toml"
+				break
+			}
 
 			if checkForFile(homeEnv + "/.config/msc/config.yaml") {
 				globals.configFilePath = homeEnv + "/.config/msc/config.yaml"
@@ -362,6 +715,10 @@ func initGlobals(osArgs []string) {
 				globals.configFilePath = homeEnv + "/.config/msc/config.json"
 				break
 			}
+			if checkForFile(homeEnv + "/.config/msc/config.toml") {
+				globals.configFilePath = homeEnv + "/.config/msc/config.toml"
+				break
+			}
 		}
 
 		if xdgConfigDirsEnv == "" {
@@ -377,6 +734,10 @@ func initGlobals(osArgs []string) {
 				globals.configFilePath = "/etc/xdg/msc/config.json"
 				break
 			}
+			if checkForFile("/etc/xdg/msc/config.toml") {
+				globals.configFilePath = "/etc/xdg/msc/config.toml"
+				break
+			}
 		} else { // xdgConfigDirsEnv != ""
 			xdgConfigDirContainedConfigFile = false
 			for _, xdgConfigDir = range strings.Split(xdgConfigDirsEnv, ":") {
@@ -395,6 +756,11 @@ func initGlobals(osArgs []string) {
 					xdgConfigDirContainedConfigFile = true
 					break
 				}
+				if checkForFile(xdgConfigDir + "/msc/config.toml") {
+					globals.configFilePath = xdgConfigDir + "/msc/config.toml"
+					xdgConfigDirContainedConfigFile = true
+					break
+				}
 			}
 			if xdgConfigDirContainedConfigFile {
 				break
@@ -413,12 +779,19 @@ func initGlobals(osArgs []string) {
 			globals.configFilePath = "/etc/msc_config.json"
 			break
 		}
+		if checkForFile("/etc/msc_config.toml") {
+			globals.configFilePath = "/etc/msc_config.toml"
+			break
+		}
 
 		dumpStack()
 		globals.logger.Fatalf("[FATAL] config-file not found along search path")
 	}
 
 	globals.logger.Printf("[INFO] config-file path: \"%s\"", globals.configFilePath)
+	for _, configFileDefaultPath = range globals.configFileDefaultPaths {
+		globals.logger.Printf("[INFO] config-file defaults path: \"%s\"", configFileDefaultPath)
+	}
 
 	globals.config = nil
 	globals.backendsToUnmount = make(map[string]*backendStruct)
@@ -427,6 +800,41 @@ func initGlobals(osArgs []string) {
 	globals.errChan = make(chan error, 1)
 }
 
+// `findMSFSConfigFiles` returns the config.{yaml|yml|json|toml} (in that order of preference)
+// found in each of msfsConfigDirPaths (e.g. per-user ahead of system-wide) in the same order.
+// A directory reached via more than one of msfsConfigDirPaths is only searched once.
+func findMSFSConfigFiles(msfsConfigDirPaths []string) (msfsConfigFilePaths []string) {
+	var (
+		err                error
+		msfsConfigDirPath  string
+		msfsConfigDirsSeen = make(map[string]struct{})
+		msfsConfigFileExt  string
+		ok                 bool
+		realDirPath        string
+	)
+
+	for _, msfsConfigDirPath = range msfsConfigDirPaths {
+		realDirPath, err = filepath.EvalSymlinks(msfsConfigDirPath)
+		if err != nil {
+			continue
+		}
+		_, ok = msfsConfigDirsSeen[realDirPath]
+		if ok {
+			continue
+		}
+		msfsConfigDirsSeen[realDirPath] = struct{}{}
+
+		for _, msfsConfigFileExt = range []string{".yaml", ".yml", ".json", ".toml"} {
+			if checkForFile(msfsConfigDirPath + "/config" + msfsConfigFileExt) {
+				msfsConfigFilePaths = append(msfsConfigFilePaths, msfsConfigDirPath+"/config"+msfsConfigFileExt)
+				break
+			}
+		}
+	}
+
+	return
+}
+
 // `checkForFile` indicates whether or not a file exists at filePath.
 func checkForFile(filePath string) (ok bool) {
 	fileInfo, err := os.Stat(filePath)
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// `healthStruct` tracks, for a backend specifying a health_check_interval, the outcome
+// of periodic probes. Once health_check_failure_threshold consecutive probes have failed,
+// the backend is considered down (i.e. its circuit breaker is open) until a probe succeeds.
+type healthStruct struct {
+	sync.Mutex                         // Protects consecutiveFailures, down, & downSince
+	backend             *backendStruct //
+	consecutiveFailures uint64         //
+	down                bool           // If true, requests fail fast rather than being issued to the backend
+	downSince           time.Time      // If down, when the circuit breaker was opened
+	stopChan            chan struct{}  // Closed to stop checker()
+	stopWaitGroup       sync.WaitGroup // Awaited after closing stopChan
+}
+
+// `refreshHealthChecksAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start probing each newly mounted backend specifying
+// a health_check_interval.
+func refreshHealthChecksAlreadyLocked() {
+	var (
+		backend *backendStruct
+	)
+
+	for _, backend = range globals.config.backends {
+		if (backend.healthCheckInterval == 0) || (backend.healthState != nil) {
+			continue
+		}
+
+		backend.healthState = &healthStruct{
+			backend:  backend,
+			stopChan: make(chan struct{}),
+		}
+
+		backend.healthState.stopWaitGroup.Go(backend.healthState.checker)
+	}
+}
+
+// `stopHealthCheckAlreadyLocked` is called while globals.Lock() is held as backend
+// is unmounted to stop probing it (if it was being probed).
+func (backend *backendStruct) stopHealthCheckAlreadyLocked() {
+	if backend.healthState != nil {
+		close(backend.healthState.stopChan)
+		backend.healthState.stopWaitGroup.Wait()
+	}
+}
+
+// `healthCheck` is called prior to issuing a request to backend. If the backend is
+// down, an error wrapping syscall.EHOSTDOWN is returned such that the request fails
+// fast rather than waiting out the backend's full retry sequence.
+func (backend *backendStruct) healthCheck() (err error) {
+	var (
+		healthState = backend.healthState
+	)
+
+	if healthState == nil {
+		return
+	}
+
+	healthState.Lock()
+	if healthState.down {
+		err = fmt.Errorf("%s down since %s: %w", backend.dirName, healthState.downSince.Format(time.RFC3339), syscall.EHOSTDOWN)
+	}
+	healthState.Unlock()
+
+	return
+}
+
+// `serveStale` returns whether inodes (and their cache lines) of backend should be
+// retained beyond their expiration as the backend is down and specifies health_check_serve_stale.
+func (backend *backendStruct) serveStale() (serveStale bool) {
+	var (
+		healthState = backend.healthState
+	)
+
+	if (healthState == nil) || !backend.healthCheckServeStale {
+		return
+	}
+
+	healthState.Lock()
+	serveStale = healthState.down
+	healthState.Unlock()
+
+	return
+}
+
+// `checker` is run as a background worker while the backend is mounted
+// to probe it every backend.healthCheckInterval.
+func (healthState *healthStruct) checker() {
+	var (
+		ticker = time.NewTicker(healthState.backend.healthCheckInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-healthState.stopChan:
+			return
+		case <-ticker.C:
+			healthState.recordProbe(healthState.probe())
+		}
+	}
+}
+
+// `probe` issues a minimal listing of the backend's root directory directly (i.e. bypassing
+// listDirectoryWrapper() and, thus, healthCheck()) as a successful listing of even an empty
+// backend indicates it is reachable.
+func (healthState *healthStruct) probe() (err error) {
+	_, err = healthState.backend.context.listDirectory(&listDirectoryInputStruct{
+		continuationToken: "",
+		maxItems:          1,
+		dirPath:           "",
+	})
+
+	return
+}
+
+// `recordProbe` updates the backend's health given the outcome of a probe,
+// opening the circuit breaker upon health_check_failure_threshold consecutive
+// failures and closing it upon the first success.
+func (healthState *healthStruct) recordProbe(probeErr error) {
+	var (
+		backend = healthState.backend
+	)
+
+	healthState.Lock()
+	defer healthState.Unlock()
+
+	if probeErr == nil {
+		if healthState.down {
+			globals.logger.Printf("[INFO] [health] %s is back up after being down since %s", backend.dirName, healthState.downSince.Format(time.RFC3339))
+		}
+
+		healthState.consecutiveFailures = 0
+		healthState.down = false
+
+		return
+	}
+
+	healthState.consecutiveFailures++
+
+	if !healthState.down && (healthState.consecutiveFailures >= backend.healthCheckFailureThreshold) {
+		globals.logger.Printf("[WARN] [health] %s is down after %v consecutive failed probes: %v", backend.dirName, healthState.consecutiveFailures, probeErr)
+		globals.webhooks.notify(webhookEventCircuitOpen, backend.dirName, fmt.Sprintf("down after %v consecutive failed probes: %v", healthState.consecutiveFailures, probeErr))
+
+		healthState.down = true
+		healthState.downSince = time.Now()
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	var (
+		backend *backendStruct
+		err     error
+		ok      bool
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "flaky",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"health_check_interval": 3600000,
+				"health_check_failure_threshold": 2,
+				"health_check_serve_stale": true
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	backend, ok = globals.config.backends["flaky"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"flaky\"] returned !ok")
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "a", buf: []byte("a")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"a\") failed: %v", err)
+	}
+
+	err = backend.healthState.probe()
+	if err != nil {
+		t.Fatalf("probe() failed: %v", err)
+	}
+
+	// A single failed probe should not open the circuit breaker
+
+	backend.healthState.recordProbe(errors.New("simulated failure"))
+
+	if backend.serveStale() {
+		t.Fatalf("serveStale() returned true after a single failed probe")
+	}
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: "a"})
+	if err != nil {
+		t.Fatalf("statFileWrapper(\"a\") failed after a single failed probe: %v", err)
+	}
+
+	backend.healthState.recordProbe(errors.New("simulated failure"))
+
+	if !backend.serveStale() {
+		t.Fatalf("serveStale() returned false once down")
+	}
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: "a"})
+	if !errors.Is(err, syscall.EHOSTDOWN) {
+		t.Fatalf("statFileWrapper(\"a\") returned %v once down (expected EHOSTDOWN)", err)
+	}
+	_, err = readFileWrapper(backend.context, &readFileInputStruct{filePath: "a", cacheLineSize: globals.config.cacheLineSize})
+	if !errors.Is(err, syscall.EHOSTDOWN) {
+		t.Fatalf("readFileWrapper(\"a\") returned %v once down (expected EHOSTDOWN)", err)
+	}
+
+	// A successful probe should close the circuit breaker
+
+	backend.healthState.recordProbe(nil)
+
+	_, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: "a"})
+	if err != nil {
+		t.Fatalf("statFileWrapper(\"a\") failed after a successful probe: %v", err)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -79,10 +82,20 @@ func startHTTPHandler() {
 
 func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var (
-		backend     *backendStruct
-		backendName string
-		numDrained  uint64
-		registry    *prometheus.Registry
+		backend              *backendStruct
+		backendName          string
+		cp                   *copyStruct
+		err                  error
+		maxAge               time.Duration
+		maxAgeInMilliseconds uint64
+		rename               bool
+		migration            *migrationStruct
+		numAborted           uint64
+		numDrained           uint64
+		numFiles             uint64
+		query                url.Values
+		registry             *prometheus.Registry
+		workers              uint64
 	)
 
 	switch {
@@ -95,7 +108,10 @@ func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprintf(w, "  <li><a href=\"/backends\">/backends</a></li>\n")
 			fmt.Fprintf(w, "  <li><a href=\"/drain\">/drain</a></li>\n")
 			fmt.Fprintf(w, "  <li><a href=\"/dump\">/dump</a></li>\n")
+			fmt.Fprintf(w, "  <li><a href=\"/log_level\">/log_level</a></li>\n")
 			fmt.Fprintf(w, "  <li><a href=\"/metrics\">/metrics</a></li>\n")
+			fmt.Fprintf(w, "  <li><a href=\"/migrations\">/migrations</a></li>\n")
+			fmt.Fprintf(w, "  <li><a href=\"/copies\">/copies</a></li>\n")
 			globals.Lock()
 			for _, backend = range globals.config.backends {
 				fmt.Fprintf(w, "  <li><a href=\"/metrics/%s\">/metrics/%s</a></li>\n", backend.dirName, backend.dirName)
@@ -108,7 +124,14 @@ func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprintf(w, "  /backends\n")
 			fmt.Fprintf(w, "  /drain\n")
 			fmt.Fprintf(w, "  /dump\n")
+			fmt.Fprintf(w, "  /log_level[?{reset=true|level=<level>[&subsystem=<subsystem>][&duration=<milliseconds>]}]\n")
 			fmt.Fprintf(w, "  /metrics\n")
+			fmt.Fprintf(w, "  /migrate?src=<dir_name>&dst=<dir_name>[&prefix=<prefix>][&workers=<workers>]\n")
+			fmt.Fprintf(w, "  /migrations\n")
+			fmt.Fprintf(w, "  /copy?src=<dir_name>&src_path=<path>&dst=<dir_name>[&dst_path=<path>][&rename=true]\n")
+			fmt.Fprintf(w, "  /copies\n")
+			fmt.Fprintf(w, "  /multipart_gc?backend=<dir_name>[&max_age=<milliseconds>]\n")
+			fmt.Fprintf(w, "  /snapshot?backend=<dir_name>&name=<name>[&prefix=<prefix>]\n")
 			globals.Lock()
 			for _, backend = range globals.config.backends {
 				fmt.Fprintf(w, "  /metrics/%s\n", backend.dirName)
@@ -140,6 +163,23 @@ func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		dumpFS(w)
 
+	case r.RequestURI == "/log_level":
+		w.WriteHeader(http.StatusOK)
+		globals.logSink.describe(w)
+
+	case strings.HasPrefix(r.RequestURI, "/log_level?"):
+		err = globals.logSink.applyLogLevelQuery(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%v\n", err)
+			return
+		}
+
+		globals.logger.Printf("[INFO] log levels changed via /log_level?%s", r.URL.RawQuery)
+
+		w.WriteHeader(http.StatusOK)
+		globals.logSink.describe(w)
+
 	case r.RequestURI == "/metrics":
 		registry = prometheus.NewRegistry()
 
@@ -148,8 +188,16 @@ func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		registerFissionMetrics(registry, globals.fissionMetrics)
 		registerBackendMetrics(registry, globals.backendMetrics)
 
+		globals.cacheMetrics.updateAlreadyLocked()
+		registerCacheMetrics(registry, globals.cacheMetrics)
+
 		globals.Unlock()
 
+		if globals.retryBudget != nil {
+			globals.retryBudget.updateMetrics()
+			registerRetryBudgetMetrics(registry, globals.retryBudget.metrics)
+		}
+
 		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
 
 	case strings.HasPrefix(r.RequestURI, "/metrics/"):
@@ -179,13 +227,153 @@ func (*globalsStruct) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
 
+	case strings.HasPrefix(r.RequestURI, "/migrate?"):
+		query = r.URL.Query()
+
+		if query.Get("workers") == "" {
+			workers = 0
+		} else {
+			workers, err = strconv.ParseUint(query.Get("workers"), 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad workers: %v\n", err)
+				return
+			}
+		}
+
+		migration, err = startMigration(query.Get("src"), query.Get("dst"), query.Get("prefix"), workers)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%v\n", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%s\n", migration.id)
+
+	case r.RequestURI == "/migrations":
+		w.WriteHeader(http.StatusOK)
+
+		globals.Lock()
+
+		for _, migration = range globals.migrations {
+			fmt.Fprintf(w, "%s\n", migration.status())
+		}
+
+		globals.Unlock()
+
+	case strings.HasPrefix(r.RequestURI, "/copy?"):
+		query = r.URL.Query()
+
+		if query.Get("rename") == "" {
+			rename = false
+		} else {
+			rename, err = strconv.ParseBool(query.Get("rename"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad rename: %v\n", err)
+				return
+			}
+		}
+
+		cp, err = startCopy(query.Get("src"), query.Get("src_path"), query.Get("dst"), query.Get("dst_path"), rename)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%v\n", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%s\n", cp.id)
+
+	case r.RequestURI == "/copies":
+		w.WriteHeader(http.StatusOK)
+
+		globals.Lock()
+
+		for _, cp = range globals.copies {
+			fmt.Fprintf(w, "%s\n", cp.status())
+		}
+
+		globals.Unlock()
+
+	case strings.HasPrefix(r.RequestURI, "/multipart_gc?"):
+		query = r.URL.Query()
+
+		globals.Lock()
+		backend = globals.config.backends[query.Get("backend")]
+		globals.Unlock()
+
+		if backend == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "backend %q not found\n", query.Get("backend"))
+			return
+		}
+
+		if query.Get("max_age") == "" {
+			maxAge = backend.multipartUploadMaxAge
+		} else {
+			maxAgeInMilliseconds, err = strconv.ParseUint(query.Get("max_age"), 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "bad max_age: %v\n", err)
+				return
+			}
+			maxAge = time.Duration(maxAgeInMilliseconds) * time.Millisecond
+		}
+
+		numAborted, err = backend.gcMultipartUploads(maxAge)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "%v aborted before failing: %v\n", numAborted, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%v\n", numAborted)
+
+	case strings.HasPrefix(r.RequestURI, "/snapshot?"):
+		query = r.URL.Query()
+
+		globals.Lock()
+		backend = globals.config.backends[query.Get("backend")]
+		globals.Unlock()
+
+		if backend == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "backend %q not found\n", query.Get("backend"))
+			return
+		}
+
+		numFiles, err = backend.takeSnapshot(query.Get("name"), query.Get("prefix"))
+		if errors.Is(err, syscall.EEXIST) {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprintf(w, "%v\n", err)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%v\n", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "%v\n", numFiles)
+
 	default:
 		w.WriteHeader(http.StatusNotFound)
 		fmt.Fprintf(w, "unknown endpoint - must be one of:\n")
 		fmt.Fprintf(w, "  /backends\n")
 		fmt.Fprintf(w, "  /drain\n")
 		fmt.Fprintf(w, "  /dump\n")
+		fmt.Fprintf(w, "  /log_level[?{reset=true|level=<level>[&subsystem=<subsystem>][&duration=<milliseconds>]}]\n")
 		fmt.Fprintf(w, "  /metrics\n")
+		fmt.Fprintf(w, "  /migrate?src=<dir_name>&dst=<dir_name>[&prefix=<prefix>][&workers=<workers>]\n")
+		fmt.Fprintf(w, "  /migrations\n")
+		fmt.Fprintf(w, "  /copy?src=<dir_name>&src_path=<path>&dst=<dir_name>[&dst_path=<path>][&rename=true]\n")
+		fmt.Fprintf(w, "  /copies\n")
+		fmt.Fprintf(w, "  /multipart_gc?backend=<dir_name>[&max_age=<milliseconds>]\n")
+		fmt.Fprintf(w, "  /snapshot?backend=<dir_name>&name=<name>[&prefix=<prefix>]\n")
 		globals.Lock()
 		for _, backend = range globals.config.backends {
 			fmt.Fprintf(w, "  /metrics/%s\n", backend.dirName)
@@ -237,6 +425,7 @@ func registerFissionMetrics(registry *prometheus.Registry, m *fissionMetricsStru
 	registry.MustRegister(m.ReadCacheMisses)
 	registry.MustRegister(m.ReadCacheWaits)
 	registry.MustRegister(m.ReadCachePrefetches)
+	registry.MustRegister(m.ReadCacheBypasses)
 	registry.MustRegister(m.StatFSCalls)
 	registry.MustRegister(m.ReleaseSuccesses)
 	registry.MustRegister(m.ReleaseFailures)
@@ -268,6 +457,7 @@ func registerFissionMetrics(registry *prometheus.Registry, m *fissionMetricsStru
 	registry.MustRegister(m.StatXFailures)
 	registry.MustRegister(m.StatXSuccessLatencies)
 	registry.MustRegister(m.StatXFailureLatencies)
+	registry.MustRegister(m.Ops)
 }
 
 func registerBackendMetrics(registry *prometheus.Registry, m *backendMetricsStruct) {
@@ -296,4 +486,33 @@ func registerBackendMetrics(registry *prometheus.Registry, m *backendMetricsStru
 	registry.MustRegister(m.StatFileSuccessLatencies)
 	registry.MustRegister(m.StatFileFailureLatencies)
 	registry.MustRegister(m.DirectoryPrefetchLatencies)
+	registry.MustRegister(m.Requests)
+	registry.MustRegister(m.RequestLatencies)
+	registry.MustRegister(m.RequestLatencyQuantiles)
+}
+
+func registerCacheMetrics(registry *prometheus.Registry, m *cacheMetricsStruct) {
+	if m == nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] registerCacheMetrics() passed a nil *cacheMetricsStruct")
+	}
+	registry.MustRegister(m.LineEvictions)
+	registry.MustRegister(m.LineRevalidations)
+	registry.MustRegister(m.LineRevalidationsUnchanged)
+	registry.MustRegister(m.LineETagConflicts)
+	registry.MustRegister(m.CleanLines)
+	registry.MustRegister(m.DirtyLines)
+	registry.MustRegister(m.DirtyBytes)
+	registry.MustRegister(m.InflightFetches)
+	registry.MustRegister(m.InflightFlushes)
+}
+
+func registerRetryBudgetMetrics(registry *prometheus.Registry, m *retryBudgetMetricsStruct) {
+	if m == nil {
+		dumpStack()
+		globals.logger.Fatalf("[FATAL] registerRetryBudgetMetrics() passed a nil *retryBudgetMetricsStruct")
+	}
+	registry.MustRegister(m.Tokens)
+	registry.MustRegister(m.Retries)
+	registry.MustRegister(m.RetriesShed)
 }
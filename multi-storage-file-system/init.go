@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	initDefaultCacheLineSize = uint64(1048576)
+	initDefaultCacheLines    = uint64(4096)
+	initMinCacheLines        = uint64(256)
+	initCacheRAMFraction     = uint64(8) // Suggested cache_lines * cache_line_size is 1/initCacheRAMFraction of RAM
+)
+
+// `initHostStruct` describes the resources of the host (see detectInitHost())
+// from which runInit() suggests settings.
+type initHostStruct struct {
+	memTotal uint64 // Bytes of RAM (0 if unknown)
+}
+
+// `initWizardStruct` poses each question of runInit() and reads its answer.
+type initWizardStruct struct {
+	reader *bufio.Reader
+	w      io.Writer
+}
+
+// `detectInitHost` returns the RAM (from /proc/meminfo) of this host. As the cache of
+// file content is held only in memory, local disks (e.g. NVMe) play no part in its
+// sizing. What cannot be detected is left zero.
+func detectInitHost() (host *initHostStruct) {
+	var (
+		content []byte
+		err     error
+		fields  []string
+		line    string
+		memKiB  uint64
+	)
+
+	host = &initHostStruct{}
+
+	content, err = os.ReadFile("/proc/meminfo")
+	if err == nil {
+		for _, line = range strings.Split(string(content), "\n") {
+			fields = strings.Fields(line)
+			if (len(fields) >= 2) && (fields[0] == "MemTotal:") {
+				memKiB, err = strconv.ParseUint(fields[1], 10, 64)
+				if err == nil {
+					host.memTotal = memKiB * 1024
+				}
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// `defaultInitConfigFilePath` returns where runInit() writes the configuration file
+// if none is specified: the first location searched for one (see initGlobals()).
+func defaultInitConfigFilePath() string {
+	if os.Getenv("XDG_CONFIG_HOME") != "" {
+		return os.Getenv("XDG_CONFIG_HOME") + "/msfs/config.yaml"
+	}
+	if os.Getenv("HOME") != "" {
+		return os.Getenv("HOME") + "/.config/msfs/config.yaml"
+	}
+	return "/etc/msfs/config.yaml"
+}
+
+// `ask` poses question (noting defaultValue, if any) and returns the (trimmed)
+// answer or, if the answer was empty, defaultValue.
+func (wizard *initWizardStruct) ask(question string, defaultValue string) (answer string, err error) {
+	var (
+		line string
+	)
+
+	if defaultValue == "" {
+		_, _ = fmt.Fprintf(wizard.w, "%s: ", question)
+	} else {
+		_, _ = fmt.Fprintf(wizard.w, "%s [%s]: ", question, defaultValue)
+	}
+
+	line, err = wizard.reader.ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) || (line == "") {
+			err = errors.New("input ended before the configuration was complete")
+			return
+		}
+		err = nil
+	}
+
+	answer = strings.TrimSpace(line)
+	if answer == "" {
+		answer = defaultValue
+	}
+
+	return
+}
+
+// `askRequired` poses question until a non-empty answer is given.
+func (wizard *initWizardStruct) askRequired(question string, defaultValue string) (answer string, err error) {
+	for {
+		answer, err = wizard.ask(question, defaultValue)
+		if (err != nil) || (answer != "") {
+			return
+		}
+		_, _ = fmt.Fprintf(wizard.w, "  an answer is required\n")
+	}
+}
+
+// `askChoice` poses question until one of choices (matched case-insensitively) is given.
+func (wizard *initWizardStruct) askChoice(question string, choices []string, defaultValue string) (answer string, err error) {
+	var (
+		choice string
+	)
+
+	for {
+		answer, err = wizard.ask(question+" ("+strings.Join(choices, ", ")+")", defaultValue)
+		if err != nil {
+			return
+		}
+		for _, choice = range choices {
+			if strings.EqualFold(answer, choice) {
+				answer = choice
+				return
+			}
+		}
+		_, _ = fmt.Fprintf(wizard.w, "  must be one of %s\n", strings.Join(choices, ", "))
+	}
+}
+
+// `askBool` poses question until one of y, yes, n, or no is given.
+func (wizard *initWizardStruct) askBool(question string, defaultValue bool) (answer bool, err error) {
+	var (
+		answerString       string
+		defaultValueString = "n"
+	)
+
+	if defaultValue {
+		defaultValueString = "y"
+	}
+
+	for {
+		answerString, err = wizard.ask(question+" (y/n)", defaultValueString)
+		if err != nil {
+			return
+		}
+		switch strings.ToLower(answerString) {
+		case "y", "yes":
+			answer = true
+			return
+		case "n", "no":
+			answer = false
+			return
+		}
+		_, _ = fmt.Fprintf(wizard.w, "  must be y or n\n")
+	}
+}
+
+// `askUint64` poses question until a non-zero decimal is given.
+func (wizard *initWizardStruct) askUint64(question string, defaultValue uint64) (answer uint64, err error) {
+	var (
+		answerString string
+	)
+
+	for {
+		answerString, err = wizard.ask(question, strconv.FormatUint(defaultValue, 10))
+		if err != nil {
+			return
+		}
+		answer, err = strconv.ParseUint(answerString, 10, 64)
+		if (err == nil) && (answer != 0) {
+			return
+		}
+		err = nil
+		_, _ = fmt.Fprintf(wizard.w, "  must be a non-zero decimal\n")
+	}
+}
+
+// `runInit` interviews the user (reading answers from r and posing questions to w)
+// for the settings of a configuration file presenting a single backend, suggesting
+// cache sizing from the resources of host. The resulting configuration file is validated (and, if requested, its
+// backend probed as by checkBackends()) before being written to configFilePath.
+func runInit(r io.Reader, w io.Writer, configFilePath string, host *initHostStruct) (err error) {
+	var (
+		answer            string
+		backendMap        = make(map[string]interface{})
+		backendSubMap     = make(map[string]interface{})
+		backendType       string
+		cacheLineSize     uint64
+		cacheLines        uint64
+		configFileContent bytes.Buffer
+		configFileExt     = filepath.Ext(configFilePath)
+		configMap         = map[string]interface{}{"msfs_version": 1}
+		credentialsSource string
+		defaultValue      string
+		dirName           string
+		jsonEncoder       *json.Encoder
+		ok                bool
+		readOnly          bool
+		tmpConfigFile     *os.File
+		tmpConfigFilePath string
+		wizard            = &initWizardStruct{reader: bufio.NewReader(r), w: w}
+		yamlEncoder       *yaml.Encoder
+	)
+
+	if !slices.Contains([]string{".yaml", ".yml", ".json", ".toml"}, configFileExt) {
+		err = fmt.Errorf("config-file \"%s\" must end in suffix .yaml, .yml, .json, or .toml", configFilePath)
+		return
+	}
+
+	if checkForFile(configFilePath) {
+		ok, err = wizard.askBool(fmt.Sprintf("\"%s\" already exists; overwrite it?", configFilePath), false)
+		if err != nil {
+			return
+		}
+		if !ok {
+			err = fmt.Errorf("not overwriting \"%s\"", configFilePath)
+			return
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Writing a configuration file presenting a single backend to \"%s\" (more may be added to its backends later)\n", configFilePath)
+
+	// Global settings
+
+	defaultValue = os.Getenv("MSFS_MOUNTPOINT")
+	if defaultValue == "" {
+		defaultValue = "/mnt"
+	}
+	configMap["mountpoint"], err = wizard.askRequired("mountpoint", defaultValue)
+	if err != nil {
+		return
+	}
+
+	// Backend settings common to each backend_type
+
+	backendType, err = wizard.askChoice("backend_type", []string{"S3", "AIStore", "RAM"}, "S3")
+	if err != nil {
+		return
+	}
+	backendMap["backend_type"] = backendType
+
+	dirName, err = wizard.askRequired("dir_name (the subdirectory of mountpoint presenting the backend)", strings.ToLower(backendType))
+	if err != nil {
+		return
+	}
+	backendMap["dir_name"] = dirName
+
+	backendMap["bucket_container_name"], err = wizard.askRequired("bucket_container_name", "")
+	if err != nil {
+		return
+	}
+
+	answer, err = wizard.ask("prefix (within the bucket; blank for all of it)", "")
+	if err != nil {
+		return
+	}
+	if answer != "" {
+		if !strings.HasSuffix(answer, "/") {
+			answer += "/"
+		}
+		backendMap["prefix"] = answer
+	}
+
+	readOnly, err = wizard.askBool("readonly", true)
+	if err != nil {
+		return
+	}
+	backendMap["readonly"] = readOnly
+
+	// Backend settings specific to backend_type
+
+	switch backendType {
+	case "S3":
+		answer, err = wizard.ask("endpoint (blank to derive from region)", os.Getenv("AWS_ENDPOINT"))
+		if err != nil {
+			return
+		}
+		if answer != "" {
+			backendSubMap["endpoint"] = answer
+		}
+
+		defaultValue = os.Getenv("AWS_REGION")
+		if defaultValue == "" {
+			defaultValue = "us-east-1"
+		}
+		backendSubMap["region"], err = wizard.askRequired("region", defaultValue)
+		if err != nil {
+			return
+		}
+
+		defaultValue = "env"
+		if (os.Getenv("HOME") != "") && checkForFile(os.Getenv("HOME")+"/.aws/credentials") {
+			defaultValue = "profile"
+		}
+		_, _ = fmt.Fprintf(w, "Credentials may be taken from ${AWS_ACCESS_KEY_ID} & ${AWS_SECRET_ACCESS_KEY} as mounted (env), a profile of the AWS credentials file (profile),\n")
+		_, _ = fmt.Fprintf(w, "written into the configuration file (static), fetched from a secrets store (secret), or output by a command (command)\n")
+		credentialsSource, err = wizard.askChoice("credentials source", []string{"env", "profile", "static", "secret", "command"}, defaultValue)
+		if err != nil {
+			return
+		}
+
+		switch credentialsSource {
+		case "env":
+			// The defaults of access_key_id & secret_access_key apply
+		case "profile":
+			backendSubMap["use_credentials_env"] = true
+			defaultValue = os.Getenv("AWS_PROFILE")
+			if defaultValue == "" {
+				defaultValue = "default"
+			}
+			backendSubMap["config_credentials_profile"], err = wizard.askRequired("config_credentials_profile", defaultValue)
+			if err != nil {
+				return
+			}
+		case "static":
+			backendSubMap["access_key_id"], err = wizard.askRequired("access_key_id", "")
+			if err != nil {
+				return
+			}
+			backendSubMap["secret_access_key"], err = wizard.askRequired("secret_access_key", "")
+			if err != nil {
+				return
+			}
+		case "secret":
+			_, _ = fmt.Fprintf(w, "Each is a secret reference (e.g. vault-aws:aws/creds/msfs#access_key or aws-secretsmanager:msfs/s3#access_key_id)\n")
+			backendSubMap["access_key_id"], err = wizard.askRequired("access_key_id reference", "")
+			if err != nil {
+				return
+			}
+			backendSubMap["secret_access_key"], err = wizard.askRequired("secret_access_key reference", "")
+			if err != nil {
+				return
+			}
+		case "command":
+			_, _ = fmt.Fprintf(w, "The command must output credentials as JSON (as would an AWS credential_process)\n")
+			backendSubMap["credential_refresh_command"], err = wizard.askRequired("credential_refresh_command", "")
+			if err != nil {
+				return
+			}
+		}
+	case "AIStore":
+		backendSubMap["endpoint"], err = wizard.askRequired("endpoint", os.Getenv("AIS_ENDPOINT"))
+		if err != nil {
+			return
+		}
+
+		backendSubMap["provider"], err = wizard.askRequired("provider (of the bucket; \"ais\" for a native bucket)", defaultAIStoreProvider)
+		if err != nil {
+			return
+		}
+
+		credentialsSource, err = wizard.askChoice("credentials source", []string{"none", "token-file", "authn"}, "none")
+		if err != nil {
+			return
+		}
+
+		switch credentialsSource {
+		case "none":
+			backendSubMap["authn_token"] = ""
+			backendSubMap["authn_token_file"] = ""
+		case "token-file":
+			defaultValue = ""
+			if os.Getenv("HOME") != "" {
+				defaultValue = os.Getenv("HOME") + "/.config/ais/cli/auth.token"
+			}
+			backendSubMap["authn_token"] = ""
+			backendSubMap["authn_token_file"], err = wizard.askRequired("authn_token_file", defaultValue)
+			if err != nil {
+				return
+			}
+		case "authn":
+			backendSubMap["authn_token"] = ""
+			backendSubMap["authn_token_file"] = ""
+			backendSubMap["authn_endpoint"], err = wizard.askRequired("authn_endpoint", os.Getenv("AIS_AUTHN_URL"))
+			if err != nil {
+				return
+			}
+			backendSubMap["authn_username"], err = wizard.askRequired("authn_username", "")
+			if err != nil {
+				return
+			}
+			backendSubMap["authn_password"], err = wizard.askRequired("authn_password (or a secret reference)", "")
+			if err != nil {
+				return
+			}
+		}
+	case "RAM":
+		// The defaults of max_total_objects, max_total_object_space, & max_directory_page_size apply
+	}
+
+	if len(backendSubMap) > 0 {
+		backendMap[backendType] = backendSubMap
+	}
+
+	configMap["backends"] = []map[string]interface{}{backendMap}
+
+	// Cache sizing
+
+	cacheLineSize, err = wizard.askUint64("cache_line_size (bytes)", initDefaultCacheLineSize)
+	if err != nil {
+		return
+	}
+	if cacheLineSize != initDefaultCacheLineSize {
+		configMap["cache_line_size"] = cacheLineSize
+	}
+
+	cacheLines = initDefaultCacheLines
+	if host.memTotal != 0 {
+		cacheLines = max(initMinCacheLines, host.memTotal/initCacheRAMFraction/cacheLineSize)
+		_, _ = fmt.Fprintf(w, "Detected %v MiB of RAM; suggesting cache_lines caching 1/%v of it\n", host.memTotal>>20, initCacheRAMFraction)
+	}
+	cacheLines, err = wizard.askUint64("cache_lines", cacheLines)
+	if err != nil {
+		return
+	}
+	configMap["cache_lines"] = cacheLines
+
+	// Render configMap per configFileExt
+
+	switch configFileExt {
+	case ".json":
+		jsonEncoder = json.NewEncoder(&configFileContent)
+		jsonEncoder.SetIndent("", "  ")
+		err = jsonEncoder.Encode(configMap)
+	case ".toml":
+		_, _ = configFileContent.WriteString("# Generated by msfs --init\n")
+		err = toml.NewEncoder(&configFileContent).Encode(configMap)
+	default:
+		_, _ = configFileContent.WriteString("# Generated by msfs --init\n")
+		yamlEncoder = yaml.NewEncoder(&configFileContent)
+		yamlEncoder.SetIndent(2)
+		err = yamlEncoder.Encode(configMap)
+		if err == nil {
+			err = yamlEncoder.Close()
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to render configuration: %v", err)
+		return
+	}
+
+	// Validate the configuration (as it would be parsed when mounting) before writing it
+
+	err = os.MkdirAll(filepath.Dir(configFilePath), 0o755)
+	if err != nil {
+		return
+	}
+
+	tmpConfigFile, err = os.CreateTemp(filepath.Dir(configFilePath), ".msfs-init-*"+configFileExt)
+	if err != nil {
+		return
+	}
+	tmpConfigFilePath = tmpConfigFile.Name()
+	defer func() {
+		if tmpConfigFilePath != "" {
+			_ = os.Remove(tmpConfigFilePath)
+		}
+	}()
+
+	_, err = tmpConfigFile.Write(configFileContent.Bytes())
+	if err == nil {
+		err = tmpConfigFile.Close()
+	} else {
+		_ = tmpConfigFile.Close()
+	}
+	if err != nil {
+		return
+	}
+
+	globals.configFilePath = tmpConfigFilePath
+	globals.configFileDefaultPaths = nil
+	globals.configOverrides = nil
+	globals.configProfile = ""
+	globals.config = nil
+	globals.backendsToUnmount = make(map[string]*backendStruct)
+	globals.backendsToMount = make(map[string]*backendStruct)
+	globals.backendsSkipped = make(map[string]struct{})
+
+	err = checkConfigFile()
+	if err != nil {
+		err = fmt.Errorf("generated configuration failed validation: %v", err)
+		return
+	}
+
+	ok, err = wizard.askBool("Check that the backend is reachable now?", true)
+	if err != nil {
+		return
+	}
+	if ok && !checkBackends(w) {
+		ok, err = wizard.askBool("Write the configuration file anyway?", false)
+		if err != nil {
+			return
+		}
+		if !ok {
+			err = errors.New("backend unreachable; configuration file not written")
+			return
+		}
+	}
+
+	err = os.Rename(tmpConfigFilePath, configFilePath)
+	if err != nil {
+		return
+	}
+	tmpConfigFilePath = ""
+
+	_, _ = fmt.Fprintf(w, "Wrote \"%s\"; mount it with: msfs %s\n", configFilePath, configFilePath)
+
+	return
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	var (
+		backend        *backendStruct
+		configFilePath string
+		err            error
+		ok             bool
+		output         bytes.Buffer
+		tmpDir         = t.TempDir()
+	)
+
+	t.Setenv("MSFS_MOUNTPOINT", "") // Would otherwise override the mountpoint answered
+
+	// A writable RAM backend (probed)
+
+	configFilePath = filepath.Join(tmpDir, "msfs", "config.yaml")
+
+	err = runInit(strings.NewReader(strings.Join([]string{
+		"/mnt/test", // mountpoint
+		"ram",       // backend_type (matched case-insensitively)
+		"",          // dir_name (defaulted to "ram")
+		"",          // bucket_container_name (required, so re-asked)
+		"bucket",    // bucket_container_name
+		"data",      // prefix (to which "/" is appended)
+		"maybe",     // readonly (neither y nor n, so re-asked)
+		"n",         // readonly
+		"",          // cache_line_size
+		"",          // cache_lines (suggested from RAM)
+		"y",         // check reachability
+	}, "\n")+"\n"), &output, configFilePath, &initHostStruct{memTotal: 64 << 30})
+	if err != nil {
+		t.Fatalf("runInit() failed: %v\noutput:\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), "backends[\"ram\"] (RAM ") || !strings.Contains(output.String(), "reachable") {
+		t.Fatalf("runInit() did not report the backend reachable:\n%s", output.String())
+	}
+
+	globals.configFilePath = configFilePath
+	globals.config = nil
+	globals.backendsToMount = make(map[string]*backendStruct)
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() of the written config-file failed: %v", err)
+	}
+
+	if globals.config.mountPoint != "/mnt/test" {
+		t.Fatalf("mountpoint was %q (expected \"/mnt/test\")", globals.config.mountPoint)
+	}
+	if globals.config.cacheLines != (64<<30)/initCacheRAMFraction/initDefaultCacheLineSize {
+		t.Fatalf("cache_lines was %v (expected 1/%v of RAM)", globals.config.cacheLines, initCacheRAMFraction)
+	}
+
+	backend, ok = globals.backendsToMount["ram"]
+	if !ok {
+		t.Fatalf("backends[\"ram\"] missing from the written config-file")
+	}
+	if (backend.bucketContainerName != "bucket") || (backend.prefix != "data/") || backend.readOnly {
+		t.Fatalf("backends[\"ram\"] was %q %q readonly:%v (expected \"bucket\" \"data/\" readonly:false)", backend.bucketContainerName, backend.prefix, backend.readOnly)
+	}
+
+	// An existing config-file is only overwritten if confirmed
+
+	output.Reset()
+
+	err = runInit(strings.NewReader("n\n"), &output, configFilePath, &initHostStruct{})
+	if err == nil {
+		t.Fatalf("runInit() unexpectedly overwrote an existing config-file")
+	}
+
+	// An S3 backend with static credentials written as JSON (not probed)
+
+	configFilePath = filepath.Join(tmpDir, "config.json")
+
+	output.Reset()
+
+	err = runInit(strings.NewReader(strings.Join([]string{
+		"",                      // mountpoint
+		"",                      // backend_type (defaulted to "S3")
+		"s3",                    // dir_name
+		"dev",                   // bucket_container_name
+		"",                      // prefix
+		"",                      // readonly
+		"http://localhost:9000", // endpoint
+		"us-west-2",             // region
+		"static",                // credentials source
+		"AKID",                  // access_key_id
+		"SECRET",                // secret_access_key
+		"65536",                 // cache_line_size
+		"1024",                  // cache_lines
+		"n",                     // check reachability
+	}, "\n")+"\n"), &output, configFilePath, &initHostStruct{})
+	if err != nil {
+		t.Fatalf("runInit() failed: %v\noutput:\n%s", err, output.String())
+	}
+
+	globals.configFilePath = configFilePath
+	globals.config = nil
+	globals.backendsToMount = make(map[string]*backendStruct)
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() of the written config-file failed: %v", err)
+	}
+
+	if (globals.config.cacheLineSize != 65536) || (globals.config.cacheLines != 1024) {
+		t.Fatalf("cache_line_size & cache_lines were %v & %v (expected 65536 & 1024)", globals.config.cacheLineSize, globals.config.cacheLines)
+	}
+
+	backend, ok = globals.backendsToMount["s3"]
+	if !ok || !backend.readOnly {
+		t.Fatalf("backends[\"s3\"] missing from (or not readonly in) the written config-file")
+	}
+
+	// Input ending before the configuration is complete writes nothing
+
+	configFilePath = filepath.Join(tmpDir, "partial.yaml")
+
+	err = runInit(strings.NewReader("/mnt\nS3\n"), &output, configFilePath, &initHostStruct{})
+	if err == nil {
+		t.Fatalf("runInit() with incomplete input unexpectedly succeeded")
+	}
+
+	_, err = os.Stat(configFilePath)
+	if !os.IsNotExist(err) {
+		t.Fatalf("runInit() with incomplete input left \"%s\" (err: %v)", configFilePath, err)
+	}
+}
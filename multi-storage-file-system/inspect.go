@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// `setupInspectBackends` is called, in lieu of mounting, after the configuration file has
+// been successfully parsed to set up each backend on the globals.backendsToMount list so
+// that it may be inspected (see inspectLs(), inspectStat(), and inspectCat()) directly
+// (i.e. without FUSE). A backend that cannot be set up is logged (and left without a
+// context) rather than preventing the inspection of the others. As the backends are then
+// accessed via the wrapper functions (e.g. listDirectoryWrapper()), their metrics, QoS
+// scheduler, and retry budget are also provisioned (if not already).
+func setupInspectBackends() {
+	var (
+		backend  *backendStruct
+		dirName  string
+		dirNames []string
+		err      error
+	)
+
+	if globals.secrets == nil {
+		globals.secrets = newSecrets()
+	}
+	if globals.backendMetrics == nil {
+		globals.backendMetrics = newBackendMetrics()
+	}
+	if globals.qosScheduler == nil {
+		globals.qosScheduler = newQoSScheduler(globals.config.maxConcurrentBackendRequests)
+	}
+	if globals.retryBudget == nil {
+		globals.retryBudget = newRetryBudget(globals.config.retryBudgetRatio, globals.config.retryBudgetMinPerSecond, globals.config.retryBudgetBurst)
+	}
+
+	dirNames = make([]string, 0, len(globals.backendsToMount))
+	for dirName = range globals.backendsToMount {
+		dirNames = append(dirNames, dirName)
+	}
+	slices.Sort(dirNames)
+
+	for _, dirName = range dirNames {
+		backend = globals.backendsToMount[dirName]
+
+		if backend.backendMetrics == nil {
+			backend.backendMetrics = newBackendMetrics()
+		}
+
+		err = backend.setupContext()
+		if err != nil {
+			backend.context = nil
+			globals.logger.Printf("[WARN] unable to setup backend context: %s (err: %v)", dirName, err)
+		}
+	}
+
+	globals.Lock()
+	refreshShardedAlreadyLocked()
+	refreshSnapshotsAlreadyLocked()
+	globals.Unlock()
+}
+
+// `inspectStatError` returns the error reporting that target could not be found per err
+// (returned by a statFile() or statDirectory() of it). Should err indicate not that target
+// is missing but rather that the backend failed to say (e.g. it was throttled or denied
+// access), that is what is reported so that a transient failure isn't mistaken for target
+// having been deleted.
+func inspectStatError(target string, notFound string, err error) error {
+	var (
+		errno syscall.Errno
+	)
+
+	errno = backendErrno(err)
+	if errno == syscall.ENOENT {
+		return fmt.Errorf("%s: %s", target, notFound)
+	}
+
+	return fmt.Errorf("%s: unable to stat (%s): %w", target, errnoName(errno), err)
+}
+
+// `inspectTarget` splits target (of the form <dir_name>[/<path>]) into the backend
+// named <dir_name> (which must have been set up by setupInspectBackends()) and the
+// path (relative to its prefix) without any trailing "/".
+func inspectTarget(target string) (backend *backendStruct, path string, err error) {
+	var (
+		dirName string
+		ok      bool
+	)
+
+	dirName, path, _ = strings.Cut(target, "/")
+	path = strings.TrimSuffix(path, "/")
+
+	backend, ok = globals.backendsToMount[dirName]
+	if !ok {
+		err = fmt.Errorf("no backend with dir_name \"%s\"", dirName)
+		return
+	}
+	if backend.context == nil {
+		err = fmt.Errorf("backend \"%s\" could not be set up", dirName)
+		return
+	}
+
+	return
+}
+
+// `inspectLs` reports to w each subdirectory and file of the directory identified by
+// target (of the form <dir_name>[/<path>]) as would `ls -l`.
+func inspectLs(w io.Writer, target string) (err error) {
+	var (
+		backend             *backendStruct
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		path                string
+		subdirectory        string
+	)
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+
+	listDirectoryInput = &listDirectoryInputStruct{}
+	if path != "" {
+		listDirectoryInput.dirPath = path + "/"
+
+		_, err = backend.context.statDirectory(&statDirectoryInputStruct{dirPath: listDirectoryInput.dirPath})
+		if err != nil {
+			err = inspectStatError(target, "no such directory", err)
+			return
+		}
+	}
+
+	for {
+		listDirectoryOutput, err = backend.context.listDirectory(listDirectoryInput)
+		if err != nil {
+			return
+		}
+
+		for _, subdirectory = range listDirectoryOutput.subdirectory {
+			_, _ = fmt.Fprintf(w, "%12s  %-20s  %s/\n", "-", "-", subdirectory)
+		}
+		for _, file := range listDirectoryOutput.file {
+			_, _ = fmt.Fprintf(w, "%12d  %-20s  %s\n", file.size, file.mTime.UTC().Format(time.RFC3339), file.basename)
+		}
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			return
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+}
+
+// `inspectStat` reports to w the metadata of the file or directory identified by
+// target (of the form <dir_name>[/<path>]).
+func inspectStat(w io.Writer, target string) (err error) {
+	var (
+		backend        *backendStruct
+		path           string
+		statFileError  error
+		statFileOutput *statFileOutputStruct
+	)
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+
+	if path != "" {
+		statFileOutput, err = backend.context.statFile(&statFileInputStruct{filePath: path})
+		if err == nil {
+			_, _ = fmt.Fprintf(w, "path:  %s\ntype:  file\nsize:  %d\nmtime: %s\netag:  %s\n", target, statFileOutput.size, statFileOutput.mTime.UTC().Format(time.RFC3339Nano), statFileOutput.eTag)
+			return
+		}
+
+		statFileError = err
+
+		_, err = backend.context.statDirectory(&statDirectoryInputStruct{dirPath: path + "/"})
+		if err != nil {
+			if backendErrno(err) == syscall.ENOENT {
+				err = statFileError
+			}
+			err = inspectStatError(target, "no such file or directory", err)
+			return
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "path:  %s\ntype:  directory\n", target)
+
+	return
+}
+
+// `inspectCat` writes to w the length bytes (if 0, through the end of the file) at offset
+// of the file identified by target (of the form <dir_name>/<path>). The file is read in
+// cache line sized ranges (per backend.pathSettings()) as it would be if mounted.
+func inspectCat(w io.Writer, target string, offset uint64, length uint64) (err error) {
+	var (
+		backend        *backendStruct
+		buf            []byte
+		cacheLineSize  uint64
+		end            uint64
+		lineNumber     uint64
+		lineOffset     uint64
+		path           string
+		readFileOutput *readFileOutputStruct
+		statFileOutput *statFileOutputStruct
+	)
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+	if path == "" {
+		err = fmt.Errorf("%s: is a directory", target)
+		return
+	}
+
+	statFileOutput, err = backend.context.statFile(&statFileInputStruct{filePath: path})
+	if err != nil {
+		err = fmt.Errorf("%s: no such file", target)
+		return
+	}
+
+	end = statFileOutput.size
+	if (length != 0) && (offset+length < end) {
+		end = offset + length
+	}
+
+	globals.Lock()
+	cacheLineSize = backend.pathSettings(path).cacheLineSize
+	globals.Unlock()
+
+	for offset < end {
+		lineNumber = offset / cacheLineSize
+		lineOffset = lineNumber * cacheLineSize
+
+		readFileOutput, err = backend.context.readFile(&readFileInputStruct{
+			filePath:        path,
+			offsetCacheLine: lineNumber,
+			cacheLineSize:   cacheLineSize,
+			ifMatch:         statFileOutput.eTag,
+		})
+		if err != nil {
+			return
+		}
+
+		buf = readFileOutput.buf
+		if uint64(len(buf)) <= (offset - lineOffset) {
+			err = errors.New("file shrank while being read")
+			return
+		}
+		buf = buf[offset-lineOffset : min(uint64(len(buf)), end-lineOffset)]
+
+		_, err = w.Write(buf)
+		if err != nil {
+			return
+		}
+
+		offset += uint64(len(buf))
+	}
+
+	return
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// `testThrottledContextStruct` overlays a backend's context such that every stat is throttled.
+type testThrottledContextStruct struct {
+	backendContextIf
+}
+
+func (testThrottledContext *testThrottledContextStruct) statDirectory(statDirectoryInput *statDirectoryInputStruct) (statDirectoryOutput *statDirectoryOutputStruct, err error) {
+	err = testS3ResponseError(503)
+	return
+}
+
+func (testThrottledContext *testThrottledContextStruct) statFile(statFileInput *statFileInputStruct) (statFileOutput *statFileOutputStruct, err error) {
+	err = testS3ResponseError(503)
+	return
+}
+
+func TestInspect(t *testing.T) {
+	var (
+		backend *backendStruct
+		err     error
+		output  bytes.Buffer
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_line_size: 4
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	for filePath, content := range map[string]string{
+		"fileA":       "0123456789",
+		"dir1/fileB":  "abc",
+		"dir1/fileC":  "",
+		"dir1/dir2/x": "x",
+	} {
+		_, err = globals.backendsToMount["ram"].context.writeFile(&writeFileInputStruct{filePath: filePath, buf: []byte(content)})
+		if err != nil {
+			t.Fatalf("writeFile(\"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	err = inspectLs(&output, "ram/dir1/")
+	if err != nil {
+		t.Fatalf("inspectLs(\"ram/dir1/\") failed: %v", err)
+	}
+	if !strings.HasPrefix(output.String(), "           -  -                     dir2/\n") {
+		t.Fatalf("inspectLs(\"ram/dir1/\") lacks dir2/:\n%s", output.String())
+	}
+	if !strings.Contains(output.String(), "           3  ") || !strings.HasSuffix(output.String(), "  fileC\n") || (strings.Count(output.String(), "\n") != 3) {
+		t.Fatalf("inspectLs(\"ram/dir1/\") unexpected:\n%s", output.String())
+	}
+
+	output.Reset()
+	err = inspectLs(&output, "ram")
+	if (err != nil) || !strings.Contains(output.String(), "  dir1/\n") || !strings.Contains(output.String(), "  fileA\n") {
+		t.Fatalf("inspectLs(\"ram\") unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	err = inspectLs(&output, "ram/dirZ")
+	if err == nil {
+		t.Fatalf("inspectLs(\"ram/dirZ\") should have failed")
+	}
+
+	output.Reset()
+	err = inspectStat(&output, "ram/fileA")
+	if (err != nil) || !strings.Contains(output.String(), "type:  file\nsize:  10\n") {
+		t.Fatalf("inspectStat(\"ram/fileA\") unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	output.Reset()
+	err = inspectStat(&output, "ram/dir1")
+	if (err != nil) || (output.String() != "path:  ram/dir1\ntype:  directory\n") {
+		t.Fatalf("inspectStat(\"ram/dir1\") unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	err = inspectStat(&output, "ram/fileZ")
+	if err == nil {
+		t.Fatalf("inspectStat(\"ram/fileZ\") should have failed")
+	}
+
+	// With cache_line_size of 4, byte ranges span multiple cache lines
+
+	for _, testCase := range []struct {
+		offset   uint64
+		length   uint64
+		expected string
+	}{
+		{0, 0, "0123456789"},
+		{3, 0, "3456789"},
+		{3, 6, "345678"},
+		{8, 100, "89"},
+		{10, 0, ""},
+		{20, 0, ""},
+	} {
+		output.Reset()
+		err = inspectCat(&output, "ram/fileA", testCase.offset, testCase.length)
+		if (err != nil) || (output.String() != testCase.expected) {
+			t.Fatalf("inspectCat(\"ram/fileA\",%v,%v) returned \"%s\" (err: %v) (expected \"%s\")", testCase.offset, testCase.length, output.String(), err, testCase.expected)
+		}
+	}
+
+	err = inspectCat(&output, "ram/dir1", 0, 0)
+	if err == nil {
+		t.Fatalf("inspectCat(\"ram/dir1\") should have failed")
+	}
+
+	err = inspectLs(&output, "none")
+	if (err == nil) || !strings.Contains(err.Error(), "no backend") {
+		t.Fatalf("inspectLs(\"none\") returned err: %v", err)
+	}
+
+	// A throttled stat must not be reported as if its target were missing
+
+	backend = globals.backendsToMount["ram"]
+	backend.context = &testThrottledContextStruct{backendContextIf: backend.context}
+
+	err = inspectLs(&output, "ram/dir1")
+	if (err == nil) || strings.Contains(err.Error(), "no such") || !strings.Contains(err.Error(), "EAGAIN") {
+		t.Fatalf("throttled inspectLs(\"ram/dir1\") returned err: %v", err)
+	}
+
+	err = inspectStat(&output, "ram/fileA")
+	if (err == nil) || strings.Contains(err.Error(), "no such") || !strings.Contains(err.Error(), "EAGAIN") {
+		t.Fatalf("throttled inspectStat(\"ram/fileA\") returned err: %v", err)
+	}
+
+	backend.context = backend.context.(*testThrottledContextStruct).backendContextIf
+
+	err = inspectStat(&output, "ram/fileZ")
+	if (err == nil) || !strings.Contains(err.Error(), "no such file or directory") {
+		t.Fatalf("inspectStat(\"ram/fileZ\") returned err: %v", err)
+	}
+}
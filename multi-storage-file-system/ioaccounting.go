@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+const (
+	ioAccountingMaxEntries = 10000 // Of each of ioAccountingStruct.by{Inode|PID|UID} (beyond which the least read is forgotten)
+	ioAccountingDefaultTop = 10    // Entries of each reported by GET /io lacking ?top=
+)
+
+// `ioAccountingStruct` accumulates the I/O performed by FUSE reads per inode, per
+// requesting PID, and per requesting UID such that the heaviest may be reported.
+type ioAccountingStruct struct {
+	sync.Mutex                            // Serializes record(), top(), and reset()
+	byInode    map[uint64]*ioCountsStruct // Key: inodeStruct.inodeNumber
+	byPID      map[uint64]*ioCountsStruct // Key: fission.InHeader.PID
+	byUID      map[uint64]*ioCountsStruct // Key: fission.InHeader.UID
+}
+
+// `ioCountsStruct` is the I/O accumulated for an inode, PID, or UID (and an element of
+// the corresponding "inodes", "pids", or "uids" of the response to GET /io).
+type ioCountsStruct struct {
+	Inode       uint64 `json:"inode,omitempty"`   // Only for an inode
+	Backend     string `json:"backend,omitempty"` // Only for an inode
+	Path        string `json:"path,omitempty"`    // Only for an inode
+	PID         uint32 `json:"pid,omitempty"`     // Only for a PID
+	UID         uint32 `json:"uid"`               // For an inode or PID, of the most recent read
+	Reads       uint64 `json:"reads"`
+	BytesRead   uint64 `json:"bytes_read"`
+	CacheMisses uint64 `json:"cache_misses"` // Cache lines fetched from the backend to satisfy the reads
+}
+
+// `ioTopStruct` is the response to GET /io.
+type ioTopStruct struct {
+	Inodes []*ioCountsStruct `json:"inodes"`
+	PIDs   []*ioCountsStruct `json:"pids"`
+	UIDs   []*ioCountsStruct `json:"uids"`
+}
+
+// `newIOAccounting` returns an empty ioAccountingStruct.
+func newIOAccounting() (ioAccounting *ioAccountingStruct) {
+	ioAccounting = &ioAccountingStruct{}
+	ioAccounting.reset()
+
+	return
+}
+
+// `reset` forgets all I/O accumulated so far.
+func (ioAccounting *ioAccountingStruct) reset() {
+	ioAccounting.Lock()
+	ioAccounting.byInode = make(map[uint64]*ioCountsStruct)
+	ioAccounting.byPID = make(map[uint64]*ioCountsStruct)
+	ioAccounting.byUID = make(map[uint64]*ioCountsStruct)
+	ioAccounting.Unlock()
+}
+
+// `record` accumulates a read of bytes from inode (requiring cacheMisses cache lines to
+// be fetched) on behalf of the caller described by inHeader. Must be called without
+// globals.Lock() held.
+func (ioAccounting *ioAccountingStruct) record(inHeader *fission.InHeader, inode *inodeStruct, bytes uint64, cacheMisses uint64) {
+	var (
+		counts *ioCountsStruct
+	)
+
+	ioAccounting.Lock()
+	defer ioAccounting.Unlock()
+
+	counts = ioCountsFetch(ioAccounting.byInode, inode.inodeNumber, func() *ioCountsStruct {
+		counts := &ioCountsStruct{Inode: inode.inodeNumber, Path: inode.objectPath}
+		if inode.backend != nil {
+			counts.Backend = inode.backend.dirName
+		}
+		return counts
+	})
+	counts.add(inHeader.UID, bytes, cacheMisses)
+
+	counts = ioCountsFetch(ioAccounting.byPID, uint64(inHeader.PID), func() *ioCountsStruct {
+		return &ioCountsStruct{PID: inHeader.PID}
+	})
+	counts.add(inHeader.UID, bytes, cacheMisses)
+
+	counts = ioCountsFetch(ioAccounting.byUID, uint64(inHeader.UID), func() *ioCountsStruct {
+		return &ioCountsStruct{}
+	})
+	counts.add(inHeader.UID, bytes, cacheMisses)
+}
+
+// `ioCountsFetch` returns the entry of m for key, inserting (should it be absent) the one
+// returned by newCounts. Should m have reached ioAccountingMaxEntries, the entry having read
+// the fewest bytes is first removed to make room.
+func ioCountsFetch(m map[uint64]*ioCountsStruct, key uint64, newCounts func() *ioCountsStruct) (counts *ioCountsStruct) {
+	var (
+		candidateKey uint64
+		leastKey     uint64
+		ok           bool
+	)
+
+	counts, ok = m[key]
+	if ok {
+		return
+	}
+
+	if len(m) >= ioAccountingMaxEntries {
+		ok = false
+		for candidateKey, counts = range m {
+			if !ok || (counts.BytesRead < m[leastKey].BytesRead) {
+				ok = true
+				leastKey = candidateKey
+			}
+		}
+		delete(m, leastKey)
+	}
+
+	counts = newCounts()
+	m[key] = counts
+
+	return
+}
+
+// `add` accumulates a read of bytes requiring cacheMisses cache lines to be fetched on
+// behalf of uid.
+func (counts *ioCountsStruct) add(uid uint32, bytes uint64, cacheMisses uint64) {
+	counts.UID = uid
+	counts.Reads++
+	counts.BytesRead += bytes
+	counts.CacheMisses += cacheMisses
+}
+
+// `top` returns (copies of) the n inodes, PIDs, and UIDs having read the most bytes.
+func (ioAccounting *ioAccountingStruct) top(n int) (ioTop *ioTopStruct) {
+	ioAccounting.Lock()
+	defer ioAccounting.Unlock()
+
+	ioTop = &ioTopStruct{
+		Inodes: ioCountsTop(ioAccounting.byInode, n),
+		PIDs:   ioCountsTop(ioAccounting.byPID, n),
+		UIDs:   ioCountsTop(ioAccounting.byUID, n),
+	}
+
+	return
+}
+
+// `ioCountsTop` returns copies of the (up to) n entries of m having read the most bytes
+// (in decreasing order).
+func ioCountsTop(m map[uint64]*ioCountsStruct, n int) (top []*ioCountsStruct) {
+	var (
+		counts *ioCountsStruct
+	)
+
+	top = make([]*ioCountsStruct, 0, len(m))
+
+	for _, counts = range m {
+		countsCopy := *counts
+		top = append(top, &countsCopy)
+	}
+
+	slices.SortFunc(top, func(a, b *ioCountsStruct) int {
+		return cmp.Compare(b.BytesRead, a.BytesRead)
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return
+}
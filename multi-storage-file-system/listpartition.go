@@ -0,0 +1,171 @@
+package main
+
+import (
+	"sync"
+)
+
+const (
+	listPartitionsMax = uint64(256)
+)
+
+// `listPartitionBoundaries` are the leading characters (of paths relative to the directory
+// being listed) at which listDirectoryPartitioned() splits the key space. Paths beginning with
+// other characters are nonetheless listed (by whichever partition spans them).
+const listPartitionBoundaries = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// `listPartitionStruct` is the portion of a directory listed by one of the concurrent
+// listings issued by listDirectoryPartitioned(): those entries whose key (the path relative
+// to the directory, with a trailing "/" for subdirectories) is in (startAfter, endAt].
+type listPartitionStruct struct {
+	startAfter string
+	endAt      string // If == "", the partition extends to the end of the directory
+	output     *listDirectoryOutputStruct
+	err        error
+}
+
+// `listDirectoryPartitioned` performs listDirectoryInput via listDirectoryWrapper(). Should
+// list_partitions > 1 and the first page of a directory's listing be truncated, the rest of the
+// directory is instead listed by up to list_partitions concurrent listings each starting after
+// a different listPartitionBoundaries character and the result returned as a single page
+// (i.e. with isTruncated == false) containing the entire directory.
+func (backend *backendStruct) listDirectoryPartitioned(listDirectoryInput *listDirectoryInputStruct) (listDirectoryOutput *listDirectoryOutputStruct, err error) {
+	var (
+		boundaries     []string
+		boundaryIndex  int
+		lastKey        string
+		partition      *listPartitionStruct
+		partitionGroup sync.WaitGroup
+		partitions     []*listPartitionStruct
+		partitionsMax  int
+		subdirectory   string
+	)
+
+	listDirectoryOutput, err = listDirectoryWrapper(backend.context, listDirectoryInput)
+	if (err != nil) || (backend.listPartitions <= 1) || (listDirectoryInput.continuationToken != "") || !listDirectoryOutput.isTruncated {
+		return
+	}
+
+	// Partition the key space beyond the last key of the first page (ignoring any
+	// synthesized s3VersionsDirName as it is not among the keys actually listed)
+
+	for _, subdirectory = range listDirectoryOutput.subdirectory {
+		if subdirectory != s3VersionsDirName {
+			lastKey = max(lastKey, subdirectory+"/")
+		}
+	}
+	for _, file := range listDirectoryOutput.file {
+		lastKey = max(lastKey, file.basename)
+	}
+
+	if lastKey == "" {
+		return
+	}
+
+	for boundaryIndex = range len(listPartitionBoundaries) {
+		if listPartitionBoundaries[boundaryIndex:boundaryIndex+1] > lastKey {
+			boundaries = append(boundaries, listPartitionBoundaries[boundaryIndex:boundaryIndex+1])
+		}
+	}
+
+	partitionsMax = min(int(backend.listPartitions), len(boundaries)+1)
+
+	partitions = []*listPartitionStruct{{startAfter: lastKey}}
+
+	for boundaryIndex = 1; boundaryIndex < partitionsMax; boundaryIndex++ {
+		partition = partitions[len(partitions)-1]
+		partition.endAt = boundaries[(boundaryIndex*len(boundaries))/partitionsMax]
+		partitions = append(partitions, &listPartitionStruct{startAfter: partition.endAt})
+	}
+
+	for _, partition = range partitions {
+		partitionGroup.Add(1)
+		go func(partition *listPartitionStruct) {
+			partition.output, partition.err = backend.listPartition(listDirectoryInput, partition.startAfter, partition.endAt)
+			partitionGroup.Done()
+		}(partition)
+	}
+
+	partitionGroup.Wait()
+
+	// Append each partition's entries (in order) to those of the first page
+
+	for _, partition = range partitions {
+		if partition.err != nil {
+			listDirectoryOutput = nil
+			err = partition.err
+			return
+		}
+
+		listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, partition.output.subdirectory...)
+		listDirectoryOutput.file = append(listDirectoryOutput.file, partition.output.file...)
+	}
+
+	listDirectoryOutput.nextContinuationToken = ""
+	listDirectoryOutput.isTruncated = false
+
+	return
+}
+
+// `listPartition` lists (page by page) those entries of listDirectoryInput.dirPath whose
+// key is in (startAfter, endAt] (or, if endAt == "", simply after startAfter).
+func (backend *backendStruct) listPartition(listDirectoryInput *listDirectoryInputStruct, startAfter string, endAt string) (partitionOutput *listDirectoryOutputStruct, err error) {
+	var (
+		done                bool
+		key                 string
+		listDirectoryOutput *listDirectoryOutputStruct
+		partitionInput      = &listDirectoryInputStruct{
+			maxItems:   listDirectoryInput.maxItems,
+			dirPath:    listDirectoryInput.dirPath,
+			bulk:       listDirectoryInput.bulk,
+			startAfter: startAfter,
+		}
+		subdirectory string
+	)
+
+	partitionOutput = &listDirectoryOutputStruct{
+		subdirectory: make([]string, 0),
+		file:         make([]listDirectoryOutputFileStruct, 0),
+	}
+
+	// Note that, as each page may extend beyond endAt, done is only
+	// acted upon once the entirety of the page has been examined
+
+	for !done {
+		listDirectoryOutput, err = listDirectoryWrapper(backend.context, partitionInput)
+		if err != nil {
+			return
+		}
+
+		for _, subdirectory = range listDirectoryOutput.subdirectory {
+			key = subdirectory + "/"
+			switch {
+			case key <= startAfter:
+				// Already listed by the previous partition (or the first page)
+			case (endAt != "") && (key > endAt):
+				done = true
+			default:
+				partitionOutput.subdirectory = append(partitionOutput.subdirectory, subdirectory)
+			}
+		}
+
+		for _, file := range listDirectoryOutput.file {
+			key = file.basename
+			switch {
+			case key <= startAfter:
+				// Already listed by the previous partition (or the first page)
+			case (endAt != "") && (key > endAt):
+				done = true
+			default:
+				partitionOutput.file = append(partitionOutput.file, file)
+			}
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			done = true
+		}
+
+		partitionInput.continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+
+	return
+}
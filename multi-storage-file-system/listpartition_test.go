@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// `testListObjectsV2ResultStruct` is the (partial) response of the fake S3 ListObjectsV2
+// served by testListObjectsV2Handler().
+type testListObjectsV2ResultStruct struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Name                  string   `xml:"Name"`
+	Prefix                string   `xml:"Prefix"`
+	KeyCount              int      `xml:"KeyCount"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	NextContinuationToken string   `xml:"NextContinuationToken,omitempty"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// `testListObjectsV2Handler` returns an http.HandlerFunc serving ListObjectsV2 (honoring
+// prefix, delimiter "/", start-after, continuation-token, & max-keys) of the sorted keys
+// and recording the start-after of each request.
+func testListObjectsV2Handler(keys []string, startAfters *[]string, startAftersLock *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			after    string
+			key      string
+			maxKeys  = 1000
+			prefix   = r.URL.Query().Get("prefix")
+			result   = &testListObjectsV2ResultStruct{Name: "dev", Prefix: prefix}
+			returned string
+		)
+
+		startAftersLock.Lock()
+		*startAfters = append(*startAfters, r.URL.Query().Get("start-after"))
+		startAftersLock.Unlock()
+
+		if r.URL.Query().Get("max-keys") != "" {
+			maxKeys, _ = strconv.Atoi(r.URL.Query().Get("max-keys"))
+		}
+
+		after = r.URL.Query().Get("start-after")
+		if r.URL.Query().Get("continuation-token") != "" {
+			after = r.URL.Query().Get("continuation-token")
+		}
+
+		for _, key = range keys {
+			if !strings.HasPrefix(key, prefix) || (key <= after) || (strings.HasSuffix(returned, "/") && strings.HasPrefix(key, returned)) {
+				continue
+			}
+
+			if result.KeyCount == maxKeys {
+				result.IsTruncated = true
+				result.NextContinuationToken = returned
+				break
+			}
+
+			slashIndex := strings.Index(key[len(prefix):], "/")
+			if slashIndex >= 0 {
+				returned = key[:len(prefix)+slashIndex+1]
+				if strings.HasPrefix(after, returned) {
+					continue
+				}
+				result.CommonPrefixes = append(result.CommonPrefixes, struct {
+					Prefix string `xml:"Prefix"`
+				}{returned})
+			} else {
+				returned = key
+				result.Contents = append(result.Contents, struct {
+					Key          string `xml:"Key"`
+					LastModified string `xml:"LastModified"`
+					ETag         string `xml:"ETag"`
+					Size         int    `xml:"Size"`
+				}{key, "2024-01-01T00:00:00.000Z", "\"etag\"", len(key)})
+			}
+
+			result.KeyCount++
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(result)
+	}
+}
+
+func TestListDirectoryPartitioned(t *testing.T) {
+	var (
+		backend             *backendStruct
+		err                 error
+		expected            []string
+		httpServer          *httptest.Server
+		keys                []string
+		listDirectoryOutput *listDirectoryOutputStruct
+		listed              []string
+		startAfters         []string
+		startAftersLock     sync.Mutex
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	// A directory of files and subdirectories (the latter each with a few files) spanning
+	// (and beyond) listPartitionBoundaries as well as a file outside of it
+
+	for _, leading := range []string{"+", "0", "5", "A", "M", "Z", "a", "m", "z", "~"} {
+		for index := range 10 {
+			keys = append(keys, fmt.Sprintf("dir/%s%02d", leading, index))
+			expected = append(expected, fmt.Sprintf("%s%02d", leading, index))
+		}
+		for _, basename := range []string{"0", "1"} {
+			keys = append(keys, fmt.Sprintf("dir/%s/%s", leading, basename))
+		}
+		expected = append(expected, leading+"/")
+	}
+	keys = append(keys, "other")
+
+	slices.Sort(keys)
+	slices.Sort(expected)
+
+	httpServer = httptest.NewServer(testListObjectsV2Handler(keys, &startAfters, &startAftersLock))
+	defer httpServer.Close()
+
+	backend = &backendStruct{
+		dirName:             "s3",
+		bucketContainerName: "dev",
+		directoryPageSize:   7,
+		listPartitions:      4,
+		backendMetrics:      newBackendMetrics(),
+		backendTypeSpecifics: &backendConfigS3Struct{
+			accessKeyID:     "accessKeyID",
+			secretAccessKey: "secretAccessKey",
+			region:          "us-east-1",
+			endpoint:        httpServer.URL,
+			retryMode:       S3RetryModeStandard,
+			retryAttempts:   1,
+		},
+	}
+
+	err = backend.setupS3Context()
+	if err != nil {
+		t.Fatalf("setupS3Context() failed: %v", err)
+	}
+
+	list := func() (listed []string) {
+		startAfters = nil
+
+		listDirectoryOutput, err = backend.listDirectoryPartitioned(&listDirectoryInputStruct{
+			maxItems: backend.directoryPageSize,
+			dirPath:  "dir/",
+		})
+		if err != nil {
+			t.Fatalf("listDirectoryPartitioned() failed: %v", err)
+		}
+
+		for _, subdirectory := range listDirectoryOutput.subdirectory {
+			listed = append(listed, subdirectory+"/")
+		}
+		for _, file := range listDirectoryOutput.file {
+			listed = append(listed, file.basename)
+		}
+
+		if !slices.IsSorted(listDirectoryOutput.subdirectory) || !slices.IsSortedFunc(listDirectoryOutput.file, func(a, b listDirectoryOutputFileStruct) int { return strings.Compare(a.basename, b.basename) }) {
+			t.Errorf("listDirectoryPartitioned() returned out of order entries: %v", listed)
+		}
+
+		slices.Sort(listed)
+
+		return
+	}
+
+	// The entire directory is returned as a single page (each entry exactly once) having
+	// been listed by list_partitions concurrent listings after the first page
+
+	listed = list()
+	if listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken != "") || !slices.Equal(listed, expected) {
+		t.Fatalf("listDirectoryPartitioned() returned %v (isTruncated: %v) (expected %v)", listed, listDirectoryOutput.isTruncated, expected)
+	}
+
+	slices.Sort(startAfters)
+	startAfters = slices.Compact(startAfters)
+	if len(startAfters) != 5 {
+		t.Fatalf("listDirectoryPartitioned() listed starting after %q (expected \"\" and 4 partitions)", startAfters)
+	}
+
+	// Without list_partitions, only the first page is returned
+
+	backend.listPartitions = 0
+
+	listDirectoryOutput, err = backend.listDirectoryPartitioned(&listDirectoryInputStruct{
+		maxItems: backend.directoryPageSize,
+		dirPath:  "dir/",
+	})
+	if (err != nil) || !listDirectoryOutput.isTruncated || (len(listDirectoryOutput.subdirectory)+len(listDirectoryOutput.file) != 7) {
+		t.Fatalf("listDirectoryPartitioned() without list_partitions returned %+v, %v", listDirectoryOutput, err)
+	}
+
+	// More list_partitions than remaining listPartitionBoundaries are limited to the latter
+
+	backend.listPartitions = listPartitionsMax
+
+	listed = list()
+	if !slices.Equal(listed, expected) {
+		t.Fatalf("listDirectoryPartitioned() with list_partitions %v returned %v (expected %v)", listPartitionsMax, listed, expected)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// `logLevelFileCheckInterval` is how often log_level_file is checked for changes.
+const logLevelFileCheckInterval = 1 * time.Second
+
+// `logLevelOverrideStruct` is a level set at runtime (via the /log_level endpoint or
+// log_level_file) in place of the configured log_level or log_levels[subsystem].
+type logLevelOverrideStruct struct {
+	level  int         // Index into logLevels
+	expiry time.Time   // If !.IsZero(), when the override reverts
+	timer  *time.Timer // If expiry is set, fires to revert the override
+}
+
+// `setOverride` sets level as the least severe level logged for subsystem (or, if "",
+// for all subsystems) until, if duration != 0, it reverts after duration.
+func (logSink *logSinkStruct) setOverride(subsystem string, level string, duration time.Duration) (err error) {
+	var (
+		levelIndex int
+		ok         bool
+		override   *logLevelOverrideStruct
+	)
+
+	levelIndex, ok = logLevelIndex(level)
+	if !ok {
+		err = fmt.Errorf("bad level: \"%s\"", level)
+		return
+	}
+
+	subsystem = strings.ToLower(subsystem)
+
+	override = &logLevelOverrideStruct{level: levelIndex}
+
+	logSink.Lock()
+
+	logSink.stopOverrideAlreadyLocked(subsystem)
+
+	if duration != 0 {
+		override.expiry = time.Now().Add(duration)
+		override.timer = time.AfterFunc(duration, func() {
+			logSink.Lock()
+			expired := logSink.overrides[subsystem] == override
+			if expired {
+				delete(logSink.overrides, subsystem)
+			}
+			logSink.Unlock()
+
+			if expired {
+				globals.logger.Printf("[INFO] temporary log level \"%s\" for %s reverted", logLevels[override.level], logLevelOverrideScope(subsystem))
+			}
+		})
+	}
+
+	logSink.overrides[subsystem] = override
+
+	logSink.Unlock()
+
+	return
+}
+
+// `resetOverrides` removes each level set at runtime (reverting to the configured levels).
+func (logSink *logSinkStruct) resetOverrides() {
+	var (
+		subsystem string
+	)
+
+	logSink.Lock()
+
+	for subsystem = range logSink.overrides {
+		logSink.stopOverrideAlreadyLocked(subsystem)
+	}
+
+	logSink.Unlock()
+}
+
+// `stopOverrideAlreadyLocked` is called while logSink.Lock() is held to remove any
+// override for subsystem (stopping its revert timer).
+func (logSink *logSinkStruct) stopOverrideAlreadyLocked(subsystem string) {
+	var (
+		ok       bool
+		override *logLevelOverrideStruct
+	)
+
+	override, ok = logSink.overrides[subsystem]
+	if ok {
+		if override.timer != nil {
+			_ = override.timer.Stop()
+		}
+		delete(logSink.overrides, subsystem)
+	}
+}
+
+// `applyLogLevelQuery` applies the query parameters of a /log_level request (or of a line
+// of log_level_file): reset=true removes all overrides, while level=<level> (with optional
+// subsystem=<subsystem> and duration=<milliseconds>) sets one.
+func (logSink *logSinkStruct) applyLogLevelQuery(query url.Values) (err error) {
+	var (
+		duration             time.Duration
+		durationMilliseconds uint64
+		reset                bool
+	)
+
+	if query.Get("reset") != "" {
+		reset, err = strconv.ParseBool(query.Get("reset"))
+		if err != nil {
+			err = fmt.Errorf("bad reset: %v", err)
+			return
+		}
+	}
+
+	if query.Get("level") == "" {
+		if !reset {
+			err = errors.New("level or reset=true required")
+		} else {
+			logSink.resetOverrides()
+		}
+		return
+	}
+
+	if query.Get("duration") != "" {
+		durationMilliseconds, err = strconv.ParseUint(query.Get("duration"), 10, 64)
+		if err != nil {
+			err = fmt.Errorf("bad duration: %v", err)
+			return
+		}
+		duration = time.Duration(durationMilliseconds) * time.Millisecond
+	}
+
+	if reset {
+		logSink.resetOverrides()
+	}
+
+	err = logSink.setOverride(query.Get("subsystem"), query.Get("level"), duration)
+
+	return
+}
+
+// `checkLogLevelFile` applies the contents of filePath (i.e. log_level_file) should they
+// have changed since last checked. Each non-empty line not starting with "#" holds the
+// query parameters of a /log_level request (e.g. "level=debug&duration=600000"). As the
+// contents replace all overrides, removing or emptying the file reverts to the configured
+// levels.
+func (logSink *logSinkStruct) checkLogLevelFile(filePath string) {
+	var (
+		content []byte
+		err     error
+		line    string
+		query   url.Values
+	)
+
+	content, err = os.ReadFile(filePath)
+	if (err != nil) && !errors.Is(err, os.ErrNotExist) {
+		globals.logger.Printf("[WARN] unable to read log_level_file (\"%s\"): %v", filePath, err)
+		return
+	}
+
+	logSink.Lock()
+	if bytes.Equal(content, logSink.logLevelFile) && ((content == nil) == (logSink.logLevelFile == nil)) {
+		logSink.Unlock()
+		return
+	}
+	logSink.logLevelFile = content
+	logSink.Unlock()
+
+	logSink.resetOverrides()
+
+	for _, line = range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if (line == "") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		query, err = url.ParseQuery(line)
+		if err == nil {
+			err = logSink.applyLogLevelQuery(query)
+		}
+		if err != nil {
+			globals.logger.Printf("[WARN] ignoring log_level_file (\"%s\") line \"%s\": %v", filePath, line, err)
+		}
+	}
+
+	globals.logger.Printf("[INFO] log_level_file (\"%s\") applied", filePath)
+}
+
+// `describe` outputs the configured levels followed by any runtime overrides.
+func (logSink *logSinkStruct) describe(w io.Writer) {
+	var (
+		override   *logLevelOverrideStruct
+		subsystem  string
+		subsystems []string
+	)
+
+	logSink.Lock()
+	defer logSink.Unlock()
+
+	fmt.Fprintf(w, "log_level: %s\n", logLevels[logSink.level])
+
+	for subsystem = range logSink.subsystemLevels {
+		subsystems = append(subsystems, subsystem)
+	}
+	slices.Sort(subsystems)
+	for _, subsystem = range subsystems {
+		fmt.Fprintf(w, "log_levels[%s]: %s\n", subsystem, logLevels[logSink.subsystemLevels[subsystem]])
+	}
+
+	subsystems = subsystems[:0]
+	for subsystem = range logSink.overrides {
+		subsystems = append(subsystems, subsystem)
+	}
+	slices.Sort(subsystems)
+	for _, subsystem = range subsystems {
+		override = logSink.overrides[subsystem]
+		if override.expiry.IsZero() {
+			fmt.Fprintf(w, "override for %s: %s\n", logLevelOverrideScope(subsystem), logLevels[override.level])
+		} else {
+			fmt.Fprintf(w, "override for %s: %s (until %s)\n", logLevelOverrideScope(subsystem), logLevels[override.level], override.expiry.Format(time.RFC3339))
+		}
+	}
+}
+
+// `logLevelOverrideScope` describes the subsystem(s) to which an override applies.
+func logLevelOverrideScope(subsystem string) string {
+	if subsystem == "" {
+		return "all subsystems"
+	}
+	return fmt.Sprintf("subsystem \"%s\"", subsystem)
+}
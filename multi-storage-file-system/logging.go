@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logFormatText   = "text"   // "2006/01/02 15:04:05 [LEVEL] [subsystem] msg" (as emitted by log.New(,,log.Ldate|log.Ltime|log.Lmsgprefix))
+	logFormatJSON   = "json"   // {"time":...,"level":...,"subsystem":...,"backend":...,"op":...,"path":...,"msg":...}
+	logFormatLogfmt = "logfmt" // time=... level=... subsystem=... backend=... op=... path=... msg=...
+
+	logTimeFormatText       = "2006/01/02 15:04:05"
+	logTimeFormatStructured = time.RFC3339Nano
+)
+
+// `logLevels` enumerates, in increasing order of severity, the levels that may
+// lead off a logged line (e.g. "[WARN] ..."). A line without one is at "info".
+var logLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// `logSinkStruct` is the io.Writer beneath globals.logger (and the loggers derived from it).
+// Each line written is expected to follow the "[LEVEL] [subsystem] <dirName> msg" convention
+// from which the fields of structured output are extracted and against whose level each is
+// filtered. Lines at "fatal" are never filtered.
+type logSinkStruct struct {
+	sync.Mutex                                         //
+	out             io.Writer                          //
+	format          string                             // One of logFormat*
+	level           int                                // Index into logLevels below which lines not otherwise covered by subsystemLevels are dropped
+	subsystemLevels map[string]int                     // Key: lower-cased subsystem (e.g. "mirror" or "fission"); Value: index into logLevels
+	backendNames    map[string]struct{}                // Key: backendStruct.dirName
+	overrides       map[string]*logLevelOverrideStruct // Key: lower-cased subsystem ("" for all subsystems); set at runtime (see logctl.go)
+	logLevelFile    []byte                             // Contents of log_level_file last applied (nil if absent)
+}
+
+// `logLineStruct` holds the fields extracted from a logged line.
+type logLineStruct struct {
+	level     string
+	subsystem string
+	backend   string
+	op        string
+	path      string
+	msg       string
+}
+
+// `newLogSink` returns a logSinkStruct emitting every line in logFormatText to out.
+func newLogSink(out io.Writer) (logSink *logSinkStruct) {
+	logSink = &logSinkStruct{
+		out:             out,
+		format:          logFormatText,
+		level:           0,
+		subsystemLevels: make(map[string]int),
+		backendNames:    make(map[string]struct{}),
+		overrides:       make(map[string]*logLevelOverrideStruct),
+	}
+
+	return
+}
+
+// `logLevelIndex` returns the index into logLevels of level (case insensitively).
+func logLevelIndex(level string) (index int, ok bool) {
+	for index = range logLevels {
+		if strings.EqualFold(level, logLevels[index]) {
+			ok = true
+			return
+		}
+	}
+
+	ok = false
+	return
+}
+
+// `configure` applies config's log_format, log_level, and log_levels settings and
+// records the names of its backends (so that they may be recognized in logged lines).
+// A nil logSink (as when globals.logger was set directly) is left unconfigured.
+func (logSink *logSinkStruct) configure(config *configStruct) {
+	var (
+		dirName   string
+		level     string
+		subsystem string
+	)
+
+	if logSink == nil {
+		return
+	}
+
+	logSink.Lock()
+	defer logSink.Unlock()
+
+	logSink.format = config.logFormat
+	logSink.level, _ = logLevelIndex(config.logLevel)
+
+	logSink.subsystemLevels = make(map[string]int, len(config.logLevels))
+	for subsystem, level = range config.logLevels {
+		logSink.subsystemLevels[strings.ToLower(subsystem)], _ = logLevelIndex(level)
+	}
+
+	logSink.backendNames = make(map[string]struct{}, len(config.backends))
+	for dirName = range config.backends {
+		logSink.backendNames[dirName] = struct{}{}
+	}
+}
+
+// `Write` implements io.Writer. Each call from a log.Logger supplies exactly one line.
+func (logSink *logSinkStruct) Write(p []byte) (n int, err error) {
+	var (
+		buf        bytes.Buffer
+		levelIndex int
+		logLine    *logLineStruct
+		now        = time.Now()
+	)
+
+	logSink.Lock()
+	defer logSink.Unlock()
+
+	logLine = logSink.parse(strings.TrimSuffix(string(p), "\n"))
+
+	levelIndex, _ = logLevelIndex(logLine.level)
+	if (levelIndex < logSink.minLevelAlreadyLocked(logLine.subsystem)) && (logLine.level != "fatal") {
+		n = len(p)
+		return
+	}
+
+	switch logSink.format {
+	case logFormatJSON:
+		logLine.appendJSON(&buf, now)
+	case logFormatLogfmt:
+		logLine.appendLogfmt(&buf, now)
+	default:
+		buf.WriteString(now.Format(logTimeFormatText))
+		buf.WriteByte(' ')
+		buf.Write(bytes.TrimSuffix(p, []byte("\n")))
+	}
+	buf.WriteByte('\n')
+
+	_, err = logSink.out.Write(buf.Bytes())
+	if err == nil {
+		n = len(p)
+	}
+
+	return
+}
+
+// `minLevelAlreadyLocked` is called while logSink.Lock() is held to return the index into
+// logLevels of the least severe level logged for subsystem. Runtime overrides, first for
+// subsystem and then for all subsystems, take precedence over log_levels and log_level.
+func (logSink *logSinkStruct) minLevelAlreadyLocked(subsystem string) (minLevel int) {
+	var (
+		ok       bool
+		override *logLevelOverrideStruct
+	)
+
+	override, ok = logSink.overrides[subsystem]
+	if ok {
+		minLevel = override.level
+		return
+	}
+
+	override, ok = logSink.overrides[""]
+	if ok {
+		minLevel = override.level
+		return
+	}
+
+	minLevel, ok = logSink.subsystemLevels[subsystem]
+	if !ok {
+		minLevel = logSink.level
+	}
+
+	return
+}
+
+// `parse` extracts the fields of line. Leading "[X] " tokens supply the level (if X is one
+// of logLevels) or the subsystem (otherwise). A following word naming a backend (as in
+// "<dirName>.readFile(...)" or "<dirName> unable to ...") supplies backend (and, if followed
+// by ".<op>(", op) while any `filePath:"..."` (as formatted by %#v) supplies path.
+func (logSink *logSinkStruct) parse(line string) (logLine *logLineStruct) {
+	var (
+		closeIndex int
+		opEnd      int
+		pathStart  int
+		token      string
+		wordEnd    int
+	)
+
+	logLine = &logLineStruct{level: "info"}
+
+	for strings.HasPrefix(line, "[") {
+		closeIndex = strings.IndexByte(line, ']')
+		if closeIndex < 0 {
+			break
+		}
+		token = line[1:closeIndex]
+		if _, ok := logLevelIndex(token); ok {
+			logLine.level = strings.ToLower(token)
+		} else if logLine.subsystem == "" {
+			logLine.subsystem = strings.ToLower(token)
+		} else {
+			break
+		}
+		line = strings.TrimLeft(line[closeIndex+1:], " ")
+	}
+
+	logLine.msg = line
+
+	wordEnd = strings.IndexAny(line, " .:(")
+	if wordEnd > 0 {
+		if _, ok := logSink.backendNames[line[:wordEnd]]; ok {
+			logLine.backend = line[:wordEnd]
+			if line[wordEnd] == '.' {
+				opEnd = strings.IndexByte(line[wordEnd+1:], '(')
+				if opEnd > 0 {
+					logLine.op = line[wordEnd+1 : wordEnd+1+opEnd]
+				}
+			}
+		}
+	}
+
+	pathStart = strings.Index(line, "filePath:\"")
+	if pathStart >= 0 {
+		if path, err := strconv.QuotedPrefix(line[pathStart+len("filePath:"):]); err == nil {
+			logLine.path, _ = strconv.Unquote(path)
+		}
+	}
+
+	return
+}
+
+// `appendJSON` appends logLine as a JSON object (omitting empty fields).
+func (logLine *logLineStruct) appendJSON(buf *bytes.Buffer, now time.Time) {
+	var (
+		field string
+		key   string
+	)
+
+	buf.WriteString(`{"time":`)
+	appendJSONString(buf, now.Format(logTimeFormatStructured))
+	for _, key = range []string{"level", "subsystem", "backend", "op", "path", "msg"} {
+		field = logLine.field(key)
+		if field != "" {
+			buf.WriteString(`,"` + key + `":`)
+			appendJSONString(buf, field)
+		}
+	}
+	buf.WriteByte('}')
+}
+
+// `appendLogfmt` appends logLine as space-separated key=value pairs (omitting empty fields).
+func (logLine *logLineStruct) appendLogfmt(buf *bytes.Buffer, now time.Time) {
+	var (
+		field string
+		key   string
+	)
+
+	buf.WriteString("time=" + now.Format(logTimeFormatStructured))
+	for _, key = range []string{"level", "subsystem", "backend", "op", "path", "msg"} {
+		field = logLine.field(key)
+		if field != "" {
+			buf.WriteString(" " + key + "=")
+			if strings.ContainsAny(field, " =\"\\") || (strings.IndexFunc(field, func(r rune) bool { return r < ' ' }) >= 0) {
+				buf.WriteString(strconv.Quote(field))
+			} else {
+				buf.WriteString(field)
+			}
+		}
+	}
+}
+
+// `field` returns the named field of logLine.
+func (logLine *logLineStruct) field(key string) string {
+	switch key {
+	case "level":
+		return logLine.level
+	case "subsystem":
+		return logLine.subsystem
+	case "backend":
+		return logLine.backend
+	case "op":
+		return logLine.op
+	case "path":
+		return logLine.path
+	default:
+		return logLine.msg
+	}
+}
+
+// `appendJSONString` appends s as a JSON string.
+func appendJSONString(buf *bytes.Buffer, s string) {
+	var (
+		encoded []byte
+	)
+
+	encoded, _ = json.Marshal(s)
+	buf.Write(encoded)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogSink(t *testing.T) {
+	var (
+		err      error
+		logLine  map[string]string
+		logSink  *logSinkStruct
+		logger   *log.Logger
+		out      bytes.Buffer
+		outLines []string
+	)
+
+	logSink = newLogSink(&out)
+	logger = log.New(logSink, "", 0)
+
+	logSink.configure(&configStruct{
+		logFormat: logFormatJSON,
+		logLevel:  "warn",
+		logLevels: map[string]string{"mirror": "debug"},
+		backends:  map[string]*backendStruct{"ram": nil},
+	})
+
+	logger.Printf("[INFO] dropped as less severe than log_level")
+	logger.Printf("[INFO] [mirror] kept as not less severe than log_levels[\"mirror\"]")
+	logger.Printf("[WARN] ram.readFile(%#v) returning err: %v", &readFileInputStruct{filePath: "dir1/fileC"}, "EIO")
+
+	outLines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(outLines) != 2 {
+		t.Fatalf("expected 2 lines logged but got:\n%s", out.String())
+	}
+
+	err = json.Unmarshal([]byte(outLines[0]), &logLine)
+	if err != nil {
+		t.Fatalf("json.Unmarshal(outLines[0]) failed: %v", err)
+	}
+	if (logLine["level"] != "info") || (logLine["subsystem"] != "mirror") || (logLine["backend"] != "") || (logLine["msg"] != "kept as not less severe than log_levels[\"mirror\"]") {
+		t.Fatalf("outLines[0] unexpected: %s", outLines[0])
+	}
+
+	logLine = nil
+	err = json.Unmarshal([]byte(outLines[1]), &logLine)
+	if err != nil {
+		t.Fatalf("json.Unmarshal(outLines[1]) failed: %v", err)
+	}
+	if (logLine["level"] != "warn") || (logLine["subsystem"] != "") || (logLine["backend"] != "ram") || (logLine["op"] != "readFile") || (logLine["path"] != "dir1/fileC") || (logLine["time"] == "") {
+		t.Fatalf("outLines[1] unexpected: %s", outLines[1])
+	}
+
+	logSink.configure(&configStruct{
+		logFormat: logFormatLogfmt,
+		logLevel:  "debug",
+		backends:  map[string]*backendStruct{"ram": nil},
+	})
+
+	out.Reset()
+	logger.Printf("[DEBUG] [credentials] ram refreshed credentials")
+
+	if !strings.HasSuffix(out.String(), " level=debug subsystem=credentials backend=ram msg=\"ram refreshed credentials\"\n") {
+		t.Fatalf("logfmt line unexpected: %s", out.String())
+	}
+
+	logSink.configure(&configStruct{
+		logFormat: logFormatText,
+		logLevel:  "error",
+	})
+
+	out.Reset()
+	logger.Printf("[FATAL] never dropped")
+
+	if !strings.HasSuffix(out.String(), " [FATAL] never dropped\n") {
+		t.Fatalf("text line unexpected: %s", out.String())
+	}
+}
+
+func TestLogLevelOverride(t *testing.T) {
+	var (
+		err              error
+		logLevelFilePath = filepath.Join(t.TempDir(), "log_level")
+		logSink          *logSinkStruct
+		logger           *log.Logger
+		out              bytes.Buffer
+	)
+
+	if globals.logger == nil {
+		globals.logger = log.New(os.Stdout, "", log.Ldate|log.Ltime|log.Lmsgprefix)
+	}
+
+	logSink = newLogSink(&out)
+	logger = log.New(logSink, "", 0)
+
+	logSink.configure(&configStruct{
+		logFormat: logFormatText,
+		logLevel:  "warn",
+		logLevels: map[string]string{"mirror": "error"},
+	})
+
+	expectLogged := func(line string, expected bool) {
+		out.Reset()
+		logger.Print(line)
+		if (out.Len() != 0) != expected {
+			t.Fatalf("logging \"%s\" emitted \"%s\" (expected logged == %v)", line, out.String(), expected)
+		}
+	}
+
+	expectLogged("[DEBUG] [mirror] a", false)
+	expectLogged("[WARN] [mirror] b", false)
+
+	// A temporary override for all subsystems supersedes log_levels until it reverts
+
+	err = logSink.applyLogLevelQuery(url.Values{"level": {"debug"}, "duration": {"100"}})
+	if err != nil {
+		t.Fatalf("applyLogLevelQuery(level=debug&duration=100) failed: %v", err)
+	}
+
+	expectLogged("[DEBUG] [mirror] c", true)
+	expectLogged("[DEBUG] d", true)
+
+	time.Sleep(200 * time.Millisecond)
+
+	expectLogged("[DEBUG] [mirror] e", false)
+	expectLogged("[INFO] f", false)
+
+	// A per-subsystem override lasts until reset
+
+	err = logSink.applyLogLevelQuery(url.Values{"level": {"info"}, "subsystem": {"Mirror"}})
+	if err != nil {
+		t.Fatalf("applyLogLevelQuery(level=info&subsystem=Mirror) failed: %v", err)
+	}
+
+	expectLogged("[INFO] [mirror] g", true)
+	expectLogged("[INFO] h", false)
+
+	err = logSink.applyLogLevelQuery(url.Values{"reset": {"true"}})
+	if err != nil {
+		t.Fatalf("applyLogLevelQuery(reset=true) failed: %v", err)
+	}
+
+	expectLogged("[INFO] [mirror] i", false)
+
+	err = logSink.applyLogLevelQuery(url.Values{"level": {"verbose"}})
+	if err == nil {
+		t.Fatalf("applyLogLevelQuery(level=verbose) unexpectedly succeeded")
+	}
+
+	// log_level_file replaces all overrides whenever it changes
+
+	err = os.WriteFile(logLevelFilePath, []byte("# comment\nlevel=trace&subsystem=mirror\n"), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile(logLevelFilePath) failed: %v", err)
+	}
+
+	logSink.checkLogLevelFile(logLevelFilePath)
+
+	expectLogged("[TRACE] [mirror] j", true)
+	expectLogged("[INFO] k", false)
+
+	err = os.Remove(logLevelFilePath)
+	if err != nil {
+		t.Fatalf("os.Remove(logLevelFilePath) failed: %v", err)
+	}
+
+	logSink.checkLogLevelFile(logLevelFilePath)
+
+	expectLogged("[TRACE] [mirror] l", false)
+}
@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -20,24 +25,86 @@ import (
 // determined in the initGlobals() call. Next, the FUSE file system is
 // initialized and the configuration file specified backends are mounted
 // beneath the root of the FUSE file system. The daemon then enters a loop
-// until receiving a SIGINT or SIGTERM. Either periodically or in response
-// to a SIGHUP, the configuration file is re-read and the list of backends
-// is adjusted based on any changes detected.
+// until receiving a SIGINT or SIGTERM (upon which it quiesces, see quiesceFS(),
+// before unmounting). If run as a systemd service, readiness is reported once
+// mounted and any watchdog is pinged (see sdNotify() and startSDWatchdog()).
+// Either periodically or in response to a SIGHUP, the configuration file is
+// re-read and the list of backends is adjusted based on any changes detected. In response to a SIGUSR1, the
+// state of the daemon is written to a file (see dumpState()). Alternatively, the configuration
+// file may merely be checked (see checkBackends()) without mounting anything or
+// its backends benchmarked (see runBench()) through the cache layer without mounting.
+// URLs for files of S3 backends may also be presigned (see runPresign()), the
+// usage of a backend's directory tree totaled (see diskUsage()), the tree
+// deleted in batches (see removeTree()), or a local directory compared with a
+// backend prefix to report any drift (see verifyFiles()).
+// A new configuration file may be written by interviewing the user (see runInit()).
+// The cache of a running daemon may also be controlled (see cacheControl()), its
+// activity watched (see runTop()), and the state persisted on local disk by a
+// stopped daemon checked (see runFsck()).
+// Any setting of the configuration file may be overridden on the command line
+// (see extractConfigOverrides()) or by selecting one of its named profiles (see
+// extractConfigProfile()).
 func main() {
 	var (
+		benchArgs              []string
+		benchDurationSeconds   uint64
+		benchOptions           *benchOptionsStruct
+		cacheArgs              []string
+		cacheCommand           string
+		cacheTargetArg         string
 		displayHelp            bool
+		fsckArgs               []string
+		fsckRepair             bool
+		initConfigFilePath     string
 		displayHelpMatchSet    map[string]struct{}
+		duArgs                 []string
+		duOptions              *duOptionsStruct
 		err                    error
+		configOverrides        []configOverrideStruct
+		configProfile          string
 		errLastCheckConfigFile error
+		logLevelFileTicker     *time.Ticker
+		reloadDoneChan         chan error
 		osArgs                 []string // Copy of os.Args so that initGlobals() can be passed a modified set of arguments in testing/benchmarking
+		osArgsSansConfigFlags  []string // Copy of osArgs minus any {-profile|--profile} <name> and {-set|--set} <key>=<value>
+		presignArgs            []string
+		presignExpires         time.Duration
+		presignExpiresSeconds  uint64
+		presignMethod          string
+		rmArgs                 []string
+		rmOptions              *rmOptionsStruct
 		signalChan             chan os.Signal
 		signalReceived         os.Signal
+		inspectArgs            []string
+		inspectCommand         string
+		inspectLength          uint64
+		inspectOffset          uint64
+		inspectTargetArg       string
+		stateDumpFilePath      string
+		stdout                 *os.File
+		syncArgs               []string
+		syncOptions            *syncOptionsStruct
+		topArgs                []string
+		topIntervalSeconds     uint64
+		topOptions             *topOptionsStruct
+		topStdoutInfo          os.FileInfo
+		verifyArgs             []string
+		verifyOptions          *verifyOptionsStruct
 		ticker                 *time.Ticker
 	)
 
 	osArgs = make([]string, len(os.Args))
 	_ = copy(osArgs, os.Args)
 
+	osArgsSansConfigFlags, configOverrides, err = extractConfigOverrides(osArgs)
+	if err == nil {
+		osArgsSansConfigFlags, configProfile, err = extractConfigProfile(osArgsSansConfigFlags)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
 	displayHelpMatchSet = make(map[string]struct{})
 	displayHelpMatchSet["-?"] = struct{}{}
 	displayHelpMatchSet["-h"] = struct{}{}
@@ -48,24 +115,587 @@ func main() {
 	displayHelpMatchSet["-version"] = struct{}{}
 	displayHelpMatchSet["--version"] = struct{}{}
 
-	switch len(osArgs) {
+	switch len(osArgsSansConfigFlags) {
 	case 1:
 		displayHelp = false
 	case 2:
-		_, displayHelp = displayHelpMatchSet[osArgs[1]]
+		_, displayHelp = displayHelpMatchSet[osArgsSansConfigFlags[1]]
 	default:
 		displayHelp = true
 	}
 
+	if (len(osArgsSansConfigFlags) == 2) && ((osArgsSansConfigFlags[1] == "-schema") || (osArgsSansConfigFlags[1] == "--schema")) {
+		_, _ = os.Stdout.Write(configSchemaJSON())
+		os.Exit(0)
+	}
+
+	if (len(osArgsSansConfigFlags) >= 2) && (len(osArgsSansConfigFlags) <= 3) && ((osArgsSansConfigFlags[1] == "-check-config") || (osArgsSansConfigFlags[1] == "--check-config")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and probe each backend without mounting
+
+		initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, osArgsSansConfigFlags[2:]...), configOverrides, configProfile)
+
+		if !checkBackends(os.Stdout) {
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	if (len(osArgsSansConfigFlags) >= 2) && (len(osArgsSansConfigFlags) <= 3) && ((osArgsSansConfigFlags[1] == "-init") || (osArgsSansConfigFlags[1] == "--init")) {
+		// Interview the user for the settings of a new <config-file> (if not supplied, the first location searched for one)
+
+		initConfigFilePath = defaultInitConfigFilePath()
+		if len(osArgsSansConfigFlags) == 3 {
+			initConfigFilePath = osArgsSansConfigFlags[2]
+		}
+
+		// Log to stderr so that stdout conveys only the interview
+
+		globals.logSink = newLogSink(os.Stderr)
+		globals.logger = log.New(globals.logSink, "", 0)
+
+		err = runInit(os.Stdin, os.Stdout, initConfigFilePath, detectInitHost())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "init: %v\n", err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	if len(osArgsSansConfigFlags) >= 3 {
+		switch osArgsSansConfigFlags[1] {
+		case "-ls", "--ls", "-stat", "--stat", "-cat", "--cat":
+			// Parse <config-file> (if supplied, else found as if mounting) and inspect the target directly without mounting
+
+			inspectCommand = strings.TrimLeft(osArgsSansConfigFlags[1], "-")
+			inspectTargetArg = osArgsSansConfigFlags[2]
+			inspectArgs = osArgsSansConfigFlags[3:]
+
+			inspectOffset, inspectLength = 0, 0
+			if (inspectCommand == "cat") && (len(inspectArgs) > 0) {
+				inspectOffset, err = strconv.ParseUint(inspectArgs[0], 10, 64)
+				if err == nil {
+					inspectArgs = inspectArgs[1:]
+					if len(inspectArgs) > 0 {
+						inspectLength, err = strconv.ParseUint(inspectArgs[0], 10, 64)
+						if err == nil {
+							inspectArgs = inspectArgs[1:]
+						}
+					}
+				}
+			}
+
+			if len(inspectArgs) > 1 {
+				break
+			}
+
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, inspectArgs...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			switch inspectCommand {
+			case "ls":
+				err = inspectLs(os.Stdout, inspectTargetArg)
+			case "stat":
+				err = inspectStat(os.Stdout, inspectTargetArg)
+			case "cat":
+				err = inspectCat(os.Stdout, inspectTargetArg, inspectOffset, inspectLength)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", inspectCommand, err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 4) && ((osArgsSansConfigFlags[1] == "-sync") || (osArgsSansConfigFlags[1] == "--sync")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and sync <src> to <dst> without mounting
+
+		syncOptions = &syncOptionsStruct{parallel: syncParallelDefault}
+		syncArgs = osArgsSansConfigFlags[2:]
+
+		for (len(syncArgs) > 0) && strings.HasPrefix(syncArgs[0], "-") {
+			switch syncArgs[0] {
+			case "-delete", "--delete":
+				syncOptions.deleteExtraneous = true
+			case "-dry-run", "--dry-run":
+				syncOptions.dryRun = true
+			case "-parallel", "--parallel":
+				if len(syncArgs) < 2 {
+					fmt.Fprintf(os.Stderr, "missing %s value\n", syncArgs[0])
+					os.Exit(1)
+				}
+				syncOptions.parallel, err = strconv.ParseUint(syncArgs[1], 10, 64)
+				if (err != nil) || (syncOptions.parallel == 0) || (syncOptions.parallel > syncParallelMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", syncArgs[0], syncParallelMax)
+					os.Exit(1)
+				}
+				syncArgs = syncArgs[1:]
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], syncArgs[0])
+				os.Exit(1)
+			}
+			syncArgs = syncArgs[1:]
+		}
+
+		if (len(syncArgs) >= 2) && (len(syncArgs) <= 3) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, syncArgs[2:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = syncFiles(os.Stdout, syncArgs[0], syncArgs[1], syncOptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "sync: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 4) && ((osArgsSansConfigFlags[1] == "-verify") || (osArgsSansConfigFlags[1] == "--verify")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and compare <local-dir> with msfs://<dir_name>[/<prefix>] without mounting
+
+		verifyOptions = &verifyOptionsStruct{parallel: verifyParallelDefault}
+		verifyArgs = osArgsSansConfigFlags[2:]
+
+		for (len(verifyArgs) > 0) && strings.HasPrefix(verifyArgs[0], "-") {
+			switch verifyArgs[0] {
+			case "-read", "--read":
+				verifyOptions.read = true
+			case "-parallel", "--parallel":
+				if len(verifyArgs) < 2 {
+					fmt.Fprintf(os.Stderr, "missing %s value\n", verifyArgs[0])
+					os.Exit(1)
+				}
+				verifyOptions.parallel, err = strconv.ParseUint(verifyArgs[1], 10, 64)
+				if (err != nil) || (verifyOptions.parallel == 0) || (verifyOptions.parallel > verifyParallelMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", verifyArgs[0], verifyParallelMax)
+					os.Exit(1)
+				}
+				verifyArgs = verifyArgs[1:]
+			case "-part-size", "--part-size":
+				if len(verifyArgs) < 2 {
+					fmt.Fprintf(os.Stderr, "missing %s value\n", verifyArgs[0])
+					os.Exit(1)
+				}
+				verifyOptions.partSize, err = strconv.ParseUint(verifyArgs[1], 10, 64)
+				if (err != nil) || (verifyOptions.partSize == 0) || (verifyOptions.partSize > verifyPartSizeMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", verifyArgs[0], verifyPartSizeMax)
+					os.Exit(1)
+				}
+				verifyArgs = verifyArgs[1:]
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], verifyArgs[0])
+				os.Exit(1)
+			}
+			verifyArgs = verifyArgs[1:]
+		}
+
+		if (len(verifyArgs) >= 2) && (len(verifyArgs) <= 3) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, verifyArgs[2:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = verifyFiles(os.Stdout, verifyArgs[0], verifyArgs[1], verifyOptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "verify: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 3) && ((osArgsSansConfigFlags[1] == "-cache") || (osArgsSansConfigFlags[1] == "--cache")) && slices.Contains(cacheControlCommands, osArgsSansConfigFlags[2]) {
+		// Parse <config-file> (if supplied, else found as if mounting) to locate the running daemon's admin_socket and issue a cache control command to it
+
+		cacheCommand = osArgsSansConfigFlags[2]
+		cacheArgs = osArgsSansConfigFlags[3:]
+
+		if slices.Contains(cacheControlTargetCommands, cacheCommand) && (len(cacheArgs) >= 1) {
+			cacheTargetArg = cacheArgs[0]
+			cacheArgs = cacheArgs[1:]
+		}
+
+		if (len(cacheArgs) <= 1) && ((cacheTargetArg != "") || !slices.Contains(cacheControlTargetCommands, cacheCommand)) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, cacheArgs...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			err = cacheControl(os.Stdout, cacheCommand, cacheTargetArg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "cache %s: %v\n", cacheCommand, err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 2) && ((osArgsSansConfigFlags[1] == "-top") || (osArgsSansConfigFlags[1] == "--top")) {
+		// Parse <config-file> (if supplied, else found as if mounting) to locate the running daemon's admin_socket and display its activity
+
+		topOptions = &topOptionsStruct{interval: topIntervalDefault, files: topFilesDefault}
+		topArgs = osArgsSansConfigFlags[2:]
+
+		for (len(topArgs) > 0) && strings.HasPrefix(topArgs[0], "-") {
+			if len(topArgs) < 2 {
+				fmt.Fprintf(os.Stderr, "missing %s value\n", topArgs[0])
+				os.Exit(1)
+			}
+			switch topArgs[0] {
+			case "-interval", "--interval":
+				topIntervalSeconds, err = strconv.ParseUint(topArgs[1], 10, 64)
+				if (err != nil) || (topIntervalSeconds == 0) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be > 0 seconds)\n", topArgs[0])
+					os.Exit(1)
+				}
+				topOptions.interval = time.Duration(topIntervalSeconds) * time.Second
+			case "-iterations", "--iterations":
+				topOptions.iterations, err = strconv.ParseUint(topArgs[1], 10, 64)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "bad %s value\n", topArgs[0])
+					os.Exit(1)
+				}
+			case "-files", "--files":
+				topOptions.files, err = strconv.Atoi(topArgs[1])
+				if (err != nil) || (topOptions.files < 0) || (topOptions.files > topFilesFetched) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 0..%v)\n", topArgs[0], topFilesFetched)
+					os.Exit(1)
+				}
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], topArgs[0])
+				os.Exit(1)
+			}
+			topArgs = topArgs[2:]
+		}
+
+		if len(topArgs) <= 1 {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, topArgs...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			topStdoutInfo, err = os.Stdout.Stat()
+			topOptions.clearScreen = (err == nil) && ((topStdoutInfo.Mode() & os.ModeCharDevice) != 0)
+
+			err = runTop(os.Stdout, topOptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "top: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 3) && ((osArgsSansConfigFlags[1] == "-presign") || (osArgsSansConfigFlags[1] == "--presign")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and presign a URL for <dir_name>/<path> without mounting
+
+		presignMethod = http.MethodGet
+		presignExpires = presignExpiresDefault
+		presignArgs = osArgsSansConfigFlags[2:]
+
+		for (len(presignArgs) > 0) && strings.HasPrefix(presignArgs[0], "-") {
+			if len(presignArgs) < 2 {
+				fmt.Fprintf(os.Stderr, "missing %s value\n", presignArgs[0])
+				os.Exit(1)
+			}
+			switch presignArgs[0] {
+			case "-method", "--method":
+				presignMethod = strings.ToUpper(presignArgs[1])
+				if !slices.Contains(presignMethods, presignMethod) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be one of %s)\n", presignArgs[0], strings.Join(presignMethods, ", "))
+					os.Exit(1)
+				}
+			case "-expires", "--expires":
+				presignExpiresSeconds, err = strconv.ParseUint(presignArgs[1], 10, 64)
+				if (err != nil) || (presignExpiresSeconds == 0) || (presignExpiresSeconds > uint64(presignExpiresMax/time.Second)) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v seconds)\n", presignArgs[0], uint64(presignExpiresMax/time.Second))
+					os.Exit(1)
+				}
+				presignExpires = time.Duration(presignExpiresSeconds) * time.Second
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], presignArgs[0])
+				os.Exit(1)
+			}
+			presignArgs = presignArgs[2:]
+		}
+
+		if (len(presignArgs) >= 1) && (len(presignArgs) <= 2) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, presignArgs[1:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = runPresign(os.Stdout, presignArgs[0], presignMethod, presignExpires)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "presign: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 3) && ((osArgsSansConfigFlags[1] == "-du") || (osArgsSansConfigFlags[1] == "--du")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and total the usage beneath <dir_name>[/<path>] without mounting
+
+		duOptions = &duOptionsStruct{depth: duDepthDefault, parallel: duParallelDefault}
+		duArgs = osArgsSansConfigFlags[2:]
+
+		for (len(duArgs) > 0) && strings.HasPrefix(duArgs[0], "-") {
+			if len(duArgs) < 2 {
+				fmt.Fprintf(os.Stderr, "missing %s value\n", duArgs[0])
+				os.Exit(1)
+			}
+			switch duArgs[0] {
+			case "-depth", "--depth":
+				duOptions.depth, err = strconv.ParseUint(duArgs[1], 10, 64)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "bad %s value\n", duArgs[0])
+					os.Exit(1)
+				}
+			case "-parallel", "--parallel":
+				duOptions.parallel, err = strconv.ParseUint(duArgs[1], 10, 64)
+				if (err != nil) || (duOptions.parallel == 0) || (duOptions.parallel > duParallelMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", duArgs[0], duParallelMax)
+					os.Exit(1)
+				}
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], duArgs[0])
+				os.Exit(1)
+			}
+			duArgs = duArgs[2:]
+		}
+
+		if (len(duArgs) >= 1) && (len(duArgs) <= 2) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, duArgs[1:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = diskUsage(os.Stdout, duArgs[0], duOptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "du: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 3) && ((osArgsSansConfigFlags[1] == "-rm") || (osArgsSansConfigFlags[1] == "--rm")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and recursively delete <dir_name>/<path> without mounting
+
+		rmOptions = &rmOptionsStruct{parallel: rmParallelDefault}
+		rmArgs = osArgsSansConfigFlags[2:]
+
+		for (len(rmArgs) > 0) && strings.HasPrefix(rmArgs[0], "-") {
+			switch rmArgs[0] {
+			case "-dry-run", "--dry-run":
+				rmOptions.dryRun = true
+			case "-parallel", "--parallel":
+				if len(rmArgs) < 2 {
+					fmt.Fprintf(os.Stderr, "missing %s value\n", rmArgs[0])
+					os.Exit(1)
+				}
+				rmOptions.parallel, err = strconv.ParseUint(rmArgs[1], 10, 64)
+				if (err != nil) || (rmOptions.parallel == 0) || (rmOptions.parallel > rmParallelMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", rmArgs[0], rmParallelMax)
+					os.Exit(1)
+				}
+				rmArgs = rmArgs[1:]
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], rmArgs[0])
+				os.Exit(1)
+			}
+			rmArgs = rmArgs[1:]
+		}
+
+		if (len(rmArgs) >= 1) && (len(rmArgs) <= 2) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, rmArgs[1:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = removeTree(os.Stdout, os.Stderr, rmArgs[0], rmOptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "rm: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 3) && ((osArgsSansConfigFlags[1] == "-bench") || (osArgsSansConfigFlags[1] == "--bench")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and benchmark <dir_name>[/<path>] through the cache layer without mounting
+
+		benchOptions = &benchOptionsStruct{
+			pattern:   BenchPatternSequential,
+			blockSize: benchBlockSizeDefault,
+			threads:   benchThreadsDefault,
+			duration:  benchDurationDefault,
+		}
+		benchArgs = osArgsSansConfigFlags[2:]
+
+		for (len(benchArgs) > 0) && strings.HasPrefix(benchArgs[0], "-") {
+			if len(benchArgs) < 2 {
+				fmt.Fprintf(os.Stderr, "missing %s value\n", benchArgs[0])
+				os.Exit(1)
+			}
+			switch benchArgs[0] {
+			case "-pattern", "--pattern":
+				benchOptions.pattern = benchArgs[1]
+				if !slices.Contains(benchPatterns, benchOptions.pattern) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be one of %s)\n", benchArgs[0], strings.Join(benchPatterns, ", "))
+					os.Exit(1)
+				}
+			case "-block-size", "--block-size":
+				benchOptions.blockSize, err = strconv.ParseUint(benchArgs[1], 10, 64)
+				if (err != nil) || (benchOptions.blockSize == 0) || (benchOptions.blockSize > uint64(maxRead)) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", benchArgs[0], maxRead)
+					os.Exit(1)
+				}
+			case "-threads", "--threads":
+				benchOptions.threads, err = strconv.ParseUint(benchArgs[1], 10, 64)
+				if (err != nil) || (benchOptions.threads == 0) || (benchOptions.threads > benchThreadsMax) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be 1..%v)\n", benchArgs[0], benchThreadsMax)
+					os.Exit(1)
+				}
+			case "-duration", "--duration":
+				benchDurationSeconds, err = strconv.ParseUint(benchArgs[1], 10, 64)
+				if (err != nil) || (benchDurationSeconds == 0) {
+					fmt.Fprintf(os.Stderr, "bad %s value (must be a positive number of seconds)\n", benchArgs[0])
+					os.Exit(1)
+				}
+				benchOptions.duration = time.Duration(benchDurationSeconds) * time.Second
+			default:
+				fmt.Fprintf(os.Stderr, "unknown %s option: %s\n", osArgsSansConfigFlags[1], benchArgs[0])
+				os.Exit(1)
+			}
+			benchArgs = benchArgs[2:]
+		}
+
+		if (len(benchArgs) >= 1) && (len(benchArgs) <= 2) {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, benchArgs[1:]...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			initFS()
+			processToMountList()
+
+			err = runBench(os.Stdout, benchArgs[0], benchOptions)
+
+			drainFS()
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
+	if (len(osArgsSansConfigFlags) >= 2) && ((osArgsSansConfigFlags[1] == "-fsck") || (osArgsSansConfigFlags[1] == "--fsck")) {
+		// Parse <config-file> (if supplied, else found as if mounting) and check the state its backends persist on local disk without mounting
+
+		fsckArgs = osArgsSansConfigFlags[2:]
+
+		if (len(fsckArgs) > 0) && ((fsckArgs[0] == "-repair") || (fsckArgs[0] == "--repair")) {
+			fsckRepair = true
+			fsckArgs = fsckArgs[1:]
+		}
+
+		if len(fsckArgs) <= 1 {
+			// Log to stderr so that stdout conveys only what was requested
+
+			stdout = os.Stdout
+			os.Stdout = os.Stderr
+			initGlobalsWithoutMounting(append([]string{osArgsSansConfigFlags[0]}, fsckArgs...), configOverrides, configProfile)
+			os.Stdout = stdout
+
+			setupInspectBackends()
+
+			err = runFsck(os.Stdout, fsckRepair)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fsck: %v\n", err)
+				os.Exit(1)
+			}
+
+			os.Exit(0)
+		}
+	}
+
 	if displayHelp {
-		fmt.Printf("usage: %s [{-?|-h|help|-help|--help|-v|-version|--version} | <config-file>]\n", osArgs[0])
-		fmt.Printf("  where a <config-file>, ending in suffix .yaml, .yml, or .json, is to be found while searching:\n")
+		fmt.Printf("usage: %s [{-?|-h|help|-help|--help|-v|-version|--version} | {-schema|--schema} | {-check-config|--check-config} [<config-file>] | {-init|--init} [<config-file>] | {-ls|--ls|-stat|--stat} <dir_name>[/<path>] [<config-file>] | {-cat|--cat} <dir_name>/<path> [<offset> [<length>]] [<config-file>] | {-presign|--presign} [{-method|--method} {GET|PUT}] [{-expires|--expires} <seconds>] <dir_name>/<path> [<config-file>] | {-du|--du} [{-depth|--depth} <n>] [{-parallel|--parallel} <n>] <dir_name>[/<path>] [<config-file>] | {-rm|--rm} [{-dry-run|--dry-run}] [{-parallel|--parallel} <n>] <dir_name>/<path> [<config-file>] | {-sync|--sync} [{-delete|--delete}] [{-dry-run|--dry-run}] [{-parallel|--parallel} <n>] <src> <dst> [<config-file>] | {-verify|--verify} [{-read|--read}] [{-parallel|--parallel} <n>] [{-part-size|--part-size} <bytes>] <local-dir> msfs://<dir_name>[/<prefix>] [<config-file>] | {-cache|--cache} {stats|ls|{drop|pin|unpin|warm} <dir_name>[/<path>]} [<config-file>] | {-top|--top} [{-interval|--interval} <seconds>] [{-iterations|--iterations} <n>] [{-files|--files} <n>] [<config-file>] | {-bench|--bench} [{-pattern|--pattern} <pattern>] [{-block-size|--block-size} <bytes>] [{-threads|--threads} <threads>] [{-duration|--duration} <seconds>] <dir_name>[/<path>] [<config-file>] | {-fsck|--fsck} [{-repair|--repair}] [<config-file>] | <config-file>] [{-profile|--profile} <name>] [{-set|--set} <key>=<value>]...\n", osArgs[0])
+		fmt.Printf("  where {-schema|--schema} outputs the JSON Schema of a msfs_version 1 <config-file>\n")
+		fmt.Printf("  and {-check-config|--check-config} parses <config-file> and reports the reachability of each backend without mounting\n")
+		fmt.Printf("  and {-init|--init} interviews the user for the settings of a new <config-file> (validated before being written)\n")
+		fmt.Printf("  and {-ls|--ls}, {-stat|--stat}, and {-cat|--cat} list a directory, report the metadata of a file or directory, or output (a byte range of) a file of a backend without mounting\n")
+		fmt.Printf("  and {-presign|--presign} outputs a URL (default GET, expiring after 3600 seconds) presigned with the credentials of an S3 backend without mounting\n")
+		fmt.Printf("  and {-du|--du} reports the bytes and objects beneath <dir_name>[/<path>] and each subdirectory up to <n> (default 1) levels beneath it without mounting\n")
+		fmt.Printf("  and {-rm|--rm} deletes the file or directory tree <dir_name>/<path> in batches (reporting progress to stderr) without mounting\n")
+		fmt.Printf("  and {-sync|--sync} copies each new or changed file from <src> to <dst> (a local directory and msfs://<dir_name>[/<prefix>] in either order) without mounting\n")
+		fmt.Printf("  and {-verify|--verify} reports each file missing, extraneous, or differing (in size or, per eTag, digest) between <local-dir> and msfs://<dir_name>[/<prefix>] without mounting\n")
+		fmt.Printf("  and {-cache|--cache} reports on (stats or ls) or drops, pins, unpins, or warms the cache of the running daemon via its admin_socket\n")
+		fmt.Printf("  and {-top|--top} displays (every 2 seconds by default, until interrupted or after <n> iterations) the FUSE op rates, cache hit ratio, backend throughput, and hottest files of the running daemon via its admin_socket\n")
+		fmt.Printf("  and {-bench|--bench} reports the throughput, IOPS, and latency of <pattern> (seq, random, small-files, or write) operations against <dir_name>[/<path>] through the cache layer without mounting\n")
+		fmt.Printf("  and {-fsck|--fsck} reports journaled mirror operations left on local disk (repairing inconsistencies if {-repair|--repair}) without mounting\n")
+		fmt.Printf("  and {-profile|--profile} <name> (else ${MSFS_PROFILE}) selects which of the <config-file>'s msfs_profiles to apply\n")
+		fmt.Printf("  and each {-set|--set} <key>=<value> overrides the <config-file> setting at dot-separated <key> (e.g. cache_lines or backends.<dir_name>.S3.endpoint)\n")
+		fmt.Printf("  and a <config-file>, ending in suffix .yaml, .yml, .json, or .toml, is to be found while searching:\n")
 		fmt.Printf("    ${MSC_CONFIG}\n")
-		fmt.Printf("    ${XDG_CONFIG_HOME}/msc/config.{yaml|yml|json}\n")
-		fmt.Printf("    ${HOME}/.msc_config.{yaml|yml|json}\n")
-		fmt.Printf("    ${HOME}/.config/msc/config.{yaml|yml|json}\n")
-		fmt.Printf("    ${XDG_CONFIG_DIRS:-/etc/xdg}/msc/config.{yaml|yml|json}\n")
-		fmt.Printf("    /etc/msc_config.{yaml|yml|json}\n")
+		fmt.Printf("    ${XDG_CONFIG_HOME}/msfs/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    ${HOME}/.config/msfs/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    /etc/msfs/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    ${XDG_CONFIG_HOME}/msc/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    ${HOME}/.msc_config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    ${HOME}/.config/msc/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    ${XDG_CONFIG_DIRS:-/etc/xdg}/msc/config.{yaml|yml|json|toml}\n")
+		fmt.Printf("    /etc/msc_config.{yaml|yml|json|toml}\n")
+		fmt.Printf("  where, if found along the msfs/ paths, each found later along them supplies defaults beneath the first\n")
 		fmt.Printf("version:\n")
 		fmt.Printf("  %s\n", GitTag)
 		os.Exit(0)
@@ -93,8 +723,16 @@ func main() {
 
 	startHTTPHandler()
 
+	globals.reloadChan = make(chan chan error)
+
+	startAdminSocket()
+
+	sdNotifyAndLog("READY=1\nSTATUS=mounted at " + globals.config.mountPoint)
+
+	startSDWatchdog()
+
 	signalChan = make(chan os.Signal, 1)
-	signal.Notify(signalChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(signalChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
 
 	if globals.config.autoSIGHUPInterval == 0 {
 		ticker = time.NewTicker(365 * 24 * time.Hour)
@@ -103,13 +741,40 @@ func main() {
 		ticker = time.NewTicker(globals.config.autoSIGHUPInterval)
 	}
 
+	if globals.config.logLevelFile == "" {
+		logLevelFileTicker = time.NewTicker(365 * 24 * time.Hour)
+		logLevelFileTicker.Stop()
+	} else {
+		globals.logSink.checkLogLevelFile(globals.config.logLevelFile)
+		logLevelFileTicker = time.NewTicker(logLevelFileCheckInterval)
+	}
+
 	errLastCheckConfigFile = nil
 
 	for {
 		select {
 		case signalReceived = <-signalChan:
+			if signalReceived == syscall.SIGUSR1 {
+				// We received a syscall.SIGUSR1... so dump our state to a file and resume
+
+				stateDumpFilePath, err = dumpState(globals.config.stateDumpDir)
+				if err == nil {
+					globals.logger.Printf("[INFO] state dumped to \"%s\"", stateDumpFilePath)
+				} else {
+					globals.logger.Printf("[WARN] dumping state failed: %v", err)
+				}
+
+				continue
+			}
+
 			if signalReceived != syscall.SIGHUP {
-				// We received either syscall.SIGINT or syscall.SIGTERM...so terminate normally
+				// We received either syscall.SIGINT or syscall.SIGTERM...so quiesce and terminate normally
+
+				sdNotifyAndLog("STOPPING=1")
+
+				stopAdminSocket()
+
+				_ = quiesceFS(globals.config.shutdownTimeout)
 
 				err = performFissionUnmount()
 				if err != nil {
@@ -132,6 +797,19 @@ func main() {
 					cancel()
 				}
 
+				// Shutdown tracing (flush pending spans)
+				if globals.tracerProvider != nil {
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					if tp, ok := globals.tracerProvider.(interface{ Shutdown(context.Context) error }); ok {
+						if err := tp.Shutdown(shutdownCtx); err != nil {
+							globals.logger.Printf("[WARN] error shutting down tracer provider: %v", err)
+						} else {
+							globals.logger.Printf("[INFO] tracer provider shut down successfully")
+						}
+					}
+					cancel()
+				}
+
 				os.Exit(0)
 			}
 
@@ -149,6 +827,23 @@ func main() {
 			}
 
 			errLastCheckConfigFile = err
+		case reloadDoneChan = <-globals.reloadChan:
+			// Act like we received a syscall.SIGHUP (but report the outcome to the admin API caller)
+
+			err = checkConfigFile()
+			if err == nil {
+				globals.logger.Printf("[INFO] parsing config-file (\"%s\") succeeded", globals.configFilePath)
+
+				processToUnmountList()
+
+				processToMountList()
+			} else {
+				globals.logger.Printf("[WARN] parsing config-file (\"%s\") failed: %v", globals.configFilePath, err)
+			}
+
+			errLastCheckConfigFile = err
+
+			reloadDoneChan <- err
 		case <-ticker.C:
 			// Act like we received a syscall.SIGHUP... so re-parse (current) content of globals.condfigFilePath and resume
 
@@ -166,6 +861,8 @@ func main() {
 			}
 
 			errLastCheckConfigFile = err
+		case <-logLevelFileTicker.C:
+			globals.logSink.checkLogLevelFile(globals.config.logLevelFile)
 		case err = <-globals.errChan:
 			// We received an Unexpected exit of /dev/fuse read loop... to terminate abnormally
 
@@ -175,6 +872,27 @@ func main() {
 	}
 }
 
+// `initGlobalsWithoutMounting` initializes globals per osArgs (whose <config-file>, if
+// supplied, follows osArgs[0]), configOverrides, and configProfile then parses the
+// configuration file such that its backends may be set up without mounting them.
+func initGlobalsWithoutMounting(osArgs []string, configOverrides []configOverrideStruct, configProfile string) {
+	var (
+		err error
+	)
+
+	initGlobals(osArgs)
+
+	globals.configOverrides = configOverrides
+	if configProfile != "" {
+		globals.configProfile = configProfile
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		globals.logger.Fatalf("[FATAL] parsing config-file (\"%s\") failed: %v", globals.configFilePath, err)
+	}
+}
+
 // initObservability initializes metrics via OTLP for MSCP.
 // Config structure matches MSC Python schema exactly: opentelemetry.metrics.{attributes, reader, exporter}
 // Logs are written to stdout (redirected to /var/log/msc/mscp_*.log by mount.msc).
@@ -185,6 +903,8 @@ func initObservability() {
 		return
 	}
 
+	initTracing()
+
 	// Check if metrics exporter is configured (matches Python schema requirement)
 	if globals.config.observability.metricsExporter == nil {
 		globals.logger.Printf("[INFO] metrics exporter not configured, skipping metrics initialization")
@@ -331,6 +1051,51 @@ func initObservability() {
 	globals.logger.Printf("[INFO] metrics instruments created successfully")
 }
 
+// initTracing initializes tracing via OTLP (of the read path) if opentelemetry.traces.exporter is configured.
+func initTracing() {
+	if globals.config.observability.tracesExporter == nil {
+		globals.logger.Printf("[INFO] traces exporter not configured, skipping tracing initialization")
+		return
+	}
+
+	exporterType := globals.config.observability.tracesExporter.Type
+	exporterOptions := globals.config.observability.tracesExporter.Options
+
+	if exporterType != "otlp" {
+		globals.logger.Printf("[WARN] unsupported traces exporter type: %s (supported: 'otlp')", exporterType)
+		return
+	}
+
+	endpoint, ok := exporterOptions["endpoint"].(string)
+	if !ok {
+		globals.logger.Printf("[WARN] traces exporter endpoint not configured, skipping tracing initialization")
+		return
+	}
+
+	insecure := true // default to insecure for dev (as for metrics)
+	if insecureVal, ok := exporterOptions["insecure"].(bool); ok {
+		insecure = insecureVal
+	}
+
+	tracesConfig := telemetry.TracesConfig{
+		OTLPEndpoint:       endpoint,
+		Insecure:           insecure,
+		SamplerType:        globals.config.observability.tracesSampler.Type,
+		SamplerRatio:       globals.config.observability.tracesSampler.Ratio,
+		ServiceName:        "msc-posix",
+		AttributeProviders: processAttributeProviders(globals.config.observability.metricsAttributes),
+	}
+
+	tracerProvider, err := telemetry.SetupTracing(&tracesConfig)
+	if err != nil {
+		globals.logger.Printf("[WARN] failed to initialize tracing: %v", err)
+		return
+	}
+
+	globals.tracerProvider = tracerProvider // Store for shutdown later
+	globals.logger.Printf("[INFO] tracing initialized (sampler=%s, ratio=%v), sending to %s", tracesConfig.SamplerType, tracesConfig.SamplerRatio, endpoint)
+}
+
 // processAttributeProviders instantiates attribute providers from configuration.
 // Matches Python: providers/base.py:_init_metrics() attribute provider instantiation
 func processAttributeProviders(configs []attributeProviderStruct) []attributes.AttributesProvider {
@@ -31,7 +31,7 @@ func TestMain(m *testing.M) {
 
 	testGlobals.testConfigFilePathMap = make(map[string]string)
 
-	for _, testConfigFilePathSuffix = range []string{".json", ".yaml", ".yml", ".other", ""} {
+	for _, testConfigFilePathSuffix = range []string{".json", ".yaml", ".yml", ".toml", ".other", ""} {
 		testConfigFile, err = os.CreateTemp("", "MSFSTestConfigFile*"+testConfigFilePathSuffix)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "os.CreateTemp(\"\", \"MSFSTestConfigFile*%s\") failed: %v\n", testConfigFilePathSuffix, err)
@@ -1,7 +1,18 @@
 package main
 
 import (
+	"container/list"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/NVIDIA/aistore/cmn"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // `fissionMetricsStruct` is used to record metrics for the `fission` front end
@@ -47,6 +58,7 @@ type fissionMetricsStruct struct {
 	ReadCacheMisses             prometheus.Counter
 	ReadCacheWaits              prometheus.Counter
 	ReadCachePrefetches         prometheus.Counter
+	ReadCacheBypasses           prometheus.Counter
 	StatFSCalls                 prometheus.Counter // Only applicable to globals.fissionMetrics
 	ReleaseSuccesses            prometheus.Counter
 	ReleaseFailures             prometheus.Counter
@@ -78,6 +90,8 @@ type fissionMetricsStruct struct {
 	StatXFailures               prometheus.Counter
 	StatXSuccessLatencies       prometheus.Histogram
 	StatXFailureLatencies       prometheus.Histogram
+
+	Ops *prometheus.CounterVec // Labeled by "op" (e.g. "getattr") and "result" ("ok" or, e.g., "ENOENT"; see fuseOpResult())
 }
 
 // `newFissionMetrics` provisions and initializes a `fissionMetricsStruct`.
@@ -262,6 +276,10 @@ func newFissionMetrics() (fissionMetrics *fissionMetricsStruct) {
 			Name: "fission_read_cache_prefetches_total",
 			Help: "Total number of Read operation triggered cache prefetches",
 		}),
+		ReadCacheBypasses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fission_read_cache_bypasses_total",
+			Help: "Total number of Read operation triggered cache line fetches bypassing the cache",
+		}),
 
 		StatFSCalls: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "fission_statfs_calls_total",
@@ -408,11 +426,90 @@ func newFissionMetrics() (fissionMetrics *fissionMetricsStruct) {
 			Help:    "Latency of failed StatX operations",
 			Buckets: latencyBuckets,
 		}),
+
+		Ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "fission_ops_total",
+			Help: "Total number of FUSE operations by operation and result",
+		}, []string{"op", "result"}),
+	}
+
+	return
+}
+
+// `recordFUSEOp` counts, in both the global and (should inode be non-nil and belong to one)
+// the backend's fission_ops_total, an op having returned errno. Unless inode is nil, it must
+// be called while globals.Lock() is held (as inode.backend is protected by it).
+func recordFUSEOp(op string, inode *inodeStruct, errno syscall.Errno) {
+	var (
+		result = fuseOpResult(errno)
+	)
+
+	globals.fissionMetrics.Ops.WithLabelValues(op, result).Inc()
+	if (inode != nil) && (inode.backend != nil) {
+		inode.backend.fissionMetrics.Ops.WithLabelValues(op, result).Inc()
+	}
+}
+
+// `fuseOpResult` returns the "result" label of a FUSE operation that returned errno:
+// "ok" if it succeeded and otherwise the errno (e.g. "ENOENT").
+func fuseOpResult(errno syscall.Errno) (result string) {
+	if errno == 0 {
+		result = "ok"
+	} else {
+		result = errnoName(errno)
+	}
+
+	return
+}
+
+// `opCounts` returns, for each FUSE operation counted by recordFUSEOp(), how many
+// have completed (regardless of result) since startup.
+func (fissionMetrics *fissionMetricsStruct) opCounts() (opCounts map[string]uint64) {
+	var (
+		dtoMetric  dto.Metric
+		err        error
+		labelPair  *dto.LabelPair
+		metric     prometheus.Metric
+		metricChan = make(chan prometheus.Metric)
+	)
+
+	opCounts = make(map[string]uint64)
+
+	go func() {
+		fissionMetrics.Ops.Collect(metricChan)
+		close(metricChan)
+	}()
+
+	for metric = range metricChan {
+		dtoMetric.Reset()
+		err = metric.Write(&dtoMetric)
+		if (err != nil) || (dtoMetric.GetCounter() == nil) {
+			continue
+		}
+
+		for _, labelPair = range dtoMetric.GetLabel() {
+			if labelPair.GetName() == "op" {
+				opCounts[labelPair.GetValue()] += uint64(dtoMetric.GetCounter().GetValue())
+			}
+		}
 	}
 
 	return
 }
 
+// `counterValue` returns the current value of counter.
+func counterValue(counter prometheus.Counter) uint64 {
+	var (
+		dtoMetric dto.Metric
+	)
+
+	if counter.Write(&dtoMetric) != nil {
+		return 0
+	}
+
+	return uint64(dtoMetric.GetCounter().GetValue())
+}
+
 // `backendMetricsStruct` is used to record metrics for the `fission` front end
 // operations. Such metrics will be maintained globally as well as for each backend.
 type backendMetricsStruct struct {
@@ -438,6 +535,24 @@ type backendMetricsStruct struct {
 	StatFileFailureLatencies      prometheus.Histogram
 
 	DirectoryPrefetchLatencies prometheus.Histogram
+
+	Requests                *prometheus.CounterVec   // Labeled by "operation" and "status" (see backendRequestStatus())
+	RequestLatencies        *prometheus.HistogramVec // Labeled by "operation"
+	RequestLatencyQuantiles *prometheus.SummaryVec   // Labeled by "operation" (reporting each of backendLatencyObjectives over the last 10 minutes)
+}
+
+// `backendLatencyObjectives` are the quantiles (and their allowed error) of the latency
+// distribution of backend requests reported by RequestLatencyQuantiles.
+var backendLatencyObjectives = map[float64]float64{0.5: 0.05, 0.95: 0.01, 0.99: 0.001}
+
+// `backendLatencyQuantilesStruct` reports, for an operation, the latency distribution
+// of (recent) backend requests.
+type backendLatencyQuantilesStruct struct {
+	Operation string  `json:"operation"`
+	Count     uint64  `json:"count"` // Since startup
+	P50       float64 `json:"p50_seconds"`
+	P95       float64 `json:"p95_seconds"`
+	P99       float64 `json:"p99_seconds"`
 }
 
 // `newBackendMetrics` provisions and initializes a `backendMetricsStruct`.
@@ -545,6 +660,263 @@ func newBackendMetrics() (backendMetrics *backendMetricsStruct) {
 			Help:    "Latency of directory prefetch operations",
 			Buckets: latencyBuckets,
 		}),
+
+		Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "backend_requests_total",
+			Help: "Total number of backend requests by operation and status",
+		}, []string{"operation", "status"}),
+		RequestLatencies: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "backend_request_latency_seconds",
+			Help:    "Latency of backend requests by operation",
+			Buckets: latencyBuckets,
+		}, []string{"operation"}),
+		RequestLatencyQuantiles: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       "backend_request_latency_quantile_seconds",
+			Help:       "Quantiles of the latency of recent backend requests by operation",
+			Objectives: backendLatencyObjectives,
+		}, []string{"operation"}),
+	}
+
+	return
+}
+
+// `latencyQuantiles` returns, for each operation for which a backend request has been
+// recorded, the p50, p95, and p99 latencies of recent requests (ordered by operation).
+// Should no request have been recorded recently, each is reported as 0.
+func (backendMetrics *backendMetricsStruct) latencyQuantiles() (latencyQuantiles []*backendLatencyQuantilesStruct) {
+	var (
+		dtoMetric       dto.Metric
+		err             error
+		labelPair       *dto.LabelPair
+		latencyQuantile *backendLatencyQuantilesStruct
+		metric          prometheus.Metric
+		metricChan      = make(chan prometheus.Metric)
+		quantile        *dto.Quantile
+		value           float64
+	)
+
+	latencyQuantiles = make([]*backendLatencyQuantilesStruct, 0)
+
+	go func() {
+		backendMetrics.RequestLatencyQuantiles.Collect(metricChan)
+		close(metricChan)
+	}()
+
+	for metric = range metricChan {
+		dtoMetric.Reset()
+		err = metric.Write(&dtoMetric)
+		if (err != nil) || (dtoMetric.GetSummary() == nil) {
+			continue
+		}
+
+		latencyQuantile = &backendLatencyQuantilesStruct{Count: dtoMetric.GetSummary().GetSampleCount()}
+
+		for _, labelPair = range dtoMetric.GetLabel() {
+			if labelPair.GetName() == "operation" {
+				latencyQuantile.Operation = labelPair.GetValue()
+			}
+		}
+
+		for _, quantile = range dtoMetric.GetSummary().GetQuantile() {
+			value = quantile.GetValue()
+			if math.IsNaN(value) {
+				value = 0
+			}
+			switch quantile.GetQuantile() {
+			case 0.5:
+				latencyQuantile.P50 = value
+			case 0.95:
+				latencyQuantile.P95 = value
+			case 0.99:
+				latencyQuantile.P99 = value
+			}
+		}
+
+		latencyQuantiles = append(latencyQuantiles, latencyQuantile)
+	}
+
+	slices.SortFunc(latencyQuantiles, func(a, b *backendLatencyQuantilesStruct) int {
+		return strings.Compare(a.Operation, b.Operation)
+	})
+
+	return
+}
+
+// `cacheMetricsStruct` is used to record metrics for the (global) cache of file content.
+// Apart from the Line* counters, each is a gauge set (by updateAlreadyLocked()) as scraped.
+type cacheMetricsStruct struct {
+	LineEvictions              prometheus.Counter
+	LineRevalidations          prometheus.Counter
+	LineRevalidationsUnchanged prometheus.Counter
+	LineETagConflicts          prometheus.Counter
+	CleanLines                 prometheus.Gauge
+	DirtyLines                 prometheus.Gauge
+	DirtyBytes                 prometheus.Gauge
+	InflightFetches            prometheus.Gauge
+	InflightFlushes            prometheus.Gauge
+}
+
+// `newCacheMetrics` provisions and initializes a `cacheMetricsStruct`.
+func newCacheMetrics() (cacheMetrics *cacheMetricsStruct) {
+	cacheMetrics = &cacheMetricsStruct{
+		LineEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_line_evictions_total",
+			Help: "Total number of clean cache lines evicted to make room for others",
+		}),
+		LineRevalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_line_revalidations_total",
+			Help: "Total number of clean cache lines older than cache_line_ttl conditionally refetched",
+		}),
+		LineRevalidationsUnchanged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_line_revalidations_unchanged_total",
+			Help: "Total number of revalidated cache lines found unchanged (so their content was retained)",
+		}),
+		LineETagConflicts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_line_etag_conflicts_total",
+			Help: "Total number of cache line fetches finding their file's eTag changed (resolved per etag_conflict_policy)",
+		}),
+		CleanLines: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_clean_lines",
+			Help: "Number of clean cache lines",
+		}),
+		DirtyLines: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_dirty_lines",
+			Help: "Number of dirty cache lines (awaiting flush)",
+		}),
+		DirtyBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_dirty_bytes",
+			Help: "Number of bytes held by dirty cache lines (awaiting flush)",
+		}),
+		InflightFetches: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_inflight_fetches",
+			Help: "Number of cache lines being fetched from backends",
+		}),
+		InflightFlushes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_inflight_flushes",
+			Help: "Number of cache lines being flushed to backends",
+		}),
+	}
+
+	return
+}
+
+// `updateAlreadyLocked` is called while globals.Lock() is held (just before the metrics
+// are scraped) to set each gauge of cacheMetrics from the current state of the cache.
+func (cacheMetrics *cacheMetricsStruct) updateAlreadyLocked() {
+	var (
+		dirtyBytes  uint64
+		listElement *list.Element
+	)
+
+	for listElement = globals.dirtyCacheLineLRU.Front(); listElement != nil; listElement = listElement.Next() {
+		dirtyBytes += uint64(len(listElement.Value.(*cacheLineStruct).content))
+	}
+
+	cacheMetrics.CleanLines.Set(float64(globals.cleanCacheLineLRU.Len()))
+	cacheMetrics.DirtyLines.Set(float64(globals.dirtyCacheLineLRU.Len()))
+	cacheMetrics.DirtyBytes.Set(float64(dirtyBytes))
+	cacheMetrics.InflightFetches.Set(float64(globals.inboundCacheLineCount))
+	cacheMetrics.InflightFlushes.Set(float64(globals.outboundCacheLineCount))
+}
+
+// `backendRequestStatus` returns the "status" label of a backend request that returned
+// err: "ok" if it succeeded, the HTTP status code (e.g. "404") of a failure response
+// from S3 or AIStore, the errno (e.g. "ENOENT") of other failures reporting one, and
+// otherwise "error".
+func backendRequestStatus(err error) (status string) {
+	var (
+		errHTTP *cmn.ErrHTTP
+		errno   syscall.Errno
+		httpErr *awshttp.ResponseError
+	)
+
+	switch {
+	case err == nil:
+		status = "ok"
+	case errors.As(err, &httpErr):
+		status = strconv.Itoa(httpErr.HTTPStatusCode())
+	case errors.As(err, &errno):
+		status = errnoName(errno)
+	default:
+		errHTTP = cmn.AsErrHTTP(err)
+		if (errHTTP != nil) && (errHTTP.Status != 0) {
+			status = strconv.Itoa(errHTTP.Status)
+		} else {
+			status = "error"
+		}
+	}
+
+	return
+}
+
+// `errnoName` returns the symbolic name (e.g. "ENOENT") of those errno values a
+// backend or FUSE operation is expected to report (else "errno_<value>").
+func errnoName(errno syscall.Errno) string {
+	switch errno {
+	case syscall.EACCES:
+		return "EACCES"
+	case syscall.EAGAIN:
+		return "EAGAIN"
+	case syscall.EBADF:
+		return "EBADF"
+	case syscall.EBUSY:
+		return "EBUSY"
+	case syscall.EEXIST:
+		return "EEXIST"
+	case syscall.EHOSTDOWN:
+		return "EHOSTDOWN"
+	case syscall.EINVAL:
+		return "EINVAL"
+	case syscall.EIO:
+		return "EIO"
+	case syscall.EISDIR:
+		return "EISDIR"
+	case syscall.ENOENT:
+		return "ENOENT"
+	case syscall.ENOSPC:
+		return "ENOSPC"
+	case syscall.ENOSYS:
+		return "ENOSYS"
+	case syscall.ENOTDIR:
+		return "ENOTDIR"
+	case syscall.ENOTEMPTY:
+		return "ENOTEMPTY"
+	case syscall.EPERM:
+		return "EPERM"
+	case syscall.ESTALE:
+		return "ESTALE"
+	case syscall.ETIMEDOUT:
+		return "ETIMEDOUT"
+	case syscall.EXDEV:
+		return "EXDEV"
+	default:
+		return "errno_" + strconv.Itoa(int(errno))
+	}
+}
+
+// `retryBudgetMetricsStruct` is used to record metrics for the (global) retry budget.
+// Tokens is a gauge set (by globals.retryBudget.updateMetrics()) as scraped.
+type retryBudgetMetricsStruct struct {
+	Tokens      prometheus.Gauge
+	Retries     prometheus.Counter
+	RetriesShed prometheus.Counter
+}
+
+// `newRetryBudgetMetrics` provisions and initializes a `retryBudgetMetricsStruct`.
+func newRetryBudgetMetrics() (retryBudgetMetrics *retryBudgetMetricsStruct) {
+	retryBudgetMetrics = &retryBudgetMetricsStruct{
+		Tokens: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "retry_budget_tokens",
+			Help: "Number of retries (across all backends) that may currently be issued before further retries are shed",
+		}),
+		Retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "retry_budget_retries_total",
+			Help: "Total number of retries admitted by the retry budget",
+		}),
+		RetriesShed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "retry_budget_retries_shed_total",
+			Help: "Total number of retries shed (their failures returned instead) as the retry budget was exhausted",
+		}),
 	}
 
 	return
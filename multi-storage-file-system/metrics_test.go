@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestBackendRequestStatus(t *testing.T) {
+	for _, testCase := range []struct {
+		err            error
+		expectedStatus string
+	}{
+		{nil, "ok"},
+		{testS3ResponseError(http.StatusServiceUnavailable), "503"},
+		{fmt.Errorf("wrapped: %w", testS3ResponseError(http.StatusNotFound)), "404"},
+		{syscall.ENOENT, "ENOENT"},
+		{fmt.Errorf("wrapped: %w", syscall.EIO), "EIO"},
+		{errors.New("other"), "error"},
+	} {
+		if status := backendRequestStatus(testCase.err); status != testCase.expectedStatus {
+			t.Fatalf("backendRequestStatus(%v) returned \"%s\" (expected \"%s\")", testCase.err, status, testCase.expectedStatus)
+		}
+	}
+}
+
+func TestBackendErrno(t *testing.T) {
+	for _, testCase := range []struct {
+		err           error
+		expectedErrno syscall.Errno
+	}{
+		{nil, 0},
+		{testS3ResponseError(http.StatusNotFound), syscall.ENOENT},
+		{testS3ResponseError(http.StatusForbidden), syscall.EACCES},
+		{fmt.Errorf("retry budget exhausted: %w", testS3ResponseError(http.StatusTooManyRequests)), syscall.EAGAIN},
+		{testS3ResponseError(http.StatusServiceUnavailable), syscall.EAGAIN},
+		{testS3ResponseError(http.StatusPreconditionFailed), syscall.ESTALE},
+		{testS3ResponseError(http.StatusInternalServerError), syscall.EIO},
+		{fmt.Errorf("eTag mismatch: %w", syscall.ESTALE), syscall.ESTALE},
+		{fmt.Errorf("ram down: %w", syscall.EHOSTDOWN), syscall.EHOSTDOWN},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), syscall.ETIMEDOUT},
+		{errors.New("checksum mismatch"), syscall.EIO},
+	} {
+		if errno := backendErrno(testCase.err); errno != testCase.expectedErrno {
+			t.Fatalf("backendErrno(%v) returned %s (expected %s)", testCase.err, errnoName(errno), errnoName(testCase.expectedErrno))
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	var (
+		errno            syscall.Errno
+		httpRecorder     *httptest.ResponseRecorder
+		lookupOut        *fission.LookupOut
+		openOut          *fission.OpenOut
+		ramDirIno        uint64
+		responseAsString string
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoRead(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 1})
+	if errno != 0 {
+		t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.ReleaseIn{FH: openOut.FH})
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileZ")})
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileZ\") returned errno: %v (expected ENOENT)", errno)
+	}
+
+	httpRecorder = httptest.NewRecorder()
+	globals.ServeHTTP(httpRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if httpRecorder.Code != http.StatusOK {
+		t.Fatalf("GET /metrics returned %v", httpRecorder.Code)
+	}
+
+	responseAsString = httpRecorder.Body.String()
+
+	for _, expected := range []string{
+		"fission_read_successes_total 1",
+		"fission_ops_total{op=\"lookup\",result=\"ENOENT\"} 1",
+		"fission_ops_total{op=\"lookup\",result=\"ok\"} 2",
+		"fission_ops_total{op=\"read\",result=\"ok\"} 1",
+		"backend_requests_total{operation=\"read\",status=\"ok\"} 1",
+		"backend_request_latency_seconds_count{operation=\"read\"} 1",
+		"backend_request_latency_quantile_seconds{operation=\"read\",quantile=\"0.99\"}",
+		"cache_clean_lines 1",
+		"cache_dirty_bytes 0",
+		"cache_inflight_fetches 0",
+		"cache_line_evictions_total 0",
+	} {
+		if !strings.Contains(responseAsString, expected) {
+			t.Fatalf("GET /metrics response missing \"%s\":\n%s", expected, responseAsString)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MigrationStateRunning = "running"
+	MigrationStateDone    = "done"
+	MigrationStateFailed  = "failed"
+
+	migrationWorkersDefault = uint64(8)
+	migrationWorkersMax     = uint64(256)
+)
+
+// `migrationStruct` tracks a migration (copy) of every file beneath a prefix of one
+// mounted backend to the same path of another. As files are copied and verified,
+// their paths are appended to stateFile (if globals.config.migrationStateDir != "")
+// such that a subsequent migration of the same prefix skips them.
+type migrationStruct struct {
+	sync.Mutex                       // Protects endTime through lastErr & serializes appends to stateFile
+	id           string              // Derived from srcDirName, dstDirName, & prefix
+	srcDirName   string              //
+	dstDirName   string              //
+	prefix       string              // Relative to backend.prefix (of both src & dst); if != "", ends with a trailing "/"
+	workers      uint64              //
+	stateFile    string              // If == "", progress is not recorded
+	startTime    time.Time           //
+	endTime      time.Time           // If state == MigrationStateRunning, time.Time{}
+	state        string              // One of MigrationState*
+	completed    map[string]struct{} // Paths recorded in stateFile by a prior (interrupted) migration
+	filesCopied  uint64              //
+	filesSkipped uint64              // Already in completed
+	filesFailed  uint64              //
+	bytesCopied  uint64              //
+	lastErr      error               // If != nil, the most recent failure
+	doneChan     chan struct{}       // Closed once state != MigrationStateRunning
+	srcContext   backendContextIf    //
+	dstContext   backendContextIf    //
+	filePathChan chan string         // Feeds workers
+	workerGroup  sync.WaitGroup      // Awaited after closing filePathChan
+}
+
+// `migrationID` returns the identifier of a migration of prefix from srcDirName to dstDirName.
+func migrationID(srcDirName string, dstDirName string, prefix string) (id string) {
+	var (
+		sum = sha256.Sum256([]byte(srcDirName + "\x00" + dstDirName + "\x00" + prefix))
+	)
+
+	id = hex.EncodeToString(sum[:8])
+
+	return
+}
+
+// `startMigration` is called to begin (or resume) copying every file beneath prefix
+// of the srcDirName backend to the dstDirName backend using the specified number of
+// parallel workers (if == 0, migrationWorkersDefault). Both backends must be mounted
+// (and remain so for the duration) and the dstDirName backend must be writable. The
+// migration proceeds in the background while the file system remains online.
+func startMigration(srcDirName string, dstDirName string, prefix string, workers uint64) (migration *migrationStruct, err error) {
+	var (
+		dstBackend *backendStruct
+		id         string
+		ok         bool
+		srcBackend *backendStruct
+	)
+
+	if (prefix != "") && (strings.HasPrefix(prefix, "/") || !strings.HasSuffix(prefix, "/")) {
+		err = fmt.Errorf("prefix \"%s\" must either be \"\" or end (but not start) with \"/\"", prefix)
+		return
+	}
+
+	if workers == 0 {
+		workers = migrationWorkersDefault
+	} else if workers > migrationWorkersMax {
+		err = fmt.Errorf("workers (%v) must not exceed %v", workers, migrationWorkersMax)
+		return
+	}
+
+	globals.Lock()
+	defer globals.Unlock()
+
+	srcBackend, ok = globals.config.backends[srcDirName]
+	if !ok || !srcBackend.mounted {
+		err = fmt.Errorf("src backend \"%s\" not mounted", srcDirName)
+		return
+	}
+	dstBackend, ok = globals.config.backends[dstDirName]
+	if !ok || !dstBackend.mounted {
+		err = fmt.Errorf("dst backend \"%s\" not mounted", dstDirName)
+		return
+	}
+	if srcBackend == dstBackend {
+		err = errors.New("src and dst backends must differ")
+		return
+	}
+	if dstBackend.readOnly {
+		err = fmt.Errorf("dst backend \"%s\" is readonly", dstDirName)
+		return
+	}
+
+	id = migrationID(srcDirName, dstDirName, prefix)
+
+	if globals.migrations == nil {
+		globals.migrations = make(map[string]*migrationStruct)
+	}
+
+	migration, ok = globals.migrations[id]
+	if ok && (migration.currentState() == MigrationStateRunning) {
+		err = fmt.Errorf("migration %s already running", id)
+		return
+	}
+
+	migration = &migrationStruct{
+		id:           id,
+		srcDirName:   srcDirName,
+		dstDirName:   dstDirName,
+		prefix:       prefix,
+		workers:      workers,
+		startTime:    time.Now(),
+		state:        MigrationStateRunning,
+		completed:    make(map[string]struct{}),
+		doneChan:     make(chan struct{}),
+		srcContext:   srcBackend.context,
+		dstContext:   dstBackend.context,
+		filePathChan: make(chan string, 2*workers),
+	}
+
+	if globals.config.migrationStateDir != "" {
+		err = os.MkdirAll(globals.config.migrationStateDir, 0o700)
+		if err != nil {
+			return
+		}
+
+		migration.stateFile = filepath.Join(globals.config.migrationStateDir, id+".migration")
+
+		err = migration.loadStateFile()
+		if err != nil {
+			err = fmt.Errorf("unable to load \"%s\": %v", migration.stateFile, err)
+			return
+		}
+	}
+
+	globals.migrations[id] = migration
+
+	go migration.run()
+
+	globals.logger.Printf("[INFO] [migration] %s started copying \"%s\" from %s to %s with %v workers (%v files previously completed)", id, prefix, srcDirName, dstDirName, workers, len(migration.completed))
+
+	return
+}
+
+// `loadStateFile` populates migration.completed from the paths recorded in
+// migration.stateFile by a prior (interrupted) migration, if any.
+func (migration *migrationStruct) loadStateFile() (err error) {
+	var (
+		filePath         string
+		scanner          *bufio.Scanner
+		stateFileContent []byte
+	)
+
+	stateFileContent, err = os.ReadFile(migration.stateFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	scanner = bufio.NewScanner(bytes.NewReader(stateFileContent))
+	for scanner.Scan() {
+		err = json.Unmarshal(scanner.Bytes(), &filePath)
+		if err != nil {
+			// Likely a torn write of the final entry... which will simply be copied again
+			err = nil
+			continue
+		}
+
+		migration.completed[filePath] = struct{}{}
+	}
+
+	return
+}
+
+// `currentState` returns migration.state.
+func (migration *migrationStruct) currentState() (state string) {
+	migration.Lock()
+	state = migration.state
+	migration.Unlock()
+
+	return
+}
+
+// `run` is the background driver of a migration. It walks migration.prefix of the
+// src backend feeding each file found to migration.workers parallel workers.
+func (migration *migrationStruct) run() {
+	var (
+		err       error
+		stateFile *os.File
+		workerIdx uint64
+	)
+
+	if migration.stateFile != "" {
+		stateFile, err = os.OpenFile(migration.stateFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			migration.finish(fmt.Errorf("unable to open \"%s\": %v", migration.stateFile, err))
+			return
+		}
+	}
+
+	for workerIdx = 0; workerIdx < migration.workers; workerIdx++ {
+		migration.workerGroup.Go(func() {
+			migration.worker(stateFile)
+		})
+	}
+
+	err = migration.walk(migration.prefix)
+
+	close(migration.filePathChan)
+	migration.workerGroup.Wait()
+
+	if stateFile != nil {
+		_ = stateFile.Close()
+	}
+
+	migration.finish(err)
+}
+
+// `walk` recursively lists dirPath of the src backend feeding each file found to the workers.
+func (migration *migrationStruct) walk(dirPath string) (err error) {
+	var (
+		continuationToken       string
+		listDirectoryOutput     *listDirectoryOutputStruct
+		listDirectoryOutputFile listDirectoryOutputFileStruct
+		subdirectories          []string
+		subdirectory            string
+	)
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(migration.srcContext, &listDirectoryInputStruct{
+			continuationToken: continuationToken,
+			maxItems:          migration.srcContext.backendCommon().directoryPageSize,
+			dirPath:           dirPath,
+			bulk:              true,
+		})
+		if err != nil {
+			err = fmt.Errorf("unable to list \"%s\" of %s: %v", dirPath, migration.srcDirName, err)
+			return
+		}
+
+		subdirectories = append(subdirectories, listDirectoryOutput.subdirectory...)
+
+		for _, listDirectoryOutputFile = range listDirectoryOutput.file {
+			migration.filePathChan <- dirPath + listDirectoryOutputFile.basename
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			break
+		}
+
+		continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+
+	for _, subdirectory = range subdirectories {
+		err = migration.walk(dirPath + subdirectory + "/")
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `worker` copies (and verifies) each file fed by walk() not already completed
+// by a prior migration, recording each success in stateFile (if != nil).
+func (migration *migrationStruct) worker(stateFile *os.File) {
+	var (
+		bytesCopied uint64
+		err         error
+		filePath    string
+		ok          bool
+		stateEntry  []byte
+	)
+
+	for filePath = range migration.filePathChan {
+		migration.Lock()
+		_, ok = migration.completed[filePath]
+		if ok {
+			migration.filesSkipped++
+		}
+		migration.Unlock()
+		if ok {
+			continue
+		}
+
+		bytesCopied, err = migration.copyAndVerify(filePath)
+
+		migration.Lock()
+		if err == nil {
+			migration.filesCopied++
+			migration.bytesCopied += bytesCopied
+
+			if stateFile != nil {
+				stateEntry, _ = json.Marshal(filePath)
+				_, err = stateFile.Write(append(stateEntry, '\n'))
+				if err != nil {
+					globals.logger.Printf("[WARN] [migration] %s unable to record \"%s\" in \"%s\": %v", migration.id, filePath, migration.stateFile, err)
+				}
+			}
+		} else {
+			migration.filesFailed++
+			migration.lastErr = err
+			globals.logger.Printf("[WARN] [migration] %s unable to copy \"%s\" from %s to %s: %v", migration.id, filePath, migration.srcDirName, migration.dstDirName, err)
+		}
+		migration.Unlock()
+	}
+}
+
+// `copyAndVerify` copies filePath from the src backend to the dst backend and then
+// reads it back from the dst backend to verify its SHA-256 checksum matches.
+func (migration *migrationStruct) copyAndVerify(filePath string) (bytesCopied uint64, err error) {
+	var (
+		dstBuf []byte
+		srcBuf []byte
+	)
+
+	srcBuf, _, err = readWholeFile(migration.srcContext, filePath)
+	if err != nil {
+		return
+	}
+
+	_, err = writeFileWrapper(migration.dstContext, &writeFileInputStruct{
+		filePath: filePath,
+		buf:      srcBuf,
+	})
+	if err != nil {
+		return
+	}
+
+	dstBuf, _, err = readWholeFile(migration.dstContext, filePath)
+	if err != nil {
+		return
+	}
+
+	if sha256.Sum256(srcBuf) != sha256.Sum256(dstBuf) {
+		err = errors.New("checksum mismatch after copy")
+		return
+	}
+
+	bytesCopied = uint64(len(srcBuf))
+
+	return
+}
+
+// `finish` concludes a migration. If it fully succeeded, the state file is no longer
+// needed (a subsequent migration of the same prefix should start afresh) and is removed.
+func (migration *migrationStruct) finish(err error) {
+	migration.Lock()
+
+	if err != nil {
+		migration.lastErr = err
+		migration.state = MigrationStateFailed
+	} else if migration.filesFailed > 0 {
+		migration.state = MigrationStateFailed
+	} else {
+		migration.state = MigrationStateDone
+	}
+
+	migration.endTime = time.Now()
+
+	if (migration.state == MigrationStateDone) && (migration.stateFile != "") {
+		err = os.Remove(migration.stateFile)
+		if (err != nil) && !errors.Is(err, fs.ErrNotExist) {
+			globals.logger.Printf("[WARN] [migration] %s unable to remove \"%s\": %v", migration.id, migration.stateFile, err)
+		}
+	}
+
+	globals.logger.Printf("[INFO] [migration] %s", migration.statusAlreadyLocked())
+
+	migration.Unlock()
+
+	close(migration.doneChan)
+}
+
+// `status` returns a single line summarizing the progress of a migration.
+func (migration *migrationStruct) status() (status string) {
+	migration.Lock()
+	status = migration.statusAlreadyLocked()
+	migration.Unlock()
+
+	return
+}
+
+// `statusAlreadyLocked` is called while migration.Lock() is held to
+// return a single line summarizing the progress of a migration.
+func (migration *migrationStruct) statusAlreadyLocked() (status string) {
+	var (
+		elapsed time.Duration
+	)
+
+	if migration.state == MigrationStateRunning {
+		elapsed = time.Since(migration.startTime)
+	} else {
+		elapsed = migration.endTime.Sub(migration.startTime)
+	}
+
+	status = fmt.Sprintf("%s %s -> %s prefix:\"%s\" state:%s copied:%v skipped:%v failed:%v bytes:%v elapsed:%v",
+		migration.id, migration.srcDirName, migration.dstDirName, migration.prefix, migration.state,
+		migration.filesCopied, migration.filesSkipped, migration.filesFailed, migration.bytesCopied,
+		elapsed.Truncate(time.Millisecond))
+
+	if migration.lastErr != nil {
+		status += fmt.Sprintf(" last_err:\"%v\"", migration.lastErr)
+	}
+
+	return
+}
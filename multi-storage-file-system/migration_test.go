@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMigration(t *testing.T) {
+	var (
+		dstBackend        *backendStruct
+		err               error
+		fileContent       []byte
+		migration         *migrationStruct
+		migrationStateDir = t.TempDir()
+		ok                bool
+		srcBackend        *backendStruct
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"migration_state_dir": "`+migrationStateDir+`",
+		"backends": [
+			{
+				"dir_name": "src",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "dst",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	srcBackend, ok = globals.config.backends["src"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"src\"] returned !ok")
+	}
+	dstBackend, ok = globals.config.backends["dst"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"dst\"] returned !ok")
+	}
+
+	for _, filePath := range []string{"data/a", "data/sub/b", "data/sub/c", "other/d"} {
+		_, err = writeFileWrapper(srcBackend.context, &writeFileInputStruct{
+			filePath: filePath,
+			buf:      []byte(filePath),
+		})
+		if err != nil {
+			t.Fatalf("writeFileWrapper(src, \"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	_, err = startMigration("src", "src", "data/", 0)
+	if err == nil {
+		t.Fatalf("startMigration(\"src\", \"src\", ...) unexpectedly succeeded")
+	}
+	_, err = startMigration("src", "dst", "data", 0)
+	if err == nil {
+		t.Fatalf("startMigration(..., \"data\", ...) unexpectedly succeeded")
+	}
+
+	// Pretend a prior migration was interrupted after completing "data/sub/b"
+
+	err = os.WriteFile(filepath.Join(migrationStateDir, migrationID("src", "dst", "data/")+".migration"), []byte("\"data/sub/b\"\n"), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() of state file failed: %v", err)
+	}
+
+	migration, err = startMigration("src", "dst", "data/", 2)
+	if err != nil {
+		t.Fatalf("startMigration(\"src\", \"dst\", \"data/\", 2) failed: %v", err)
+	}
+
+	select {
+	case <-migration.doneChan:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("migration failed to complete: %s", migration.status())
+	}
+
+	if (migration.state != MigrationStateDone) || (migration.filesCopied != 2) || (migration.filesSkipped != 1) || (migration.filesFailed != 0) {
+		t.Fatalf("migration concluded with unexpected status: %s", migration.status())
+	}
+
+	for _, filePath := range []string{"data/a", "data/sub/c"} {
+		fileContent, _, err = readWholeFile(dstBackend.context, filePath)
+		if (err != nil) || !bytes.Equal(fileContent, []byte(filePath)) {
+			t.Fatalf("readWholeFile(dst, \"%s\") returned %q, %v", filePath, fileContent, err)
+		}
+	}
+
+	_, err = statFileWrapper(dstBackend.context, &statFileInputStruct{filePath: "other/d"})
+	if err == nil {
+		t.Fatalf("migration of \"data/\" unexpectedly copied \"other/d\"")
+	}
+
+	_, err = os.Stat(migration.stateFile)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("completed migration failed to remove its state file (err: %v)", err)
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	MirrorJournalOpDelete = "delete"
+	MirrorJournalOpWrite  = "write"
+)
+
+// `mirrorStruct` tracks, for a backend specifying a mirror, the context of that
+// mirror (while mounted) and the journal of operations yet to be applied to it.
+type mirrorStruct struct {
+	sync.Mutex                     // Protects mirrorContext & serializes access to primary.mirrorJournalFile
+	primary       *backendStruct   //
+	mirrorContext backendContextIf // If nil, the mirror is not currently mounted
+	stopChan      chan struct{}    // Closed to stop reconciler()
+	stopWaitGroup sync.WaitGroup   // Awaited after closing stopChan
+}
+
+// `mirrorJournalEntryStruct` is the JSON-encoded form of each line of
+// a mirror journal describing an operation successfully applied to the
+// primary backend that has yet to be successfully applied to its mirror.
+type mirrorJournalEntryStruct struct {
+	Op       string `json:"op"`             // One of MirrorJournalOp*
+	FilePath string `json:"file_path"`      // Relative to backend.prefix (of both primary & mirror)
+	ETag     string `json:"etag,omitempty"` // If Op == MirrorJournalOpWrite, the eTag (if any) the primary reported for the write
+}
+
+// `refreshMirrorsAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start tracking the mirror of each newly mounted
+// backend specifying one and to (re)link each such backend to its mirror's context.
+func refreshMirrorsAlreadyLocked() {
+	var (
+		backend       *backendStruct
+		mirrorBackend *backendStruct
+		mirrorContext backendContextIf
+		ok            bool
+	)
+
+	for _, backend = range globals.config.backends {
+		if backend.mirror == "" {
+			continue
+		}
+
+		mirrorBackend, ok = globals.config.backends[backend.mirror]
+		if ok && mirrorBackend.mounted {
+			mirrorContext = mirrorBackend.context
+		} else {
+			mirrorContext = nil
+		}
+
+		if backend.mirrorState == nil {
+			backend.mirrorState = &mirrorStruct{
+				primary:       backend,
+				mirrorContext: mirrorContext,
+				stopChan:      make(chan struct{}),
+			}
+
+			backend.mirrorState.stopWaitGroup.Go(backend.mirrorState.reconciler)
+		} else {
+			backend.mirrorState.Lock()
+			backend.mirrorState.mirrorContext = mirrorContext
+			backend.mirrorState.Unlock()
+		}
+	}
+}
+
+// `stopMirrorAlreadyLocked` is called while globals.Lock() is held as backend is
+// unmounted to stop tracking its mirror (if any) and to unlink any other backend
+// using it as a mirror. Operations not yet applied to the mirror remain journaled.
+func (backend *backendStruct) stopMirrorAlreadyLocked() {
+	var (
+		otherBackend *backendStruct
+	)
+
+	if backend.mirrorState != nil {
+		close(backend.mirrorState.stopChan)
+		backend.mirrorState.stopWaitGroup.Wait()
+		backend.mirrorState.Lock()
+		backend.mirrorState.mirrorContext = nil
+		backend.mirrorState.Unlock()
+	}
+
+	for _, otherBackend = range globals.config.backends {
+		if (otherBackend.mirror == backend.dirName) && (otherBackend.mirrorState != nil) {
+			otherBackend.mirrorState.Lock()
+			otherBackend.mirrorState.mirrorContext = nil
+			otherBackend.mirrorState.Unlock()
+		}
+	}
+}
+
+// `mirrorDeleteFiles` is called after filePaths have been successfully deleted from
+// backend to synchronously delete them from its mirror (if any). Should that fail,
+// the deletes are journaled for later application by reconciler().
+func (backend *backendStruct) mirrorDeleteFiles(filePaths []string) {
+	var (
+		err           error
+		mirrorContext backendContextIf
+		mirrorState   = backend.mirrorState
+	)
+
+	if mirrorState == nil {
+		return
+	}
+
+	mirrorState.Lock()
+	mirrorContext = mirrorState.mirrorContext
+	mirrorState.Unlock()
+
+	if mirrorContext == nil {
+		err = errors.New("mirror not mounted")
+	} else {
+		_, err = deleteFilesWrapper(mirrorContext, &deleteFilesInputStruct{
+			filePaths: filePaths,
+		})
+		if err == nil {
+			return
+		}
+	}
+
+	globals.logger.Printf("[WARN] [mirror] unable to apply %v delete(s) from %s to %s (journaling): %v", len(filePaths), backend.dirName, backend.mirror, err)
+
+	mirrorState.Lock()
+	err = appendToMirrorJournal(backend.mirrorJournalFile, MirrorJournalOpDelete, filePaths, "")
+	mirrorState.Unlock()
+	if err != nil {
+		globals.logger.Printf("[WARN] [mirror] unable to journal %v delete(s) from %s to %s: %v", len(filePaths), backend.dirName, backend.mirror, err)
+	}
+}
+
+// `mirrorWriteFile` is called after writeFileInput has been successfully applied to
+// backend (which reported eTag for it) to synchronously apply it to its mirror (if any).
+// Should that fail, the write is journaled for later application by reconciler().
+func (backend *backendStruct) mirrorWriteFile(writeFileInput *writeFileInputStruct, eTag string) {
+	var (
+		err           error
+		mirrorContext backendContextIf
+		mirrorState   = backend.mirrorState
+	)
+
+	if mirrorState == nil {
+		return
+	}
+
+	mirrorState.Lock()
+	mirrorContext = mirrorState.mirrorContext
+	mirrorState.Unlock()
+
+	if mirrorContext == nil {
+		err = errors.New("mirror not mounted")
+	} else {
+		_, err = writeFileWrapper(mirrorContext, writeFileInput)
+		if err == nil {
+			return
+		}
+	}
+
+	globals.logger.Printf("[WARN] [mirror] unable to apply write of \"%s\" from %s to %s (journaling): %v", writeFileInput.filePath, backend.dirName, backend.mirror, err)
+
+	mirrorState.Lock()
+	err = appendToMirrorJournal(backend.mirrorJournalFile, MirrorJournalOpWrite, []string{writeFileInput.filePath}, eTag)
+	mirrorState.Unlock()
+	if err != nil {
+		globals.logger.Printf("[WARN] [mirror] unable to journal write of \"%s\" from %s to %s: %v", writeFileInput.filePath, backend.dirName, backend.mirror, err)
+	}
+}
+
+// `appendToMirrorJournal` is called while mirrorState.Lock() is held to durably
+// append an entry for op (recording eTag, if != "") on each of filePaths to the
+// specified journal file.
+func appendToMirrorJournal(mirrorJournalFile string, op string, filePaths []string, eTag string) (err error) {
+	var (
+		buf         bytes.Buffer
+		encoder     = json.NewEncoder(&buf)
+		filePath    string
+		journalFile *os.File
+	)
+
+	for _, filePath = range filePaths {
+		err = encoder.Encode(&mirrorJournalEntryStruct{
+			Op:       op,
+			FilePath: filePath,
+			ETag:     eTag,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	journalFile, err = os.OpenFile(mirrorJournalFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+
+	_, err = journalFile.Write(buf.Bytes())
+	if err == nil {
+		err = journalFile.Sync()
+	}
+	if err != nil {
+		_ = journalFile.Close()
+		return
+	}
+
+	err = journalFile.Close()
+
+	return
+}
+
+// `reconciler` is run as a background worker while the primary backend is mounted
+// to apply journaled operations to its mirror, first immediately (to catch up on
+// any left journaled from before) and then every primary.mirrorReconcileInterval.
+func (mirrorState *mirrorStruct) reconciler() {
+	var (
+		ticker = time.NewTicker(mirrorState.primary.mirrorReconcileInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		mirrorState.reconcile()
+
+		select {
+		case <-mirrorState.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// `reconcile` attempts to apply all journaled operations to the mirror. Rather than
+// replaying each operation, the mirror's copy of each journaled path is made to match
+// the primary's current state (i.e. copied if present in the primary and deleted if not)
+// rendering the order (and repetition) of journaled operations immaterial. The journal
+// is then rewritten to contain only those paths that could not be reconciled.
+func (mirrorState *mirrorStruct) reconcile() {
+	var (
+		err                error
+		filePath           string
+		filePathsToDelete  []string
+		filePathsToRetain  []string
+		filePathsSeen      = make(map[string]struct{})
+		journalContent     []byte
+		journalFileTmp     string
+		mirrorJournalEntry mirrorJournalEntryStruct
+		ok                 bool
+		primary            = mirrorState.primary
+		scanner            *bufio.Scanner
+	)
+
+	mirrorState.Lock()
+	defer mirrorState.Unlock()
+
+	if (mirrorState.mirrorContext == nil) || (primary.context == nil) {
+		return
+	}
+
+	journalContent, err = os.ReadFile(primary.mirrorJournalFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			globals.logger.Printf("[WARN] [mirror] unable to read journal \"%s\": %v", primary.mirrorJournalFile, err)
+		}
+		return
+	}
+
+	scanner = bufio.NewScanner(bytes.NewReader(journalContent))
+	for scanner.Scan() {
+		err = json.Unmarshal(scanner.Bytes(), &mirrorJournalEntry)
+		if (err != nil) || ((mirrorJournalEntry.Op != MirrorJournalOpDelete) && (mirrorJournalEntry.Op != MirrorJournalOpWrite)) {
+			// Likely a torn write of the final entry... which was therefore never acknowledged
+			globals.logger.Printf("[WARN] [mirror] skipping malformed entry in journal \"%s\": %s", primary.mirrorJournalFile, scanner.Text())
+			continue
+		}
+
+		filePath = mirrorJournalEntry.FilePath
+
+		_, ok = filePathsSeen[filePath]
+		if ok {
+			continue
+		}
+		filePathsSeen[filePath] = struct{}{}
+
+		_, err = statFileWrapper(primary.context, &statFileInputStruct{
+			filePath: filePath,
+			ifMatch:  "",
+			bulk:     true,
+		})
+		if err != nil {
+			if backendErrno(err) == syscall.ENOENT {
+				// No longer present in the primary
+				filePathsToDelete = append(filePathsToDelete, filePath)
+			} else {
+				// Whether still present in the primary is unknown (e.g. throttled), so try again later
+				globals.logger.Printf("[WARN] [mirror] unable to stat journaled \"%s\" in %s: %v", filePath, primary.dirName, err)
+				filePathsToRetain = append(filePathsToRetain, filePath)
+			}
+			continue
+		}
+
+		err = copyFile(primary.context, mirrorState.mirrorContext, filePath)
+		if err != nil {
+			globals.logger.Printf("[WARN] [mirror] unable to copy journaled \"%s\" from %s to %s: %v", filePath, primary.dirName, primary.mirror, err)
+			filePathsToRetain = append(filePathsToRetain, filePath)
+		}
+	}
+
+	if len(filePathsToDelete) > 0 {
+		_, err = deleteFilesWrapper(mirrorState.mirrorContext, &deleteFilesInputStruct{
+			filePaths: filePathsToDelete,
+		})
+		if err != nil {
+			globals.logger.Printf("[WARN] [mirror] unable to apply %v journaled delete(s) from %s to %s: %v", len(filePathsToDelete), primary.dirName, primary.mirror, err)
+			filePathsToRetain = append(filePathsToRetain, filePathsToDelete...)
+		}
+	}
+
+	if len(filePathsToRetain) < len(filePathsSeen) {
+		globals.logger.Printf("[INFO] [mirror] reconciled %v journaled path(s) from %s to %s", len(filePathsSeen)-len(filePathsToRetain), primary.dirName, primary.mirror)
+	}
+
+	// Replace the journal with one containing only those paths yet to be reconciled
+
+	journalFileTmp = primary.mirrorJournalFile + ".tmp"
+
+	err = os.Remove(journalFileTmp)
+	if (err != nil) && !errors.Is(err, fs.ErrNotExist) {
+		globals.logger.Printf("[WARN] [mirror] unable to remove \"%s\": %v", journalFileTmp, err)
+		return
+	}
+
+	if len(filePathsToRetain) > 0 {
+		err = appendToMirrorJournal(journalFileTmp, MirrorJournalOpWrite, filePathsToRetain, "")
+		if err != nil {
+			globals.logger.Printf("[WARN] [mirror] unable to write \"%s\": %v", journalFileTmp, err)
+			return
+		}
+
+		err = os.Rename(journalFileTmp, primary.mirrorJournalFile)
+		if err != nil {
+			globals.logger.Printf("[WARN] [mirror] unable to replace journal \"%s\": %v", primary.mirrorJournalFile, err)
+		}
+	} else {
+		err = os.Remove(primary.mirrorJournalFile)
+		if err != nil {
+			globals.logger.Printf("[WARN] [mirror] unable to remove journal \"%s\": %v", primary.mirrorJournalFile, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	var (
+		err               error
+		mirrorBackend     *backendStruct
+		mirrorFileContent []byte
+		mirrorJournalFile = filepath.Join(t.TempDir(), "mirror.journal")
+		ok                bool
+		primaryBackend    *backendStruct
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "primary",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"mirror": "mirror",
+				"mirror_journal_file": "`+mirrorJournalFile+`",
+				"mirror_reconcile_interval": 3600000
+			},
+			{
+				"dir_name": "mirror",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	primaryBackend, ok = globals.config.backends["primary"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"primary\"] returned !ok")
+	}
+	mirrorBackend, ok = globals.config.backends["mirror"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"mirror\"] returned !ok")
+	}
+
+	// With the mirror mounted, writes must be applied synchronously
+
+	_, err = writeFileWrapper(primaryBackend.context, &writeFileInputStruct{
+		filePath: "dir/fileA",
+		buf:      []byte("A"),
+	})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(primary, \"dir/fileA\") failed: %v", err)
+	}
+
+	mirrorFileContent, _, err = readWholeFile(mirrorBackend.context, "dir/fileA")
+	if (err != nil) || !bytes.Equal(mirrorFileContent, []byte("A")) {
+		t.Fatalf("readWholeFile(mirror, \"dir/fileA\") returned %q, %v (expected \"A\", nil)", mirrorFileContent, err)
+	}
+
+	// With the mirror (apparently) unmounted, writes and deletes must be journaled
+
+	primaryBackend.mirrorState.Lock()
+	primaryBackend.mirrorState.mirrorContext = nil
+	primaryBackend.mirrorState.Unlock()
+
+	_, err = deleteFileWrapper(primaryBackend.context, &deleteFileInputStruct{
+		filePath: "dir/fileA",
+	})
+	if err != nil {
+		t.Fatalf("deleteFileWrapper(primary, \"dir/fileA\") failed: %v", err)
+	}
+
+	_, err = writeFileWrapper(primaryBackend.context, &writeFileInputStruct{
+		filePath: "fileB",
+		buf:      []byte("B"),
+	})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(primary, \"fileB\") failed: %v", err)
+	}
+
+	_, err = os.Stat(mirrorJournalFile)
+	if err != nil {
+		t.Fatalf("os.Stat(mirrorJournalFile) failed: %v", err)
+	}
+
+	// Once the mirror is back, reconcile() must bring it up to date and remove the journal
+
+	primaryBackend.mirrorState.Lock()
+	primaryBackend.mirrorState.mirrorContext = mirrorBackend.context
+	primaryBackend.mirrorState.Unlock()
+
+	primaryBackend.mirrorState.reconcile()
+
+	_, err = statFileWrapper(mirrorBackend.context, &statFileInputStruct{
+		filePath: "dir/fileA",
+	})
+	if err == nil {
+		t.Fatalf("reconcile() failed to delete \"dir/fileA\" from the mirror")
+	}
+
+	mirrorFileContent, _, err = readWholeFile(mirrorBackend.context, "fileB")
+	if (err != nil) || !bytes.Equal(mirrorFileContent, []byte("B")) {
+		t.Fatalf("readWholeFile(mirror, \"fileB\") returned %q, %v (expected \"B\", nil)", mirrorFileContent, err)
+	}
+
+	_, err = os.Stat(mirrorJournalFile)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("reconcile() failed to remove the journal (err: %v)", err)
+	}
+}
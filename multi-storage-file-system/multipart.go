@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// `multipartGCStruct` tracks, for a backend specifying a multipart_upload_gc_interval,
+// the background worker periodically aborting its orphaned multipart uploads.
+type multipartGCStruct struct {
+	backend       *backendStruct //
+	stopChan      chan struct{}  // Closed to stop collector()
+	stopWaitGroup sync.WaitGroup // Awaited after closing stopChan
+}
+
+// `refreshMultipartGCsAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start collecting the orphaned multipart uploads
+// of each newly mounted backend specifying a multipart_upload_gc_interval.
+func refreshMultipartGCsAlreadyLocked() {
+	var (
+		backend *backendStruct
+	)
+
+	for _, backend = range globals.config.backends {
+		if (backend.multipartUploadGCInterval == 0) || (backend.multipartGC != nil) {
+			continue
+		}
+
+		backend.multipartGC = &multipartGCStruct{
+			backend:  backend,
+			stopChan: make(chan struct{}),
+		}
+
+		backend.multipartGC.stopWaitGroup.Go(backend.multipartGC.collector)
+	}
+}
+
+// `stopMultipartGCAlreadyLocked` is called while globals.Lock() is held as backend is
+// unmounted to stop collecting its orphaned multipart uploads (if it was doing so).
+func (backend *backendStruct) stopMultipartGCAlreadyLocked() {
+	if backend.multipartGC != nil {
+		close(backend.multipartGC.stopChan)
+		backend.multipartGC.stopWaitGroup.Wait()
+	}
+}
+
+// `collector` is run as a background worker while the backend is mounted to abort its
+// orphaned multipart uploads every backend.multipartUploadGCInterval. Should the backend
+// be unable to enumerate its multipart uploads, the worker logs as much and exits.
+func (multipartGC *multipartGCStruct) collector() {
+	var (
+		backend = multipartGC.backend
+		err     error
+		ticker  = time.NewTicker(backend.multipartUploadGCInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-multipartGC.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		_, err = backend.gcMultipartUploads(backend.multipartUploadMaxAge)
+		if errors.Is(err, syscall.ENOTSUP) {
+			globals.logger.Printf("[WARN] [multipart] %s unable to collect orphaned multipart uploads: %v", backend.dirName, err)
+			return
+		}
+		if err != nil {
+			globals.logger.Printf("[WARN] [multipart] %s failed to collect orphaned multipart uploads: %v", backend.dirName, err)
+		}
+	}
+}
+
+// `gcMultipartUploads` aborts each multipart upload beneath backend.prefix initiated more
+// than maxAge ago (and, thus, presumably orphaned by a writer that failed to complete it)
+// returning the number aborted. Failures to abort individual uploads are logged and the
+// first such failure returned once all have been attempted.
+func (backend *backendStruct) gcMultipartUploads(maxAge time.Duration) (numAborted uint64, err error) {
+	var (
+		abortErr                   error
+		cutoffTime                 = time.Now().Add(-maxAge)
+		listMultipartUploadsOutput *listMultipartUploadsOutputStruct
+		upload                     listMultipartUploadsOutputUploadStruct
+	)
+
+	if backend.readOnly {
+		err = fmt.Errorf("%s is readonly", backend.dirName)
+		return
+	}
+
+	err = backend.healthCheck()
+	if err != nil {
+		return
+	}
+
+	listMultipartUploadsOutput, err = backend.context.listMultipartUploads(&listMultipartUploadsInputStruct{
+		prefix: "",
+	})
+	if err != nil {
+		return
+	}
+
+	for _, upload = range listMultipartUploadsOutput.upload {
+		if upload.initiated.After(cutoffTime) {
+			continue
+		}
+
+		_, abortErr = backend.context.abortMultipartUpload(&abortMultipartUploadInputStruct{
+			filePath: upload.filePath,
+			uploadID: upload.uploadID,
+		})
+		if abortErr != nil {
+			globals.logger.Printf("[WARN] [multipart] %s unable to abort multipart upload \"%s\" of \"%s\" initiated %s: %v", backend.dirName, upload.uploadID, upload.filePath, upload.initiated.Format(time.RFC3339), abortErr)
+			if err == nil {
+				err = abortErr
+			}
+			continue
+		}
+
+		numAborted++
+
+		if backend.traceLevel > 0 {
+			globals.logger.Printf("[INFO] [multipart] %s aborted multipart upload \"%s\" of \"%s\" initiated %s", backend.dirName, upload.uploadID, upload.filePath, upload.initiated.Format(time.RFC3339))
+		}
+	}
+
+	if numAborted > 0 {
+		globals.logger.Printf("[INFO] [multipart] %s aborted %v orphaned multipart upload(s)", backend.dirName, numAborted)
+	}
+
+	return
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// `testMultipartContextStruct` overlays a backend's context with a fixed set of multipart uploads.
+type testMultipartContextStruct struct {
+	backendContextIf
+	uploads []listMultipartUploadsOutputUploadStruct
+	aborted []string
+}
+
+func (testMultipartContext *testMultipartContextStruct) listMultipartUploads(listMultipartUploadsInput *listMultipartUploadsInputStruct) (listMultipartUploadsOutput *listMultipartUploadsOutputStruct, err error) {
+	listMultipartUploadsOutput = &listMultipartUploadsOutputStruct{
+		upload: testMultipartContext.uploads,
+	}
+	return
+}
+
+func (testMultipartContext *testMultipartContextStruct) abortMultipartUpload(abortMultipartUploadInput *abortMultipartUploadInputStruct) (abortMultipartUploadOutput *abortMultipartUploadOutputStruct, err error) {
+	testMultipartContext.aborted = append(testMultipartContext.aborted, abortMultipartUploadInput.uploadID)
+	abortMultipartUploadOutput = &abortMultipartUploadOutputStruct{}
+	return
+}
+
+func TestMultipartGC(t *testing.T) {
+	var (
+		backend              *backendStruct
+		err                  error
+		numAborted           uint64
+		ok                   bool
+		testMultipartContext *testMultipartContextStruct
+		timeNow              = time.Now()
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "uploads",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"multipart_upload_gc_interval": 3600000,
+				"multipart_upload_max_age": 86400000
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	backend, ok = globals.config.backends["uploads"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"uploads\"] returned !ok")
+	}
+
+	numAborted, err = backend.gcMultipartUploads(backend.multipartUploadMaxAge)
+	if (err != nil) || (numAborted != 0) {
+		t.Fatalf("gcMultipartUploads() of RAM backend returned %v, %v (expected 0, nil)", numAborted, err)
+	}
+
+	testMultipartContext = &testMultipartContextStruct{
+		backendContextIf: backend.context,
+		uploads: []listMultipartUploadsOutputUploadStruct{
+			{filePath: "stale", uploadID: "stale-upload", initiated: timeNow.Add(-48 * time.Hour)},
+			{filePath: "active", uploadID: "active-upload", initiated: timeNow.Add(-time.Minute)},
+		},
+	}
+
+	backend.context = testMultipartContext
+	defer func() {
+		backend.context = testMultipartContext.backendContextIf
+	}()
+
+	numAborted, err = backend.gcMultipartUploads(backend.multipartUploadMaxAge)
+	if (err != nil) || (numAborted != 1) {
+		t.Fatalf("gcMultipartUploads() returned %v, %v (expected 1, nil)", numAborted, err)
+	}
+	if !slices.Equal(testMultipartContext.aborted, []string{"stale-upload"}) {
+		t.Fatalf("gcMultipartUploads() aborted %v (expected [stale-upload])", testMultipartContext.aborted)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// `pathSettings` returns the caching (and readonly) settings applicable to path of the
+// backend. Those of the longest matching entry of backend.pathOverrides apply, else those
+// of the backend itself (and the global ones). Note: This call must be made while holding
+// the globals.Lock() as SIGHUP may replace backend.pathOverrides.
+func (backend *backendStruct) pathSettings(path string) (pathSettings backendPathOverrideStruct) {
+	var (
+		matched      bool
+		pathOverride backendPathOverrideStruct
+	)
+
+	pathSettings = backendPathOverrideStruct{
+		prefix:               "",
+		readOnly:             backend.readOnly,
+		cacheLineSize:        globals.config.cacheLineSize,
+		cacheLinesToPrefetch: globals.config.cacheLinesToPrefetch,
+		directReadThreshold:  globals.config.directReadThreshold,
+		cacheLineTTL:         globals.config.cacheLineTTL,
+		eTagConflictPolicy:   globals.config.eTagConflictPolicy,
+		statOnOpen:           globals.config.statOnOpen,
+		entryAttrTTL:         globals.config.entryAttrTTL,
+	}
+
+	for _, pathOverride = range backend.pathOverrides {
+		if (!matched || (len(pathOverride.prefix) > len(pathSettings.prefix))) && strings.HasPrefix(path, pathOverride.prefix) {
+			matched = true
+			pathSettings = pathOverride
+		}
+	}
+
+	return
+}
+
+// `readOnlyAt` returns whether path of the backend may not be modified (either
+// because the backend itself is readonly or a matching path override is).
+func (backend *backendStruct) readOnlyAt(path string) bool {
+	return backend.pathSettings(path).readOnly
+}
+
+// `entryAttrTTL` returns the amount of time Linux VFS is allowed to cache the
+// metadata returned for inode. Note: This call must be made while holding the
+// globals.Lock().
+func (inode *inodeStruct) entryAttrTTL() time.Duration {
+	if inode.backend == nil {
+		return globals.config.entryAttrTTL
+	}
+
+	return inode.backend.pathSettings(inode.objectPath).entryAttrTTL
+}
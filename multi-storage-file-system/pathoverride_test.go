@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestPathOverrides(t *testing.T) {
+	var (
+		backend      *backendStruct
+		errno        syscall.Errno
+		fileBFH      uint64
+		fileBIno     uint64
+		fileBOffset  uint64
+		inHeader     *fission.InHeader
+		lookupOut    *fission.LookupOut
+		ok           bool
+		openOut      *fission.OpenOut
+		pathSettings backendPathOverrideStruct
+		ramDirIno    uint64
+		readOut      *fission.ReadOut
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	backend, ok = globals.config.backends["ram"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"ram\"] returned !ok")
+	}
+
+	globals.Lock()
+	backend.pathOverrides = []backendPathOverrideStruct{
+		{prefix: "dir1/", readOnly: true, cacheLineSize: 65536, cacheLinesToPrefetch: 0, entryAttrTTL: time.Second},
+		{prefix: "dir1/dir3/", readOnly: true, cacheLineSize: 16384, cacheLinesToPrefetch: 1, entryAttrTTL: 0},
+		{prefix: "fileB", readOnly: true, cacheLineSize: 4096, cacheLinesToPrefetch: 2, entryAttrTTL: 0},
+	}
+	globals.Unlock()
+
+	// The longest matching prefix applies, else the backend's (and global) settings
+
+	for _, testCase := range []struct {
+		path          string
+		readOnly      bool
+		cacheLineSize uint64
+	}{
+		{"fileA", false, globals.config.cacheLineSize},
+		{"dir1/fileC", true, 65536},
+		{"dir1/dir3/fileD", true, 16384},
+		{"fileB", true, 4096},
+	} {
+		globals.Lock()
+		pathSettings = backend.pathSettings(testCase.path)
+		globals.Unlock()
+
+		if (pathSettings.readOnly != testCase.readOnly) || (pathSettings.cacheLineSize != testCase.cacheLineSize) {
+			t.Fatalf("pathSettings(\"%s\") returned %+v", testCase.path, pathSettings)
+		}
+	}
+
+	// A file beneath a path override is cached in lines of its cache_line_size and may not be written
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileB")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+	if (lookupOut.EntryOut.EntryValidSec != 0) || (lookupOut.EntryOut.EntryValidNSec != 0) {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") returned non-zero EntryValid{Sec|NSec} (expected entry_attr_ttl of 0)")
+	}
+	fileBIno = lookupOut.EntryOut.NodeID
+
+	inHeader = &fission.InHeader{NodeID: fileBIno}
+
+	_, errno = globals.DoOpen(inHeader, &fission.OpenIn{Flags: fission.FOpenRequestRDWR})
+	if errno != syscall.EACCES {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDWR) returned errno: %v (expected EACCES)", errno)
+	}
+
+	openOut, errno = globals.DoOpen(inHeader, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileBIno, Flags: fission.FOpenRequestRDONLY) unexpectedly failed (errno: %v)", errno)
+	}
+	fileBFH = openOut.FH
+
+	for fileBOffset = 0; fileBOffset < 65536; fileBOffset += uint64(len(readOut.Data)) {
+		readOut, errno = globals.DoRead(inHeader, &fission.ReadIn{FH: fileBFH, Offset: fileBOffset, Size: 10000})
+		if errno != 0 {
+			t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly failed (errno: %v)", fileBOffset, errno)
+		}
+		if !bytes.Equal(readOut.Data, testFissionFileBContent[fileBOffset:(fileBOffset+uint64(len(readOut.Data)))]) {
+			t.Fatalf("DoRead(FH: fileBFH, Offset: %v) unexpectedly returned mismatched bytes", fileBOffset)
+		}
+	}
+
+	globals.Lock()
+	if globals.inodeMap[fileBIno].cacheLineSize != 4096 {
+		t.Fatalf("inode.cacheLineSize == %v (expected 4096)", globals.inodeMap[fileBIno].cacheLineSize)
+	}
+	globals.Unlock()
+
+	errno = globals.DoRelease(inHeader, &fission.ReleaseIn{FH: fileBFH})
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileBFH) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Creating a file beneath a readonly path override is not permitted
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("dir1")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"dir1\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoCreate(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.CreateIn{Name: []byte("fileF"), Mode: syscall.S_IFREG | 0o644})
+	if errno != syscall.EPERM {
+		t.Fatalf("DoCreate(dir1Ino,Name:\"fileF\") returned errno: %v (expected EPERM)", errno)
+	}
+}
+
+func TestPathOverridesConfig(t *testing.T) {
+	var (
+		backend *backendStruct
+		err     error
+		ok      bool
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+cache_lines_to_prefetch: 3
+backends:
+  - dir_name: ram
+    bucket_container_name: ignored
+    backend_type: RAM
+    readonly: false
+    path_overrides:
+      - prefix: meta/
+        cache_line_size: 4096
+        cache_lines_to_prefetch: 0
+      - prefix: frozen/
+        readonly: true
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	backend, ok = globals.backendsToMount["ram"]
+	if !ok || (len(backend.pathOverrides) != 2) {
+		t.Fatalf("checkConfigFile() failed to parse backends[\"ram\"].path_overrides")
+	}
+	if (backend.pathOverrides[0].cacheLineSize != 4096) || (backend.pathOverrides[0].cacheLinesToPrefetch != 0) || backend.pathOverrides[0].readOnly {
+		t.Fatalf("checkConfigFile() parsed path_overrides[0] as %+v", backend.pathOverrides[0])
+	}
+	if (backend.pathOverrides[1].cacheLineSize != globals.config.cacheLineSize) || (backend.pathOverrides[1].cacheLinesToPrefetch != 3) || !backend.pathOverrides[1].readOnly || (backend.pathOverrides[1].entryAttrTTL != globals.config.entryAttrTTL) {
+		t.Fatalf("checkConfigFile() parsed path_overrides[1] as %+v", backend.pathOverrides[1])
+	}
+
+	// A path override may not make a prefix of a readonly backend writable
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+backends:
+  - dir_name: ram
+    bucket_container_name: ignored
+    backend_type: RAM
+    path_overrides:
+      - prefix: scratch/
+        readonly: false
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = checkConfigFile()
+	if (err == nil) || !strings.Contains(err.Error(), "bad path_overrides[0]") {
+		t.Fatalf("checkConfigFile() returned %v (expected readonly: false beneath a readonly backend to be rejected)", err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	presignExpiresDefault = 1 * time.Hour
+	presignExpiresMax     = 7 * 24 * time.Hour // The longest SigV4 permits
+)
+
+// `presignMethods` are the HTTP methods for which runPresign() may presign a URL.
+var presignMethods = []string{http.MethodGet, http.MethodPut}
+
+// `presign` returns a URL (and the headers, if any, that must accompany its use)
+// permitting anyone holding it to perform method (GET or PUT) on the object at
+// filePath until expires has elapsed. It is signed with the backend's current
+// credentials (as resolved for any other request) without contacting the backend.
+func (s3Context *s3ContextStruct) presign(method string, filePath string, expires time.Duration) (url string, signedHeader http.Header, err error) {
+	var (
+		awsCredentials       aws.Credentials
+		backend              = s3Context.backend
+		backendS3            = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+		cancel               context.CancelFunc
+		ctx                  context.Context
+		depth                int
+		presignClient        = s3.NewPresignClient(s3Context.s3Client, s3.WithPresignExpires(expires))
+		presignedHTTPRequest *v4.PresignedHTTPRequest
+	)
+
+	_, _, _, depth = s3Context.versionsPath(filePath)
+	if depth != 0 {
+		err = errors.New("[S3] presign of a .versions path not supported")
+		return
+	}
+
+	ctx, cancel = s3Context.newRequestContext()
+	defer cancel()
+
+	switch method {
+	case http.MethodGet:
+		presignedHTTPRequest, err = presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(backend.bucketContainerName),
+			Key:    aws.String(backend.prefix + filePath),
+		})
+	case http.MethodPut:
+		presignedHTTPRequest, err = presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(backend.bucketContainerName),
+			Key:    aws.String(backend.prefix + filePath),
+		})
+	default:
+		err = fmt.Errorf("[S3] presign of method %s not supported", method)
+	}
+	if err != nil {
+		return
+	}
+
+	url = presignedHTTPRequest.URL
+	signedHeader = presignedHTTPRequest.SignedHeader
+
+	if backendS3.signatureVersion == S3SignatureVersionV2 {
+		// The SDK only presigns per SigV4, so its URL (having resolved the endpoint
+		// and addressing style) is instead presigned per SigV2
+
+		awsCredentials, err = s3Context.s3Client.Options().Credentials.Retrieve(ctx)
+		if err != nil {
+			err = fmt.Errorf("[S3] retrieving credentials failed: %w", err)
+			return
+		}
+
+		url, err = s3PresignURLV2(url, method, backend.bucketContainerName, s3Context.s3Client.Options().UsePathStyle, awsCredentials, time.Now().Add(expires))
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `runPresign` reports to w a URL presigned for method (one of presignMethods) on the
+// file identified by target (of the form <dir_name>/<path>) valid for expires. The
+// backend must be of backend_type "S3" (or "Sharded" across such backends, in which
+// case the URL addresses the shard holding the file). Any headers that must accompany
+// use of the URL (other than Host) follow it, one per line, as "<name>: <value>".
+func runPresign(w io.Writer, target string, method string, expires time.Duration) (err error) {
+	var (
+		backend        *backendStruct
+		backendContext backendContextIf
+		headerName     string
+		headerNames    []string
+		headerValue    string
+		ok             bool
+		path           string
+		readOnly       bool
+		s3Context      *s3ContextStruct
+		shardedContext *shardedContextStruct
+		signedHeader   http.Header
+		url            string
+	)
+
+	if !slices.Contains(presignMethods, method) {
+		err = fmt.Errorf("method must be one of %s", strings.Join(presignMethods, ", "))
+		return
+	}
+	if (expires <= 0) || (expires > presignExpiresMax) {
+		err = fmt.Errorf("expiry must be positive and no more than %v", presignExpiresMax)
+		return
+	}
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+	if path == "" {
+		err = fmt.Errorf("\"%s\" does not identify a file", target)
+		return
+	}
+
+	backendContext = backend.context
+
+	shardedContext, ok = backendContext.(*shardedContextStruct)
+	if ok {
+		backendContext, err = shardedContext.shardFor(path)
+		if err != nil {
+			return
+		}
+	}
+
+	s3Context, ok = backendContext.(*s3ContextStruct)
+	if !ok {
+		err = fmt.Errorf("backend \"%s\" is not of backend_type S3", backend.dirName)
+		return
+	}
+
+	if method == http.MethodPut {
+		globals.Lock()
+		readOnly = backend.readOnlyAt(path)
+		globals.Unlock()
+
+		if readOnly {
+			err = fmt.Errorf("\"%s\" is readonly", target)
+			return
+		}
+	}
+
+	url, signedHeader, err = s3Context.presign(method, path, expires)
+	if err != nil {
+		return
+	}
+
+	_, _ = fmt.Fprintln(w, url)
+
+	for headerName = range signedHeader {
+		if !strings.EqualFold(headerName, "Host") {
+			headerNames = append(headerNames, headerName)
+		}
+	}
+	slices.Sort(headerNames)
+
+	for _, headerName = range headerNames {
+		for _, headerValue = range signedHeader.Values(headerName) {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", headerName, headerValue)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresign(t *testing.T) {
+	var (
+		err          error
+		output       bytes.Buffer
+		presignedURL *url.URL
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "s3",
+				"bucket_container_name": "bucket",
+				"prefix": "prefix/",
+				"backend_type": "S3",
+				"readonly": false,
+				"path_overrides": [{"prefix": "frozen/", "readonly": true}],
+				"S3": {
+					"region": "us-west-2",
+					"endpoint": "http://127.0.0.1:9",
+					"access_key_id": "presignAccessKeyID",
+					"secret_access_key": "presignSecretAccessKey"
+				}
+			},
+			{
+				"dir_name": "s3v2",
+				"bucket_container_name": "bucket",
+				"backend_type": "S3",
+				"readonly": false,
+				"S3": {
+					"region": "us-west-2",
+					"endpoint": "http://127.0.0.1:9",
+					"access_key_id": "presignAccessKeyID",
+					"secret_access_key": "presignSecretAccessKey",
+					"signature_version": "v2"
+				}
+			},
+			{
+				"dir_name": "ram",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM"
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	for _, method := range presignMethods {
+		output.Reset()
+
+		err = runPresign(&output, "s3/dir/file", method, 10*time.Minute)
+		if err != nil {
+			t.Fatalf("runPresign(%s) failed: %v", method, err)
+		}
+
+		presignedURL, err = url.Parse(strings.SplitN(output.String(), "\n", 2)[0])
+		if err != nil {
+			t.Fatalf("runPresign(%s) output unparseable URL: %v\n%s", method, err, output.String())
+		}
+		if (presignedURL.Host != "127.0.0.1:9") || (presignedURL.Path != "/bucket/prefix/dir/file") {
+			t.Fatalf("runPresign(%s) presigned %s (expected http://127.0.0.1:9/bucket/prefix/dir/file)", method, presignedURL)
+		}
+		if presignedURL.Query().Get("X-Amz-Expires") != "600" {
+			t.Fatalf("runPresign(%s) presigned X-Amz-Expires=%s (expected 600)", method, presignedURL.Query().Get("X-Amz-Expires"))
+		}
+		if !strings.HasPrefix(presignedURL.Query().Get("X-Amz-Credential"), "presignAccessKeyID/") || (presignedURL.Query().Get("X-Amz-Signature") == "") {
+			t.Fatalf("runPresign(%s) presigned %s without the backend's credentials", method, presignedURL)
+		}
+	}
+
+	// A backend with signature_version "v2" presigns per SigV2's query string authentication
+
+	output.Reset()
+
+	err = runPresign(&output, "s3v2/dir/file", http.MethodGet, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("runPresign(s3v2) failed: %v", err)
+	}
+
+	presignedURL, err = url.Parse(strings.SplitN(output.String(), "\n", 2)[0])
+	if err != nil {
+		t.Fatalf("runPresign(s3v2) output unparseable URL: %v\n%s", err, output.String())
+	}
+	if (presignedURL.Path != "/bucket/dir/file") || (presignedURL.Query().Get("X-Amz-Signature") != "") {
+		t.Fatalf("runPresign(s3v2) presigned %s (expected a SigV2 URL for http://127.0.0.1:9/bucket/dir/file)", presignedURL)
+	}
+	if (presignedURL.Query().Get("AWSAccessKeyId") != "presignAccessKeyID") || (presignedURL.Query().Get("Signature") != s3SignatureV2("presignSecretAccessKey", "GET\n\n\n"+presignedURL.Query().Get("Expires")+"\n/bucket/dir/file")) {
+		t.Fatalf("runPresign(s3v2) presigned %s without the expected signature", presignedURL)
+	}
+
+	for _, testCase := range []struct {
+		target  string
+		method  string
+		expires time.Duration
+	}{
+		{"s3/dir/file", http.MethodDelete, time.Minute},     // Unsupported method
+		{"s3/dir/file", http.MethodGet, 0},                  // Non-positive expiry
+		{"s3/dir/file", http.MethodGet, 8 * 24 * time.Hour}, // Expiry beyond SigV4's limit
+		{"s3", http.MethodGet, time.Minute},                 // Not a file
+		{"s3/frozen/file", http.MethodPut, time.Minute},     // Readonly beneath a path_overrides prefix
+		{"ram/file", http.MethodGet, time.Minute},           // Not an S3 backend
+		{"missing/file", http.MethodGet, time.Minute},       // No such backend
+	} {
+		err = runPresign(&output, testCase.target, testCase.method, testCase.expires)
+		if err == nil {
+			t.Fatalf("runPresign(%q, %s, %v) unexpectedly succeeded", testCase.target, testCase.method, testCase.expires)
+		}
+	}
+}
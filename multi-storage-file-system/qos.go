@@ -0,0 +1,135 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// QoS priority classes in which backend requests are scheduled (most urgent first).
+const (
+	QoSClassInteractive = uint8(iota)
+	QoSClassNormal
+	QoSClassBulk
+
+	qosClasses = int(QoSClassBulk) + 1
+)
+
+// `qosClassByName` maps the JSON/YAML "priority" values to their QoS priority class.
+var qosClassByName = map[string]uint8{
+	"interactive": QoSClassInteractive,
+	"normal":      QoSClassNormal,
+	"bulk":        QoSClassBulk,
+}
+
+// `qosSchedulerStruct` limits the number of backend requests in flight (across all backends).
+// Once the limit is reached, requests wait in a FIFO queue per QoS priority class and, as
+// each in flight request completes, its slot is handed to the oldest request waiting in the
+// most urgent non-empty queue. As such, interactive requests are never starved by bulk ones.
+type qosSchedulerStruct struct {
+	sync.Mutex                         // Protects inFlight & waiters
+	maxInFlight uint64                 // == globals.config.maxConcurrentBackendRequests
+	inFlight    uint64                 // Includes slots handed to (but not yet resumed) waiters
+	waiters     [qosClasses]*list.List // Indexed by QoS priority class; each list.Element.Value is a chan struct{}
+}
+
+// `newQoSScheduler` returns a qosSchedulerStruct admitting up to maxInFlight concurrent
+// backend requests. If maxInFlight == 0, nil (i.e. no scheduling) is returned.
+func newQoSScheduler(maxInFlight uint64) (qosScheduler *qosSchedulerStruct) {
+	var (
+		qosClass int
+	)
+
+	if maxInFlight == 0 {
+		return
+	}
+
+	qosScheduler = &qosSchedulerStruct{
+		maxInFlight: maxInFlight,
+	}
+
+	for qosClass = range qosClasses {
+		qosScheduler.waiters[qosClass] = list.New()
+	}
+
+	return
+}
+
+// `qosClass` returns the QoS priority class of a request for path of the backend. The class of the
+// longest matching entry of backend.priorityPrefixes applies, else that of the backend itself. A bulk
+// request (e.g. prefetch or other background work) is never scheduled ahead of its QoSClassBulk peers.
+func (backend *backendStruct) qosClass(path string, bulk bool) (qosClass uint8) {
+	var (
+		matchedPrefixLen int
+		priorityPrefix   backendPriorityPrefixStruct
+	)
+
+	if bulk {
+		qosClass = QoSClassBulk
+		return
+	}
+
+	qosClass = backend.priority
+
+	for _, priorityPrefix = range backend.priorityPrefixes {
+		if (len(priorityPrefix.prefix) > matchedPrefixLen) && strings.HasPrefix(path, priorityPrefix.prefix) {
+			matchedPrefixLen = len(priorityPrefix.prefix)
+			qosClass = priorityPrefix.priority
+		}
+	}
+
+	return
+}
+
+// `acquire` is called prior to issuing a backend request of the specified QoS priority class,
+// blocking until it may proceed. Each call must be followed by a call to release() once the
+// request completes. Callers must not issue other backend requests while holding a slot.
+func (qosScheduler *qosSchedulerStruct) acquire(qosClass uint8) {
+	var (
+		waiterChan chan struct{}
+	)
+
+	if qosScheduler == nil {
+		return
+	}
+
+	qosScheduler.Lock()
+
+	if qosScheduler.inFlight < qosScheduler.maxInFlight {
+		qosScheduler.inFlight++
+		qosScheduler.Unlock()
+		return
+	}
+
+	waiterChan = make(chan struct{})
+	_ = qosScheduler.waiters[qosClass].PushBack(waiterChan)
+
+	qosScheduler.Unlock()
+
+	<-waiterChan
+}
+
+// `release` is called as a backend request admitted by acquire() completes.
+func (qosScheduler *qosSchedulerStruct) release() {
+	var (
+		qosClass int
+		waiters  *list.List
+	)
+
+	if qosScheduler == nil {
+		return
+	}
+
+	qosScheduler.Lock()
+	defer qosScheduler.Unlock()
+
+	for qosClass = range qosClasses {
+		waiters = qosScheduler.waiters[qosClass]
+		if waiters.Len() > 0 {
+			close(waiters.Remove(waiters.Front()).(chan struct{}))
+			return
+		}
+	}
+
+	qosScheduler.inFlight--
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQoSClass(t *testing.T) {
+	var (
+		backend = &backendStruct{
+			priority: QoSClassNormal,
+			priorityPrefixes: []backendPriorityPrefixStruct{
+				{prefix: "models/", priority: QoSClassInteractive},
+				{prefix: "models/warm/", priority: QoSClassBulk},
+			},
+		}
+	)
+
+	for _, testCase := range []struct {
+		path     string
+		bulk     bool
+		qosClass uint8
+	}{
+		{"data/a", false, QoSClassNormal},
+		{"models/a", false, QoSClassInteractive},
+		{"models/a", true, QoSClassBulk},
+		{"models/warm/a", false, QoSClassBulk},
+	} {
+		if backend.qosClass(testCase.path, testCase.bulk) != testCase.qosClass {
+			t.Fatalf("qosClass(\"%s\", %v) returned %v (expected %v)", testCase.path, testCase.bulk, backend.qosClass(testCase.path, testCase.bulk), testCase.qosClass)
+		}
+	}
+}
+
+func TestQoSScheduler(t *testing.T) {
+	var (
+		admittedChan = make(chan uint8, 2)
+		qosScheduler = newQoSScheduler(1)
+	)
+
+	if newQoSScheduler(0) != nil {
+		t.Fatalf("newQoSScheduler(0) returned non-nil")
+	}
+
+	qosScheduler.acquire(QoSClassNormal)
+
+	// Queue a bulk request ahead of an interactive one
+
+	for _, qosClass := range []uint8{QoSClassBulk, QoSClassInteractive} {
+		go func(qosClass uint8) {
+			qosScheduler.acquire(qosClass)
+			admittedChan <- qosClass
+		}(qosClass)
+
+		for {
+			qosScheduler.Lock()
+			if qosScheduler.waiters[qosClass].Len() == 1 {
+				qosScheduler.Unlock()
+				break
+			}
+			qosScheduler.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	qosScheduler.release()
+
+	if <-admittedChan != QoSClassInteractive {
+		t.Fatalf("bulk request admitted ahead of interactive request")
+	}
+
+	qosScheduler.release()
+
+	if <-admittedChan != QoSClassBulk {
+		t.Fatalf("bulk request not admitted")
+	}
+
+	qosScheduler.release()
+
+	if qosScheduler.inFlight != 0 {
+		t.Fatalf("qosScheduler.inFlight == %v after all requests released (expected 0)", qosScheduler.inFlight)
+	}
+}
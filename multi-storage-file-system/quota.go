@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// `quotaStruct` tracks, for a backend specifying quotas, the bytes used beneath the
+// prefix of each. Bytes written are added as writes are performed (with overwrites
+// and deletes not discounted) and the totals periodically reconciled against listings.
+type quotaStruct struct {
+	sync.Mutex                   // Protects usedBytes & writtenBytes
+	backend       *backendStruct //
+	usedBytes     []uint64       // Indexed as backend.quotas
+	writtenBytes  []uint64       // Indexed as backend.quotas; bytes reserved since the backend was mounted
+	stopChan      chan struct{}  // Closed to stop reconciler()
+	stopWaitGroup sync.WaitGroup // Awaited after closing stopChan
+}
+
+// `refreshQuotasAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start tracking the quotas of each newly
+// mounted backend specifying them.
+func refreshQuotasAlreadyLocked() {
+	var (
+		backend *backendStruct
+	)
+
+	for _, backend = range globals.config.backends {
+		if (len(backend.quotas) == 0) || (backend.quotaState != nil) {
+			continue
+		}
+
+		backend.quotaState = &quotaStruct{
+			backend:      backend,
+			usedBytes:    make([]uint64, len(backend.quotas)),
+			writtenBytes: make([]uint64, len(backend.quotas)),
+			stopChan:     make(chan struct{}),
+		}
+
+		backend.quotaState.stopWaitGroup.Go(backend.quotaState.reconciler)
+	}
+}
+
+// `stopQuotasAlreadyLocked` is called while globals.Lock() is held as backend is
+// unmounted to stop tracking its quotas (if any).
+func (backend *backendStruct) stopQuotasAlreadyLocked() {
+	if backend.quotaState != nil {
+		close(backend.quotaState.stopChan)
+		backend.quotaState.stopWaitGroup.Wait()
+	}
+}
+
+// `quotaReserve` is called prior to writing size bytes to filePath of backend. If
+// doing so would exceed any quota whose prefix contains filePath, an error wrapping
+// syscall.EDQUOT is returned. Otherwise, the bytes are charged against each such quota.
+func (backend *backendStruct) quotaReserve(filePath string, size uint64) (err error) {
+	var (
+		quotaIndex int
+		quotaState = backend.quotaState
+		quota      backendQuotaStruct
+	)
+
+	if quotaState == nil {
+		return
+	}
+
+	quotaState.Lock()
+	defer quotaState.Unlock()
+
+	for quotaIndex, quota = range backend.quotas {
+		if strings.HasPrefix(filePath, quota.prefix) && ((quotaState.usedBytes[quotaIndex] + size) > quota.maxBytes) {
+			err = fmt.Errorf("quota of %v bytes for prefix \"%s\" of %s exceeded (%v bytes used): %w", quota.maxBytes, quota.prefix, backend.dirName, quotaState.usedBytes[quotaIndex], syscall.EDQUOT)
+			return
+		}
+	}
+
+	for quotaIndex, quota = range backend.quotas {
+		if strings.HasPrefix(filePath, quota.prefix) {
+			quotaState.usedBytes[quotaIndex] += size
+			quotaState.writtenBytes[quotaIndex] += size
+		}
+	}
+
+	return
+}
+
+// `quotaRelease` is called should a write of size bytes to filePath
+// of backend previously reserved via quotaReserve() subsequently fail.
+func (backend *backendStruct) quotaRelease(filePath string, size uint64) {
+	var (
+		quotaIndex int
+		quotaState = backend.quotaState
+		quota      backendQuotaStruct
+	)
+
+	if quotaState == nil {
+		return
+	}
+
+	quotaState.Lock()
+	defer quotaState.Unlock()
+
+	for quotaIndex, quota = range backend.quotas {
+		if strings.HasPrefix(filePath, quota.prefix) {
+			quotaState.usedBytes[quotaIndex] -= min(size, quotaState.usedBytes[quotaIndex])
+		}
+	}
+}
+
+// `reconciler` is run as a background worker while the backend is mounted to
+// reconcile the bytes used beneath each quota's prefix against listings, first
+// immediately and then every backend.quotaReconcileInterval.
+func (quotaState *quotaStruct) reconciler() {
+	var (
+		ticker = time.NewTicker(quotaState.backend.quotaReconcileInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		quotaState.reconcile()
+
+		select {
+		case <-quotaState.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// `reconcile` replaces the bytes used beneath each quota's prefix with the total
+// size of the files listed there. Bytes written while listing are also retained
+// (even though the listing may already include them) to err on the side of caution.
+func (quotaState *quotaStruct) reconcile() {
+	var (
+		backend      = quotaState.backend
+		err          error
+		listedBytes  uint64
+		quotaIndex   int
+		quota        backendQuotaStruct
+		writtenBytes uint64
+	)
+
+	if backend.context == nil {
+		return
+	}
+
+	for quotaIndex, quota = range backend.quotas {
+		quotaState.Lock()
+		writtenBytes = quotaState.writtenBytes[quotaIndex]
+		quotaState.Unlock()
+
+		listedBytes, err = quotaState.sumDirectory(quota.prefix)
+		if err != nil {
+			globals.logger.Printf("[WARN] [quota] unable to reconcile quota for prefix \"%s\" of %s: %v", quota.prefix, backend.dirName, err)
+			continue
+		}
+
+		quotaState.Lock()
+		quotaState.usedBytes[quotaIndex] = listedBytes + (quotaState.writtenBytes[quotaIndex] - writtenBytes)
+		quotaState.Unlock()
+	}
+}
+
+// `sumDirectory` recursively lists dirPath of the backend returning the total size of the files found.
+func (quotaState *quotaStruct) sumDirectory(dirPath string) (listedBytes uint64, err error) {
+	var (
+		backend                 = quotaState.backend
+		continuationToken       string
+		listDirectoryOutput     *listDirectoryOutputStruct
+		listDirectoryOutputFile listDirectoryOutputFileStruct
+		subdirectories          []string
+		subdirectory            string
+		subdirectoryBytes       uint64
+	)
+
+	for {
+		select {
+		case <-quotaState.stopChan:
+			err = fmt.Errorf("%s unmounted", backend.dirName)
+			return
+		default:
+		}
+
+		listDirectoryOutput, err = listDirectoryWrapper(backend.context, &listDirectoryInputStruct{
+			continuationToken: continuationToken,
+			maxItems:          backend.directoryPageSize,
+			dirPath:           dirPath,
+			bulk:              true,
+		})
+		if err != nil {
+			return
+		}
+
+		subdirectories = append(subdirectories, listDirectoryOutput.subdirectory...)
+
+		for _, listDirectoryOutputFile = range listDirectoryOutput.file {
+			listedBytes += listDirectoryOutputFile.size
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			break
+		}
+
+		continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+
+	for _, subdirectory = range subdirectories {
+		subdirectoryBytes, err = quotaState.sumDirectory(dirPath + subdirectory + "/")
+		if err != nil {
+			return
+		}
+
+		listedBytes += subdirectoryBytes
+	}
+
+	return
+}
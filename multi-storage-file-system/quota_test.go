@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	var (
+		backend *backendStruct
+		err     error
+		ok      bool
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "scratch",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"quotas": [
+					{"prefix": "teamA/", "max_bytes": 10}
+				],
+				"quota_reconcile_interval": 3600000
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	backend, ok = globals.config.backends["scratch"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"scratch\"] returned !ok")
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "teamA/a", buf: []byte("123456")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"teamA/a\") failed: %v", err)
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "teamA/b", buf: []byte("123456")})
+	if !errors.Is(err, syscall.EDQUOT) {
+		t.Fatalf("writeFileWrapper(\"teamA/b\") returned %v (expected EDQUOT)", err)
+	}
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "teamB/b", buf: []byte("123456")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"teamB/b\") failed: %v", err)
+	}
+
+	// Deletes are only credited upon reconciliation
+
+	_, err = deleteFileWrapper(backend.context, &deleteFileInputStruct{filePath: "teamA/a"})
+	if err != nil {
+		t.Fatalf("deleteFileWrapper(\"teamA/a\") failed: %v", err)
+	}
+
+	backend.quotaState.reconcile()
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "teamA/b", buf: []byte("123456")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"teamA/b\") after reconcile() failed: %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	replicaLatencyWeight = 0.3 // Weight given to each new probe's latency in a replica's moving average
+)
+
+// `replicaStruct` tracks the probed latency of a backend (either the one specifying
+// replicas or one of those replicas) able to serve the same reads.
+type replicaStruct struct {
+	backend *backendStruct //
+	healthy bool           // If false, the most recent probe failed
+	latency time.Duration  // Exponentially weighted moving average of successful probe latencies
+}
+
+// `replicaRouterStruct` tracks, for a backend specifying replicas, which of it and
+// those replicas most recently responded fastest such that reads may be routed to it.
+type replicaRouterStruct struct {
+	sync.Mutex                    // Protects replica[].{healthy|latency} & nearest
+	backend       *backendStruct  //
+	replica       []replicaStruct // replica[0] is backend itself followed by each mounted member of backend.replicas
+	nearest       *backendStruct  // The healthy replica (possibly backend itself) with the lowest latency
+	stopChan      chan struct{}   // Closed to stop prober()
+	stopWaitGroup sync.WaitGroup  // Awaited after closing stopChan
+}
+
+// `refreshReplicasAlreadyLocked` is called while globals.Lock() is held, after backends
+// have been mounted, to start probing the latency of each newly mounted backend
+// specifying replicas (and of those replicas).
+func refreshReplicasAlreadyLocked() {
+	var (
+		backend        *backendStruct
+		ok             bool
+		replicaBackend *backendStruct
+		replicaDirName string
+		replicaRouter  *replicaRouterStruct
+	)
+
+	for _, backend = range globals.config.backends {
+		if (len(backend.replicas) == 0) || (backend.replicaRouter != nil) {
+			continue
+		}
+
+		replicaRouter = &replicaRouterStruct{
+			backend:  backend,
+			replica:  []replicaStruct{{backend: backend, healthy: true}},
+			nearest:  backend,
+			stopChan: make(chan struct{}),
+		}
+
+		for _, replicaDirName = range backend.replicas {
+			replicaBackend, ok = globals.config.backends[replicaDirName]
+			if !ok || (replicaBackend.context == nil) {
+				globals.logger.Printf("[WARN] [replica] %s unable to find replica \"%s\"", backend.dirName, replicaDirName)
+				continue
+			}
+
+			replicaRouter.replica = append(replicaRouter.replica, replicaStruct{backend: replicaBackend})
+		}
+
+		backend.replicaRouter = replicaRouter
+
+		replicaRouter.stopWaitGroup.Go(replicaRouter.prober)
+	}
+}
+
+// `stopReplicaRouterAlreadyLocked` is called while globals.Lock() is held as backend
+// is unmounted to stop probing its replicas (if it specified any).
+func (backend *backendStruct) stopReplicaRouterAlreadyLocked() {
+	if backend.replicaRouter != nil {
+		close(backend.replicaRouter.stopChan)
+		backend.replicaRouter.stopWaitGroup.Wait()
+	}
+}
+
+// `nearestReplicaContext` returns the context of the replica to which reads of backend
+// should be routed or nil if they should be issued to backend itself.
+func (backend *backendStruct) nearestReplicaContext() (replicaContext backendContextIf) {
+	var (
+		replicaRouter = backend.replicaRouter
+	)
+
+	if replicaRouter == nil {
+		return
+	}
+
+	replicaRouter.Lock()
+	if replicaRouter.nearest != backend {
+		replicaContext = replicaRouter.nearest.context
+	}
+	replicaRouter.Unlock()
+
+	return
+}
+
+// `hedgeReplicaContext` returns, if backend specifies a replica_hedge_delay, the context of
+// the usable replica (possibly backend itself) with the lowest latency other than the one
+// to which reads are routed. Otherwise, or if there is no such replica, nil is returned.
+func (backend *backendStruct) hedgeReplicaContext() (hedgeContext backendContextIf) {
+	var (
+		hedge         *replicaStruct
+		replica       *replicaStruct
+		replicaIndex  int
+		replicaRouter = backend.replicaRouter
+	)
+
+	if (replicaRouter == nil) || (backend.replicaHedgeDelay == 0) {
+		return
+	}
+
+	replicaRouter.Lock()
+	defer replicaRouter.Unlock()
+
+	for replicaIndex = range replicaRouter.replica {
+		replica = &replicaRouter.replica[replicaIndex]
+
+		if (replica.backend == replicaRouter.nearest) || !replica.usable() {
+			continue
+		}
+
+		if (hedge == nil) || (replica.latency < hedge.latency) {
+			hedge = replica
+		}
+	}
+
+	if hedge != nil {
+		hedgeContext = hedge.backend.context
+	}
+
+	return
+}
+
+// `hedgeReadFileResultStruct` conveys the outcome of one of the reads issued by hedgeReadFile().
+type hedgeReadFileResultStruct struct {
+	readFileOutput *readFileOutputStruct
+	err            error
+}
+
+// `hedgeReadFile` issues readFileInput to primaryContext and, should it not have succeeded
+// within hedgeDelay (or fail sooner), also to hedgeContext returning whichever read first
+// succeeds. As backendContextIf offers no means of cancelling a request, the losing read is
+// left to complete in the background with its result discarded.
+func hedgeReadFile(primaryContext, hedgeContext backendContextIf, hedgeDelay time.Duration, readFileInput *readFileInputStruct) (readFileOutput *readFileOutputStruct, err error) {
+	var (
+		hedged      bool
+		outstanding int
+		result      hedgeReadFileResultStruct
+		resultChan  = make(chan hedgeReadFileResultStruct, 2) // Buffered such that the loser need not be awaited
+		routedInput = *readFileInput
+		timer       = time.NewTimer(hedgeDelay)
+	)
+
+	defer timer.Stop()
+
+	routedInput.replicaRouted = true
+
+	issue := func(backendContext backendContextIf) {
+		outstanding++
+		go func() {
+			var (
+				result hedgeReadFileResultStruct
+			)
+
+			result.readFileOutput, result.err = readFileWrapper(backendContext, &routedInput)
+			resultChan <- result
+		}()
+	}
+
+	issue(primaryContext)
+
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				issue(hedgeContext)
+			}
+		case result = <-resultChan:
+			outstanding--
+
+			if result.err == nil {
+				readFileOutput = result.readFileOutput
+				return
+			}
+
+			if !hedged {
+				hedged = true
+				issue(hedgeContext)
+				continue
+			}
+
+			if outstanding == 0 {
+				err = result.err
+				return
+			}
+		}
+	}
+}
+
+// `prober` is run as a background worker while the backend is mounted to probe
+// it and each of its replicas immediately and then every backend.replicaProbeInterval.
+func (replicaRouter *replicaRouterStruct) prober() {
+	var (
+		ticker = time.NewTicker(replicaRouter.backend.replicaProbeInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		replicaRouter.probeAll()
+
+		select {
+		case <-replicaRouter.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// `probeAll` probes each replica (concurrently so that a slow replica does not delay
+// the measurement of the others) and then selects the nearest healthy one.
+func (replicaRouter *replicaRouterStruct) probeAll() {
+	var (
+		probeWaitGroup sync.WaitGroup
+	)
+
+	for replicaIndex := range replicaRouter.replica {
+		probeWaitGroup.Go(func() {
+			replicaRouter.recordProbe(replicaIndex, replicaRouter.replica[replicaIndex].backend.probeLatency())
+		})
+	}
+
+	probeWaitGroup.Wait()
+
+	replicaRouter.selectNearest()
+}
+
+// `probeLatency` times a minimal listing of the backend's root directory issued directly
+// (i.e. bypassing listDirectoryWrapper()) returning a negative latency should it fail.
+func (backend *backendStruct) probeLatency() (latency time.Duration) {
+	var (
+		err       error
+		startTime = time.Now()
+	)
+
+	_, err = backend.context.listDirectory(&listDirectoryInputStruct{
+		continuationToken: "",
+		maxItems:          1,
+		dirPath:           "",
+	})
+	if err != nil {
+		latency = -1
+		return
+	}
+
+	latency = time.Since(startTime)
+
+	return
+}
+
+// `recordProbe` folds the outcome of probing replica[replicaIndex] into its health & latency.
+func (replicaRouter *replicaRouterStruct) recordProbe(replicaIndex int, latency time.Duration) {
+	var (
+		replica *replicaStruct
+	)
+
+	replicaRouter.Lock()
+	defer replicaRouter.Unlock()
+
+	replica = &replicaRouter.replica[replicaIndex]
+
+	if latency < 0 {
+		replica.healthy = false
+		return
+	}
+
+	if replica.latency == 0 {
+		replica.latency = latency
+	} else {
+		replica.latency = time.Duration((replicaLatencyWeight * float64(latency)) + ((1 - replicaLatencyWeight) * float64(replica.latency)))
+	}
+
+	replica.healthy = true
+}
+
+// `usable` returns whether reads may be routed to replica (i.e. its most recent
+// probe succeeded and its circuit breaker, if any, is closed).
+func (replica *replicaStruct) usable() bool {
+	return replica.healthy && (replica.backend.healthCheck() == nil)
+}
+
+// `selectNearest` selects the healthy replica with the lowest latency as the one to which reads
+// are routed. Replicas whose circuit breaker is open (see healthCheck()) are not considered. If
+// no replica is healthy, reads are routed to the backend itself.
+func (replicaRouter *replicaRouterStruct) selectNearest() {
+	var (
+		nearest      *replicaStruct
+		replicaIndex int
+		replica      *replicaStruct
+	)
+
+	replicaRouter.Lock()
+	defer replicaRouter.Unlock()
+
+	for replicaIndex = range replicaRouter.replica {
+		replica = &replicaRouter.replica[replicaIndex]
+
+		if !replica.usable() {
+			continue
+		}
+
+		if (nearest == nil) || (replica.latency < nearest.latency) {
+			nearest = replica
+		}
+	}
+
+	if nearest == nil {
+		nearest = &replicaRouter.replica[0]
+	}
+
+	if nearest.backend != replicaRouter.nearest {
+		globals.logger.Printf("[INFO] [replica] %s now routing reads to %s (latency %v)", replicaRouter.backend.dirName, nearest.backend.dirName, nearest.latency)
+
+		replicaRouter.nearest = nearest.backend
+	}
+}
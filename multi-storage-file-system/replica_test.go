@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	var (
+		err         error
+		farBackend  *backendStruct
+		fileContent []byte
+		nearBackend *backendStruct
+		ok          bool
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "near",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "far",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	nearBackend, ok = globals.config.backends["near"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"near\"] returned !ok")
+	}
+	farBackend, ok = globals.config.backends["far"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"far\"] returned !ok")
+	}
+
+	// Distinguish the replicas by (equally sized) content so that it is evident which served each read
+
+	_, err = writeFileWrapper(nearBackend.context, &writeFileInputStruct{filePath: "a", buf: []byte("near")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"a\") to near failed: %v", err)
+	}
+	_, err = writeFileWrapper(farBackend.context, &writeFileInputStruct{filePath: "a", buf: []byte("far!")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"a\") to far failed: %v", err)
+	}
+
+	// Drive the router by hand (i.e. without starting its prober) so that latencies are deterministic
+
+	nearBackend.replicaRouter = &replicaRouterStruct{
+		backend:  nearBackend,
+		replica:  []replicaStruct{{backend: nearBackend, healthy: true}, {backend: farBackend}},
+		nearest:  nearBackend,
+		stopChan: make(chan struct{}),
+	}
+
+	nearBackend.replicaRouter.recordProbe(0, 50*time.Millisecond)
+	nearBackend.replicaRouter.recordProbe(1, 10*time.Millisecond)
+	nearBackend.replicaRouter.selectNearest()
+
+	fileContent, _, err = readWholeFile(nearBackend.context, "a")
+	if (err != nil) || !bytes.Equal(fileContent, []byte("far!")) {
+		t.Fatalf("readWholeFile(\"a\") returned %q, %v (expected \"far!\")", fileContent, err)
+	}
+
+	// Latencies are smoothed such that a single slow probe does not immediately reroute reads
+
+	nearBackend.replicaRouter.recordProbe(1, 100*time.Millisecond)
+	if nearBackend.replicaRouter.replica[1].latency != 37*time.Millisecond {
+		t.Fatalf("replica latency was %v (expected 37ms)", nearBackend.replicaRouter.replica[1].latency)
+	}
+	nearBackend.replicaRouter.selectNearest()
+	if nearBackend.nearestReplicaContext() != farBackend.context {
+		t.Fatalf("nearestReplicaContext() should have remained far")
+	}
+
+	// A failed probe excludes the replica until it next succeeds
+
+	nearBackend.replicaRouter.recordProbe(1, -1)
+	nearBackend.replicaRouter.selectNearest()
+
+	fileContent, _, err = readWholeFile(nearBackend.context, "a")
+	if (err != nil) || !bytes.Equal(fileContent, []byte("near")) {
+		t.Fatalf("readWholeFile(\"a\") returned %q, %v (expected \"near\")", fileContent, err)
+	}
+}
+
+func TestReplicaHedging(t *testing.T) {
+	var (
+		err            error
+		farBackend     *backendStruct
+		nearBackend    *backendStruct
+		ok             bool
+		readFileOutput *readFileOutputStruct
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "near",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			},
+			{
+				"dir_name": "far",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	nearBackend, ok = globals.config.backends["near"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"near\"] returned !ok")
+	}
+	farBackend, ok = globals.config.backends["far"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"far\"] returned !ok")
+	}
+
+	// Only far holds the file such that reads issued to near fail
+
+	_, err = writeFileWrapper(farBackend.context, &writeFileInputStruct{filePath: "a", buf: []byte("far!")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"a\") to far failed: %v", err)
+	}
+
+	nearBackend.replicaHedgeDelay = time.Hour
+	nearBackend.replicaRouter = &replicaRouterStruct{
+		backend:  nearBackend,
+		replica:  []replicaStruct{{backend: nearBackend}, {backend: farBackend}},
+		nearest:  nearBackend,
+		stopChan: make(chan struct{}),
+	}
+
+	nearBackend.replicaRouter.recordProbe(0, 10*time.Millisecond)
+	nearBackend.replicaRouter.recordProbe(1, 50*time.Millisecond)
+	nearBackend.replicaRouter.selectNearest()
+
+	if nearBackend.hedgeReplicaContext() != farBackend.context {
+		t.Fatalf("hedgeReplicaContext() should have returned far")
+	}
+
+	// The failure of the read issued to near should trigger the hedged read (well before replica_hedge_delay)
+
+	readFileOutput, err = readFileWrapper(nearBackend.context, &readFileInputStruct{filePath: "a", cacheLineSize: globals.config.cacheLineSize})
+	if (err != nil) || !bytes.Equal(readFileOutput.buf, []byte("far!")) {
+		t.Fatalf("readFileWrapper(\"a\") should have been served by far (err: %v)", err)
+	}
+
+	// Absent a usable replica to hedge to, reads are issued only to near
+
+	nearBackend.replicaRouter.recordProbe(1, -1)
+
+	if nearBackend.hedgeReplicaContext() != nil {
+		t.Fatalf("hedgeReplicaContext() should have returned nil")
+	}
+
+	_, err = readFileWrapper(nearBackend.context, &readFileInputStruct{filePath: "a", cacheLineSize: globals.config.cacheLineSize})
+	if err == nil {
+		t.Fatalf("readFileWrapper(\"a\") unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// `retryBudgetStruct` bounds the retries issued (across all backends) to a fraction of the
+// requests completed so that, should a backend melt down, retries are shed rather than
+// multiplying the load upon it. It is a token bucket holding at most burst tokens: each
+// completed backend request deposits ratio tokens, minPerSecond tokens are deposited each
+// second (so that retries are possible even while few requests complete), and each retry
+// must withdraw a whole token or else is shed (i.e. the failure is returned instead).
+type retryBudgetStruct struct {
+	sync.Mutex                             // Protects tokens & lastRefill
+	ratio        float64                   // == globals.config.retryBudgetRatio
+	minPerSecond float64                   // == globals.config.retryBudgetMinPerSecond
+	burst        float64                   // == globals.config.retryBudgetBurst
+	tokens       float64                   // In [0:burst]
+	lastRefill   time.Time                 // When minPerSecond was last applied to tokens
+	metrics      *retryBudgetMetricsStruct //
+}
+
+// `newRetryBudget` returns a retryBudgetStruct (initially holding burst tokens). If ratio
+// == 0, nil (i.e. no retry budget) is returned.
+func newRetryBudget(ratio float64, minPerSecond float64, burst uint64) (retryBudget *retryBudgetStruct) {
+	if ratio == 0 {
+		return
+	}
+
+	retryBudget = &retryBudgetStruct{
+		ratio:        ratio,
+		minPerSecond: minPerSecond,
+		burst:        float64(burst),
+		tokens:       float64(burst),
+		lastRefill:   time.Now(),
+		metrics:      newRetryBudgetMetrics(),
+	}
+
+	return
+}
+
+// `refill` is called while retryBudget.Lock() is held to deposit the minPerSecond tokens
+// accrued since lastRefill.
+func (retryBudget *retryBudgetStruct) refill() {
+	var (
+		timeNow = time.Now()
+	)
+
+	retryBudget.tokens = min(retryBudget.tokens+(timeNow.Sub(retryBudget.lastRefill).Seconds()*retryBudget.minPerSecond), retryBudget.burst)
+	retryBudget.lastRefill = timeNow
+}
+
+// `deposit` is called as each backend request completes.
+func (retryBudget *retryBudgetStruct) deposit() {
+	if retryBudget == nil {
+		return
+	}
+
+	retryBudget.Lock()
+	retryBudget.tokens = min(retryBudget.tokens+retryBudget.ratio, retryBudget.burst)
+	retryBudget.Unlock()
+}
+
+// `withdraw` is called just prior to a retry to determine whether it may be issued
+// (or should instead be shed).
+func (retryBudget *retryBudgetStruct) withdraw() (ok bool) {
+	if retryBudget == nil {
+		ok = true
+		return
+	}
+
+	retryBudget.Lock()
+	retryBudget.refill()
+	ok = retryBudget.tokens >= 1
+	if ok {
+		retryBudget.tokens--
+	}
+	retryBudget.Unlock()
+
+	if ok {
+		retryBudget.metrics.Retries.Inc()
+	} else {
+		retryBudget.metrics.RetriesShed.Inc()
+	}
+
+	return
+}
+
+// `available` returns the (whole) retries that may currently be issued.
+func (retryBudget *retryBudgetStruct) available() (available uint64) {
+	retryBudget.Lock()
+	retryBudget.refill()
+	available = uint64(retryBudget.tokens)
+	retryBudget.Unlock()
+
+	return
+}
+
+// `updateMetrics` is called just before the metrics are scraped to set the Tokens gauge.
+func (retryBudget *retryBudgetStruct) updateMetrics() {
+	retryBudget.metrics.Tokens.Set(float64(retryBudget.available()))
+}
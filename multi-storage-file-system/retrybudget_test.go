@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	var (
+		retryBudget *retryBudgetStruct
+	)
+
+	// Without retry_budget_ratio, retries are never shed
+
+	retryBudget = newRetryBudget(0, 10, 100)
+	if (retryBudget != nil) || !retryBudget.withdraw() {
+		t.Fatalf("newRetryBudget(0,,) returned %v (or withdraw() returned false)", retryBudget)
+	}
+	retryBudget.deposit()
+
+	// Retries beyond the initial burst must be earned by completed requests
+
+	retryBudget = newRetryBudget(0.5, 0, 2)
+
+	if !retryBudget.withdraw() || !retryBudget.withdraw() {
+		t.Fatalf("withdraw() of the initial burst unexpectedly returned false")
+	}
+	if retryBudget.withdraw() {
+		t.Fatalf("withdraw() beyond the initial burst unexpectedly returned true")
+	}
+
+	retryBudget.deposit()
+	if retryBudget.withdraw() {
+		t.Fatalf("withdraw() after a single deposit() unexpectedly returned true")
+	}
+
+	retryBudget.deposit()
+	if !retryBudget.withdraw() {
+		t.Fatalf("withdraw() after 1/retry_budget_ratio deposit()'s unexpectedly returned false")
+	}
+
+	for range 10 {
+		retryBudget.deposit()
+	}
+	if retryBudget.available() != 2 {
+		t.Fatalf("available() returned %v (expected retry_budget_burst of 2)", retryBudget.available())
+	}
+
+	if (counterValue(retryBudget.metrics.Retries) != 3) || (counterValue(retryBudget.metrics.RetriesShed) != 2) {
+		t.Fatalf("retry budget counted %v retries and %v shed (expected 3 & 2)", counterValue(retryBudget.metrics.Retries), counterValue(retryBudget.metrics.RetriesShed))
+	}
+
+	retryBudget.updateMetrics()
+
+	// Retries are also permitted at retry_budget_min_per_second
+
+	retryBudget = newRetryBudget(0.5, 1000, 1)
+
+	if !retryBudget.withdraw() {
+		t.Fatalf("withdraw() of the initial burst unexpectedly returned false")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if !retryBudget.withdraw() {
+		t.Fatalf("withdraw() after retry_budget_min_per_second has accrued unexpectedly returned false")
+	}
+}
+
+func TestRetryBudgetS3(t *testing.T) {
+	var (
+		backend = &backendStruct{
+			backendTypeSpecifics: &backendConfigS3Struct{
+				retryMode:     S3RetryModeStandard,
+				retryAttempts: 3,
+			},
+		}
+		err                error
+		opErr              = testS3ResponseError(http.StatusServiceUnavailable)
+		retryBudgetAtStart = globals.retryBudget
+	)
+
+	defer func() {
+		globals.retryBudget = retryBudgetAtStart
+	}()
+
+	globals.retryBudget = newRetryBudget(0.1, 0, 1)
+
+	_, err = backend.GetRetryToken(context.Background(), opErr)
+	if err != nil {
+		t.Fatalf("GetRetryToken() within the retry budget unexpectedly failed: %v", err)
+	}
+
+	_, err = backend.GetRetryToken(context.Background(), opErr)
+	if (err == nil) || !errors.Is(err, opErr) {
+		t.Fatalf("GetRetryToken() beyond the retry budget returned %v (expected the wrapped 503)", err)
+	}
+
+	if backend.retries.Load() != 1 {
+		t.Fatalf("GetRetryToken() counted %v retries (expected only the 1 not shed)", backend.retries.Load())
+	}
+}
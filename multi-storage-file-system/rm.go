@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	rmBatchSize        = s3DeleteObjectsMax // Files per deleteFiles() request (as many as a single S3 DeleteObjects request accepts)
+	rmParallelDefault  = uint64(8)
+	rmParallelMax      = uint64(256)
+	rmProgressInterval = 1 * time.Second
+)
+
+// `rmOptionsStruct` holds the options of removeTree().
+type rmOptionsStruct struct {
+	dryRun   bool   // If true, the files that would be deleted are reported but not deleted
+	parallel uint64 // Number of deleteFiles() requests issued concurrently
+}
+
+// `rmBatchStruct` is a set of files deleted together by a single deleteFiles() request.
+type rmBatchStruct struct {
+	filePaths []string // Each relative to backend.prefix
+	bytes     uint64
+}
+
+// `removerStruct` tracks the progress of removeTree().
+type removerStruct struct {
+	sync.Mutex                       // Serializes writes to w and protects the counts below
+	w            io.Writer           //
+	backend      *backendStruct      //
+	options      *rmOptionsStruct    //
+	skipVersions bool                // If true, each s3VersionsDirName subdirectory is not walked
+	batchChan    chan *rmBatchStruct // Feeds workers each batch of files to delete
+	workerGroup  sync.WaitGroup      // Awaited after closing batchChan
+	startTime    time.Time
+	filesDeleted uint64
+	bytesDeleted uint64
+	filesSkipped uint64 // Files beneath a readonly path_overrides prefix
+	filesFailed  uint64
+	dirsFailed   uint64
+}
+
+// `removeTree` deletes, as would `rm -r`, the file or directory tree identified by target
+// (of the form <dir_name>/<path>). Directories are walked (each fully listed, page by page,
+// before its files are deleted so that the deletions cannot disturb its listing) with their
+// files deleted in batches of up to rmBatchSize, options.parallel batches at a time. Files
+// beneath a readonly path_overrides prefix are skipped. If options.dryRun, each file that
+// would be deleted is reported to w instead. Unless nil, progress (and the rate of deletion)
+// is reported to progress every rmProgressInterval. A summary is reported to w and an error
+// returned if any file could not be deleted (or directory listed).
+func removeTree(w io.Writer, progress io.Writer, target string, options *rmOptionsStruct) (err error) {
+	var (
+		backend            *backendStruct
+		elapsed            time.Duration
+		ok                 bool
+		path               string
+		progressDoneChan   chan struct{}
+		progressGroup      sync.WaitGroup
+		readOnly           bool
+		remover            *removerStruct
+		s3ConfigData       *backendConfigS3Struct
+		statFileOutput     *statFileOutputStruct
+		statDirectoryError error
+	)
+
+	backend, path, err = inspectTarget(target)
+	if err != nil {
+		return
+	}
+	if path == "" {
+		err = fmt.Errorf("refusing to remove the entirety of backend \"%s\"", backend.dirName)
+		return
+	}
+
+	_, statDirectoryError = statDirectoryWrapper(backend.context, &statDirectoryInputStruct{dirPath: path + "/"})
+	if statDirectoryError != nil {
+		statFileOutput, err = statFileWrapper(backend.context, &statFileInputStruct{filePath: path})
+		if err != nil {
+			if backendErrno(err) == syscall.ENOENT {
+				err = statDirectoryError
+			}
+			err = inspectStatError(target, "no such file or directory", err)
+			return
+		}
+	}
+
+	if !options.dryRun {
+		globals.Lock()
+		if statDirectoryError == nil {
+			readOnly = backend.readOnlyAt(path + "/")
+		} else {
+			readOnly = backend.readOnlyAt(path)
+		}
+		globals.Unlock()
+
+		if readOnly {
+			err = fmt.Errorf("\"%s\" is readonly", target)
+			return
+		}
+	}
+
+	remover = &removerStruct{
+		w:         w,
+		backend:   backend,
+		options:   options,
+		batchChan: make(chan *rmBatchStruct),
+		startTime: time.Now(),
+	}
+
+	s3ConfigData, ok = backend.backendTypeSpecifics.(*backendConfigS3Struct)
+	remover.skipVersions = ok && s3ConfigData.exposeVersions
+
+	for range max(options.parallel, 1) {
+		remover.workerGroup.Go(remover.worker)
+	}
+
+	if (progress != nil) && !options.dryRun {
+		progressDoneChan = make(chan struct{})
+		progressGroup.Go(func() {
+			remover.reportProgress(progress, progressDoneChan)
+		})
+	}
+
+	if statDirectoryError != nil {
+		remover.queue([]string{path}, []uint64{statFileOutput.size})
+	} else {
+		remover.walk(path + "/")
+	}
+
+	close(remover.batchChan)
+	remover.workerGroup.Wait()
+
+	if progressDoneChan != nil {
+		close(progressDoneChan)
+		progressGroup.Wait()
+	}
+
+	elapsed = time.Since(remover.startTime)
+
+	if options.dryRun {
+		_, _ = fmt.Fprintf(w, "dry run: would delete %v files (%v bytes), %v readonly skipped\n", remover.filesDeleted, remover.bytesDeleted, remover.filesSkipped)
+	} else {
+		_, _ = fmt.Fprintf(w, "deleted %v files (%v bytes) in %v (%.0f files/s), %v readonly skipped, %v failed\n", remover.filesDeleted, remover.bytesDeleted, elapsed.Round(time.Millisecond), float64(remover.filesDeleted)/elapsed.Seconds(), remover.filesSkipped, remover.filesFailed)
+	}
+
+	if (remover.filesFailed != 0) || (remover.dirsFailed != 0) {
+		err = fmt.Errorf("%v files failed to be deleted and %v directories failed to be listed", remover.filesFailed, remover.dirsFailed)
+	}
+
+	return
+}
+
+// `walk` lists (page by page) dirPath in its entirety, queues its files for deletion,
+// and then walks each of its subdirectories.
+func (remover *removerStruct) walk(dirPath string) {
+	var (
+		err                 error
+		filePaths           []string
+		fileSizes           []uint64
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		subdirectories      []string
+		subdirectory        string
+	)
+
+	listDirectoryInput = &listDirectoryInputStruct{
+		maxItems: remover.backend.directoryPageSize,
+		dirPath:  dirPath,
+		bulk:     true,
+	}
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(remover.backend.context, listDirectoryInput)
+		if err != nil {
+			remover.Lock()
+			remover.dirsFailed++
+			_, _ = fmt.Fprintf(remover.w, "failed: unable to list \"%s\" of %s: %v\n", dirPath, remover.backend.dirName, err)
+			remover.Unlock()
+			return
+		}
+
+		for _, subdirectory = range listDirectoryOutput.subdirectory {
+			if !remover.skipVersions || (subdirectory != s3VersionsDirName) {
+				subdirectories = append(subdirectories, subdirectory)
+			}
+		}
+
+		for _, file := range listDirectoryOutput.file {
+			filePaths = append(filePaths, dirPath+file.basename)
+			fileSizes = append(fileSizes, file.size)
+		}
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			break
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+
+	remover.queue(filePaths, fileSizes)
+
+	for _, subdirectory = range subdirectories {
+		remover.walk(dirPath + subdirectory + "/")
+	}
+}
+
+// `queue` feeds the workers the files at filePaths (of the corresponding fileSizes) in
+// batches of up to rmBatchSize skipping any beneath a readonly path_overrides prefix.
+func (remover *removerStruct) queue(filePaths []string, fileSizes []uint64) {
+	var (
+		batch     *rmBatchStruct
+		fileIndex int
+		filePath  string
+		readOnly  []bool
+	)
+
+	readOnly = make([]bool, len(filePaths))
+
+	globals.Lock()
+	for fileIndex, filePath = range filePaths {
+		readOnly[fileIndex] = remover.backend.readOnlyAt(filePath)
+	}
+	globals.Unlock()
+
+	batch = &rmBatchStruct{}
+
+	for fileIndex, filePath = range filePaths {
+		if readOnly[fileIndex] {
+			remover.Lock()
+			remover.filesSkipped++
+			_, _ = fmt.Fprintf(remover.w, "skipped (readonly): %s/%s\n", remover.backend.dirName, filePath)
+			remover.Unlock()
+			continue
+		}
+
+		batch.filePaths = append(batch.filePaths, filePath)
+		batch.bytes += fileSizes[fileIndex]
+
+		if len(batch.filePaths) == rmBatchSize {
+			remover.batchChan <- batch
+			batch = &rmBatchStruct{}
+		}
+	}
+
+	if len(batch.filePaths) > 0 {
+		remover.batchChan <- batch
+	}
+}
+
+// `worker` deletes (or, if dry-run, reports) each batch fed by walk() via queue().
+func (remover *removerStruct) worker() {
+	var (
+		batch    *rmBatchStruct
+		err      error
+		filePath string
+	)
+
+	for batch = range remover.batchChan {
+		if !remover.options.dryRun {
+			_, err = deleteFilesWrapper(remover.backend.context, &deleteFilesInputStruct{filePaths: batch.filePaths})
+		}
+
+		remover.Lock()
+		if err != nil {
+			remover.filesFailed += uint64(len(batch.filePaths))
+			_, _ = fmt.Fprintf(remover.w, "failed: %v files from %s/%s: %v\n", len(batch.filePaths), remover.backend.dirName, batch.filePaths[0], err)
+		} else {
+			remover.filesDeleted += uint64(len(batch.filePaths))
+			remover.bytesDeleted += batch.bytes
+			if remover.options.dryRun {
+				for _, filePath = range batch.filePaths {
+					_, _ = fmt.Fprintf(remover.w, "delete: %s/%s\n", remover.backend.dirName, filePath)
+				}
+			}
+		}
+		remover.Unlock()
+
+		err = nil
+	}
+}
+
+// `reportProgress` reports to progress, every rmProgressInterval until doneChan is closed,
+// the files (and bytes) deleted thus far and the rate at which they have been deleted.
+func (remover *removerStruct) reportProgress(progress io.Writer, doneChan chan struct{}) {
+	var (
+		elapsed time.Duration
+		ticker  = time.NewTicker(rmProgressInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-doneChan:
+			return
+		case <-ticker.C:
+			remover.Lock()
+			elapsed = time.Since(remover.startTime)
+			_, _ = fmt.Fprintf(progress, "deleted %v files (%v bytes) in %v (%.0f files/s)\n", remover.filesDeleted, remover.bytesDeleted, elapsed.Round(time.Second), float64(remover.filesDeleted)/elapsed.Seconds())
+			remover.Unlock()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRemoveTree(t *testing.T) {
+	var (
+		err      error
+		filePath string
+		output   bytes.Buffer
+		progress bytes.Buffer
+		ram      *backendStruct
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`msfs_version: 1
+backends:
+  - dir_name: ram
+    bucket_container_name: ignored
+    backend_type: RAM
+    readonly: false
+    RAM:
+      max_directory_page_size: 3
+    path_overrides:
+      - prefix: tree/frozen/
+        readonly: true
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	ram = globals.backendsToMount["ram"]
+
+	// Lay out more than rmBatchSize files (spanning several batches and listing pages) beneath tree/
+
+	filePaths := []string{"keep", "tree/frozen/f", "tree/sub/deeper/g", "lone"}
+	for fileIndex := range rmBatchSize + 5 {
+		filePaths = append(filePaths, fmt.Sprintf("tree/sub/file%04d", fileIndex))
+	}
+
+	for _, filePath = range filePaths {
+		_, err = ram.context.writeFile(&writeFileInputStruct{filePath: filePath, buf: []byte("12345")})
+		if err != nil {
+			t.Fatalf("writeFile(\"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	exists := func(filePath string) bool {
+		_, err := ram.context.statFile(&statFileInputStruct{filePath: filePath})
+		return err == nil
+	}
+
+	// A dry run reports (but does not delete) each file
+
+	err = removeTree(&output, &progress, "ram/tree", &rmOptionsStruct{dryRun: true, parallel: 4})
+	if err != nil {
+		t.Fatalf("removeTree(dry-run) failed: %v\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), "delete: ram/tree/sub/deeper/g\n") || !strings.Contains(output.String(), "skipped (readonly): ram/tree/frozen/f\n") {
+		t.Fatalf("removeTree(dry-run) reported:\n%s", output.String())
+	}
+	if !strings.HasSuffix(output.String(), fmt.Sprintf("dry run: would delete %v files (%v bytes), 1 readonly skipped\n", rmBatchSize+6, 5*(rmBatchSize+6))) {
+		t.Fatalf("removeTree(dry-run) summarized:\n%s", output.String())
+	}
+	if !exists("tree/sub/deeper/g") {
+		t.Fatalf("removeTree(dry-run) deleted tree/sub/deeper/g")
+	}
+
+	// The tree (but not the readonly file within it) is deleted (serially as the RAM backend does not support concurrent mutation)
+
+	output.Reset()
+
+	err = removeTree(&output, &progress, "ram/tree/", &rmOptionsStruct{parallel: 1})
+	if err != nil {
+		t.Fatalf("removeTree() failed: %v\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), fmt.Sprintf("deleted %v files (%v bytes) in ", rmBatchSize+6, 5*(rmBatchSize+6))) || !strings.Contains(output.String(), ", 1 readonly skipped, 0 failed\n") {
+		t.Fatalf("removeTree() summarized:\n%s", output.String())
+	}
+	if exists("tree/sub/file0000") || exists("tree/sub/deeper/g") {
+		t.Fatalf("removeTree() left files beneath tree/sub/")
+	}
+	if !exists("tree/frozen/f") || !exists("keep") || !exists("lone") {
+		t.Fatalf("removeTree() deleted a readonly file or one outside of tree/")
+	}
+
+	// A single file may be deleted
+
+	output.Reset()
+
+	err = removeTree(&output, nil, "ram/lone", &rmOptionsStruct{parallel: 1})
+	if (err != nil) || exists("lone") || !exists("keep") {
+		t.Fatalf("removeTree(\"ram/lone\") failed (err: %v)\n%s", err, output.String())
+	}
+
+	// Neither the entire backend, anything missing, nor a readonly path may be removed
+
+	for _, target := range []string{"ram", "ram/", "ram/missing", "missing/tree", "ram/tree/frozen"} {
+		err = removeTree(&output, nil, target, &rmOptionsStruct{parallel: 1})
+		if err == nil {
+			t.Fatalf("removeTree(\"%s\") unexpectedly succeeded", target)
+		}
+	}
+}
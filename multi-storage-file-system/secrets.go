@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Credential settings (S3 access_key_id, secret_access_key, & session_token as well as
+// AIStore authn_token & authn_password) may, rather than holding a secret itself, hold a
+// reference of the form "<source>:<path>[#<key>]" to a secret fetched from one of these sources.
+const (
+	SecretSourceVault             = "vault"              // <path> == "<mount>/<secret>" of a Vault KV v2 secret; <key> selects one of its keys
+	SecretSourceVaultAWS          = "vault-aws"          // <path> == "<mount>/creds/<role>" (or "<mount>/sts/<role>") of a Vault AWS secrets engine; <key> is "access_key", "secret_key", or "security_token"
+	SecretSourceAWSSecretsManager = "aws-secretsmanager" // <path> == name or ARN of an AWS Secrets Manager secret; if present, <key> selects a key of its (JSON object) SecretString
+	SecretSourceAWSSSM            = "aws-ssm"            // <path> == name (or ARN) of an AWS SSM Parameter Store parameter (decrypted if a SecureString); <key> is not supported
+)
+
+// `secretRenewFraction` is the fraction of a secret's lease after which it is considered
+// expired so that it is fetched anew prior to the lease actually expiring.
+const secretRenewFraction = 0.9
+
+// `secretsHTTPTimeout` bounds each request to a secret source.
+const secretsHTTPTimeout = 30 * time.Second
+
+// `secretRefStruct` is the parsed form of a credential setting referencing a secret.
+type secretRefStruct struct {
+	source string // One of SecretSource*
+	path   string //
+	key    string //
+}
+
+// `secretStruct` holds each key's value of a fetched secret.
+type secretStruct struct {
+	values map[string]string //
+	expiry time.Time         // If .IsZero(), the secret is never fetched anew
+}
+
+// `secretsStruct` caches fetched secrets such that the settings referencing
+// different keys of the same secret (e.g. "access_key" and "secret_key" of a
+// Vault AWS secrets engine issued credential) obtain them from a single fetch.
+type secretsStruct struct {
+	sync.Mutex                               // Protects cache & awsConfig{Cached|Loaded} (and serializes fetches)
+	cache           map[string]*secretStruct // Key: "<source>:<path>"
+	httpClient      *http.Client             //
+	awsConfigCached aws.Config               // Valid only if awsConfigLoaded == true
+	awsConfigLoaded bool                     //
+}
+
+// `newSecrets` returns an empty secretsStruct.
+func newSecrets() (secrets *secretsStruct) {
+	secrets = &secretsStruct{
+		cache: make(map[string]*secretStruct),
+		httpClient: &http.Client{
+			Timeout: secretsHTTPTimeout,
+		},
+	}
+
+	return
+}
+
+// `parseSecretRef` determines if value references a secret. If so, isRef is returned
+// as true and, should value be malformed, a non-nil err is returned.
+func parseSecretRef(value string) (secretRef secretRefStruct, isRef bool, err error) {
+	var (
+		found bool
+		rest  string
+	)
+
+	secretRef.source, rest, found = strings.Cut(value, ":")
+	if !found {
+		return
+	}
+
+	switch secretRef.source {
+	case SecretSourceVault, SecretSourceVaultAWS, SecretSourceAWSSecretsManager, SecretSourceAWSSSM:
+		isRef = true
+	default:
+		return
+	}
+
+	secretRef.path, secretRef.key, found = strings.Cut(rest, "#")
+
+	switch secretRef.source {
+	case SecretSourceVault, SecretSourceVaultAWS:
+		if !found || (secretRef.key == "") {
+			err = fmt.Errorf("secret reference \"%s\" missing \"#<key>\"", value)
+			return
+		}
+		secretRef.path = strings.Trim(secretRef.path, "/")
+		if (secretRef.source == SecretSourceVault) && !strings.Contains(secretRef.path, "/") {
+			err = fmt.Errorf("secret reference \"%s\" must be of the form \"%s:<mount>/<secret>#<key>\"", value, SecretSourceVault)
+			return
+		}
+	case SecretSourceAWSSecretsManager:
+		if found && (secretRef.key == "") {
+			err = fmt.Errorf("secret reference \"%s\" has an empty \"#<key>\"", value)
+			return
+		}
+	case SecretSourceAWSSSM:
+		if found {
+			err = fmt.Errorf("secret reference \"%s\" may not specify a \"#<key>\"", value)
+			return
+		}
+	}
+
+	if secretRef.path == "" {
+		err = fmt.Errorf("secret reference \"%s\" missing <path>", value)
+		return
+	}
+
+	return
+}
+
+// `isSecretRef` returns whether or not value references a secret.
+func isSecretRef(value string) (isRef bool) {
+	_, isRef, _ = parseSecretRef(value)
+	return
+}
+
+// `checkSecretRef` validates value should it reference a secret. Referencing a Vault
+// secret requires the vault_address setting (already parsed into config).
+func checkSecretRef(config *configStruct, value string) (err error) {
+	var (
+		isRef     bool
+		secretRef secretRefStruct
+	)
+
+	secretRef, isRef, err = parseSecretRef(value)
+	if !isRef || (err != nil) {
+		return
+	}
+
+	switch secretRef.source {
+	case SecretSourceVault, SecretSourceVaultAWS:
+		if config.vaultAddress == "" {
+			err = fmt.Errorf("secret reference \"%s\" requires vault_address", value)
+		}
+	}
+
+	return
+}
+
+// `resolveSecrets` returns the value of each element of values, fetching those that
+// reference a secret as necessary. As all of values are resolved together, those
+// referencing the same secret are guaranteed to obtain their values from the same fetch.
+// The returned expiry is the earliest expiry of any secret referenced (or zero if none).
+func resolveSecrets(values ...string) (resolved []string, expiry time.Time, err error) {
+	var (
+		cacheKey  string
+		fetched   = make(map[string]*secretStruct)
+		isRef     bool
+		ok        bool
+		secret    *secretStruct
+		secretRef secretRefStruct
+		timeNow   = time.Now()
+	)
+
+	resolved = make([]string, len(values))
+
+	for i, value := range values {
+		secretRef, isRef, err = parseSecretRef(value)
+		if err != nil {
+			return
+		}
+		if !isRef {
+			resolved[i] = value
+			continue
+		}
+
+		if globals.secrets == nil {
+			err = errors.New("secrets not initialized")
+			return
+		}
+
+		cacheKey = secretRef.source + ":" + secretRef.path
+
+		secret, ok = fetched[cacheKey]
+		if !ok {
+			globals.secrets.Lock()
+			secret, ok = globals.secrets.cache[cacheKey]
+			if !ok || (!secret.expiry.IsZero() && !timeNow.Before(secret.expiry)) {
+				secret, err = globals.secrets.fetch(secretRef)
+				if err != nil {
+					globals.secrets.Unlock()
+					err = fmt.Errorf("fetching secret \"%s\" failed: %v", cacheKey, err)
+					return
+				}
+				globals.secrets.cache[cacheKey] = secret
+			}
+			globals.secrets.Unlock()
+
+			fetched[cacheKey] = secret
+		}
+
+		resolved[i], ok = secret.values[secretRef.key]
+		if !ok {
+			if secretRef.key == "" {
+				err = fmt.Errorf("secret \"%s\" requires a \"#<key>\"", cacheKey)
+			} else {
+				err = fmt.Errorf("secret \"%s\" has no key \"%s\"", cacheKey, secretRef.key)
+			}
+			return
+		}
+
+		if !secret.expiry.IsZero() && (expiry.IsZero() || secret.expiry.Before(expiry)) {
+			expiry = secret.expiry
+		}
+	}
+
+	return
+}
+
+// `invalidateSecret` discards the cached secret (if any) referenced by value such that
+// the next call to resolveSecrets() referencing it will fetch it anew.
+func invalidateSecret(value string) {
+	var (
+		isRef     bool
+		secretRef secretRefStruct
+	)
+
+	secretRef, isRef, _ = parseSecretRef(value)
+	if !isRef || (globals.secrets == nil) {
+		return
+	}
+
+	globals.secrets.Lock()
+	delete(globals.secrets.cache, secretRef.source+":"+secretRef.path)
+	globals.secrets.Unlock()
+}
+
+// `fetch` obtains the secret referenced by secretRef from its source. Absent a lease
+// (which determines its expiry), a secret expires after secrets_refresh_interval.
+// Called while holding secrets.Lock().
+func (secrets *secretsStruct) fetch(secretRef secretRefStruct) (secret *secretStruct, err error) {
+	switch secretRef.source {
+	case SecretSourceVault, SecretSourceVaultAWS:
+		secret, err = secrets.fetchVault(secretRef)
+	case SecretSourceAWSSecretsManager:
+		secret, err = secrets.fetchAWSSecretsManager(secretRef)
+	case SecretSourceAWSSSM:
+		secret, err = secrets.fetchAWSSSM(secretRef)
+	default:
+		err = fmt.Errorf("unsupported secret source \"%s\"", secretRef.source)
+	}
+	if err != nil {
+		return
+	}
+
+	if secret.expiry.IsZero() && (globals.config.secretsRefreshInterval != 0) {
+		secret.expiry = time.Now().Add(globals.config.secretsRefreshInterval)
+	}
+
+	return
+}
+
+// `awsConfig` returns the AWS SDK configuration used to fetch secrets held by AWS, loading it
+// upon first use. Credentials are located via the AWS SDK's default chain (environment,
+// shared files, or the instance/task role) in the region given by aws_secrets_region.
+// Called while holding secrets.Lock().
+func (secrets *secretsStruct) awsConfig() (awsConfig aws.Config, err error) {
+	if secrets.awsConfigLoaded {
+		awsConfig = secrets.awsConfigCached
+		return
+	}
+
+	awsConfig, err = config.LoadDefaultConfig(context.Background(), config.WithRegion(globals.config.awsSecretsRegion), config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(secretsHTTPTimeout)))
+	if err != nil {
+		err = fmt.Errorf("config.LoadDefaultConfig() failed: %v", err)
+		return
+	}
+
+	if globals.config.awsSecretsEndpoint != "" {
+		awsConfig.BaseEndpoint = aws.String(globals.config.awsSecretsEndpoint)
+	}
+
+	secrets.awsConfigCached = awsConfig
+	secrets.awsConfigLoaded = true
+
+	return
+}
+
+// `fetchAWSSecretsManager` reads the current version of the AWS Secrets Manager secret
+// referenced by secretRef. Its SecretString is available as key "" and, should it be
+// a JSON object, each of its (string) members as an additional key.
+func (secrets *secretsStruct) fetchAWSSecretsManager(secretRef secretRefStruct) (secret *secretStruct, err error) {
+	var (
+		awsConfig                aws.Config
+		getSecretValueOutput     *secretsmanager.GetSecretValueOutput
+		secretStringAsJSONObject map[string]interface{}
+	)
+
+	awsConfig, err = secrets.awsConfig()
+	if err != nil {
+		return
+	}
+
+	getSecretValueOutput, err = secretsmanager.NewFromConfig(awsConfig).GetSecretValue(context.Background(), &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretRef.path),
+	})
+	if err != nil {
+		return
+	}
+
+	if getSecretValueOutput.SecretString == nil {
+		err = errors.New("secret has no SecretString")
+		return
+	}
+
+	secret = &secretStruct{
+		values: map[string]string{"": *getSecretValueOutput.SecretString},
+	}
+
+	if json.Unmarshal([]byte(*getSecretValueOutput.SecretString), &secretStringAsJSONObject) == nil {
+		secret.addValues(secretStringAsJSONObject)
+	}
+
+	return
+}
+
+// `fetchAWSSSM` reads the AWS SSM Parameter Store parameter referenced by secretRef,
+// decrypting it should it be a SecureString. Its value is available as key "".
+func (secrets *secretsStruct) fetchAWSSSM(secretRef secretRefStruct) (secret *secretStruct, err error) {
+	var (
+		awsConfig          aws.Config
+		getParameterOutput *ssm.GetParameterOutput
+	)
+
+	awsConfig, err = secrets.awsConfig()
+	if err != nil {
+		return
+	}
+
+	getParameterOutput, err = ssm.NewFromConfig(awsConfig).GetParameter(context.Background(), &ssm.GetParameterInput{
+		Name:           aws.String(secretRef.path),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return
+	}
+
+	if (getParameterOutput.Parameter == nil) || (getParameterOutput.Parameter.Value == nil) {
+		err = errors.New("parameter has no Value")
+		return
+	}
+
+	secret = &secretStruct{
+		values: map[string]string{"": *getParameterOutput.Parameter.Value},
+	}
+
+	return
+}
+
+// `addValues` records each member of data as a key of secret. Members whose value
+// is null are omitted while non-string values are recorded in their printed form.
+func (secret *secretStruct) addValues(data map[string]interface{}) {
+	for key, value := range data {
+		switch value := value.(type) {
+		case nil:
+			// Omit (e.g. the "security_token" of an "iam_user" credential)
+		case string:
+			secret.values[key] = value
+		default:
+			secret.values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// `fetchVault` reads the secret referenced by secretRef from Vault. For a KV v2 secret,
+// "<mount>/<secret>" is read via "<mount>/data/<secret>". For an AWS secrets engine, each
+// read issues a new credential whose lease determines when it is to be fetched anew.
+func (secrets *secretsStruct) fetchVault(secretRef secretRefStruct) (secret *secretStruct, err error) {
+	var (
+		apiPath          string
+		httpRequest      *http.Request
+		httpResponse     *http.Response
+		mount            string
+		responseBody     []byte
+		secretData       map[string]interface{}
+		secretName       string
+		vaultResponse    vaultResponseStruct
+		vaultToken       string
+		vaultTokenAsByte []byte
+	)
+
+	if secretRef.source == SecretSourceVault {
+		mount, secretName, _ = strings.Cut(secretRef.path, "/")
+		apiPath = mount + "/data/" + secretName
+	} else {
+		apiPath = secretRef.path
+	}
+
+	if globals.config.vaultTokenFile != "" {
+		// Re-read on each fetch so that a token renewed externally (e.g. by a Vault Agent) is picked up
+
+		vaultTokenAsByte, err = os.ReadFile(globals.config.vaultTokenFile)
+		if err != nil {
+			err = fmt.Errorf("reading vault_token_file failed: %v", err)
+			return
+		}
+		vaultToken = strings.TrimSpace(string(vaultTokenAsByte))
+	} else {
+		vaultToken = globals.config.vaultToken
+	}
+
+	httpRequest, err = http.NewRequest(http.MethodGet, strings.TrimSuffix(globals.config.vaultAddress, "/")+"/v1/"+apiPath, nil)
+	if err != nil {
+		return
+	}
+
+	httpRequest.Header.Set("X-Vault-Token", vaultToken)
+	if globals.config.vaultNamespace != "" {
+		httpRequest.Header.Set("X-Vault-Namespace", globals.config.vaultNamespace)
+	}
+
+	httpResponse, err = secrets.httpClient.Do(httpRequest)
+	if err != nil {
+		return
+	}
+
+	responseBody, err = io.ReadAll(httpResponse.Body)
+	_ = httpResponse.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		err = fmt.Errorf("Vault returned %s", httpResponse.Status)
+		return
+	}
+
+	err = json.Unmarshal(responseBody, &vaultResponse)
+	if err != nil {
+		err = fmt.Errorf("Vault response unparseable: %v", err)
+		return
+	}
+
+	secretData = vaultResponse.Data
+	if secretRef.source == SecretSourceVault {
+		secretData, _ = vaultResponse.Data["data"].(map[string]interface{})
+	}
+
+	secret = &secretStruct{
+		values: make(map[string]string, len(secretData)),
+	}
+
+	secret.addValues(secretData)
+
+	if vaultResponse.LeaseDuration > 0 {
+		secret.expiry = time.Now().Add(time.Duration(float64(vaultResponse.LeaseDuration) * secretRenewFraction * float64(time.Second)))
+	}
+
+	return
+}
+
+// `vaultResponseStruct` is the subset of a Vault read response that is consumed.
+type vaultResponseStruct struct {
+	LeaseDuration uint64                 `json:"lease_duration"` // In seconds; == 0 if the secret has no lease
+	Data          map[string]interface{} `json:"data"`           //
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestSecrets(t *testing.T) {
+	var (
+		awsCredentials aws.Credentials
+		awsReads       atomic.Int32
+		backend        *backendStruct
+		err            error
+		expiry         time.Time
+		ok             bool
+		resolved       []string
+		s3Context      *s3ContextStruct
+		vaultServer    *httptest.Server
+	)
+
+	vaultServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "testToken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/msfs/s3":
+			_, _ = fmt.Fprint(w, `{"lease_duration":0,"data":{"data":{"access_key_id":"kvAccessKeyID","secret_access_key":"kvSecretAccessKey"},"metadata":{"version":1}}}`)
+		case "/v1/aws/creds/msfs":
+			// Each read issues a new credential leased for 1 second
+
+			n := awsReads.Add(1)
+			_, _ = fmt.Fprintf(w, `{"lease_id":"aws/creds/msfs/%d","lease_duration":1,"renewable":true,"data":{"access_key":"awsAccessKeyID%d","secret_key":"awsSecretAccessKey%d","security_token":null}}`, n, n, n)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer vaultServer.Close()
+
+	globals.config = &configStruct{
+		vaultAddress: vaultServer.URL,
+		vaultToken:   "testToken",
+	}
+	globals.secrets = newSecrets()
+
+	// Malformed references and references lacking vault_address are rejected
+
+	for _, value := range []string{"vault:secret#key", "vault:secret/msfs", "vault-aws:#access_key"} {
+		err = checkSecretRef(globals.config, value)
+		if err == nil {
+			t.Fatalf("checkSecretRef(\"%s\") unexpectedly succeeded", value)
+		}
+	}
+	err = checkSecretRef(&configStruct{}, "vault:secret/msfs/s3#access_key_id")
+	if err == nil {
+		t.Fatalf("checkSecretRef() without vault_address unexpectedly succeeded")
+	}
+	err = checkSecretRef(globals.config, "notASecretRef")
+	if err != nil {
+		t.Fatalf("checkSecretRef(\"notASecretRef\") failed: %v", err)
+	}
+
+	// A KV v2 secret has no lease
+
+	resolved, expiry, err = resolveSecrets("vault:secret/msfs/s3#access_key_id", "plain", "vault:secret/msfs/s3#secret_access_key")
+	if err != nil {
+		t.Fatalf("resolveSecrets() of KV v2 secret failed: %v", err)
+	}
+	if (resolved[0] != "kvAccessKeyID") || (resolved[1] != "plain") || (resolved[2] != "kvSecretAccessKey") || !expiry.IsZero() {
+		t.Fatalf("resolveSecrets() of KV v2 secret returned %v, %v", resolved, expiry)
+	}
+
+	_, _, err = resolveSecrets("vault:secret/msfs/s3#missing_key")
+	if err == nil {
+		t.Fatalf("resolveSecrets() of missing key unexpectedly succeeded")
+	}
+	_, _, err = resolveSecrets("vault:secret/msfs/missing#key")
+	if err == nil {
+		t.Fatalf("resolveSecrets() of missing secret unexpectedly succeeded")
+	}
+
+	// Both keys of an AWS secrets engine credential come from the same (leased) read
+
+	resolved, expiry, err = resolveSecrets("vault-aws:aws/creds/msfs#access_key", "vault-aws:aws/creds/msfs#secret_key")
+	if err != nil {
+		t.Fatalf("resolveSecrets() of AWS secrets engine credential failed: %v", err)
+	}
+	if (resolved[0] != "awsAccessKeyID1") || (resolved[1] != "awsSecretAccessKey1") || expiry.IsZero() {
+		t.Fatalf("resolveSecrets() of AWS secrets engine credential returned %v, %v", resolved, expiry)
+	}
+
+	// An S3 backend's credentials are fetched upon setup and anew as the lease expires
+
+	backend = &backendStruct{
+		bucketContainerName: "dev",
+		backendTypeSpecifics: &backendConfigS3Struct{
+			region:          "us-east-1",
+			endpoint:        "http://minio:9000",
+			accessKeyID:     "vault-aws:aws/creds/msfs#access_key",
+			secretAccessKey: "vault-aws:aws/creds/msfs#secret_key",
+			retryMode:       S3RetryModeStandard,
+			retryAttempts:   1,
+		},
+	}
+
+	err = backend.setupS3Context()
+	if err != nil {
+		t.Fatalf("setupS3Context() failed: %v", err)
+	}
+
+	s3Context, ok = backend.context.(*s3ContextStruct)
+	if !ok {
+		t.Fatalf("backend.context.(*s3ContextStruct) returned !ok")
+	}
+
+	awsCredentials, err = s3Context.s3Client.Options().Credentials.Retrieve(context.Background())
+	if (err != nil) || (awsCredentials.AccessKeyID != "awsAccessKeyID1") || (awsCredentials.SecretAccessKey != "awsSecretAccessKey1") || !awsCredentials.CanExpire {
+		t.Fatalf("Credentials.Retrieve() returned %+v, %v (expected leased credential #1)", awsCredentials, err)
+	}
+
+	time.Sleep(time.Second)
+
+	awsCredentials, err = s3Context.s3Client.Options().Credentials.Retrieve(context.Background())
+	if (err != nil) || (awsCredentials.AccessKeyID != "awsAccessKeyID2") || (awsCredentials.SecretAccessKey != "awsSecretAccessKey2") {
+		t.Fatalf("Credentials.Retrieve() after lease expiry returned %+v, %v (expected leased credential #2)", awsCredentials, err)
+	}
+
+	// Setup fails if a referenced secret cannot be fetched
+
+	globals.config.vaultToken = "badToken"
+	globals.secrets = newSecrets()
+
+	err = backend.setupS3Context()
+	if err == nil {
+		t.Fatalf("setupS3Context() with unfetchable secret unexpectedly succeeded")
+	}
+}
+
+func TestAWSSecrets(t *testing.T) {
+	var (
+		awsServer      *httptest.Server
+		err            error
+		expiry         time.Time
+		resolved       []string
+		secretVersions atomic.Int32
+	)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "testAccessKeyID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "testSecretAccessKey")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	awsServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			request struct {
+				SecretId       string
+				Name           string
+				WithDecryption bool
+			}
+		)
+
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			if request.SecretId != "msfs/s3" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"__type":"ResourceNotFoundException","message":"not found"}`)
+				return
+			}
+
+			// Each read returns the next version of the secret
+
+			n := secretVersions.Add(1)
+			secretString, _ := json.Marshal(fmt.Sprintf(`{"access_key_id":"smAccessKeyID%d","secret_access_key":"smSecretAccessKey%d"}`, n, n))
+			_, _ = fmt.Fprintf(w, `{"Name":"msfs/s3","SecretString":%s}`, secretString)
+		case "AmazonSSM.GetParameter":
+			if (request.Name != "/msfs/ais/token") || !request.WithDecryption {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"__type":"ParameterNotFound","message":"not found"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"Parameter":{"Name":"/msfs/ais/token","Type":"SecureString","Value":"ssmToken"}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer awsServer.Close()
+
+	globals.config = &configStruct{
+		awsSecretsRegion:       "us-east-1",
+		awsSecretsEndpoint:     awsServer.URL,
+		secretsRefreshInterval: 100 * time.Millisecond,
+	}
+	globals.secrets = newSecrets()
+
+	for _, value := range []string{"aws-secretsmanager:msfs/s3#", "aws-ssm:/msfs/ais/token#value", "aws-ssm:"} {
+		err = checkSecretRef(globals.config, value)
+		if err == nil {
+			t.Fatalf("checkSecretRef(\"%s\") unexpectedly succeeded", value)
+		}
+	}
+
+	// Keys of a Secrets Manager secret's JSON SecretString and an SSM parameter's value
+
+	resolved, expiry, err = resolveSecrets("aws-secretsmanager:msfs/s3#access_key_id", "aws-secretsmanager:msfs/s3#secret_access_key", "aws-ssm:/msfs/ais/token")
+	if err != nil {
+		t.Fatalf("resolveSecrets() failed: %v", err)
+	}
+	if (resolved[0] != "smAccessKeyID1") || (resolved[1] != "smSecretAccessKey1") || (resolved[2] != "ssmToken") || expiry.IsZero() {
+		t.Fatalf("resolveSecrets() returned %v, %v", resolved, expiry)
+	}
+
+	// Cached until secrets_refresh_interval elapses
+
+	resolved, _, err = resolveSecrets("aws-secretsmanager:msfs/s3#access_key_id")
+	if (err != nil) || (resolved[0] != "smAccessKeyID1") {
+		t.Fatalf("resolveSecrets() [cached] returned %v, %v", resolved, err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	resolved, _, err = resolveSecrets("aws-secretsmanager:msfs/s3#access_key_id")
+	if (err != nil) || (resolved[0] != "smAccessKeyID2") {
+		t.Fatalf("resolveSecrets() [refreshed] returned %v, %v", resolved, err)
+	}
+
+	_, _, err = resolveSecrets("aws-secretsmanager:msfs/missing#key")
+	if err == nil {
+		t.Fatalf("resolveSecrets() of missing secret unexpectedly succeeded")
+	}
+	_, _, err = resolveSecrets("aws-ssm:/msfs/missing")
+	if err == nil {
+		t.Fatalf("resolveSecrets() of missing parameter unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	shutdownPollInterval = 100 * time.Millisecond
+)
+
+// `shutdownProgressStruct` counts what remains to be awaited before unmounting.
+type shutdownProgressStruct struct {
+	inboundCacheLines uint64 // Backend reads in flight
+}
+
+// `quiesced` returns whether nothing remains to be awaited.
+func (shutdownProgress *shutdownProgressStruct) quiesced() bool {
+	return shutdownProgress.inboundCacheLines == 0
+}
+
+// `shutdownProgress` returns a snapshot of what remains to be awaited before unmounting.
+func shutdownProgress() (shutdownProgress *shutdownProgressStruct) {
+	globals.Lock()
+
+	shutdownProgress = &shutdownProgressStruct{
+		inboundCacheLines: globals.inboundCacheLineCount,
+	}
+
+	globals.Unlock()
+
+	return
+}
+
+// `quiesceFS` is called at SIGINT/SIGTERM prior to unmounting. New opens (and creates)
+// are failed from here on while in-flight reads complete. As the mount has no write path,
+// there is no dirty data to be flushed. If the reads have not completed within timeout,
+// false is returned (and what remains is logged) so that the caller unmounts anyway. As
+// the unmount is lazy, processes still holding files open do not prevent it.
+func quiesceFS(timeout time.Duration) (quiesced bool) {
+	var (
+		deadline = time.Now().Add(timeout)
+		progress *shutdownProgressStruct
+	)
+
+	globals.Lock()
+	globals.shuttingDown = true
+	globals.Unlock()
+
+	for {
+		progress = shutdownProgress()
+		if progress.quiesced() {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			globals.logger.Printf("[WARN] [shutdown] unmounting after shutdown_timeout (%v) with %v inbound cache line(s) outstanding", timeout, progress.inboundCacheLines)
+			return false
+		}
+		time.Sleep(min(shutdownPollInterval, time.Until(deadline)))
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestQuiesceFS(t *testing.T) {
+	var (
+		err       error
+		errno     syscall.Errno
+		startTime time.Time
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "ram",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer func() {
+		globals.Lock()
+		globals.shuttingDown = false
+		globals.Unlock()
+		drainFS()
+	}()
+
+	// While a backend read is (seemingly) in flight, shutdown_timeout should expire
+
+	globals.Lock()
+	globals.inboundCacheLineCount++
+	globals.Unlock()
+
+	startTime = time.Now()
+
+	if quiesceFS(200 * time.Millisecond) {
+		t.Fatalf("quiesceFS() unexpectedly returned true while a read was in flight")
+	}
+	if time.Since(startTime) < 200*time.Millisecond {
+		t.Fatalf("quiesceFS() returned false before its timeout elapsed")
+	}
+	if shutdownProgress().inboundCacheLines != 1 {
+		t.Fatalf("shutdownProgress().inboundCacheLines should have been 1")
+	}
+
+	// New opens should now fail
+
+	_, errno = globals.DoOpen(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.OpenIn{})
+	if errno != syscall.ESHUTDOWN {
+		t.Fatalf("DoOpen() while shutting down returned errno %v (expected ESHUTDOWN)", errno)
+	}
+
+	_, errno = globals.DoOpenDir(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.OpenDirIn{})
+	if errno != syscall.ESHUTDOWN {
+		t.Fatalf("DoOpenDir() while shutting down returned errno %v (expected ESHUTDOWN)", errno)
+	}
+
+	// Once the read completes, quiescence should follow
+
+	globals.Lock()
+	globals.inboundCacheLineCount--
+	globals.Unlock()
+
+	if !quiesceFS(10 * time.Second) {
+		t.Fatalf("quiesceFS() unexpectedly returned false once the read completed")
+	}
+}
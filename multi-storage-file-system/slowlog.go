@@ -0,0 +1,71 @@
+package main
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+// `logSlowBackendRequest` logs (under the "slow" subsystem) a backend request for operation
+// (and, if != "", filePath) started at startTime should it have taken at least
+// slow_backend_request_threshold. As retries are counted per backend, the retries reported
+// are those the backend issued since retriesAtStart (i.e. backend.retries.Load() at startTime)
+// which, while other requests of the backend are outstanding, may include some of theirs.
+// As callers may hold globals.Lock(), slow_backend_request_threshold (which cannot change
+// via SIGHUP) is read without it.
+func (backend *backendStruct) logSlowBackendRequest(operation string, filePath string, startTime time.Time, retriesAtStart uint64, bytes int64, err error) {
+	var (
+		elapsed   = time.Since(startTime)
+		threshold = globals.config.slowBackendRequestThreshold
+	)
+
+	if (threshold == 0) || (elapsed < threshold) {
+		return
+	}
+
+	if filePath == "" {
+		globals.logger.Printf("[WARN] [slow] %s.%s() took %v (threshold: %v retries: %v bytes: %v err: %v)", backend.dirName, operation, elapsed, threshold, backend.retries.Load()-retriesAtStart, bytes, err)
+	} else {
+		globals.logger.Printf("[WARN] [slow] %s.%s(filePath:%q) took %v (threshold: %v retries: %v bytes: %v err: %v)", backend.dirName, operation, filePath, elapsed, threshold, backend.retries.Load()-retriesAtStart, bytes, err)
+	}
+}
+
+// `logSlowFUSEOp` logs (under the "slow" subsystem) op, performed on behalf of the caller
+// described by inHeader and started at startTime, should it have taken at least
+// slow_fuse_op_threshold. As for auditStruct.record(), the path is inode's objectPath with
+// basename appended (for operations naming a child of inode). Must be called without
+// globals.Lock() held (as it is taken to read slow_fuse_op_threshold and inode).
+func logSlowFUSEOp(inHeader *fission.InHeader, op string, inode *inodeStruct, basename string, bytes uint64, startTime time.Time, errno syscall.Errno) {
+	var (
+		backendName string
+		elapsed     = time.Since(startTime)
+		filePath    = basename
+		result      = "ok"
+		threshold   time.Duration
+	)
+
+	globals.Lock()
+
+	threshold = globals.config.slowFUSEOpThreshold
+
+	if (threshold == 0) || (elapsed < threshold) {
+		globals.Unlock()
+		return
+	}
+
+	if inode != nil {
+		if inode.backend != nil {
+			backendName = inode.backend.dirName
+		}
+		filePath = inode.objectPath + basename
+	}
+
+	globals.Unlock()
+
+	if errno != 0 {
+		result = errno.Error()
+	}
+
+	globals.logger.Printf("[WARN] [slow] fuse.%s(filePath:%q) took %v (threshold: %v backend: %q uid: %v pid: %v bytes: %v result: %s)", op, filePath, elapsed, threshold, backendName, inHeader.UID, inHeader.PID, bytes, result)
+}
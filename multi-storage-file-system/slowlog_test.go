@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestSlowLog(t *testing.T) {
+	var (
+		errno     syscall.Errno
+		lookupOut *fission.LookupOut
+		loggerOld *log.Logger
+		out       bytes.Buffer
+		ramDirIno uint64
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	loggerOld = globals.logger
+	globals.logger = log.New(&out, "", 0)
+	defer func() {
+		globals.logger = loggerOld
+	}()
+
+	// With thresholds disabled, nothing is logged
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	ramDirIno = lookupOut.EntryOut.NodeID
+
+	// As the ram directory's prefetch (in a goroutine of its own) issues backend requests (and
+	// logs them), it must be awaited before the thresholds (or the logger) may be changed
+
+	awaitPrefetch := func() {
+		for {
+			globals.Lock()
+			if !globals.inodeMap[ramDirIno].isPrefetchInProgress {
+				globals.Unlock()
+				return
+			}
+			globals.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	awaitPrefetch()
+
+	if strings.Contains(out.String(), "[slow]") {
+		t.Fatalf("slow operations logged with thresholds disabled: %s", out.String())
+	}
+
+	// With (trivially exceeded) thresholds, FUSE ops and the backend requests they issue (here, for
+	// the fileZ missing from the prefetched directory) are logged
+
+	globals.Lock()
+	globals.config.slowBackendRequestThreshold = time.Nanosecond
+	globals.config.slowFUSEOpThreshold = time.Nanosecond
+	globals.Unlock()
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno, UID: 1234}, &fission.LookupIn{Name: []byte("fileB")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileB\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoLookup(&fission.InHeader{NodeID: ramDirIno}, &fission.LookupIn{Name: []byte("fileZ")})
+	if errno != syscall.ENOENT {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileZ\") returned errno: %v (expected ENOENT)", errno)
+	}
+
+	awaitPrefetch()
+
+	globals.logger = loggerOld
+
+	for _, expected := range []string{
+		`[WARN] [slow] ram.statFile(filePath:"fileZ") took `,
+		`[WARN] [slow] fuse.lookup(filePath:"fileB") took `,
+		`backend: "ram" uid: 1234 pid: 0 bytes: 0 result: ok)`,
+		`[WARN] [slow] fuse.lookup(filePath:"fileZ") took `,
+		`result: no such file or directory)`,
+		`retries: 0 bytes: 0 err: file not found: no such file or directory)`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("slow operation log lacks \"%s\": %s", expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	snapshotManifestSuffix = ".snapshot"
+)
+
+// `snapshotManifestStruct` is the JSON-encoded content of each snapshot manifest
+// recorded in the snapshot_dir of the backend from which the snapshot was taken.
+type snapshotManifestStruct struct {
+	Name    string                         `json:"name"`
+	Backend string                         `json:"backend"` // dir_name of the backend snapshotted
+	Prefix  string                         `json:"prefix"`  // Relative to that backend's prefix; if != "", ends with a trailing "/"
+	Created time.Time                      `json:"created"` //
+	Objects []snapshotManifestObjectStruct `json:"objects"` // Sorted by Path
+}
+
+// `snapshotManifestObjectStruct` describes each file captured by a snapshot.
+type snapshotManifestObjectStruct struct {
+	Path  string    `json:"path"` // Relative to snapshotManifestStruct.Prefix
+	ETag  string    `json:"etag"` // If == "", the backend does not report an eTag
+	MTime time.Time `json:"mtime"`
+	Size  uint64    `json:"size"`
+}
+
+// `snapshotManifestFilePath` returns the path of the manifest for the named snapshot
+// in backend.snapshotDir. Names must be non-empty and not contain a path separator.
+func (backend *backendStruct) snapshotManifestFilePath(name string) (manifestFilePath string, err error) {
+	if backend.snapshotDir == "" {
+		err = fmt.Errorf("%s does not specify a snapshot_dir", backend.dirName)
+		return
+	}
+	if (name == "") || (name == ".") || (name == "..") || strings.ContainsRune(name, os.PathSeparator) {
+		err = fmt.Errorf("bad snapshot name %q", name)
+		return
+	}
+
+	manifestFilePath = filepath.Join(backend.snapshotDir, name+snapshotManifestSuffix)
+
+	return
+}
+
+// `takeSnapshot` records the key, eTag, modification time, and size of each file beneath
+// prefix as the named snapshot in backend.snapshotDir returning the number of files captured.
+// As snapshots are immutable, taking one with the name of an existing one fails with EEXIST.
+func (backend *backendStruct) takeSnapshot(name, prefix string) (numFiles uint64, err error) {
+	var (
+		manifest            *snapshotManifestStruct
+		manifestBuf         []byte
+		manifestFilePath    string
+		manifestFilePathTmp string
+		manifestFile        *os.File
+	)
+
+	if (prefix != "") && (!strings.HasSuffix(prefix, "/") || strings.HasPrefix(prefix, "/")) {
+		err = fmt.Errorf("bad snapshot prefix %q", prefix)
+		return
+	}
+
+	manifestFilePath, err = backend.snapshotManifestFilePath(name)
+	if err != nil {
+		return
+	}
+
+	_, err = os.Stat(manifestFilePath)
+	if err == nil {
+		err = fmt.Errorf("snapshot %q of %s: %w", name, backend.dirName, syscall.EEXIST)
+		return
+	}
+
+	manifest = &snapshotManifestStruct{
+		Name:    name,
+		Backend: backend.dirName,
+		Prefix:  prefix,
+		Created: time.Now().UTC(),
+		Objects: make([]snapshotManifestObjectStruct, 0),
+	}
+
+	err = backend.appendToSnapshotManifest(manifest, "")
+	if err != nil {
+		return
+	}
+
+	slices.SortFunc(manifest.Objects, func(a, b snapshotManifestObjectStruct) int { return strings.Compare(a.Path, b.Path) })
+
+	manifestBuf, err = json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(backend.snapshotDir, 0o700)
+	if err != nil {
+		return
+	}
+
+	manifestFilePathTmp = manifestFilePath + ".tmp"
+
+	manifestFile, err = os.OpenFile(manifestFilePathTmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return
+	}
+
+	_, err = manifestFile.Write(manifestBuf)
+	if err == nil {
+		err = manifestFile.Sync()
+	}
+	_ = manifestFile.Close()
+	if err == nil {
+		err = os.Rename(manifestFilePathTmp, manifestFilePath)
+	}
+	if err != nil {
+		_ = os.Remove(manifestFilePathTmp)
+		return
+	}
+
+	numFiles = uint64(len(manifest.Objects))
+
+	globals.logger.Printf("[INFO] [snapshot] %s captured %v file(s) beneath \"%s\" as snapshot \"%s\"", backend.dirName, numFiles, prefix, name)
+
+	return
+}
+
+// `appendToSnapshotManifest` recursively lists manifest.Prefix + dirPath of the backend
+// appending each file found to manifest.Objects.
+func (backend *backendStruct) appendToSnapshotManifest(manifest *snapshotManifestStruct, dirPath string) (err error) {
+	var (
+		continuationToken       string
+		listDirectoryOutput     *listDirectoryOutputStruct
+		listDirectoryOutputFile listDirectoryOutputFileStruct
+		subdirectories          []string
+		subdirectory            string
+	)
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(backend.context, &listDirectoryInputStruct{
+			continuationToken: continuationToken,
+			maxItems:          backend.directoryPageSize,
+			dirPath:           manifest.Prefix + dirPath,
+			bulk:              true,
+		})
+		if err != nil {
+			return
+		}
+
+		subdirectories = append(subdirectories, listDirectoryOutput.subdirectory...)
+
+		for _, listDirectoryOutputFile = range listDirectoryOutput.file {
+			manifest.Objects = append(manifest.Objects, snapshotManifestObjectStruct{
+				Path:  dirPath + listDirectoryOutputFile.basename,
+				ETag:  listDirectoryOutputFile.eTag,
+				MTime: listDirectoryOutputFile.mTime,
+				Size:  listDirectoryOutputFile.size,
+			})
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			break
+		}
+
+		continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+
+	for _, subdirectory = range subdirectories {
+		err = backend.appendToSnapshotManifest(manifest, dirPath+subdirectory+"/")
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `loadSnapshotManifest` reads the snapshot manifest at manifestFilePath.
+func loadSnapshotManifest(manifestFilePath string) (manifest *snapshotManifestStruct, err error) {
+	var (
+		manifestBuf []byte
+	)
+
+	manifestBuf, err = os.ReadFile(manifestFilePath)
+	if err != nil {
+		return
+	}
+
+	manifest = &snapshotManifestStruct{}
+
+	err = json.Unmarshal(manifestBuf, manifest)
+	if err != nil {
+		manifest = nil
+		err = fmt.Errorf("bad snapshot manifest %s: %v", manifestFilePath, err)
+		return
+	}
+
+	if !slices.IsSortedFunc(manifest.Objects, func(a, b snapshotManifestObjectStruct) int { return strings.Compare(a.Path, b.Path) }) {
+		manifest = nil
+		err = fmt.Errorf("bad snapshot manifest %s: objects not sorted", manifestFilePath)
+		return
+	}
+
+	return
+}
+
+// `refreshSnapshotsAlreadyLocked` is called while globals.Lock() is held, after backends
+// have been mounted, to connect each newly mounted Snapshot backend to the backend
+// from which its snapshot was taken.
+func refreshSnapshotsAlreadyLocked() {
+	var (
+		backend         *backendStruct
+		snapshotContext *snapshotContextStruct
+		ok              bool
+	)
+
+	for _, backend = range globals.config.backends {
+		snapshotContext, ok = backend.context.(*snapshotContextStruct)
+		if !ok || (snapshotContext.source != nil) {
+			continue
+		}
+
+		snapshotContext.source, ok = globals.config.backends[backend.backendTypeSpecifics.(*backendConfigSnapshotStruct).backend]
+		if !ok {
+			snapshotContext.source = nil
+			globals.logger.Printf("[WARN] [snapshot] %s unable to find backend \"%s\"", backend.dirName, backend.backendTypeSpecifics.(*backendConfigSnapshotStruct).backend)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	var (
+		backend             *backendStruct
+		err                 error
+		fileContent         []byte
+		listDirectoryOutput *listDirectoryOutputStruct
+		numFiles            uint64
+		ok                  bool
+		snapshotBackend     *backendStruct
+		snapshotDir         = t.TempDir()
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	dataBackendConfig := `
+			{
+				"dir_name": "data",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"snapshot_dir": "` + snapshotDir + `"
+			}`
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [`+dataBackendConfig+`
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	backend, ok = globals.config.backends["data"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"data\"] returned !ok")
+	}
+
+	for filePath, content := range map[string]string{"train/a": "a", "train/sub/b": "b", "other/c": "c"} {
+		_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: filePath, buf: []byte(content)})
+		if err != nil {
+			t.Fatalf("writeFileWrapper(\"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	numFiles, err = backend.takeSnapshot("exp1", "train/")
+	if (err != nil) || (numFiles != 2) {
+		t.Fatalf("takeSnapshot(\"exp1\", \"train/\") returned %v, %v (expected 2, nil)", numFiles, err)
+	}
+
+	_, err = backend.takeSnapshot("exp1", "train/")
+	if !errors.Is(err, syscall.EEXIST) {
+		t.Fatalf("takeSnapshot() of existing snapshot returned %v (expected EEXIST)", err)
+	}
+
+	// Files written after the snapshot was taken should not appear in it
+
+	_, err = writeFileWrapper(backend.context, &writeFileInputStruct{filePath: "train/later", buf: []byte("later")})
+	if err != nil {
+		t.Fatalf("writeFileWrapper(\"train/later\") failed: %v", err)
+	}
+
+	// Present the snapshot as a backend via SIGHUP
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [`+dataBackendConfig+`,
+			{
+				"dir_name": "exp1",
+				"bucket_container_name": "ignored",
+				"backend_type": "Snapshot",
+				"Snapshot": {
+					"backend": "data",
+					"name": "exp1"
+				}
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() [SIGHUP] unexpectedly failed: %v", err)
+	}
+
+	processToUnmountList()
+	processToMountList()
+
+	snapshotBackend, ok = globals.config.backends["exp1"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"exp1\"] returned !ok")
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(snapshotBackend.context, &listDirectoryInputStruct{dirPath: ""})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(\"\") of snapshot failed: %v", err)
+	}
+	if !slices.Equal(listDirectoryOutput.subdirectory, []string{"sub"}) || (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "a") {
+		t.Fatalf("listDirectoryWrapper(\"\") of snapshot returned %+v (expected subdirectory [sub] and file [a])", listDirectoryOutput)
+	}
+
+	fileContent, _, err = readWholeFile(snapshotBackend.context, "sub/b")
+	if (err != nil) || !bytes.Equal(fileContent, []byte("b")) {
+		t.Fatalf("readWholeFile(\"sub/b\") of snapshot returned %q, %v (expected \"b\")", fileContent, err)
+	}
+
+	_, err = statFileWrapper(snapshotBackend.context, &statFileInputStruct{filePath: "later"})
+	if err == nil {
+		t.Fatalf("statFileWrapper(\"later\") of snapshot unexpectedly succeeded")
+	}
+
+	_, err = writeFileWrapper(snapshotBackend.context, &writeFileInputStruct{filePath: "a", buf: []byte("x")})
+	if !errors.Is(err, syscall.EROFS) {
+		t.Fatalf("writeFileWrapper(\"a\") of snapshot returned %v (expected EROFS)", err)
+	}
+}
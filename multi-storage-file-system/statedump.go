@@ -0,0 +1,233 @@
+package main
+
+import (
+	"cmp"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// `stateDumpStruct` is the content of each file written by dumpState().
+type stateDumpStruct struct {
+	Time       time.Time                   `json:"time"`
+	PID        int                         `json:"pid"`
+	Version    string                      `json:"version"`
+	Inodes     []*stateDumpInodeStruct     `json:"inodes"`     // Ordered by inode number
+	Cache      *stateDumpCacheStruct       `json:"cache"`      //
+	Orphans    []*stateDumpCacheLineStruct `json:"orphans"`    // Cache lines whose inode is missing from globals.inodeMap (or no longer references them)
+	Goroutines string                      `json:"goroutines"` // Stacks of all goroutines (as would be reported by a panic)
+}
+
+// `stateDumpInodeStruct` describes an element of globals.inodeMap.
+type stateDumpInodeStruct struct {
+	Inode                  uint64                      `json:"inode"`
+	Type                   string                      `json:"type"`
+	Backend                string                      `json:"backend,omitempty"`
+	Path                   string                      `json:"path"`
+	ParentInode            uint64                      `json:"parent_inode"`
+	IsVirt                 bool                        `json:"is_virt"`
+	PendingDelete          bool                        `json:"pending_delete"`
+	OpenFileHandles        uint64                      `json:"open_file_handles"`
+	EvictionTime           *time.Time                  `json:"eviction_time,omitempty"` // If nil, not on globals.inodeEvictionLRU
+	InboundCacheLineCount  uint64                      `json:"inbound_cache_line_count"`
+	OutboundCacheLineCount uint64                      `json:"outbound_cache_line_count"`
+	DirtyCacheLineCount    uint64                      `json:"dirty_cache_line_count"`
+	CacheLines             []*stateDumpCacheLineStruct `json:"cache_lines,omitempty"` // Ordered by line number
+}
+
+// `stateDumpCacheStruct` describes the membership of the cache line LRUs.
+type stateDumpCacheStruct struct {
+	InboundCacheLineCount  uint64                      `json:"inbound_cache_line_count"`
+	OutboundCacheLineCount uint64                      `json:"outbound_cache_line_count"`
+	CleanLRU               []*stateDumpCacheLineStruct `json:"clean_lru"` // From least to most recently used
+	DirtyLRU               []*stateDumpCacheLineStruct `json:"dirty_lru"` // From least to most recently used
+}
+
+// `stateDumpCacheLineStruct` describes a cacheLineStruct.
+type stateDumpCacheLineStruct struct {
+	Inode    uint64 `json:"inode"`
+	Line     uint64 `json:"line"`
+	State    string `json:"state"`
+	Waiters  int    `json:"waiters"`
+	Bytes    int    `json:"bytes"`
+	Prefetch bool   `json:"prefetch"`
+}
+
+// `dumpState` writes (as JSON) the inode table, the membership of the cache line LRUs
+// (along with the count of waiters on each cache line), and the stacks of all goroutines
+// to a new file in dir (or, if dir == "", os.TempDir()) returning its path. This is done
+// upon receipt of a SIGUSR1.
+func dumpState(dir string) (dumpFilePath string, err error) {
+	var (
+		cacheLine   *cacheLineStruct
+		dump        *stateDumpStruct
+		dumpInode   *stateDumpInodeStruct
+		dumpJSON    []byte
+		inode       *inodeStruct
+		orphans     map[*cacheLineStruct]struct{}
+		stackBuf    []byte
+		stackBufLen int
+		tmpFilePath string
+		xTime       time.Time
+	)
+
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	dump = &stateDumpStruct{
+		Time:    time.Now().UTC(),
+		PID:     os.Getpid(),
+		Version: GitTag,
+		Orphans: make([]*stateDumpCacheLineStruct, 0),
+	}
+
+	globals.Lock()
+
+	dump.Inodes = make([]*stateDumpInodeStruct, 0, len(globals.inodeMap))
+
+	orphans = make(map[*cacheLineStruct]struct{})
+
+	dump.Cache = &stateDumpCacheStruct{
+		InboundCacheLineCount:  globals.inboundCacheLineCount,
+		OutboundCacheLineCount: globals.outboundCacheLineCount,
+		CleanLRU:               stateDumpLRU(globals.cleanCacheLineLRU, orphans),
+		DirtyLRU:               stateDumpLRU(globals.dirtyCacheLineLRU, orphans),
+	}
+
+	for _, inode = range globals.inodeMap {
+		dumpInode = &stateDumpInodeStruct{
+			Inode:                  inode.inodeNumber,
+			Type:                   inodeTypeName(inode.inodeType),
+			Path:                   inode.objectPath,
+			ParentInode:            inode.parentInodeNumber,
+			IsVirt:                 inode.isVirt,
+			PendingDelete:          inode.pendingDelete,
+			OpenFileHandles:        uint64(len(inode.fhMap)),
+			InboundCacheLineCount:  inode.inboundCacheLineCount,
+			OutboundCacheLineCount: inode.outboundCacheLineCount,
+			DirtyCacheLineCount:    inode.dirtyCacheLineCount,
+		}
+		if inode.backend != nil {
+			dumpInode.Backend = inode.backend.dirName
+		}
+		if inode.listElement != nil {
+			xTime = inode.xTime
+			dumpInode.EvictionTime = &xTime
+		}
+
+		for _, cacheLine = range inode.cache {
+			dumpInode.CacheLines = append(dumpInode.CacheLines, stateDumpCacheLine(cacheLine))
+		}
+
+		slices.SortFunc(dumpInode.CacheLines, func(a, b *stateDumpCacheLineStruct) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+
+		dump.Inodes = append(dump.Inodes, dumpInode)
+	}
+
+	for cacheLine = range orphans {
+		dump.Orphans = append(dump.Orphans, stateDumpCacheLine(cacheLine))
+	}
+
+	// Capture the goroutine stacks while still holding globals.Lock() so that those awaiting it are evident
+
+	stackBufLen = 1 << 20
+	for {
+		stackBuf = make([]byte, stackBufLen)
+		stackBufLen = runtime.Stack(stackBuf, true)
+		if stackBufLen < len(stackBuf) {
+			break
+		}
+		stackBufLen = 2 * len(stackBuf)
+	}
+	dump.Goroutines = string(stackBuf[:stackBufLen])
+
+	globals.Unlock()
+
+	slices.SortFunc(dump.Inodes, func(a, b *stateDumpInodeStruct) int {
+		return cmp.Compare(a.Inode, b.Inode)
+	})
+	slices.SortFunc(dump.Orphans, func(a, b *stateDumpCacheLineStruct) int {
+		return cmp.Or(cmp.Compare(a.Inode, b.Inode), cmp.Compare(a.Line, b.Line))
+	})
+
+	dumpJSON, err = json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return
+	}
+
+	dumpFilePath = filepath.Join(dir, fmt.Sprintf("msfs-state-%d-%s.json", dump.PID, dump.Time.Format("20060102T150405.000000000Z")))
+	tmpFilePath = dumpFilePath + ".tmp"
+
+	err = os.WriteFile(tmpFilePath, dumpJSON, 0o600)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmpFilePath, dumpFilePath)
+	if err != nil {
+		_ = os.Remove(tmpFilePath)
+	}
+
+	return
+}
+
+// `stateDumpLRU` describes each cacheLineStruct on lru (which must be either globals.cleanCacheLineLRU
+// or globals.dirtyCacheLineLRU), adding to orphans any whose inode is missing from globals.inodeMap
+// or no longer references them. Must be called with globals.Lock() held.
+func stateDumpLRU(lru *list.List, orphans map[*cacheLineStruct]struct{}) (cacheLines []*stateDumpCacheLineStruct) {
+	var (
+		cacheLine   *cacheLineStruct
+		inode       *inodeStruct
+		listElement *list.Element
+		ok          bool
+	)
+
+	cacheLines = make([]*stateDumpCacheLineStruct, 0, lru.Len())
+
+	for listElement = lru.Front(); listElement != nil; listElement = listElement.Next() {
+		cacheLine = listElement.Value.(*cacheLineStruct)
+
+		cacheLines = append(cacheLines, stateDumpCacheLine(cacheLine))
+
+		inode, ok = globals.inodeMap[cacheLine.inodeNumber]
+		if !ok || (inode.cache[cacheLine.lineNumber] != cacheLine) {
+			orphans[cacheLine] = struct{}{}
+		}
+	}
+
+	return
+}
+
+// `stateDumpCacheLine` describes cacheLine. Must be called with globals.Lock() held.
+func stateDumpCacheLine(cacheLine *cacheLineStruct) (dumpCacheLine *stateDumpCacheLineStruct) {
+	dumpCacheLine = &stateDumpCacheLineStruct{
+		Inode:    cacheLine.inodeNumber,
+		Line:     cacheLine.lineNumber,
+		Waiters:  len(cacheLine.waiters),
+		Bytes:    len(cacheLine.content),
+		Prefetch: cacheLine.prefetch,
+	}
+
+	switch cacheLine.state {
+	case CacheLineInbound:
+		dumpCacheLine.State = "inbound"
+	case CacheLineClean:
+		dumpCacheLine.State = "clean"
+	case CacheLineOutbound:
+		dumpCacheLine.State = "outbound"
+	case CacheLineDirty:
+		dumpCacheLine.State = "dirty"
+	default:
+		dumpCacheLine.State = fmt.Sprintf("unknown(%d)", cacheLine.state)
+	}
+
+	return
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestStateDump(t *testing.T) {
+	var (
+		dump          *stateDumpStruct
+		dumpDir       = t.TempDir()
+		dumpFilePath  string
+		dumpInode     *stateDumpInodeStruct
+		dumpJSON      []byte
+		err           error
+		errno         syscall.Errno
+		fileAIno      uint64
+		lookupOut     *fission.LookupOut
+		openOut       *fission.OpenOut
+		orphan        *cacheLineStruct
+		orphanInodeNo = uint64(0xFFFFFFFF)
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileAIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoRead(&fission.InHeader{NodeID: fileAIno}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 1})
+	if errno != 0 {
+		t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// Simulate a clean cache line whose inode has gone missing
+
+	globals.Lock()
+	orphan = &cacheLineStruct{state: CacheLineClean, inodeNumber: orphanInodeNo, lineNumber: 7}
+	orphan.listElement = globals.cleanCacheLineLRU.PushBack(orphan)
+	globals.Unlock()
+
+	dumpFilePath, err = dumpState(dumpDir)
+
+	globals.Lock()
+	globals.cleanCacheLineLRU.Remove(orphan.listElement)
+	globals.Unlock()
+
+	if err != nil {
+		t.Fatalf("dumpState() failed: %v", err)
+	}
+	if (filepath.Dir(dumpFilePath) != dumpDir) || !strings.HasPrefix(filepath.Base(dumpFilePath), "msfs-state-") {
+		t.Fatalf("dumpState() returned unexpected dumpFilePath: %s", dumpFilePath)
+	}
+
+	dumpJSON, err = os.ReadFile(dumpFilePath)
+	if err != nil {
+		t.Fatalf("os.ReadFile(dumpFilePath) failed: %v", err)
+	}
+
+	err = json.Unmarshal(dumpJSON, &dump)
+	if err != nil {
+		t.Fatalf("json.Unmarshal(dumpJSON) failed: %v", err)
+	}
+
+	if dump.PID != os.Getpid() {
+		t.Fatalf("dump.PID (%v) != os.Getpid() (%v)", dump.PID, os.Getpid())
+	}
+
+	for _, dumpInode = range dump.Inodes {
+		if dumpInode.Inode == fileAIno {
+			break
+		}
+	}
+	if (dumpInode == nil) || (dumpInode.Inode != fileAIno) {
+		t.Fatalf("dump.Inodes lacks fileAIno")
+	}
+	if (dumpInode.Type != "file") || (dumpInode.Backend != "ram") || (dumpInode.Path != "fileA") || (dumpInode.OpenFileHandles != 1) {
+		t.Fatalf("dump.Inodes[fileAIno] unexpected: %+v", dumpInode)
+	}
+	if (len(dumpInode.CacheLines) != 1) || (dumpInode.CacheLines[0].State != "clean") || (dumpInode.CacheLines[0].Waiters != 0) {
+		t.Fatalf("dump.Inodes[fileAIno].CacheLines unexpected: %+v", dumpInode.CacheLines)
+	}
+
+	if len(dump.Cache.CleanLRU) != 2 {
+		t.Fatalf("len(dump.Cache.CleanLRU) (%v) != 2", len(dump.Cache.CleanLRU))
+	}
+	if (len(dump.Orphans) != 1) || (dump.Orphans[0].Inode != orphanInodeNo) || (dump.Orphans[0].Line != 7) {
+		t.Fatalf("dump.Orphans unexpected: %+v", dump.Orphans)
+	}
+
+	if !strings.Contains(dump.Goroutines, "TestStateDump") {
+		t.Fatalf("dump.Goroutines lacks TestStateDump")
+	}
+}
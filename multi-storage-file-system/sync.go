@@ -0,0 +1,493 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	syncBackendScheme    = "msfs://"
+	syncParallelDefault  = uint64(8)
+	syncParallelMax      = uint64(256)
+	syncTmpFileSuffix    = ".msfs-sync.tmp"
+	syncMD5ETagHexDigits = 2 * md5.Size
+	syncActionCopy       = "copy"
+	syncActionDelete     = "delete"
+	syncActionUnchanged  = ""
+	syncLocalDirPerm     = 0o755
+	syncLocalFilePerm    = 0o644
+)
+
+// `syncOptionsStruct` holds the options of syncFiles().
+type syncOptionsStruct struct {
+	deleteExtraneous bool   // If true, files of dst absent from src are deleted
+	dryRun           bool   // If true, the actions that would be taken are reported but not taken
+	parallel         uint64 // Number of files transferred (or deleted) concurrently
+}
+
+// `syncEndpointStruct` is either a local directory or a prefix of a backend.
+type syncEndpointStruct struct {
+	backend  *backendStruct // If == nil, localDir applies
+	prefix   string         // Relative to backend.prefix; if != "", ends with a trailing "/"
+	localDir string         //
+	entries  map[string]*syncEntryStruct
+}
+
+// `syncEntryStruct` describes a file of a syncEndpointStruct.
+type syncEntryStruct struct {
+	size uint64
+	eTag string // If the endpoint is a local directory, == ""
+}
+
+// `syncerStruct` tracks the progress of syncFiles().
+type syncerStruct struct {
+	sync.Mutex               // Serializes writes to w and protects the counts below
+	w              io.Writer //
+	src            *syncEndpointStruct
+	dst            *syncEndpointStruct
+	options        *syncOptionsStruct
+	relPathChan    chan string    // Feeds workers each relative path of src.entries or (if deleting) dst.entries
+	workerGroup    sync.WaitGroup // Awaited after closing relPathChan
+	filesCopied    uint64
+	bytesCopied    uint64
+	filesDeleted   uint64
+	filesUnchanged uint64
+	filesFailed    uint64
+}
+
+// `syncFiles` makes dst (either a local directory or, if of the form msfs://<dir_name>[/<prefix>],
+// a prefix of a backend) match src (the other of the two) by copying each file of src that is
+// absent from dst or whose size or (where both are known) MD5 digest differs. If deleteExtraneous,
+// each file of dst absent from src is deleted. Each action (taken or, if dryRun, merely planned) is
+// reported to w followed by a summary. An error is returned if any action failed.
+func syncFiles(w io.Writer, srcArg string, dstArg string, options *syncOptionsStruct) (err error) {
+	var (
+		relPath  string
+		relPaths []string
+		syncer   *syncerStruct
+	)
+
+	syncer = &syncerStruct{
+		w:       w,
+		options: options,
+	}
+
+	syncer.src, err = parseSyncEndpoint(srcArg)
+	if err != nil {
+		return
+	}
+	syncer.dst, err = parseSyncEndpoint(dstArg)
+	if err != nil {
+		return
+	}
+
+	if (syncer.src.backend == nil) == (syncer.dst.backend == nil) {
+		err = fmt.Errorf("exactly one of \"%s\" and \"%s\" must be of the form %s<dir_name>[/<prefix>]", srcArg, dstArg, syncBackendScheme)
+		return
+	}
+	if (syncer.dst.backend != nil) && syncer.dst.backend.readOnly && !options.dryRun {
+		err = fmt.Errorf("backend \"%s\" is readonly", syncer.dst.backend.dirName)
+		return
+	}
+
+	err = syncer.src.list(true)
+	if err != nil {
+		return
+	}
+	err = syncer.dst.list(false)
+	if err != nil {
+		return
+	}
+
+	relPaths = make([]string, 0, len(syncer.src.entries)+len(syncer.dst.entries))
+	for relPath = range syncer.src.entries {
+		relPaths = append(relPaths, relPath)
+	}
+	if options.deleteExtraneous {
+		for relPath = range syncer.dst.entries {
+			if _, ok := syncer.src.entries[relPath]; !ok {
+				relPaths = append(relPaths, relPath)
+			}
+		}
+	}
+	slices.Sort(relPaths)
+
+	syncer.relPathChan = make(chan string)
+
+	for range max(options.parallel, 1) {
+		syncer.workerGroup.Go(syncer.worker)
+	}
+
+	for _, relPath = range relPaths {
+		syncer.relPathChan <- relPath
+	}
+
+	close(syncer.relPathChan)
+	syncer.workerGroup.Wait()
+
+	if options.dryRun {
+		_, _ = fmt.Fprintf(w, "dry run: would copy %v files (%v bytes) and delete %v files (%v unchanged)\n", syncer.filesCopied, syncer.bytesCopied, syncer.filesDeleted, syncer.filesUnchanged)
+	} else {
+		_, _ = fmt.Fprintf(w, "copied %v files (%v bytes), deleted %v files, %v unchanged, %v failed\n", syncer.filesCopied, syncer.bytesCopied, syncer.filesDeleted, syncer.filesUnchanged, syncer.filesFailed)
+	}
+
+	if syncer.filesFailed != 0 {
+		err = fmt.Errorf("%v files failed to sync", syncer.filesFailed)
+	}
+
+	return
+}
+
+// `parseSyncEndpoint` interprets arg as either msfs://<dir_name>[/<prefix>] (naming a
+// backend set up by setupInspectBackends()) or a local directory.
+func parseSyncEndpoint(arg string) (endpoint *syncEndpointStruct, err error) {
+	var (
+		path string
+	)
+
+	endpoint = &syncEndpointStruct{
+		entries: make(map[string]*syncEntryStruct),
+	}
+
+	if strings.HasPrefix(arg, syncBackendScheme) {
+		endpoint.backend, path, err = inspectTarget(strings.TrimPrefix(arg, syncBackendScheme))
+		if (err == nil) && (path != "") {
+			endpoint.prefix = path + "/"
+		}
+		return
+	}
+
+	endpoint.localDir = filepath.Clean(arg)
+
+	return
+}
+
+// `list` populates endpoint.entries with each file beneath it (keyed by its "/" separated
+// path relative to it). A missing local directory is only an error if mustExist.
+func (endpoint *syncEndpointStruct) list(mustExist bool) (err error) {
+	if endpoint.backend != nil {
+		err = endpoint.listBackend(endpoint.prefix)
+		return
+	}
+
+	err = filepath.WalkDir(endpoint.localDir, func(path string, dirEntry fs.DirEntry, walkErr error) (err error) {
+		var (
+			fileInfo fs.FileInfo
+			relPath  string
+		)
+
+		if walkErr != nil {
+			if (path == endpoint.localDir) && errors.Is(walkErr, fs.ErrNotExist) && !mustExist {
+				return fs.SkipAll
+			}
+			return walkErr
+		}
+
+		if !dirEntry.Type().IsRegular() || strings.HasSuffix(path, syncTmpFileSuffix) {
+			return
+		}
+
+		fileInfo, err = dirEntry.Info()
+		if err != nil {
+			return
+		}
+
+		relPath, err = filepath.Rel(endpoint.localDir, path)
+		if err != nil {
+			return
+		}
+
+		endpoint.entries[filepath.ToSlash(relPath)] = &syncEntryStruct{size: uint64(fileInfo.Size())}
+
+		return
+	})
+
+	return
+}
+
+// `listBackend` recursively lists dirPath of endpoint.backend adding each file found to endpoint.entries.
+func (endpoint *syncEndpointStruct) listBackend(dirPath string) (err error) {
+	var (
+		listDirectoryInput  *listDirectoryInputStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		subdirectories      []string
+		subdirectory        string
+	)
+
+	listDirectoryInput = &listDirectoryInputStruct{
+		maxItems: endpoint.backend.directoryPageSize,
+		dirPath:  dirPath,
+		bulk:     true,
+	}
+
+	for {
+		listDirectoryOutput, err = listDirectoryWrapper(endpoint.backend.context, listDirectoryInput)
+		if err != nil {
+			err = fmt.Errorf("unable to list \"%s\" of %s: %v", dirPath, endpoint.backend.dirName, err)
+			return
+		}
+
+		subdirectories = append(subdirectories, listDirectoryOutput.subdirectory...)
+
+		for _, file := range listDirectoryOutput.file {
+			endpoint.entries[strings.TrimPrefix(dirPath+file.basename, endpoint.prefix)] = &syncEntryStruct{size: file.size, eTag: file.eTag}
+		}
+
+		if !listDirectoryOutput.isTruncated || (listDirectoryOutput.nextContinuationToken == "") {
+			break
+		}
+
+		listDirectoryInput.continuationToken = listDirectoryOutput.nextContinuationToken
+		listDirectoryInput.reuse = listDirectoryOutput
+	}
+
+	for _, subdirectory = range subdirectories {
+		err = endpoint.listBackend(dirPath + subdirectory + "/")
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// `worker` determines (and, unless dry-run, takes) the action for each relPath fed by syncFiles().
+func (syncer *syncerStruct) worker() {
+	var (
+		action   string
+		dstEntry *syncEntryStruct
+		err      error
+		ok       bool
+		relPath  string
+		srcEntry *syncEntryStruct
+	)
+
+	for relPath = range syncer.relPathChan {
+		srcEntry, ok = syncer.src.entries[relPath]
+		if !ok {
+			action = syncActionDelete
+		} else {
+			dstEntry, ok = syncer.dst.entries[relPath]
+			if !ok {
+				action = syncActionCopy
+			} else {
+				action, err = syncer.compare(relPath, srcEntry, dstEntry)
+			}
+		}
+
+		if (err == nil) && !syncer.options.dryRun {
+			switch action {
+			case syncActionCopy:
+				err = syncer.copy(relPath, srcEntry)
+			case syncActionDelete:
+				err = syncer.delete(relPath)
+			}
+		}
+
+		syncer.Lock()
+		if err != nil {
+			syncer.filesFailed++
+			_, _ = fmt.Fprintf(syncer.w, "failed: %s: %v\n", relPath, err)
+		} else {
+			switch action {
+			case syncActionCopy:
+				syncer.filesCopied++
+				syncer.bytesCopied += srcEntry.size
+				_, _ = fmt.Fprintf(syncer.w, "copy: %s (%v bytes)\n", relPath, srcEntry.size)
+			case syncActionDelete:
+				syncer.filesDeleted++
+				_, _ = fmt.Fprintf(syncer.w, "delete: %s\n", relPath)
+			default:
+				syncer.filesUnchanged++
+			}
+		}
+		syncer.Unlock()
+
+		err = nil
+	}
+}
+
+// `compare` returns syncActionCopy if the file at relPath differs between src and dst,
+// else syncActionUnchanged. Files differ if their sizes do or, should the backend's eTag
+// be an MD5 digest (as it is for objects uploaded to S3 in a single part), if that of
+// the local file does not match it.
+func (syncer *syncerStruct) compare(relPath string, srcEntry *syncEntryStruct, dstEntry *syncEntryStruct) (action string, err error) {
+	var (
+		backendETag string
+		localMD5    string
+	)
+
+	if srcEntry.size != dstEntry.size {
+		action = syncActionCopy
+		return
+	}
+
+	if syncer.src.backend != nil {
+		backendETag = srcEntry.eTag
+	} else {
+		backendETag = dstEntry.eTag
+	}
+
+	if !isMD5ETag(backendETag) {
+		action = syncActionUnchanged
+		return
+	}
+
+	if syncer.src.backend != nil {
+		localMD5, err = localFileMD5(filepath.Join(syncer.dst.localDir, filepath.FromSlash(relPath)))
+	} else {
+		localMD5, err = localFileMD5(filepath.Join(syncer.src.localDir, filepath.FromSlash(relPath)))
+	}
+	if err != nil {
+		return
+	}
+
+	if strings.EqualFold(localMD5, backendETag) {
+		action = syncActionUnchanged
+	} else {
+		action = syncActionCopy
+	}
+
+	return
+}
+
+// `isMD5ETag` returns whether eTag has the form of an MD5 digest (i.e. 32 hex digits).
+func isMD5ETag(eTag string) bool {
+	if len(eTag) != syncMD5ETagHexDigits {
+		return false
+	}
+
+	_, err := hex.DecodeString(eTag)
+
+	return err == nil
+}
+
+// `localFileMD5` returns the (hex-encoded) MD5 digest of the content of the local file at path.
+func localFileMD5(path string) (digest string, err error) {
+	var (
+		file *os.File
+	)
+
+	file, err = os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := md5.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return
+	}
+
+	digest = hex.EncodeToString(hash.Sum(nil))
+
+	return
+}
+
+// `copy` copies the file at relPath from src to dst.
+func (syncer *syncerStruct) copy(relPath string, srcEntry *syncEntryStruct) (err error) {
+	var (
+		buf             []byte
+		cacheLineSize   = globals.config.cacheLineSize
+		file            *os.File
+		localPath       string
+		offsetCacheLine uint64
+		readFileOutput  *readFileOutputStruct
+		size            uint64
+		tmpPath         string
+	)
+
+	if syncer.dst.backend != nil {
+		buf, err = os.ReadFile(filepath.Join(syncer.src.localDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return
+		}
+
+		_, err = writeFileWrapper(syncer.dst.backend.context, &writeFileInputStruct{
+			filePath: syncer.dst.prefix + relPath,
+			buf:      buf,
+		})
+
+		return
+	}
+
+	localPath = filepath.Join(syncer.dst.localDir, filepath.FromSlash(relPath))
+	tmpPath = localPath + syncTmpFileSuffix
+
+	err = os.MkdirAll(filepath.Dir(localPath), syncLocalDirPerm)
+	if err != nil {
+		return
+	}
+
+	file, err = os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, syncLocalFilePerm)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	for offsetCacheLine = 0; size < srcEntry.size; offsetCacheLine++ {
+		readFileOutput, err = readFileWrapper(syncer.src.backend.context, &readFileInputStruct{
+			filePath:        syncer.src.prefix + relPath,
+			offsetCacheLine: offsetCacheLine,
+			cacheLineSize:   cacheLineSize,
+			ifMatch:         srcEntry.eTag,
+			bulk:            true,
+		})
+		if err != nil {
+			return
+		}
+		if len(readFileOutput.buf) == 0 {
+			err = fmt.Errorf("truncated at %v bytes (expected %v)", size, srcEntry.size)
+			return
+		}
+
+		_, err = file.Write(readFileOutput.buf)
+		if err != nil {
+			return
+		}
+
+		size += uint64(len(readFileOutput.buf))
+	}
+
+	err = file.Close()
+	file = nil
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmpPath, localPath)
+
+	return
+}
+
+// `delete` deletes the file at relPath from dst.
+func (syncer *syncerStruct) delete(relPath string) (err error) {
+	if syncer.dst.backend != nil {
+		_, err = deleteFileWrapper(syncer.dst.backend.context, &deleteFileInputStruct{filePath: syncer.dst.prefix + relPath})
+		return
+	}
+
+	err = os.Remove(filepath.Join(syncer.dst.localDir, filepath.FromSlash(relPath)))
+
+	return
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	var (
+		err      error
+		localDir = t.TempDir()
+		output   bytes.Buffer
+		ram      *backendStruct
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_line_size: 4
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	ram = globals.backendsToMount["ram"]
+
+	writeLocal := func(relPath string, content string) {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(localDir, relPath)), 0o755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(localDir, relPath), []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatalf("writing local \"%s\" failed: %v", relPath, err)
+		}
+	}
+
+	readBackend := func(filePath string) (content string) {
+		var buf bytes.Buffer
+		err := inspectCat(&buf, "ram/"+filePath, 0, 0)
+		if err != nil {
+			t.Fatalf("inspectCat(\"ram/%s\") failed: %v", filePath, err)
+		}
+		return buf.String()
+	}
+
+	writeLocal("fileA", "0123456789")
+	writeLocal("dir1/fileB", "abc")
+
+	// A dry run takes no action
+
+	err = syncFiles(&output, localDir, "msfs://ram/dst", &syncOptionsStruct{dryRun: true, parallel: 2})
+	if (err != nil) || !strings.HasSuffix(output.String(), "dry run: would copy 2 files (13 bytes) and delete 0 files (0 unchanged)\n") {
+		t.Fatalf("syncFiles(dryRun) unexpected (err: %v):\n%s", err, output.String())
+	}
+	_, err = ram.context.statFile(&statFileInputStruct{filePath: "dst/fileA"})
+	if err == nil {
+		t.Fatalf("syncFiles(dryRun) unexpectedly copied fileA")
+	}
+
+	// Local to backend
+
+	output.Reset()
+	err = syncFiles(&output, localDir, "msfs://ram/dst", &syncOptionsStruct{parallel: 2})
+	if (err != nil) || !strings.Contains(output.String(), "copy: dir1/fileB (3 bytes)\n") || !strings.HasSuffix(output.String(), "copied 2 files (13 bytes), deleted 0 files, 0 unchanged, 0 failed\n") {
+		t.Fatalf("syncFiles(local->ram) unexpected (err: %v):\n%s", err, output.String())
+	}
+	if (readBackend("dst/fileA") != "0123456789") || (readBackend("dst/dir1/fileB") != "abc") {
+		t.Fatalf("syncFiles(local->ram) copied unexpected content")
+	}
+
+	// As the RAM backend reports no eTags, only size changes are detected
+
+	writeLocal("fileA", "9876543210")
+	writeLocal("dir1/fileB", "abcd")
+
+	output.Reset()
+	err = syncFiles(&output, localDir, "msfs://ram/dst", &syncOptionsStruct{parallel: 1})
+	if (err != nil) || (output.String() != "copy: dir1/fileB (4 bytes)\ncopied 1 files (4 bytes), deleted 0 files, 1 unchanged, 0 failed\n") {
+		t.Fatalf("syncFiles(local->ram) second pass unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	// Backend to (a new) local directory with delete propagation
+
+	dstDir := filepath.Join(t.TempDir(), "new")
+	err = os.MkdirAll(filepath.Join(dstDir, "stale"), 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dstDir, "stale", "old"), []byte("old"), 0o644)
+	}
+	if err != nil {
+		t.Fatalf("writing stale file failed: %v", err)
+	}
+
+	output.Reset()
+	err = syncFiles(&output, "msfs://ram/dst/", dstDir, &syncOptionsStruct{deleteExtraneous: true, parallel: 4})
+	if (err != nil) || !strings.Contains(output.String(), "delete: stale/old\n") || !strings.HasSuffix(output.String(), "copied 2 files (14 bytes), deleted 1 files, 0 unchanged, 0 failed\n") {
+		t.Fatalf("syncFiles(ram->local) unexpected (err: %v):\n%s", err, output.String())
+	}
+	for relPath, expected := range map[string]string{"fileA": "0123456789", "dir1/fileB": "abcd"} {
+		content, err := os.ReadFile(filepath.Join(dstDir, relPath))
+		if (err != nil) || (string(content) != expected) {
+			t.Fatalf("syncFiles(ram->local) produced \"%s\" with \"%s\" (err: %v)", relPath, content, err)
+		}
+	}
+	_, err = os.Stat(filepath.Join(dstDir, "stale", "old"))
+	if err == nil {
+		t.Fatalf("syncFiles(ram->local) failed to delete stale/old")
+	}
+
+	// Neither or both being backends is rejected
+
+	err = syncFiles(&output, localDir, dstDir, &syncOptionsStruct{parallel: 1})
+	if err == nil {
+		t.Fatalf("syncFiles(local->local) should have failed")
+	}
+
+	// MD5 eTags detect same-sized changes
+
+	digest := md5.Sum([]byte("abcd"))
+
+	if !isMD5ETag(hex.EncodeToString(digest[:])) || isMD5ETag("abcd") || isMD5ETag("0123456789abcdef0123456789abcdef-2") {
+		t.Fatalf("isMD5ETag() misclassified")
+	}
+
+	syncer := &syncerStruct{
+		src: &syncEndpointStruct{localDir: localDir},
+		dst: &syncEndpointStruct{backend: ram},
+	}
+
+	action, err := syncer.compare("dir1/fileB", &syncEntryStruct{size: 4}, &syncEntryStruct{size: 4, eTag: hex.EncodeToString(digest[:])})
+	if (err != nil) || (action != syncActionUnchanged) {
+		t.Fatalf("compare() with matching MD5 returned \"%s\" (err: %v)", action, err)
+	}
+
+	digest = md5.Sum([]byte("wxyz"))
+
+	action, err = syncer.compare("dir1/fileB", &syncEntryStruct{size: 4}, &syncEntryStruct{size: 4, eTag: hex.EncodeToString(digest[:])})
+	if (err != nil) || (action != syncActionCopy) {
+		t.Fatalf("compare() with mismatched MD5 returned \"%s\" (err: %v)", action, err)
+	}
+}
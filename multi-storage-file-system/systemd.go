@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// `sdNotify` sends state (newline-separated assignments such as "READY=1") to the
+// systemd service manager via the datagram socket named by ${NOTIFY_SOCKET}. If
+// ${NOTIFY_SOCKET} is not set (i.e. not run as a Type=notify service), sent is
+// false and nothing is done. A leading '@' denotes a socket in the abstract namespace.
+func sdNotify(state string) (sent bool, err error) {
+	var (
+		conn         *net.UnixConn
+		notifySocket = os.Getenv("NOTIFY_SOCKET")
+	)
+
+	if notifySocket == "" {
+		return
+	}
+
+	if notifySocket[0] == '@' {
+		notifySocket = "\x00" + notifySocket[1:]
+	}
+
+	conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: notifySocket, Net: "unixgram"})
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return
+	}
+
+	sent = true
+	return
+}
+
+// `sdNotifyAndLog` is called at each transition reported to the service manager,
+// logging (rather than returning) any failure to report it.
+func sdNotifyAndLog(state string) {
+	var (
+		err error
+	)
+
+	_, err = sdNotify(state)
+	if err != nil {
+		globals.logger.Printf("[WARN] [systemd] unable to notify service manager of %q: %v", state, err)
+	}
+}
+
+// `sdWatchdogInterval` returns how often the service manager's watchdog should be
+// pinged (half of ${WATCHDOG_USEC}) or 0 if no watchdog is enabled for this process
+// (${WATCHDOG_PID}, if set, must match our PID).
+func sdWatchdogInterval() (interval time.Duration) {
+	var (
+		err         error
+		watchdogPID int
+		watchdogUS  uint64
+	)
+
+	watchdogUS, err = strconv.ParseUint(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if (err != nil) || (watchdogUS == 0) {
+		return
+	}
+
+	if os.Getenv("WATCHDOG_PID") != "" {
+		watchdogPID, err = strconv.Atoi(os.Getenv("WATCHDOG_PID"))
+		if (err != nil) || (watchdogPID != os.Getpid()) {
+			return
+		}
+	}
+
+	interval = time.Duration(watchdogUS) * time.Microsecond / 2
+	return
+}
+
+// `startSDWatchdog` is called once mounted to launch, if the service manager's
+// watchdog is enabled, a goroutine pinging it. As each ping (accompanied by a
+// STATUS reported by `systemctl status`) is only sent after briefly acquiring
+// globals.Lock(), a daemon hung while holding it (and thus unable to serve any
+// FUSE operation) stops pinging and is restarted.
+func startSDWatchdog() {
+	var (
+		interval = sdWatchdogInterval()
+	)
+
+	if interval == 0 {
+		return
+	}
+
+	globals.logger.Printf("[INFO] [systemd] pinging service manager watchdog every %v", interval)
+
+	go func() {
+		var (
+			numBackends int
+			numInodes   int
+			ticker      = time.NewTicker(interval)
+		)
+
+		for range ticker.C {
+			globals.Lock()
+			numBackends = len(globals.config.backends)
+			numInodes = len(globals.inodeMap)
+			globals.Unlock()
+
+			sdNotifyAndLog(fmt.Sprintf("WATCHDOG=1\nSTATUS=serving %v backend(s) with %v inode(s)", numBackends, numInodes))
+		}
+	}()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSDNotify(t *testing.T) {
+	var (
+		buf          = make([]byte, 256)
+		conn         *net.UnixConn
+		err          error
+		n            int
+		notifySocket = filepath.Join(t.TempDir(), "notify.sock")
+		sent         bool
+	)
+
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err = sdNotify("READY=1")
+	if sent || (err != nil) {
+		t.Fatalf("sdNotify() without NOTIFY_SOCKET returned %v, %v (expected false, nil)", sent, err)
+	}
+
+	conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: notifySocket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("net.ListenUnixgram() failed: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	t.Setenv("NOTIFY_SOCKET", notifySocket)
+
+	sent, err = sdNotify("READY=1\nSTATUS=mounted")
+	if !sent || (err != nil) {
+		t.Fatalf("sdNotify() returned %v, %v (expected true, nil)", sent, err)
+	}
+
+	err = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err != nil {
+		t.Fatalf("conn.SetReadDeadline() failed: %v", err)
+	}
+
+	n, err = conn.Read(buf)
+	if (err != nil) || (string(buf[:n]) != "READY=1\nSTATUS=mounted") {
+		t.Fatalf("conn.Read() returned %q, %v", buf[:n], err)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+	_, err = sdNotify("READY=1")
+	if err == nil {
+		t.Fatalf("sdNotify() to a missing NOTIFY_SOCKET unexpectedly succeeded")
+	}
+}
+
+func TestSDWatchdogInterval(t *testing.T) {
+	for _, testCase := range []struct {
+		watchdogUSec string
+		watchdogPID  string
+		expected     time.Duration
+	}{
+		{"", "", 0},
+		{"0", "", 0},
+		{"bad", "", 0},
+		{"30000000", "", 15 * time.Second},
+		{"30000000", strconv.Itoa(os.Getpid()), 15 * time.Second},
+		{"30000000", strconv.Itoa(os.Getpid() + 1), 0},
+	} {
+		t.Setenv("WATCHDOG_USEC", testCase.watchdogUSec)
+		t.Setenv("WATCHDOG_PID", testCase.watchdogPID)
+
+		if sdWatchdogInterval() != testCase.expected {
+			t.Fatalf("sdWatchdogInterval() with WATCHDOG_USEC=%q WATCHDOG_PID=%q returned %v (expected %v)", testCase.watchdogUSec, testCase.watchdogPID, sdWatchdogInterval(), testCase.expected)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: Copyright (c) 2025 NVIDIA CORPORATION & AFFILIATES. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NVIDIA/multi-storage-client/multi-storage-file-system/telemetry/attributes"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Sampler types accepted in TracesConfig.SamplerType.
+const (
+	SamplerAlwaysOn     = "always_on"    // Every trace is sampled
+	SamplerAlwaysOff    = "always_off"   // No trace is sampled
+	SamplerTraceIDRatio = "traceidratio" // The fraction SamplerRatio of traces are sampled
+	SamplerParentBased  = "parentbased"  // As the parent span was sampled, else as per traceidratio
+	DefaultSamplerType  = SamplerParentBased
+	DefaultSamplerRatio = float64(0.01)
+)
+
+// TracesConfig holds configuration for OTLP trace export.
+type TracesConfig struct {
+	OTLPEndpoint       string                          // e.g. "otel-collector:4318" (HTTP/OTLP)
+	Insecure           bool                            // If true, use insecure connection (no TLS)
+	SamplerType        string                          // One of Sampler*
+	SamplerRatio       float64                         // Fraction of traces sampled if SamplerType is SamplerTraceIDRatio or SamplerParentBased
+	ServiceName        string                          //
+	AttributeProviders []attributes.AttributesProvider // Attribute providers to add to resource
+}
+
+// SetupTracing initializes an OTLP/HTTP trace exporter batching the spans sampled per
+// config's sampler and sets the resulting TracerProvider as the global one.
+func SetupTracing(config *TracesConfig) (*sdktrace.TracerProvider, error) {
+	var sampler sdktrace.Sampler
+
+	switch config.SamplerType {
+	case SamplerAlwaysOn:
+		sampler = sdktrace.AlwaysSample()
+	case SamplerAlwaysOff:
+		sampler = sdktrace.NeverSample()
+	case SamplerTraceIDRatio:
+		sampler = sdktrace.TraceIDRatioBased(config.SamplerRatio)
+	case SamplerParentBased:
+		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SamplerRatio))
+	default:
+		return nil, fmt.Errorf("unsupported sampler type: %s", config.SamplerType)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(config.OTLPEndpoint),
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceAttrs := attributes.CollectAttributes(config.AttributeProviders)
+	resourceAttrs = append(resourceAttrs, semconv.ServiceName(config.ServiceName))
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, resourceAttrs...)),
+	)
+
+	// Set global tracer provider (to which tracers already obtained via otel.Tracer() delegate)
+	otel.SetTracerProvider(tracerProvider)
+
+	return tracerProvider, nil
+}
@@ -0,0 +1,634 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// `tieringStruct` tracks, for a (hot) backend specifying a tier_cold_backend, the
+// context of that cold backend (while mounted), the location map of which files
+// have been migrated to it, and the access counts used to decide which to migrate.
+type tieringStruct struct {
+	sync.Mutex                                              // Protects coldContext, locationMap, & accessCount
+	hot           *backendStruct                            //
+	coldContext   backendContextIf                          // If nil, the cold backend is not currently mounted
+	locationMap   map[string]*tieringLocationMapEntryStruct // Key is filePath (relative to backend.prefix of both hot & cold) of each file residing in the cold backend
+	accessCount   map[string]uint64                         // Key is filePath; value is the number of reads (of its first cache line) since the last tiering pass
+	stopChan      chan struct{}                             // Closed to stop tierer()
+	stopWaitGroup sync.WaitGroup                            // Awaited after closing stopChan
+}
+
+// `tieringLocationMapEntryStruct` is the JSON-encoded form of each value of the
+// location map persisted to hot.tierLocationMapFile. It records those attributes
+// of a file residing in the cold backend needed to list it as if in the hot one.
+type tieringLocationMapEntryStruct struct {
+	ETag  string    `json:"etag"`
+	MTime time.Time `json:"mtime"`
+	Size  uint64    `json:"size"`
+}
+
+// `refreshTieringAlreadyLocked` is called while globals.Lock() is held, after
+// backends have been mounted, to start tiering each newly mounted backend
+// specifying a tier_cold_backend and to (re)link each such backend to its
+// cold backend's context.
+func refreshTieringAlreadyLocked() {
+	var (
+		backend     *backendStruct
+		coldBackend *backendStruct
+		coldContext backendContextIf
+		err         error
+		locationMap map[string]*tieringLocationMapEntryStruct
+		ok          bool
+	)
+
+	for _, backend = range globals.config.backends {
+		if backend.tierColdBackend == "" {
+			continue
+		}
+
+		coldBackend, ok = globals.config.backends[backend.tierColdBackend]
+		if ok && coldBackend.mounted {
+			coldContext = coldBackend.context
+		} else {
+			coldContext = nil
+		}
+
+		if backend.tieringState == nil {
+			locationMap, err = loadTieringLocationMap(backend.tierLocationMapFile)
+			if err != nil {
+				globals.logger.Printf("[WARN] [tiering] unable to load location map \"%s\" (files already migrated to %s will not be visible in %s): %v", backend.tierLocationMapFile, backend.tierColdBackend, backend.dirName, err)
+				locationMap = make(map[string]*tieringLocationMapEntryStruct)
+			}
+
+			backend.tieringState = &tieringStruct{
+				hot:         backend,
+				coldContext: coldContext,
+				locationMap: locationMap,
+				accessCount: make(map[string]uint64),
+				stopChan:    make(chan struct{}),
+			}
+
+			backend.tieringState.stopWaitGroup.Go(backend.tieringState.tierer)
+		} else {
+			backend.tieringState.Lock()
+			backend.tieringState.coldContext = coldContext
+			backend.tieringState.Unlock()
+		}
+	}
+}
+
+// `stopTieringAlreadyLocked` is called while globals.Lock() is held as backend is
+// unmounted to stop tiering it (if applicable) and to unlink any other backend using
+// it as a tier_cold_backend. The location map remains persisted for the next mount.
+func (backend *backendStruct) stopTieringAlreadyLocked() {
+	var (
+		otherBackend *backendStruct
+	)
+
+	if backend.tieringState != nil {
+		close(backend.tieringState.stopChan)
+		backend.tieringState.stopWaitGroup.Wait()
+		backend.tieringState.Lock()
+		backend.tieringState.coldContext = nil
+		backend.tieringState.Unlock()
+	}
+
+	for _, otherBackend = range globals.config.backends {
+		if (otherBackend.tierColdBackend == backend.dirName) && (otherBackend.tieringState != nil) {
+			otherBackend.tieringState.Lock()
+			otherBackend.tieringState.coldContext = nil
+			otherBackend.tieringState.Unlock()
+		}
+	}
+}
+
+// `loadTieringLocationMap` reads the location map persisted to the specified file.
+// If the file does not exist, no files have yet been migrated and the map is empty.
+func loadTieringLocationMap(tierLocationMapFile string) (locationMap map[string]*tieringLocationMapEntryStruct, err error) {
+	var (
+		locationMapContent []byte
+	)
+
+	locationMap = make(map[string]*tieringLocationMapEntryStruct)
+
+	locationMapContent, err = os.ReadFile(tierLocationMapFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	err = json.Unmarshal(locationMapContent, &locationMap)
+
+	return
+}
+
+// `persistLocationMapAlreadyLocked` is called while tieringState.Lock() is held to
+// durably replace the persisted location map with the current one.
+func (tieringState *tieringStruct) persistLocationMapAlreadyLocked() (err error) {
+	var (
+		locationMapContent  []byte
+		locationMapFile     = tieringState.hot.tierLocationMapFile
+		locationMapFileTmp  = locationMapFile + ".tmp"
+		locationMapFileTmpF *os.File
+	)
+
+	locationMapContent, err = json.Marshal(tieringState.locationMap)
+	if err != nil {
+		return
+	}
+
+	locationMapFileTmpF, err = os.OpenFile(locationMapFileTmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+
+	_, err = locationMapFileTmpF.Write(locationMapContent)
+	if err == nil {
+		err = locationMapFileTmpF.Sync()
+	}
+	if err != nil {
+		_ = locationMapFileTmpF.Close()
+		return
+	}
+
+	err = locationMapFileTmpF.Close()
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(locationMapFileTmp, locationMapFile)
+
+	return
+}
+
+// `tieringColdContext` returns, for a backend specifying a tier_cold_backend, the
+// context of that cold backend if filePath has been migrated to it. Otherwise (or
+// if the cold backend is not currently mounted), nil is returned indicating the
+// operation on filePath should be applied to backend itself.
+func (backend *backendStruct) tieringColdContext(filePath string) (coldContext backendContextIf) {
+	var (
+		ok           bool
+		tieringState = backend.tieringState
+	)
+
+	if tieringState == nil {
+		return
+	}
+
+	tieringState.Lock()
+	_, ok = tieringState.locationMap[filePath]
+	if ok {
+		coldContext = tieringState.coldContext
+	}
+	tieringState.Unlock()
+
+	return
+}
+
+// `tieringRecordAccess` is called for each read of a backend specifying
+// a tier_cold_backend to count accesses to each file since the last
+// tiering pass. Only reads of the first cache line are counted such
+// that a sequential read of a file counts as a single access.
+func (backend *backendStruct) tieringRecordAccess(filePath string, offsetCacheLine uint64) {
+	var (
+		tieringState = backend.tieringState
+	)
+
+	if (tieringState == nil) || (offsetCacheLine != 0) {
+		return
+	}
+
+	tieringState.Lock()
+	tieringState.accessCount[filePath]++
+	tieringState.Unlock()
+}
+
+// `tieringForget` is called once filePaths no longer reside in backend's cold backend
+// (or are about to be superseded by a write to backend itself) to remove them from the
+// location map. If deleteFromCold is true, the cold backend's copies are also deleted.
+func (backend *backendStruct) tieringForget(filePaths []string, deleteFromCold bool) {
+	var (
+		coldContext    backendContextIf
+		err            error
+		filePath       string
+		forgottenPaths []string
+		ok             bool
+		tieringState   = backend.tieringState
+	)
+
+	if tieringState == nil {
+		return
+	}
+
+	tieringState.Lock()
+	for _, filePath = range filePaths {
+		_, ok = tieringState.locationMap[filePath]
+		if ok {
+			delete(tieringState.locationMap, filePath)
+			forgottenPaths = append(forgottenPaths, filePath)
+		}
+	}
+	if len(forgottenPaths) == 0 {
+		tieringState.Unlock()
+		return
+	}
+	err = tieringState.persistLocationMapAlreadyLocked()
+	if err != nil {
+		globals.logger.Printf("[WARN] [tiering] unable to persist location map \"%s\": %v", backend.tierLocationMapFile, err)
+	}
+	coldContext = tieringState.coldContext
+	tieringState.Unlock()
+
+	if deleteFromCold && (coldContext != nil) {
+		_, err = deleteFilesWrapper(coldContext, &deleteFilesInputStruct{
+			filePaths: forgottenPaths,
+		})
+		if err != nil {
+			globals.logger.Printf("[WARN] [tiering] unable to delete %v superseded file(s) from %s: %v", len(forgottenPaths), backend.tierColdBackend, err)
+		}
+	}
+}
+
+// `tieringPartition` splits filePaths into those that have been migrated to
+// backend's cold backend (if mounted) and those that should be applied to
+// backend itself.
+func (backend *backendStruct) tieringPartition(filePaths []string) (coldContext backendContextIf, coldFilePaths []string, hotFilePaths []string) {
+	var (
+		filePath     string
+		ok           bool
+		tieringState = backend.tieringState
+	)
+
+	if tieringState == nil {
+		hotFilePaths = filePaths
+		return
+	}
+
+	tieringState.Lock()
+	defer tieringState.Unlock()
+
+	coldContext = tieringState.coldContext
+	if coldContext == nil {
+		hotFilePaths = filePaths
+		return
+	}
+
+	for _, filePath = range filePaths {
+		_, ok = tieringState.locationMap[filePath]
+		if ok {
+			coldFilePaths = append(coldFilePaths, filePath)
+		} else {
+			hotFilePaths = append(hotFilePaths, filePath)
+		}
+	}
+
+	return
+}
+
+// `tieringHasDirectory` reports whether any file migrated to backend's cold
+// backend resides within dirPath (which, if != "", ends with a trailing "/").
+func (backend *backendStruct) tieringHasDirectory(dirPath string) (hasDirectory bool) {
+	var (
+		filePath     string
+		tieringState = backend.tieringState
+	)
+
+	if tieringState == nil {
+		return
+	}
+
+	tieringState.Lock()
+	defer tieringState.Unlock()
+
+	if tieringState.coldContext == nil {
+		return
+	}
+
+	for filePath = range tieringState.locationMap {
+		if strings.HasPrefix(filePath, dirPath) {
+			hasDirectory = true
+			return
+		}
+	}
+
+	return
+}
+
+// `tieringMergeListDirectory` is called with the final page of a listDirectory()
+// of backend to append the files (and subdirectories containing files) that have
+// been migrated to backend's cold backend and reside directly within dirPath.
+func (backend *backendStruct) tieringMergeListDirectory(dirPath string, listDirectoryOutput *listDirectoryOutputStruct) {
+	var (
+		basename         string
+		filePath         string
+		locationMapEntry *tieringLocationMapEntryStruct
+		ok               bool
+		slashIndex       int
+		subdirectory     string
+		subdirectorySet  map[string]struct{}
+		tieringState     = backend.tieringState
+	)
+
+	if tieringState == nil {
+		return
+	}
+
+	tieringState.Lock()
+	defer tieringState.Unlock()
+
+	if tieringState.coldContext == nil {
+		return
+	}
+
+	subdirectorySet = make(map[string]struct{}, len(listDirectoryOutput.subdirectory))
+	for _, subdirectory = range listDirectoryOutput.subdirectory {
+		subdirectorySet[subdirectory] = struct{}{}
+	}
+
+	for filePath, locationMapEntry = range tieringState.locationMap {
+		basename, ok = strings.CutPrefix(filePath, dirPath)
+		if !ok {
+			continue
+		}
+
+		slashIndex = strings.Index(basename, "/")
+		if slashIndex < 0 {
+			listDirectoryOutput.file = append(listDirectoryOutput.file, listDirectoryOutputFileStruct{
+				basename: basename,
+				eTag:     locationMapEntry.ETag,
+				mTime:    locationMapEntry.MTime,
+				size:     locationMapEntry.Size,
+			})
+			continue
+		}
+
+		subdirectory = basename[:slashIndex]
+		_, ok = subdirectorySet[subdirectory]
+		if !ok {
+			subdirectorySet[subdirectory] = struct{}{}
+			listDirectoryOutput.subdirectory = append(listDirectoryOutput.subdirectory, subdirectory)
+		}
+	}
+}
+
+// `tierer` is run as a background worker while the hot backend is mounted
+// to migrate files between it and its cold backend every hot.tierInterval.
+func (tieringState *tieringStruct) tierer() {
+	var (
+		ticker = time.NewTicker(tieringState.hot.tierInterval)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-tieringState.stopChan:
+			return
+		case <-ticker.C:
+			tieringState.tier()
+		}
+	}
+}
+
+// `matchesPathPatterns` reports whether filePath matches any of hot.tierPathPatterns
+// (each matched against the entire filePath via path.Match()).
+func (tieringState *tieringStruct) matchesPathPatterns(filePath string) (matches bool) {
+	var (
+		tierPathPattern string
+	)
+
+	for _, tierPathPattern = range tieringState.hot.tierPathPatterns {
+		matches, _ = path.Match(tierPathPattern, filePath)
+		if matches {
+			return
+		}
+	}
+
+	return
+}
+
+// `tier` performs a single tiering pass. First, each file residing in the cold backend
+// accessed more than hot.tierPromoteAccessCount times (if != 0) since the last pass and
+// not matching hot.tierPathPatterns is promoted back to the hot backend. Then, each file
+// residing in the hot backend that either matches hot.tierPathPatterns or (if hot.tierMinAge
+// != 0) is at least hot.tierMinAge old and was accessed no more than hot.tierMaxAccessCount
+// times since the last pass is demoted to the cold backend. In either case, the location
+// map is updated (and persisted) such that each file's path within the hot backend is stable.
+func (tieringState *tieringStruct) tier() {
+	var (
+		accessCount   map[string]uint64
+		coldContext   backendContextIf
+		err           error
+		filePath      string
+		filesDemoted  uint64
+		filesPromoted uint64
+		hot           = tieringState.hot
+		ok            bool
+		promotePaths  []string
+	)
+
+	tieringState.Lock()
+
+	coldContext = tieringState.coldContext
+	if (coldContext == nil) || (hot.context == nil) {
+		tieringState.Unlock()
+		return
+	}
+
+	accessCount = tieringState.accessCount
+	tieringState.accessCount = make(map[string]uint64)
+
+	if hot.tierPromoteAccessCount != 0 {
+		for filePath = range tieringState.locationMap {
+			if (accessCount[filePath] > hot.tierPromoteAccessCount) && !tieringState.matchesPathPatterns(filePath) {
+				promotePaths = append(promotePaths, filePath)
+			}
+		}
+	}
+
+	tieringState.Unlock()
+
+	// Promote (writeFileWrapper() of hot will remove each from the location map & delete it from cold)
+
+	for _, filePath = range promotePaths {
+		if tieringState.stopping() {
+			return
+		}
+
+		err = copyFile(coldContext, hot.context, filePath)
+		if err != nil {
+			globals.logger.Printf("[WARN] [tiering] unable to promote \"%s\" from %s to %s: %v", filePath, hot.tierColdBackend, hot.dirName, err)
+			continue
+		}
+
+		filesPromoted++
+	}
+
+	// Demote
+
+	ok = tieringState.demoteDirectory(coldContext, "", accessCount, &filesDemoted)
+	if !ok {
+		return
+	}
+
+	if (filesPromoted > 0) || (filesDemoted > 0) {
+		globals.logger.Printf("[INFO] [tiering] promoted %v file(s) from %s and demoted %v file(s) to %s", filesPromoted, hot.tierColdBackend, filesDemoted, hot.tierColdBackend)
+	}
+}
+
+// `stopping` reports whether tierer() has been asked to stop.
+func (tieringState *tieringStruct) stopping() (stopping bool) {
+	select {
+	case <-tieringState.stopChan:
+		stopping = true
+	default:
+		stopping = false
+	}
+
+	return
+}
+
+// `demoteDirectory` recursively walks dirPath of the hot backend demoting each eligible file
+// to the cold backend. It returns false if the walk was abandoned (i.e. tierer() is stopping).
+func (tieringState *tieringStruct) demoteDirectory(coldContext backendContextIf, dirPath string, accessCount map[string]uint64, filesDemoted *uint64) (ok bool) {
+	var (
+		continuationToken       string
+		err                     error
+		filePath                string
+		hot                     = tieringState.hot
+		listDirectoryOutput     *listDirectoryOutputStruct
+		listDirectoryOutputFile listDirectoryOutputFileStruct
+		migrated                bool
+		subdirectories          []string
+		subdirectory            string
+	)
+
+	for {
+		if tieringState.stopping() {
+			ok = false
+			return
+		}
+
+		listDirectoryOutput, err = hot.context.listDirectory(&listDirectoryInputStruct{
+			continuationToken: continuationToken,
+			maxItems:          hot.directoryPageSize,
+			dirPath:           dirPath,
+		})
+		if err != nil {
+			globals.logger.Printf("[WARN] [tiering] unable to list \"%s\" of %s: %v", dirPath, hot.dirName, err)
+			ok = true
+			return
+		}
+
+		subdirectories = append(subdirectories, listDirectoryOutput.subdirectory...)
+
+		for _, listDirectoryOutputFile = range listDirectoryOutput.file {
+			filePath = dirPath + listDirectoryOutputFile.basename
+
+			tieringState.Lock()
+			_, migrated = tieringState.locationMap[filePath]
+			tieringState.Unlock()
+
+			if migrated {
+				continue
+			}
+
+			if !tieringState.matchesPathPatterns(filePath) {
+				if (hot.tierMinAge == 0) || (time.Since(listDirectoryOutputFile.mTime) < hot.tierMinAge) || (accessCount[filePath] > hot.tierMaxAccessCount) {
+					continue
+				}
+			}
+
+			if tieringState.stopping() {
+				ok = false
+				return
+			}
+
+			err = tieringState.demoteFile(coldContext, filePath)
+			if err != nil {
+				globals.logger.Printf("[WARN] [tiering] unable to demote \"%s\" from %s to %s: %v", filePath, hot.dirName, hot.tierColdBackend, err)
+				continue
+			}
+
+			*filesDemoted++
+		}
+
+		if !listDirectoryOutput.isTruncated {
+			break
+		}
+
+		continuationToken = listDirectoryOutput.nextContinuationToken
+	}
+
+	for _, subdirectory = range subdirectories {
+		ok = tieringState.demoteDirectory(coldContext, dirPath+subdirectory+"/", accessCount, filesDemoted)
+		if !ok {
+			return
+		}
+	}
+
+	ok = true
+	return
+}
+
+// `demoteFile` copies filePath from the hot backend to the cold backend, records it in
+// the (persisted) location map, and only then deletes it from the hot backend. Note that
+// the hot backend's mirror (if any) retains its copy.
+func (tieringState *tieringStruct) demoteFile(coldContext backendContextIf, filePath string) (err error) {
+	var (
+		hot            = tieringState.hot
+		statFileOutput *statFileOutputStruct
+	)
+
+	err = copyFile(hot.context, coldContext, filePath)
+	if err != nil {
+		return
+	}
+
+	statFileOutput, err = statFileWrapper(coldContext, &statFileInputStruct{
+		filePath: filePath,
+		ifMatch:  "",
+		bulk:     true,
+	})
+	if err != nil {
+		return
+	}
+
+	tieringState.Lock()
+	tieringState.locationMap[filePath] = &tieringLocationMapEntryStruct{
+		ETag:  statFileOutput.eTag,
+		MTime: statFileOutput.mTime,
+		Size:  statFileOutput.size,
+	}
+	err = tieringState.persistLocationMapAlreadyLocked()
+	if err != nil {
+		delete(tieringState.locationMap, filePath)
+	}
+	delete(tieringState.accessCount, filePath) // Discount the reads performed by copyFile()
+	tieringState.Unlock()
+	if err != nil {
+		return
+	}
+
+	// Bypass deleteFileWrapper() which would now redirect filePath to the cold backend
+
+	_, err = hot.context.deleteFile(&deleteFileInputStruct{
+		filePath: filePath,
+		ifMatch:  "",
+	})
+	if err != nil {
+		// Reads are already redirected to the cold backend, so the hot copy is merely orphaned
+		globals.logger.Printf("[WARN] [tiering] unable to delete demoted \"%s\" from %s: %v", filePath, hot.dirName, err)
+		err = nil
+	}
+
+	return
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTiering(t *testing.T) {
+	var (
+		coldBackend         *backendStruct
+		err                 error
+		fileContent         []byte
+		hotBackend          *backendStruct
+		listDirectoryOutput *listDirectoryOutputStruct
+		locationMap         map[string]*tieringLocationMapEntryStruct
+		locationMapFile     = filepath.Join(t.TempDir(), "tier.map")
+		ok                  bool
+	)
+
+	err = os.Setenv("MSFS_MOUNTPOINT", testGlobals.testMountPoint)
+	if err != nil {
+		t.Fatalf("os.Setenv(\"MSFS_MOUNTPOINT\", testGlobals.testMountPoint) failed: %v", err)
+	}
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".json"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+	{
+		"msfs_version": 1,
+		"backends": [
+			{
+				"dir_name": "hot",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false,
+				"tier_cold_backend": "cold",
+				"tier_location_map_file": "`+locationMapFile+`",
+				"tier_promote_access_count": 1,
+				"tier_path_patterns": ["archive/*"]
+			},
+			{
+				"dir_name": "cold",
+				"bucket_container_name": "ignored",
+				"backend_type": "RAM",
+				"readonly": false
+			}
+		]
+	}
+	`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() unexpectedly failed: %v", err)
+	}
+
+	initFS()
+	processToMountList()
+	defer drainFS()
+
+	hotBackend, ok = globals.config.backends["hot"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"hot\"] returned !ok")
+	}
+	coldBackend, ok = globals.config.backends["cold"]
+	if !ok {
+		t.Fatalf("globals.config.backends[\"cold\"] returned !ok")
+	}
+
+	for _, filePath := range []string{"archive/a", "live/b"} {
+		_, err = writeFileWrapper(hotBackend.context, &writeFileInputStruct{
+			filePath: filePath,
+			buf:      []byte(filePath),
+		})
+		if err != nil {
+			t.Fatalf("writeFileWrapper(hot, \"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	// Both files should be demoted ("archive/a" by pattern, "live/b" explicitly as RAM reports mTime as now) yet remain visible in hot
+
+	hotBackend.tieringState.tier()
+
+	err = hotBackend.tieringState.demoteFile(coldBackend.context, "live/b")
+	if err != nil {
+		t.Fatalf("demoteFile(\"live/b\") failed: %v", err)
+	}
+
+	for _, filePath := range []string{"archive/a", "live/b"} {
+		_, err = hotBackend.context.statFile(&statFileInputStruct{filePath: filePath})
+		if err == nil {
+			t.Fatalf("tier() failed to demote \"%s\" from hot", filePath)
+		}
+		_, err = coldBackend.context.statFile(&statFileInputStruct{filePath: filePath})
+		if err != nil {
+			t.Fatalf("tier() failed to demote \"%s\" to cold: %v", filePath, err)
+		}
+		_, err = statFileWrapper(hotBackend.context, &statFileInputStruct{filePath: filePath})
+		if err != nil {
+			t.Fatalf("statFileWrapper(hot, \"%s\") failed after demotion: %v", filePath, err)
+		}
+	}
+
+	_, err = statDirectoryWrapper(hotBackend.context, &statDirectoryInputStruct{dirPath: "archive/"})
+	if err != nil {
+		t.Fatalf("statDirectoryWrapper(hot, \"archive/\") failed after demotion: %v", err)
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(hotBackend.context, &listDirectoryInputStruct{dirPath: "archive/"})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(hot, \"archive/\") failed: %v", err)
+	}
+	if (len(listDirectoryOutput.file) != 1) || (listDirectoryOutput.file[0].basename != "a") || (listDirectoryOutput.file[0].size != uint64(len("archive/a"))) {
+		t.Fatalf("listDirectoryWrapper(hot, \"archive/\") returned files %+v", listDirectoryOutput.file)
+	}
+
+	listDirectoryOutput, err = listDirectoryWrapper(hotBackend.context, &listDirectoryInputStruct{dirPath: ""})
+	if err != nil {
+		t.Fatalf("listDirectoryWrapper(hot, \"\") failed: %v", err)
+	}
+	slices.Sort(listDirectoryOutput.subdirectory)
+	if !slices.Equal(listDirectoryOutput.subdirectory, []string{"archive", "live"}) {
+		t.Fatalf("listDirectoryWrapper(hot, \"\") returned subdirectories %v", listDirectoryOutput.subdirectory)
+	}
+
+	// Frequently accessed "live/b" should be promoted back to hot
+
+	for range 2 {
+		fileContent, _, err = readWholeFile(hotBackend.context, "live/b")
+		if (err != nil) || !bytes.Equal(fileContent, []byte("live/b")) {
+			t.Fatalf("readWholeFile(hot, \"live/b\") returned %q, %v", fileContent, err)
+		}
+	}
+
+	hotBackend.tieringState.tier()
+
+	_, err = hotBackend.context.statFile(&statFileInputStruct{filePath: "live/b"})
+	if err != nil {
+		t.Fatalf("tier() failed to promote \"live/b\" to hot: %v", err)
+	}
+	_, err = coldBackend.context.statFile(&statFileInputStruct{filePath: "live/b"})
+	if err == nil {
+		t.Fatalf("tier() failed to remove promoted \"live/b\" from cold")
+	}
+
+	// Deleting "archive/a" via hot should delete it from cold and the location map
+
+	_, err = deleteFileWrapper(hotBackend.context, &deleteFileInputStruct{filePath: "archive/a"})
+	if err != nil {
+		t.Fatalf("deleteFileWrapper(hot, \"archive/a\") failed: %v", err)
+	}
+	_, err = coldBackend.context.statFile(&statFileInputStruct{filePath: "archive/a"})
+	if err == nil {
+		t.Fatalf("deleteFileWrapper(hot, \"archive/a\") failed to delete it from cold")
+	}
+
+	locationMap, err = loadTieringLocationMap(locationMapFile)
+	if err != nil {
+		t.Fatalf("loadTieringLocationMap() failed: %v", err)
+	}
+	if len(locationMap) != 0 {
+		t.Fatalf("loadTieringLocationMap() returned %v entries (expected 0)", len(locationMap))
+	}
+}
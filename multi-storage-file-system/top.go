@@ -0,0 +1,294 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	topIntervalDefault = 2 * time.Second
+	topFilesDefault    = 10  // Hottest files displayed by runTop() lacking {-files|--files}
+	topFilesFetched    = 256 // Files requested of GET /top so that those hottest over the interval are likely among them
+	topFUSEOpsShown    = 8   // FUSE operations (those with the highest rates) displayed by renderTop()
+	topClearScreen     = "\x1b[H\x1b[2J"
+)
+
+// `adminTopStruct` is the response to GET /top. Each count is cumulative (since startup
+// or, for files, the last POST /reset_io) so that rates may be derived from successive
+// responses by runTop().
+type adminTopStruct struct {
+	Time               time.Time                `json:"time"`
+	FUSEOps            map[string]uint64        `json:"fuse_ops"` // Keyed by op (e.g. "read")
+	CacheHits          uint64                   `json:"cache_hits"`
+	CacheMisses        uint64                   `json:"cache_misses"`
+	CacheWaits         uint64                   `json:"cache_waits"` // Cache lines awaited while being fetched to satisfy another read
+	OpenFileHandles    uint64                   `json:"open_file_handles"`
+	CacheLinesMax      uint64                   `json:"cache_lines_max"`
+	InboundCacheLines  uint64                   `json:"inbound_cache_lines"`
+	CleanCacheLines    uint64                   `json:"clean_cache_lines"`
+	OutboundCacheLines uint64                   `json:"outbound_cache_lines"`
+	DirtyCacheLines    uint64                   `json:"dirty_cache_lines"`
+	Backends           []*adminTopBackendStruct `json:"backends"`
+	Files              []*ioCountsStruct        `json:"files"` // The files having read the most bytes (see ioAccountingStruct)
+}
+
+// `adminTopBackendStruct` is an element of adminTopStruct.Backends.
+type adminTopBackendStruct struct {
+	Backend      string `json:"backend"`
+	Requests     uint64 `json:"requests"`
+	BytesRead    uint64 `json:"bytes_read"`
+	BytesWritten uint64 `json:"bytes_written"`
+	FetchWorkers uint64 `json:"fetch_workers"` // Current limit of concurrent cache line fetches (varying if fetch_workers_adaptive)
+}
+
+// `topOptionsStruct` holds the options of runTop().
+type topOptionsStruct struct {
+	interval    time.Duration // Between successive samples
+	iterations  uint64        // If == 0, runTop() continues until interrupted
+	files       int           // Hottest files displayed
+	clearScreen bool          // If true, each display replaces the last (i.e. w is a terminal)
+}
+
+// `adminTop` returns the cumulative counts from which runTop() derives rates including
+// (up to) files of the files having read the most bytes.
+func adminTop(files int) (top *adminTopStruct) {
+	var (
+		backend *backendStruct
+		stats   = adminStats()
+	)
+
+	top = &adminTopStruct{
+		Time:               time.Now(),
+		FUSEOps:            globals.fissionMetrics.opCounts(),
+		CacheHits:          counterValue(globals.fissionMetrics.ReadCacheHits),
+		CacheMisses:        counterValue(globals.fissionMetrics.ReadCacheMisses),
+		CacheWaits:         counterValue(globals.fissionMetrics.ReadCacheWaits),
+		OpenFileHandles:    stats.OpenFileHandles,
+		CacheLinesMax:      stats.CacheLinesMax,
+		InboundCacheLines:  stats.InboundCacheLines,
+		CleanCacheLines:    stats.CleanCacheLines,
+		OutboundCacheLines: stats.OutboundCacheLines,
+		DirtyCacheLines:    stats.DirtyCacheLines,
+		Backends:           make([]*adminTopBackendStruct, 0),
+		Files:              globals.ioAccounting.top(files).Inodes,
+	}
+
+	globals.Lock()
+	for _, backend = range globals.config.backends {
+		top.Backends = append(top.Backends, &adminTopBackendStruct{
+			Backend:      backend.dirName,
+			Requests:     backend.requests.Load(),
+			BytesRead:    backend.bytesRead.Load(),
+			BytesWritten: backend.bytesWritten.Load(),
+			FetchWorkers: backend.fetchPool.limit(),
+		})
+	}
+	globals.Unlock()
+
+	slices.SortFunc(top.Backends, func(a, b *adminTopBackendStruct) int {
+		return strings.Compare(a.Backend, b.Backend)
+	})
+
+	return
+}
+
+// `fetchAdminTop` issues GET /top to the running daemon via the admin API on admin_socket.
+func fetchAdminTop() (top *adminTopStruct, err error) {
+	var (
+		body         []byte
+		httpResponse *http.Response
+	)
+
+	httpResponse, err = adminSocketClient().Get("http://msfs/top?files=" + strconv.Itoa(topFilesFetched))
+	if err != nil {
+		err = fmt.Errorf("unable to reach daemon via admin_socket (\"%s\"): %v", globals.config.adminSocket, err)
+		return
+	}
+
+	body, err = io.ReadAll(httpResponse.Body)
+	_ = httpResponse.Body.Close()
+	if err != nil {
+		return
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		err = errors.New(strings.TrimSpace(string(body)))
+		return
+	}
+
+	top = &adminTopStruct{}
+
+	err = json.Unmarshal(body, top)
+
+	return
+}
+
+// `runTop` displays to w, every options.interval, the rates of FUSE operations, the cache
+// hit ratio, the throughput of each backend, and the hottest files of the running daemon
+// (as derived from successive samples of GET /top via the admin API on admin_socket).
+func runTop(w io.Writer, options *topOptionsStruct) (err error) {
+	var (
+		cur       *adminTopStruct
+		iteration uint64
+		prev      *adminTopStruct
+	)
+
+	if globals.config.adminSocket == "" {
+		err = errors.New("admin_socket not specified in config-file")
+		return
+	}
+
+	prev, err = fetchAdminTop()
+	if err != nil {
+		return
+	}
+
+	for iteration = 0; (options.iterations == 0) || (iteration < options.iterations); iteration++ {
+		time.Sleep(options.interval)
+
+		cur, err = fetchAdminTop()
+		if err != nil {
+			return
+		}
+
+		renderTop(w, prev, cur, options)
+
+		prev = cur
+	}
+
+	return
+}
+
+// `topRowStruct` is a line (of FUSE operations or files) displayed by renderTop() in order of rate.
+type topRowStruct struct {
+	name   string
+	rate   float64
+	detail string // Columns displayed between rate and name
+}
+
+// `topRate` returns the rate at which a cumulative count went from prev to cur over seconds.
+// Should the count have gone backwards (e.g. as the daemon was restarted), 0 is returned.
+func topRate(prev uint64, cur uint64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+
+	return float64(cur-prev) / seconds
+}
+
+// `renderTop` displays to w the rates derived from successive GET /top responses prev and cur.
+func renderTop(w io.Writer, prev *adminTopStruct, cur *adminTopStruct, options *topOptionsStruct) {
+	var (
+		backend      *adminTopBackendStruct
+		cacheLookups float64
+		count        uint64
+		file         *ioCountsStruct
+		hitRatio     = "-"
+		hitsRate     float64
+		missesRate   float64
+		op           string
+		opsTotal     float64
+		prevBackends = make(map[string]*adminTopBackendStruct)
+		prevBackend  *adminTopBackendStruct
+		prevFiles    = make(map[uint64]*ioCountsStruct)
+		prevFile     *ioCountsStruct
+		rows         []*topRowStruct
+		row          *topRowStruct
+		seconds      = cur.Time.Sub(prev.Time).Seconds()
+		waitsRate    float64
+	)
+
+	if seconds <= 0 {
+		seconds = options.interval.Seconds()
+	}
+
+	if options.clearScreen {
+		_, _ = io.WriteString(w, topClearScreen)
+	}
+
+	_, _ = fmt.Fprintf(w, "msfs top - %s (every %v)\n", cur.Time.Local().Format(time.DateTime), options.interval)
+	_, _ = fmt.Fprintf(w, "open file handles: %v  cache lines: %v clean, %v inbound, %v outbound, %v dirty (of %v)\n", cur.OpenFileHandles, cur.CleanCacheLines, cur.InboundCacheLines, cur.OutboundCacheLines, cur.DirtyCacheLines, cur.CacheLinesMax)
+
+	hitsRate = topRate(prev.CacheHits, cur.CacheHits, seconds)
+	missesRate = topRate(prev.CacheMisses, cur.CacheMisses, seconds)
+	waitsRate = topRate(prev.CacheWaits, cur.CacheWaits, seconds)
+	cacheLookups = hitsRate + missesRate + waitsRate
+	if cacheLookups > 0 {
+		hitRatio = fmt.Sprintf("%.1f%%", 100*hitsRate/cacheLookups)
+	}
+
+	_, _ = fmt.Fprintf(w, "cache hit ratio: %s (%.0f hits/s, %.0f misses/s, %.0f waits/s)\n", hitRatio, hitsRate, missesRate, waitsRate)
+
+	// FUSE operations (those with the highest rates)
+
+	for op, count = range cur.FUSEOps {
+		row = &topRowStruct{name: op, rate: topRate(prev.FUSEOps[op], count, seconds)}
+		opsTotal += row.rate
+		if row.rate > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b *topRowStruct) int {
+		return cmp.Or(cmp.Compare(b.rate, a.rate), strings.Compare(a.name, b.name))
+	})
+
+	_, _ = fmt.Fprintf(w, "\nFUSE OPS/s: %.0f\n", opsTotal)
+	for _, row = range rows[:min(len(rows), topFUSEOpsShown)] {
+		_, _ = fmt.Fprintf(w, "  %-12s %10.0f\n", row.name, row.rate)
+	}
+
+	// Backend throughput
+
+	for _, backend = range prev.Backends {
+		prevBackends[backend.Backend] = backend
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%-24s %10s %14s %14s\n", "BACKEND", "REQS/s", "READ MiB/s", "WRITE MiB/s")
+	for _, backend = range cur.Backends {
+		prevBackend = prevBackends[backend.Backend]
+		if prevBackend == nil {
+			prevBackend = &adminTopBackendStruct{}
+		}
+		_, _ = fmt.Fprintf(w, "%-24s %10.0f %14.2f %14.2f\n", backend.Backend, topRate(prevBackend.Requests, backend.Requests, seconds), topRate(prevBackend.BytesRead, backend.BytesRead, seconds)/(1<<20), topRate(prevBackend.BytesWritten, backend.BytesWritten, seconds)/(1<<20))
+	}
+
+	// Hottest files (by bytes read over the interval)
+
+	for _, file = range prev.Files {
+		prevFiles[file.Inode] = file
+	}
+
+	rows = rows[:0]
+	for _, file = range cur.Files {
+		prevFile = prevFiles[file.Inode]
+		if prevFile == nil {
+			prevFile = &ioCountsStruct{}
+		}
+		row = &topRowStruct{
+			name:   file.Backend + "/" + file.Path,
+			rate:   topRate(prevFile.BytesRead, file.BytesRead, seconds),
+			detail: fmt.Sprintf("%10.0f %12.0f", topRate(prevFile.Reads, file.Reads, seconds), topRate(prevFile.CacheMisses, file.CacheMisses, seconds)),
+		}
+		if row.rate > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	slices.SortFunc(rows, func(a, b *topRowStruct) int {
+		return cmp.Or(cmp.Compare(b.rate, a.rate), strings.Compare(a.name, b.name))
+	})
+
+	_, _ = fmt.Fprintf(w, "\n%14s %10s %12s  %s\n", "READ MiB/s", "READS/s", "MISSES/s", "FILE")
+	for _, row = range rows[:min(len(rows), options.files)] {
+		_, _ = fmt.Fprintf(w, "%14.2f %s  %s\n", row.rate/(1<<20), row.detail, row.name)
+	}
+}
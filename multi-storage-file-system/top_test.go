@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+)
+
+func TestTop(t *testing.T) {
+	var (
+		cur       *adminTopStruct
+		err       error
+		errno     syscall.Errno
+		fileAIno  uint64
+		lookupOut *fission.LookupOut
+		openOut   *fission.OpenOut
+		options   = &topOptionsStruct{interval: time.Second, iterations: 1, files: topFilesDefault}
+		output    bytes.Buffer
+		prev      *adminTopStruct
+	)
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	err = runTop(&output, options)
+	if (err == nil) || !strings.Contains(err.Error(), "admin_socket") {
+		t.Fatalf("runTop() without admin_socket returned err: %v", err)
+	}
+
+	globals.config.adminSocket = filepath.Join(t.TempDir(), "admin.sock")
+	startAdminSocket()
+	defer stopAdminSocket()
+
+	prev, err = fetchAdminTop()
+	if err != nil {
+		t.Fatalf("fetchAdminTop() failed: %v", err)
+	}
+
+	// Read fileA (a cache miss followed by hits) between the two samples
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileAIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	for range 4 {
+		_, errno = globals.DoRead(&fission.InHeader{NodeID: fileAIno}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 4})
+		if errno != 0 {
+			t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+		}
+	}
+
+	cur, err = fetchAdminTop()
+	if err != nil {
+		t.Fatalf("fetchAdminTop() failed: %v", err)
+	}
+
+	if (cur.FUSEOps["read"] != prev.FUSEOps["read"]+4) || (cur.CacheMisses != prev.CacheMisses+1) || (cur.CacheHits != prev.CacheHits+3) || (cur.OpenFileHandles != 1) {
+		t.Fatalf("GET /top after 4 reads returned %+v (prior %+v)", cur, prev)
+	}
+	if (len(cur.Backends) != 1) || (cur.Backends[0].Backend != "ram") || (cur.Backends[0].BytesRead <= prev.Backends[0].BytesRead) || (cur.Backends[0].Requests <= prev.Backends[0].Requests) {
+		t.Fatalf("GET /top returned backends %+v (prior %+v)", cur.Backends, prev.Backends)
+	}
+	if (len(cur.Files) == 0) || (cur.Files[0].Inode != fileAIno) {
+		t.Fatalf("GET /top returned files %+v", cur.Files)
+	}
+
+	// Rates are derived from the two samples (as if a second apart)
+
+	cur.Time = prev.Time.Add(time.Second)
+
+	output.Reset()
+	renderTop(&output, prev, cur, options)
+
+	for _, expected := range []string{
+		"cache hit ratio: 75.0% (3 hits/s, 1 misses/s, 0 waits/s)\n",
+		"  read                  4\n",
+		"\nram ",
+		"          4            1  ram/fileA\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("renderTop() output lacks %q:\n%s", expected, output.String())
+		}
+	}
+	if strings.Contains(output.String(), topClearScreen) {
+		t.Fatalf("renderTop() cleared the screen though not requested")
+	}
+
+	// A single iteration samples twice (a second apart)
+
+	output.Reset()
+
+	err = runTop(&output, options)
+	if (err != nil) || !strings.HasPrefix(output.String(), "msfs top - ") {
+		t.Fatalf("runTop() returned err: %v\n%s", err, output.String())
+	}
+}
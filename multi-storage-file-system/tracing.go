@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"syscall"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// `msfsTracer` creates the spans tracing the read path (a FUSE read, the cache line
+// fetches it triggers, and the backend reads they issue). Until opentelemetry.traces
+// configures an exporter (see initTracing()), the global TracerProvider is a no-op.
+var msfsTracer = otel.Tracer("msfs")
+
+// `traceContextOrBackground` returns ctx unless nil, in which case context.Background()
+// is returned (i.e. for an operation not initiated by a traced FUSE read).
+func traceContextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+
+	return ctx
+}
+
+// `endSpanWithErr` records err (if non-nil) as the status of span and ends it.
+func endSpanWithErr(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// `endSpanWithErrno` records errno (if non-zero) as the status of span and ends it.
+func endSpanWithErrno(span trace.Span, errno syscall.Errno) {
+	if errno != 0 {
+		endSpanWithErr(span, errno)
+		return
+	}
+
+	span.End()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/fission/v3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestReadTracing(t *testing.T) {
+	var (
+		errno          syscall.Errno
+		fileAIno       uint64
+		lookupOut      *fission.LookupOut
+		msfsTracerOld  = msfsTracer
+		openOut        *fission.OpenOut
+		spanRecorder   = tracetest.NewSpanRecorder()
+		spansByName    = make(map[string]sdktrace.ReadOnlySpan)
+		tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	)
+
+	msfsTracer = tracerProvider.Tracer("msfs")
+	defer func() {
+		msfsTracer = msfsTracerOld
+	}()
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: FUSERootDirInodeNumber}, &fission.LookupIn{Name: []byte("ram")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(FUSERootDirInodeNumber,Name:\"ram\") unexpectedly failed (errno: %v)", errno)
+	}
+
+	lookupOut, errno = globals.DoLookup(&fission.InHeader{NodeID: lookupOut.EntryOut.NodeID}, &fission.LookupIn{Name: []byte("fileA")})
+	if errno != 0 {
+		t.Fatalf("DoLookup(ramDirIno,Name:\"fileA\") unexpectedly failed (errno: %v)", errno)
+	}
+	fileAIno = lookupOut.EntryOut.NodeID
+
+	openOut, errno = globals.DoOpen(&fission.InHeader{NodeID: fileAIno}, &fission.OpenIn{Flags: fission.FOpenRequestRDONLY})
+	if errno != 0 {
+		t.Fatalf("DoOpen(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	_, errno = globals.DoRead(&fission.InHeader{NodeID: fileAIno}, &fission.ReadIn{FH: openOut.FH, Offset: 0, Size: 1})
+	if errno != 0 {
+		t.Fatalf("DoRead(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	errno = globals.DoRelease(&fission.InHeader{NodeID: fileAIno}, &fission.ReleaseIn{FH: openOut.FH})
+	if errno != 0 {
+		t.Fatalf("DoRelease(fileAIno) unexpectedly failed (errno: %v)", errno)
+	}
+
+	// A cache miss yields a fuse.read span parenting a cache.fetch span parenting a backend.read span
+	// (though the cache.fetch span may only end just after having satisfied the fuse.read)
+
+	for waits := 0; (len(spanRecorder.Ended()) < 3) && (waits < 100); waits++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, span := range spanRecorder.Ended() {
+		spansByName[span.Name()] = span
+	}
+
+	for _, parentAndChild := range [][2]string{{"fuse.read", "cache.fetch"}, {"cache.fetch", "backend.read"}} {
+		parent, ok := spansByName[parentAndChild[0]]
+		if !ok {
+			t.Fatalf("no %s span recorded", parentAndChild[0])
+		}
+		child, ok := spansByName[parentAndChild[1]]
+		if !ok {
+			t.Fatalf("no %s span recorded", parentAndChild[1])
+		}
+		if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("%s span not a child of %s span", parentAndChild[1], parentAndChild[0])
+		}
+	}
+
+	if len(spansByName["fuse.read"].Events()) != 1 || (spansByName["fuse.read"].Events()[0].Name != "cache.miss") {
+		t.Fatalf("fuse.read span recorded events %+v (expected a single cache.miss)", spansByName["fuse.read"].Events())
+	}
+}
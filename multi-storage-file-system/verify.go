@@ -0,0 +1,377 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	verifyParallelDefault = uint64(8)
+	verifyParallelMax     = uint64(256)
+	verifyPartSizeMax     = uint64(5 << 30) // The largest part of an S3 multipart upload
+)
+
+// `verifyPartSizeGuesses` are the part sizes (beyond that of the backend's own multipart
+// uploads) commonly used by S3 clients (e.g. 8 MiB for the AWS CLI) tried, in order, when
+// checking a local file against a multipart eTag lacking {-part-size|--part-size}.
+var verifyPartSizeGuesses = []uint64{
+	8 << 20,
+	5 << 20,
+	16 << 20,
+	15 << 20,
+	32 << 20,
+	64 << 20,
+	100 << 20,
+	128 << 20,
+	256 << 20,
+	512 << 20,
+	1 << 30,
+}
+
+// `verifyOptionsStruct` holds the options of verifyFiles().
+type verifyOptionsStruct struct {
+	parallel uint64 // Number of files checked concurrently
+	partSize uint64 // If != 0, the part size of multipart eTags (else guessed per verifyPartSizeGuesses)
+	read     bool   // If true, a file whose eTag cannot be checked is read from the backend to compare digests
+}
+
+// `verifierStruct` tracks the progress of verifyFiles().
+type verifierStruct struct {
+	sync.Mutex                  // Serializes writes to w and protects the counts below
+	w                 io.Writer //
+	local             *syncEndpointStruct
+	remote            *syncEndpointStruct
+	options           *verifyOptionsStruct
+	relPathChan       chan string    // Feeds workers each relative path of local.entries and remote.entries
+	workerGroup       sync.WaitGroup // Awaited after closing relPathChan
+	filesMatched      uint64
+	bytesMatched      uint64
+	filesSizeOnly     uint64 // Of filesMatched, those whose digest could not be checked
+	filesMissing      uint64 // Files of local absent from remote
+	filesExtraneous   uint64 // Files of remote absent from local
+	filesSizeDiffer   uint64
+	filesDigestDiffer uint64
+	filesFailed       uint64
+}
+
+// `verifyFiles` compares, without modifying either, the local directory localArg with the
+// backend prefix remoteArg (of the form msfs://<dir_name>[/<prefix>]) reporting to w each file
+// missing from remoteArg, extraneous in remoteArg, or whose size or digest differs followed by
+// a summary. The digest of a local file is compared with the backend's eTag if that is either
+// an MD5 digest (as it is for objects uploaded to S3 in a single part) or a multipart eTag whose
+// part size is options.partSize (or, if 0, is successfully guessed). Files whose digests cannot
+// be so compared match on size alone unless options.read. An error is returned if any drift
+// was found (or any file could not be checked).
+func verifyFiles(w io.Writer, localArg string, remoteArg string, options *verifyOptionsStruct) (err error) {
+	var (
+		relPath  string
+		relPaths []string
+		verifier *verifierStruct
+	)
+
+	verifier = &verifierStruct{
+		w:       w,
+		options: options,
+	}
+
+	verifier.local, err = parseSyncEndpoint(localArg)
+	if err != nil {
+		return
+	}
+	verifier.remote, err = parseSyncEndpoint(remoteArg)
+	if err != nil {
+		return
+	}
+
+	if (verifier.local.backend != nil) || (verifier.remote.backend == nil) {
+		err = fmt.Errorf("\"%s\" must be a local directory and \"%s\" of the form %s<dir_name>[/<prefix>]", localArg, remoteArg, syncBackendScheme)
+		return
+	}
+
+	err = verifier.local.list(true)
+	if err != nil {
+		return
+	}
+	err = verifier.remote.list(false)
+	if err != nil {
+		return
+	}
+
+	relPaths = make([]string, 0, len(verifier.local.entries)+len(verifier.remote.entries))
+	for relPath = range verifier.local.entries {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath = range verifier.remote.entries {
+		if _, ok := verifier.local.entries[relPath]; !ok {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	slices.Sort(relPaths)
+
+	verifier.relPathChan = make(chan string)
+
+	for range max(options.parallel, 1) {
+		verifier.workerGroup.Go(verifier.worker)
+	}
+
+	for _, relPath = range relPaths {
+		verifier.relPathChan <- relPath
+	}
+
+	close(verifier.relPathChan)
+	verifier.workerGroup.Wait()
+
+	_, _ = fmt.Fprintf(w, "verified %v files: %v matched (%v bytes, %v by size only), %v missing, %v extraneous, %v differ in size, %v differ in digest, %v failed\n", len(relPaths), verifier.filesMatched, verifier.bytesMatched, verifier.filesSizeOnly, verifier.filesMissing, verifier.filesExtraneous, verifier.filesSizeDiffer, verifier.filesDigestDiffer, verifier.filesFailed)
+
+	if (verifier.filesMissing + verifier.filesExtraneous + verifier.filesSizeDiffer + verifier.filesDigestDiffer) != 0 {
+		err = fmt.Errorf("%v files drifted", verifier.filesMissing+verifier.filesExtraneous+verifier.filesSizeDiffer+verifier.filesDigestDiffer)
+	} else if verifier.filesFailed != 0 {
+		err = fmt.Errorf("%v files failed to be verified", verifier.filesFailed)
+	}
+
+	return
+}
+
+// `worker` checks (reporting any drift) each relPath fed by verifyFiles().
+func (verifier *verifierStruct) worker() {
+	var (
+		err         error
+		localEntry  *syncEntryStruct
+		localOK     bool
+		matched     bool
+		relPath     string
+		remoteEntry *syncEntryStruct
+		remoteOK    bool
+		sizeOnly    bool
+	)
+
+	for relPath = range verifier.relPathChan {
+		localEntry, localOK = verifier.local.entries[relPath]
+		remoteEntry, remoteOK = verifier.remote.entries[relPath]
+
+		if localOK && remoteOK && (localEntry.size == remoteEntry.size) {
+			matched, sizeOnly, err = verifier.compareDigests(relPath, remoteEntry)
+		}
+
+		verifier.Lock()
+		switch {
+		case !remoteOK:
+			verifier.filesMissing++
+			_, _ = fmt.Fprintf(verifier.w, "missing: %s (%v bytes)\n", relPath, localEntry.size)
+		case !localOK:
+			verifier.filesExtraneous++
+			_, _ = fmt.Fprintf(verifier.w, "extraneous: %s (%v bytes)\n", relPath, remoteEntry.size)
+		case localEntry.size != remoteEntry.size:
+			verifier.filesSizeDiffer++
+			_, _ = fmt.Fprintf(verifier.w, "size differs: %s (local %v bytes, backend %v bytes)\n", relPath, localEntry.size, remoteEntry.size)
+		case err != nil:
+			verifier.filesFailed++
+			_, _ = fmt.Fprintf(verifier.w, "failed: %s: %v\n", relPath, err)
+		case !matched:
+			verifier.filesDigestDiffer++
+			_, _ = fmt.Fprintf(verifier.w, "digest differs: %s\n", relPath)
+		default:
+			verifier.filesMatched++
+			verifier.bytesMatched += localEntry.size
+			if sizeOnly {
+				verifier.filesSizeOnly++
+			}
+		}
+		verifier.Unlock()
+
+		err = nil
+	}
+}
+
+// `compareDigests` returns whether the local file at relPath (of the same size as remoteEntry)
+// matches remoteEntry per its eTag or, if that cannot be checked and options.read, per the
+// MD5 digest of its content read from the backend. If neither applies, sizeOnly is returned
+// true (with matched as the sizes already do).
+func (verifier *verifierStruct) compareDigests(relPath string, remoteEntry *syncEntryStruct) (matched bool, sizeOnly bool, err error) {
+	var (
+		localDigest  string
+		localPath    = filepath.Join(verifier.local.localDir, filepath.FromSlash(relPath))
+		numParts     uint64
+		ok           bool
+		partSize     uint64
+		remoteDigest string
+	)
+
+	if isMD5ETag(remoteEntry.eTag) {
+		localDigest, err = localFileMD5(localPath)
+		matched = (err == nil) && strings.EqualFold(localDigest, remoteEntry.eTag)
+		return
+	}
+
+	numParts, ok = multipartETagParts(remoteEntry.eTag)
+	if ok {
+		for _, partSize = range verifier.partSizeCandidates(remoteEntry.size, numParts) {
+			localDigest, err = localFileMultipartETag(localPath, partSize)
+			if err != nil {
+				return
+			}
+			if strings.EqualFold(localDigest, remoteEntry.eTag) {
+				matched = true
+				return
+			}
+		}
+
+		if verifier.options.partSize != 0 {
+			// The part size was given rather than guessed so a mismatch is conclusive
+
+			return
+		}
+	}
+
+	if !verifier.options.read {
+		matched = true
+		sizeOnly = true
+		return
+	}
+
+	remoteDigest, err = verifier.remoteFileMD5(relPath, remoteEntry)
+	if err != nil {
+		return
+	}
+	localDigest, err = localFileMD5(localPath)
+	if err != nil {
+		return
+	}
+
+	matched = (localDigest == remoteDigest)
+
+	return
+}
+
+// `partSizeCandidates` returns the part sizes (in the order they should be tried) that, for
+// a file of size bytes, would have produced a multipart upload of numParts parts. If
+// options.partSize != 0, only it is a candidate. Otherwise, the part size of the backend's
+// own multipart uploads is followed by verifyPartSizeGuesses.
+func (verifier *verifierStruct) partSizeCandidates(size uint64, numParts uint64) (partSizes []uint64) {
+	var (
+		partSize uint64
+		guesses  []uint64
+	)
+
+	if verifier.options.partSize != 0 {
+		guesses = []uint64{verifier.options.partSize}
+	} else {
+		guesses = append([]uint64{verifier.remote.backend.uploadPartCacheLines * globals.config.cacheLineSize}, verifyPartSizeGuesses...)
+	}
+
+	for _, partSize = range guesses {
+		if (partSize != 0) && (((size + partSize - 1) / partSize) == numParts) && !slices.Contains(partSizes, partSize) {
+			partSizes = append(partSizes, partSize)
+		}
+	}
+
+	return
+}
+
+// `multipartETagParts` returns the number of parts of eTag if it has the form of the eTag of
+// an S3 multipart upload (i.e. the MD5 digest of the concatenated part digests followed by
+// "-<number of parts>").
+func multipartETagParts(eTag string) (numParts uint64, ok bool) {
+	digest, parts, found := strings.Cut(eTag, "-")
+	if !found || !isMD5ETag(digest) {
+		return
+	}
+
+	numParts, err := strconv.ParseUint(parts, 10, 64)
+	ok = (err == nil) && (numParts > 0)
+
+	return
+}
+
+// `localFileMultipartETag` returns the eTag S3 would report for the content of the local file
+// at path had it been uploaded as a multipart upload of parts partSize bytes in size.
+func localFileMultipartETag(path string, partSize uint64) (eTag string, err error) {
+	var (
+		file     *os.File
+		n        int64
+		numParts uint64
+		digests  []byte
+	)
+
+	file, err = os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	for {
+		partHash := md5.New()
+
+		n, err = io.CopyN(partHash, file, int64(partSize))
+		if (n == 0) && errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if (err != nil) && !errors.Is(err, io.EOF) {
+			return
+		}
+
+		digests = partHash.Sum(digests)
+		numParts++
+
+		if err != nil {
+			err = nil
+			break
+		}
+	}
+
+	sum := md5.Sum(digests)
+
+	eTag = hex.EncodeToString(sum[:]) + "-" + strconv.FormatUint(numParts, 10)
+
+	return
+}
+
+// `remoteFileMD5` returns the (hex-encoded) MD5 digest of the content of the file at relPath
+// of verifier.remote read (insisting its eTag remains that of remoteEntry) a cache line at a time.
+func (verifier *verifierStruct) remoteFileMD5(relPath string, remoteEntry *syncEntryStruct) (digest string, err error) {
+	var (
+		cacheLineSize   = globals.config.cacheLineSize
+		offsetCacheLine uint64
+		readFileOutput  *readFileOutputStruct
+		size            uint64
+	)
+
+	hash := md5.New()
+
+	for offsetCacheLine = 0; size < remoteEntry.size; offsetCacheLine++ {
+		readFileOutput, err = verifier.remote.backend.context.readFile(&readFileInputStruct{
+			filePath:        verifier.remote.prefix + relPath,
+			offsetCacheLine: offsetCacheLine,
+			cacheLineSize:   cacheLineSize,
+			ifMatch:         remoteEntry.eTag,
+			bulk:            true,
+		})
+		if err != nil {
+			return
+		}
+		if len(readFileOutput.buf) == 0 {
+			err = fmt.Errorf("truncated at %v bytes (expected %v)", size, remoteEntry.size)
+			return
+		}
+
+		_, _ = hash.Write(readFileOutput.buf)
+
+		size += uint64(len(readFileOutput.buf))
+	}
+
+	digest = hex.EncodeToString(hash.Sum(nil))
+
+	return
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	var (
+		err      error
+		localDir = t.TempDir()
+		output   bytes.Buffer
+		ram      *backendStruct
+	)
+
+	initGlobals(testOsArgs(testGlobals.testConfigFilePathMap[".yaml"]))
+
+	err = os.WriteFile(globals.configFilePath, []byte(`
+msfs_version: 1
+cache_line_size: 4
+backends: [
+  {
+    dir_name: ram,
+    bucket_container_name: ignored,
+    backend_type: RAM,
+    readonly: false,
+    upload_part_cache_lines: 1,
+  },
+]
+`), 0o600)
+	if err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+
+	err = checkConfigFile()
+	if err != nil {
+		t.Fatalf("checkConfigFile() failed: %v", err)
+	}
+
+	setupInspectBackends()
+
+	ram = globals.backendsToMount["ram"]
+
+	writeLocal := func(relPath string, content string) {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(localDir, relPath)), 0o755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(localDir, relPath), []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatalf("writing local \"%s\" failed: %v", relPath, err)
+		}
+	}
+
+	writeBackend := func(filePath string, content string) {
+		_, err := ram.context.writeFile(&writeFileInputStruct{filePath: filePath, buf: []byte(content)})
+		if err != nil {
+			t.Fatalf("writeFile(\"%s\") failed: %v", filePath, err)
+		}
+	}
+
+	writeLocal("same", "0123456789")
+	writeLocal("dir1/changed", "abc")
+	writeLocal("dir1/grown", "abc")
+	writeLocal("missing", "xyz")
+
+	writeBackend("dst/same", "0123456789")
+	writeBackend("dst/dir1/changed", "abd")
+	writeBackend("dst/dir1/grown", "abcd")
+	writeBackend("dst/extraneous", "12")
+	writeBackend("elsewhere", "ignored")
+
+	// Lacking eTags (as does the RAM backend), files of the same size match by size alone
+
+	err = verifyFiles(&output, localDir, "msfs://ram/dst", &verifyOptionsStruct{parallel: 2})
+	if err == nil {
+		t.Fatalf("verifyFiles() unexpectedly succeeded:\n%s", output.String())
+	}
+	for _, expected := range []string{
+		"extraneous: extraneous (2 bytes)\n",
+		"missing: missing (3 bytes)\n",
+		"size differs: dir1/grown (local 3 bytes, backend 4 bytes)\n",
+		"verified 5 files: 2 matched (13 bytes, 2 by size only), 1 missing, 1 extraneous, 1 differ in size, 0 differ in digest, 0 failed\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Fatalf("verifyFiles() output lacks %q:\n%s", expected, output.String())
+		}
+	}
+
+	// Reading the files reveals those whose content differs
+
+	output.Reset()
+
+	err = verifyFiles(&output, localDir, "msfs://ram/dst", &verifyOptionsStruct{parallel: 2, read: true})
+	if (err == nil) || !strings.Contains(output.String(), "digest differs: dir1/changed\n") || !strings.Contains(output.String(), "1 matched (10 bytes, 0 by size only)") {
+		t.Fatalf("verifyFiles(read) unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	// Once the drift is resolved, verification succeeds
+
+	writeLocal("dir1/changed", "abd")
+	writeLocal("dir1/grown", "abcd")
+	writeLocal("extraneous", "12")
+	writeBackend("dst/missing", "xyz")
+
+	output.Reset()
+
+	err = verifyFiles(&output, localDir, "msfs://ram/dst/", &verifyOptionsStruct{parallel: 1, read: true})
+	if (err != nil) || (output.String() != "verified 5 files: 5 matched (22 bytes, 0 by size only), 0 missing, 0 extraneous, 0 differ in size, 0 differ in digest, 0 failed\n") {
+		t.Fatalf("verifyFiles() after resolving drift unexpected (err: %v):\n%s", err, output.String())
+	}
+
+	// Both endpoints must be given (local first)
+
+	for _, args := range [][2]string{{"msfs://ram/dst", localDir}, {localDir, localDir}, {localDir, "msfs://missing"}} {
+		err = verifyFiles(&output, args[0], args[1], &verifyOptionsStruct{parallel: 1})
+		if err == nil {
+			t.Fatalf("verifyFiles(\"%s\", \"%s\") unexpectedly succeeded", args[0], args[1])
+		}
+	}
+
+	// eTags (single part or multipart) are checked against the local file's content
+
+	md5Hex := func(buf []byte) string {
+		sum := md5.Sum(buf)
+		return hex.EncodeToString(sum[:])
+	}
+
+	partDigests := make([]byte, 0, 3*md5.Size)
+	for _, part := range []string{"0123", "4567", "89"} {
+		sum := md5.Sum([]byte(part))
+		partDigests = append(partDigests, sum[:]...)
+	}
+	multipartETag := md5Hex(partDigests) + "-3"
+
+	eTag, err := localFileMultipartETag(filepath.Join(localDir, "same"), 4)
+	if (err != nil) || (eTag != multipartETag) {
+		t.Fatalf("localFileMultipartETag() returned \"%s\" (err: %v), expected \"%s\"", eTag, err, multipartETag)
+	}
+
+	numParts, ok := multipartETagParts(multipartETag)
+	if !ok || (numParts != 3) {
+		t.Fatalf("multipartETagParts(\"%s\") returned %v, %v", multipartETag, numParts, ok)
+	}
+	for _, badETag := range []string{"", md5Hex(nil), md5Hex(nil) + "-", md5Hex(nil) + "-0", "xyz-3"} {
+		_, ok = multipartETagParts(badETag)
+		if ok {
+			t.Fatalf("multipartETagParts(\"%s\") unexpectedly succeeded", badETag)
+		}
+	}
+
+	verifier := &verifierStruct{
+		local:   &syncEndpointStruct{localDir: localDir},
+		remote:  &syncEndpointStruct{backend: ram, prefix: "dst/"},
+		options: &verifyOptionsStruct{},
+	}
+
+	for _, testCase := range []struct {
+		eTag     string
+		partSize uint64
+		matched  bool
+		sizeOnly bool
+	}{
+		{md5Hex([]byte("0123456789")), 0, true, false},
+		{md5Hex([]byte("0123456788")), 0, false, false},
+		{multipartETag, 0, true, false}, // Guessed from the backend's upload_part_cache_lines * cache_line_size
+		{multipartETag, 4, true, false},
+		{md5Hex(partDigests[md5.Size:]) + "-3", 4, false, false},
+		{md5Hex(partDigests[md5.Size:]) + "-3", 0, true, true}, // No guess matched
+		{md5Hex(partDigests) + "-2", 5, false, false},          // Given the part size, a mismatch is conclusive
+		{"opaque", 0, true, true},
+	} {
+		verifier.options.partSize = testCase.partSize
+
+		matched, sizeOnly, err := verifier.compareDigests("same", &syncEntryStruct{size: 10, eTag: testCase.eTag})
+		if (err != nil) || (matched != testCase.matched) || (sizeOnly != testCase.sizeOnly) {
+			t.Fatalf("compareDigests(eTag: \"%s\", partSize: %v) returned matched: %v sizeOnly: %v (err: %v)", testCase.eTag, testCase.partSize, matched, sizeOnly, err)
+		}
+	}
+}
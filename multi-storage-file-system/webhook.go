@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	webhookEventCircuitOpen      = "circuit_open"      // A backend is down after health_check_failure_threshold consecutive failed probes
+	webhookEventCredentialExpiry = "credential_expiry" // A backend's session_token expired with no means to refresh it
+	webhookEventCacheCorruption  = "cache_corruption"  // A cache line was found to be inconsistent with the inode table
+)
+
+// `webhookEvents` enumerates the events that may be listed in webhook_events (and their default).
+var webhookEvents = []string{webhookEventCircuitOpen, webhookEventCredentialExpiry, webhookEventCacheCorruption}
+
+// `webhooksStruct` POSTs a JSON-encoded webhookPayloadStruct to each of globals.config.webhookURLs
+// upon each of globals.config.webhookEvents (suppressing repeats of the same event for the same
+// backend within globals.config.webhookRepeatInterval).
+type webhooksStruct struct {
+	sync.Mutex                      // Protects lastSent
+	events     map[string]struct{}  // Key: each of globals.config.webhookEvents
+	lastSent   map[string]time.Time // Key: event + "/" + backend; Value: time of the most recent notify() not suppressed
+	hostname   string               // Reported in each webhookPayloadStruct
+	httpClient *http.Client         //
+	waitGroup  sync.WaitGroup       // Tracks in-flight deliveries
+}
+
+// `webhookPayloadStruct` is the JSON-encoded body of each webhook request.
+type webhookPayloadStruct struct {
+	Event     string `json:"event"`             // One of webhookEvents
+	Time      string `json:"time"`              // RFC3339Nano
+	Mountname string `json:"mountname"`         //
+	Hostname  string `json:"hostname"`          //
+	Backend   string `json:"backend,omitempty"` // backend.dirName (if the event pertains to a backend)
+	Message   string `json:"message"`           // Matches what was logged
+}
+
+// `newWebhooks` returns a webhooksStruct if any webhook_urls were specified.
+// If not, a nil *webhooksStruct is returned and notify() is a no-op.
+func newWebhooks() (webhooks *webhooksStruct) {
+	var (
+		err error
+	)
+
+	if len(globals.config.webhookURLs) == 0 {
+		return
+	}
+
+	webhooks = &webhooksStruct{
+		events:     make(map[string]struct{}, len(globals.config.webhookEvents)),
+		lastSent:   make(map[string]time.Time),
+		httpClient: &http.Client{Timeout: globals.config.webhookTimeout},
+	}
+
+	for _, event := range globals.config.webhookEvents {
+		webhooks.events[event] = struct{}{}
+	}
+
+	webhooks.hostname, err = os.Hostname()
+	if err != nil {
+		webhooks.hostname = ""
+	}
+
+	return
+}
+
+// `notify` asynchronously delivers event (pertaining to backendName if != "") along with
+// message to each webhook_url unless event is not among webhook_events or was already
+// delivered for backendName within webhook_repeat_interval. Any lock may be held by the caller.
+func (webhooks *webhooksStruct) notify(event string, backendName string, message string) {
+	var (
+		body     []byte
+		err      error
+		key      = event + "/" + backendName
+		lastSent time.Time
+		ok       bool
+		timeNow  = time.Now()
+	)
+
+	if webhooks == nil {
+		return
+	}
+
+	_, ok = webhooks.events[event]
+	if !ok {
+		return
+	}
+
+	webhooks.Lock()
+	lastSent, ok = webhooks.lastSent[key]
+	if ok && (globals.config.webhookRepeatInterval != 0) && (timeNow.Sub(lastSent) < globals.config.webhookRepeatInterval) {
+		webhooks.Unlock()
+		return
+	}
+	webhooks.lastSent[key] = timeNow
+	webhooks.Unlock()
+
+	body, err = json.Marshal(&webhookPayloadStruct{
+		Event:     event,
+		Time:      timeNow.UTC().Format(time.RFC3339Nano),
+		Mountname: globals.config.mountName,
+		Hostname:  webhooks.hostname,
+		Backend:   backendName,
+		Message:   message,
+	})
+	if err != nil {
+		globals.logger.Printf("[WARN] [webhook] unable to encode %s event: %v", event, err)
+		return
+	}
+
+	for _, url := range globals.config.webhookURLs {
+		webhooks.waitGroup.Go(func() {
+			webhooks.deliver(url, event, body)
+		})
+	}
+}
+
+// `deliver` POSTs body (describing event) to url, logging any failure.
+func (webhooks *webhooksStruct) deliver(url string, event string, body []byte) {
+	var (
+		err          error
+		httpRequest  *http.Request
+		httpResponse *http.Response
+	)
+
+	httpRequest, err = http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		globals.logger.Printf("[WARN] [webhook] unable to deliver %s event to \"%s\": %v", event, url, err)
+		return
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err = webhooks.httpClient.Do(httpRequest)
+	if err != nil {
+		globals.logger.Printf("[WARN] [webhook] unable to deliver %s event to \"%s\": %v", event, url, err)
+		return
+	}
+
+	_ = httpResponse.Body.Close()
+
+	if (httpResponse.StatusCode < 200) || (httpResponse.StatusCode > 299) {
+		globals.logger.Printf("[WARN] [webhook] delivering %s event to \"%s\" returned %s", event, url, httpResponse.Status)
+	}
+}
+
+// `wait` awaits the completion of all in-flight deliveries.
+func (webhooks *webhooksStruct) wait() {
+	if webhooks == nil {
+		return
+	}
+
+	webhooks.waitGroup.Wait()
+}
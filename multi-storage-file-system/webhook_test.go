@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhooks(t *testing.T) {
+	var (
+		payloads      []*webhookPayloadStruct
+		payloadsMutex sync.Mutex
+		webhookServer *httptest.Server
+	)
+
+	webhookServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			payload *webhookPayloadStruct
+		)
+
+		if (r.Method != http.MethodPost) || (r.Header.Get("Content-Type") != "application/json") {
+			t.Errorf("webhook request unexpectedly used %s with Content-Type \"%s\"", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			t.Errorf("webhook request body not decodable: %v", err)
+		}
+
+		payloadsMutex.Lock()
+		payloads = append(payloads, payload)
+		payloadsMutex.Unlock()
+	}))
+	defer webhookServer.Close()
+
+	fissionTestUp(t)
+	defer fissionTestDown(t)
+
+	if globals.webhooks != nil {
+		t.Fatalf("globals.webhooks should be nil absent webhook_urls")
+	}
+
+	globals.webhooks.notify(webhookEventCircuitOpen, "ram", "ignored") // Must be a no-op
+
+	globals.config.webhookURLs = []string{webhookServer.URL}
+	globals.config.webhookEvents = []string{webhookEventCircuitOpen, webhookEventCredentialExpiry}
+	globals.config.webhookTimeout = 5 * time.Second
+	globals.config.webhookRepeatInterval = time.Hour
+
+	globals.webhooks = newWebhooks()
+	defer func() {
+		globals.webhooks = nil
+	}()
+
+	// A repeat for the same backend is suppressed, one for another backend is not, and an excluded event is never delivered
+
+	globals.config.backends["ram"].healthCheckFailureThreshold = 1
+	(&healthStruct{backend: globals.config.backends["ram"]}).recordProbe(errors.New("probe failed"))
+
+	globals.webhooks.notify(webhookEventCircuitOpen, "ram", "repeated")
+	globals.webhooks.notify(webhookEventCircuitOpen, "other", "other backend")
+	globals.webhooks.notify(webhookEventCacheCorruption, "", "excluded")
+
+	globals.webhooks.wait()
+
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 webhook deliveries but got %v", len(payloads))
+	}
+
+	for _, payload := range payloads {
+		if (payload.Event != webhookEventCircuitOpen) || (payload.Mountname != globals.config.mountName) || (payload.Time == "") {
+			t.Fatalf("unexpected webhook payload: %+v", payload)
+		}
+		switch payload.Backend {
+		case "ram":
+			if payload.Message != "down after 1 consecutive failed probes: probe failed" {
+				t.Fatalf("unexpected webhook payload.Message: \"%s\"", payload.Message)
+			}
+		case "other":
+		default:
+			t.Fatalf("unexpected webhook payload.Backend: \"%s\"", payload.Backend)
+		}
+	}
+
+	// With webhook_repeat_interval == 0, repeats are delivered
+
+	globals.config.webhookRepeatInterval = 0
+
+	globals.webhooks.notify(webhookEventCredentialExpiry, "ram", "first")
+	globals.webhooks.notify(webhookEventCredentialExpiry, "ram", "second")
+
+	globals.webhooks.wait()
+
+	if len(payloads) != 4 {
+		t.Fatalf("expected 4 webhook deliveries but got %v", len(payloads))
+	}
+}